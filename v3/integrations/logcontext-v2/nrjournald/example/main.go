@@ -0,0 +1,46 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// This example is run as a systemd service with the environment variable
+// NEW_RELIC_LICENSE_KEY set to your license key.
+package main
+
+import (
+	"log"
+	"os"
+	"time"
+
+	"github.com/newrelic/go-agent/v3/integrations/logcontext-v2/nrjournald"
+	"github.com/newrelic/go-agent/v3/newrelic"
+)
+
+func main() {
+	app, err := newrelic.NewApplication(
+		newrelic.ConfigAppName("nrjournald example"),
+		newrelic.ConfigLicense(os.Getenv("NEW_RELIC_LICENSE_KEY")),
+		newrelic.ConfigAppLogForwardingEnabled(true),
+	)
+	if err != nil {
+		panic(err)
+	}
+	app.WaitForConnection(5 * time.Second)
+
+	w, err := nrjournald.New(app)
+	if err != nil {
+		panic(err)
+	}
+	defer w.Close()
+
+	logger := log.New(w, "", 0)
+	logger.Print("application started")
+
+	txn := app.StartTransaction("work")
+	txnLogger := log.New(w.WithTransaction(txn), "", 0)
+	txnLogger.Print("<4>cache miss")
+	w.WithTransaction(txn).Send(nrjournald.PriorityFromLevel("error"), "work failed", map[string]string{
+		"order_id": "42",
+	})
+	txn.End()
+
+	app.Shutdown(10 * time.Second)
+}
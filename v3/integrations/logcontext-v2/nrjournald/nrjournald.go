@@ -0,0 +1,263 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package nrjournald writes logs to the systemd journal, and captures them
+// for New Relic Logs in Context.
+//
+// Each log is sent to journald using its native protocol, with the priority
+// of the log, and is recorded as a log event of the application or of the
+// transaction of the Writer.  The message is decorated with the linking
+// metadata when local decorating is enabled, and the trace and the span of
+// the transaction are added to the journal entry as the NR_TRACE_ID and the
+// NR_SPAN_ID fields, so that they can be queried using journalctl:
+//
+//	journalctl NR_TRACE_ID=4bf92f3577b34da6a3ce929d0e0e4736
+//
+// A Writer is an io.Writer, and so can be the output of the log package and
+// of most logging frameworks:
+//
+//	w, err := nrjournald.New(app)
+//	if err != nil {
+//		panic(err)
+//	}
+//	logger := log.New(w, "", 0)
+//	logger.Print("<3>payment failed")
+package nrjournald
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/newrelic/go-agent/v3/internal"
+	"github.com/newrelic/go-agent/v3/newrelic"
+)
+
+func init() { internal.TrackUsage("integration", "logcontext-v2", "nrjournald") }
+
+// DefaultSocket is the socket on which journald receives entries.
+const DefaultSocket = "/run/systemd/journal/socket"
+
+// Priority is the syslog priority of a journal entry.
+type Priority int
+
+// The priorities, from the most to the least severe.
+const (
+	PriorityEmergency Priority = iota
+	PriorityAlert
+	PriorityCritical
+	PriorityError
+	PriorityWarning
+	PriorityNotice
+	PriorityInfo
+	PriorityDebug
+)
+
+var severities = [...]string{"EMERGENCY", "ALERT", "CRITICAL", "ERROR", "WARNING", "NOTICE", "INFO", "DEBUG"}
+
+// Severity returns the severity of the log events of the priority, such as
+// "ERROR".
+func (p Priority) Severity() string {
+	if p < PriorityEmergency || p > PriorityDebug {
+		return "UNKNOWN"
+	}
+	return severities[p]
+}
+
+// PriorityFromLevel returns the priority of a log level name, such as "warn"
+// or "ERROR".  The fatal and panic levels of logging frameworks are mapped to
+// PriorityCritical, and unknown levels to PriorityInfo.
+func PriorityFromLevel(level string) Priority {
+	switch strings.ToLower(strings.TrimSpace(level)) {
+	case "emerg", "emergency":
+		return PriorityEmergency
+	case "alert":
+		return PriorityAlert
+	case "crit", "critical", "fatal", "panic", "dpanic":
+		return PriorityCritical
+	case "err", "error":
+		return PriorityError
+	case "warn", "warning":
+		return PriorityWarning
+	case "notice":
+		return PriorityNotice
+	case "debug", "trace":
+		return PriorityDebug
+	default:
+		return PriorityInfo
+	}
+}
+
+// Writer sends logs to journald.  It is safe for concurrent use.
+type Writer struct {
+	conn       *net.UnixConn
+	socket     string
+	app        *newrelic.Application
+	txn        *newrelic.Transaction
+	identifier string
+	priority   Priority
+}
+
+// Option configures a Writer.
+type Option func(*Writer)
+
+// WithIdentifier sets the SYSLOG_IDENTIFIER field of the entries.  It is the
+// name of the executable by default.
+func WithIdentifier(identifier string) Option {
+	return func(w *Writer) { w.identifier = identifier }
+}
+
+// WithPriority sets the priority of the logs written using Write which do not
+// start with a priority prefix.  It is PriorityInfo by default.
+func WithPriority(p Priority) Option {
+	return func(w *Writer) { w.priority = p }
+}
+
+// WithSocket sets the socket on which journald receives entries.  It is
+// DefaultSocket by default.
+func WithSocket(path string) Option {
+	return func(w *Writer) { w.socket = path }
+}
+
+// New connects to journald.  app must be a valid, non nil New Relic
+// Application.
+func New(app *newrelic.Application, opts ...Option) (*Writer, error) {
+	w := &Writer{
+		socket:     DefaultSocket,
+		app:        app,
+		identifier: filepath.Base(os.Args[0]),
+		priority:   PriorityInfo,
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: w.socket, Net: "unixgram"})
+	if err != nil {
+		return nil, err
+	}
+	w.conn = conn
+	return w, nil
+}
+
+// WithTransaction returns a Writer sharing the connection of w which records
+// the logs in txn.
+func (w *Writer) WithTransaction(txn *newrelic.Transaction) *Writer {
+	cp := *w
+	cp.txn = txn
+	return &cp
+}
+
+// WithContext returns a Writer sharing the connection of w which records the
+// logs in the transaction of ctx.
+func (w *Writer) WithContext(ctx context.Context) *Writer {
+	return w.WithTransaction(newrelic.FromContext(ctx))
+}
+
+// Close closes the connection to journald, which is shared by the Writers
+// returned by WithTransaction and WithContext.
+func (w *Writer) Close() error {
+	return w.conn.Close()
+}
+
+// Write sends a log to journald.  A log starting with a priority prefix, as
+// in "<3>payment failed", is sent with that priority, like the logs of
+// services written to their standard output.
+func (w *Writer) Write(p []byte) (int, error) {
+	msg := string(p)
+	priority := w.priority
+	if len(msg) >= 3 && msg[0] == '<' && msg[2] == '>' && msg[1] >= '0' && msg[1] <= '7' {
+		priority = Priority(msg[1] - '0')
+		msg = msg[3:]
+	}
+	if err := w.Send(priority, msg, nil); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Send sends a log to journald with the priority and the additional fields
+// given.  Field names are converted to the journal format, which only allows
+// uppercase letters, digits, and underscores.
+func (w *Writer) Send(p Priority, msg string, fields map[string]string) error {
+	msg = strings.TrimRight(msg, "\n")
+	data := newrelic.LogData{Message: msg, Severity: p.Severity()}
+
+	buf := bytes.NewBufferString(msg)
+	var enricher newrelic.EnricherOption
+	if w.txn != nil {
+		w.txn.RecordLog(data)
+		enricher = newrelic.FromTxn(w.txn)
+	} else {
+		w.app.RecordLog(data)
+		enricher = newrelic.FromApp(w.app)
+	}
+	// Logs are sent even if they cannot be decorated.
+	newrelic.EnrichLog(buf, enricher)
+
+	entry := &bytes.Buffer{}
+	appendField(entry, "MESSAGE", buf.String())
+	appendField(entry, "PRIORITY", strconv.Itoa(int(p)))
+	if w.identifier != "" {
+		appendField(entry, "SYSLOG_IDENTIFIER", w.identifier)
+	}
+	if w.txn != nil {
+		md := w.txn.GetLinkingMetadata()
+		if md.TraceID != "" {
+			appendField(entry, "NR_TRACE_ID", md.TraceID)
+		}
+		if md.SpanID != "" {
+			appendField(entry, "NR_SPAN_ID", md.SpanID)
+		}
+		if md.EntityGUID != "" {
+			appendField(entry, "NR_ENTITY_GUID", md.EntityGUID)
+		}
+	}
+	for name, value := range fields {
+		if name = fieldName(name); name != "" {
+			appendField(entry, name, value)
+		}
+	}
+
+	_, err := w.conn.Write(entry.Bytes())
+	return err
+}
+
+// appendField appends a field to an entry using the native protocol of
+// journald.  Values containing newlines are written with their length.
+func appendField(entry *bytes.Buffer, name, value string) {
+	entry.WriteString(name)
+	if !strings.Contains(value, "\n") {
+		entry.WriteByte('=')
+		entry.WriteString(value)
+		entry.WriteByte('\n')
+		return
+	}
+	entry.WriteByte('\n')
+	var size [8]byte
+	binary.LittleEndian.PutUint64(size[:], uint64(len(value)))
+	entry.Write(size[:])
+	entry.WriteString(value)
+	entry.WriteByte('\n')
+}
+
+// fieldName converts a name to a journal field name, or returns "" if it
+// cannot be converted.  Names starting with an underscore are reserved to
+// journald.
+func fieldName(name string) string {
+	out := []byte(strings.ToUpper(name))
+	for i, c := range out {
+		if !(c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_') {
+			out[i] = '_'
+		}
+	}
+	s := strings.TrimLeft(string(out), "_")
+	if s == "" || s[0] >= '0' && s[0] <= '9' || len(s) > 64 {
+		return ""
+	}
+	return s
+}
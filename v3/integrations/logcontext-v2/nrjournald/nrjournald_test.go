@@ -0,0 +1,148 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package nrjournald
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/newrelic/go-agent/v3/internal"
+	"github.com/newrelic/go-agent/v3/internal/integrationsupport"
+	"github.com/newrelic/go-agent/v3/newrelic"
+)
+
+// journal is a socket receiving entries like journald.
+type journal struct {
+	conn *net.UnixConn
+}
+
+func newJournal(t *testing.T) (*journal, string) {
+	path := filepath.Join(t.TempDir(), "journal.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Skip("unix datagram sockets are not supported:", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return &journal{conn: conn}, path
+}
+
+// read reads an entry, decoding the fields written with their length.
+func (j *journal) read(t *testing.T) map[string]string {
+	buf := make([]byte, 64*1024)
+	n, err := j.conn.Read(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields := make(map[string]string)
+	data := buf[:n]
+	for len(data) > 0 {
+		line := bytes.IndexByte(data, '\n')
+		if eq := bytes.IndexByte(data[:line], '='); eq >= 0 {
+			fields[string(data[:eq])] = string(data[eq+1 : line])
+			data = data[line+1:]
+			continue
+		}
+		name := string(data[:line])
+		size := binary.LittleEndian.Uint64(data[line+1 : line+9])
+		fields[name] = string(data[line+9 : line+9+int(size)])
+		data = data[line+9+int(size)+1:]
+	}
+	return fields
+}
+
+func TestWrite(t *testing.T) {
+	j, path := newJournal(t)
+	app := integrationsupport.NewTestApp(integrationsupport.SampleEverythingReplyFn,
+		newrelic.ConfigAppLogForwardingEnabled(true),
+		newrelic.ConfigAppLogDecoratingEnabled(false),
+	)
+	w, err := New(app.Application, WithSocket(path), WithIdentifier("checkout"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	if n, err := w.Write([]byte("<3>payment failed\n")); err != nil || n != 18 {
+		t.Fatal(n, err)
+	}
+	fields := j.read(t)
+	if fields["MESSAGE"] != "payment failed" || fields["PRIORITY"] != "3" || fields["SYSLOG_IDENTIFIER"] != "checkout" {
+		t.Error("incorrect entry:", fields)
+	}
+
+	if err := w.Send(PriorityWarning, "retrying\nattempt 2", map[string]string{"order.id": "42", "_hidden": "x", "1st": "y"}); err != nil {
+		t.Fatal(err)
+	}
+	fields = j.read(t)
+	if fields["MESSAGE"] != "retrying\nattempt 2" || fields["PRIORITY"] != "4" || fields["ORDER_ID"] != "42" || fields["HIDDEN"] != "x" || len(fields) != 5 {
+		t.Error("incorrect entry:", fields)
+	}
+
+	app.ExpectLogEvents(t, []internal.WantLog{
+		{Severity: "ERROR", Message: "payment failed", Timestamp: internal.MatchAnyUnixMilli},
+		{Severity: "WARNING", Message: "retrying\nattempt 2", Timestamp: internal.MatchAnyUnixMilli},
+	})
+}
+
+func TestWriteWithTransaction(t *testing.T) {
+	j, path := newJournal(t)
+	app := integrationsupport.NewTestApp(integrationsupport.SampleEverythingReplyFn,
+		newrelic.ConfigAppLogForwardingEnabled(true),
+		newrelic.ConfigAppLogDecoratingEnabled(true),
+	)
+	w, err := New(app.Application, WithSocket(path), WithPriority(PriorityNotice))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	txn := app.StartTransaction("checkout")
+	md := txn.GetLinkingMetadata()
+	if _, err := w.WithTransaction(txn).Write([]byte("order placed")); err != nil {
+		t.Fatal(err)
+	}
+	txn.End()
+
+	fields := j.read(t)
+	if fields["PRIORITY"] != "5" || fields["NR_TRACE_ID"] != md.TraceID || fields["NR_SPAN_ID"] != md.SpanID {
+		t.Error("incorrect entry:", fields)
+	}
+	if !strings.HasPrefix(fields["MESSAGE"], "order placed NR-LINKING|") {
+		t.Error("message not decorated:", fields["MESSAGE"])
+	}
+	app.ExpectLogEvents(t, []internal.WantLog{{
+		Severity:  "NOTICE",
+		Message:   "order placed",
+		SpanID:    md.SpanID,
+		TraceID:   md.TraceID,
+		Timestamp: internal.MatchAnyUnixMilli,
+	}})
+}
+
+func TestPriorityFromLevel(t *testing.T) {
+	for level, want := range map[string]Priority{
+		"EMERG":   PriorityEmergency,
+		"alert":   PriorityAlert,
+		"fatal":   PriorityCritical,
+		"panic":   PriorityCritical,
+		"ERROR":   PriorityError,
+		"warn":    PriorityWarning,
+		"notice":  PriorityNotice,
+		"info":    PriorityInfo,
+		"verbose": PriorityInfo,
+		" debug ": PriorityDebug,
+		"trace":   PriorityDebug,
+	} {
+		if got := PriorityFromLevel(level); got != want {
+			t.Errorf("incorrect priority for %q: %d, want %d", level, got, want)
+		}
+	}
+	if s := Priority(9).Severity(); s != "UNKNOWN" {
+		t.Error("incorrect severity:", s)
+	}
+}
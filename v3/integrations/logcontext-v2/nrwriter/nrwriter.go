@@ -16,10 +16,12 @@ import (
 
 // LogWriter is an io.Writer that captures log data for use with New Relic Logs in Context
 type LogWriter struct {
-	debug bool
-	out   io.Writer
-	app   *newrelic.Application
-	txn   *newrelic.Transaction
+	debug     bool
+	json      bool
+	multiline bool
+	out       io.Writer
+	app       *newrelic.Application
+	txn       *newrelic.Transaction
 }
 
 // New creates a new NewRelicWriter Object
@@ -38,13 +40,29 @@ func (b *LogWriter) DebugLogging(enabled bool) {
 	b.debug = enabled
 }
 
+// JSONDecoration enables or disables adding the linking metadata of logs which are JSON objects
+// as JSON fields, which keeps the logs valid JSON. By default, the linking metadata is appended
+// to the end of every log.
+func (b *LogWriter) JSONDecoration(enabled bool) {
+	b.json = enabled
+}
+
+// MultilineDecoration enables or disables appending the linking metadata to each line of
+// multi-line logs, such as logs containing stack traces. By default, the linking metadata
+// is only appended to the last line.
+func (b *LogWriter) MultilineDecoration(enabled bool) {
+	b.multiline = enabled
+}
+
 // WithTransaction duplicates the current NewRelicWriter and sets the transaction to txn
 func (b *LogWriter) WithTransaction(txn *newrelic.Transaction) LogWriter {
 	return LogWriter{
-		out:   b.out,
-		app:   b.app,
-		debug: b.debug,
-		txn:   txn,
+		out:       b.out,
+		app:       b.app,
+		debug:     b.debug,
+		json:      b.json,
+		multiline: b.multiline,
+		txn:       txn,
 	}
 }
 
@@ -52,10 +70,12 @@ func (b *LogWriter) WithTransaction(txn *newrelic.Transaction) LogWriter {
 func (b *LogWriter) WithContext(ctx context.Context) LogWriter {
 	txn := newrelic.FromContext(ctx)
 	return LogWriter{
-		out:   b.out,
-		app:   b.app,
-		debug: b.debug,
-		txn:   txn,
+		out:       b.out,
+		app:       b.app,
+		debug:     b.debug,
+		json:      b.json,
+		multiline: b.multiline,
+		txn:       txn,
 	}
 }
 
@@ -66,14 +86,21 @@ func (b *LogWriter) EnrichLog(data newrelic.LogData, p []byte) []byte {
 	logLine := bytes.TrimRight(p, "\n")
 	buf := bytes.NewBuffer(logLine)
 
-	var enrichErr error
+	opts := make([]newrelic.EnricherOption, 1, 3)
 	if b.txn != nil {
 		b.txn.RecordLog(data)
-		enrichErr = newrelic.EnrichLog(buf, newrelic.FromTxn(b.txn))
+		opts[0] = newrelic.FromTxn(b.txn)
 	} else {
 		b.app.RecordLog(data)
-		enrichErr = newrelic.EnrichLog(buf, newrelic.FromApp(b.app))
+		opts[0] = newrelic.FromApp(b.app)
+	}
+	if b.json {
+		opts = append(opts, newrelic.WithJSONDecoration())
+	}
+	if b.multiline {
+		opts = append(opts, newrelic.WithMultilineDecoration())
 	}
+	enrichErr := newrelic.EnrichLog(buf, opts...)
 
 	if b.debug && enrichErr != nil {
 		buf.WriteString("\n")
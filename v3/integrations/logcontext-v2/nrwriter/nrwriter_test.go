@@ -3,7 +3,9 @@ package nrwriter
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"io"
+	"reflect"
 	"strings"
 	"testing"
 
@@ -173,3 +175,53 @@ func TestNilContextLog(t *testing.T) {
 		EntityName: integrationsupport.SampleAppName,
 	})
 }
+
+func TestJSONDecoration(t *testing.T) {
+	app := integrationsupport.NewTestApp(integrationsupport.SampleEverythingReplyFn, newrelic.ConfigAppLogDecoratingEnabled(true))
+	buf := bytes.NewBuffer([]byte{})
+	a := New(buf, app.Application)
+	a.JSONDecoration(true)
+
+	txn := app.StartTransaction("test transaction")
+	b := a.WithTransaction(txn)
+
+	b.Write(b.EnrichLog(newrelic.LogData{}, []byte("{\"message\":\"hello\\nworld\"}\n")))
+	var fields map[string]string
+	if err := json.Unmarshal(buf.Bytes(), &fields); err != nil {
+		t.Fatalf("decorated log is not valid JSON: %v: %s", err, buf.String())
+	}
+	md := txn.GetLinkingMetadata()
+	expect := map[string]string{
+		"message":     "hello\nworld",
+		"entity.guid": integrationsupport.TestEntityGUID,
+		"entity.name": integrationsupport.SampleAppName,
+		"hostname":    host,
+		"trace.id":    md.TraceID,
+		"span.id":     md.SpanID,
+	}
+	if !reflect.DeepEqual(fields, expect) {
+		t.Errorf("incorrect fields: expect %v, actual %v", expect, fields)
+	}
+	txn.End()
+}
+
+func TestMultilineDecoration(t *testing.T) {
+	app := integrationsupport.NewTestApp(integrationsupport.SampleEverythingReplyFn, newrelic.ConfigAppLogDecoratingEnabled(true))
+	buf := bytes.NewBuffer([]byte{})
+	a := New(buf, app.Application)
+	a.MultilineDecoration(true)
+
+	b := a.WithContext(context.Background())
+	b.Write(b.EnrichLog(newrelic.LogData{}, []byte("panic: oops\n\tat main.go:12\n")))
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("incorrect number of lines: %q", buf.String())
+	}
+	for _, line := range lines {
+		logcontext.ValidateDecoratedOutput(t, bytes.NewBufferString(line), &logcontext.DecorationExpect{
+			EntityGUID: integrationsupport.TestEntityGUID,
+			Hostname:   host,
+			EntityName: integrationsupport.SampleAppName,
+		})
+	}
+}
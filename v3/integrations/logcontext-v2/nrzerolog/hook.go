@@ -1,7 +1,10 @@
 package nrzerolog
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"reflect"
 
 	"github.com/newrelic/go-agent/v3/internal"
 	"github.com/newrelic/go-agent/v3/newrelic"
@@ -10,12 +13,26 @@ import (
 
 func init() { internal.TrackUsage("integration", "logcontext-v2", "zerolog") }
 
+// NewRelicHook is a zerolog hook which forwards logs to New Relic.  The
+// fields of the log, including the fields of the logger context, are
+// forwarded as log attributes.
+//
+// Logs which are not written by the logger because of its level or its
+// sampler are not forwarded either.  Use Sampler to forward a sample of the
+// logs which are written.
 type NewRelicHook struct {
 	App     *newrelic.Application
 	Context context.Context
+	// Sampler, if set, samples the logs forwarded to New Relic.  It does
+	// not affect the logs written by the logger.
+	Sampler zerolog.Sampler
 }
 
 func (h NewRelicHook) Run(e *zerolog.Event, level zerolog.Level, msg string) {
+	if h.Sampler != nil && !h.Sampler.Sample(level) {
+		return
+	}
+
 	var txn *newrelic.Transaction
 	if h.Context != nil {
 		txn = newrelic.FromContext(h.Context)
@@ -27,8 +44,9 @@ func (h NewRelicHook) Run(e *zerolog.Event, level zerolog.Level, msg string) {
 	}
 
 	data := newrelic.LogData{
-		Severity: logLevel,
-		Message:  msg,
+		Severity:   logLevel,
+		Message:    msg,
+		Attributes: eventAttributes(e),
 	}
 
 	if txn != nil {
@@ -37,3 +55,59 @@ func (h NewRelicHook) Run(e *zerolog.Event, level zerolog.Level, msg string) {
 		h.App.RecordLog(data)
 	}
 }
+
+// eventBuffer returns the fields encoded into the event so far.  zerolog does
+// not give hooks access to the fields of an event, so they are read from the
+// event's buffer.
+func eventBuffer(e *zerolog.Event) []byte {
+	if e == nil {
+		return nil
+	}
+	v := reflect.ValueOf(e).Elem().FieldByName("buf")
+	if !v.IsValid() || v.Kind() != reflect.Slice || v.Type().Elem().Kind() != reflect.Uint8 {
+		return nil
+	}
+	return v.Bytes()
+}
+
+// eventAttributes returns the fields of the event as log attributes.  The
+// level and timestamp fields are excluded since they are recorded separately.
+func eventAttributes(e *zerolog.Event) map[string]any {
+	return parseAttributes(eventBuffer(e))
+}
+
+// parseAttributes parses the JSON fields written by zerolog, which are not
+// yet terminated by a closing brace when hooks are run.  It returns nil if
+// the fields cannot be parsed, for example if zerolog was built using the
+// binary_log tag.
+func parseAttributes(buf []byte) map[string]any {
+	if len(buf) < 2 || buf[0] != '{' {
+		return nil
+	}
+	js := make([]byte, 0, len(buf)+1)
+	js = append(js, buf...)
+	js = append(js, '}')
+
+	var fields map[string]any
+	dec := json.NewDecoder(bytes.NewReader(js))
+	dec.UseNumber()
+	if err := dec.Decode(&fields); err != nil {
+		return nil
+	}
+	delete(fields, zerolog.LevelFieldName)
+	delete(fields, zerolog.TimestampFieldName)
+	delete(fields, zerolog.MessageFieldName)
+	if len(fields) == 0 {
+		return nil
+	}
+	for key, val := range fields {
+		if n, ok := val.(json.Number); ok {
+			if i, err := n.Int64(); err == nil {
+				fields[key] = i
+			} else if f, err := n.Float64(); err == nil {
+				fields[key] = f
+			}
+		}
+	}
+	return fields
+}
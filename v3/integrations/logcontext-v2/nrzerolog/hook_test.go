@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"io"
+	"reflect"
 	"testing"
 
 	"github.com/newrelic/go-agent/v3/internal"
@@ -212,3 +213,103 @@ func TestLogInContext(t *testing.T) {
 
 	txn.End()
 }
+
+func TestLogAttributes(t *testing.T) {
+	app := integrationsupport.NewTestApp(integrationsupport.SampleEverythingReplyFn,
+		newrelic.ConfigAppLogForwardingEnabled(true),
+	)
+	out := bytes.NewBuffer([]byte{})
+	log := newLogger(out, app.Application).With().Str("service", "checkout").Logger()
+	message := "Hello World!"
+	log.Info().Str("user", "gopher").Int("attempt", 2).Timestamp().Msg(message)
+
+	app.ExpectLogEvents(t, []internal.WantLog{
+		{
+			Severity:  zerolog.InfoLevel.String(),
+			Message:   message,
+			Timestamp: internal.MatchAnyUnixMilli,
+			Attributes: map[string]interface{}{
+				"service": "checkout",
+				"user":    "gopher",
+				"attempt": 2,
+			},
+		},
+	})
+}
+
+func TestParseAttributes(t *testing.T) {
+	testcases := []struct {
+		buf   string
+		attrs map[string]interface{}
+	}{
+		{buf: ``, attrs: nil},
+		{buf: `{`, attrs: nil},
+		{buf: `{"level":"info"`, attrs: nil},
+		{buf: `{"level":"info","time":"2022-01-01T00:00:00Z"`, attrs: nil},
+		{buf: `{"level":"info","user":"gopher"`, attrs: map[string]interface{}{"user": "gopher"}},
+		{buf: `{"level":"info","attempt":2,"ratio":0.5,"ok":true`, attrs: map[string]interface{}{
+			"attempt": int64(2),
+			"ratio":   0.5,
+			"ok":      true,
+		}},
+		{buf: `{"tags":["a","b"],"obj":{"k":"v"}`, attrs: map[string]interface{}{
+			"tags": []interface{}{"a", "b"},
+			"obj":  map[string]interface{}{"k": "v"},
+		}},
+		{buf: `{"user":"gop`, attrs: nil},
+	}
+	for _, tc := range testcases {
+		attrs := parseAttributes([]byte(tc.buf))
+		if !reflect.DeepEqual(attrs, tc.attrs) {
+			t.Errorf("incorrect attributes for %q: got %#v, want %#v", tc.buf, attrs, tc.attrs)
+		}
+	}
+}
+
+func TestLoggerSampling(t *testing.T) {
+	app := integrationsupport.NewTestApp(integrationsupport.SampleEverythingReplyFn,
+		newrelic.ConfigAppLogForwardingEnabled(true),
+	)
+	out := bytes.NewBuffer([]byte{})
+	log := newLogger(out, app.Application).Sample(&zerolog.BasicSampler{N: 2})
+	log.Info().Msg("first")
+	log.Info().Msg("second")
+	log.Info().Msg("third")
+
+	app.ExpectLogEvents(t, []internal.WantLog{
+		{
+			Severity:  zerolog.InfoLevel.String(),
+			Message:   "first",
+			Timestamp: internal.MatchAnyUnixMilli,
+		},
+		{
+			Severity:  zerolog.InfoLevel.String(),
+			Message:   "third",
+			Timestamp: internal.MatchAnyUnixMilli,
+		},
+	})
+}
+
+func TestHookSampling(t *testing.T) {
+	app := integrationsupport.NewTestApp(integrationsupport.SampleEverythingReplyFn,
+		newrelic.ConfigAppLogForwardingEnabled(true),
+	)
+	out := bytes.NewBuffer([]byte{})
+	log := zerolog.New(out).Hook(NewRelicHook{
+		App:     app.Application,
+		Sampler: zerolog.LevelSampler{InfoSampler: zerolog.RandomSampler(0)},
+	})
+	log.Info().Msg("sampled out")
+	log.Error().Msg("forwarded")
+
+	app.ExpectLogEvents(t, []internal.WantLog{
+		{
+			Severity:  zerolog.ErrorLevel.String(),
+			Message:   "forwarded",
+			Timestamp: internal.MatchAnyUnixMilli,
+		},
+	})
+	if lines := bytes.Count(out.Bytes(), []byte("\n")); lines != 2 {
+		t.Errorf("hook sampler must not affect the logger output: %d lines written", lines)
+	}
+}
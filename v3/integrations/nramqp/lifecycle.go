@@ -0,0 +1,178 @@
+package nramqp
+
+import (
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	"github.com/newrelic/go-agent/v3/newrelic"
+)
+
+// LifecycleEventType is the type of the custom events recorded by
+// MonitorConnection, MonitorChannel, and RecordReconnectAttempt.  The "event"
+// attribute of each event is one of "connection.blocked",
+// "connection.unblocked", "connection.closed", "channel.closed", or
+// "connection.reconnect".
+const LifecycleEventType = "RabbitMQLifecycleEvent"
+
+// Names of the custom metrics recorded for lifecycle events, after the
+// "Custom/" prefix added by RecordCustomMetric.
+const (
+	connectionBlockedMetric     = "MessageBroker/RabbitMQ/Connection/Blocked"
+	connectionBlockedTimeMetric = "MessageBroker/RabbitMQ/Connection/BlockedTime"
+	connectionClosedMetric      = "MessageBroker/RabbitMQ/Connection/Closed"
+	channelClosedMetric         = "MessageBroker/RabbitMQ/Channel/Closed"
+	reconnectAttemptMetric      = "MessageBroker/RabbitMQ/Connection/ReconnectAttempt"
+)
+
+type lifecycleMonitor struct {
+	app  *newrelic.Application
+	host string
+	port string
+}
+
+func newLifecycleMonitor(app *newrelic.Application, url string) lifecycleMonitor {
+	host, port := GetHostAndPortFromURL(url)
+	return lifecycleMonitor{app: app, host: host, port: port}
+}
+
+func (m lifecycleMonitor) record(event string, params map[string]interface{}) {
+	params["event"] = event
+	params["host"] = m.host
+	params["port"] = m.port
+	m.app.RecordCustomEvent(LifecycleEventType, params)
+}
+
+func (m lifecycleMonitor) recordClose(event, metric string, err *amqp.Error) {
+	m.record(event, map[string]interface{}{
+		"code":    err.Code,
+		"reason":  err.Reason,
+		"server":  err.Server,
+		"recover": err.Recover,
+	})
+	m.app.RecordCustomMetric(metric, 1)
+}
+
+// MonitorConnection records when the broker blocks and unblocks the
+// connection for flow control, and when the connection is closed with an
+// error, as custom events and metrics.  Publishing on a blocked connection
+// waits until it is unblocked, so flow control is a common hidden cause of
+// publish latency.  The url is used to record the host and port of the
+// broker.
+//
+// Each block is counted in the "Custom/MessageBroker/RabbitMQ/Connection/Blocked"
+// metric, and the time the connection stayed blocked is recorded in the
+// "Custom/MessageBroker/RabbitMQ/Connection/BlockedTime" metric.  Closes are
+// counted in the "Custom/MessageBroker/RabbitMQ/Connection/Closed" metric.
+// Call it once, after dialing the connection.
+func MonitorConnection(app *newrelic.Application, conn *amqp.Connection, url string) {
+	if app == nil || conn == nil {
+		return
+	}
+	m := newLifecycleMonitor(app, url)
+	blocked := conn.NotifyBlocked(make(chan amqp.Blocking, 1))
+	closed := conn.NotifyClose(make(chan *amqp.Error, 1))
+	go m.watchConnection(blocked, closed)
+}
+
+// watchConnection records the notifications of a connection until it is
+// closed.  Both channels are closed by the connection when it closes, and
+// no error is sent on a graceful close.
+func (m lifecycleMonitor) watchConnection(blocked <-chan amqp.Blocking, closed <-chan *amqp.Error) {
+	var blockedSince time.Time
+	for {
+		select {
+		case b, ok := <-blocked:
+			if !ok {
+				blocked = nil
+				continue
+			}
+			m.recordBlocking(b, &blockedSince)
+		case err, ok := <-closed:
+			// Record the notifications sent before the close first.
+			m.drainBlocking(blocked, &blockedSince)
+			if ok && err != nil {
+				m.recordClose("connection.closed", connectionClosedMetric, err)
+			}
+			return
+		}
+	}
+}
+
+// drainBlocking records the notifications already sent on blocked.
+func (m lifecycleMonitor) drainBlocking(blocked <-chan amqp.Blocking, blockedSince *time.Time) {
+	for blocked != nil {
+		select {
+		case b, ok := <-blocked:
+			if !ok {
+				return
+			}
+			m.recordBlocking(b, blockedSince)
+		default:
+			return
+		}
+	}
+}
+
+// recordBlocking records a connection being blocked or unblocked.
+// blockedSince holds the time the connection was last blocked.
+func (m lifecycleMonitor) recordBlocking(b amqp.Blocking, blockedSince *time.Time) {
+	if b.Active {
+		*blockedSince = time.Now()
+		m.record("connection.blocked", map[string]interface{}{
+			"reason": b.Reason,
+		})
+		m.app.RecordCustomMetric(connectionBlockedMetric, 1)
+		return
+	}
+	params := map[string]interface{}{}
+	if !blockedSince.IsZero() {
+		d := time.Since(*blockedSince)
+		params["blockedDuration"] = d.Seconds()
+		m.app.RecordCustomMetric(connectionBlockedTimeMetric, d.Seconds())
+		*blockedSince = time.Time{}
+	}
+	m.record("connection.unblocked", params)
+}
+
+// MonitorChannel records when the channel is closed with an error, such as
+// a channel exception raised by the broker, as a custom event with the code
+// and reason of the error.  Closes are counted in the
+// "Custom/MessageBroker/RabbitMQ/Channel/Closed" metric.  The url is used to
+// record the host and port of the broker.  Call it once, after opening the
+// channel.
+func MonitorChannel(app *newrelic.Application, ch *amqp.Channel, url string) {
+	if app == nil || ch == nil {
+		return
+	}
+	m := newLifecycleMonitor(app, url)
+	closed := ch.NotifyClose(make(chan *amqp.Error, 1))
+	go m.watchChannel(closed)
+}
+
+func (m lifecycleMonitor) watchChannel(closed <-chan *amqp.Error) {
+	if err, ok := <-closed; ok && err != nil {
+		m.recordClose("channel.closed", channelClosedMetric, err)
+	}
+}
+
+// RecordReconnectAttempt records an attempt to reconnect to the broker as a
+// custom event, and counts it in the
+// "Custom/MessageBroker/RabbitMQ/Connection/ReconnectAttempt" metric.  The
+// amqp091-go library does not reconnect automatically, so call it from your
+// reconnection logic with the number of the attempt and the error returned
+// by the attempt, if any.
+func RecordReconnectAttempt(app *newrelic.Application, url string, attempt int, err error) {
+	if app == nil {
+		return
+	}
+	m := newLifecycleMonitor(app, url)
+	params := map[string]interface{}{
+		"attempt": attempt,
+	}
+	if err != nil {
+		params["error"] = err.Error()
+	}
+	m.record("connection.reconnect", params)
+	app.RecordCustomMetric(reconnectAttemptMetric, 1)
+}
@@ -123,6 +123,7 @@ func Consume(app *newrelic.Application, ch *amqp.Channel, queue, consumer string
 			integrationsupport.AddAgentAttribute(txn, newrelic.AttributeMessageRoutingKey, delivery.RoutingKey, nil)
 			integrationsupport.AddAgentAttribute(txn, newrelic.AttributeMessageCorrelationID, delivery.CorrelationId, nil)
 			integrationsupport.AddAgentAttribute(txn, newrelic.AttributeMessageReplyTo, delivery.ReplyTo, nil)
+			txn.SetMessageQueueTime(delivery.Timestamp)
 
 			return txn
 		}
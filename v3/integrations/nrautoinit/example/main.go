@@ -0,0 +1,33 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// This example is run with the environment variable NEW_RELIC_LICENSE_KEY set
+// to your license key, and optionally NEW_RELIC_APP_NAME set to the name of
+// the application.
+package main
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/newrelic/go-agent/v3/integrations/nrautoinit"
+	"github.com/newrelic/go-agent/v3/newrelic"
+)
+
+func index(w http.ResponseWriter, r *http.Request) {
+	io.WriteString(w, "hello world")
+}
+
+func users(w http.ResponseWriter, r *http.Request) {
+	// The transaction is in the request context.
+	txn := newrelic.FromContext(r.Context())
+	txn.AddAttribute("user", r.URL.Query().Get("id"))
+	io.WriteString(w, "hello "+r.URL.Query().Get("id"))
+}
+
+func main() {
+	http.HandleFunc("/", index)
+	http.HandleFunc("/users", users)
+
+	nrautoinit.ListenAndServe(":8000")
+}
@@ -0,0 +1,95 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package nrautoinit creates the application from environment variables when
+// it is imported, for simple services which should be monitored with as
+// little code as possible.
+//
+// Import the package, and serve requests using its ListenAndServe function,
+// or its Handler, instead of serving http.DefaultServeMux directly:
+//
+//	import "github.com/newrelic/go-agent/v3/integrations/nrautoinit"
+//
+//	func main() {
+//		http.HandleFunc("/users", usersHandler)
+//		// Instead of http.ListenAndServe(":8000", nil):
+//		nrautoinit.ListenAndServe(":8000")
+//	}
+//
+// The application is created when the package is initialized, using
+// newrelic.ConfigFromEnvironment.  At least NEW_RELIC_LICENSE_KEY must be
+// set.  The application name is NEW_RELIC_APP_NAME, or the name of the
+// program if it is not set.  Monitoring is disabled by setting
+// NEW_RELIC_ENABLED to false.  If the application cannot be created, for
+// example because the license key is missing, the error is logged using the
+// standard log package and requests are served without instrumentation.
+//
+// Each request handled by http.DefaultServeMux is recorded as a web
+// transaction, as by newrelic.NewMiddleware.  The handlers registered with
+// http.DefaultServeMux are not wrapped when they are registered, since
+// handlers are registered after the package is initialized, which is why the
+// server must use ListenAndServe or Handler.  Application returns the
+// application, so that other instrumentation can be added as the service
+// grows.
+//
+// Full example:
+// https://github.com/newrelic/go-agent/blob/master/v3/integrations/nrautoinit/example/main.go
+package nrautoinit
+
+import (
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/newrelic/go-agent/v3/internal"
+	"github.com/newrelic/go-agent/v3/newrelic"
+)
+
+var app *newrelic.Application
+
+func init() {
+	internal.TrackUsage("integration", "autoinit")
+	var err error
+	app, err = newApplication()
+	if nil != err {
+		log.Printf("newrelic: unable to create application: %v", err)
+	}
+}
+
+// newApplication creates the application from the environment.
+func newApplication() (*newrelic.Application, error) {
+	return newrelic.NewApplication(
+		newrelic.ConfigAppName(programName()),
+		newrelic.ConfigFromEnvironment(),
+	)
+}
+
+// programName returns the name of the running program, which is the default
+// application name.
+func programName() string {
+	if len(os.Args) == 0 {
+		return ""
+	}
+	return filepath.Base(os.Args[0])
+}
+
+// Application returns the application created from the environment, or nil
+// if it could not be created.
+func Application() *newrelic.Application {
+	return app
+}
+
+// Handler returns http.DefaultServeMux instrumented using
+// newrelic.NewMiddleware.  Use it in place of http.DefaultServeMux, or of a
+// nil handler, when serving requests:
+//
+//	server := &http.Server{Addr: ":8000", Handler: nrautoinit.Handler()}
+func Handler() http.Handler {
+	return newrelic.NewMiddleware(app)(http.DefaultServeMux)
+}
+
+// ListenAndServe is http.ListenAndServe with the handler returned by Handler.
+func ListenAndServe(addr string) error {
+	return http.ListenAndServe(newrelic.WrapListen(addr), Handler())
+}
@@ -0,0 +1,53 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package nrautoinit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/newrelic/go-agent/v3/internal"
+	"github.com/newrelic/go-agent/v3/internal/integrationsupport"
+)
+
+func init() {
+	http.HandleFunc("/hello", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("hello"))
+	})
+}
+
+func TestHandlerWithoutApplication(t *testing.T) {
+	// The license key is not set when testing, so the application could
+	// not be created.
+	if nil != Application() {
+		t.Fatal("application created without a license key")
+	}
+	w := httptest.NewRecorder()
+	Handler().ServeHTTP(w, httptest.NewRequest("GET", "/hello", nil))
+	if w.Body.String() != "hello" {
+		t.Error(w.Body.String())
+	}
+}
+
+func TestHandler(t *testing.T) {
+	testApp := integrationsupport.NewBasicTestApp()
+	app = testApp.Application
+	defer func() { app = nil }()
+
+	w := httptest.NewRecorder()
+	Handler().ServeHTTP(w, httptest.NewRequest("GET", "/hello", nil))
+	if w.Body.String() != "hello" {
+		t.Error(w.Body.String())
+	}
+	testApp.ExpectMetricsPresent(t, []internal.WantMetric{
+		{Name: "WebTransaction/Go/GET", Scope: "", Forced: true, Data: nil},
+	})
+}
+
+func TestProgramName(t *testing.T) {
+	if name := programName(); name != "nrautoinit.test" {
+		t.Error(name)
+	}
+}
@@ -0,0 +1,234 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package nrawssdk
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	snstypes "github.com/aws/aws-sdk-go-v2/service/sns/types"
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/newrelic/go-agent/v3/internal"
+	"github.com/newrelic/go-agent/v3/internal/integrationsupport"
+	"github.com/newrelic/go-agent/v3/newrelic"
+)
+
+const (
+	sqsLibrary = "SQS"
+
+	// maxMessageAttributes is the maximum number of message attributes
+	// that SQS and SNS accept for a single message.
+	maxMessageAttributes = 10
+
+	// AttributeSQSMessageID is the custom attribute added to transactions
+	// started by StartSQSMessageTransaction that contains the SQS message
+	// ID.
+	AttributeSQSMessageID = "aws.sqs.messageId"
+)
+
+// DistributedTraceAttributeNames contains the names of the message attributes
+// used to propagate distributed tracing headers through SQS and SNS.  SQS only
+// returns the message attributes which are requested, so either add these
+// names or "All" to the MessageAttributeNames field of ReceiveMessageInput:
+//
+//	out, err := client.ReceiveMessage(ctx, &sqs.ReceiveMessageInput{
+//		QueueUrl:              aws.String(queueURL),
+//		MessageAttributeNames: nrawssdk.DistributedTraceAttributeNames,
+//	})
+var DistributedTraceAttributeNames = []string{
+	"traceparent",
+	"tracestate",
+	"newrelic",
+}
+
+// distributedTraceHeaders returns the distributed tracing headers of the
+// transaction keyed by their message attribute names, in the order in which
+// they should be added.  Attribute names are lower case since SQS and SNS do
+// not canonicalize them.
+func distributedTraceHeaders(txn *newrelic.Transaction) ([]string, http.Header) {
+	hdrs := http.Header{}
+	txn.InsertDistributedTraceHeaders(hdrs)
+	var names []string
+	for _, name := range DistributedTraceAttributeNames {
+		if hdrs.Get(name) != "" {
+			names = append(names, name)
+		}
+	}
+	return names, hdrs
+}
+
+// InsertSQSDistributedTraceAttributes adds the distributed tracing headers of
+// the transaction to the SQS message attributes and returns them.  If attrs is
+// nil, a new map is returned.  Use it when sending a message:
+//
+//	_, err := client.SendMessage(ctx, &sqs.SendMessageInput{
+//		QueueUrl:          aws.String(queueURL),
+//		MessageBody:       aws.String(body),
+//		MessageAttributes: nrawssdk.InsertSQSDistributedTraceAttributes(txn, nil),
+//	})
+//
+// Since SQS rejects messages with more than 10 attributes, headers are only
+// added while there is room for them.
+func InsertSQSDistributedTraceAttributes(txn *newrelic.Transaction, attrs map[string]sqstypes.MessageAttributeValue) map[string]sqstypes.MessageAttributeValue {
+	if nil == attrs {
+		attrs = make(map[string]sqstypes.MessageAttributeValue)
+	}
+	names, hdrs := distributedTraceHeaders(txn)
+	for _, name := range names {
+		if _, ok := attrs[name]; !ok && len(attrs) >= maxMessageAttributes {
+			break
+		}
+		attrs[name] = sqstypes.MessageAttributeValue{
+			DataType:    aws.String("String"),
+			StringValue: aws.String(hdrs.Get(name)),
+		}
+	}
+	return attrs
+}
+
+// InsertSNSDistributedTraceAttributes adds the distributed tracing headers of
+// the transaction to the SNS message attributes and returns them.  If attrs is
+// nil, a new map is returned.  Use it when publishing a message:
+//
+//	_, err := client.Publish(ctx, &sns.PublishInput{
+//		TopicArn:          aws.String(topicARN),
+//		Message:           aws.String(body),
+//		MessageAttributes: nrawssdk.InsertSNSDistributedTraceAttributes(txn, nil),
+//	})
+//
+// SNS copies message attributes to SQS subscriptions, so the trace can be
+// continued by a consumer using StartSQSMessageTransaction.  Since SQS rejects
+// messages with more than 10 attributes, headers are only added while there
+// is room for them.
+func InsertSNSDistributedTraceAttributes(txn *newrelic.Transaction, attrs map[string]snstypes.MessageAttributeValue) map[string]snstypes.MessageAttributeValue {
+	if nil == attrs {
+		attrs = make(map[string]snstypes.MessageAttributeValue)
+	}
+	names, hdrs := distributedTraceHeaders(txn)
+	for _, name := range names {
+		if _, ok := attrs[name]; !ok && len(attrs) >= maxMessageAttributes {
+			break
+		}
+		attrs[name] = snstypes.MessageAttributeValue{
+			DataType:    aws.String("String"),
+			StringValue: aws.String(hdrs.Get(name)),
+		}
+	}
+	return attrs
+}
+
+// AcceptSQSDistributedTraceAttributes continues the trace found in the SQS
+// message attributes in the transaction.  It is not required when using
+// StartSQSMessageTransaction.
+func AcceptSQSDistributedTraceAttributes(txn *newrelic.Transaction, attrs map[string]sqstypes.MessageAttributeValue) {
+	hdrs := http.Header{}
+	for key, val := range attrs {
+		if nil != val.StringValue {
+			hdrs.Set(key, *val.StringValue)
+		}
+	}
+	txn.AcceptDistributedTraceHeaders(newrelic.TransportQueue, hdrs)
+}
+
+// snsNotification is the envelope used by SNS when delivering a message to an
+// SQS queue without raw message delivery enabled.
+type snsNotification struct {
+	Type              string `json:"Type"`
+	MessageAttributes map[string]struct {
+		Type  string `json:"Type"`
+		Value string `json:"Value"`
+	} `json:"MessageAttributes"`
+}
+
+// snsNotificationAttributes returns the message attributes of the SNS
+// notification contained in the body, or nil if the body is not an SNS
+// notification.
+func snsNotificationAttributes(body *string) map[string]sqstypes.MessageAttributeValue {
+	if nil == body || !strings.Contains(*body, `"Notification"`) {
+		return nil
+	}
+	var n snsNotification
+	if err := json.Unmarshal([]byte(*body), &n); nil != err || n.Type != "Notification" {
+		return nil
+	}
+	attrs := make(map[string]sqstypes.MessageAttributeValue, len(n.MessageAttributes))
+	for key, val := range n.MessageAttributes {
+		attrs[key] = sqstypes.MessageAttributeValue{
+			DataType:    aws.String(val.Type),
+			StringValue: aws.String(val.Value),
+		}
+	}
+	return attrs
+}
+
+// hasDistributedTraceAttributes returns true if the attributes contain any
+// distributed tracing headers.
+func hasDistributedTraceAttributes(attrs map[string]sqstypes.MessageAttributeValue) bool {
+	for _, name := range DistributedTraceAttributeNames {
+		if _, ok := attrs[name]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// queueName returns the name of the queue from a queue URL of the form
+// https://sqs.<region>.amazonaws.com/<account id>/<queue name>.
+func queueName(queueURL string) string {
+	u, err := url.Parse(queueURL)
+	if nil != err {
+		return ""
+	}
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	return parts[len(parts)-1]
+}
+
+// StartSQSMessageTransaction starts a transaction for processing a message
+// received from the SQS queue with the given URL, continuing the trace found
+// in its message attributes.  Messages delivered by SNS without raw message
+// delivery are also supported.  It is intended for use in poll loops:
+//
+//	out, err := client.ReceiveMessage(ctx, &sqs.ReceiveMessageInput{
+//		QueueUrl:              aws.String(queueURL),
+//		MessageAttributeNames: nrawssdk.DistributedTraceAttributeNames,
+//	})
+//	...
+//	for _, msg := range out.Messages {
+//		txn := nrawssdk.StartSQSMessageTransaction(app, queueURL, msg)
+//		process(newrelic.NewContext(ctx, txn), msg)
+//		txn.End()
+//	}
+//
+// The transaction is named after the queue.  The caller is responsible for
+// ending the transaction.  If app is nil, nil is returned.
+func StartSQSMessageTransaction(app *newrelic.Application, queueURL string, message sqstypes.Message) *newrelic.Transaction {
+	if nil == app {
+		return nil
+	}
+	name := queueName(queueURL)
+	namer := internal.MessageMetricKey{
+		Library:         sqsLibrary,
+		DestinationType: string(newrelic.MessageQueue),
+		DestinationName: name,
+		Consumer:        true,
+	}
+	txn := app.StartTransaction(namer.Name())
+
+	attrs := message.MessageAttributes
+	if !hasDistributedTraceAttributes(attrs) {
+		if n := snsNotificationAttributes(message.Body); nil != n {
+			attrs = n
+		}
+	}
+	AcceptSQSDistributedTraceAttributes(txn, attrs)
+
+	integrationsupport.AddAgentAttribute(txn, newrelic.AttributeMessageQueueName, name, nil)
+	if nil != message.MessageId {
+		txn.AddAttribute(AttributeSQSMessageID, *message.MessageId)
+	}
+	return txn
+}
@@ -0,0 +1,182 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package nrawssdk
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/newrelic/go-agent/v3/internal"
+	"github.com/newrelic/go-agent/v3/internal/integrationsupport"
+	"github.com/newrelic/go-agent/v3/newrelic"
+)
+
+const testQueueURL = "https://sqs.us-west-2.amazonaws.com/123456789012/MyQueue"
+
+func messagingTestApp() integrationsupport.ExpectApp {
+	return integrationsupport.NewTestApp(func(reply *internal.ConnectReply) {
+		reply.SetSampleEverything()
+		reply.AccountID = "123"
+		reply.TrustedAccountKey = "123"
+		reply.PrimaryAppID = "456"
+	}, integrationsupport.DTEnabledCfgFn, newrelic.ConfigCodeLevelMetricsEnabled(false))
+}
+
+func TestQueueName(t *testing.T) {
+	for queueURL, want := range map[string]string{
+		testQueueURL: "MyQueue",
+		"https://sqs.us-west-2.amazonaws.com/123456789012/MyQueue/": "MyQueue",
+		"": "",
+	} {
+		if got := queueName(queueURL); got != want {
+			t.Errorf("queueName(%q) = %q, want %q", queueURL, got, want)
+		}
+	}
+}
+
+func TestInsertSQSDistributedTraceAttributes(t *testing.T) {
+	app := messagingTestApp()
+	txn := app.StartTransaction("producer")
+	attrs := InsertSQSDistributedTraceAttributes(txn, map[string]sqstypes.MessageAttributeValue{
+		"zip": {DataType: aws.String("String"), StringValue: aws.String("zap")},
+	})
+	txn.End()
+
+	for _, name := range append(DistributedTraceAttributeNames, "zip") {
+		if v, ok := attrs[name]; !ok || aws.ToString(v.StringValue) == "" {
+			t.Error("missing attribute", name, attrs)
+		}
+	}
+}
+
+func TestInsertSQSDistributedTraceAttributesLimit(t *testing.T) {
+	app := messagingTestApp()
+	txn := app.StartTransaction("producer")
+	attrs := make(map[string]sqstypes.MessageAttributeValue)
+	for i := 0; i < maxMessageAttributes-1; i++ {
+		attrs[fmt.Sprint(i)] = sqstypes.MessageAttributeValue{DataType: aws.String("String"), StringValue: aws.String("x")}
+	}
+	attrs = InsertSQSDistributedTraceAttributes(txn, attrs)
+	txn.End()
+
+	if len(attrs) != maxMessageAttributes {
+		t.Error(len(attrs))
+	}
+	if _, ok := attrs["traceparent"]; !ok {
+		t.Error("traceparent missing", attrs)
+	}
+}
+
+func TestInsertSNSDistributedTraceAttributesNilTxn(t *testing.T) {
+	attrs := InsertSNSDistributedTraceAttributes(nil, nil)
+	if nil == attrs || len(attrs) != 0 {
+		t.Error(attrs)
+	}
+}
+
+func TestStartSQSMessageTransactionNilApp(t *testing.T) {
+	if txn := StartSQSMessageTransaction(nil, testQueueURL, sqstypes.Message{}); nil != txn {
+		t.Error(txn)
+	}
+}
+
+func TestStartSQSMessageTransaction(t *testing.T) {
+	app := messagingTestApp()
+	producer := app.StartTransaction("producer")
+	msg := sqstypes.Message{
+		MessageId:         aws.String("abcd-1234"),
+		Body:              aws.String("hello"),
+		MessageAttributes: InsertSQSDistributedTraceAttributes(producer, nil),
+	}
+	producer.End()
+
+	txn := StartSQSMessageTransaction(app.Application, testQueueURL, msg)
+	txn.End()
+
+	app.ExpectMetricsPresent(t, []internal.WantMetric{
+		{Name: "OtherTransaction/Go/Message/SQS/Queue/Named/MyQueue", Scope: ""},
+		{Name: "Supportability/TraceContext/Accept/Success", Scope: ""},
+	})
+	app.ExpectTxnEvents(t, []internal.WantEvent{
+		{
+			Intrinsics: map[string]interface{}{
+				"name":     "OtherTransaction/Go/producer",
+				"guid":     internal.MatchAnything,
+				"priority": internal.MatchAnything,
+				"sampled":  internal.MatchAnything,
+				"traceId":  internal.MatchAnything,
+			},
+		},
+		{
+			Intrinsics: map[string]interface{}{
+				"name":                     "OtherTransaction/Go/Message/SQS/Queue/Named/MyQueue",
+				"guid":                     internal.MatchAnything,
+				"priority":                 internal.MatchAnything,
+				"sampled":                  internal.MatchAnything,
+				"traceId":                  internal.MatchAnything,
+				"parentId":                 internal.MatchAnything,
+				"parentSpanId":             internal.MatchAnything,
+				"parent.type":              "App",
+				"parent.app":               "456",
+				"parent.account":           "123",
+				"parent.transportType":     "Queue",
+				"parent.transportDuration": internal.MatchAnything,
+			},
+			UserAttributes: map[string]interface{}{
+				AttributeSQSMessageID: "abcd-1234",
+			},
+			AgentAttributes: map[string]interface{}{
+				newrelic.AttributeMessageQueueName: "MyQueue",
+			},
+		},
+	})
+}
+
+func TestStartSQSMessageTransactionSNSNotification(t *testing.T) {
+	app := messagingTestApp()
+	producer := app.StartTransaction("producer")
+	snsAttrs := InsertSNSDistributedTraceAttributes(producer, nil)
+	producer.End()
+
+	// Build the envelope that SNS delivers to SQS subscriptions when raw
+	// message delivery is disabled.
+	type attr struct {
+		Type  string
+		Value string
+	}
+	envelope := struct {
+		Type              string
+		Message           string
+		MessageAttributes map[string]attr
+	}{
+		Type:              "Notification",
+		Message:           "hello",
+		MessageAttributes: map[string]attr{},
+	}
+	for key, val := range snsAttrs {
+		envelope.MessageAttributes[key] = attr{Type: aws.ToString(val.DataType), Value: aws.ToString(val.StringValue)}
+	}
+	body, err := json.Marshal(envelope)
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	txn := StartSQSMessageTransaction(app.Application, testQueueURL, sqstypes.Message{Body: aws.String(string(body))})
+	txn.End()
+
+	app.ExpectMetricsPresent(t, []internal.WantMetric{
+		{Name: "Supportability/TraceContext/Accept/Success", Scope: ""},
+	})
+}
+
+func TestSNSNotificationAttributesNotNotification(t *testing.T) {
+	for _, body := range []*string{nil, aws.String("hello"), aws.String(`{"Type":"SubscriptionConfirmation"}`)} {
+		if attrs := snsNotificationAttributes(body); nil != attrs {
+			t.Error(attrs)
+		}
+	}
+}
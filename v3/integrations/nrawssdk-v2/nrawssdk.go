@@ -21,9 +21,18 @@
 // displayed on the Databases page. All operations will also be displayed on
 // transaction traces and distributed traces.
 //
+// DynamoDB segments are named using the operation, such as GetItem or Query,
+// and the table name.  When a request sets ReturnConsumedCapacity, the
+// capacity consumed is added to the span.
+//
 // To use this integration, simply apply the AppendMiddlewares fuction to the apiOptions in
 // your AWS Config object before performing any AWS operations. See
 // example/main.go for a working sample.
+//
+// To continue distributed traces through SQS and SNS, add the tracing headers
+// to outgoing messages using InsertSQSDistributedTraceAttributes or
+// InsertSNSDistributedTraceAttributes, and start a transaction for each
+// received SQS message using StartSQSMessageTransaction.
 package nrawssdk
 
 import (
@@ -34,6 +43,8 @@ import (
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	awsmiddle "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	dynamodbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 	"github.com/aws/aws-sdk-go-v2/service/sqs"
 	"github.com/aws/smithy-go/middleware"
 	smithymiddle "github.com/aws/smithy-go/middleware"
@@ -49,7 +60,10 @@ type nrMiddleware struct {
 // Context key for SQS service queue
 type contextKey string
 
-const queueURLKey contextKey = "QueueURL"
+const (
+	queueURLKey  contextKey = "QueueURL"
+	tableNameKey contextKey = "TableName"
+)
 
 type endable interface{ End() }
 
@@ -75,10 +89,12 @@ func (m nrMiddleware) deserializeMiddleware(stack *smithymiddle.Stack) error {
 
 		var segment endable
 		// Service name capitalization is different for v1 and v2.
-		if serviceName == "dynamodb" || serviceName == "DynamoDB" {
+		isDynamoDB := serviceName == "dynamodb" || serviceName == "DynamoDB"
+		if isDynamoDB {
+			tableName, _ := ctx.Value(tableNameKey).(string)
 			segment = &newrelic.DatastoreSegment{
 				Product:            newrelic.DatastoreDynamoDB,
-				Collection:         "", // AWS SDK V2 doesn't expose TableName
+				Collection:         tableName,
 				Operation:          operation,
 				ParameterizedQuery: "",
 				QueryParameters:    nil,
@@ -116,6 +132,9 @@ func (m nrMiddleware) deserializeMiddleware(stack *smithymiddle.Stack) error {
 
 				}
 			}
+			if isDynamoDB {
+				addConsumedCapacityAttributes(txn, out.Result)
+			}
 			// Set additional span attributes
 			integrationsupport.AddAgentSpanAttribute(txn,
 				newrelic.AttributeResponseCode, strconv.Itoa(response.StatusCode))
@@ -176,10 +195,154 @@ func (m nrMiddleware) serializeMiddleware(stack *middleware.Stack) error {
 			// Store the QueueURL in the context
 			ctx = context.WithValue(ctx, queueURLKey, QueueURL)
 		}
+		if serviceName == "dynamodb" || serviceName == "DynamoDB" {
+			// Store the table name in the context
+			ctx = context.WithValue(ctx, tableNameKey, dynamoDBTableName(in.Parameters))
+		}
 		return next.HandleInitialize(ctx, in)
 	}), middleware.After)
 }
 
+// dynamoDBTableName returns the name of the table used by the DynamoDB
+// operation input, or the empty string if the operation does not use exactly
+// one table.
+func dynamoDBTableName(params interface{}) string {
+	switch params := params.(type) {
+	case *dynamodb.GetItemInput:
+		return aws.ToString(params.TableName)
+	case *dynamodb.PutItemInput:
+		return aws.ToString(params.TableName)
+	case *dynamodb.UpdateItemInput:
+		return aws.ToString(params.TableName)
+	case *dynamodb.DeleteItemInput:
+		return aws.ToString(params.TableName)
+	case *dynamodb.QueryInput:
+		return aws.ToString(params.TableName)
+	case *dynamodb.ScanInput:
+		return aws.ToString(params.TableName)
+	case *dynamodb.CreateTableInput:
+		return aws.ToString(params.TableName)
+	case *dynamodb.DeleteTableInput:
+		return aws.ToString(params.TableName)
+	case *dynamodb.DescribeTableInput:
+		return aws.ToString(params.TableName)
+	case *dynamodb.UpdateTableInput:
+		return aws.ToString(params.TableName)
+	case *dynamodb.DescribeTimeToLiveInput:
+		return aws.ToString(params.TableName)
+	case *dynamodb.UpdateTimeToLiveInput:
+		return aws.ToString(params.TableName)
+	case *dynamodb.BatchGetItemInput:
+		return singleTableName(params.RequestItems)
+	case *dynamodb.BatchWriteItemInput:
+		return singleTableName(params.RequestItems)
+	case *dynamodb.TransactGetItemsInput:
+		tables := make(map[string]struct{}, len(params.TransactItems))
+		for _, item := range params.TransactItems {
+			if nil != item.Get {
+				tables[aws.ToString(item.Get.TableName)] = struct{}{}
+			}
+		}
+		return singleTableName(tables)
+	case *dynamodb.TransactWriteItemsInput:
+		tables := make(map[string]struct{}, len(params.TransactItems))
+		for _, item := range params.TransactItems {
+			switch {
+			case nil != item.Put:
+				tables[aws.ToString(item.Put.TableName)] = struct{}{}
+			case nil != item.Update:
+				tables[aws.ToString(item.Update.TableName)] = struct{}{}
+			case nil != item.Delete:
+				tables[aws.ToString(item.Delete.TableName)] = struct{}{}
+			case nil != item.ConditionCheck:
+				tables[aws.ToString(item.ConditionCheck.TableName)] = struct{}{}
+			}
+		}
+		return singleTableName(tables)
+	}
+	return ""
+}
+
+// singleTableName returns the only key of the map, or the empty string if the
+// map does not contain exactly one key.
+func singleTableName[V any](tables map[string]V) string {
+	if len(tables) != 1 {
+		return ""
+	}
+	for table := range tables {
+		return table
+	}
+	return ""
+}
+
+// addConsumedCapacityAttributes adds the capacity consumed by a DynamoDB
+// operation to the current span.  Capacity is only returned when the request
+// sets ReturnConsumedCapacity.
+func addConsumedCapacityAttributes(txn *newrelic.Transaction, result interface{}) {
+	var consumed []dynamodbtypes.ConsumedCapacity
+	switch result := result.(type) {
+	case *dynamodb.GetItemOutput:
+		consumed = appendConsumedCapacity(consumed, result.ConsumedCapacity)
+	case *dynamodb.PutItemOutput:
+		consumed = appendConsumedCapacity(consumed, result.ConsumedCapacity)
+	case *dynamodb.UpdateItemOutput:
+		consumed = appendConsumedCapacity(consumed, result.ConsumedCapacity)
+	case *dynamodb.DeleteItemOutput:
+		consumed = appendConsumedCapacity(consumed, result.ConsumedCapacity)
+	case *dynamodb.QueryOutput:
+		consumed = appendConsumedCapacity(consumed, result.ConsumedCapacity)
+	case *dynamodb.ScanOutput:
+		consumed = appendConsumedCapacity(consumed, result.ConsumedCapacity)
+	case *dynamodb.BatchGetItemOutput:
+		consumed = result.ConsumedCapacity
+	case *dynamodb.BatchWriteItemOutput:
+		consumed = result.ConsumedCapacity
+	case *dynamodb.TransactGetItemsOutput:
+		consumed = result.ConsumedCapacity
+	case *dynamodb.TransactWriteItemsOutput:
+		consumed = result.ConsumedCapacity
+	}
+	if len(consumed) == 0 {
+		return
+	}
+
+	var total, read, write float64
+	var hasTotal, hasRead, hasWrite bool
+	for _, c := range consumed {
+		if nil != c.CapacityUnits {
+			total += *c.CapacityUnits
+			hasTotal = true
+		}
+		if nil != c.ReadCapacityUnits {
+			read += *c.ReadCapacityUnits
+			hasRead = true
+		}
+		if nil != c.WriteCapacityUnits {
+			write += *c.WriteCapacityUnits
+			hasWrite = true
+		}
+	}
+	if hasTotal {
+		integrationsupport.AddAgentSpanAttribute(txn,
+			newrelic.SpanAttributeAWSDynamoDBConsumedCapacity, strconv.FormatFloat(total, 'f', -1, 64))
+	}
+	if hasRead {
+		integrationsupport.AddAgentSpanAttribute(txn,
+			newrelic.SpanAttributeAWSDynamoDBConsumedReadCapacity, strconv.FormatFloat(read, 'f', -1, 64))
+	}
+	if hasWrite {
+		integrationsupport.AddAgentSpanAttribute(txn,
+			newrelic.SpanAttributeAWSDynamoDBConsumedWriteCapacity, strconv.FormatFloat(write, 'f', -1, 64))
+	}
+}
+
+func appendConsumedCapacity(consumed []dynamodbtypes.ConsumedCapacity, c *dynamodbtypes.ConsumedCapacity) []dynamodbtypes.ConsumedCapacity {
+	if nil == c {
+		return consumed
+	}
+	return append(consumed, *c)
+}
+
 // AppendMiddlewares inserts New Relic middleware in the given `apiOptions` for
 // the AWS SDK V2 for Go. It must be called only once per AWS configuration.
 //
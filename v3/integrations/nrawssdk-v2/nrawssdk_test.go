@@ -15,6 +15,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/aws/retry"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	dynamodbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 	"github.com/aws/aws-sdk-go-v2/service/lambda"
 	"github.com/aws/aws-sdk-go-v2/service/lambda/types"
 
@@ -169,7 +170,7 @@ var (
 	}
 	datastoreSpan = internal.WantEvent{
 		Intrinsics: map[string]interface{}{
-			"name":          "Datastore/operation/DynamoDB/DescribeTable",
+			"name":          "Datastore/statement/DynamoDB/thebesttable/DescribeTable",
 			"sampled":       true,
 			"category":      "datastore",
 			"priority":      internal.MatchAnything,
@@ -185,7 +186,8 @@ var (
 			"aws.operation":   "DescribeTable",
 			"aws.region":      awsRegion,
 			"aws.requestId":   requestID,
-			"db.statement":    "'DescribeTable' on 'unknown' using 'DynamoDB'",
+			"db.collection":   "thebesttable",
+			"db.statement":    "'DescribeTable' on 'thebesttable' using 'DynamoDB'",
 			"peer.address":    "dynamodb.us-west-2.amazonaws.com:unknown",
 			"peer.hostname":   "dynamodb.us-west-2.amazonaws.com",
 			"http.statusCode": "200",
@@ -212,7 +214,8 @@ var (
 		{Name: "Datastore/allOther", Scope: "", Forced: true, Data: nil},
 		{Name: "Datastore/instance/DynamoDB/dynamodb.us-west-2.amazonaws.com/unknown", Scope: "", Forced: false, Data: nil},
 		{Name: "Datastore/operation/DynamoDB/DescribeTable", Scope: "", Forced: false, Data: nil},
-		{Name: "Datastore/operation/DynamoDB/DescribeTable", Scope: "OtherTransaction/Go/aws-txn", Forced: false, Data: nil},
+		{Name: "Datastore/statement/DynamoDB/thebesttable/DescribeTable", Scope: "", Forced: false, Data: nil},
+		{Name: "Datastore/statement/DynamoDB/thebesttable/DescribeTable", Scope: "OtherTransaction/Go/aws-txn", Forced: false, Data: nil},
 	}...)
 )
 
@@ -862,3 +865,97 @@ func TestNoRequestIDFound(t *testing.T) {
 		},
 	)
 }
+
+type bodyTransport struct {
+	body string
+}
+
+func (t bodyTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	return &http.Response{
+		Status:     "200 OK",
+		StatusCode: 200,
+		Body:       ioutil.NopCloser(bytes.NewReader([]byte(t.body))),
+		Header: http.Header{
+			"X-Amzn-Requestid": []string{requestID},
+		},
+	}, nil
+}
+
+func TestDynamoDBTableName(t *testing.T) {
+	testcases := []struct {
+		input interface{}
+		want  string
+	}{
+		{input: &dynamodb.GetItemInput{TableName: aws.String("users")}, want: "users"},
+		{input: &dynamodb.QueryInput{TableName: aws.String("users")}, want: "users"},
+		{input: &dynamodb.BatchGetItemInput{RequestItems: map[string]dynamodbtypes.KeysAndAttributes{
+			"users": {},
+		}}, want: "users"},
+		{input: &dynamodb.BatchWriteItemInput{RequestItems: map[string][]dynamodbtypes.WriteRequest{
+			"users":  nil,
+			"orders": nil,
+		}}, want: ""},
+		{input: &dynamodb.TransactWriteItemsInput{TransactItems: []dynamodbtypes.TransactWriteItem{
+			{Put: &dynamodbtypes.Put{TableName: aws.String("users")}},
+			{Delete: &dynamodbtypes.Delete{TableName: aws.String("users")}},
+		}}, want: "users"},
+		{input: &dynamodb.ListTablesInput{}, want: ""},
+	}
+	for _, tc := range testcases {
+		if got := dynamoDBTableName(tc.input); got != tc.want {
+			t.Errorf("dynamoDBTableName(%T) = %q, want %q", tc.input, got, tc.want)
+		}
+	}
+}
+
+func TestDynamoDBConsumedCapacity(t *testing.T) {
+	app := testApp()
+	txn := app.StartTransaction(txnName)
+	ctx := context.Background()
+
+	cfg := newConfig(ctx, txn)
+	cfg.HTTPClient = &http.Client{Transport: bodyTransport{
+		body: `{"ConsumedCapacity":{"TableName":"users","CapacityUnits":1.5,"ReadCapacityUnits":1.5}}`,
+	}}
+	client := dynamodb.NewFromConfig(cfg)
+	_, err := client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:              aws.String("users"),
+		Key:                    map[string]dynamodbtypes.AttributeValue{"id": &dynamodbtypes.AttributeValueMemberS{Value: "1"}},
+		ReturnConsumedCapacity: dynamodbtypes.ReturnConsumedCapacityTotal,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	txn.End()
+
+	app.ExpectSpanEvents(t, []internal.WantEvent{
+		{
+			Intrinsics: map[string]interface{}{
+				"name":          "Datastore/statement/DynamoDB/users/GetItem",
+				"sampled":       true,
+				"category":      "datastore",
+				"priority":      internal.MatchAnything,
+				"guid":          internal.MatchAnything,
+				"transactionId": internal.MatchAnything,
+				"traceId":       internal.MatchAnything,
+				"parentId":      internal.MatchAnything,
+				"component":     "DynamoDB",
+				"span.kind":     "client",
+			},
+			UserAttributes: map[string]interface{}{},
+			AgentAttributes: map[string]interface{}{
+				"aws.operation":   "GetItem",
+				"aws.region":      awsRegion,
+				"aws.requestId":   requestID,
+				"db.collection":   "users",
+				"db.statement":    "'GetItem' on 'users' using 'DynamoDB'",
+				"peer.address":    "dynamodb.us-west-2.amazonaws.com:unknown",
+				"peer.hostname":   "dynamodb.us-west-2.amazonaws.com",
+				"http.statusCode": "200",
+				newrelic.SpanAttributeAWSDynamoDBConsumedCapacity:     "1.5",
+				newrelic.SpanAttributeAWSDynamoDBConsumedReadCapacity: "1.5",
+			},
+		},
+		genericSpan,
+	})
+}
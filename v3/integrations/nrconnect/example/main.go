@@ -0,0 +1,70 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/newrelic/go-agent/v3/integrations/nrconnect"
+	"github.com/newrelic/go-agent/v3/newrelic"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+const greetProcedure = "/greet.v1.GreetService/Greet"
+
+func greet(ctx context.Context, req *connect.Request[wrapperspb.StringValue]) (*connect.Response[wrapperspb.StringValue], error) {
+	if req.Msg.Value == "" {
+		// Noticed with the error class "invalid_argument".
+		return nil, connect.NewError(connect.CodeInvalidArgument, errors.New("name is required"))
+	}
+	// The transaction is in the context, and can be used to create
+	// segments.
+	defer newrelic.FromContext(ctx).StartSegment("compose").End()
+	time.Sleep(10 * time.Millisecond)
+	return connect.NewResponse(wrapperspb.String("Hello, " + req.Msg.Value)), nil
+}
+
+func main() {
+	app, err := newrelic.NewApplication(
+		newrelic.ConfigAppName("Connect App"),
+		newrelic.ConfigLicense(os.Getenv("NEW_RELIC_LICENSE_KEY")),
+		newrelic.ConfigDebugLogger(os.Stdout),
+	)
+	if nil != err {
+		panic(err)
+	}
+	interceptor := nrconnect.NewInterceptor(app)
+
+	mux := http.NewServeMux()
+	mux.Handle(greetProcedure, connect.NewUnaryHandler(greetProcedure, greet,
+		connect.WithInterceptors(interceptor)))
+
+	// The client calls the server every few seconds inside of a
+	// transaction, to show distributed tracing between the two.
+	go func() {
+		client := connect.NewClient[wrapperspb.StringValue, wrapperspb.StringValue](
+			http.DefaultClient, "http://localhost:8000"+greetProcedure,
+			connect.WithInterceptors(interceptor))
+		for {
+			time.Sleep(5 * time.Second)
+			txn := app.StartTransaction("greet")
+			resp, err := client.CallUnary(newrelic.NewContext(context.Background(), txn),
+				connect.NewRequest(wrapperspb.String("gopher")))
+			txn.End()
+			if nil != err {
+				fmt.Println(err)
+				continue
+			}
+			fmt.Println(resp.Msg.Value)
+		}
+	}()
+
+	http.ListenAndServe(":8000", mux)
+}
@@ -0,0 +1,21 @@
+module github.com/newrelic/go-agent/v3/integrations/nrconnect
+
+// As of v1.16.2, the connectrpc.com/connect go.mod file uses 1.20:
+// https://github.com/connectrpc/connect-go/blob/main/go.mod
+go 1.21
+
+require (
+	connectrpc.com/connect v1.16.2
+	github.com/newrelic/go-agent/v3 v3.35.0
+	google.golang.org/protobuf v1.34.2
+)
+
+require (
+	golang.org/x/net v0.25.0 // indirect
+	golang.org/x/sys v0.20.0 // indirect
+	golang.org/x/text v0.15.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20240528184218-531527333157 // indirect
+	google.golang.org/grpc v1.65.0 // indirect
+)
+
+replace github.com/newrelic/go-agent/v3 => ../..
@@ -0,0 +1,246 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package nrconnect instruments https://github.com/connectrpc/connect-go.
+//
+// Use this package to instrument Connect clients and handlers.  The
+// interceptor returned by NewInterceptor instruments both, and is added using
+// connect.WithInterceptors:
+//
+//	interceptor := nrconnect.NewInterceptor(app)
+//	mux.Handle(greetv1connect.NewGreetServiceHandler(&greeter{},
+//		connect.WithInterceptors(interceptor)))
+//
+//	client := greetv1connect.NewGreetServiceClient(http.DefaultClient,
+//		"http://localhost:8080", connect.WithInterceptors(interceptor))
+//
+// Each call handled by a handler is recorded as a web transaction named after
+// the procedure, for example "WebTransaction/Go/greet.v1.GreetService/Greet".
+// Inbound distributed tracing headers are accepted, and the transaction is
+// added to the context passed to the handler.  If the context already
+// contains a transaction, for example because the handler was wrapped using
+// newrelic.WrapHandle, that transaction is named after the procedure instead.
+// Errors returned by the handler are noticed with the Connect error code, for
+// example "not_found", as their class.
+//
+// Calls made by a client with a context containing a transaction are recorded
+// as external segments, and distributed tracing headers are added to the
+// request.  The segments have the span attributes defined by the OpenTelemetry
+// semantic conventions for RPC spans: "rpc.system", "rpc.service", and
+// "rpc.method", and "rpc.connect_rpc.error_code" if the call fails.  The
+// response code of the segment is the HTTP status code which the Connect
+// protocol uses for the error code of the call, or 200 if the call succeeds.
+//
+// Full example:
+// https://github.com/newrelic/go-agent/blob/master/v3/integrations/nrconnect/example/main.go
+package nrconnect
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"connectrpc.com/connect"
+	"github.com/newrelic/go-agent/v3/internal"
+	"github.com/newrelic/go-agent/v3/internal/integrationsupport"
+	"github.com/newrelic/go-agent/v3/newrelic"
+)
+
+func init() { internal.TrackUsage("integration", "framework", "connect") }
+
+// NewInterceptor returns a connect.Interceptor which instruments Connect
+// clients and handlers.  Handlers are only instrumented if app is not nil.
+func NewInterceptor(app *newrelic.Application) connect.Interceptor {
+	return &interceptor{app: app}
+}
+
+type interceptor struct {
+	app *newrelic.Application
+}
+
+func (i *interceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		if req.Spec().IsClient {
+			txn := newrelic.FromContext(ctx)
+			if nil == txn {
+				return next(ctx, req)
+			}
+			seg := startClientSegment(txn, req.Spec(), req.Peer(), req.Header())
+			resp, err := next(ctx, req)
+			endClientSegment(txn, seg, err)
+			return resp, err
+		}
+		txn, ctx, end := i.startTransaction(ctx, req.Spec(), req.Header(), req.HTTPMethod())
+		defer end()
+		resp, err := next(ctx, req)
+		noticeError(txn, err)
+		return resp, err
+	}
+}
+
+func (i *interceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return func(ctx context.Context, spec connect.Spec) connect.StreamingClientConn {
+		conn := next(ctx, spec)
+		txn := newrelic.FromContext(ctx)
+		if nil == txn {
+			return conn
+		}
+		return &streamingClientConn{
+			StreamingClientConn: conn,
+			txn:                 txn,
+			seg:                 startClientSegment(txn, spec, conn.Peer(), conn.RequestHeader()),
+		}
+	}
+}
+
+func (i *interceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return func(ctx context.Context, conn connect.StreamingHandlerConn) error {
+		txn, ctx, end := i.startTransaction(ctx, conn.Spec(), conn.RequestHeader(), http.MethodPost)
+		defer end()
+		err := next(ctx, conn)
+		noticeError(txn, err)
+		return err
+	}
+}
+
+// startTransaction returns the transaction recording a call handled by a
+// handler, the context containing it, and the function which ends it.
+func (i *interceptor) startTransaction(ctx context.Context, spec connect.Spec, hdr http.Header, method string) (*newrelic.Transaction, context.Context, func()) {
+	name := strings.TrimPrefix(spec.Procedure, "/")
+	if txn := newrelic.FromContext(ctx); nil != txn {
+		txn.SetName(name)
+		return txn, ctx, func() {}
+	}
+	if nil == i.app {
+		return nil, ctx, func() {}
+	}
+	txn := i.app.StartTransaction(name)
+	txn.SetWebRequest(newrelic.WebRequest{
+		Header:    hdr,
+		URL:       &url.URL{Path: spec.Procedure},
+		Method:    method,
+		Transport: newrelic.TransportHTTP,
+	})
+	return txn, newrelic.NewContext(ctx, txn), txn.End
+}
+
+// noticeError notices the error returned by a handler, using its Connect
+// error code as the error class.
+func noticeError(txn *newrelic.Transaction, err error) {
+	if nil == txn || nil == err {
+		return
+	}
+	txn.NoticeError(newrelic.Error{
+		Message: err.Error(),
+		Class:   connect.CodeOf(err).String(),
+	})
+}
+
+// startClientSegment starts the external segment recording a call made by a
+// client, and adds distributed tracing headers to the request headers.
+func startClientSegment(txn *newrelic.Transaction, spec connect.Spec, peer connect.Peer, hdr http.Header) *newrelic.ExternalSegment {
+	procedure := strings.TrimPrefix(spec.Procedure, "/")
+	seg := newrelic.StartExternalSegment(txn, nil)
+	seg.Host = peer.Addr
+	seg.Library = "Connect"
+	seg.Procedure = procedure
+
+	txn.InsertDistributedTraceHeaders(hdr)
+
+	service, method := splitProcedure(procedure)
+	integrationsupport.AddAgentSpanAttribute(txn, newrelic.SpanAttributeRPCSystem, rpcSystem(peer.Protocol))
+	integrationsupport.AddAgentSpanAttribute(txn, newrelic.SpanAttributeRPCService, service)
+	integrationsupport.AddAgentSpanAttribute(txn, newrelic.SpanAttributeRPCMethod, method)
+	return seg
+}
+
+// endClientSegment records the result of a call made by a client on its
+// external segment, which is then ended.
+func endClientSegment(txn *newrelic.Transaction, seg *newrelic.ExternalSegment, err error) {
+	code := http.StatusOK
+	if nil != err {
+		c := connect.CodeOf(err)
+		integrationsupport.AddAgentSpanAttribute(txn, newrelic.SpanAttributeRPCConnectErrorCode, c.String())
+		code = httpStatus(c)
+	}
+	seg.SetStatusCode(code)
+	seg.End()
+}
+
+// splitProcedure splits a procedure in the form "<service>/<method>" into
+// the fully qualified name of the service and the name of the method.
+func splitProcedure(procedure string) (string, string) {
+	if i := strings.LastIndex(procedure, "/"); i >= 0 {
+		return procedure[:i], procedure[i+1:]
+	}
+	return "", procedure
+}
+
+// rpcSystem returns the OpenTelemetry name of the protocol of a call.
+func rpcSystem(protocol string) string {
+	switch protocol {
+	case connect.ProtocolConnect:
+		return "connect_rpc"
+	case connect.ProtocolGRPC:
+		return "grpc"
+	case connect.ProtocolGRPCWeb:
+		return "grpc_web"
+	}
+	return protocol
+}
+
+// httpStatus returns the HTTP status code used by the Connect protocol for
+// the error code.
+func httpStatus(code connect.Code) int {
+	switch code {
+	case connect.CodeCanceled:
+		return 499
+	case connect.CodeInvalidArgument, connect.CodeFailedPrecondition, connect.CodeOutOfRange:
+		return http.StatusBadRequest
+	case connect.CodeDeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case connect.CodeNotFound:
+		return http.StatusNotFound
+	case connect.CodeAlreadyExists, connect.CodeAborted:
+		return http.StatusConflict
+	case connect.CodePermissionDenied:
+		return http.StatusForbidden
+	case connect.CodeResourceExhausted:
+		return http.StatusTooManyRequests
+	case connect.CodeUnimplemented:
+		return http.StatusNotImplemented
+	case connect.CodeUnavailable:
+		return http.StatusServiceUnavailable
+	case connect.CodeUnauthenticated:
+		return http.StatusUnauthorized
+	}
+	return http.StatusInternalServerError
+}
+
+// streamingClientConn ends the external segment of a streaming call when the
+// response is closed.
+type streamingClientConn struct {
+	connect.StreamingClientConn
+	txn  *newrelic.Transaction
+	seg  *newrelic.ExternalSegment
+	once sync.Once
+	err  error
+}
+
+func (c *streamingClientConn) Receive(msg any) error {
+	err := c.StreamingClientConn.Receive(msg)
+	if nil != err && !errors.Is(err, io.EOF) {
+		c.err = err
+	}
+	return err
+}
+
+func (c *streamingClientConn) CloseResponse() error {
+	err := c.StreamingClientConn.CloseResponse()
+	c.once.Do(func() { endClientSegment(c.txn, c.seg, c.err) })
+	return err
+}
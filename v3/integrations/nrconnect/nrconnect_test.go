@@ -0,0 +1,221 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package nrconnect
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"connectrpc.com/connect"
+	"github.com/newrelic/go-agent/v3/internal"
+	"github.com/newrelic/go-agent/v3/internal/integrationsupport"
+	"github.com/newrelic/go-agent/v3/newrelic"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+const echoProcedure = "/test.v1.EchoService/Echo"
+
+func echo(ctx context.Context, req *connect.Request[wrapperspb.StringValue]) (*connect.Response[wrapperspb.StringValue], error) {
+	if req.Msg.Value == "" {
+		return nil, connect.NewError(connect.CodeNotFound, errors.New("nothing to echo"))
+	}
+	return connect.NewResponse(wrapperspb.String(req.Msg.Value)), nil
+}
+
+func replyFn(reply *internal.ConnectReply) {
+	reply.SetSampleEverything()
+	reply.AccountID = "123"
+	reply.TrustedAccountKey = "123"
+	reply.PrimaryAppID = "456"
+}
+
+func testApp() integrationsupport.ExpectApp {
+	return integrationsupport.NewTestApp(replyFn,
+		newrelic.ConfigCodeLevelMetricsEnabled(false),
+		newrelic.ConfigDistributedTracerEnabled(true))
+}
+
+func newServer(t *testing.T, opts ...connect.HandlerOption) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.Handle(echoProcedure, connect.NewUnaryHandler(echoProcedure, echo, opts...))
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newClient(srv *httptest.Server, opts ...connect.ClientOption) *connect.Client[wrapperspb.StringValue, wrapperspb.StringValue] {
+	return connect.NewClient[wrapperspb.StringValue, wrapperspb.StringValue](srv.Client(), srv.URL+echoProcedure, opts...)
+}
+
+func clientSpanEvents(srv *httptest.Server, agentAttributes map[string]interface{}) []internal.WantEvent {
+	return []internal.WantEvent{
+		{
+			Intrinsics: map[string]interface{}{
+				"category":  "http",
+				"component": "Connect",
+				"name":      "External/" + srv.Listener.Addr().String() + "/Connect/test.v1.EchoService/Echo",
+				"parentId":  internal.MatchAnything,
+				"span.kind": "client",
+			},
+			UserAttributes:  map[string]interface{}{},
+			AgentAttributes: agentAttributes,
+		},
+		{
+			Intrinsics: map[string]interface{}{
+				"category":         "generic",
+				"name":             "OtherTransaction/Go/client",
+				"transaction.name": "OtherTransaction/Go/client",
+				"nr.entryPoint":    true,
+			},
+			UserAttributes:  map[string]interface{}{},
+			AgentAttributes: map[string]interface{}{},
+		},
+	}
+}
+
+func TestClientUnary(t *testing.T) {
+	var traceparent string
+	srv := newServer(t, connect.WithInterceptors(connect.UnaryInterceptorFunc(func(next connect.UnaryFunc) connect.UnaryFunc {
+		return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+			traceparent = req.Header().Get("traceparent")
+			return next(ctx, req)
+		}
+	})))
+
+	app := testApp()
+	client := newClient(srv, connect.WithInterceptors(NewInterceptor(app.Application)))
+	txn := app.StartTransaction("client")
+	ctx := newrelic.NewContext(context.Background(), txn)
+	if _, err := client.CallUnary(ctx, connect.NewRequest(wrapperspb.String("hello"))); nil != err {
+		t.Fatal(err)
+	}
+	txn.End()
+
+	if "" == traceparent {
+		t.Error("distributed tracing headers missing")
+	}
+	app.ExpectMetricsPresent(t, []internal.WantMetric{
+		{Name: "External/all", Scope: "", Forced: true, Data: []float64{1}},
+		{Name: "External/" + srv.Listener.Addr().String() + "/Connect/test.v1.EchoService/Echo", Scope: "OtherTransaction/Go/client", Forced: false, Data: []float64{1}},
+	})
+	app.ExpectSpanEvents(t, clientSpanEvents(srv, map[string]interface{}{
+		"rpc.system":      "connect_rpc",
+		"rpc.service":     "test.v1.EchoService",
+		"rpc.method":      "Echo",
+		"http.statusCode": 200,
+	}))
+}
+
+func TestClientUnaryError(t *testing.T) {
+	srv := newServer(t)
+	app := testApp()
+	client := newClient(srv, connect.WithInterceptors(NewInterceptor(app.Application)))
+	txn := app.StartTransaction("client")
+	ctx := newrelic.NewContext(context.Background(), txn)
+	_, err := client.CallUnary(ctx, connect.NewRequest(wrapperspb.String("")))
+	if connect.CodeOf(err) != connect.CodeNotFound {
+		t.Fatal(err)
+	}
+	txn.End()
+
+	app.ExpectSpanEvents(t, clientSpanEvents(srv, map[string]interface{}{
+		"rpc.system":                 "connect_rpc",
+		"rpc.service":                "test.v1.EchoService",
+		"rpc.method":                 "Echo",
+		"rpc.connect_rpc.error_code": "not_found",
+		"http.statusCode":            404,
+	}))
+}
+
+func TestClientGRPCProtocol(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.Handle(echoProcedure, connect.NewUnaryHandler(echoProcedure, echo))
+	srv := httptest.NewUnstartedServer(mux)
+	srv.EnableHTTP2 = true
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+
+	app := testApp()
+	client := newClient(srv, connect.WithGRPC(), connect.WithInterceptors(NewInterceptor(app.Application)))
+	txn := app.StartTransaction("client")
+	ctx := newrelic.NewContext(context.Background(), txn)
+	if _, err := client.CallUnary(ctx, connect.NewRequest(wrapperspb.String("hello"))); nil != err {
+		t.Fatal(err)
+	}
+	txn.End()
+
+	app.ExpectSpanEvents(t, clientSpanEvents(srv, map[string]interface{}{
+		"rpc.system":      "grpc",
+		"rpc.service":     "test.v1.EchoService",
+		"rpc.method":      "Echo",
+		"http.statusCode": 200,
+	}))
+}
+
+func TestClientNoTransaction(t *testing.T) {
+	srv := newServer(t)
+	client := newClient(srv, connect.WithInterceptors(NewInterceptor(nil)))
+	if _, err := client.CallUnary(context.Background(), connect.NewRequest(wrapperspb.String("hello"))); nil != err {
+		t.Fatal(err)
+	}
+}
+
+func TestHandlerUnary(t *testing.T) {
+	app := testApp()
+	srv := newServer(t, connect.WithInterceptors(NewInterceptor(app.Application)))
+	client := newClient(srv)
+
+	if _, err := client.CallUnary(context.Background(), connect.NewRequest(wrapperspb.String("hello"))); nil != err {
+		t.Fatal(err)
+	}
+	if _, err := client.CallUnary(context.Background(), connect.NewRequest(wrapperspb.String(""))); nil == err {
+		t.Fatal("missing error")
+	}
+	app.ExpectMetricsPresent(t, []internal.WantMetric{
+		{Name: "WebTransaction/Go/test.v1.EchoService/Echo", Scope: "", Forced: true, Data: []float64{2}},
+		{Name: "Errors/WebTransaction/Go/test.v1.EchoService/Echo", Scope: "", Forced: true, Data: []float64{1}},
+	})
+	app.ExpectErrorEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"error.class":     "not_found",
+			"error.message":   "not_found: nothing to echo",
+			"transactionName": "WebTransaction/Go/test.v1.EchoService/Echo",
+			"guid":            internal.MatchAnything,
+			"priority":        internal.MatchAnything,
+			"sampled":         internal.MatchAnything,
+			"spanId":          internal.MatchAnything,
+			"traceId":         internal.MatchAnything,
+		},
+	}})
+}
+
+func TestSplitProcedure(t *testing.T) {
+	for procedure, want := range map[string][2]string{
+		"test.v1.EchoService/Echo": {"test.v1.EchoService", "Echo"},
+		"Echo":                     {"", "Echo"},
+	} {
+		if service, method := splitProcedure(procedure); service != want[0] || method != want[1] {
+			t.Errorf("splitProcedure(%q) = %q, %q", procedure, service, method)
+		}
+	}
+}
+
+func TestHTTPStatus(t *testing.T) {
+	for code, want := range map[connect.Code]int{
+		connect.CodeCanceled:         499,
+		connect.CodeUnknown:          500,
+		connect.CodeInvalidArgument:  400,
+		connect.CodeNotFound:         404,
+		connect.CodeUnauthenticated:  401,
+		connect.CodeUnavailable:      503,
+		connect.CodeDeadlineExceeded: 504,
+	} {
+		if got := httpStatus(code); got != want {
+			t.Errorf("httpStatus(%s) = %d, want %d", code, got, want)
+		}
+	}
+}
@@ -20,6 +20,7 @@ import (
 
 	"github.com/labstack/echo"
 	"github.com/newrelic/go-agent/v3/internal"
+	"github.com/newrelic/go-agent/v3/internal/errorutil"
 	newrelic "github.com/newrelic/go-agent/v3/newrelic"
 )
 
@@ -62,6 +63,15 @@ func transactionName(c echo.Context) (string, string) {
 	return c.Request().Method + " " + c.Path(), c.Path()
 }
 
+// statusCode returns the response code of an error returned by a handler,
+// mimicking the logic of echo.DefaultHTTPErrorHandler.
+func statusCode(err error) int {
+	if httperr, ok := err.(*echo.HTTPError); ok {
+		return httperr.Code
+	}
+	return http.StatusInternalServerError
+}
+
 // Middleware creates Echo middleware that instruments requests.
 //
 //	e := echo.New()
@@ -99,11 +109,7 @@ func Middleware(app *newrelic.Application) func(echo.HandlerFunc) echo.HandlerFu
 
 				c.Response().Writer = rw
 
-				if httperr, ok := err.(*echo.HTTPError); ok {
-					txn.SetWebResponse(nil).WriteHeader(httperr.Code)
-				} else {
-					txn.SetWebResponse(nil).WriteHeader(http.StatusInternalServerError)
-				}
+				errorutil.NoticeError(txn, statusCode(err), nil, err)
 				if newrelic.IsSecurityAgentPresent() {
 					newrelic.GetSecurityAgentInterface().SendEvent("RESPONSE_HEADER", c.Response().Header())
 				}
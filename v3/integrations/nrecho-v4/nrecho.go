@@ -20,6 +20,7 @@ import (
 
 	"github.com/labstack/echo/v4"
 	"github.com/newrelic/go-agent/v3/internal"
+	"github.com/newrelic/go-agent/v3/internal/errorutil"
 	newrelic "github.com/newrelic/go-agent/v3/newrelic"
 )
 
@@ -65,6 +66,15 @@ func WithSkipper(skipper Skipper) ConfigOption {
 	return func(cfg *Config) { cfg.Skipper = skipper }
 }
 
+// statusCode returns the response code of an error returned by a handler,
+// mimicking the logic of echo.DefaultHTTPErrorHandler.
+func statusCode(err error) int {
+	if httperr, ok := err.(*echo.HTTPError); ok {
+		return httperr.Code
+	}
+	return http.StatusInternalServerError
+}
+
 // Middleware creates Echo middleware with provided config that
 // instruments requests.
 //
@@ -122,11 +132,7 @@ func Middleware(app *newrelic.Application, opts ...ConfigOption) func(echo.Handl
 
 				c.Response().Writer = rw
 
-				if httperr, ok := err.(*echo.HTTPError); ok {
-					txn.SetWebResponse(nil).WriteHeader(httperr.Code)
-				} else {
-					txn.SetWebResponse(nil).WriteHeader(http.StatusInternalServerError)
-				}
+				errorutil.NoticeError(txn, statusCode(err), nil, err)
 				if newrelic.IsSecurityAgentPresent() {
 					newrelic.GetSecurityAgentInterface().SendEvent("RESPONSE_HEADER", c.Response().Header())
 				}
@@ -0,0 +1,45 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package nrecho
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/newrelic/go-agent/v3/internal/integrationsupport"
+	"github.com/newrelic/go-agent/v3/newrelic"
+)
+
+// RouteCatalogEventType is the type of the custom events recorded by
+// ReportRouteCatalog, one for each route registered with the Echo instance.
+const RouteCatalogEventType = integrationsupport.RouteCatalogEventType
+
+// ReportRouteCatalog reports the catalog of the routes registered with the
+// Echo instance, so that New Relic knows the endpoints of the application,
+// including those which are never requested.  Call it once every route is
+// registered, before starting the server:
+//
+//	e := echo.New()
+//	e.Use(nrecho.Middleware(app))
+//	e.GET("/users/:id", getUser)
+//	nrecho.ReportRouteCatalog(app, e)
+//	e.Start(":8000")
+//
+// Once the application is connected, each route is recorded as a
+// "HttpRouteCatalog" custom event with the "framework", "method", and
+// "pattern" attributes.  The pattern is the path of the route, as used by
+// Middleware to name the transactions of the route.  Only the methods and
+// patterns of the routes are reported.
+func ReportRouteCatalog(app *newrelic.Application, e *echo.Echo) {
+	if app == nil || e == nil {
+		return
+	}
+	integrationsupport.ReportCatalog(app, "echo route catalog", RouteCatalogEventType, routeCatalog(e.Routes()))
+}
+
+func routeCatalog(routes []*echo.Route) []map[string]interface{} {
+	catalog := make([]integrationsupport.Route, len(routes))
+	for i, r := range routes {
+		catalog[i] = integrationsupport.Route{Method: r.Method, Pattern: r.Path}
+	}
+	return integrationsupport.RouteCatalog("echo", catalog)
+}
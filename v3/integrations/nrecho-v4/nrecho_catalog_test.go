@@ -0,0 +1,34 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package nrecho
+
+import (
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/newrelic/go-agent/v3/internal"
+	"github.com/newrelic/go-agent/v3/internal/integrationsupport"
+)
+
+func TestRecordRouteCatalog(t *testing.T) {
+	app := integrationsupport.NewBasicTestApp()
+	e := echo.New()
+	e.Use(Middleware(app.Application))
+	handler := func(c echo.Context) error { return nil }
+	e.POST("/users", handler)
+	e.GET("/users", handler)
+	e.GET("/users/:id", handler)
+
+	integrationsupport.RecordCatalog(app.Application, RouteCatalogEventType, routeCatalog(e.Routes()))
+	app.ExpectCustomEvents(t, []internal.WantEvent{
+		integrationsupport.WantRouteCatalogEvent("echo", "GET", "/users"),
+		integrationsupport.WantRouteCatalogEvent("echo", "POST", "/users"),
+		integrationsupport.WantRouteCatalogEvent("echo", "GET", "/users/:id"),
+	})
+}
+
+func TestReportRouteCatalogNil(t *testing.T) {
+	ReportRouteCatalog(nil, echo.New())
+	ReportRouteCatalog(integrationsupport.NewBasicTestApp().Application, nil)
+}
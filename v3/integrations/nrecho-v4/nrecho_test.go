@@ -259,6 +259,11 @@ func TestReturnsHTTPError(t *testing.T) {
 		},
 		UserAttributes: map[string]interface{}{},
 	}})
+	app.ExpectErrors(t, []internal.WantError{{
+		TxnName: "WebTransaction/Go/GET /hello",
+		Msg:     "code=418, message=I'm a teapot!",
+		Klass:   "418",
+	}})
 }
 
 func TestReturnsError(t *testing.T) {
@@ -301,6 +306,11 @@ func TestReturnsError(t *testing.T) {
 		},
 		UserAttributes: map[string]interface{}{},
 	}})
+	app.ExpectErrors(t, []internal.WantError{{
+		TxnName: "WebTransaction/Go/GET /hello",
+		Msg:     "ooooooooops",
+		Klass:   "500",
+	}})
 }
 
 func TestResponseCode(t *testing.T) {
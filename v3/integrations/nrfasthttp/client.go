@@ -0,0 +1,96 @@
+package nrfasthttp
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/newrelic/go-agent/v3/newrelic"
+	"github.com/valyala/fasthttp"
+)
+
+// Doer is implemented by the fasthttp clients, such as fasthttp.Client,
+// fasthttp.HostClient, and fasthttp.PipelineClient.
+type Doer interface {
+	Do(req *fasthttp.Request, resp *fasthttp.Response) error
+}
+
+// Do performs the request using the client, recording it as an external
+// segment of the transaction.  Distributed tracing headers are added to the
+// request, and the status code of the response is recorded on the segment.
+//
+//	req := fasthttp.AcquireRequest()
+//	resp := fasthttp.AcquireResponse()
+//	defer fasthttp.ReleaseRequest(req)
+//	defer fasthttp.ReleaseResponse(resp)
+//	req.SetRequestURI("http://example.com")
+//	err := nrfasthttp.Do(txn, &fasthttp.Client{}, req, resp)
+func Do(txn *newrelic.Transaction, client Doer, req *fasthttp.Request, resp *fasthttp.Response) error {
+	seg := startClientSegment(txn, req)
+	err := client.Do(req, resp)
+	if err == nil {
+		seg.SetStatusCode(resp.StatusCode())
+	}
+	seg.End()
+	return err
+}
+
+// Client wraps a fasthttp.Client so that the requests it performs are recorded
+// as external segments of the transaction passed to each call.
+type Client struct {
+	client *fasthttp.Client
+}
+
+// WrapClient wraps the fasthttp.Client.  If client is nil, a fasthttp.Client
+// with the default settings is used.
+func WrapClient(client *fasthttp.Client) *Client {
+	if client == nil {
+		client = &fasthttp.Client{}
+	}
+	return &Client{client: client}
+}
+
+// Do performs the request using fasthttp.Client.Do, recording it as an
+// external segment of the transaction.
+func (c *Client) Do(txn *newrelic.Transaction, req *fasthttp.Request, resp *fasthttp.Response) error {
+	return Do(txn, c.client, req, resp)
+}
+
+// DoTimeout performs the request using fasthttp.Client.DoTimeout, recording it
+// as an external segment of the transaction.
+func (c *Client) DoTimeout(txn *newrelic.Transaction, req *fasthttp.Request, resp *fasthttp.Response, timeout time.Duration) error {
+	return Do(txn, doerFunc(func(req *fasthttp.Request, resp *fasthttp.Response) error {
+		return c.client.DoTimeout(req, resp, timeout)
+	}), req, resp)
+}
+
+// Unwrap returns the wrapped fasthttp.Client.
+func (c *Client) Unwrap() *fasthttp.Client {
+	return c.client
+}
+
+type doerFunc func(req *fasthttp.Request, resp *fasthttp.Response) error
+
+func (f doerFunc) Do(req *fasthttp.Request, resp *fasthttp.Response) error {
+	return f(req, resp)
+}
+
+// startClientSegment starts an external segment for the request and adds the
+// distributed tracing headers to it.
+func startClientSegment(txn *newrelic.Transaction, req *fasthttp.Request) *newrelic.ExternalSegment {
+	r := &http.Request{
+		Method: string(req.Header.Method()),
+		Header: http.Header{},
+	}
+	if u, err := url.Parse(req.URI().String()); err == nil {
+		r.URL = u
+		r.Host = u.Host
+	}
+	seg := newrelic.StartExternalSegment(txn, r)
+	for key, values := range r.Header {
+		for _, value := range values {
+			req.Header.Set(key, value)
+		}
+	}
+	return seg
+}
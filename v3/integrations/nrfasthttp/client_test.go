@@ -0,0 +1,147 @@
+package nrfasthttp
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/newrelic/go-agent/v3/internal"
+	"github.com/newrelic/go-agent/v3/internal/integrationsupport"
+	"github.com/valyala/fasthttp"
+	"github.com/valyala/fasthttp/fasthttputil"
+)
+
+func dtReplyFn(reply *internal.ConnectReply) {
+	reply.SetSampleEverything()
+	reply.AccountID = "123"
+	reply.TrustedAccountKey = "123"
+	reply.PrimaryAppID = "456"
+}
+
+// newTestClient returns a client connected to an in-memory server which
+// responds with a 418 and echoes the traceparent header of the request.
+func newTestClient(t *testing.T) *fasthttp.Client {
+	ln := fasthttputil.NewInmemoryListener()
+	srv := &fasthttp.Server{Handler: func(ctx *fasthttp.RequestCtx) {
+		ctx.SetStatusCode(fasthttp.StatusTeapot)
+		ctx.SetBody(ctx.Request.Header.Peek("traceparent"))
+	}}
+	go srv.Serve(ln)
+	t.Cleanup(func() { ln.Close() })
+	return &fasthttp.Client{
+		Dial: func(addr string) (net.Conn, error) { return ln.Dial() },
+	}
+}
+
+func newTestRequest() (*fasthttp.Request, *fasthttp.Response) {
+	req := fasthttp.AcquireRequest()
+	req.SetRequestURI("http://example.com/hello")
+	req.Header.SetMethod("GET")
+	return req, fasthttp.AcquireResponse()
+}
+
+var clientMetrics = []internal.WantMetric{
+	{Name: "External/all", Scope: "", Forced: true, Data: nil},
+	{Name: "External/allOther", Scope: "", Forced: true, Data: nil},
+	{Name: "External/example.com/all", Scope: "", Forced: false, Data: nil},
+	{Name: "External/example.com/http/GET", Scope: "OtherTransaction/Go/myTxn", Forced: false, Data: nil},
+}
+
+func TestDo(t *testing.T) {
+	app := integrationsupport.NewTestApp(dtReplyFn, integrationsupport.DTEnabledCfgFn)
+	client := newTestClient(t)
+
+	txn := app.StartTransaction("myTxn")
+	req, resp := newTestRequest()
+	defer fasthttp.ReleaseRequest(req)
+	defer fasthttp.ReleaseResponse(resp)
+
+	if err := Do(txn, client, req, resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Body()) == 0 {
+		t.Error("distributed tracing headers were not added to the request")
+	}
+	txn.End()
+
+	app.ExpectMetricsPresent(t, clientMetrics)
+	app.ExpectSpanEvents(t, []internal.WantEvent{
+		{
+			Intrinsics: map[string]interface{}{
+				"name":          "External/example.com/http/GET",
+				"category":      "http",
+				"component":     "http",
+				"span.kind":     "client",
+				"parentId":      internal.MatchAnything,
+				"transactionId": internal.MatchAnything,
+			},
+			AgentAttributes: map[string]interface{}{
+				"http.url":        "http://example.com/hello",
+				"http.method":     "GET",
+				"http.statusCode": 418,
+			},
+		},
+		{
+			Intrinsics: map[string]interface{}{
+				"name":             "OtherTransaction/Go/myTxn",
+				"category":         "generic",
+				"nr.entryPoint":    true,
+				"transaction.name": "OtherTransaction/Go/myTxn",
+				"transactionId":    internal.MatchAnything,
+			},
+		},
+	})
+}
+
+func TestClientDoTimeout(t *testing.T) {
+	app := integrationsupport.NewTestApp(dtReplyFn, integrationsupport.DTEnabledCfgFn)
+	client := WrapClient(newTestClient(t))
+
+	txn := app.StartTransaction("myTxn")
+	req, resp := newTestRequest()
+	defer fasthttp.ReleaseRequest(req)
+	defer fasthttp.ReleaseResponse(resp)
+
+	if err := client.DoTimeout(txn, req, resp, time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode() != fasthttp.StatusTeapot {
+		t.Error("incorrect status code:", resp.StatusCode())
+	}
+	txn.End()
+
+	app.ExpectMetricsPresent(t, clientMetrics)
+}
+
+func TestClientDoError(t *testing.T) {
+	app := integrationsupport.NewTestApp(dtReplyFn, integrationsupport.DTEnabledCfgFn)
+	client := WrapClient(&fasthttp.Client{
+		Dial: func(addr string) (net.Conn, error) { return nil, errors.New("dial failed") },
+	})
+
+	txn := app.StartTransaction("myTxn")
+	req, resp := newTestRequest()
+	defer fasthttp.ReleaseRequest(req)
+	defer fasthttp.ReleaseResponse(resp)
+
+	if err := client.Do(txn, req, resp); err == nil {
+		t.Error("expected an error")
+	}
+	txn.End()
+
+	app.ExpectMetricsPresent(t, clientMetrics)
+}
+
+func TestDoNilTransaction(t *testing.T) {
+	req, resp := newTestRequest()
+	defer fasthttp.ReleaseRequest(req)
+	defer fasthttp.ReleaseResponse(resp)
+
+	if err := Do(nil, newTestClient(t), req, resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Body()) != 0 {
+		t.Error("unexpected distributed tracing headers:", string(resp.Body()))
+	}
+}
@@ -12,6 +12,8 @@ import (
 	"github.com/valyala/fasthttp"
 )
 
+var client = nrfasthttp.WrapClient(&fasthttp.Client{})
+
 func doRequest(txn *newrelic.Transaction) error {
 	req := fasthttp.AcquireRequest()
 	resp := fasthttp.AcquireResponse()
@@ -21,10 +23,7 @@ func doRequest(txn *newrelic.Transaction) error {
 	req.SetRequestURI("http://localhost:8080/hello")
 	req.Header.SetMethod("GET")
 
-	seg := nrfasthttp.StartExternalSegment(txn, req)
-	defer seg.End()
-
-	err := fasthttp.Do(req, resp)
+	err := client.Do(txn, req, resp)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,51 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/gofiber/fiber/v3"
+	nrfiber "github.com/newrelic/go-agent/v3/integrations/nrfiber-v3"
+	"github.com/newrelic/go-agent/v3/newrelic"
+)
+
+func getUser(c fiber.Ctx) error {
+	id := c.Params("id")
+
+	txn := nrfiber.FromContext(c)
+	txn.AddAttribute("userId", id)
+
+	return c.SendString(id)
+}
+
+func main() {
+	nrApp, err := newrelic.NewApplication(
+		newrelic.ConfigAppName("Fiber App"),
+		newrelic.ConfigLicense(os.Getenv("NEW_RELIC_LICENSE_KEY")),
+		newrelic.ConfigDebugLogger(os.Stdout),
+	)
+	if nil != err {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	app := fiber.New()
+
+	// The New Relic Middleware should be the first middleware registered
+	app.Use(nrfiber.Middleware(nrApp))
+
+	// Routes
+	app.Get("/home", func(c fiber.Ctx) error {
+		return c.SendString("Hello, World!")
+	})
+
+	// Groups
+	g := app.Group("/user")
+	g.Get("/:id", getUser)
+
+	// Start server
+	app.Listen(":8000")
+}
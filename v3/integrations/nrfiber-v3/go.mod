@@ -0,0 +1,24 @@
+module github.com/newrelic/go-agent/v3/integrations/nrfiber-v3
+
+// As of v3.1.0, the fiber go.mod file uses 1.25:
+// https://github.com/gofiber/fiber/blob/main/go.mod
+go 1.25.0
+
+require (
+	github.com/gofiber/fiber/v3 v3.1.0
+	github.com/newrelic/go-agent/v3 v3.35.0
+	github.com/valyala/fasthttp v1.69.0
+)
+
+require (
+	github.com/andybalholm/brotli v1.2.0 // indirect
+	github.com/valyala/bytebufferpool v1.0.0 // indirect
+	golang.org/x/net v0.50.0 // indirect
+	golang.org/x/sys v0.41.0 // indirect
+	golang.org/x/text v0.34.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20240528184218-531527333157 // indirect
+	google.golang.org/grpc v1.65.0 // indirect
+	google.golang.org/protobuf v1.34.2 // indirect
+)
+
+replace github.com/newrelic/go-agent/v3 => ../..
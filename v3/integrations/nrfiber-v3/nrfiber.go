@@ -0,0 +1,156 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package nrfiber instruments applications using
+// https://github.com/gofiber/fiber v3.
+//
+// Use this package to instrument inbound requests handled by a fiber.App
+// instance.
+//
+//	app := fiber.New()
+//	// Add the nrfiber middleware before other middlewares or routes:
+//	app.Use(nrfiber.Middleware(nrApp))
+//
+// Transactions are named after the method and the pattern of the matched
+// route, for example "GET /users/:id".  Requests which do not match a route
+// are named "NotFoundHandler".  The transaction is stored in the fiber.Ctx and
+// in its context.Context, and can be retrieved using FromContext or
+// newrelic.FromContext(c.Context()).
+//
+// Example: https://github.com/newrelic/go-agent/tree/master/v3/integrations/nrfiber-v3/example/main.go
+package nrfiber
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/newrelic/go-agent/v3/internal"
+	"github.com/newrelic/go-agent/v3/internal/errorutil"
+	"github.com/newrelic/go-agent/v3/newrelic"
+	"github.com/valyala/fasthttp/fasthttpadaptor"
+)
+
+func init() { internal.TrackUsage("integration", "framework", "fiber") }
+
+// transactionKey is the key of the transaction in the locals of a fiber.Ctx.
+type transactionKey struct{}
+
+// FromContext returns the Transaction from the fiber.Ctx if present, and nil
+// otherwise.
+func FromContext(c fiber.Ctx) *newrelic.Transaction {
+	if txn, ok := c.Locals(transactionKey{}).(*newrelic.Transaction); ok {
+		return txn
+	}
+	return newrelic.FromContext(c.Context())
+}
+
+// Skipper defines a function to skip middleware. Returning true skips processing
+// the middleware.
+type Skipper func(c fiber.Ctx) bool
+
+// Config defines the config for the middleware.
+type Config struct {
+	// App contains newrelic application.
+	App *newrelic.Application
+
+	// Skipper defines a function to skip middleware.
+	Skipper Skipper
+}
+
+type ConfigOption func(*Config)
+
+func WithSkipper(skipper Skipper) ConfigOption {
+	return func(cfg *Config) { cfg.Skipper = skipper }
+}
+
+func transactionName(c fiber.Ctx) string {
+	if !c.Matched() {
+		return "NotFoundHandler"
+	}
+	return c.Method() + " " + c.FullPath()
+}
+
+// responseWriter provides the status code and headers of the fiber response
+// to the transaction.
+type responseWriter struct {
+	c fiber.Ctx
+}
+
+func (w responseWriter) Header() http.Header {
+	hdrs := http.Header{}
+	w.c.Response().Header.VisitAll(func(key, value []byte) {
+		hdrs.Add(string(key), string(value))
+	})
+	return hdrs
+}
+
+func (w responseWriter) Write(b []byte) (int, error) { return len(b), nil }
+
+func (w responseWriter) WriteHeader(int) {}
+
+// statusCode returns the status code of the response, mimicking the logic of
+// fiber.DefaultErrorHandler when the handler returns an error.
+func statusCode(c fiber.Ctx, err error) int {
+	if err == nil {
+		return c.Response().StatusCode()
+	}
+	var fe *fiber.Error
+	if errors.As(err, &fe) {
+		return fe.Code
+	}
+	return fiber.StatusInternalServerError
+}
+
+// Middleware creates fiber middleware that instruments requests.
+//
+//	app := fiber.New()
+//	// Add the nrfiber middleware before other middlewares or routes:
+//	app.Use(nrfiber.Middleware(nrApp))
+func Middleware(app *newrelic.Application, opts ...ConfigOption) fiber.Handler {
+	if app == nil {
+		return func(c fiber.Ctx) error {
+			return c.Next()
+		}
+	}
+
+	config := Config{
+		App: app,
+	}
+
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	return func(c fiber.Ctx) error {
+		if config.Skipper != nil && config.Skipper(c) {
+			return c.Next()
+		}
+
+		// The route is not known until the router has matched it, so
+		// the transaction is renamed once the handlers have returned.
+		txn := config.App.StartTransaction(c.Method() + " " + c.Path())
+		defer txn.End()
+
+		r := &http.Request{}
+		if err := fasthttpadaptor.ConvertRequest(c.RequestCtx(), r, true); err == nil {
+			txn.SetWebRequestHTTP(r)
+		}
+
+		c.Locals(transactionKey{}, txn)
+		c.SetContext(newrelic.NewContext(c.Context(), txn))
+
+		err := c.Next()
+
+		txn.SetName(transactionName(c))
+		if newrelic.IsSecurityAgentPresent() {
+			txn.SetCsecAttributes(newrelic.AttributeCsecRoute, c.FullPath())
+		}
+		if err != nil {
+			errorutil.NoticeError(txn, statusCode(c, err), responseWriter{c: c}.Header(), err)
+		} else {
+			txn.SetWebResponse(responseWriter{c: c}).WriteHeader(statusCode(c, err))
+		}
+		return err
+	}
+}
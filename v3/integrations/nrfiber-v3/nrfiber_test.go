@@ -0,0 +1,146 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package nrfiber
+
+import (
+	"errors"
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v3"
+	"github.com/newrelic/go-agent/v3/internal"
+	"github.com/newrelic/go-agent/v3/internal/integrationsupport"
+	"github.com/newrelic/go-agent/v3/newrelic"
+)
+
+func newApp(nrApp *newrelic.Application, opts ...ConfigOption) *fiber.App {
+	app := fiber.New()
+	app.Use(Middleware(nrApp, opts...))
+	app.Get("/users/:id", func(c fiber.Ctx) error {
+		if FromContext(c) == nil || newrelic.FromContext(c.Context()) == nil {
+			return errors.New("transaction missing")
+		}
+		c.Set("Content-Type", "text/plain")
+		return c.SendString("user " + c.Params("id"))
+	})
+	app.Get("/teapot", func(c fiber.Ctx) error {
+		return fiber.NewError(fiber.StatusTeapot, "short and stout")
+	})
+	app.Get("/error", func(c fiber.Ctx) error {
+		return errors.New("oops")
+	})
+	app.Get("/health", func(c fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+	return app
+}
+
+func request(t *testing.T, app *fiber.App, path string) (int, string) {
+	resp, err := app.Test(httptest.NewRequest("GET", path, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(body)
+}
+
+func TestRoutePattern(t *testing.T) {
+	nrApp := integrationsupport.NewTestApp(nil, newrelic.ConfigCodeLevelMetricsEnabled(false))
+	app := newApp(nrApp.Application)
+
+	if code, body := request(t, app, "/users/123?remove=me"); code != 200 || body != "user 123" {
+		t.Error("unexpected response", code, body)
+	}
+	nrApp.ExpectTxnMetrics(t, internal.WantTxn{
+		Name:          "GET /users/:id",
+		IsWeb:         true,
+		UnknownCaller: true,
+	})
+	nrApp.ExpectTxnEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"name":             "WebTransaction/Go/GET /users/:id",
+			"nr.apdexPerfZone": "S",
+			"sampled":          false,
+			"guid":             internal.MatchAnything,
+			"traceId":          internal.MatchAnything,
+			"priority":         internal.MatchAnything,
+		},
+		AgentAttributes: map[string]interface{}{
+			"httpResponseCode":             200,
+			"http.statusCode":              200,
+			"request.method":               "GET",
+			"request.uri":                  "/users/123",
+			"request.headers.host":         "example.com",
+			"response.headers.contentType": "text/plain",
+		},
+		UserAttributes: map[string]interface{}{},
+	}})
+}
+
+func TestErrorStatus(t *testing.T) {
+	nrApp := integrationsupport.NewTestApp(nil, newrelic.ConfigCodeLevelMetricsEnabled(false))
+	app := newApp(nrApp.Application)
+
+	if code, _ := request(t, app, "/teapot"); code != fiber.StatusTeapot {
+		t.Error("unexpected status", code)
+	}
+	if code, _ := request(t, app, "/error"); code != fiber.StatusInternalServerError {
+		t.Error("unexpected status", code)
+	}
+	nrApp.ExpectErrors(t, []internal.WantError{{
+		TxnName: "WebTransaction/Go/GET /teapot",
+		Msg:     "short and stout",
+		Klass:   "418",
+	}, {
+		TxnName: "WebTransaction/Go/GET /error",
+		Msg:     "oops",
+		Klass:   "500",
+	}})
+	nrApp.ExpectMetricsPresent(t, []internal.WantMetric{
+		{Name: "WebTransaction/Go/GET /teapot", Scope: "", Forced: true, Data: nil},
+		{Name: "WebTransaction/Go/GET /error", Scope: "", Forced: true, Data: nil},
+	})
+}
+
+func TestNotFound(t *testing.T) {
+	nrApp := integrationsupport.NewTestApp(nil, newrelic.ConfigCodeLevelMetricsEnabled(false))
+	app := newApp(nrApp.Application)
+
+	if code, _ := request(t, app, "/missing/123"); code != fiber.StatusNotFound {
+		t.Error("unexpected status", code)
+	}
+	nrApp.ExpectTxnMetrics(t, internal.WantTxn{
+		Name:          "NotFoundHandler",
+		IsWeb:         true,
+		UnknownCaller: true,
+	})
+}
+
+func TestSkipper(t *testing.T) {
+	nrApp := integrationsupport.NewTestApp(nil, newrelic.ConfigCodeLevelMetricsEnabled(false))
+	app := newApp(nrApp.Application, WithSkipper(func(c fiber.Ctx) bool {
+		return c.Path() == "/health"
+	}))
+
+	if code, _ := request(t, app, "/health"); code != fiber.StatusNoContent {
+		t.Error("unexpected status", code)
+	}
+	nrApp.ExpectTxnEvents(t, []internal.WantEvent{})
+}
+
+func TestNilApplication(t *testing.T) {
+	app := fiber.New()
+	app.Use(Middleware(nil))
+	app.Get("/hello", func(c fiber.Ctx) error {
+		if FromContext(c) != nil {
+			return errors.New("unexpected transaction")
+		}
+		return c.SendString("hello")
+	})
+	if code, body := request(t, app, "/hello"); code != 200 || body != "hello" {
+		t.Error("unexpected response", code, body)
+	}
+}
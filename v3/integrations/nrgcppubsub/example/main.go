@@ -0,0 +1,64 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"cloud.google.com/go/pubsub"
+	"github.com/newrelic/go-agent/v3/integrations/nrgcppubsub"
+	"github.com/newrelic/go-agent/v3/newrelic"
+)
+
+func main() {
+	app, err := newrelic.NewApplication(
+		newrelic.ConfigAppName("Pub/Sub App"),
+		newrelic.ConfigLicense(os.Getenv("NEW_RELIC_LICENSE_KEY")),
+		newrelic.ConfigDebugLogger(os.Stdout),
+	)
+	if nil != err {
+		panic(err)
+	}
+	defer app.Shutdown(10 * time.Second)
+	if err := app.WaitForConnection(5 * time.Second); nil != err {
+		panic(err)
+	}
+
+	ctx := context.Background()
+	client, err := pubsub.NewClient(ctx, os.Getenv("GOOGLE_CLOUD_PROJECT"))
+	if nil != err {
+		panic(err)
+	}
+	defer client.Close()
+
+	// Publish a message inside of a transaction.  The distributed tracing
+	// headers are added to the message attributes.
+	txn := app.StartTransaction("publish")
+	topic := client.Topic("my-topic")
+	result := nrgcppubsub.Publish(newrelic.NewContext(ctx, txn), topic, &pubsub.Message{
+		Data: []byte("Hello World"),
+	})
+	if _, err := result.Get(ctx); nil != err {
+		txn.NoticeError(err)
+	}
+	txn.End()
+	topic.Stop()
+
+	// Receive messages.  Each message is handled in its own transaction.
+	cctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	sub := client.Subscription("my-subscription")
+	err = sub.Receive(cctx, nrgcppubsub.WrapReceiveHandler(app, sub,
+		func(ctx context.Context, msg *pubsub.Message) {
+			defer newrelic.FromContext(ctx).StartSegment("process").End()
+			fmt.Println("Received message:", string(msg.Data))
+			msg.Ack()
+		}))
+	if nil != err {
+		panic(err)
+	}
+}
@@ -0,0 +1,19 @@
+module github.com/newrelic/go-agent/v3/integrations/nrgcppubsub
+
+go 1.21
+
+require (
+	cloud.google.com/go/pubsub v1.40.0
+	github.com/newrelic/go-agent/v3 v3.35.0
+	google.golang.org/api v0.187.0
+	google.golang.org/grpc v1.65.0
+)
+
+require (
+	golang.org/x/net v0.26.0 // indirect
+	golang.org/x/sys v0.21.0 // indirect
+	golang.org/x/text v0.16.0 // indirect
+	google.golang.org/protobuf v1.34.2 // indirect
+)
+
+replace github.com/newrelic/go-agent/v3 => ../..
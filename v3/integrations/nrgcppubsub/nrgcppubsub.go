@@ -0,0 +1,130 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package nrgcppubsub
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"cloud.google.com/go/pubsub"
+	"github.com/newrelic/go-agent/v3/internal"
+	"github.com/newrelic/go-agent/v3/internal/integrationsupport"
+	"github.com/newrelic/go-agent/v3/newrelic"
+)
+
+const (
+	// library is used in metric and transaction names.
+	library = "GCPPubSub"
+
+	// AttributeOrderingKey is the custom attribute containing the ordering
+	// key of a received message.
+	AttributeOrderingKey = "messaging.gcp_pubsub.message.ordering_key"
+	// AttributeDeliveryAttempt is the custom attribute containing the
+	// delivery attempt of a received message.  It is only available when
+	// the subscription has a dead letter policy.
+	AttributeDeliveryAttempt = "messaging.gcp_pubsub.message.delivery_attempt"
+	// AttributeMessageID is the custom attribute containing the server
+	// assigned ID of a received message.
+	AttributeMessageID = "messaging.message.id"
+)
+
+// StartPublishSegment creates and starts a `newrelic.MessageProducerSegment`
+// for a message about to be published to the topic provided, and adds the
+// distributed tracing headers to the message attributes.  Call `End()` on the
+// returned segment when the publish is complete.  `Publish` should be used
+// instead when possible.
+func StartPublishSegment(txn *newrelic.Transaction, topic *pubsub.Topic, msg *pubsub.Message) *newrelic.MessageProducerSegment {
+	if nil == txn || nil == topic {
+		return nil
+	}
+	if nil != msg {
+		insertDistributedTraceHeaders(txn, msg)
+	}
+	return &newrelic.MessageProducerSegment{
+		StartTime:       txn.StartSegmentNow(),
+		Library:         library,
+		DestinationType: newrelic.MessageTopic,
+		DestinationName: topic.ID(),
+	}
+}
+
+// Publish publishes the message to the topic using `pubsub.Topic.Publish`.  If
+// the context contains a transaction, the publish is recorded as a
+// `newrelic.MessageProducerSegment` and the distributed tracing headers are
+// added to the message attributes so that the subscriber transaction is
+// connected to the publisher.
+func Publish(ctx context.Context, topic *pubsub.Topic, msg *pubsub.Message) *pubsub.PublishResult {
+	seg := StartPublishSegment(newrelic.FromContext(ctx), topic, msg)
+	defer seg.End()
+
+	return topic.Publish(ctx, msg)
+}
+
+// WrapReceiveHandler wraps the function passed to `pubsub.Subscription.Receive`.
+// If the `newrelic.Application` parameter is non-nil, a background transaction
+// is started for each message, added to the handler's context, and ended when
+// the handler returns.  Distributed tracing headers found in the message
+// attributes are accepted by the transaction.
+func WrapReceiveHandler(app *newrelic.Application, sub *pubsub.Subscription, handler func(context.Context, *pubsub.Message)) func(context.Context, *pubsub.Message) {
+	if nil == app {
+		return handler
+	}
+	var subID string
+	if nil != sub {
+		subID = sub.ID()
+	}
+	namer := internal.MessageMetricKey{
+		Library:         library,
+		DestinationType: string(newrelic.MessageQueue),
+		DestinationName: subID,
+		Consumer:        true,
+	}
+	name := namer.Name()
+	return func(ctx context.Context, msg *pubsub.Message) {
+		txn := app.StartTransaction(name)
+		defer txn.End()
+
+		if nil != msg {
+			txn.AcceptDistributedTraceHeaders(newrelic.TransportQueue, headersFromAttributes(msg.Attributes))
+			integrationsupport.AddAgentAttribute(txn, newrelic.AttributeMessageQueueName, subID, nil)
+			if "" != msg.ID {
+				txn.AddAttribute(AttributeMessageID, msg.ID)
+			}
+			if "" != msg.OrderingKey {
+				txn.AddAttribute(AttributeOrderingKey, msg.OrderingKey)
+			}
+			if nil != msg.DeliveryAttempt {
+				txn.AddAttribute(AttributeDeliveryAttempt, *msg.DeliveryAttempt)
+			}
+		}
+
+		handler(newrelic.NewContext(ctx, txn), msg)
+	}
+}
+
+// insertDistributedTraceHeaders adds the distributed tracing headers to the
+// message attributes.  Attribute keys are lower case since Pub/Sub does not
+// canonicalize them.
+func insertDistributedTraceHeaders(txn *newrelic.Transaction, msg *pubsub.Message) {
+	hdrs := http.Header{}
+	txn.InsertDistributedTraceHeaders(hdrs)
+	if len(hdrs) == 0 {
+		return
+	}
+	if nil == msg.Attributes {
+		msg.Attributes = make(map[string]string, len(hdrs))
+	}
+	for key := range hdrs {
+		msg.Attributes[strings.ToLower(key)] = hdrs.Get(key)
+	}
+}
+
+func headersFromAttributes(attrs map[string]string) http.Header {
+	hdrs := make(http.Header, len(attrs))
+	for key, val := range attrs {
+		hdrs.Set(key, val)
+	}
+	return hdrs
+}
@@ -0,0 +1,46 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package nrgcppubsub instruments https://github.com/googleapis/google-cloud-go/tree/main/pubsub.
+//
+// This package can be used to instrument Google Cloud Pub/Sub publishers and
+// subscribers.  Distributed tracing context is propagated from publishers to
+// subscribers using Pub/Sub message attributes.
+//
+// Pub/Sub publishers
+//
+// Use `Publish` in place of `pubsub.Topic.Publish`.  It records a
+// `newrelic.MessageProducerSegment` for the publish call using the transaction
+// found in the context and adds the distributed tracing headers to the
+// message attributes.  Example:
+//
+//	ctx := newrelic.NewContext(context.Background(), txn)
+//	topic := client.Topic("my-topic")
+//	result := nrgcppubsub.Publish(ctx, topic, &pubsub.Message{
+//		Data: []byte("Hello World"),
+//	})
+//	id, err := result.Get(ctx)
+//
+// Pub/Sub subscribers
+//
+// Use `WrapReceiveHandler` to wrap the function passed to
+// `pubsub.Subscription.Receive`.  A background transaction is created for each
+// message received and ended when the handler returns.  The transaction is
+// added to the context passed to the handler, and the ordering key and
+// delivery attempt of the message are recorded as attributes.  Example:
+//
+//	sub := client.Subscription("my-subscription")
+//	err := sub.Receive(ctx, nrgcppubsub.WrapReceiveHandler(app, sub,
+//		func(ctx context.Context, msg *pubsub.Message) {
+//			txn := newrelic.FromContext(ctx)
+//			// ...
+//			msg.Ack()
+//		}))
+//
+// Full Publisher/Subscriber example:
+// https://github.com/newrelic/go-agent/blob/master/v3/integrations/nrgcppubsub/example/main.go
+package nrgcppubsub
+
+import "github.com/newrelic/go-agent/v3/internal"
+
+func init() { internal.TrackUsage("integration", "messagebroker", "gcppubsub") }
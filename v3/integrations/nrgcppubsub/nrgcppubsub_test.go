@@ -0,0 +1,149 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package nrgcppubsub
+
+import (
+	"context"
+	"testing"
+
+	"cloud.google.com/go/pubsub"
+	"cloud.google.com/go/pubsub/pstest"
+	"github.com/newrelic/go-agent/v3/internal"
+	"github.com/newrelic/go-agent/v3/internal/integrationsupport"
+	"github.com/newrelic/go-agent/v3/newrelic"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+func testApp() integrationsupport.ExpectApp {
+	return integrationsupport.NewTestApp(replyFn, integrationsupport.ConfigFullTraces, newrelic.ConfigCodeLevelMetricsEnabled(false))
+}
+
+var replyFn = func(reply *internal.ConnectReply) {
+	reply.SetSampleEverything()
+	reply.AccountID = "123"
+	reply.TrustedAccountKey = "123"
+	reply.PrimaryAppID = "456"
+}
+
+func testClient(t *testing.T) *pubsub.Client {
+	srv := pstest.NewServer()
+	t.Cleanup(func() { srv.Close() })
+	conn, err := grpc.NewClient(srv.Addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if nil != err {
+		t.Fatal(err)
+	}
+	client, err := pubsub.NewClient(context.Background(), "project", option.WithGRPCConn(conn))
+	if nil != err {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestStartPublishSegmentNilTxn(t *testing.T) {
+	client := testClient(t)
+	msg := &pubsub.Message{}
+	StartPublishSegment(nil, client.Topic("my-topic"), msg).End()
+	if len(msg.Attributes) != 0 {
+		t.Error(msg.Attributes)
+	}
+}
+
+func TestPublish(t *testing.T) {
+	ctx := context.Background()
+	client := testClient(t)
+	topic, err := client.CreateTopic(ctx, "my-topic")
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer topic.Stop()
+
+	app := testApp()
+	txn := app.StartTransaction("testing")
+	msg := &pubsub.Message{Data: []byte("data")}
+	if _, err := Publish(newrelic.NewContext(ctx, txn), topic, msg).Get(ctx); nil != err {
+		t.Fatal(err)
+	}
+	txn.End()
+
+	if msg.Attributes["newrelic"] == "" || msg.Attributes["traceparent"] == "" {
+		t.Error("distributed tracing headers missing from attributes", msg.Attributes)
+	}
+	app.ExpectMetricsPresent(t, []internal.WantMetric{
+		{Name: "MessageBroker/GCPPubSub/Topic/Produce/Named/my-topic", Scope: ""},
+		{Name: "MessageBroker/GCPPubSub/Topic/Produce/Named/my-topic", Scope: "OtherTransaction/Go/testing"},
+	})
+}
+
+func TestWrapReceiveHandlerNilApp(t *testing.T) {
+	var called bool
+	handler := WrapReceiveHandler(nil, nil, func(ctx context.Context, msg *pubsub.Message) {
+		called = true
+		if txn := newrelic.FromContext(ctx); nil != txn {
+			t.Error("unexpected transaction in context")
+		}
+	})
+	handler(context.Background(), &pubsub.Message{})
+	if !called {
+		t.Error("handler not called")
+	}
+}
+
+func TestWrapReceiveHandler(t *testing.T) {
+	client := testClient(t)
+	sub := client.Subscription("my-subscription")
+
+	app := testApp()
+	attempt := 3
+	msg := &pubsub.Message{
+		ID:              "1234",
+		Data:            []byte("data"),
+		OrderingKey:     "customer-1",
+		DeliveryAttempt: &attempt,
+	}
+	handler := WrapReceiveHandler(app.Application, sub, func(ctx context.Context, msg *pubsub.Message) {
+		if txn := newrelic.FromContext(ctx); nil == txn {
+			t.Error("missing transaction in context")
+		}
+	})
+	handler(context.Background(), msg)
+
+	app.ExpectTxnEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"name":     "OtherTransaction/Go/Message/GCPPubSub/Queue/Named/my-subscription",
+			"guid":     internal.MatchAnything,
+			"priority": internal.MatchAnything,
+			"sampled":  internal.MatchAnything,
+			"traceId":  internal.MatchAnything,
+		},
+		UserAttributes: map[string]interface{}{
+			AttributeMessageID:       "1234",
+			AttributeOrderingKey:     "customer-1",
+			AttributeDeliveryAttempt: 3,
+		},
+		AgentAttributes: map[string]interface{}{
+			newrelic.AttributeMessageQueueName: "my-subscription",
+		},
+	}})
+}
+
+func TestDistributedTracePropagation(t *testing.T) {
+	client := testClient(t)
+	app := testApp()
+
+	txn := app.StartTransaction("publisher")
+	msg := &pubsub.Message{Data: []byte("data")}
+	StartPublishSegment(txn, client.Topic("my-topic"), msg).End()
+	txn.End()
+
+	handler := WrapReceiveHandler(app.Application, client.Subscription("my-subscription"),
+		func(ctx context.Context, msg *pubsub.Message) {})
+	handler(context.Background(), msg)
+
+	app.ExpectMetricsPresent(t, []internal.WantMetric{
+		{Name: "Supportability/TraceContext/Accept/Success", Scope: ""},
+	})
+}
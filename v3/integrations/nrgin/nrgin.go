@@ -11,6 +11,11 @@
 //	// Add the nrgin middleware before other middlewares or routes:
 //	router.Use(nrgin.Middleware(app))
 //
+// The last error added to the gin.Context, for example using
+// gin.Context.AbortWithError, is recorded as the error of the transaction with
+// the response code, unless the response code is below 400 or is one of the
+// ErrorCollector.IgnoreStatusCodes.
+//
 // Example: https://github.com/newrelic/go-agent/tree/master/v3/integrations/nrgin/example/main.go
 package nrgin
 
@@ -19,6 +24,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/newrelic/go-agent/v3/internal"
+	"github.com/newrelic/go-agent/v3/internal/errorutil"
 	"github.com/newrelic/go-agent/v3/newrelic"
 )
 
@@ -53,6 +59,19 @@ func (w *replacementResponseWriter) flushHeader() {
 	}
 }
 
+// noticeError records the last error of the context, if any, with the
+// response code.  The error is not recorded if the response code has already
+// been written, since the error may have been added after the response.
+func (w *replacementResponseWriter) noticeError(txn *newrelic.Transaction, c *gin.Context) {
+	if w.written {
+		return
+	}
+	if err := c.Errors.Last(); err != nil {
+		errorutil.NoticeError(txn, w.code, w.Header(), err)
+		w.written = true
+	}
+}
+
 func (w *replacementResponseWriter) WriteHeader(code int) {
 	w.code = code
 	w.ResponseWriter.WriteHeader(code)
@@ -75,7 +94,12 @@ func (w *replacementResponseWriter) WriteString(s string) (int, error) {
 }
 
 func (w *replacementResponseWriter) WriteHeaderNow() {
-	w.flushHeader()
+	// gin.Context.AbortWithError writes the response code before adding
+	// the error to the context, so error response codes are recorded when
+	// the body is written or the handlers return, along with the error.
+	if w.code < http.StatusBadRequest {
+		w.flushHeader()
+	}
 	w.ResponseWriter.WriteHeaderNow()
 }
 
@@ -183,6 +207,7 @@ func middleware(app *newrelic.Application, useNewNames bool) gin.HandlerFunc {
 			}
 			c.Writer = repl
 			defer repl.flushHeader()
+			defer repl.noticeError(txn, c)
 
 			c.Set(internal.GinTransactionContextKey, txn)
 		}
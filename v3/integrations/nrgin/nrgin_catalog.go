@@ -0,0 +1,45 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package nrgin
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/newrelic/go-agent/v3/internal/integrationsupport"
+	"github.com/newrelic/go-agent/v3/newrelic"
+)
+
+// RouteCatalogEventType is the type of the custom events recorded by
+// ReportRouteCatalog, one for each route registered with the engine.
+const RouteCatalogEventType = integrationsupport.RouteCatalogEventType
+
+// ReportRouteCatalog reports the catalog of the routes registered with the
+// engine, so that New Relic knows the endpoints of the application, including
+// those which are never requested.  Call it once every route is registered,
+// before running the engine:
+//
+//	router := gin.Default()
+//	router.Use(nrgin.Middleware(app))
+//	router.GET("/users/:id", getUser)
+//	nrgin.ReportRouteCatalog(app, router)
+//	router.Run()
+//
+// Once the application is connected, each route is recorded as a
+// "HttpRouteCatalog" custom event with the "framework", "method", and
+// "pattern" attributes.  The pattern is the path of the route, as used by
+// Middleware to name the transactions of the route.  Only the methods and
+// patterns of the routes are reported.
+func ReportRouteCatalog(app *newrelic.Application, engine *gin.Engine) {
+	if app == nil || engine == nil {
+		return
+	}
+	integrationsupport.ReportCatalog(app, "gin route catalog", RouteCatalogEventType, routeCatalog(engine.Routes()))
+}
+
+func routeCatalog(routes gin.RoutesInfo) []map[string]interface{} {
+	catalog := make([]integrationsupport.Route, len(routes))
+	for i, r := range routes {
+		catalog[i] = integrationsupport.Route{Method: r.Method, Pattern: r.Path}
+	}
+	return integrationsupport.RouteCatalog("gin", catalog)
+}
@@ -0,0 +1,34 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package nrgin
+
+import (
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/newrelic/go-agent/v3/internal"
+	"github.com/newrelic/go-agent/v3/internal/integrationsupport"
+)
+
+func TestRecordRouteCatalog(t *testing.T) {
+	app := integrationsupport.NewBasicTestApp()
+	router := gin.New()
+	router.Use(Middleware(app.Application))
+	router.POST("/users", hello)
+	router.GET("/users", hello)
+	group := router.Group("/users")
+	group.GET("/:id", hello)
+
+	integrationsupport.RecordCatalog(app.Application, RouteCatalogEventType, routeCatalog(router.Routes()))
+	app.ExpectCustomEvents(t, []internal.WantEvent{
+		integrationsupport.WantRouteCatalogEvent("gin", "GET", "/users"),
+		integrationsupport.WantRouteCatalogEvent("gin", "POST", "/users"),
+		integrationsupport.WantRouteCatalogEvent("gin", "GET", "/users/:id"),
+	})
+}
+
+func TestReportRouteCatalogNil(t *testing.T) {
+	ReportRouteCatalog(nil, gin.New())
+	ReportRouteCatalog(integrationsupport.NewBasicTestApp().Application, nil)
+}
@@ -292,6 +292,31 @@ func TestStatusCodes(t *testing.T) {
 	}})
 }
 
+func TestContextErrors(t *testing.T) {
+	app := integrationsupport.NewBasicTestApp()
+	router := gin.New()
+	router.Use(Middleware(app.Application))
+	router.GET("/upstream", func(c *gin.Context) {
+		c.AbortWithError(http.StatusBadGateway, errors.New("upstream unavailable"))
+	})
+	router.GET("/ignored", func(c *gin.Context) {
+		c.AbortWithError(http.StatusNotFound, errors.New("no such user"))
+	})
+
+	for _, path := range []string{"/upstream", "/ignored"} {
+		req, err := http.NewRequest("GET", path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	app.ExpectErrors(t, []internal.WantError{{
+		TxnName: "WebTransaction/Go/GET /upstream",
+		Msg:     "upstream unavailable",
+		Klass:   "502",
+	}})
+}
+
 func noBody(c *gin.Context) {
 	c.Status(500)
 }
@@ -0,0 +1,51 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/newrelic/go-agent/v3/integrations/nrgoose"
+	"github.com/newrelic/go-agent/v3/newrelic"
+	"github.com/pressly/goose/v3"
+	_ "modernc.org/sqlite"
+)
+
+func main() {
+	app, err := newrelic.NewApplication(
+		newrelic.ConfigAppName("Goose App"),
+		newrelic.ConfigLicense(os.Getenv("NEW_RELIC_LICENSE_KEY")),
+		newrelic.ConfigDebugLogger(os.Stdout),
+	)
+	if nil != err {
+		panic(err)
+	}
+	// Shutdown sends the data recorded for the migrations before exiting.
+	defer app.Shutdown(10 * time.Second)
+	if err := app.WaitForConnection(5 * time.Second); nil != err {
+		panic(err)
+	}
+
+	db, err := sql.Open("sqlite", "example.db")
+	if nil != err {
+		panic(err)
+	}
+	defer db.Close()
+
+	provider, err := goose.NewProvider(goose.DialectSQLite3, db, os.DirFS("migrations"))
+	if nil != err {
+		panic(err)
+	}
+	results, err := nrgoose.Up(context.Background(), app, provider)
+	for _, r := range results {
+		fmt.Println(r)
+	}
+	if nil != err {
+		panic(err)
+	}
+}
@@ -0,0 +1,176 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package nrgoose instruments https://github.com/pressly/goose.
+//
+// Use this package to record each migration applied or rolled back by a
+// goose.Provider as a background transaction, so that slow or failing
+// migrations run during a deploy are visible in New Relic.  Call the
+// functions in this package in place of the corresponding goose.Provider
+// methods:
+//
+//	provider, err := goose.NewProvider(goose.DialectPostgres, db, os.DirFS("migrations"))
+//	if nil != err {
+//		panic(err)
+//	}
+//	results, err := nrgoose.Up(ctx, app, provider)
+//
+// Each migration is recorded as a transaction named
+// "Migration/<direction>/<version>", for example "Migration/up/3", with the
+// version, direction, and source file added as attributes.  Errors returned
+// while running a migration are recorded on the transaction.  The context
+// passed to Go migrations contains the transaction, so that queries made using
+// an instrumented database driver appear inside it.
+//
+// Since migrations are often run shortly before an application exits, make
+// sure to call Application.Shutdown after the migrations complete so that
+// the data is sent.
+//
+// Full example:
+// https://github.com/newrelic/go-agent/blob/master/v3/integrations/nrgoose/example/main.go
+package nrgoose
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"github.com/newrelic/go-agent/v3/internal"
+	"github.com/newrelic/go-agent/v3/newrelic"
+	"github.com/pressly/goose/v3"
+)
+
+func init() { internal.TrackUsage("integration", "migration", "goose") }
+
+const (
+	// AttributeMigrationVersion is the custom attribute containing the
+	// version of the migration run by the transaction.
+	AttributeMigrationVersion = "migration.version"
+	// AttributeMigrationDirection is the custom attribute containing the
+	// direction, "up" or "down", of the migration run by the transaction.
+	AttributeMigrationDirection = "migration.direction"
+	// AttributeMigrationSource is the custom attribute containing the path
+	// of the migration's source file.
+	AttributeMigrationSource = "migration.source"
+)
+
+// step runs a single migration inside of a transaction.
+func step(ctx context.Context, app *newrelic.Application, direction string,
+	run func(context.Context) (*goose.MigrationResult, error)) (*goose.MigrationResult, error) {
+	if nil == app {
+		return run(ctx)
+	}
+	txn := app.StartTransaction("Migration/" + direction)
+	defer txn.End()
+
+	res, err := run(newrelic.NewContext(ctx, txn))
+	if errors.Is(err, goose.ErrNoNextVersion) {
+		txn.Ignore()
+		return res, err
+	}
+
+	recorded := res
+	var partial *goose.PartialError
+	if errors.As(err, &partial) {
+		recorded = partial.Failed
+	}
+	if nil != recorded && nil != recorded.Source {
+		version := recorded.Source.Version
+		txn.SetName("Migration/" + direction + "/" + strconv.FormatInt(version, 10))
+		txn.AddAttribute(AttributeMigrationVersion, version)
+		txn.AddAttribute(AttributeMigrationSource, recorded.Source.Path)
+	}
+	txn.AddAttribute(AttributeMigrationDirection, direction)
+	if nil != err {
+		txn.NoticeError(err)
+	}
+	return res, err
+}
+
+// UpByOne applies the next pending migration using goose.Provider.UpByOne.
+func UpByOne(ctx context.Context, app *newrelic.Application, p *goose.Provider) (*goose.MigrationResult, error) {
+	return step(ctx, app, "up", p.UpByOne)
+}
+
+// Up applies all pending migrations, one transaction per migration.  Like
+// goose.Provider.Up, it returns an empty list and a nil error if there are no
+// migrations to apply.
+func Up(ctx context.Context, app *newrelic.Application, p *goose.Provider) ([]*goose.MigrationResult, error) {
+	var results []*goose.MigrationResult
+	for {
+		res, err := UpByOne(ctx, app, p)
+		if errors.Is(err, goose.ErrNoNextVersion) {
+			return results, nil
+		}
+		if nil != err {
+			return results, err
+		}
+		results = append(results, res)
+	}
+}
+
+// UpTo applies all pending migrations up to, and including, the specified
+// version, one transaction per migration.
+func UpTo(ctx context.Context, app *newrelic.Application, p *goose.Provider, version int64) ([]*goose.MigrationResult, error) {
+	var results []*goose.MigrationResult
+	for {
+		next, ok, err := nextPending(ctx, p)
+		if nil != err {
+			return results, err
+		}
+		if !ok || next > version {
+			return results, nil
+		}
+		res, err := UpByOne(ctx, app, p)
+		if errors.Is(err, goose.ErrNoNextVersion) {
+			return results, nil
+		}
+		if nil != err {
+			return results, err
+		}
+		results = append(results, res)
+	}
+}
+
+// nextPending returns the lowest version which has not been applied.
+func nextPending(ctx context.Context, p *goose.Provider) (int64, bool, error) {
+	statuses, err := p.Status(ctx)
+	if nil != err {
+		return 0, false, err
+	}
+	for _, s := range statuses {
+		if s.State == goose.StatePending {
+			return s.Source.Version, true, nil
+		}
+	}
+	return 0, false, nil
+}
+
+// Down rolls back the most recently applied migration using
+// goose.Provider.Down.
+func Down(ctx context.Context, app *newrelic.Application, p *goose.Provider) (*goose.MigrationResult, error) {
+	return step(ctx, app, "down", p.Down)
+}
+
+// DownTo rolls back all migrations down to, but not including, the specified
+// version, one transaction per migration.
+func DownTo(ctx context.Context, app *newrelic.Application, p *goose.Provider, version int64) ([]*goose.MigrationResult, error) {
+	var results []*goose.MigrationResult
+	for {
+		current, err := p.GetDBVersion(ctx)
+		if nil != err {
+			return results, err
+		}
+		if current <= version {
+			return results, nil
+		}
+		res, err := Down(ctx, app, p)
+		if errors.Is(err, goose.ErrNoNextVersion) {
+			return results, nil
+		}
+		if nil != err {
+			return results, err
+		}
+		results = append(results, res)
+	}
+}
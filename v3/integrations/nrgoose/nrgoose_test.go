@@ -0,0 +1,138 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package nrgoose
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"testing/fstest"
+
+	"github.com/newrelic/go-agent/v3/internal"
+	"github.com/newrelic/go-agent/v3/internal/integrationsupport"
+	"github.com/newrelic/go-agent/v3/newrelic"
+	"github.com/pressly/goose/v3"
+	_ "modernc.org/sqlite"
+)
+
+func testApp() integrationsupport.ExpectApp {
+	return integrationsupport.NewTestApp(integrationsupport.SampleEverythingReplyFn,
+		newrelic.ConfigCodeLevelMetricsEnabled(false))
+}
+
+var migrations = fstest.MapFS{
+	"00001_create_users.sql": {Data: []byte(`-- +goose Up
+CREATE TABLE users (id INTEGER);
+-- +goose Down
+DROP TABLE users;
+`)},
+	"00002_add_email.sql": {Data: []byte(`-- +goose Up
+ALTER TABLE users ADD COLUMN email TEXT;
+-- +goose Down
+NOT VALID SQL;
+`)},
+}
+
+func newProvider(t *testing.T, goMigrations ...*goose.Migration) *goose.Provider {
+	db, err := sql.Open("sqlite", ":memory:")
+	if nil != err {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	p, err := goose.NewProvider(goose.DialectSQLite3, db, migrations, goose.WithGoMigrations(goMigrations...))
+	if nil != err {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestUpNilApp(t *testing.T) {
+	p := newProvider(t)
+	results, err := Up(context.Background(), nil, p)
+	if nil != err {
+		t.Fatal(err)
+	}
+	if len(results) != 2 {
+		t.Error(results)
+	}
+}
+
+func TestUp(t *testing.T) {
+	var hasTxn bool
+	p := newProvider(t, goose.NewGoMigration(3,
+		&goose.GoFunc{RunTx: func(ctx context.Context, tx *sql.Tx) error {
+			hasTxn = nil != newrelic.FromContext(ctx)
+			return nil
+		}},
+		&goose.GoFunc{RunTx: func(ctx context.Context, tx *sql.Tx) error { return nil }},
+	))
+	app := testApp()
+	results, err := Up(context.Background(), app.Application, p)
+	if nil != err {
+		t.Fatal(err)
+	}
+	if len(results) != 3 {
+		t.Error(results)
+	}
+	if !hasTxn {
+		t.Error("transaction missing from go migration context")
+	}
+
+	app.ExpectMetricsPresent(t, []internal.WantMetric{
+		{Name: "OtherTransaction/Go/Migration/up/1", Scope: ""},
+		{Name: "OtherTransaction/Go/Migration/up/2", Scope: ""},
+		{Name: "OtherTransaction/Go/Migration/up/3", Scope: ""},
+	})
+}
+
+func TestUpTo(t *testing.T) {
+	p := newProvider(t)
+	app := testApp()
+	results, err := UpTo(context.Background(), app.Application, p, 1)
+	if nil != err {
+		t.Fatal(err)
+	}
+	if len(results) != 1 {
+		t.Error(results)
+	}
+	app.ExpectTxnEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"name":     "OtherTransaction/Go/Migration/up/1",
+			"guid":     internal.MatchAnything,
+			"priority": internal.MatchAnything,
+			"sampled":  internal.MatchAnything,
+			"traceId":  internal.MatchAnything,
+		},
+		UserAttributes: map[string]interface{}{
+			AttributeMigrationVersion:   1,
+			AttributeMigrationDirection: "up",
+			AttributeMigrationSource:    "00001_create_users.sql",
+		},
+	}})
+}
+
+func TestDownError(t *testing.T) {
+	p := newProvider(t)
+	if _, err := p.Up(context.Background()); nil != err {
+		t.Fatal(err)
+	}
+	app := testApp()
+	if _, err := DownTo(context.Background(), app.Application, p, 0); nil == err {
+		t.Fatal("expected error")
+	}
+	app.ExpectMetricsPresent(t, []internal.WantMetric{
+		{Name: "OtherTransaction/Go/Migration/down/2", Scope: ""},
+		{Name: "Errors/OtherTransaction/Go/Migration/down/2", Scope: ""},
+	})
+}
+
+func TestDownNoNextVersion(t *testing.T) {
+	p := newProvider(t)
+	app := testApp()
+	if _, err := Down(context.Background(), app.Application, p); nil == err {
+		t.Fatal("expected error")
+	}
+	app.ExpectTxnEvents(t, []internal.WantEvent{})
+}
@@ -0,0 +1,61 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package nrgorilla
+
+import (
+	"github.com/gorilla/mux"
+	"github.com/newrelic/go-agent/v3/internal/integrationsupport"
+	newrelic "github.com/newrelic/go-agent/v3/newrelic"
+)
+
+// RouteCatalogEventType is the type of the custom events recorded by
+// ReportRouteCatalog, one for each route registered with the router.
+const RouteCatalogEventType = integrationsupport.RouteCatalogEventType
+
+// ReportRouteCatalog reports the catalog of the routes registered with the
+// router, so that New Relic knows the endpoints of the application, including
+// those which are never requested.  Call it once every route is registered,
+// before serving:
+//
+//	r := mux.NewRouter()
+//	r.Use(nrgorilla.Middleware(app))
+//	r.HandleFunc("/users/{id}", getUser).Methods("GET")
+//	nrgorilla.ReportRouteCatalog(app, r)
+//	http.ListenAndServe(":8000", r)
+//
+// Once the application is connected, each route is recorded as a
+// "HttpRouteCatalog" custom event with the "framework", "method", and
+// "pattern" attributes.  The pattern is the path template of the route, as
+// used by Middleware to name the transactions of the route.  The method is "*"
+// for routes matching any method.  Routes without a path template or a
+// handler, such as the routes of subrouters, are not reported.
+func ReportRouteCatalog(app *newrelic.Application, router *mux.Router) {
+	if app == nil || router == nil {
+		return
+	}
+	integrationsupport.ReportCatalog(app, "gorilla route catalog", RouteCatalogEventType, routeCatalog(router))
+}
+
+func routeCatalog(router *mux.Router) []map[string]interface{} {
+	var routes []integrationsupport.Route
+	router.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		// The routes of subrouters have no handler.
+		if route.GetHandler() == nil {
+			return nil
+		}
+		pattern, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+		methods, _ := route.GetMethods()
+		if len(methods) == 0 {
+			methods = []string{"*"}
+		}
+		for _, method := range methods {
+			routes = append(routes, integrationsupport.Route{Method: method, Pattern: pattern})
+		}
+		return nil
+	})
+	return integrationsupport.RouteCatalog("gorilla", routes)
+}
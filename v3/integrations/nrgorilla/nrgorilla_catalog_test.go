@@ -0,0 +1,38 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package nrgorilla
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/newrelic/go-agent/v3/internal"
+	"github.com/newrelic/go-agent/v3/internal/integrationsupport"
+)
+
+func TestRecordRouteCatalog(t *testing.T) {
+	app := integrationsupport.NewBasicTestApp()
+	r := mux.NewRouter()
+	r.Use(Middleware(app.Application))
+	handler := func(w http.ResponseWriter, r *http.Request) {}
+	r.HandleFunc("/users", handler).Methods("GET", "POST")
+	r.HandleFunc("/users/{id}", handler)
+	r.Host("example.com").HandlerFunc(handler)
+	sub := r.PathPrefix("/admin").Subrouter()
+	sub.HandleFunc("/stats", handler).Methods("GET")
+
+	integrationsupport.RecordCatalog(app.Application, RouteCatalogEventType, routeCatalog(r))
+	app.ExpectCustomEvents(t, []internal.WantEvent{
+		integrationsupport.WantRouteCatalogEvent("gorilla", "GET", "/admin/stats"),
+		integrationsupport.WantRouteCatalogEvent("gorilla", "GET", "/users"),
+		integrationsupport.WantRouteCatalogEvent("gorilla", "POST", "/users"),
+		integrationsupport.WantRouteCatalogEvent("gorilla", "*", "/users/{id}"),
+	})
+}
+
+func TestReportRouteCatalogNil(t *testing.T) {
+	ReportRouteCatalog(nil, mux.NewRouter())
+	ReportRouteCatalog(integrationsupport.NewBasicTestApp().Application, nil)
+}
@@ -0,0 +1,73 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/newrelic/go-agent/v3/integrations/nrgorm"
+	"github.com/newrelic/go-agent/v3/newrelic"
+	"gorm.io/gorm"
+)
+
+type User struct {
+	ID    uint
+	Email string `gorm:"uniqueIndex"`
+}
+
+func main() {
+	app, err := newrelic.NewApplication(
+		newrelic.ConfigAppName("GORM App"),
+		newrelic.ConfigLicense(os.Getenv("NEW_RELIC_LICENSE_KEY")),
+		newrelic.ConfigDebugLogger(os.Stdout),
+	)
+	if nil != err {
+		panic(err)
+	}
+	defer app.Shutdown(10 * time.Second)
+	if err := app.WaitForConnection(5 * time.Second); nil != err {
+		panic(err)
+	}
+
+	db, err := gorm.Open(sqlite.Open("example.db"), &gorm.Config{
+		// TranslateError translates driver errors into gorm errors such
+		// as gorm.ErrDuplicatedKey.
+		TranslateError: true,
+	})
+	if nil != err {
+		panic(err)
+	}
+	if err := db.Use(nrgorm.NewPlugin()); nil != err {
+		panic(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := nrgorm.StartPoolStats(ctx, app, db, 30*time.Second); nil != err {
+		panic(err)
+	}
+
+	txn := app.StartTransaction("gorm-users")
+	defer txn.End()
+	tx := db.WithContext(newrelic.NewContext(ctx, txn))
+
+	if err := tx.AutoMigrate(&User{}); nil != err {
+		panic(err)
+	}
+	tx.Create(&User{Email: "gopher@example.com"})
+	// Creating the same user again returns gorm.ErrDuplicatedKey, which is
+	// recorded on the transaction.
+	tx.Create(&User{Email: "gopher@example.com"})
+
+	var user User
+	// gorm.ErrRecordNotFound is not recorded on the transaction.
+	if err := tx.First(&user, "email = ?", "nobody@example.com").Error; errors.Is(err, gorm.ErrRecordNotFound) {
+		fmt.Println("user not found")
+	}
+}
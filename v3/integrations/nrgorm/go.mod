@@ -0,0 +1,23 @@
+module github.com/newrelic/go-agent/v3/integrations/nrgorm
+
+go 1.21
+
+require (
+	github.com/glebarez/sqlite v1.11.0
+	github.com/newrelic/go-agent/v3 v3.35.0
+	gorm.io/gorm v1.25.10
+)
+
+require (
+	github.com/glebarez/go-sqlite v1.21.2 // indirect
+	github.com/jinzhu/inflection v1.0.0 // indirect
+	github.com/jinzhu/now v1.1.5 // indirect
+	golang.org/x/net v0.25.0 // indirect
+	golang.org/x/sys v0.20.0 // indirect
+	golang.org/x/text v0.15.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20240528184218-531527333157 // indirect
+	google.golang.org/grpc v1.65.0 // indirect
+	google.golang.org/protobuf v1.34.2 // indirect
+)
+
+replace github.com/newrelic/go-agent/v3 => ../..
@@ -0,0 +1,196 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package nrgorm instruments https://github.com/go-gorm/gorm.
+//
+// Use this package to record the database operations made using gorm as
+// datastore segments, and to record the errors returned by gorm on the
+// transaction.  Register the plugin after opening the database:
+//
+//	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+//	if nil != err {
+//		panic(err)
+//	}
+//	if err := db.Use(nrgorm.NewPlugin()); nil != err {
+//		panic(err)
+//	}
+//
+// The transaction must be added to the context used by gorm:
+//
+//	ctx := newrelic.NewContext(context.Background(), txn)
+//	db.WithContext(ctx).First(&user)
+//
+// Errors returned by gorm, including the errors translated by gorm when
+// gorm.Config.TranslateError is set, are recorded on the transaction using
+// the name of the gorm error as the error class, for example
+// "gorm.ErrDuplicatedKey".  Since gorm.ErrRecordNotFound is usually an
+// expected outcome rather than a failure, it is not recorded by default.  Use
+// WithIgnoredErrors to change which errors are not recorded.
+//
+// Use StartPoolStats to record the statistics of the connection pool of the
+// sql.DB used by the gorm dialector as custom metrics.
+//
+// Full example:
+// https://github.com/newrelic/go-agent/blob/master/v3/integrations/nrgorm/example/main.go
+package nrgorm
+
+import (
+	"errors"
+
+	"github.com/newrelic/go-agent/v3/internal"
+	"github.com/newrelic/go-agent/v3/newrelic"
+	"github.com/newrelic/go-agent/v3/newrelic/sqlparse"
+	"gorm.io/gorm"
+)
+
+func init() { internal.TrackUsage("integration", "datastore", "gorm") }
+
+const segmentKey = "newrelic:segment"
+
+// Plugin is a gorm.Plugin which instruments the database operations made
+// using gorm.  Create it using NewPlugin and register it using gorm.DB.Use.
+type Plugin struct {
+	ignoredErrors []error
+}
+
+// PluginOption configures a Plugin.
+type PluginOption func(*Plugin)
+
+// WithIgnoredErrors sets the errors which are not recorded on the
+// transaction, replacing the default of gorm.ErrRecordNotFound.  Errors are
+// matched using errors.Is.  Calling WithIgnoredErrors without any errors
+// records all errors.
+func WithIgnoredErrors(errs ...error) PluginOption {
+	return func(p *Plugin) { p.ignoredErrors = errs }
+}
+
+// NewPlugin creates a new Plugin.
+func NewPlugin(opts ...PluginOption) *Plugin {
+	p := &Plugin{
+		ignoredErrors: []error{gorm.ErrRecordNotFound},
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Name implements gorm.Plugin.
+func (p *Plugin) Name() string { return "newrelic" }
+
+// Initialize implements gorm.Plugin by registering the callbacks which record
+// the datastore segments.
+func (p *Plugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	return errors.Join(
+		cb.Create().Before("gorm:create").Register("newrelic:before_create", p.before),
+		cb.Create().After("gorm:create").Register("newrelic:after_create", p.after("insert")),
+		cb.Query().Before("gorm:query").Register("newrelic:before_query", p.before),
+		cb.Query().After("gorm:query").Register("newrelic:after_query", p.after("select")),
+		cb.Update().Before("gorm:update").Register("newrelic:before_update", p.before),
+		cb.Update().After("gorm:update").Register("newrelic:after_update", p.after("update")),
+		cb.Delete().Before("gorm:delete").Register("newrelic:before_delete", p.before),
+		cb.Delete().After("gorm:delete").Register("newrelic:after_delete", p.after("delete")),
+		cb.Row().Before("gorm:row").Register("newrelic:before_row", p.before),
+		cb.Row().After("gorm:row").Register("newrelic:after_row", p.after("")),
+		cb.Raw().Before("gorm:raw").Register("newrelic:before_raw", p.before),
+		cb.Raw().After("gorm:raw").Register("newrelic:after_raw", p.after("")),
+	)
+}
+
+func (p *Plugin) before(db *gorm.DB) {
+	txn := newrelic.FromContext(db.Statement.Context)
+	if nil == txn {
+		return
+	}
+	db.InstanceSet(segmentKey, &newrelic.DatastoreSegment{
+		StartTime: txn.StartSegmentNow(),
+		Product:   datastoreProduct(db.Dialector.Name()),
+	})
+}
+
+// after returns the callback which ends the segment started by before.  The
+// operation is used if it cannot be parsed from the query.
+func (p *Plugin) after(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		v, ok := db.InstanceGet(segmentKey)
+		if !ok {
+			return
+		}
+		seg, ok := v.(*newrelic.DatastoreSegment)
+		if !ok {
+			return
+		}
+		sqlparse.ParseQuery(seg, db.Statement.SQL.String())
+		if "" == seg.Operation {
+			seg.Operation = operation
+		}
+		if "" == seg.Collection {
+			seg.Collection = db.Statement.Table
+		}
+		seg.End()
+
+		if err := db.Error; nil != err && !p.ignored(err) {
+			newrelic.FromContext(db.Statement.Context).NoticeError(gormError(err))
+		}
+	}
+}
+
+func (p *Plugin) ignored(err error) bool {
+	for _, ignored := range p.ignoredErrors {
+		if errors.Is(err, ignored) {
+			return true
+		}
+	}
+	return false
+}
+
+func datastoreProduct(dialector string) newrelic.DatastoreProduct {
+	switch dialector {
+	case "postgres":
+		return newrelic.DatastorePostgres
+	case "mysql":
+		return newrelic.DatastoreMySQL
+	case "sqlite":
+		return newrelic.DatastoreSQLite
+	case "sqlserver":
+		return newrelic.DatastoreMSSQL
+	default:
+		return newrelic.DatastoreProduct(dialector)
+	}
+}
+
+// gormErrors are the errors returned by gorm, in the order they are checked.
+var gormErrors = []struct {
+	err   error
+	class string
+}{
+	{err: gorm.ErrRecordNotFound, class: "gorm.ErrRecordNotFound"},
+	{err: gorm.ErrDuplicatedKey, class: "gorm.ErrDuplicatedKey"},
+	{err: gorm.ErrForeignKeyViolated, class: "gorm.ErrForeignKeyViolated"},
+	{err: gorm.ErrCheckConstraintViolated, class: "gorm.ErrCheckConstraintViolated"},
+	{err: gorm.ErrInvalidTransaction, class: "gorm.ErrInvalidTransaction"},
+	{err: gorm.ErrMissingWhereClause, class: "gorm.ErrMissingWhereClause"},
+	{err: gorm.ErrPrimaryKeyRequired, class: "gorm.ErrPrimaryKeyRequired"},
+	{err: gorm.ErrModelValueRequired, class: "gorm.ErrModelValueRequired"},
+	{err: gorm.ErrInvalidData, class: "gorm.ErrInvalidData"},
+	{err: gorm.ErrInvalidField, class: "gorm.ErrInvalidField"},
+	{err: gorm.ErrEmptySlice, class: "gorm.ErrEmptySlice"},
+	{err: gorm.ErrInvalidValue, class: "gorm.ErrInvalidValue"},
+	{err: gorm.ErrInvalidDB, class: "gorm.ErrInvalidDB"},
+}
+
+// gormError returns the error to record on the transaction.  gorm errors are
+// recorded using their name as the error class, since their type does not
+// distinguish them.
+func gormError(err error) error {
+	for _, e := range gormErrors {
+		if errors.Is(err, e.err) {
+			return newrelic.Error{
+				Message: err.Error(),
+				Class:   e.class,
+			}
+		}
+	}
+	return err
+}
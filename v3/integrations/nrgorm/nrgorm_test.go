@@ -0,0 +1,162 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package nrgorm
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/newrelic/go-agent/v3/internal"
+	"github.com/newrelic/go-agent/v3/internal/integrationsupport"
+	"github.com/newrelic/go-agent/v3/newrelic"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type user struct {
+	ID    uint
+	Email string `gorm:"uniqueIndex"`
+}
+
+func testApp() integrationsupport.ExpectApp {
+	return integrationsupport.NewTestApp(integrationsupport.SampleEverythingReplyFn,
+		newrelic.ConfigCodeLevelMetricsEnabled(false))
+}
+
+func openDB(t *testing.T, opts ...PluginOption) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		TranslateError: true,
+		Logger:         logger.Default.LogMode(logger.Silent),
+	})
+	if nil != err {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&user{}); nil != err {
+		t.Fatal(err)
+	}
+	if err := db.Use(NewPlugin(opts...)); nil != err {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestDatastoreSegments(t *testing.T) {
+	db := openDB(t)
+	app := testApp()
+	txn := app.StartTransaction("txn")
+	tx := db.WithContext(newrelic.NewContext(context.Background(), txn))
+
+	if err := tx.Create(&user{Email: "gopher@example.com"}).Error; nil != err {
+		t.Fatal(err)
+	}
+	var u user
+	if err := tx.First(&u).Error; nil != err {
+		t.Fatal(err)
+	}
+	if err := tx.Model(&u).Update("email", "other@example.com").Error; nil != err {
+		t.Fatal(err)
+	}
+	if err := tx.Delete(&u).Error; nil != err {
+		t.Fatal(err)
+	}
+	var count int
+	if err := tx.Raw("SELECT COUNT(*) FROM users").Scan(&count).Error; nil != err {
+		t.Fatal(err)
+	}
+	txn.End()
+
+	app.ExpectMetricsPresent(t, []internal.WantMetric{
+		{Name: "Datastore/statement/SQLite/users/insert", Scope: "", Forced: false, Data: []float64{1}},
+		{Name: "Datastore/statement/SQLite/users/select", Scope: "", Forced: false, Data: []float64{2}},
+		{Name: "Datastore/statement/SQLite/users/update", Scope: "", Forced: false, Data: []float64{1}},
+		{Name: "Datastore/statement/SQLite/users/delete", Scope: "", Forced: false, Data: []float64{1}},
+		{Name: "Datastore/statement/SQLite/users/select", Scope: "OtherTransaction/Go/txn", Forced: false, Data: []float64{2}},
+	})
+	app.ExpectErrorEvents(t, []internal.WantEvent{})
+}
+
+func TestNoTransaction(t *testing.T) {
+	db := openDB(t)
+	if err := db.Create(&user{Email: "gopher@example.com"}).Error; nil != err {
+		t.Fatal(err)
+	}
+}
+
+func TestRecordNotFoundIgnored(t *testing.T) {
+	db := openDB(t)
+	app := testApp()
+	txn := app.StartTransaction("txn")
+	tx := db.WithContext(newrelic.NewContext(context.Background(), txn))
+
+	var u user
+	if err := tx.First(&u).Error; !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatal(err)
+	}
+	txn.End()
+	app.ExpectErrorEvents(t, []internal.WantEvent{})
+}
+
+func TestTranslatedError(t *testing.T) {
+	db := openDB(t, WithIgnoredErrors())
+	app := testApp()
+	txn := app.StartTransaction("txn")
+	tx := db.WithContext(newrelic.NewContext(context.Background(), txn))
+
+	tx.Create(&user{Email: "gopher@example.com"})
+	if err := tx.Create(&user{Email: "gopher@example.com"}).Error; !errors.Is(err, gorm.ErrDuplicatedKey) {
+		t.Fatal(err)
+	}
+	var u user
+	if err := tx.First(&u, "email = ?", "nobody@example.com").Error; !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatal(err)
+	}
+	txn.End()
+
+	errorEvent := func(class, msg string) internal.WantEvent {
+		return internal.WantEvent{
+			Intrinsics: map[string]interface{}{
+				"error.class":       class,
+				"error.message":     msg,
+				"transactionName":   "OtherTransaction/Go/txn",
+				"guid":              internal.MatchAnything,
+				"priority":          internal.MatchAnything,
+				"sampled":           internal.MatchAnything,
+				"spanId":            internal.MatchAnything,
+				"traceId":           internal.MatchAnything,
+				"databaseCallCount": internal.MatchAnything,
+				"databaseDuration":  internal.MatchAnything,
+			},
+		}
+	}
+	app.ExpectErrorEvents(t, []internal.WantEvent{
+		errorEvent("gorm.ErrDuplicatedKey", gorm.ErrDuplicatedKey.Error()),
+		errorEvent("gorm.ErrRecordNotFound", gorm.ErrRecordNotFound.Error()),
+	})
+}
+
+func TestRecordPoolStats(t *testing.T) {
+	app := testApp()
+	previous := recordPoolStats(app.Application, "GORM/SQLite/Pool/", sql.DBStats{
+		OpenConnections: 3,
+		InUse:           2,
+		Idle:            1,
+		WaitCount:       5,
+		WaitDuration:    2 * time.Second,
+	}, sql.DBStats{})
+	recordPoolStats(app.Application, "GORM/SQLite/Pool/", sql.DBStats{
+		WaitCount:    7,
+		WaitDuration: 3 * time.Second,
+	}, previous)
+
+	app.ExpectMetricsPresent(t, []internal.WantMetric{
+		{Name: "Custom/GORM/SQLite/Pool/OpenConnections", Scope: "", Forced: false, Data: []float64{2, 3, 3, 0, 3, 9}},
+		{Name: "Custom/GORM/SQLite/Pool/InUse", Scope: "", Forced: false, Data: []float64{2, 2, 2, 0, 2, 4}},
+		{Name: "Custom/GORM/SQLite/Pool/WaitCount", Scope: "", Forced: false, Data: []float64{2, 7, 7, 2, 5, 29}},
+		{Name: "Custom/GORM/SQLite/Pool/WaitDuration", Scope: "", Forced: false, Data: []float64{2, 3, 3, 1, 2, 5}},
+	})
+}
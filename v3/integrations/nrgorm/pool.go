@@ -0,0 +1,56 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package nrgorm
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/newrelic/go-agent/v3/newrelic"
+	"gorm.io/gorm"
+)
+
+// StartPoolStats starts a goroutine which records the statistics of the
+// connection pool of the sql.DB used by db every interval, until ctx is done.
+// The statistics are recorded as custom metrics named
+// "Custom/GORM/<product>/Pool/<statistic>", for example
+// "Custom/GORM/Postgres/Pool/InUse".  The WaitCount and WaitDuration metrics
+// contain the number of connections waited for and the total time spent
+// waiting, in seconds, since the previous interval.  An error is returned if
+// the gorm dialector does not use a sql.DB.
+func StartPoolStats(ctx context.Context, app *newrelic.Application, db *gorm.DB, interval time.Duration) error {
+	sqlDB, err := db.DB()
+	if nil != err {
+		return err
+	}
+	prefix := "GORM/" + string(datastoreProduct(db.Dialector.Name())) + "/Pool/"
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		var previous sql.DBStats
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				previous = recordPoolStats(app, prefix, sqlDB.Stats(), previous)
+			}
+		}
+	}()
+	return nil
+}
+
+// recordPoolStats records the pool statistics and returns stats to be used as
+// the previous statistics for the next call.
+func recordPoolStats(app *newrelic.Application, prefix string, stats, previous sql.DBStats) sql.DBStats {
+	app.RecordCustomMetric(prefix+"MaxOpenConnections", float64(stats.MaxOpenConnections))
+	app.RecordCustomMetric(prefix+"OpenConnections", float64(stats.OpenConnections))
+	app.RecordCustomMetric(prefix+"InUse", float64(stats.InUse))
+	app.RecordCustomMetric(prefix+"Idle", float64(stats.Idle))
+	app.RecordCustomMetric(prefix+"WaitCount", float64(stats.WaitCount-previous.WaitCount))
+	app.RecordCustomMetric(prefix+"WaitDuration", (stats.WaitDuration - previous.WaitDuration).Seconds())
+	return stats
+}
@@ -0,0 +1,50 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/99designs/gqlgen/graphql/handler/testserver"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+
+	"github.com/newrelic/go-agent/v3/integrations/nrgqlgen"
+	"github.com/newrelic/go-agent/v3/newrelic"
+)
+
+func main() {
+	// 1. Create the New Relic application
+	app, err := newrelic.NewApplication(
+		newrelic.ConfigAppName("Example gqlgen App"),
+		newrelic.ConfigLicense(os.Getenv("NEW_RELIC_LICENSE_KEY")),
+		newrelic.ConfigDebugLogger(os.Stdout),
+	)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	// The test server is used here in place of a server created using the
+	// code generated by gqlgen, for example:
+	//   srv := handler.NewDefaultServer(graph.NewExecutableSchema(cfg))
+	srv := testserver.New()
+	srv.AddTransport(transport.POST{})
+
+	// 2. Add the nrgqlgen extension to the server
+	srv.Use(nrgqlgen.NewExtension())
+
+	// 3. Make sure to instrument your HTTP handler, which will
+	// create/end transactions, record error codes, and add
+	// the transactions to the context.
+	http.Handle(newrelic.WrapHandle(app, "/query", srv))
+
+	// You can test your example query with curl:
+	//   curl -X POST \
+	//   -H "Content-Type: application/json" \
+	//   -d '{"query": "query GetName {name}"}' \
+	//   localhost:8080/query
+	http.ListenAndServe(":8080", nil)
+}
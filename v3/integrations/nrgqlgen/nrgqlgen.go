@@ -0,0 +1,208 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package nrgqlgen instruments https://github.com/99designs/gqlgen
+// applications.
+//
+// This package provides a gqlgen extension which names the transaction after
+// each GraphQL operation, and records the execution of the operation and of
+// each resolver as segments.  Errors returned in the GraphQL response are
+// noticed using NoticeError.  Add the extension to your gqlgen server:
+//
+//	srv := handler.NewDefaultServer(generated.NewExecutableSchema(cfg))
+//	srv.Use(nrgqlgen.NewExtension())
+//
+// The transaction must be added to the request context, for example by using
+// newrelic.WrapHandle:
+//
+//	http.Handle(newrelic.WrapHandle(app, "/query", srv))
+//
+// Transactions are named "GraphQL/<operation type>/<operation name>", for
+// example "GraphQL/query/GetUser".  The operation type, name, and complexity
+// are added to the transaction as custom attributes.  Resolver segments are
+// named "GraphQL/resolve/<parent type>/<field>", and have custom attributes
+// containing the path, complexity, and error of the field.  Fields which are
+// not resolved by resolver functions, such as struct fields, are not recorded
+// as segments.
+//
+// For a complete example, see:
+// https://github.com/newrelic/go-agent/tree/master/v3/integrations/nrgqlgen/example/main.go
+package nrgqlgen
+
+import (
+	"context"
+
+	"github.com/99designs/gqlgen/complexity"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/newrelic/go-agent/v3/internal"
+	"github.com/newrelic/go-agent/v3/newrelic"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+func init() { internal.TrackUsage("integration", "framework", "gqlgen") }
+
+// Custom attributes added to transactions.
+const (
+	AttributeOperationType       = "graphql.operation.type"
+	AttributeOperationName       = "graphql.operation.name"
+	AttributeOperationComplexity = "graphql.operation.complexity"
+)
+
+// Custom attributes added to resolver segments.
+const (
+	AttributeFieldPath       = "graphql.field.path"
+	AttributeFieldComplexity = "graphql.field.complexity"
+	AttributeFieldError      = "graphql.field.error"
+)
+
+// anonymousOperation is used in place of the name of unnamed operations.
+const anonymousOperation = "<anonymous>"
+
+// Extension is a gqlgen extension which instruments GraphQL operations.
+// Create it using NewExtension.
+type Extension struct {
+	schema graphql.ExecutableSchema
+}
+
+var (
+	_ graphql.HandlerExtension     = &Extension{}
+	_ graphql.OperationInterceptor = &Extension{}
+	_ graphql.ResponseInterceptor  = &Extension{}
+	_ graphql.FieldInterceptor     = &Extension{}
+)
+
+// NewExtension creates a new Extension.
+func NewExtension() *Extension {
+	return &Extension{}
+}
+
+// ExtensionName implements graphql.HandlerExtension.
+func (e *Extension) ExtensionName() string {
+	return "NewRelic"
+}
+
+// Validate implements graphql.HandlerExtension.  It retains the schema, which
+// is used to calculate the complexity of operations and fields.
+func (e *Extension) Validate(schema graphql.ExecutableSchema) error {
+	e.schema = schema
+	return nil
+}
+
+// InterceptOperation implements graphql.OperationInterceptor.  It names the
+// transaction after the operation, and records each response of the operation
+// as a segment.
+func (e *Extension) InterceptOperation(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+	txn := newrelic.FromContext(ctx)
+	if nil == txn {
+		return next(ctx)
+	}
+	oc := graphql.GetOperationContext(ctx)
+	opType, opName := operationType(oc), operationName(oc)
+
+	txn.SetName("GraphQL/" + opType + "/" + opName)
+	txn.AddAttribute(AttributeOperationType, opType)
+	txn.AddAttribute(AttributeOperationName, opName)
+	if nil != e.schema && nil != oc.Operation {
+		txn.AddAttribute(AttributeOperationComplexity, complexity.Calculate(e.schema, oc.Operation, oc.Variables))
+	}
+
+	responses := next(ctx)
+	return func(ctx context.Context) *graphql.Response {
+		seg := txn.StartSegment("GraphQL/operation/" + opType + "/" + opName)
+		defer seg.End()
+
+		// gqlgen does not call InterceptResponse if the execution of the
+		// operation fails immediately, so the errors are noticed here
+		// instead.
+		intercepted := new(bool)
+		resp := responses(context.WithValue(ctx, interceptedKey{}, intercepted))
+		if nil != resp && !*intercepted {
+			noticeErrors(txn, resp)
+		}
+		return resp
+	}
+}
+
+// interceptedKey is the context key of the flag set by InterceptResponse.
+type interceptedKey struct{}
+
+// InterceptResponse implements graphql.ResponseInterceptor.  It notices the
+// errors of the response, including those of requests which fail before an
+// operation is started, for example because the query cannot be parsed.
+func (e *Extension) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	if intercepted, ok := ctx.Value(interceptedKey{}).(*bool); ok {
+		*intercepted = true
+	}
+	resp := next(ctx)
+	if nil != resp {
+		noticeErrors(newrelic.FromContext(ctx), resp)
+	}
+	return resp
+}
+
+// InterceptField implements graphql.FieldInterceptor.  It records a segment
+// for each field resolved by a resolver function.
+func (e *Extension) InterceptField(ctx context.Context, next graphql.Resolver) (interface{}, error) {
+	txn := newrelic.FromContext(ctx)
+	fc := graphql.GetFieldContext(ctx)
+	if nil == txn || nil == fc || !(fc.IsResolver || fc.IsMethod) {
+		return next(ctx)
+	}
+
+	// Resolvers may be run concurrently by gqlgen.
+	txn = txn.NewGoroutine()
+	ctx = newrelic.NewContext(ctx, txn)
+
+	seg := txn.StartSegment("GraphQL/resolve/" + fc.Object + "/" + fc.Field.Name)
+	seg.AddAttribute(AttributeFieldPath, fc.Path().String())
+	if c, ok := e.fieldComplexity(ctx, fc); ok {
+		seg.AddAttribute(AttributeFieldComplexity, c)
+	}
+
+	res, err := next(ctx)
+	if nil != err {
+		// The error is noticed when the response is sent, since gqlgen
+		// adds it to the response.
+		seg.AddAttribute(AttributeFieldError, err.Error())
+	}
+	seg.End()
+	return res, err
+}
+
+// fieldComplexity calculates the complexity of the field, including its
+// selections.
+func (e *Extension) fieldComplexity(ctx context.Context, fc *graphql.FieldContext) (int, bool) {
+	field := fc.Field.Field
+	if nil == e.schema || nil == field || nil == field.Definition || nil == field.ObjectDefinition {
+		return 0, false
+	}
+	var vars map[string]interface{}
+	if graphql.HasOperationContext(ctx) {
+		vars = graphql.GetOperationContext(ctx).Variables
+	}
+	op := &ast.OperationDefinition{SelectionSet: ast.SelectionSet{field}}
+	return complexity.Calculate(e.schema, op, vars), true
+}
+
+func operationType(oc *graphql.OperationContext) string {
+	if nil == oc.Operation {
+		return "unknown"
+	}
+	return string(oc.Operation.Operation)
+}
+
+func operationName(oc *graphql.OperationContext) string {
+	if "" != oc.OperationName {
+		return oc.OperationName
+	}
+	if nil != oc.Operation && "" != oc.Operation.Name {
+		return oc.Operation.Name
+	}
+	return anonymousOperation
+}
+
+func noticeErrors(txn *newrelic.Transaction, resp *graphql.Response) {
+	for _, err := range resp.Errors {
+		txn.NoticeError(err)
+	}
+}
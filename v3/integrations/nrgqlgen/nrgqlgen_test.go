@@ -0,0 +1,242 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package nrgqlgen
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/newrelic/go-agent/v3/internal"
+	"github.com/newrelic/go-agent/v3/internal/integrationsupport"
+	"github.com/newrelic/go-agent/v3/newrelic"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+var schema = gqlparser.MustLoadSchema(&ast.Source{Input: `
+	type Query {
+		user(id: ID!): User
+		version: String!
+		fail: String
+	}
+	type Mutation {
+		noop: String
+	}
+	type User {
+		id: ID!
+		name: String!
+	}
+`})
+
+var errFail = errors.New("resolver failed")
+
+// newExecutableSchema simulates the code generated by gqlgen: the user and
+// fail fields are resolved by resolvers, the version field is not, and
+// mutations fail immediately.
+func newExecutableSchema() graphql.ExecutableSchema {
+	return &graphql.ExecutableSchemaMock{
+		SchemaFunc: func() *ast.Schema { return schema },
+		ComplexityFunc: func(typeName, fieldName string, childComplexity int, args map[string]interface{}) (int, bool) {
+			return 1 + childComplexity, true
+		},
+		ExecFunc: func(ctx context.Context) graphql.ResponseHandler {
+			oc := graphql.GetOperationContext(ctx)
+			if oc.Operation.Operation == ast.Mutation {
+				graphql.AddError(ctx, errors.New("mutations are not supported"))
+				return graphql.OneShot(nil)
+			}
+			return graphql.OneShot(func() *graphql.Response {
+				data := make(map[string]interface{})
+				for _, field := range graphql.CollectFields(oc, oc.Operation.SelectionSet, []string{"Query"}) {
+					field := field
+					fc := &graphql.FieldContext{
+						Object:     "Query",
+						Field:      field,
+						Args:       field.ArgumentMap(oc.Variables),
+						IsResolver: field.Name != "version",
+					}
+					ctx := graphql.WithFieldContext(ctx, fc)
+					res, err := oc.ResolverMiddleware(ctx, func(ctx context.Context) (interface{}, error) {
+						switch field.Name {
+						case "user":
+							return map[string]interface{}{"id": fc.Args["id"], "name": "gopher"}, nil
+						case "version":
+							return "1.0", nil
+						default:
+							return nil, errFail
+						}
+					})
+					if nil != err {
+						graphql.AddError(ctx, err)
+					}
+					data[field.Alias] = res
+				}
+				js, _ := json.Marshal(data)
+				return &graphql.Response{Data: js}
+			}())
+		},
+	}
+}
+
+func replyFn(reply *internal.ConnectReply) {
+	reply.SetSampleEverything()
+	reply.AccountID = "123"
+	reply.TrustedAccountKey = "123"
+	reply.PrimaryAppID = "456"
+}
+
+func testApp() integrationsupport.ExpectApp {
+	return integrationsupport.NewTestApp(replyFn,
+		newrelic.ConfigCodeLevelMetricsEnabled(false),
+		newrelic.ConfigDistributedTracerEnabled(true))
+}
+
+func query(t *testing.T, app *newrelic.Application, body string) {
+	srv := handler.New(newExecutableSchema())
+	srv.AddTransport(transport.POST{})
+	srv.Use(NewExtension())
+	_, h := newrelic.WrapHandle(app, "/query", srv)
+
+	req := httptest.NewRequest("POST", "/query", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rw := httptest.NewRecorder()
+	h.ServeHTTP(rw, req)
+	if rw.Code != http.StatusOK && rw.Code != http.StatusUnprocessableEntity {
+		t.Fatal("unexpected status", rw.Code, rw.Body.String())
+	}
+}
+
+func TestQuery(t *testing.T) {
+	app := testApp()
+	query(t, app.Application, `{"query":"query GetUser { user(id: \"1\") { id name } version }"}`)
+
+	app.ExpectTxnEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"name":             "WebTransaction/Go/GraphQL/query/GetUser",
+			"guid":             internal.MatchAnything,
+			"priority":         internal.MatchAnything,
+			"sampled":          internal.MatchAnything,
+			"traceId":          internal.MatchAnything,
+			"nr.apdexPerfZone": internal.MatchAnything,
+		},
+		UserAttributes: map[string]interface{}{
+			AttributeOperationType:       "query",
+			AttributeOperationName:       "GetUser",
+			AttributeOperationComplexity: 4,
+		},
+		AgentAttributes: map[string]interface{}{
+			"httpResponseCode":             200,
+			"http.statusCode":              200,
+			"request.method":               "POST",
+			"request.uri":                  "/query",
+			"request.headers.contentType":  "application/json",
+			"request.headers.host":         "example.com",
+			"response.headers.contentType": "application/json",
+		},
+	}})
+	app.ExpectMetricsPresent(t, []internal.WantMetric{
+		{Name: "Custom/GraphQL/operation/query/GetUser", Scope: "WebTransaction/Go/GraphQL/query/GetUser", Forced: false},
+		{Name: "Custom/GraphQL/resolve/Query/user", Scope: "WebTransaction/Go/GraphQL/query/GetUser", Forced: false},
+	})
+	app.ExpectSpanEvents(t, []internal.WantEvent{
+		{
+			Intrinsics: map[string]interface{}{
+				"name":     "Custom/GraphQL/resolve/Query/user",
+				"category": "generic",
+				"parentId": internal.MatchAnything,
+			},
+			UserAttributes: map[string]interface{}{
+				AttributeFieldPath:       "user",
+				AttributeFieldComplexity: 3,
+			},
+		},
+		{
+			Intrinsics: map[string]interface{}{
+				"name":     "Custom/GraphQL/operation/query/GetUser",
+				"category": "generic",
+				"parentId": internal.MatchAnything,
+			},
+		},
+		{
+			Intrinsics: map[string]interface{}{
+				"name":             "WebTransaction/Go/GraphQL/query/GetUser",
+				"category":         "generic",
+				"nr.entryPoint":    true,
+				"transaction.name": "WebTransaction/Go/GraphQL/query/GetUser",
+			},
+			AgentAttributes: map[string]interface{}{
+				"httpResponseCode":             internal.MatchAnything,
+				"http.statusCode":              200,
+				"request.method":               "POST",
+				"request.uri":                  "/query",
+				"request.headers.contentType":  "application/json",
+				"request.headers.host":         "example.com",
+				"response.headers.contentType": "application/json",
+			},
+		},
+	})
+	app.ExpectErrors(t, []internal.WantError{})
+}
+
+func TestQueryResolverError(t *testing.T) {
+	app := testApp()
+	query(t, app.Application, `{"query":"{ fail }"}`)
+
+	app.ExpectMetricsPresent(t, []internal.WantMetric{
+		{Name: "Custom/GraphQL/resolve/Query/fail", Scope: "WebTransaction/Go/GraphQL/query/<anonymous>", Forced: false},
+	})
+	app.ExpectErrors(t, []internal.WantError{{
+		TxnName: "WebTransaction/Go/GraphQL/query/<anonymous>",
+		Msg:     "input: fail resolver failed",
+		Klass:   "*errors.errorString",
+	}})
+}
+
+func TestOperationError(t *testing.T) {
+	app := testApp()
+	query(t, app.Application, `{"query":"mutation { noop }"}`)
+
+	app.ExpectErrors(t, []internal.WantError{{
+		TxnName: "WebTransaction/Go/GraphQL/mutation/<anonymous>",
+		Msg:     "input: mutations are not supported",
+		Klass:   "*errors.errorString",
+	}})
+}
+
+func TestParseError(t *testing.T) {
+	app := testApp()
+	query(t, app.Application, `{"query":"{ user("}`)
+
+	app.ExpectErrors(t, []internal.WantError{{
+		TxnName: "WebTransaction/Go/POST /query",
+		Msg:     "Unprocessable Entity",
+		Klass:   "422",
+	}, {
+		TxnName: "WebTransaction/Go/POST /query",
+		Msg:     "input:1: Expected Name, found <EOF>",
+		Klass:   "*gqlerror.Error",
+	}})
+}
+
+func TestNoTransaction(t *testing.T) {
+	srv := handler.New(newExecutableSchema())
+	srv.AddTransport(transport.POST{})
+	srv.Use(NewExtension())
+
+	req := httptest.NewRequest("POST", "/query", strings.NewReader(`{"query":"{ user(id: \"1\") { name } }"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rw := httptest.NewRecorder()
+	srv.ServeHTTP(rw, req)
+	if body := rw.Body.String(); body != `{"data":{"user":{"id":"1","name":"gopher"}}}` {
+		t.Error("unexpected response", body)
+	}
+}
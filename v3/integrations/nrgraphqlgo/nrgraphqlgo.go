@@ -20,6 +20,21 @@
 // if it is available (for example,
 // https://godoc.org/github.com/newrelic/go-agent/v3/integrations/nrgorilla)
 //
+// Subscriptions executed over websockets are supported using Connection.
+// Start a Connection when the websocket connection is established, and use
+// Connection.Subscribe in place of graphql.Subscribe:
+//
+//	conn := nrgraphqlgo.StartConnection(app, r)
+//	defer conn.End()
+//	results := conn.Subscribe(graphql.Params{
+//		Schema:        schema,
+//		RequestString: query,
+//		Context:       ctx,
+//	})
+//
+// Each event delivered by the subscription is recorded as its own
+// transaction, which is linked to the transaction of the connection.
+//
 // For a complete example, including instrumenting a graphql-go-handler, see:
 // https://github.com/newrelic/go-agent/tree/master/v3/integrations/nrgraphqlgo/example/main.go
 package nrgraphqlgo
@@ -77,6 +92,9 @@ func (Extension) ValidationDidStart(ctx context.Context) (context.Context, graph
 
 // ExecutionDidStart is called before the execution begins
 func (Extension) ExecutionDidStart(ctx context.Context) (context.Context, graphql.ExecutionFinishFunc) {
+	if sub := subscriptionFromContext(ctx); nil != sub {
+		return sub.startEvent(ctx)
+	}
 	txn := newrelic.FromContext(ctx)
 	seg := txn.StartSegment("Execution")
 	return ctx, func(res *graphql.Result) {
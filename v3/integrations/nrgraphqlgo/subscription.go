@@ -0,0 +1,154 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package nrgraphqlgo
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/newrelic/go-agent/v3/newrelic"
+)
+
+// Custom attributes added to the transactions of websocket connections.
+const (
+	AttributeWebSocketPath          = "websocket.path"
+	AttributeWebSocketRemoteAddress = "websocket.remoteAddress"
+	AttributeWebSocketSubprotocol   = "websocket.subprotocol"
+)
+
+// anonymousOperation is used in place of the name of unnamed operations.
+const anonymousOperation = "<anonymous>"
+
+// Connection records a websocket connection over which GraphQL subscriptions
+// are executed.  The connection is recorded as a transaction which lasts for
+// the lifetime of the connection, and each event delivered by a subscription
+// made using Connection.Subscribe is recorded as a separate transaction named
+// "GraphQL/subscription/<operation name>".  The event transactions are linked
+// to the connection transaction using distributed tracing.
+type Connection struct {
+	app *newrelic.Application
+	txn *newrelic.Transaction
+}
+
+// StartConnection starts the transaction of the websocket connection upgraded
+// from the request r.  The path, remote address, and subprotocol of the
+// connection are added to the transaction as custom attributes.  Connection.End
+// must be called when the connection is closed.
+func StartConnection(app *newrelic.Application, r *http.Request) *Connection {
+	if nil == app {
+		return nil
+	}
+	txn := app.StartTransaction("GraphQL/connection")
+	if nil != r {
+		txn.AcceptDistributedTraceHeaders(newrelic.TransportHTTP, r.Header)
+		if nil != r.URL {
+			txn.AddAttribute(AttributeWebSocketPath, r.URL.Path)
+		}
+		txn.AddAttribute(AttributeWebSocketRemoteAddress, r.RemoteAddr)
+		if protocol := r.Header.Get("Sec-WebSocket-Protocol"); "" != protocol {
+			txn.AddAttribute(AttributeWebSocketSubprotocol, protocol)
+		}
+	}
+	return &Connection{app: app, txn: txn}
+}
+
+// Transaction returns the transaction of the connection.
+func (c *Connection) Transaction() *newrelic.Transaction {
+	if nil == c {
+		return nil
+	}
+	return c.txn
+}
+
+// AddAttribute adds a custom attribute to the transaction of the connection,
+// for example the identifier of the connected user.
+func (c *Connection) AddAttribute(key string, val interface{}) {
+	if nil == c {
+		return
+	}
+	c.txn.AddAttribute(key, val)
+}
+
+// End ends the transaction of the connection.
+func (c *Connection) End() {
+	if nil == c {
+		return
+	}
+	c.txn.End()
+}
+
+// subscription is added to the context of subscriptions made using
+// Connection.Subscribe so that the Extension records their events.
+type subscription struct {
+	app     *newrelic.Application
+	name    string
+	headers http.Header
+}
+
+type subscriptionContextKey struct{}
+
+func subscriptionFromContext(ctx context.Context) *subscription {
+	if nil == ctx {
+		return nil
+	}
+	sub, _ := ctx.Value(subscriptionContextKey{}).(*subscription)
+	return sub
+}
+
+// Subscribe calls graphql.Subscribe, recording each event delivered by the
+// subscription as a transaction.  The Extension must be added to the schema.
+func (c *Connection) Subscribe(p graphql.Params) chan *graphql.Result {
+	if nil == c {
+		return graphql.Subscribe(p)
+	}
+	sub := &subscription{
+		app:     c.app,
+		name:    subscriptionName(p),
+		headers: http.Header{},
+	}
+	c.txn.InsertDistributedTraceHeaders(sub.headers)
+
+	ctx := p.Context
+	if nil == ctx {
+		ctx = context.Background()
+	}
+	p.Context = context.WithValue(ctx, subscriptionContextKey{}, sub)
+	return graphql.Subscribe(p)
+}
+
+// startEvent starts the transaction of an event delivered by the
+// subscription, which is ended once the event has been executed.
+func (sub *subscription) startEvent(ctx context.Context) (context.Context, graphql.ExecutionFinishFunc) {
+	txn := sub.app.StartTransaction("GraphQL/subscription/" + sub.name)
+	txn.AcceptDistributedTraceHeaders(newrelic.TransportOther, sub.headers)
+	seg := txn.StartSegment("Execution")
+	return newrelic.NewContext(ctx, txn), func(res *graphql.Result) {
+		for _, err := range res.Errors {
+			txn.NoticeError(err)
+		}
+		seg.End()
+		txn.End()
+	}
+}
+
+// subscriptionName returns the name of the subscription operation.
+func subscriptionName(p graphql.Params) string {
+	if "" != p.OperationName {
+		return p.OperationName
+	}
+	doc, err := parser.Parse(parser.ParseParams{Source: p.RequestString})
+	if nil != err {
+		return anonymousOperation
+	}
+	for _, def := range doc.Definitions {
+		op, ok := def.(*ast.OperationDefinition)
+		if ok && ast.OperationTypeSubscription == op.Operation && nil != op.Name {
+			return op.Name.Value
+		}
+	}
+	return anonymousOperation
+}
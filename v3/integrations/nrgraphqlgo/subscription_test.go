@@ -0,0 +1,186 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package nrgraphqlgo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/graphql-go/graphql"
+	"github.com/newrelic/go-agent/v3/internal"
+	"github.com/newrelic/go-agent/v3/internal/integrationsupport"
+	"github.com/newrelic/go-agent/v3/newrelic"
+)
+
+var subscriptionSchema = func() graphql.Schema {
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query: graphql.NewObject(graphql.ObjectConfig{
+			Name: "RootQuery",
+			Fields: graphql.Fields{
+				"hello": &graphql.Field{
+					Type: graphql.String,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return "world", nil
+					},
+				},
+			},
+		}),
+		Subscription: graphql.NewObject(graphql.ObjectConfig{
+			Name: "RootSubscription",
+			Fields: graphql.Fields{
+				"counter": &graphql.Field{
+					Type: graphql.Int,
+					Subscribe: func(p graphql.ResolveParams) (interface{}, error) {
+						c := make(chan interface{})
+						go func() {
+							defer close(c)
+							for i := 1; i <= 2; i++ {
+								c <- i
+							}
+						}()
+						return c, nil
+					},
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						if p.Source.(int) == 2 {
+							return nil, errors.New("counter failed")
+						}
+						return p.Source, nil
+					},
+				},
+			},
+		}),
+		Extensions: []graphql.Extension{Extension{}},
+	})
+	if err != nil {
+		panic(err)
+	}
+	return schema
+}()
+
+func replyFn(reply *internal.ConnectReply) {
+	reply.SetSampleEverything()
+	reply.AccountID = "123"
+	reply.TrustedAccountKey = "123"
+	reply.PrimaryAppID = "456"
+}
+
+func TestSubscription(t *testing.T) {
+	app := integrationsupport.NewTestApp(replyFn, newrelic.ConfigDistributedTracerEnabled(true))
+
+	r := httptest.NewRequest("GET", "/subscriptions", nil)
+	r.RemoteAddr = "10.0.0.1:4567"
+	r.Header.Set("Sec-WebSocket-Protocol", "graphql-transport-ws")
+	conn := StartConnection(app.Application, r)
+	conn.AddAttribute("user", "gopher")
+
+	results := conn.Subscribe(graphql.Params{
+		Schema:        subscriptionSchema,
+		RequestString: `subscription OnCount { counter }`,
+		Context:       context.Background(),
+	})
+	var data []string
+	for res := range results {
+		js, err := json.Marshal(res.Data)
+		if err != nil {
+			t.Error("failure to marshal json:", err)
+		}
+		data = append(data, string(js))
+	}
+	if len(data) != 2 || data[0] != `{"counter":1}` || data[1] != `{"counter":null}` {
+		t.Error("incorrect subscription data:", data)
+	}
+	conn.End()
+
+	eventIntrinsics := map[string]interface{}{
+		"name":                     "OtherTransaction/Go/GraphQL/subscription/OnCount",
+		"guid":                     internal.MatchAnything,
+		"priority":                 internal.MatchAnything,
+		"sampled":                  internal.MatchAnything,
+		"traceId":                  internal.MatchAnything,
+		"parentId":                 internal.MatchAnything,
+		"parentSpanId":             internal.MatchAnything,
+		"parent.type":              "App",
+		"parent.account":           "123",
+		"parent.app":               "456",
+		"parent.transportType":     "Other",
+		"parent.transportDuration": internal.MatchAnything,
+	}
+	app.ExpectTxnEvents(t, []internal.WantEvent{
+		{Intrinsics: eventIntrinsics},
+		{Intrinsics: mergeIntrinsics(eventIntrinsics, map[string]interface{}{"error": true})},
+		{
+			Intrinsics: map[string]interface{}{
+				"name":     "OtherTransaction/Go/GraphQL/connection",
+				"guid":     internal.MatchAnything,
+				"priority": internal.MatchAnything,
+				"sampled":  internal.MatchAnything,
+				"traceId":  internal.MatchAnything,
+			},
+			UserAttributes: map[string]interface{}{
+				AttributeWebSocketPath:          "/subscriptions",
+				AttributeWebSocketRemoteAddress: "10.0.0.1:4567",
+				AttributeWebSocketSubprotocol:   "graphql-transport-ws",
+				"user":                          "gopher",
+			},
+		},
+	})
+	app.ExpectMetricsPresent(t, []internal.WantMetric{
+		{Name: "Custom/Execution", Scope: "OtherTransaction/Go/GraphQL/subscription/OnCount", Forced: false, Data: []float64{2}},
+		{Name: "Custom/ResolveField:counter", Scope: "OtherTransaction/Go/GraphQL/subscription/OnCount", Forced: false, Data: []float64{2}},
+	})
+	app.ExpectErrors(t, []internal.WantError{{
+		TxnName: "OtherTransaction/Go/GraphQL/subscription/OnCount",
+		Msg:     "counter failed",
+		Klass:   "gqlerrors.FormattedError",
+	}})
+}
+
+func mergeIntrinsics(a, b map[string]interface{}) map[string]interface{} {
+	m := make(map[string]interface{}, len(a)+len(b))
+	for k, v := range a {
+		m[k] = v
+	}
+	for k, v := range b {
+		m[k] = v
+	}
+	return m
+}
+
+func TestSubscriptionName(t *testing.T) {
+	testcases := []struct {
+		params graphql.Params
+		name   string
+	}{
+		{params: graphql.Params{RequestString: `subscription OnCount { counter }`}, name: "OnCount"},
+		{params: graphql.Params{RequestString: `subscription { counter }`}, name: "<anonymous>"},
+		{params: graphql.Params{RequestString: `query Q { hello } subscription S { counter }`}, name: "S"},
+		{params: graphql.Params{RequestString: `subscription OnCount { counter }`, OperationName: "Other"}, name: "Other"},
+		{params: graphql.Params{RequestString: `purple`}, name: "<anonymous>"},
+	}
+	for _, tc := range testcases {
+		if name := subscriptionName(tc.params); name != tc.name {
+			t.Errorf("incorrect name for %q: got %q, want %q", tc.params.RequestString, name, tc.name)
+		}
+	}
+}
+
+func TestSubscriptionNilConnection(t *testing.T) {
+	var conn *Connection
+	conn.AddAttribute("user", "gopher")
+	results := conn.Subscribe(graphql.Params{
+		Schema:        subscriptionSchema,
+		RequestString: `subscription { counter }`,
+	})
+	var count int
+	for range results {
+		count++
+	}
+	if count != 2 {
+		t.Error("incorrect number of results:", count)
+	}
+	conn.End()
+}
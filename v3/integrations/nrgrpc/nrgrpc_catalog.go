@@ -0,0 +1,65 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package nrgrpc
+
+import (
+	"sort"
+
+	"github.com/newrelic/go-agent/v3/internal/integrationsupport"
+	"github.com/newrelic/go-agent/v3/newrelic"
+	"google.golang.org/grpc"
+)
+
+// MethodCatalogEventType is the type of the custom events recorded by
+// ReportMethodCatalog, one for each method registered with the server.
+const MethodCatalogEventType = "GrpcMethodCatalog"
+
+// ReportMethodCatalog reports the catalog of the services and methods
+// registered with the server, so that New Relic knows the endpoints of the
+// service, including those which are never called.  Call it once every
+// service is registered, before serving:
+//
+//	server := grpc.NewServer(
+//		grpc.UnaryInterceptor(nrgrpc.UnaryServerInterceptor(app)),
+//		grpc.StreamInterceptor(nrgrpc.StreamServerInterceptor(app)),
+//	)
+//	sampleapp.RegisterSampleApplicationServer(server, &Server{})
+//	nrgrpc.ReportMethodCatalog(app, server)
+//
+// Once the application is connected, each method is recorded as a
+// "GrpcMethodCatalog" custom event with the "service", "method", and
+// "fullMethod" attributes, and the "clientStreaming" and "serverStreaming"
+// attributes describing the method.  The full method, "/{service}/{method}",
+// is the name the interceptors use to name the transactions of the method.
+// Only the names of the services and methods are reported.
+func ReportMethodCatalog(app *newrelic.Application, server *grpc.Server) {
+	if app == nil || server == nil {
+		return
+	}
+	integrationsupport.ReportCatalog(app, "gRPC method catalog", MethodCatalogEventType, methodCatalog(server.GetServiceInfo()))
+}
+
+func methodCatalog(services map[string]grpc.ServiceInfo) []map[string]interface{} {
+	names := make([]string, 0, len(services))
+	for name := range services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var catalog []map[string]interface{}
+	for _, service := range names {
+		// The methods of a service are listed in no particular order.
+		methods := append([]grpc.MethodInfo(nil), services[service].Methods...)
+		sort.Slice(methods, func(i, j int) bool { return methods[i].Name < methods[j].Name })
+		for _, m := range methods {
+			catalog = append(catalog, map[string]interface{}{
+				"service":         service,
+				"method":          m.Name,
+				"fullMethod":      "/" + service + "/" + m.Name,
+				"clientStreaming": m.IsClientStream,
+				"serverStreaming": m.IsServerStream,
+			})
+		}
+	}
+	return catalog
+}
@@ -0,0 +1,50 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package nrgrpc
+
+import (
+	"testing"
+
+	"github.com/newrelic/go-agent/v3/integrations/nrgrpc/testapp"
+	"github.com/newrelic/go-agent/v3/internal"
+	"github.com/newrelic/go-agent/v3/internal/integrationsupport"
+	"google.golang.org/grpc"
+)
+
+func catalogEvent(method string, clientStreaming, serverStreaming bool) internal.WantEvent {
+	return internal.WantEvent{
+		Intrinsics: map[string]interface{}{
+			"type":      MethodCatalogEventType,
+			"timestamp": internal.MatchAnything,
+		},
+		UserAttributes: map[string]interface{}{
+			"service":         "TestApplication",
+			"method":          method,
+			"fullMethod":      "/TestApplication/" + method,
+			"clientStreaming": clientStreaming,
+			"serverStreaming": serverStreaming,
+		},
+	}
+}
+
+func TestRecordMethodCatalog(t *testing.T) {
+	app := testApp()
+	s := grpc.NewServer()
+	testapp.RegisterTestApplicationServer(s, &testapp.Server{})
+
+	integrationsupport.RecordCatalog(app.Application, MethodCatalogEventType, methodCatalog(s.GetServiceInfo()))
+	app.ExpectCustomEvents(t, []internal.WantEvent{
+		catalogEvent("DoStreamStream", true, true),
+		catalogEvent("DoStreamUnary", true, false),
+		catalogEvent("DoUnaryStream", false, true),
+		catalogEvent("DoUnaryStreamError", false, true),
+		catalogEvent("DoUnaryUnary", false, false),
+		catalogEvent("DoUnaryUnaryError", false, false),
+	})
+}
+
+func TestReportMethodCatalogNil(t *testing.T) {
+	ReportMethodCatalog(nil, grpc.NewServer())
+	ReportMethodCatalog(testApp().Application, nil)
+}
@@ -10,9 +10,12 @@ import (
 	"net/url"
 	"strings"
 
+	"github.com/newrelic/go-agent/v3/internal/integrationsupport"
 	"github.com/newrelic/go-agent/v3/newrelic"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
 )
 
 func getURL(method, target string) *url.URL {
@@ -82,6 +85,75 @@ func startClientSegment(ctx context.Context, method, target string) (*newrelic.E
 	return seg, ctx
 }
 
+// connState records the state of the grpc.ClientConn used for a call, so that
+// "which backend was slow" can be answered for client-side load-balanced
+// services.
+type connState struct {
+	txn     *newrelic.Transaction
+	cc      *grpc.ClientConn
+	start   connectivity.State
+	peer    peer.Peer
+	semconv bool
+}
+
+// startConnState adds the target and the connectivity state of the
+// grpc.ClientConn to the external segment of the call, which must have just
+// been started, along with the semantic convention attributes of the call if
+// they are enabled.  It returns nil if the context has no transaction.
+func startConnState(ctx context.Context, cc *grpc.ClientConn, method string) *connState {
+	txn := newrelic.FromContext(ctx)
+	if txn == nil {
+		return nil
+	}
+	target := cc.Target()
+	// CanonicalTarget is not available in all supported grpc versions.
+	if ct, ok := interface{}(cc).(interface{ CanonicalTarget() string }); ok {
+		target = ct.CanonicalTarget()
+	}
+	cs := &connState{
+		txn:     txn,
+		cc:      cc,
+		start:   cc.GetState(),
+		semconv: semanticConventionsEnabled(txn.Application()),
+	}
+	integrationsupport.AddAgentSpanAttribute(txn, newrelic.SpanAttributeGRPCTarget, target)
+	integrationsupport.AddAgentSpanAttribute(txn, newrelic.SpanAttributeGRPCConnectivityState, cs.start.String())
+	if cs.semconv {
+		addClientAttributes(txn, method, cc.Target())
+	}
+	return cs
+}
+
+// callOptions adds the option recording the address of the backend picked
+// for the call.
+func (cs *connState) callOptions(opts []grpc.CallOption) []grpc.CallOption {
+	if cs == nil {
+		return opts
+	}
+	return append(opts, grpc.Peer(&cs.peer))
+}
+
+// end adds the address of the backend picked for the call, the change of the
+// connectivity state during the call, and the status code of the error
+// returned by the call if the semantic convention attributes are enabled, to
+// the external segment of the call.  It must be called before the segment is
+// ended.
+func (cs *connState) end(err error) {
+	if cs == nil {
+		return
+	}
+	if cs.semconv {
+		addClientStatusCode(cs.txn, err)
+	}
+	if cs.peer.Addr != nil {
+		integrationsupport.AddAgentSpanAttribute(cs.txn, newrelic.SpanAttributePeerAddress, cs.peer.Addr.String())
+	}
+	if state := cs.cc.GetState(); state != cs.start {
+		integrationsupport.AddAgentSpanAttribute(cs.txn, newrelic.SpanAttributeGRPCConnectivityStateChange,
+			cs.start.String()+" -> "+state.String())
+	}
+}
+
 // UnaryClientInterceptor instruments client unary RPCs.  This interceptor
 // records each unary call with an external segment.  Using it requires two steps:
 //
@@ -106,19 +178,30 @@ func startClientSegment(ctx context.Context, method, target string) (*newrelic.E
 // distributed tracing is enabled.
 func UnaryClientInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
 	seg, ctx := startClientSegment(ctx, method, cc.Target())
-	defer seg.End()
-	return invoker(ctx, method, req, reply, cc, opts...)
+	state := startConnState(ctx, cc, method)
+	var err error
+	defer func() {
+		state.end(err)
+		seg.End()
+	}()
+	err = invoker(ctx, method, req, reply, cc, state.callOptions(opts)...)
+	return err
 }
 
 type wrappedClientStream struct {
 	grpc.ClientStream
 	segment       *newrelic.ExternalSegment
+	state         *connState
 	isUnaryServer bool
 }
 
 func (s wrappedClientStream) RecvMsg(m interface{}) error {
 	err := s.ClientStream.RecvMsg(m)
-	if err == io.EOF || s.isUnaryServer {
+	if err == io.EOF {
+		s.state.end(nil)
+		s.segment.End()
+	} else if s.isUnaryServer {
+		s.state.end(err)
 		s.segment.End()
 	}
 	return err
@@ -148,13 +231,15 @@ func (s wrappedClientStream) RecvMsg(m interface{}) error {
 // distributed tracing is enabled.
 func StreamClientInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
 	seg, ctx := startClientSegment(ctx, method, cc.Target())
-	s, err := streamer(ctx, desc, cc, method, opts...)
+	state := startConnState(ctx, cc, method)
+	s, err := streamer(ctx, desc, cc, method, state.callOptions(opts)...)
 	if err != nil {
 		return s, err
 	}
 	return wrappedClientStream{
 		segment:       seg,
 		ClientStream:  s,
+		state:         state,
 		isUnaryServer: !desc.ServerStreams,
 	}, nil
 }
@@ -8,6 +8,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"net"
 	"testing"
 
 	"github.com/newrelic/go-agent/v3/integrations/nrgrpc/testapp"
@@ -15,7 +16,10 @@ import (
 	"github.com/newrelic/go-agent/v3/internal"
 	"github.com/newrelic/go-agent/v3/internal/integrationsupport"
 	newrelic "github.com/newrelic/go-agent/v3/newrelic"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/test/bufconn"
 )
 
 func TestGetURL(t *testing.T) {
@@ -79,6 +83,12 @@ func testApp() integrationsupport.ExpectApp {
 	return integrationsupport.NewTestApp(replyFn, integrationsupport.ConfigFullTraces, newrelic.ConfigCodeLevelMetricsEnabled(false))
 }
 
+var clientConnAttributes = map[string]interface{}{
+	"grpc.target":            "passthrough:///bufnet",
+	"grpc.connectivityState": "READY",
+	"peer.address":           "bufconn",
+}
+
 var replyFn = func(reply *internal.ConnectReply) {
 	reply.SetSampleEverything()
 	reply.AccountID = "123"
@@ -134,7 +144,7 @@ func TestUnaryClientInterceptor(t *testing.T) {
 				"span.kind": "client",
 			},
 			UserAttributes:  map[string]interface{}{},
-			AgentAttributes: map[string]interface{}{},
+			AgentAttributes: clientConnAttributes,
 		},
 		{
 			Intrinsics: map[string]interface{}{
@@ -158,7 +168,7 @@ func TestUnaryClientInterceptor(t *testing.T) {
 				Children: []internal.WantTraceSegment{
 					{
 						SegmentName: "External/bufnet/gRPC/TestApplication/DoUnaryUnary",
-						Attributes:  map[string]interface{}{},
+						Attributes:  clientConnAttributes,
 					},
 				},
 			}},
@@ -228,7 +238,7 @@ func TestUnaryStreamClientInterceptor(t *testing.T) {
 				"span.kind": "client",
 			},
 			UserAttributes:  map[string]interface{}{},
-			AgentAttributes: map[string]interface{}{},
+			AgentAttributes: clientConnAttributes,
 		},
 		{
 			Intrinsics: map[string]interface{}{
@@ -252,7 +262,7 @@ func TestUnaryStreamClientInterceptor(t *testing.T) {
 				Children: []internal.WantTraceSegment{
 					{
 						SegmentName: "External/bufnet/gRPC/TestApplication/DoUnaryStream",
-						Attributes:  map[string]interface{}{},
+						Attributes:  clientConnAttributes,
 					},
 				},
 			}},
@@ -320,7 +330,7 @@ func TestStreamUnaryClientInterceptor(t *testing.T) {
 				"span.kind": "client",
 			},
 			UserAttributes:  map[string]interface{}{},
-			AgentAttributes: map[string]interface{}{},
+			AgentAttributes: clientConnAttributes,
 		},
 		{
 			Intrinsics: map[string]interface{}{
@@ -344,7 +354,7 @@ func TestStreamUnaryClientInterceptor(t *testing.T) {
 				Children: []internal.WantTraceSegment{
 					{
 						SegmentName: "External/bufnet/gRPC/TestApplication/DoStreamUnary",
-						Attributes:  map[string]interface{}{},
+						Attributes:  clientConnAttributes,
 					},
 				},
 			}},
@@ -436,7 +446,7 @@ func TestStreamStreamClientInterceptor(t *testing.T) {
 				"span.kind": "client",
 			},
 			UserAttributes:  map[string]interface{}{},
-			AgentAttributes: map[string]interface{}{},
+			AgentAttributes: clientConnAttributes,
 		},
 		{
 			Intrinsics: map[string]interface{}{
@@ -460,7 +470,7 @@ func TestStreamStreamClientInterceptor(t *testing.T) {
 				Children: []internal.WantTraceSegment{
 					{
 						SegmentName: "External/bufnet/gRPC/TestApplication/DoStreamStream",
-						Attributes:  map[string]interface{}{},
+						Attributes:  clientConnAttributes,
 					},
 				},
 			}},
@@ -622,3 +632,61 @@ func TestClientSecurityAgentEnabled(t *testing.T) {
 		t.Fatal("Could not setup the nrsecurityagent", err)
 	}
 }
+
+func TestClientConnStateChange(t *testing.T) {
+	app := testApp()
+	txn := app.StartTransaction("UnaryUnary")
+	ctx := newrelic.NewContext(context.Background(), txn)
+
+	s := grpc.NewServer()
+	testapp.RegisterTestApplicationServer(s, &testapp.Server{})
+	lis := bufconn.Listen(1024 * 1024)
+	go s.Serve(lis)
+	defer s.Stop()
+
+	// The connection is idle until the first call.
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUnaryInterceptor(UnaryClientInterceptor),
+	)
+	if err != nil {
+		t.Fatal("failure to create ClientConn", err)
+	}
+	defer conn.Close()
+
+	client := testapp.NewTestApplicationClient(conn)
+	if _, err := client.DoUnaryUnary(ctx, &testapp.Message{}); err != nil {
+		t.Fatal("client call to DoUnaryUnary failed", err)
+	}
+	txn.End()
+
+	app.ExpectSpanEvents(t, []internal.WantEvent{
+		{
+			Intrinsics: map[string]interface{}{
+				"category":  "http",
+				"component": "gRPC",
+				"name":      internal.MatchAnything,
+				"parentId":  internal.MatchAnything,
+				"span.kind": "client",
+			},
+			UserAttributes: map[string]interface{}{},
+			AgentAttributes: map[string]interface{}{
+				"grpc.target":                  "passthrough:///bufnet",
+				"grpc.connectivityState":       "IDLE",
+				"grpc.connectivityStateChange": "IDLE -> READY",
+				"peer.address":                 "bufconn",
+			},
+		},
+		{
+			Intrinsics: map[string]interface{}{
+				"category":         "generic",
+				"name":             "OtherTransaction/Go/UnaryUnary",
+				"transaction.name": "OtherTransaction/Go/UnaryUnary",
+				"nr.entryPoint":    true,
+			},
+			UserAttributes:  map[string]interface{}{},
+			AgentAttributes: map[string]interface{}{},
+		},
+	})
+}
@@ -102,6 +102,22 @@
 //	ctx := newrelic.NewContext(context.Background(), txn)
 //	msg, err := client.handler(ctx, &pb.Message{"Hello World"})
 //
+// The external segment of each call records the target of the grpc.ClientConn
+// ("grpc.target"), its connectivity state when the call started
+// ("grpc.connectivityState"), any change of that state during the call
+// ("grpc.connectivityStateChange"), and the address of the backend picked by
+// the load balancer ("peer.address").
+//
+// OpenTelemetry semantic conventions
+//
+// If the application is configured using
+// newrelic.ConfigSemanticConventionsRPC(true), the transactions of servers and
+// the external segments of clients also record the attributes defined by the
+// OpenTelemetry semantic conventions for RPCs: "rpc.system", "rpc.service",
+// "rpc.method", "rpc.grpc.status_code", "net.peer.name", and "net.peer.port".
+// The peer of a server transaction is the client which made the call, and the
+// peer of a client segment is the target of the grpc.ClientConn.
+//
 // Full client example:
 // https://github.com/newrelic/go-agent/blob/master/v3/integrations/nrgrpc/example/client/client.go
 package nrgrpc
@@ -0,0 +1,91 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package nrgrpc
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/newrelic/go-agent/v3/internal/integrationsupport"
+	"github.com/newrelic/go-agent/v3/newrelic"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// rpcSystem is the value of the rpc.system attribute for gRPC.
+const rpcSystem = "grpc"
+
+// semanticConventionsEnabled returns whether the application is configured
+// to add the OpenTelemetry semantic convention attributes for RPCs.
+func semanticConventionsEnabled(app *newrelic.Application) bool {
+	cfg, _ := app.Config()
+	return cfg.SemanticConventions.RPC
+}
+
+// splitMethod splits a full method in the form "/<service>/<method>" into the
+// fully qualified name of the service and the name of the method.
+func splitMethod(fullMethod string) (string, string) {
+	fullMethod = strings.TrimPrefix(fullMethod, "/")
+	if i := strings.LastIndex(fullMethod, "/"); i >= 0 {
+		return fullMethod[:i], fullMethod[i+1:]
+	}
+	return "", fullMethod
+}
+
+// splitPeer splits an address into a name and a port.  The port is zero if
+// the address has none.
+func splitPeer(addr string) (string, int) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr, 0
+	}
+	p, _ := strconv.Atoi(port)
+	return host, p
+}
+
+// addServerAttributes adds the semantic convention attributes of a call
+// handled by a server to its transaction.
+func addServerAttributes(ctx context.Context, txn *newrelic.Transaction, fullMethod string) {
+	service, method := splitMethod(fullMethod)
+	integrationsupport.AddAgentAttribute(txn, newrelic.SpanAttributeRPCSystem, rpcSystem, nil)
+	integrationsupport.AddAgentAttribute(txn, newrelic.SpanAttributeRPCService, service, nil)
+	integrationsupport.AddAgentAttribute(txn, newrelic.SpanAttributeRPCMethod, method, nil)
+	if p, ok := peer.FromContext(ctx); ok && p != nil && p.Addr != nil {
+		name, port := splitPeer(p.Addr.String())
+		integrationsupport.AddAgentAttribute(txn, newrelic.SpanAttributeNetPeerName, name, nil)
+		if port != 0 {
+			integrationsupport.AddAgentAttribute(txn, newrelic.SpanAttributeNetPeerPort, "", port)
+		}
+	}
+}
+
+// addServerStatusCode adds the gRPC status code of the error returned by a
+// handler to its transaction.
+func addServerStatusCode(txn *newrelic.Transaction, err error) {
+	integrationsupport.AddAgentAttribute(txn, newrelic.SpanAttributeRPCGRPCStatusCode, "", int(status.Code(err)))
+}
+
+// addClientAttributes adds the semantic convention attributes of a call made
+// by a client to its external segment, which must have just been started.
+// Span attributes added by integrations are strings, so the port is
+// formatted as one.
+func addClientAttributes(txn *newrelic.Transaction, fullMethod, target string) {
+	service, method := splitMethod(fullMethod)
+	integrationsupport.AddAgentSpanAttribute(txn, newrelic.SpanAttributeRPCSystem, rpcSystem)
+	integrationsupport.AddAgentSpanAttribute(txn, newrelic.SpanAttributeRPCService, service)
+	integrationsupport.AddAgentSpanAttribute(txn, newrelic.SpanAttributeRPCMethod, method)
+	name, port := splitPeer(getURL(fullMethod, target).Host)
+	integrationsupport.AddAgentSpanAttribute(txn, newrelic.SpanAttributeNetPeerName, name)
+	if port != 0 {
+		integrationsupport.AddAgentSpanAttribute(txn, newrelic.SpanAttributeNetPeerPort, strconv.Itoa(port))
+	}
+}
+
+// addClientStatusCode adds the gRPC status code of the error returned by a
+// call to its external segment.
+func addClientStatusCode(txn *newrelic.Transaction, err error) {
+	integrationsupport.AddAgentSpanAttribute(txn, newrelic.SpanAttributeRPCGRPCStatusCode, strconv.Itoa(int(status.Code(err))))
+}
@@ -0,0 +1,128 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package nrgrpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/newrelic/go-agent/v3/integrations/nrgrpc/testapp"
+	"github.com/newrelic/go-agent/v3/internal"
+	"github.com/newrelic/go-agent/v3/internal/integrationsupport"
+	"github.com/newrelic/go-agent/v3/newrelic"
+)
+
+func semconvTestApp() integrationsupport.ExpectApp {
+	return integrationsupport.NewTestApp(replyFn,
+		integrationsupport.ConfigFullTraces,
+		newrelic.ConfigCodeLevelMetricsEnabled(false),
+		newrelic.ConfigSemanticConventionsRPC(true))
+}
+
+func TestServerSemanticConventions(t *testing.T) {
+	app := semconvTestApp()
+
+	s, conn := newTestServerAndConn(t, app.Application)
+	defer s.Stop()
+	defer conn.Close()
+
+	client := testapp.NewTestApplicationClient(conn)
+	if _, err := client.DoUnaryUnaryError(context.Background(), &testapp.Message{}); err == nil {
+		t.Fatal("DoUnaryUnaryError should have returned an error")
+	}
+
+	app.ExpectTxnEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"guid":             internal.MatchAnything,
+			"name":             "WebTransaction/Go/TestApplication/DoUnaryUnaryError",
+			"nr.apdexPerfZone": internal.MatchAnything,
+			"priority":         internal.MatchAnything,
+			"sampled":          internal.MatchAnything,
+			"traceId":          internal.MatchAnything,
+		},
+		UserAttributes: map[string]interface{}{
+			"grpcStatusMessage": "oooooops!",
+			"grpcStatusCode":    "DataLoss",
+			"grpcStatusLevel":   "error",
+		},
+		AgentAttributes: map[string]interface{}{
+			"httpResponseCode":            0,
+			"http.statusCode":             0,
+			"request.headers.contentType": "application/grpc",
+			"request.method":              "TestApplication/DoUnaryUnaryError",
+			"request.uri":                 "grpc://bufnet/TestApplication/DoUnaryUnaryError",
+			"rpc.system":                  "grpc",
+			"rpc.service":                 "TestApplication",
+			"rpc.method":                  "DoUnaryUnaryError",
+			"rpc.grpc.status_code":        15,
+			"net.peer.name":               "bufconn",
+		},
+	}})
+}
+
+func TestClientSemanticConventions(t *testing.T) {
+	app := semconvTestApp()
+	txn := app.StartTransaction("UnaryUnary")
+	ctx := newrelic.NewContext(context.Background(), txn)
+
+	s, conn := newTestServerAndConn(t, nil)
+	defer s.Stop()
+	defer conn.Close()
+
+	client := testapp.NewTestApplicationClient(conn)
+	if _, err := client.DoUnaryUnary(ctx, &testapp.Message{}); err != nil {
+		t.Fatal("client call to DoUnaryUnary failed", err)
+	}
+	txn.End()
+
+	app.ExpectSpanEvents(t, []internal.WantEvent{
+		{
+			Intrinsics: map[string]interface{}{
+				"category":  "http",
+				"component": "gRPC",
+				"name":      "External/bufnet/gRPC/TestApplication/DoUnaryUnary",
+				"parentId":  internal.MatchAnything,
+				"span.kind": "client",
+			},
+			UserAttributes: map[string]interface{}{},
+			AgentAttributes: map[string]interface{}{
+				"grpc.target":            "passthrough:///bufnet",
+				"grpc.connectivityState": "READY",
+				"peer.address":           "bufconn",
+				"rpc.system":             "grpc",
+				"rpc.service":            "TestApplication",
+				"rpc.method":             "DoUnaryUnary",
+				"rpc.grpc.status_code":   "0",
+				"net.peer.name":          "bufnet",
+			},
+		},
+		{
+			Intrinsics: map[string]interface{}{
+				"category":         "generic",
+				"name":             "OtherTransaction/Go/UnaryUnary",
+				"transaction.name": "OtherTransaction/Go/UnaryUnary",
+				"nr.entryPoint":    true,
+			},
+			UserAttributes:  map[string]interface{}{},
+			AgentAttributes: map[string]interface{}{},
+		},
+	})
+}
+
+func TestSplitMethodAndPeer(t *testing.T) {
+	for fullMethod, want := range map[string][2]string{
+		"/helloworld.Greeter/SayHello": {"helloworld.Greeter", "SayHello"},
+		"SayHello":                     {"", "SayHello"},
+	} {
+		if service, method := splitMethod(fullMethod); service != want[0] || method != want[1] {
+			t.Errorf("splitMethod(%q) = %q, %q", fullMethod, service, method)
+		}
+	}
+	if name, port := splitPeer("10.0.0.1:8080"); name != "10.0.0.1" || port != 8080 {
+		t.Errorf("splitPeer = %q, %d", name, port)
+	}
+	if name, port := splitPeer("bufconn"); name != "bufconn" || port != 0 {
+		t.Errorf("splitPeer = %q, %d", name, port)
+	}
+}
@@ -300,9 +300,13 @@ func UnaryServerInterceptor(app *newrelic.Application, options ...HandlerOption)
 	for _, option := range options {
 		option(localHandlerMap)
 	}
+	semconv := semanticConventionsEnabled(app)
 
 	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
 		txn := startTransaction(ctx, app, info.FullMethod)
+		if semconv {
+			addServerAttributes(ctx, txn, info.FullMethod)
+		}
 
 		if newrelic.IsSecurityAgentPresent() {
 			messageType, version := getMessageType(req)
@@ -313,6 +317,9 @@ func UnaryServerInterceptor(app *newrelic.Application, options ...HandlerOption)
 		ctx = newrelic.NewContext(ctx, txn)
 		resp, err = handler(ctx, req)
 		reportInterceptorStatus(ctx, txn, localHandlerMap, err)
+		if semconv {
+			addServerStatusCode(txn, err)
+		}
 		return
 	}
 }
@@ -365,15 +372,22 @@ func StreamServerInterceptor(app *newrelic.Application, options ...HandlerOption
 	for _, option := range options {
 		option(localHandlerMap)
 	}
+	semconv := semanticConventionsEnabled(app)
 
 	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
 		txn := startTransaction(ss.Context(), app, info.FullMethod)
 		defer txn.End()
+		if semconv {
+			addServerAttributes(ss.Context(), txn, info.FullMethod)
+		}
 		if newrelic.IsSecurityAgentPresent() {
 			newrelic.GetSecurityAgentInterface().SendEvent("GRPC_INFO", info.IsClientStream, info.IsServerStream)
 		}
 		err := handler(srv, newWrappedServerStream(ss, txn))
 		reportInterceptorStatus(ss.Context(), txn, localHandlerMap, err)
+		if semconv {
+			addServerStatusCode(txn, err)
+		}
 		return err
 	}
 }
@@ -44,6 +44,21 @@ func getEventSourceARN(event interface{}) string {
 	return ""
 }
 
+// getRequestContextID returns the request ID of the requestContext of API
+// Gateway and Lambda function URL events, which can be used to find the
+// request in the CloudWatch logs of API Gateway.
+func getRequestContextID(event interface{}) string {
+	switch v := event.(type) {
+	case events.APIGatewayProxyRequest:
+		return v.RequestContext.RequestID
+	case events.APIGatewayV2HTTPRequest:
+		return v.RequestContext.RequestID
+	case events.LambdaFunctionURLRequest:
+		return v.RequestContext.RequestID
+	}
+	return ""
+}
+
 func eventWebRequest(event interface{}) *newrelic.WebRequest {
 	var path string
 	var request newrelic.WebRequest
@@ -59,6 +74,10 @@ func eventWebRequest(event interface{}) *newrelic.WebRequest {
 		request.Method = r.HTTPMethod
 		path = r.Path
 		headers = r.Headers
+	case events.LambdaFunctionURLRequest:
+		request.Method = r.RequestContext.HTTP.Method
+		path = r.RawPath
+		headers = r.Headers
 	default:
 		return nil
 	}
@@ -101,6 +120,20 @@ func eventResponse(event interface{}) *response {
 	case events.ALBTargetGroupResponse:
 		code = r.StatusCode
 		headers = r.Headers
+	case events.LambdaFunctionURLResponse:
+		code = r.StatusCode
+		headers = r.Headers
+	case *events.LambdaFunctionURLStreamingResponse:
+		if nil == r {
+			return nil
+		}
+		code = r.StatusCode
+		if 0 == code {
+			// The status code defaults to 200 when the response is
+			// streamed.
+			code = http.StatusOK
+		}
+		headers = r.Headers
 	default:
 		return nil
 	}
@@ -217,3 +217,30 @@ func TestEventResponse(t *testing.T) {
 		}
 	}
 }
+
+func TestGetRequestContextID(t *testing.T) {
+	testcases := []struct {
+		Name      string
+		Input     interface{}
+		RequestID string
+	}{
+		{Name: "nil", Input: nil, RequestID: ""},
+		{Name: "SQSEvent", Input: events.SQSEvent{}, RequestID: ""},
+		{Name: "ALBTargetGroupRequest", Input: events.ALBTargetGroupRequest{}, RequestID: ""},
+		{Name: "APIGatewayProxyRequest", Input: events.APIGatewayProxyRequest{
+			RequestContext: events.APIGatewayProxyRequestContext{RequestID: "id1"},
+		}, RequestID: "id1"},
+		{Name: "APIGatewayV2HTTPRequest", Input: events.APIGatewayV2HTTPRequest{
+			RequestContext: events.APIGatewayV2HTTPRequestContext{RequestID: "id2"},
+		}, RequestID: "id2"},
+		{Name: "LambdaFunctionURLRequest", Input: events.LambdaFunctionURLRequest{
+			RequestContext: events.LambdaFunctionURLRequestContext{RequestID: "id3"},
+		}, RequestID: "id3"},
+	}
+
+	for _, testcase := range testcases {
+		if id := getRequestContextID(testcase.Input); id != testcase.RequestID {
+			t.Error(testcase.Name, id, testcase.RequestID)
+		}
+	}
+}
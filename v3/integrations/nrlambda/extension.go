@@ -0,0 +1,62 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package nrlambda
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"time"
+)
+
+const (
+	// extensionEndpointEnv is the environment variable containing the URL
+	// of the New Relic Lambda extension's local telemetry API.
+	extensionEndpointEnv = "NEW_RELIC_LAMBDA_EXTENSION_ENDPOINT"
+
+	// extensionTimeout limits how long sending the telemetry payload to the
+	// extension may take, since it delays the end of the invocation.
+	extensionTimeout = 500 * time.Millisecond
+)
+
+func newWriterProvider(getenv func(string) string) writerProvider {
+	if endpoint := getenv(extensionEndpointEnv); "" != endpoint {
+		return &extensionWriterProvider{
+			endpoint: endpoint,
+			client:   &http.Client{Timeout: extensionTimeout},
+			fallback: &defaultWriterProvider{},
+		}
+	}
+	return &defaultWriterProvider{}
+}
+
+// extensionWriterProvider sends the telemetry payload to the New Relic Lambda
+// extension using its local HTTP API.  Unlike the named pipe, the request
+// completes only once the extension has received the payload, so data is not
+// lost if the execution environment is frozen right after the invocation.  If
+// the extension cannot be reached, the payload is written using the fallback
+// writerProvider instead.
+type extensionWriterProvider struct {
+	endpoint string
+	client   *http.Client
+	fallback writerProvider
+}
+
+func (wp *extensionWriterProvider) borrowWriter(needsWriter func(io.Writer)) {
+	buf := &bytes.Buffer{}
+	needsWriter(buf)
+	if 0 == buf.Len() {
+		return
+	}
+	resp, err := wp.client.Post(wp.endpoint, "application/json", bytes.NewReader(buf.Bytes()))
+	if nil == err {
+		resp.Body.Close()
+		if resp.StatusCode < 300 {
+			return
+		}
+	}
+	wp.fallback.borrowWriter(func(writer io.Writer) {
+		writer.Write(buf.Bytes())
+	})
+}
@@ -0,0 +1,71 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package nrlambda
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNewWriterProvider(t *testing.T) {
+	if _, ok := newWriterProvider(func(string) string { return "" }).(*defaultWriterProvider); !ok {
+		t.Error("expected default writer provider")
+	}
+	wp := newWriterProvider(func(key string) string {
+		if key == extensionEndpointEnv {
+			return "http://localhost/telemetry"
+		}
+		return ""
+	})
+	if ewp, ok := wp.(*extensionWriterProvider); !ok || ewp.endpoint != "http://localhost/telemetry" {
+		t.Error("expected extension writer provider", wp)
+	}
+}
+
+func TestExtensionWriterProvider(t *testing.T) {
+	var received []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received, _ = io.ReadAll(r.Body)
+	}))
+	defer srv.Close()
+
+	fallback := &bytes.Buffer{}
+	wp := &extensionWriterProvider{
+		endpoint: srv.URL,
+		client:   srv.Client(),
+		fallback: bufWriterProvider{fallback},
+	}
+	wp.borrowWriter(func(writer io.Writer) {
+		writer.Write([]byte("payload"))
+	})
+	if string(received) != "payload" {
+		t.Error(string(received))
+	}
+	if 0 != fallback.Len() {
+		t.Error("fallback should not be used", fallback.String())
+	}
+}
+
+func TestExtensionWriterProviderFallback(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	fallback := &bytes.Buffer{}
+	wp := &extensionWriterProvider{
+		endpoint: srv.URL,
+		client:   srv.Client(),
+		fallback: bufWriterProvider{fallback},
+	}
+	wp.borrowWriter(func(writer io.Writer) {
+		writer.Write([]byte("payload"))
+	})
+	if fallback.String() != "payload" {
+		t.Error(fallback.String())
+	}
+}
@@ -6,7 +6,14 @@
 // Use this package to instrument your AWS Lambda handler function.  Data is
 // sent to CloudWatch when the Lambda is invoked.  CloudWatch collects Lambda
 // log data and sends it to a New Relic log-ingestion Lambda.  The log-ingestion
-// Lambda sends that data to us.
+// Lambda sends that data to us.  When the New Relic Lambda extension is used,
+// data is instead written to the extension's named pipe, or sent to its local
+// HTTP API if the NEW_RELIC_LAMBDA_EXTENSION_ENDPOINT environment variable is
+// set to the URL of that API.
+//
+// Functions using the RESPONSE_STREAM invoke mode should use StartStreaming in
+// place of Start.  Invocations which are about to time out are recorded with a
+// LambdaTimeout error shortly before the deadline.
 //
 // Monitoring AWS Lambda requires several steps shown here:
 // https://docs.newrelic.com/docs/serverless-function-monitoring/aws-lambda-monitoring/get-started/enable-new-relic-monitoring-aws-lambda
@@ -20,6 +27,7 @@ import (
 	"net/http"
 	"os"
 	"sync"
+	"time"
 
 	"github.com/aws/aws-lambda-go/lambda"
 	"github.com/aws/aws-lambda-go/lambda/handlertrace"
@@ -51,6 +59,10 @@ func requestEvent(ctx context.Context, event interface{}) {
 		integrationsupport.AddAgentAttribute(txn, newrelic.AttributeAWSLambdaEventSourceARN, sourceARN, nil)
 	}
 
+	if requestID := getRequestContextID(event); "" != requestID {
+		integrationsupport.AddAgentAttribute(txn, newrelic.AttributeAWSRequestContextID, requestID, nil)
+	}
+
 	if request := eventWebRequest(event); nil != request {
 		txn.SetWebRequest(*request)
 	}
@@ -74,7 +86,13 @@ type writerProvider interface {
 type defaultWriterProvider struct {
 }
 
-const telemetryNamedPipe = "/tmp/newrelic-telemetry"
+const (
+	telemetryNamedPipe = "/tmp/newrelic-telemetry"
+
+	// defaultTimeoutMargin is how long before the invocation deadline the
+	// transaction is ended if the handler has not yet returned.
+	defaultTimeoutMargin = 100 * time.Millisecond
+)
 
 func (wp *defaultWriterProvider) borrowWriter(needsWriter func(io.Writer)) {
 	// If the telemetry named pipe exists and is writable, use it instead of stdout
@@ -88,19 +106,32 @@ func (wp *defaultWriterProvider) borrowWriter(needsWriter func(io.Writer)) {
 	needsWriter(pipeFile)
 }
 
-func (h *wrappedHandler) Invoke(ctx context.Context, payload []byte) ([]byte, error) {
+// errTimeout is recorded when an invocation is about to exceed its deadline.
+var errTimeout = newrelic.Error{
+	Message: "Lambda function invocation timed out",
+	Class:   "LambdaTimeout",
+}
+
+// invocation tracks the transaction created for a single Lambda invocation.
+type invocation struct {
+	h     *wrappedHandler
+	arn   string
+	txn   *newrelic.Transaction
+	timer *time.Timer
+	// written ensures that the telemetry payload is only written once, since
+	// an invocation may be ended by the timeout timer and the handler
+	// returning.
+	written sync.Once
+}
+
+func (h *wrappedHandler) startInvocation(ctx context.Context) (context.Context, *invocation) {
 	var arn, requestID string
 	if lctx, ok := lambdacontext.FromContext(ctx); ok {
 		arn = lctx.InvokedFunctionArn
 		requestID = lctx.AwsRequestID
 	}
 
-	defer h.hasWriter.borrowWriter(func(writer io.Writer) {
-		internal.ServerlessWrite(h.app.Private, arn, writer)
-	})
-
 	txn := h.app.StartTransaction(h.functionName)
-	defer txn.End()
 
 	integrationsupport.AddAgentAttribute(txn, newrelic.AttributeAWSRequestID, requestID, nil)
 	integrationsupport.AddAgentAttribute(txn, newrelic.AttributeAWSLambdaARN, arn, nil)
@@ -108,7 +139,57 @@ func (h *wrappedHandler) Invoke(ctx context.Context, payload []byte) ([]byte, er
 		integrationsupport.AddAgentAttribute(txn, newrelic.AttributeAWSLambdaColdStart, "", true)
 	})
 
-	ctx = newrelic.NewContext(ctx, txn)
+	inv := &invocation{
+		h:   h,
+		arn: arn,
+		txn: txn,
+	}
+	// Lambda freezes or kills the execution environment once the deadline
+	// passes, so the transaction is ended and written shortly before then
+	// to capture invocations which time out.
+	if deadline, ok := ctx.Deadline(); ok {
+		if d := time.Until(deadline) - h.timeoutMargin; d > 0 {
+			inv.timer = time.AfterFunc(d, inv.timeout)
+		}
+	}
+
+	return newrelic.NewContext(ctx, txn), inv
+}
+
+func (inv *invocation) stopTimer() {
+	if nil != inv.timer {
+		inv.timer.Stop()
+	}
+}
+
+func (inv *invocation) write() {
+	inv.written.Do(func() {
+		inv.h.hasWriter.borrowWriter(func(writer io.Writer) {
+			internal.ServerlessWrite(inv.h.app.Private, inv.arn, writer)
+		})
+	})
+}
+
+func (inv *invocation) timeout() {
+	inv.txn.NoticeError(errTimeout)
+	inv.txn.End()
+	inv.write()
+}
+
+// end ends the transaction and writes the telemetry payload.
+func (inv *invocation) end() {
+	inv.stopTimer()
+	inv.txn.End()
+	inv.write()
+}
+
+func (h *wrappedHandler) Invoke(ctx context.Context, payload []byte) ([]byte, error) {
+	ctx, inv := h.startInvocation(ctx)
+	defer inv.write()
+	// txn.End is deferred directly so that panics are recorded.
+	defer inv.txn.End()
+	defer inv.stopTimer()
+
 	ctx = handlertrace.NewContext(ctx, handlertrace.HandlerTrace{
 		RequestEvent:  requestEvent,
 		ResponseEvent: responseEvent,
@@ -117,7 +198,7 @@ func (h *wrappedHandler) Invoke(ctx context.Context, payload []byte) ([]byte, er
 	response, err := h.original.Invoke(ctx, payload)
 
 	if nil != err {
-		txn.NoticeError(err)
+		inv.txn.NoticeError(err)
 	}
 
 	return response, err
@@ -137,6 +218,19 @@ type wrappedHandler struct {
 	// The writerProvider manages the lifecycle of the file handle being written
 	// to, similar to the Loan pattern. This field exists mostly for testing.
 	hasWriter writerProvider
+	// timeoutMargin is how long before the invocation deadline the
+	// transaction is ended if the handler has not yet returned.
+	timeoutMargin time.Duration
+}
+
+func newWrappedHandler(handler lambda.Handler, app *newrelic.Application) *wrappedHandler {
+	return &wrappedHandler{
+		original:      handler,
+		app:           app,
+		functionName:  lambdacontext.FunctionName,
+		hasWriter:     newWriterProvider(os.Getenv),
+		timeoutMargin: defaultTimeoutMargin,
+	}
 }
 
 // WrapHandler wraps the provided handler and returns a new handler with
@@ -147,12 +241,7 @@ func WrapHandler(handler lambda.Handler, app *newrelic.Application) lambda.Handl
 	if nil == app {
 		return handler
 	}
-	return &wrappedHandler{
-		original:     handler,
-		app:          app,
-		functionName: lambdacontext.FunctionName,
-		hasWriter:    &defaultWriterProvider{},
-	}
+	return newWrappedHandler(handler, app)
 }
 
 // Wrap wraps the provided handler and returns a new handler with
@@ -13,6 +13,7 @@ import (
 	"os"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambdacontext"
@@ -246,6 +247,10 @@ func TestSetWebRequest(t *testing.T) {
 		Headers: map[string]string{
 			"X-Forwarded-Port":  "4000",
 			"X-Forwarded-Proto": "HTTPS",
+			"X-Amzn-Trace-Id":   "Root=1-5759e988-bd862e3fe1be46a994272793",
+		},
+		RequestContext: events.APIGatewayProxyRequestContext{
+			RequestID: "c6af9ac6-7b61-11e6-9a41-93e8deadbeef",
 		},
 	}
 	reqbytes, err := json.Marshal(req)
@@ -279,8 +284,10 @@ func TestSetWebRequest(t *testing.T) {
 		},
 		UserAttributes: map[string]interface{}{},
 		AgentAttributes: map[string]interface{}{
-			"aws.lambda.coldStart": true,
-			"request.uri":          "//:4000",
+			"aws.lambda.coldStart":         true,
+			"aws.requestContext.requestId": "c6af9ac6-7b61-11e6-9a41-93e8deadbeef",
+			"aws.traceId":                  "Root=1-5759e988-bd862e3fe1be46a994272793",
+			"request.uri":                  "//:4000",
 		},
 	}})
 	app.Private.(internal.Expect).ExpectSpanEvents(t, []internal.WantEvent{{
@@ -296,8 +303,10 @@ func TestSetWebRequest(t *testing.T) {
 		},
 		UserAttributes: map[string]interface{}{},
 		AgentAttributes: map[string]interface{}{
-			"aws.lambda.coldStart": true,
-			"request.uri":          "//:4000",
+			"aws.lambda.coldStart":         true,
+			"aws.requestContext.requestId": "c6af9ac6-7b61-11e6-9a41-93e8deadbeef",
+			"aws.traceId":                  "Root=1-5759e988-bd862e3fe1be46a994272793",
+			"request.uri":                  "//:4000",
 		},
 	}})
 	if 0 == buf.Len() {
@@ -593,3 +602,53 @@ func TestDefaultWriterProvider(t *testing.T) {
 		}
 	})
 }
+
+func TestTimeout(t *testing.T) {
+	release := make(chan struct{})
+	originalHandler := func(c context.Context) { <-release }
+	app := testApp(nil, t)
+	wrapped := Wrap(originalHandler, app)
+	w := wrapped.(*wrappedHandler)
+	w.functionName = "functionName"
+	w.timeoutMargin = 0
+	written := make(chan struct{})
+	w.hasWriter = notifyWriterProvider{written}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	go wrapped.Invoke(ctx, nil)
+
+	select {
+	case <-written:
+	case <-time.After(time.Second):
+		t.Fatal("output not written before the handler returned")
+	}
+	close(release)
+
+	app.Private.(internal.Expect).ExpectErrorEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"error.class":     "LambdaTimeout",
+			"error.message":   "Lambda function invocation timed out",
+			"transactionName": "OtherTransaction/Go/functionName",
+			"guid":            internal.MatchAnything,
+			"priority":        internal.MatchAnything,
+			"sampled":         internal.MatchAnything,
+			"spanId":          internal.MatchAnything,
+			"traceId":         internal.MatchAnything,
+		},
+		UserAttributes: map[string]interface{}{},
+		AgentAttributes: map[string]interface{}{
+			"aws.lambda.coldStart": true,
+		},
+	}})
+}
+
+// notifyWriterProvider closes the channel once the output is written.
+type notifyWriterProvider struct {
+	written chan struct{}
+}
+
+func (wp notifyWriterProvider) borrowWriter(needsWriter func(writer io.Writer)) {
+	needsWriter(io.Discard)
+	close(wp.written)
+}
@@ -0,0 +1,123 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package nrlambda
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+
+	"github.com/aws/aws-lambda-go/lambda"
+	newrelic "github.com/newrelic/go-agent/v3/newrelic"
+)
+
+// WrapStreaming wraps a handler for a function using the RESPONSE_STREAM
+// invoke mode, such as a handler returning
+// *events.LambdaFunctionURLStreamingResponse, and returns a new handler with
+// instrumentation.  The returned handler should be passed to lambda.Start.
+// Since the response body is sent after the handler returns, the transaction
+// ends once the response has been completely read by the Lambda runtime.
+// StartStreaming should generally be used in place of WrapStreaming.
+func WrapStreaming[TIn any, TOut io.Reader](handler func(context.Context, TIn) (TOut, error), app *newrelic.Application) func(context.Context, TIn) (io.Reader, error) {
+	if nil == app {
+		return func(ctx context.Context, event TIn) (io.Reader, error) {
+			response, err := handler(ctx, event)
+			if nil != err {
+				return nil, err
+			}
+			return response, nil
+		}
+	}
+	wrapped := newWrappedHandler(nil, app).wrapStreaming(func(ctx context.Context, event interface{}) (io.Reader, error) {
+		response, err := handler(ctx, event.(TIn))
+		if nil != err {
+			return nil, err
+		}
+		return response, nil
+	})
+	return func(ctx context.Context, event TIn) (io.Reader, error) {
+		return wrapped(ctx, event)
+	}
+}
+
+// wrapStreaming is the non-generic part of WrapStreaming.
+func (h *wrappedHandler) wrapStreaming(handler func(context.Context, interface{}) (io.Reader, error)) func(context.Context, interface{}) (io.Reader, error) {
+	return func(ctx context.Context, event interface{}) (io.Reader, error) {
+		ctx, inv := h.startInvocation(ctx)
+		requestEvent(ctx, event)
+
+		response, err := handler(ctx, event)
+		if nil != err {
+			inv.txn.NoticeError(err)
+			inv.end()
+			return nil, err
+		}
+		responseEvent(ctx, response)
+		return &streamingResponse{body: response, inv: inv}, nil
+	}
+}
+
+// StartStreaming should be used in place of lambda.Start for functions using
+// the RESPONSE_STREAM invoke mode.  Replace:
+//
+//	lambda.Start(myhandler)
+//
+// With:
+//
+//	nrlambda.StartStreaming(myhandler, app)
+func StartStreaming[TIn any, TOut io.Reader](handler func(context.Context, TIn) (TOut, error), app *newrelic.Application) {
+	lambda.Start(WrapStreaming(handler, app))
+}
+
+// streamingResponse ends the invocation's transaction once the response body
+// has been read or closed.
+type streamingResponse struct {
+	body  io.Reader
+	inv   *invocation
+	ended sync.Once
+}
+
+func (r *streamingResponse) end(err error) {
+	r.ended.Do(func() {
+		if nil != err {
+			r.inv.txn.NoticeError(err)
+		}
+		r.inv.end()
+	})
+}
+
+func (r *streamingResponse) Read(p []byte) (int, error) {
+	n, err := r.body.Read(p)
+	if err == io.EOF {
+		r.end(nil)
+	} else if nil != err {
+		r.end(err)
+	}
+	return n, err
+}
+
+// Close is called by the Lambda runtime once the response has been sent.
+func (r *streamingResponse) Close() error {
+	var err error
+	if closer, ok := r.body.(io.Closer); ok {
+		err = closer.Close()
+	}
+	r.end(nil)
+	return err
+}
+
+// ContentType is used by the Lambda runtime to set the response content type.
+func (r *streamingResponse) ContentType() string {
+	if ct, ok := r.body.(interface{ ContentType() string }); ok {
+		return ct.ContentType()
+	}
+	return "application/octet-stream"
+}
+
+// MarshalJSON prevents the Lambda runtime from serializing the response,
+// which makes it stream the response body instead.
+func (r *streamingResponse) MarshalJSON() ([]byte, error) {
+	return nil, errors.New("streaming response is not json")
+}
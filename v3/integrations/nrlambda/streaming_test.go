@@ -0,0 +1,114 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package nrlambda
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/newrelic/go-agent/v3/internal"
+)
+
+func TestStreamingResponse(t *testing.T) {
+	app := testApp(nil, t)
+	h := newWrappedHandler(nil, app)
+	h.functionName = "functionName"
+	buf := &bytes.Buffer{}
+	h.hasWriter = bufWriterProvider{buf}
+
+	wrapped := h.wrapStreaming(func(ctx context.Context, event interface{}) (io.Reader, error) {
+		return &events.LambdaFunctionURLStreamingResponse{
+			Headers: map[string]string{"Content-Type": "text/plain"},
+			Body:    strings.NewReader("hello"),
+		}, nil
+	})
+	resp, err := wrapped(context.Background(), events.LambdaFunctionURLRequest{
+		RawPath: "/stream",
+		RequestContext: events.LambdaFunctionURLRequestContext{
+			HTTP: events.LambdaFunctionURLRequestContextHTTPDescription{Method: "GET"},
+		},
+	})
+	if nil != err {
+		t.Fatal(err)
+	}
+	if _, err := json.Marshal(resp); nil == err {
+		t.Error("streaming response should not be json serializable")
+	}
+	if ct := resp.(*streamingResponse).ContentType(); ct != "application/vnd.awslambda.http-integration-response" {
+		t.Error(ct)
+	}
+	if 0 != buf.Len() {
+		t.Error("output written before the response was read")
+	}
+
+	body, err := io.ReadAll(resp)
+	if nil != err || !strings.HasSuffix(string(body), "hello") {
+		t.Error(err, string(body))
+	}
+	resp.(io.Closer).Close()
+	if 0 == buf.Len() {
+		t.Error("no output written")
+	}
+	app.Private.(internal.Expect).ExpectTxnEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"name":             "WebTransaction/Go/functionName",
+			"nr.apdexPerfZone": internal.MatchAnything,
+			"guid":             internal.MatchAnything,
+			"priority":         internal.MatchAnything,
+			"sampled":          internal.MatchAnything,
+			"traceId":          internal.MatchAnything,
+		},
+		UserAttributes: map[string]interface{}{},
+		AgentAttributes: map[string]interface{}{
+			"aws.lambda.coldStart":         true,
+			"httpResponseCode":             "200",
+			"http.statusCode":              "200",
+			"request.method":               "GET",
+			"request.uri":                  "/stream",
+			"response.headers.contentType": "text/plain",
+		},
+	}})
+}
+
+func TestStreamingError(t *testing.T) {
+	returnError := errors.New("problem")
+	app := testApp(nil, t)
+	h := newWrappedHandler(nil, app)
+	h.functionName = "functionName"
+	buf := &bytes.Buffer{}
+	h.hasWriter = bufWriterProvider{buf}
+
+	wrapped := h.wrapStreaming(func(ctx context.Context, event interface{}) (io.Reader, error) {
+		return nil, returnError
+	})
+	if resp, err := wrapped(context.Background(), nil); err != returnError || nil != resp {
+		t.Error(resp, err)
+	}
+	if 0 == buf.Len() {
+		t.Error("no output written")
+	}
+	app.Private.(internal.Expect).ExpectMetricsPresent(t, []internal.WantMetric{
+		{Name: "OtherTransaction/Go/functionName", Scope: "", Forced: true, Data: nil},
+		{Name: "Errors/OtherTransaction/Go/functionName", Scope: "", Forced: true, Data: []float64{1, 0, 0, 0, 0, 0}},
+	})
+}
+
+func TestWrapStreamingNilApp(t *testing.T) {
+	wrapped := WrapStreaming(func(ctx context.Context, event string) (*strings.Reader, error) {
+		return strings.NewReader(event), nil
+	}, nil)
+	resp, err := wrapped(context.Background(), "hello")
+	if nil != err {
+		t.Fatal(err)
+	}
+	if body, _ := io.ReadAll(resp); string(body) != "hello" {
+		t.Error(string(body))
+	}
+}
@@ -0,0 +1,50 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"database/sql"
+	"os"
+	"time"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/postgres"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/newrelic/go-agent/v3/integrations/nrmigrate"
+	"github.com/newrelic/go-agent/v3/newrelic"
+)
+
+func main() {
+	app, err := newrelic.NewApplication(
+		newrelic.ConfigAppName("Migrate App"),
+		newrelic.ConfigLicense(os.Getenv("NEW_RELIC_LICENSE_KEY")),
+		newrelic.ConfigDebugLogger(os.Stdout),
+	)
+	if nil != err {
+		panic(err)
+	}
+	// Shutdown sends the data recorded for the migrations before exiting.
+	defer app.Shutdown(10 * time.Second)
+	if err := app.WaitForConnection(5 * time.Second); nil != err {
+		panic(err)
+	}
+
+	db, err := sql.Open("postgres", os.Getenv("DATABASE_URL"))
+	if nil != err {
+		panic(err)
+	}
+	driver, err := postgres.WithInstance(db, &postgres.Config{})
+	if nil != err {
+		panic(err)
+	}
+
+	m, err := migrate.NewWithDatabaseInstance("file://migrations", "postgres",
+		nrmigrate.WrapDriver(app, driver))
+	if nil != err {
+		panic(err)
+	}
+	if err := m.Up(); nil != err && err != migrate.ErrNoChange {
+		panic(err)
+	}
+}
@@ -0,0 +1,165 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package nrmigrate instruments https://github.com/golang-migrate/migrate.
+//
+// Use this package to record each migration step run by golang-migrate as a
+// background transaction, so that slow or failing migrations run during a
+// deploy are visible in New Relic.  Wrap the database driver using
+// WrapDriver and create the migrate.Migrate instance using
+// migrate.NewWithDatabaseInstance or migrate.NewWithInstance:
+//
+//	driver, err := postgres.WithInstance(db, &postgres.Config{})
+//	if nil != err {
+//		panic(err)
+//	}
+//	m, err := migrate.NewWithDatabaseInstance("file://migrations", "postgres",
+//		nrmigrate.WrapDriver(app, driver))
+//	if nil != err {
+//		panic(err)
+//	}
+//	err = m.Up()
+//
+// Each migration step is recorded as a transaction named
+// "Migration/<direction>/<version>", for example "Migration/up/3", with the
+// version and direction added as attributes.  Errors returned while running a
+// migration are recorded on the transaction.
+//
+// Since migrations are often run shortly before an application exits, make
+// sure to call Application.Shutdown after the migrations complete so that
+// the data is sent.
+//
+// Full example:
+// https://github.com/newrelic/go-agent/blob/master/v3/integrations/nrmigrate/example/main.go
+package nrmigrate
+
+import (
+	"io"
+	"strconv"
+
+	"github.com/golang-migrate/migrate/v4/database"
+	"github.com/newrelic/go-agent/v3/internal"
+	"github.com/newrelic/go-agent/v3/newrelic"
+)
+
+func init() { internal.TrackUsage("integration", "migration", "migrate") }
+
+const (
+	// AttributeMigrationVersion is the custom attribute containing the
+	// version of the migration run by the transaction.
+	AttributeMigrationVersion = "migration.version"
+	// AttributeMigrationDirection is the custom attribute containing the
+	// direction, "up" or "down", of the migration run by the transaction.
+	AttributeMigrationDirection = "migration.direction"
+)
+
+// WrapDriver returns a database.Driver which records each migration step run
+// using the driver as a transaction.  If app is nil, the driver is returned
+// unchanged.
+func WrapDriver(app *newrelic.Application, driver database.Driver) database.Driver {
+	if nil == app || nil == driver {
+		return driver
+	}
+	return &wrappedDriver{
+		Driver:  driver,
+		app:     app,
+		current: database.NilVersion,
+	}
+}
+
+// wrappedDriver tracks the migration steps made by migrate.Migrate.  Each
+// step calls SetVersion with the target version and dirty set to true, then
+// Run with the migration body, and finally SetVersion with dirty set to
+// false.
+type wrappedDriver struct {
+	database.Driver
+	app *newrelic.Application
+
+	// current is the version of the database before the step in progress.
+	current      int
+	knowsCurrent bool
+	txn          *newrelic.Transaction
+}
+
+func (d *wrappedDriver) Open(url string) (database.Driver, error) {
+	driver, err := d.Driver.Open(url)
+	if nil != err {
+		return nil, err
+	}
+	return WrapDriver(d.app, driver), nil
+}
+
+func (d *wrappedDriver) Version() (int, bool, error) {
+	version, dirty, err := d.Driver.Version()
+	if nil == err {
+		d.current = version
+		d.knowsCurrent = true
+	}
+	return version, dirty, err
+}
+
+// startStep starts the transaction for a migration step to the target
+// version.
+func (d *wrappedDriver) startStep(target int) {
+	if !d.knowsCurrent {
+		d.Version()
+	}
+	direction, version := "up", target
+	if target < d.current {
+		// A down migration reverts the current version.
+		direction, version = "down", d.current
+	}
+	v := strconv.Itoa(version)
+	d.txn = d.app.StartTransaction("Migration/" + direction + "/" + v)
+	d.txn.AddAttribute(AttributeMigrationVersion, version)
+	d.txn.AddAttribute(AttributeMigrationDirection, direction)
+}
+
+// endStep ends the transaction for the migration step in progress, if any.
+func (d *wrappedDriver) endStep(err error) {
+	if nil == d.txn {
+		return
+	}
+	if nil != err {
+		d.txn.NoticeError(err)
+	}
+	d.txn.End()
+	d.txn = nil
+}
+
+func (d *wrappedDriver) SetVersion(version int, dirty bool) error {
+	if dirty {
+		// A previous step which was not completed is abandoned.
+		d.endStep(nil)
+		d.startStep(version)
+	}
+	err := d.Driver.SetVersion(version, dirty)
+	if nil != err || !dirty {
+		d.endStep(err)
+	}
+	if nil == err {
+		d.current = version
+		d.knowsCurrent = true
+	}
+	return err
+}
+
+func (d *wrappedDriver) Run(migration io.Reader) error {
+	var seg *newrelic.Segment
+	if nil != d.txn {
+		seg = d.txn.StartSegment("Migration/run")
+	}
+	err := d.Driver.Run(migration)
+	seg.End()
+	if nil != err {
+		// migrate.Migrate stops at the first error, so the step is
+		// complete.
+		d.endStep(err)
+	}
+	return err
+}
+
+func (d *wrappedDriver) Close() error {
+	d.endStep(nil)
+	return d.Driver.Close()
+}
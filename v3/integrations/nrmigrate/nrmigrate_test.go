@@ -0,0 +1,143 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package nrmigrate
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database"
+	"github.com/golang-migrate/migrate/v4/database/stub"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/newrelic/go-agent/v3/internal"
+	"github.com/newrelic/go-agent/v3/internal/integrationsupport"
+	"github.com/newrelic/go-agent/v3/newrelic"
+)
+
+func testApp() integrationsupport.ExpectApp {
+	return integrationsupport.NewTestApp(integrationsupport.SampleEverythingReplyFn,
+		newrelic.ConfigCodeLevelMetricsEnabled(false))
+}
+
+var migrations = fstest.MapFS{
+	"1_create_users.up.sql":   {Data: []byte("CREATE TABLE users")},
+	"1_create_users.down.sql": {Data: []byte("DROP TABLE users")},
+	"2_add_email.up.sql":      {Data: []byte("ALTER TABLE users ADD email")},
+	"2_add_email.down.sql":    {Data: []byte("FAIL")},
+}
+
+// failingDriver is a stub driver which fails to run migrations containing
+// FAIL.
+type failingDriver struct {
+	database.Driver
+}
+
+func (d failingDriver) Run(migration io.Reader) error {
+	body, _ := io.ReadAll(migration)
+	if strings.Contains(string(body), "FAIL") {
+		return errors.New("migration failed")
+	}
+	return d.Driver.Run(strings.NewReader(string(body)))
+}
+
+func newMigrate(t *testing.T, app *newrelic.Application) *migrate.Migrate {
+	src, err := iofs.New(migrations, ".")
+	if nil != err {
+		t.Fatal(err)
+	}
+	drv, err := stub.WithInstance(nil, &stub.Config{})
+	if nil != err {
+		t.Fatal(err)
+	}
+	m, err := migrate.NewWithInstance("iofs", src, "stub", WrapDriver(app, failingDriver{drv}))
+	if nil != err {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestWrapDriverNilApp(t *testing.T) {
+	drv, _ := stub.WithInstance(nil, &stub.Config{})
+	if WrapDriver(nil, drv) != drv {
+		t.Error("driver should not be wrapped")
+	}
+}
+
+func TestUp(t *testing.T) {
+	app := testApp()
+	m := newMigrate(t, app.Application)
+	if err := m.Up(); nil != err {
+		t.Fatal(err)
+	}
+
+	app.ExpectMetricsPresent(t, []internal.WantMetric{
+		{Name: "OtherTransaction/Go/Migration/up/1", Scope: ""},
+		{Name: "OtherTransaction/Go/Migration/up/2", Scope: ""},
+		{Name: "Custom/Migration/run", Scope: "OtherTransaction/Go/Migration/up/1"},
+	})
+	app.ExpectTxnEvents(t, []internal.WantEvent{
+		{
+			Intrinsics: map[string]interface{}{
+				"name":     "OtherTransaction/Go/Migration/up/1",
+				"guid":     internal.MatchAnything,
+				"priority": internal.MatchAnything,
+				"sampled":  internal.MatchAnything,
+				"traceId":  internal.MatchAnything,
+			},
+			UserAttributes: map[string]interface{}{
+				AttributeMigrationVersion:   1,
+				AttributeMigrationDirection: "up",
+			},
+		},
+		{
+			Intrinsics: map[string]interface{}{
+				"name":     "OtherTransaction/Go/Migration/up/2",
+				"guid":     internal.MatchAnything,
+				"priority": internal.MatchAnything,
+				"sampled":  internal.MatchAnything,
+				"traceId":  internal.MatchAnything,
+			},
+			UserAttributes: map[string]interface{}{
+				AttributeMigrationVersion:   2,
+				AttributeMigrationDirection: "up",
+			},
+		},
+	})
+}
+
+func TestDownError(t *testing.T) {
+	app := testApp()
+	m := newMigrate(t, app.Application)
+	if err := m.Up(); nil != err {
+		t.Fatal(err)
+	}
+	if err := m.Steps(-1); nil == err {
+		t.Fatal("expected error")
+	}
+
+	app.ExpectMetricsPresent(t, []internal.WantMetric{
+		{Name: "OtherTransaction/Go/Migration/down/2", Scope: ""},
+		{Name: "Errors/OtherTransaction/Go/Migration/down/2", Scope: ""},
+	})
+	app.ExpectErrorEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"error.class":     "*errors.errorString",
+			"error.message":   "migration failed",
+			"transactionName": "OtherTransaction/Go/Migration/down/2",
+			"guid":            internal.MatchAnything,
+			"priority":        internal.MatchAnything,
+			"sampled":         internal.MatchAnything,
+			"spanId":          internal.MatchAnything,
+			"traceId":         internal.MatchAnything,
+		},
+		UserAttributes: map[string]interface{}{
+			AttributeMigrationVersion:   2,
+			AttributeMigrationDirection: "down",
+		},
+	}})
+}
@@ -0,0 +1,60 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package nrpgx
+
+import (
+	"context"
+	"database/sql/driver"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/newrelic/go-agent/v3/internal/integrationsupport"
+	"github.com/newrelic/go-agent/v3/newrelic"
+)
+
+// explainPlan returns the function used by the segment to capture the plan of
+// the query.  The plan is captured on a separate connection opened with the
+// data source name of the database, since the connection of the query may be
+// in use by the time the plan is captured.
+func explainPlan(segment *newrelic.DatastoreSegment, dataSourceName, query string, args []driver.NamedValue) func() (string, error) {
+	if dataSourceName == "" || !integrationsupport.PostgresExplainable(segment.Operation) {
+		return nil
+	}
+	return func() (string, error) {
+		values := make([]interface{}, len(args))
+		for i, arg := range args {
+			values[i] = arg.Value
+		}
+		return explainQuery(dataSourceName, query, values)
+	}
+}
+
+func explainQuery(dataSourceName, query string, args []interface{}) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), integrationsupport.PostgresExplainTimeout)
+	defer cancel()
+
+	conn, err := pgx.Connect(ctx, dataSourceName)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close(ctx)
+
+	rows, err := conn.Query(ctx, "EXPLAIN "+query, args...)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+
+	var lines []string
+	for rows.Next() {
+		var line string
+		if err := rows.Scan(&line); err != nil {
+			return "", err
+		}
+		lines = append(lines, line)
+	}
+	if err := rows.Err(); err != nil {
+		return "", err
+	}
+	return integrationsupport.PostgresPlan(lines), nil
+}
@@ -53,6 +53,18 @@
 // https://github.com/newrelic/go-agent/tree/master/v3/integrations/nrpgx/example/sql_compat/main.go
 //
 //
+// QUERY PLANS
+//
+// When the agent is configured with newrelic.ConfigDatastoreExplainPlan(true),
+// the plan of each SELECT, INSERT, UPDATE, or DELETE query slower than
+// newrelic.ConfigDatastoreExplainPlanThreshold is captured in the background by
+// running EXPLAIN, never EXPLAIN ANALYZE, on a separate connection opened with
+// the data source name given to sql.Open.  The literals in the conditions of
+// the plan are replaced by "?", and the plan is added to the slow query trace
+// and the transaction trace.  No plan is captured for the databases opened
+// using a driver.Connector, such as those opened by stdlib.OpenDB.
+//
+//
 // USING WITH DIRECT PGX CALLS WITHOUT DATABASE/SQL
 //
 // This mode of operation is not supported by the nrpgx integration at this time.
@@ -79,8 +91,9 @@ var (
 		BaseSegment: newrelic.DatastoreSegment{
 			Product: newrelic.DatastorePostgres,
 		},
-		ParseQuery: sqlparse.ParseQuery,
-		ParseDSN:   parseDSN(os.Getenv),
+		ParseQuery:  sqlparse.ParseQuery,
+		ParseDSN:    parseDSN(os.Getenv),
+		ExplainPlan: explainPlan,
 	}
 )
 
@@ -45,6 +45,11 @@ func main() {
 	// N.B.: We do not recommend using app.WaitForConnection in production code.
 	//
 	app.WaitForConnection(5 * time.Second)
+
+	// Record the statistics of the pool every minute.
+	stop := nrpgx5.MonitorPool(app, db)
+	defer stop()
+
 	txn := app.StartTransaction("postgresQuery")
 
 	ctx := newrelic.NewContext(context.Background(), txn)
@@ -0,0 +1,57 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package nrpgx5
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/newrelic/go-agent/v3/internal/integrationsupport"
+	"github.com/newrelic/go-agent/v3/newrelic"
+)
+
+// explainPlan returns the function used by the segment to capture the plan of
+// the query.  The plan is captured on a separate connection, since the
+// connection of the query may be in use by the time the plan is captured, for
+// example while the rows of the query are read.
+func explainPlan(segment *newrelic.DatastoreSegment, conn *pgx.Conn, sql string, args []interface{}) func() (string, error) {
+	if conn == nil || !integrationsupport.PostgresExplainable(segment.Operation) {
+		return nil
+	}
+	return func() (string, error) {
+		return explainQuery(conn.Config(), sql, args)
+	}
+}
+
+func explainQuery(cfg *pgx.ConnConfig, sql string, args []interface{}) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), integrationsupport.PostgresExplainTimeout)
+	defer cancel()
+
+	// The configuration returned by pgx.Conn.Config is a copy.
+	cfg.Tracer = nil
+	conn, err := pgx.ConnectConfig(ctx, cfg)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close(ctx)
+
+	rows, err := conn.Query(ctx, "EXPLAIN "+sql, args...)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+
+	var lines []string
+	for rows.Next() {
+		var line string
+		if err := rows.Scan(&line); err != nil {
+			return "", err
+		}
+		lines = append(lines, line)
+	}
+	if err := rows.Err(); err != nil {
+		return "", err
+	}
+	return integrationsupport.PostgresPlan(lines), nil
+}
@@ -0,0 +1,31 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package nrpgx5
+
+import (
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/newrelic/go-agent/v3/newrelic"
+)
+
+func TestExplainPlanOperations(t *testing.T) {
+	if fn := explainPlan(&newrelic.DatastoreSegment{Operation: "select"}, nil, "SELECT 1", nil); fn != nil {
+		t.Error("expected no plan without a connection")
+	}
+	con := &pgx.Conn{}
+	for op, explain := range map[string]bool{
+		"select": true,
+		"INSERT": true,
+		"update": true,
+		"delete": true,
+		"create": false,
+		"":       false,
+	} {
+		fn := explainPlan(&newrelic.DatastoreSegment{Operation: op}, con, "", nil)
+		if (fn != nil) != explain {
+			t.Errorf("operation %q: expected plan %v", op, explain)
+		}
+	}
+}
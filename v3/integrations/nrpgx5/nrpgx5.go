@@ -35,6 +35,13 @@
 //       }
 //    }
 //
+// When the agent is configured with newrelic.ConfigDatastoreExplainPlan(true),
+// the plan of each SELECT, INSERT, UPDATE, or DELETE query slower than
+// newrelic.ConfigDatastoreExplainPlanThreshold is captured in the background by
+// running EXPLAIN, never EXPLAIN ANALYZE, on a separate connection.  The
+// literals in the conditions of the plan are replaced by "?", and the plan is
+// added to the slow query trace and the transaction trace.
+//
 // See the programs in the example directory for working examples of each use case.
 package nrpgx5
 
@@ -146,6 +153,7 @@ func (t *Tracer) TraceQueryStart(ctx context.Context, conn *pgx.Conn, data pgx.T
 
 	// fill Operation and Collection
 	t.ParseQuery(&segment, data.SQL)
+	segment.ExplainPlan = explainPlan(&segment, conn, data.SQL, data.Args)
 	if newrelic.IsSecurityAgentPresent() {
 		stoken := newrelic.GetSecurityAgentInterface().SendEvent("SQL", data.SQL, data.Args)
 		ctx = context.WithValue(ctx, querySecurityKey, stoken)
@@ -0,0 +1,128 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package nrpgx5
+
+import (
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/newrelic/go-agent/v3/newrelic"
+)
+
+// poolStatsPeriod matches the harvest period of the metrics.
+const poolStatsPeriod = 60 * time.Second
+
+// poolMetricPrefix is followed by the database name and the statistic.  The
+// custom metrics recorded are prefixed with "Custom/".
+const poolMetricPrefix = "DatastorePool/Postgres/"
+
+// MonitorPool starts a background sampler which records the statistics of
+// the pool, as returned by pgxpool.Pool.Stat, as custom metrics every minute,
+// so that the exhaustion of the pool shows up alongside the query spans.  The
+// metrics are named after the database of the pool:
+//
+//	Custom/DatastorePool/Postgres/<database>/AcquiredConns
+//	Custom/DatastorePool/Postgres/<database>/IdleConns
+//	Custom/DatastorePool/Postgres/<database>/TotalConns
+//	Custom/DatastorePool/Postgres/<database>/MaxConns
+//	Custom/DatastorePool/Postgres/<database>/AcquireCount
+//	Custom/DatastorePool/Postgres/<database>/AcquireDuration
+//	Custom/DatastorePool/Postgres/<database>/CanceledAcquireCount
+//	Custom/DatastorePool/Postgres/<database>/EmptyAcquireCount
+//
+// The counts and the duration, in seconds, of the acquires are those since the
+// previous sample.  The returned function stops the sampler, and should be
+// called before the pool is closed.
+//
+//	pool, err := pgxpool.NewWithConfig(context.Background(), cfg)
+//	if err != nil {
+//		panic(err)
+//	}
+//	defer pool.Close()
+//	stop := nrpgx5.MonitorPool(app, pool)
+//	defer stop()
+func MonitorPool(app *newrelic.Application, pool *pgxpool.Pool) (stop func()) {
+	if app == nil || pool == nil {
+		return func() {}
+	}
+	s := newPoolSampler(app, pool)
+	done := make(chan struct{})
+	go s.run(poolStatsPeriod, done)
+
+	var once sync.Once
+	return func() { once.Do(func() { close(done) }) }
+}
+
+type poolSampler struct {
+	app      *newrelic.Application
+	pool     *pgxpool.Pool
+	prefix   string
+	previous poolCounters
+}
+
+// poolCounters holds the cumulative statistics of a pool.
+type poolCounters struct {
+	acquireCount         int64
+	acquireDuration      time.Duration
+	canceledAcquireCount int64
+	emptyAcquireCount    int64
+}
+
+func newPoolSampler(app *newrelic.Application, pool *pgxpool.Pool) *poolSampler {
+	database := pool.Config().ConnConfig.Database
+	if database == "" {
+		database = "unknown"
+	}
+	s := &poolSampler{
+		app:    app,
+		pool:   pool,
+		prefix: poolMetricPrefix + database + "/",
+	}
+	s.previous = countersFromStat(pool.Stat())
+	return s
+}
+
+func countersFromStat(stat *pgxpool.Stat) poolCounters {
+	return poolCounters{
+		acquireCount:         stat.AcquireCount(),
+		acquireDuration:      stat.AcquireDuration(),
+		canceledAcquireCount: stat.CanceledAcquireCount(),
+		emptyAcquireCount:    stat.EmptyAcquireCount(),
+	}
+}
+
+func (s *poolSampler) run(period time.Duration, done <-chan struct{}) {
+	t := time.NewTicker(period)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			s.sample()
+		case <-done:
+			return
+		}
+	}
+}
+
+// sample records the current statistics of the pool.
+func (s *poolSampler) sample() {
+	stat := s.pool.Stat()
+	current := countersFromStat(stat)
+
+	s.record("AcquiredConns", float64(stat.AcquiredConns()))
+	s.record("IdleConns", float64(stat.IdleConns()))
+	s.record("TotalConns", float64(stat.TotalConns()))
+	s.record("MaxConns", float64(stat.MaxConns()))
+	s.record("AcquireCount", float64(current.acquireCount-s.previous.acquireCount))
+	s.record("AcquireDuration", (current.acquireDuration - s.previous.acquireDuration).Seconds())
+	s.record("CanceledAcquireCount", float64(current.canceledAcquireCount-s.previous.canceledAcquireCount))
+	s.record("EmptyAcquireCount", float64(current.emptyAcquireCount-s.previous.emptyAcquireCount))
+
+	s.previous = current
+}
+
+func (s *poolSampler) record(name string, value float64) {
+	s.app.RecordCustomMetric(s.prefix+name, value)
+}
@@ -0,0 +1,48 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/newrelic/go-agent/v3/integrations/nrprometheus"
+	"github.com/newrelic/go-agent/v3/newrelic"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var requests = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "hello_requests_total",
+	Help: "The number of requests handled.",
+}, []string{"code"})
+
+func hello(w http.ResponseWriter, r *http.Request) {
+	requests.WithLabelValues("200").Inc()
+	w.Write([]byte("hello world"))
+}
+
+func main() {
+	app, err := newrelic.NewApplication(
+		newrelic.ConfigAppName("Prometheus App"),
+		newrelic.ConfigLicense(os.Getenv("NEW_RELIC_LICENSE_KEY")),
+		newrelic.ConfigDebugLogger(os.Stdout),
+	)
+	if nil != err {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	// The metrics registered with prometheus.DefaultRegisterer, including
+	// the counter above, are forwarded every minute.
+	forwarder := nrprometheus.Start(app, prometheus.DefaultGatherer, 0)
+	defer forwarder.Stop()
+
+	http.HandleFunc(newrelic.WrapHandleFunc(app, "/hello", hello))
+	http.ListenAndServe(":8000", nil)
+
+	app.Shutdown(10 * time.Second)
+}
@@ -0,0 +1,244 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package nrprometheus forwards the metrics of a
+// https://github.com/prometheus/client_golang registry to New Relic, so that
+// applications already instrumented using Prometheus do not need to be
+// instrumented twice.
+//
+// Start a Forwarder with the registry, or nil to use
+// prometheus.DefaultGatherer:
+//
+//	forwarder := nrprometheus.Start(app, prometheus.DefaultGatherer, 0)
+//	defer forwarder.Stop()
+//
+// The metrics of the registry are gathered every interval, a minute by
+// default, and recorded as custom metrics named after the Prometheus metric
+// and its labels, sorted by name.  For example, the value of
+// http_requests_total{code="200",method="get"} is recorded in the
+// "Custom/Prometheus/http_requests_total/code/200/method/get" metric.
+//
+//   - Gauges and untyped metrics record their current value.
+//   - Counters record their increase since the previous collection.  The
+//     first collection only records the values the increase is computed
+//     from.
+//   - Histograms record the increase of their count and sum in the
+//     ".../count" and ".../sum" metrics, and the number of observations in
+//     each bucket in the ".../bucket/<upper bound>" metrics.  Like
+//     newrelic.Application.RecordHistogram, each bucket counts the
+//     observations greater than the upper bound of the previous bucket, and
+//     the "+Inf" bucket counts the observations greater than every bound.
+//   - Summaries record the increase of their count and sum like histograms,
+//     and the current value of each quantile in the ".../quantile/<quantile>"
+//     metrics.
+//
+// Each label adds a metric for each of its values, so avoid forwarding
+// metrics whose labels have many values.
+//
+// Full example:
+// https://github.com/newrelic/go-agent/blob/master/v3/integrations/nrprometheus/example/main.go
+package nrprometheus
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/newrelic/go-agent/v3/internal"
+	"github.com/newrelic/go-agent/v3/newrelic"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func init() { internal.TrackUsage("integration", "metrics", "prometheus") }
+
+// defaultInterval is the interval between collections used when none is
+// provided.
+const defaultInterval = time.Minute
+
+// metricPrefix is the prefix of the names of the metrics forwarded, after
+// the "Custom/" prefix added by RecordCustomMetric.
+const metricPrefix = "Prometheus/"
+
+// Forwarder periodically gathers the metrics of a Prometheus registry and
+// records them as New Relic custom metrics.
+type Forwarder struct {
+	app      *newrelic.Application
+	gatherer prometheus.Gatherer
+	interval time.Duration
+
+	// mu protects previous, which holds the values of the cumulative
+	// metrics at the previous collection by metric name.
+	mu       sync.Mutex
+	previous map[string]float64
+
+	stopOnce sync.Once
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// Start starts forwarding the metrics of the gatherer every interval.  If
+// gatherer is nil, prometheus.DefaultGatherer is used.  If interval is not
+// positive, the metrics are forwarded every minute.  Call Stop on the
+// Forwarder returned to stop forwarding.
+func Start(app *newrelic.Application, gatherer prometheus.Gatherer, interval time.Duration) *Forwarder {
+	f := newForwarder(app, gatherer, interval)
+	go f.run()
+	return f
+}
+
+func newForwarder(app *newrelic.Application, gatherer prometheus.Gatherer, interval time.Duration) *Forwarder {
+	if gatherer == nil {
+		gatherer = prometheus.DefaultGatherer
+	}
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+	return &Forwarder{
+		app:      app,
+		gatherer: gatherer,
+		interval: interval,
+		previous: make(map[string]float64),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+func (f *Forwarder) run() {
+	defer close(f.done)
+	t := time.NewTicker(f.interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			f.Collect()
+		case <-f.stop:
+			return
+		}
+	}
+}
+
+// Stop stops forwarding the metrics, and waits for a collection in progress
+// to complete.
+func (f *Forwarder) Stop() {
+	if f == nil {
+		return
+	}
+	f.stopOnce.Do(func() { close(f.stop) })
+	<-f.done
+}
+
+// Collect gathers the metrics of the registry and records them.  It is
+// called every interval once the Forwarder is started, and may also be
+// called directly.  Metrics gathered despite an error are still recorded.
+func (f *Forwarder) Collect() {
+	if f == nil || f.app == nil {
+		return
+	}
+	families, err := f.gatherer.Gather()
+	if err != nil {
+		if cfg, ok := f.app.Config(); ok && cfg.Logger != nil {
+			cfg.Logger.Error("unable to gather prometheus metrics", map[string]interface{}{
+				"reason": err.Error(),
+			})
+		}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, family := range families {
+		for _, m := range family.GetMetric() {
+			f.record(family.GetType(), metricName(family.GetName(), m.GetLabel()), m)
+		}
+	}
+}
+
+func (f *Forwarder) record(typ dto.MetricType, name string, m *dto.Metric) {
+	switch typ {
+	case dto.MetricType_GAUGE:
+		f.gauge(name, m.GetGauge().GetValue())
+	case dto.MetricType_UNTYPED:
+		f.gauge(name, m.GetUntyped().GetValue())
+	case dto.MetricType_COUNTER:
+		f.counter(name, m.GetCounter().GetValue())
+	case dto.MetricType_HISTOGRAM:
+		h := m.GetHistogram()
+		f.counter(name+"/count", float64(h.GetSampleCount()))
+		f.counter(name+"/sum", h.GetSampleSum())
+		// The bucket counts are cumulative, and the increase of each
+		// bucket is recorded without the observations of the previous
+		// buckets.
+		var previous float64
+		for _, b := range h.GetBucket() {
+			count := float64(b.GetCumulativeCount())
+			f.counter(name+"/bucket/"+formatFloat(b.GetUpperBound()), count-previous)
+			previous = count
+		}
+		if !math.IsInf(lastUpperBound(h.GetBucket()), 1) {
+			f.counter(name+"/bucket/+Inf", float64(h.GetSampleCount())-previous)
+		}
+	case dto.MetricType_SUMMARY:
+		s := m.GetSummary()
+		f.counter(name+"/count", float64(s.GetSampleCount()))
+		f.counter(name+"/sum", s.GetSampleSum())
+		for _, q := range s.GetQuantile() {
+			f.gauge(name+"/quantile/"+formatFloat(q.GetQuantile()), q.GetValue())
+		}
+	}
+}
+
+func (f *Forwarder) gauge(name string, value float64) {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return
+	}
+	f.app.RecordCustomMetric(name, value)
+}
+
+// counter records the increase of a cumulative value since the previous
+// collection.  A value lower than the previous one means that the value was
+// reset, in which case the whole value is the increase.
+func (f *Forwarder) counter(name string, value float64) {
+	previous, seen := f.previous[name]
+	f.previous[name] = value
+	if !seen {
+		return
+	}
+	if value < previous {
+		previous = 0
+	}
+	f.gauge(name, value-previous)
+}
+
+func lastUpperBound(buckets []*dto.Bucket) float64 {
+	if len(buckets) == 0 {
+		return 0
+	}
+	return buckets[len(buckets)-1].GetUpperBound()
+}
+
+// metricName returns the name of the custom metric of a Prometheus metric
+// with the labels given.
+func metricName(name string, labels []*dto.LabelPair) string {
+	sorted := make([]*dto.LabelPair, len(labels))
+	copy(sorted, labels)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].GetName() < sorted[j].GetName()
+	})
+	var b strings.Builder
+	b.WriteString(metricPrefix)
+	b.WriteString(name)
+	for _, l := range sorted {
+		b.WriteByte('/')
+		b.WriteString(l.GetName())
+		b.WriteByte('/')
+		b.WriteString(l.GetValue())
+	}
+	return b.String()
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
@@ -0,0 +1,88 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package nrprometheus
+
+import (
+	"testing"
+	"time"
+
+	"github.com/newrelic/go-agent/v3/internal"
+	"github.com/newrelic/go-agent/v3/internal/integrationsupport"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestCollect(t *testing.T) {
+	app := integrationsupport.NewBasicTestApp()
+	registry := prometheus.NewRegistry()
+	gauge := prometheus.NewGauge(prometheus.GaugeOpts{Name: "queue_size"})
+	counter := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "requests_total"}, []string{"method", "code"})
+	histogram := prometheus.NewHistogram(prometheus.HistogramOpts{Name: "latency_seconds", Buckets: []float64{1, 10}})
+	registry.MustRegister(gauge, counter, histogram)
+
+	f := newForwarder(app.Application, registry, 0)
+	if f.interval != defaultInterval {
+		t.Error(f.interval)
+	}
+
+	gauge.Set(3)
+	counter.WithLabelValues("get", "200").Add(5)
+	histogram.Observe(0.5)
+	f.Collect()
+
+	// Only the gauge is recorded by the first collection.
+	app.ExpectMetrics(t, []internal.WantMetric{
+		{Name: "Custom/Prometheus/queue_size", Data: []float64{1, 3, 3, 3, 3, 9}},
+	})
+
+	counter.WithLabelValues("get", "200").Add(2)
+	histogram.Observe(0.5)
+	histogram.Observe(5)
+	histogram.Observe(50)
+	f.Collect()
+
+	app.ExpectMetrics(t, []internal.WantMetric{
+		{Name: "Custom/Prometheus/queue_size", Data: []float64{2, 6, 6, 3, 3, 18}},
+		{Name: "Custom/Prometheus/requests_total/code/200/method/get", Data: []float64{1, 2, 2, 2, 2, 4}},
+		{Name: "Custom/Prometheus/latency_seconds/count", Data: []float64{1, 3, 3, 3, 3, 9}},
+		{Name: "Custom/Prometheus/latency_seconds/sum", Data: []float64{1, 55.5, 55.5, 55.5, 55.5, 55.5 * 55.5}},
+		{Name: "Custom/Prometheus/latency_seconds/bucket/1", Data: []float64{1, 1, 1, 1, 1, 1}},
+		{Name: "Custom/Prometheus/latency_seconds/bucket/10", Data: []float64{1, 1, 1, 1, 1, 1}},
+		{Name: "Custom/Prometheus/latency_seconds/bucket/+Inf", Data: []float64{1, 1, 1, 1, 1, 1}},
+	})
+}
+
+func TestCounterReset(t *testing.T) {
+	app := integrationsupport.NewBasicTestApp()
+	f := newForwarder(app.Application, prometheus.NewRegistry(), time.Second)
+	f.counter("requests_total", 10)
+	f.counter("requests_total", 4)
+	app.ExpectMetrics(t, []internal.WantMetric{
+		{Name: "Custom/requests_total", Data: []float64{1, 4, 4, 4, 4, 16}},
+	})
+}
+
+func TestMetricName(t *testing.T) {
+	name, value1, value2 := "b", "1", "2"
+	nameA := "a"
+	labels := []*dto.LabelPair{
+		{Name: &name, Value: &value2},
+		{Name: &nameA, Value: &value1},
+	}
+	if n := metricName("metric", labels); n != "Prometheus/metric/a/1/b/2" {
+		t.Error(n)
+	}
+	if n := metricName("metric", nil); n != "Prometheus/metric" {
+		t.Error(n)
+	}
+}
+
+func TestStop(t *testing.T) {
+	f := Start(nil, prometheus.NewRegistry(), time.Millisecond)
+	f.Stop()
+	f.Stop()
+	var nilForwarder *Forwarder
+	nilForwarder.Stop()
+	nilForwarder.Collect()
+}
@@ -0,0 +1,345 @@
+// Copyright 2023 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package nrredis
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/newrelic/go-agent/v3/internal/integrationsupport"
+	newrelic "github.com/newrelic/go-agent/v3/newrelic"
+	redis "github.com/redis/go-redis/v9"
+)
+
+// InstrumentClusterClient adds a hook to the redis.ClusterClient which
+// instruments its commands like NewHook.  Rather than the seed address of the
+// options, the segment of each command records the address of the node the
+// command was routed to, the hash slot of its key as the "db.redis.slot"
+// attribute, and, if the command was redirected to another node, "MOVED" or
+// "ASK" as the "db.redis.redirect" attribute.  Call it before using the
+// client, instead of adding the hook returned by NewHook.
+//
+//	client := redis.NewClusterClient(&redis.ClusterOptions{
+//		Addrs: []string{":7000", ":7001", ":7002"},
+//	})
+//	nrredis.InstrumentClusterClient(client, nrredis.WithApplication(app))
+//
+// Redirections and refreshes of the cluster topology are also recorded as
+// custom events and metrics, since slot migrations often explain latency
+// spikes.  Each MOVED or ASK redirection is recorded as a
+// "RedisClusterRedirect" event and counted in the
+// "Custom/Redis/Cluster/Redirect/MOVED" or "Custom/Redis/Cluster/Redirect/ASK"
+// metric.  Each query of the slots of the cluster made by the client is
+// recorded as a "RedisClusterTopologyRefresh" event and timed in the
+// "Custom/Redis/Cluster/TopologyRefresh" metric.  The client refreshes its
+// topology in the background, outside of any transaction, so these are only
+// recorded when the application is provided using WithApplication.  Without
+// it, redirections are recorded using the application of the transaction of
+// the command.
+func InstrumentClusterClient(c *redis.ClusterClient, options ...ClusterOption) {
+	if c == nil {
+		return
+	}
+	var cfg clusterConfig
+	for _, option := range options {
+		if option != nil {
+			option(&cfg)
+		}
+	}
+	h := hook{routed: true, slots: true}
+	h.segment.Product = newrelic.DatastoreRedis
+	c.AddHook(h)
+	c.OnNewNode(func(node *redis.Client) {
+		addNodeHook(node, cfg.app)
+	})
+}
+
+// ClusterOption configures InstrumentClusterClient.
+type ClusterOption func(*clusterConfig)
+
+type clusterConfig struct {
+	app *newrelic.Application
+}
+
+// WithApplication sets the application used to record the redirections and
+// topology refreshes of a redis.ClusterClient.
+func WithApplication(app *newrelic.Application) ClusterOption {
+	return func(cfg *clusterConfig) {
+		cfg.app = app
+	}
+}
+
+// InstrumentRing adds a hook to the redis.Ring which instruments its commands
+// like NewHook.  Rather than the seed address of the options, the segment of
+// each command records the address of the shard the command was routed to.
+// Call it before using the ring, instead of adding the hook returned by
+// NewHook.
+//
+//	ring := redis.NewRing(&redis.RingOptions{
+//		Addrs: map[string]string{"shard1": ":7000", "shard2": ":7001"},
+//	})
+//	nrredis.InstrumentRing(ring)
+func InstrumentRing(r *redis.Ring) {
+	if r == nil {
+		return
+	}
+	h := hook{routed: true}
+	h.segment.Product = newrelic.DatastoreRedis
+	r.AddHook(h)
+	r.OnNewNode(func(node *redis.Client) {
+		addNodeHook(node, nil)
+	})
+	// The shards of the options are created with the ring.
+	r.ForEachShard(context.Background(), func(ctx context.Context, shard *redis.Client) error {
+		addNodeHook(shard, nil)
+		return nil
+	})
+}
+
+func addNodeHook(node *redis.Client, app *newrelic.Application) {
+	h := nodeHook{app: app}
+	if opts := node.Options(); opts != nil {
+		h.host, h.port = instance(opts)
+	}
+	node.AddHook(h)
+}
+
+// nodeHook is added to the node clients of a redis.ClusterClient or a
+// redis.Ring.  It records the routing of a command on the segment started by
+// the hook of the ClusterClient or Ring.
+type nodeHook struct {
+	host string
+	port string
+	// app records the redirections and topology refreshes of a
+	// redis.ClusterClient, if set using WithApplication.
+	app *newrelic.Application
+}
+
+var _ redis.Hook = nodeHook{}
+
+func (h nodeHook) route(ctx context.Context) {
+	if s, ok := ctx.Value(routingContextKey).(*newrelic.DatastoreSegment); ok {
+		s.Host = h.host
+		s.PortPathOrID = h.port
+	}
+}
+
+// Custom event types and metric names recorded for a redis.ClusterClient.
+const (
+	redirectEventType        = "RedisClusterRedirect"
+	topologyRefreshEventType = "RedisClusterTopologyRefresh"
+	redirectMetricPrefix     = "Redis/Cluster/Redirect/"
+	topologyRefreshMetric    = "Redis/Cluster/TopologyRefresh"
+)
+
+// application returns the application used to record the events of the
+// node: the one provided using WithApplication, or else the one of the
+// transaction of the context.
+func (h nodeHook) application(ctx context.Context) *newrelic.Application {
+	if h.app != nil {
+		return h.app
+	}
+	return newrelic.FromContext(ctx).Application()
+}
+
+func (h nodeHook) redirected(ctx context.Context, command string, err error) {
+	if err == nil {
+		return
+	}
+	redirect := redirectKind(err)
+	if redirect == "" {
+		return
+	}
+	if ctx.Value(routingContextKey) != nil {
+		integrationsupport.AddAgentSpanAttribute(newrelic.FromContext(ctx), newrelic.SpanAttributeRedisRedirect, redirect)
+	}
+	app := h.application(ctx)
+	if app == nil {
+		return
+	}
+	params := map[string]interface{}{
+		"kind":    redirect,
+		"command": command,
+		"host":    h.host,
+		"port":    h.port,
+	}
+	// The error is in the form "MOVED <slot> <address>".
+	if fields := strings.Fields(err.Error()); len(fields) == 3 {
+		if slot, err := strconv.Atoi(fields[1]); err == nil {
+			params["slot"] = slot
+		}
+		params["target"] = fields[2]
+	}
+	app.RecordCustomEvent(redirectEventType, params)
+	app.RecordCustomMetric(redirectMetricPrefix+redirect, 1)
+}
+
+// refreshedTopology records a query of the slots of the cluster, which a
+// redis.ClusterClient makes to refresh its topology.
+func (h nodeHook) refreshedTopology(ctx context.Context, cmd redis.Cmder, duration time.Duration, err error) {
+	if !isTopologyCommand(cmd) {
+		return
+	}
+	app := h.application(ctx)
+	if app == nil {
+		return
+	}
+	params := map[string]interface{}{
+		"command":  "cluster " + stringArg(cmd, 1),
+		"host":     h.host,
+		"port":     h.port,
+		"duration": duration.Seconds(),
+	}
+	if err != nil {
+		params["error"] = err.Error()
+	}
+	app.RecordCustomEvent(topologyRefreshEventType, params)
+	app.RecordCustomMetric(topologyRefreshMetric, duration.Seconds())
+}
+
+// isTopologyCommand returns whether the command queries the slots of the
+// cluster.
+func isTopologyCommand(cmd redis.Cmder) bool {
+	if cmd.Name() != "cluster" {
+		return false
+	}
+	switch strings.ToLower(stringArg(cmd, 1)) {
+	case "slots", "shards", "nodes":
+		return true
+	}
+	return false
+}
+
+func (h nodeHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (h nodeHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		h.route(ctx)
+		start := time.Now()
+		err := next(ctx, cmd)
+		h.refreshedTopology(ctx, cmd, time.Since(start), err)
+		h.redirected(ctx, cmd.Name(), err)
+		return err
+	}
+}
+
+// ProcessPipelineHook records the routing of the command retried by a
+// redis.ClusterClient after an ASK redirection, which is sent in a pipeline
+// after the ASKING command.  The routing of pipelines is not recorded, since
+// the commands of a pipeline may be sent to several nodes.
+func (h nodeHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		h.route(ctx)
+		err := next(ctx, cmds)
+		h.redirected(ctx, pipelineOperation(cmds), err)
+		return err
+	}
+}
+
+// redirectKind returns "MOVED" or "ASK" if err is a redirection of Redis
+// Cluster.
+func redirectKind(err error) string {
+	msg := err.Error()
+	switch {
+	case strings.HasPrefix(msg, "MOVED "):
+		return "MOVED"
+	case strings.HasPrefix(msg, "ASK "):
+		return "ASK"
+	}
+	return ""
+}
+
+// instance returns the host and port of the options, as reported by the
+// segments.
+func instance(opts *redis.Options) (host, port string) {
+	// Per https://pkg.go.dev/github.com/redis/go-redis#Options the
+	// network should either be tcp or unix, and the default is tcp.
+	if opts.Network == "unix" {
+		return "localhost", opts.Addr
+	}
+	host, port, err := net.SplitHostPort(opts.Addr)
+	if err != nil {
+		return "", ""
+	}
+	if host == "" {
+		host = "localhost"
+	}
+	return host, port
+}
+
+// slotCount is the number of hash slots of Redis Cluster.
+const slotCount = 16384
+
+// commandSlot returns the hash slot of the first key of the command, following
+// the logic of redis.ClusterClient, or false if the command has no key.
+func commandSlot(cmd redis.Cmder) (int, bool) {
+	pos := 1
+	switch cmd.Name() {
+	case "eval", "evalsha", "eval_ro", "evalsha_ro", "fcall", "fcall_ro":
+		if stringArg(cmd, 2) == "0" {
+			return 0, false
+		}
+		pos = 3
+	case "memory":
+		if stringArg(cmd, 1) == "usage" {
+			pos = 2
+		}
+	}
+	if pos >= len(cmd.Args()) {
+		return 0, false
+	}
+	return keySlot(stringArg(cmd, pos)), true
+}
+
+func stringArg(cmd redis.Cmder, pos int) string {
+	args := cmd.Args()
+	if pos < 0 || pos >= len(args) {
+		return ""
+	}
+	switch v := args[pos].(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	}
+	return ""
+}
+
+// keySlot returns the hash slot of the key, using its hash tag if it has one.
+func keySlot(key string) int {
+	if start := strings.IndexByte(key, '{'); start >= 0 {
+		if end := strings.IndexByte(key[start+1:], '}'); end > 0 {
+			key = key[start+1 : start+1+end]
+		}
+	}
+	return int(crc16(key) % slotCount)
+}
+
+// crc16 is the CRC16-CCITT (XMODEM) checksum used by Redis Cluster.
+func crc16(s string) uint16 {
+	var crc uint16
+	for i := 0; i < len(s); i++ {
+		crc ^= uint16(s[i]) << 8
+		for j := 0; j < 8; j++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+func slotAttribute(cmd redis.Cmder) string {
+	if slot, ok := commandSlot(cmd); ok {
+		return strconv.Itoa(slot)
+	}
+	return ""
+}
@@ -0,0 +1,246 @@
+// Copyright 2023 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package nrredis
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/newrelic/go-agent/v3/internal"
+	"github.com/newrelic/go-agent/v3/internal/integrationsupport"
+	newrelic "github.com/newrelic/go-agent/v3/newrelic"
+	redis "github.com/redis/go-redis/v9"
+)
+
+func TestKeySlot(t *testing.T) {
+	testcases := []struct {
+		key  string
+		slot int
+	}{
+		{key: "", slot: 0},
+		{key: "foo", slot: 12182},
+		{key: "123456789", slot: 12739},
+		{key: "{user1000}.following", slot: keySlot("user1000")},
+		{key: "{user1000}.followers", slot: keySlot("user1000")},
+		// An empty hash tag is not used.
+		{key: "foo{}{bar}", slot: keySlot("foo{}{bar}")},
+	}
+	for _, tc := range testcases {
+		if slot := keySlot(tc.key); slot != tc.slot {
+			t.Errorf("key %q: expected slot %d, got %d", tc.key, tc.slot, slot)
+		}
+	}
+	if keySlot("foo{}{bar}") == keySlot("bar") {
+		t.Error("empty hash tag used")
+	}
+}
+
+func TestCommandSlot(t *testing.T) {
+	ctx := context.Background()
+	testcases := []struct {
+		cmd  redis.Cmder
+		slot string
+	}{
+		{cmd: redis.NewStringCmd(ctx, "get", "foo"), slot: "12182"},
+		{cmd: redis.NewIntCmd(ctx, "memory", "usage", "foo"), slot: "12182"},
+		{cmd: redis.NewCmd(ctx, "eval", "return 1", "1", "foo"), slot: "12182"},
+		{cmd: redis.NewCmd(ctx, "eval", "return 1", "0"), slot: ""},
+		{cmd: redis.NewStatusCmd(ctx, "ping"), slot: ""},
+	}
+	for _, tc := range testcases {
+		if slot := slotAttribute(tc.cmd); slot != tc.slot {
+			t.Errorf("%v: expected slot %q, got %q", tc.cmd.Args(), tc.slot, slot)
+		}
+	}
+}
+
+func TestRedirectKind(t *testing.T) {
+	testcases := map[string]string{
+		"MOVED 3999 127.0.0.1:6381": "MOVED",
+		"ASK 3999 127.0.0.1:6381":   "ASK",
+		"ERR unknown command":       "",
+	}
+	for msg, kind := range testcases {
+		if k := redirectKind(errors.New(msg)); k != kind {
+			t.Errorf("%q: expected %q, got %q", msg, kind, k)
+		}
+	}
+}
+
+func TestClusterNodeHook(t *testing.T) {
+	app := integrationsupport.NewTestApp(integrationsupport.SampleEverythingReplyFn,
+		integrationsupport.DTEnabledCfgFn, newrelic.ConfigCodeLevelMetricsEnabled(false))
+	txn := app.StartTransaction("txnName")
+	ctx := newrelic.NewContext(context.Background(), txn)
+
+	h := hook{routed: true, slots: true}
+	h.segment.Product = newrelic.DatastoreRedis
+	moved := nodeHook{host: "node1", port: "7000"}
+	target := nodeHook{host: "node2", port: "7001"}
+
+	// The command is redirected from the first node to the second, as done by
+	// redis.ClusterClient.
+	process := h.ProcessHook(func(ctx context.Context, cmd redis.Cmder) error {
+		err := moved.ProcessHook(func(context.Context, redis.Cmder) error {
+			return errors.New("MOVED 12182 node2:7001")
+		})(ctx, cmd)
+		if err == nil {
+			t.Error("expected redirection")
+		}
+		return target.ProcessHook(func(context.Context, redis.Cmder) error {
+			return nil
+		})(ctx, cmd)
+	})
+	if err := process(ctx, redis.NewStringCmd(ctx, "get", "foo")); err != nil {
+		t.Fatal(err)
+	}
+	txn.End()
+
+	app.ExpectMetricsPresent(t, []internal.WantMetric{
+		{Name: "Datastore/instance/Redis/node2/7001", Forced: nil},
+		{Name: "Datastore/operation/Redis/get", Forced: nil},
+	})
+	app.ExpectSpanEvents(t, []internal.WantEvent{
+		{
+			Intrinsics: map[string]interface{}{
+				"name":          "Datastore/operation/Redis/get",
+				"sampled":       true,
+				"category":      "datastore",
+				"priority":      internal.MatchAnything,
+				"guid":          internal.MatchAnything,
+				"transactionId": internal.MatchAnything,
+				"traceId":       internal.MatchAnything,
+				"parentId":      internal.MatchAnything,
+				"component":     "Redis",
+				"span.kind":     "client",
+			},
+			UserAttributes: map[string]interface{}{},
+			AgentAttributes: map[string]interface{}{
+				"db.statement":      "'get' on 'unknown' using 'Redis'",
+				"peer.address":      "node2:7001",
+				"peer.hostname":     "node2",
+				"db.redis.slot":     "12182",
+				"db.redis.redirect": "MOVED",
+			},
+		},
+		{
+			Intrinsics: map[string]interface{}{
+				"name":             "OtherTransaction/Go/txnName",
+				"transaction.name": "OtherTransaction/Go/txnName",
+				"sampled":          true,
+				"category":         "generic",
+				"priority":         internal.MatchAnything,
+				"guid":             internal.MatchAnything,
+				"transactionId":    internal.MatchAnything,
+				"nr.entryPoint":    true,
+				"traceId":          internal.MatchAnything,
+			},
+			UserAttributes:  map[string]interface{}{},
+			AgentAttributes: map[string]interface{}{},
+		},
+	})
+}
+
+func TestClusterPipelineNotRouted(t *testing.T) {
+	app := integrationsupport.NewTestApp(nil, nil)
+	txn := app.StartTransaction("txnName")
+	ctx := newrelic.NewContext(context.Background(), txn)
+
+	h := hook{routed: true, slots: true}
+	h.segment.Product = newrelic.DatastoreRedis
+	node := nodeHook{host: "node1", port: "7000"}
+
+	process := h.ProcessPipelineHook(func(ctx context.Context, cmds []redis.Cmder) error {
+		return node.ProcessPipelineHook(func(context.Context, []redis.Cmder) error {
+			return nil
+		})(ctx, cmds)
+	})
+	process(ctx, []redis.Cmder{redis.NewStatusCmd(ctx, "ping")})
+	txn.End()
+
+	app.ExpectMetrics(t, []internal.WantMetric{
+		{Name: "OtherTransaction/Go/txnName", Forced: nil},
+		{Name: "OtherTransactionTotalTime/Go/txnName", Forced: nil},
+		{Name: "OtherTransaction/all", Forced: nil},
+		{Name: "OtherTransactionTotalTime", Forced: nil},
+		{Name: "Datastore/all", Forced: nil},
+		{Name: "Datastore/allOther", Forced: nil},
+		{Name: "Datastore/Redis/all", Forced: nil},
+		{Name: "Datastore/Redis/allOther", Forced: nil},
+		{Name: "Datastore/operation/Redis/pipeline:ping", Forced: nil},
+		{Name: "Datastore/operation/Redis/pipeline:ping", Scope: "OtherTransaction/Go/txnName", Forced: nil},
+		{Name: "DurationByCaller/Unknown/Unknown/Unknown/Unknown/allOther", Forced: nil},
+		{Name: "DurationByCaller/Unknown/Unknown/Unknown/Unknown/all", Forced: nil},
+	})
+}
+
+func TestClusterRedirectEvent(t *testing.T) {
+	app := integrationsupport.NewTestApp(nil, nil)
+	node := nodeHook{host: "node1", port: "7000", app: app.Application}
+
+	process := node.ProcessHook(func(context.Context, redis.Cmder) error {
+		return errors.New("ASK 12182 node2:7001")
+	})
+	ctx := context.Background()
+	process(ctx, redis.NewStringCmd(ctx, "get", "foo"))
+
+	app.ExpectCustomEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"type":      "RedisClusterRedirect",
+			"timestamp": internal.MatchAnything,
+		},
+		UserAttributes: map[string]interface{}{
+			"kind":    "ASK",
+			"command": "get",
+			"host":    "node1",
+			"port":    "7000",
+			"slot":    12182,
+			"target":  "node2:7001",
+		},
+	}})
+	app.ExpectMetricsPresent(t, []internal.WantMetric{
+		{Name: "Custom/Redis/Cluster/Redirect/ASK", Forced: false, Data: []float64{1, 1, 1, 1, 1, 1}},
+	})
+}
+
+func TestClusterTopologyRefreshEvent(t *testing.T) {
+	app := integrationsupport.NewTestApp(nil, nil)
+	node := nodeHook{host: "node1", port: "7000", app: app.Application}
+
+	process := node.ProcessHook(func(context.Context, redis.Cmder) error {
+		return nil
+	})
+	ctx := context.Background()
+	process(ctx, redis.NewClusterSlotsCmd(ctx, "cluster", "slots"))
+	// Other commands are not topology refreshes.
+	process(ctx, redis.NewStringCmd(ctx, "get", "foo"))
+
+	app.ExpectCustomEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"type":      "RedisClusterTopologyRefresh",
+			"timestamp": internal.MatchAnything,
+		},
+		UserAttributes: map[string]interface{}{
+			"command":  "cluster slots",
+			"host":     "node1",
+			"port":     "7000",
+			"duration": internal.MatchAnything,
+		},
+	}})
+	app.ExpectMetricsPresent(t, []internal.WantMetric{
+		{Name: "Custom/Redis/Cluster/TopologyRefresh", Forced: false},
+	})
+}
+
+func TestClusterEventsWithoutApplication(t *testing.T) {
+	node := nodeHook{host: "node1", port: "7000"}
+	process := node.ProcessHook(func(context.Context, redis.Cmder) error {
+		return errors.New("MOVED 12182 node2:7001")
+	})
+	ctx := context.Background()
+	if err := process(ctx, redis.NewStringCmd(ctx, "get", "foo")); err == nil {
+		t.Error("expected redirection")
+	}
+}
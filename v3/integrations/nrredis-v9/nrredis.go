@@ -9,54 +9,50 @@ package nrredis
 
 import (
 	"context"
-	"net"
 	"strings"
 
 	"github.com/newrelic/go-agent/v3/internal"
+	"github.com/newrelic/go-agent/v3/internal/integrationsupport"
 	newrelic "github.com/newrelic/go-agent/v3/newrelic"
 	redis "github.com/redis/go-redis/v9"
 )
 
 func init() { internal.TrackUsage("integration", "datastore", "redis") }
 
-type contextKeyType struct{}
+type contextKeyType int
 
 type hook struct {
 	segment newrelic.DatastoreSegment
+	// routed is set for the hooks of a redis.ClusterClient or a redis.Ring,
+	// whose node hooks record the routing of each command.
+	routed bool
+	// slots is set for the hooks of a redis.ClusterClient.
+	slots bool
 }
 
 var _ redis.Hook = (*hook)(nil)
 
 var (
-	segmentContextKey = contextKeyType(struct{}{})
+	segmentContextKey contextKeyType = 0
+	// routingContextKey holds the segment of a single command sent through a
+	// redis.ClusterClient or a redis.Ring.
+	routingContextKey contextKeyType = 1
 )
 
 // NewHook creates a redis.Hook to instrument Redis calls.  Add it to your
 // client, then ensure that all calls contain a context which includes the
 // transaction.  The options are optional.  Provide them to get instance metrics
 // broken out by host and port.  The hook returned can be used with
-// redis.Client, redis.ClusterClient, and redis.Ring.
+// redis.Client, redis.ClusterClient, and redis.Ring.  To record the node each
+// command of a redis.ClusterClient or a redis.Ring is sent to, use
+// InstrumentClusterClient or InstrumentRing instead.
 func NewHook(opts *redis.Options) redis.Hook {
 	h := hook{}
 	h.segment.Product = newrelic.DatastoreRedis
 	if opts == nil {
 		return h
 	}
-
-	// Per https://pkg.go.dev/github.com/redis/go-redis#Options the
-	// network should either be tcp or unix, and the default is tcp.
-	if opts.Network == "unix" {
-		h.segment.Host = "localhost"
-		h.segment.PortPathOrID = opts.Addr
-		return h
-	}
-	if host, port, err := net.SplitHostPort(opts.Addr); err == nil {
-		if host == "" {
-			host = "localhost"
-		}
-		h.segment.Host = host
-		h.segment.PortPathOrID = port
-	}
+	h.segment.Host, h.segment.PortPathOrID = instance(opts)
 	return h
 }
 
@@ -93,6 +89,14 @@ func (h hook) DialHook(next redis.DialHook) redis.DialHook {
 func (h hook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
 	return func(ctx context.Context, cmd redis.Cmder) error {
 		ctx = h.before(ctx, cmd.Name())
+		if s, ok := ctx.Value(segmentContextKey).(*newrelic.DatastoreSegment); ok && h.routed {
+			ctx = context.WithValue(ctx, routingContextKey, s)
+			if h.slots {
+				if slot := slotAttribute(cmd); slot != "" {
+					integrationsupport.AddAgentSpanAttribute(newrelic.FromContext(ctx), newrelic.SpanAttributeRedisSlot, slot)
+				}
+			}
+		}
 		err := next(ctx, cmd)
 		h.after(ctx)
 		return err
@@ -75,6 +75,7 @@ func ClaimIngestion(ch *ConsumerHandler, session sarama.ConsumerGroupSession, me
 	txn := ch.txn
 	if ch.txn == nil {
 		txn = ch.app.StartTransaction("kafkaconsumer")
+		txn.SetMessageQueueTime(message.Timestamp)
 	}
 	ctx := newrelic.NewContext(context.Background(), txn)
 	segment := txn.StartSegment("Message/Kafka/Topic/Consume/Named/" + ch.topic)
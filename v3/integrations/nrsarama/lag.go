@@ -0,0 +1,196 @@
+package nrsarama
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/newrelic/go-agent/v3/newrelic"
+)
+
+// defaultLagInterval is the interval between lag collections used when none
+// is provided.
+const defaultLagInterval = time.Minute
+
+// lagEventType is the type of the custom events recording the lag of each
+// partition.
+const lagEventType = "KafkaConsumerLag"
+
+// offsetSource provides the offsets needed to compute the lag of a consumer
+// group.
+type offsetSource interface {
+	// committedOffsets returns the offsets committed by the group, by topic
+	// and partition.
+	committedOffsets(group string) (map[string]map[int32]int64, error)
+	// endOffset returns the offset of the next message produced to the
+	// partition.
+	endOffset(topic string, partition int32) (int64, error)
+}
+
+type saramaOffsets struct {
+	client sarama.Client
+	admin  sarama.ClusterAdmin
+}
+
+func (s saramaOffsets) committedOffsets(group string) (map[string]map[int32]int64, error) {
+	// Passing nil returns the offsets of every partition the group has
+	// committed to.
+	resp, err := s.admin.ListConsumerGroupOffsets(group, nil)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Err != sarama.ErrNoError {
+		return nil, resp.Err
+	}
+	offsets := make(map[string]map[int32]int64, len(resp.Blocks))
+	for topic, partitions := range resp.Blocks {
+		offsets[topic] = make(map[int32]int64, len(partitions))
+		for partition, block := range partitions {
+			if block.Err == sarama.ErrNoError {
+				offsets[topic][partition] = block.Offset
+			}
+		}
+	}
+	return offsets, nil
+}
+
+func (s saramaOffsets) endOffset(topic string, partition int32) (int64, error) {
+	return s.client.GetOffset(topic, partition, sarama.OffsetNewest)
+}
+
+// LagCollector periodically records the lag of a consumer group: the number
+// of messages produced to each partition the group consumes which the group
+// has not yet committed.
+//
+// The lag of each partition is recorded as a "KafkaConsumerLag" custom event
+// with the "consumerGroup", "topic", "partition", "lag", "committedOffset",
+// and "endOffset" attributes, so that it can be faceted by topic and
+// partition.  The total lag of each topic is recorded in the
+// "Custom/MessageBroker/Kafka/ConsumerGroup/<group>/Topic/Named/<topic>/Lag"
+// metric.  Partitions the group has not committed to are skipped.
+type LagCollector struct {
+	app      *newrelic.Application
+	source   offsetSource
+	group    string
+	interval time.Duration
+
+	stopOnce sync.Once
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// StartLagCollector starts collecting the lag of the consumer group every
+// interval, using the client to query the offsets.  If interval is not
+// positive, the lag is collected every minute.  Call Stop on the collector
+// returned to stop collecting, before closing the client.
+//
+//	client, err := sarama.NewClient(brokers, config)
+//	collector, err := nrsarama.StartLagCollector(app, client, "my-group", 0)
+//	defer collector.Stop()
+func StartLagCollector(app *newrelic.Application, client sarama.Client, group string, interval time.Duration) (*LagCollector, error) {
+	admin, err := sarama.NewClusterAdminFromClient(client)
+	if err != nil {
+		return nil, err
+	}
+	c := newLagCollector(app, saramaOffsets{client: client, admin: admin}, group, interval)
+	go c.run()
+	return c, nil
+}
+
+func newLagCollector(app *newrelic.Application, source offsetSource, group string, interval time.Duration) *LagCollector {
+	if interval <= 0 {
+		interval = defaultLagInterval
+	}
+	return &LagCollector{
+		app:      app,
+		source:   source,
+		group:    group,
+		interval: interval,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+func (c *LagCollector) run() {
+	defer close(c.done)
+	t := time.NewTicker(c.interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			c.Collect()
+		case <-c.stop:
+			return
+		}
+	}
+}
+
+// Stop stops collecting the lag, and waits for a collection in progress to
+// complete.
+func (c *LagCollector) Stop() {
+	if c == nil {
+		return
+	}
+	c.stopOnce.Do(func() { close(c.stop) })
+	<-c.done
+}
+
+// Collect records the current lag of the consumer group.  It is called every
+// interval once the collector is started, and may also be called directly.
+func (c *LagCollector) Collect() {
+	if c == nil || c.app == nil {
+		return
+	}
+	committed, err := c.source.committedOffsets(c.group)
+	if err != nil {
+		c.logError("unable to get the offsets of the consumer group", err)
+		return
+	}
+	topics := make([]string, 0, len(committed))
+	for topic := range committed {
+		topics = append(topics, topic)
+	}
+	sort.Strings(topics)
+	for _, topic := range topics {
+		var total int64
+		var recorded bool
+		for partition, offset := range committed[topic] {
+			// A negative offset means that no offset was committed.
+			if offset < 0 {
+				continue
+			}
+			end, err := c.source.endOffset(topic, partition)
+			if err != nil {
+				c.logError("unable to get the end offset of the partition", err)
+				continue
+			}
+			lag := end - offset
+			if lag < 0 {
+				lag = 0
+			}
+			c.app.RecordCustomEvent(lagEventType, map[string]interface{}{
+				"consumerGroup":   c.group,
+				"topic":           topic,
+				"partition":       partition,
+				"lag":             lag,
+				"committedOffset": offset,
+				"endOffset":       end,
+			})
+			total += lag
+			recorded = true
+		}
+		if recorded {
+			c.app.RecordCustomMetric("MessageBroker/Kafka/ConsumerGroup/"+c.group+"/Topic/Named/"+topic+"/Lag", float64(total))
+		}
+	}
+}
+
+func (c *LagCollector) logError(msg string, err error) {
+	if cfg, ok := c.app.Config(); ok && cfg.Logger != nil {
+		cfg.Logger.Error(msg, map[string]interface{}{
+			"consumerGroup": c.group,
+			"reason":        err.Error(),
+		})
+	}
+}
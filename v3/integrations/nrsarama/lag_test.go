@@ -0,0 +1,76 @@
+package nrsarama
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/newrelic/go-agent/v3/internal"
+	"github.com/newrelic/go-agent/v3/internal/integrationsupport"
+)
+
+type fakeOffsets struct {
+	committed map[string]map[int32]int64
+	end       map[int32]int64
+	err       error
+}
+
+func (f fakeOffsets) committedOffsets(group string) (map[string]map[int32]int64, error) {
+	return f.committed, f.err
+}
+
+func (f fakeOffsets) endOffset(topic string, partition int32) (int64, error) {
+	return f.end[partition], nil
+}
+
+func TestLagCollectorCollect(t *testing.T) {
+	app := integrationsupport.NewBasicTestApp()
+	source := fakeOffsets{
+		committed: map[string]map[int32]int64{
+			"topicName": {0: 10, 1: -1},
+		},
+		end: map[int32]int64{0: 15, 1: 7},
+	}
+	c := newLagCollector(app.Application, source, "myGroup", 0)
+	if c.interval != defaultLagInterval {
+		t.Error(c.interval)
+	}
+	c.Collect()
+
+	app.ExpectCustomEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"type":      "KafkaConsumerLag",
+			"timestamp": internal.MatchAnything,
+		},
+		UserAttributes: map[string]interface{}{
+			"consumerGroup":   "myGroup",
+			"topic":           "topicName",
+			"partition":       0,
+			"lag":             5,
+			"committedOffset": 10,
+			"endOffset":       15,
+		},
+	}})
+	app.ExpectMetrics(t, []internal.WantMetric{
+		{Name: "Custom/MessageBroker/Kafka/ConsumerGroup/myGroup/Topic/Named/topicName/Lag", Data: []float64{1, 5, 5, 5, 5, 25}},
+	})
+}
+
+func TestLagCollectorError(t *testing.T) {
+	app := integrationsupport.NewBasicTestApp()
+	c := newLagCollector(app.Application, fakeOffsets{err: errors.New("oops")}, "myGroup", time.Second)
+	c.Collect()
+	app.ExpectCustomEvents(t, []internal.WantEvent{})
+}
+
+func TestLagCollectorStop(t *testing.T) {
+	app := integrationsupport.NewBasicTestApp()
+	c := newLagCollector(app.Application, fakeOffsets{}, "myGroup", time.Millisecond)
+	go c.run()
+	c.Stop()
+	// Stopping twice, or a nil collector, does nothing.
+	c.Stop()
+	var nilCollector *LagCollector
+	nilCollector.Stop()
+	nilCollector.Collect()
+}
@@ -0,0 +1,64 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	"github.com/newrelic/go-agent/v3/integrations/nrspanner"
+	"github.com/newrelic/go-agent/v3/newrelic"
+	"google.golang.org/api/iterator"
+)
+
+func main() {
+	app, err := newrelic.NewApplication(
+		newrelic.ConfigAppName("Spanner App"),
+		newrelic.ConfigLicense(os.Getenv("NEW_RELIC_LICENSE_KEY")),
+		newrelic.ConfigDebugLogger(os.Stdout),
+	)
+	if nil != err {
+		panic(err)
+	}
+	defer app.Shutdown(10 * time.Second)
+	if err := app.WaitForConnection(5 * time.Second); nil != err {
+		panic(err)
+	}
+
+	ctx := context.Background()
+	// The database must be of the form
+	// projects/<project>/instances/<instance>/databases/<database>.
+	client, err := nrspanner.NewClient(ctx, os.Getenv("SPANNER_DATABASE"))
+	if nil != err {
+		panic(err)
+	}
+	defer client.Close()
+
+	txn := app.StartTransaction("spanner")
+	defer txn.End()
+	ctx = newrelic.NewContext(ctx, txn)
+
+	_, err = client.Apply(ctx, []*spanner.Mutation{
+		spanner.InsertOrUpdate("users", []string{"id", "name"}, []interface{}{1, "alice"}),
+	})
+	if nil != err {
+		panic(err)
+	}
+
+	iter := client.Single().Query(ctx, spanner.Statement{SQL: "SELECT id, name FROM users"})
+	defer iter.Stop()
+	for {
+		row, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if nil != err {
+			panic(err)
+		}
+		fmt.Println(row)
+	}
+}
@@ -0,0 +1,226 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package nrspanner instruments https://github.com/googleapis/google-cloud-go/tree/main/spanner.
+//
+// Use this package to instrument Cloud Spanner reads, queries, mutations,
+// and transactions.  Each call made by the Spanner client is recorded as a
+// datastore segment with the database name, the table when it can be
+// derived from the request, and the operation.
+//
+// Pass the options returned by ClientOptions when creating the Spanner
+// client, or use NewClient which does this for you:
+//
+//	client, err := nrspanner.NewClient(ctx, "projects/p/instances/i/databases/d")
+//
+// Then make sure that the context passed to each Spanner call contains a
+// newrelic.Transaction:
+//
+//	ctx = newrelic.NewContext(ctx, txn)
+//	iter := client.Single().Query(ctx, spanner.Statement{SQL: "SELECT * FROM users"})
+//
+// Calls made without a transaction in the context, such as the background
+// session management done by the client, are not recorded.
+//
+// Full example:
+// https://github.com/newrelic/go-agent/blob/master/v3/integrations/nrspanner/example/main.go
+package nrspanner
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"cloud.google.com/go/spanner"
+	"cloud.google.com/go/spanner/apiv1/spannerpb"
+	"github.com/newrelic/go-agent/v3/internal"
+	"github.com/newrelic/go-agent/v3/newrelic"
+	"github.com/newrelic/go-agent/v3/newrelic/sqlparse"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+)
+
+func init() { internal.TrackUsage("integration", "datastore", "spanner") }
+
+const methodPrefix = "/google.spanner.v1.Spanner/"
+
+// operations maps the instrumented Spanner RPC methods to the operation
+// recorded when the operation cannot be derived from the request itself.
+var operations = map[string]string{
+	"ExecuteSql":          "query",
+	"ExecuteStreamingSql": "query",
+	"ExecuteBatchDml":     "batch_dml",
+	"Read":                "read",
+	"StreamingRead":       "read",
+	"BeginTransaction":    "begin",
+	"Commit":              "commit",
+	"Rollback":            "rollback",
+	"PartitionQuery":      "partition_query",
+	"PartitionRead":       "partition_read",
+	"BatchWrite":          "batch_write",
+}
+
+// ClientOptions returns the options which must be passed to
+// spanner.NewClient or spanner.NewClientWithConfig to instrument the client.
+func ClientOptions() []option.ClientOption {
+	return []option.ClientOption{
+		option.WithGRPCDialOption(grpc.WithChainUnaryInterceptor(unaryInterceptor)),
+		option.WithGRPCDialOption(grpc.WithChainStreamInterceptor(streamInterceptor)),
+	}
+}
+
+// NewClient creates an instrumented Spanner client.  It is equivalent to
+// calling spanner.NewClient with the options returned by ClientOptions.
+func NewClient(ctx context.Context, database string, opts ...option.ClientOption) (*spanner.Client, error) {
+	return spanner.NewClient(ctx, database, append(opts, ClientOptions()...)...)
+}
+
+// NewClientWithConfig creates an instrumented Spanner client.  It is
+// equivalent to calling spanner.NewClientWithConfig with the options returned
+// by ClientOptions.
+func NewClientWithConfig(ctx context.Context, database string, config spanner.ClientConfig, opts ...option.ClientOption) (*spanner.Client, error) {
+	return spanner.NewClientWithConfig(ctx, database, config, append(opts, ClientOptions()...)...)
+}
+
+// startSegment starts a datastore segment for the method if the context
+// contains a transaction and the method is one that should be recorded.
+func startSegment(ctx context.Context, method string) *newrelic.DatastoreSegment {
+	if !strings.HasPrefix(method, methodPrefix) {
+		return nil
+	}
+	op, ok := operations[strings.TrimPrefix(method, methodPrefix)]
+	if !ok {
+		return nil
+	}
+	txn := newrelic.FromContext(ctx)
+	if nil == txn {
+		return nil
+	}
+	return &newrelic.DatastoreSegment{
+		StartTime: txn.StartSegmentNow(),
+		Product:   newrelic.DatastoreSpanner,
+		Operation: op,
+	}
+}
+
+// databaseName extracts the database ID from a session name of the form
+// projects/<project>/instances/<instance>/databases/<database>/sessions/<session>.
+func databaseName(session string) string {
+	parts := strings.Split(session, "/")
+	for i := 0; i+1 < len(parts); i++ {
+		if parts[i] == "databases" {
+			return parts[i+1]
+		}
+	}
+	return ""
+}
+
+// mutationTable returns the table modified by the mutation.
+func mutationTable(m *spannerpb.Mutation) string {
+	switch op := m.GetOperation().(type) {
+	case *spannerpb.Mutation_Insert:
+		return op.Insert.GetTable()
+	case *spannerpb.Mutation_Update:
+		return op.Update.GetTable()
+	case *spannerpb.Mutation_InsertOrUpdate:
+		return op.InsertOrUpdate.GetTable()
+	case *spannerpb.Mutation_Replace:
+		return op.Replace.GetTable()
+	case *spannerpb.Mutation_Delete_:
+		return op.Delete.GetTable()
+	}
+	return ""
+}
+
+// mutationsTable returns the table modified by the mutations, or the empty
+// string if they modify more than one table.
+func mutationsTable(mutations []*spannerpb.Mutation) string {
+	var table string
+	for i, m := range mutations {
+		t := mutationTable(m)
+		if i > 0 && t != table {
+			return ""
+		}
+		table = t
+	}
+	return table
+}
+
+// addRequestDetails adds the database, table, and query from the request to
+// the segment.
+func addRequestDetails(seg *newrelic.DatastoreSegment, req interface{}) {
+	switch r := req.(type) {
+	case *spannerpb.ExecuteSqlRequest:
+		seg.DatabaseName = databaseName(r.GetSession())
+		sqlparse.ParseQuery(seg, r.GetSql())
+	case *spannerpb.ExecuteBatchDmlRequest:
+		seg.DatabaseName = databaseName(r.GetSession())
+		if stmts := r.GetStatements(); len(stmts) > 0 {
+			seg.ParameterizedQuery = stmts[0].GetSql()
+		}
+	case *spannerpb.ReadRequest:
+		seg.DatabaseName = databaseName(r.GetSession())
+		seg.Collection = r.GetTable()
+	case *spannerpb.PartitionQueryRequest:
+		seg.DatabaseName = databaseName(r.GetSession())
+		seg.ParameterizedQuery = r.GetSql()
+	case *spannerpb.PartitionReadRequest:
+		seg.DatabaseName = databaseName(r.GetSession())
+		seg.Collection = r.GetTable()
+	case *spannerpb.CommitRequest:
+		seg.DatabaseName = databaseName(r.GetSession())
+		seg.Collection = mutationsTable(r.GetMutations())
+	case *spannerpb.BeginTransactionRequest:
+		seg.DatabaseName = databaseName(r.GetSession())
+	case *spannerpb.RollbackRequest:
+		seg.DatabaseName = databaseName(r.GetSession())
+	case *spannerpb.BatchWriteRequest:
+		seg.DatabaseName = databaseName(r.GetSession())
+	}
+}
+
+func unaryInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	seg := startSegment(ctx, method)
+	if nil != seg {
+		addRequestDetails(seg, req)
+		defer seg.End()
+	}
+	return invoker(ctx, method, req, reply, cc, opts...)
+}
+
+type wrappedClientStream struct {
+	grpc.ClientStream
+	segment *newrelic.DatastoreSegment
+	once    sync.Once
+}
+
+func (s *wrappedClientStream) SendMsg(m interface{}) error {
+	addRequestDetails(s.segment, m)
+	return s.ClientStream.SendMsg(m)
+}
+
+func (s *wrappedClientStream) RecvMsg(m interface{}) error {
+	err := s.ClientStream.RecvMsg(m)
+	if nil != err {
+		// The stream is complete when io.EOF or any other error is
+		// returned.
+		s.once.Do(func() { s.segment.End() })
+	}
+	return err
+}
+
+func streamInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	seg := startSegment(ctx, method)
+	s, err := streamer(ctx, desc, cc, method, opts...)
+	if nil == seg {
+		return s, err
+	}
+	if nil != err {
+		seg.End()
+		return s, err
+	}
+	return &wrappedClientStream{
+		ClientStream: s,
+		segment:      seg,
+	}, nil
+}
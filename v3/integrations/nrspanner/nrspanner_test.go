@@ -0,0 +1,164 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package nrspanner
+
+import (
+	"context"
+	"testing"
+
+	"cloud.google.com/go/spanner"
+	"cloud.google.com/go/spanner/apiv1/spannerpb"
+	"cloud.google.com/go/spanner/spannertest"
+	"cloud.google.com/go/spanner/spansql"
+	"github.com/newrelic/go-agent/v3/internal"
+	"github.com/newrelic/go-agent/v3/internal/integrationsupport"
+	"github.com/newrelic/go-agent/v3/newrelic"
+	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+const testDatabase = "projects/p/instances/i/databases/mydb"
+
+func testApp() integrationsupport.ExpectApp {
+	return integrationsupport.NewTestApp(integrationsupport.SampleEverythingReplyFn,
+		integrationsupport.ConfigFullTraces, newrelic.ConfigCodeLevelMetricsEnabled(false))
+}
+
+func testClient(t *testing.T) *spanner.Client {
+	srv, err := spannertest.NewServer("localhost:0")
+	if nil != err {
+		t.Fatal(err)
+	}
+	t.Cleanup(srv.Close)
+
+	ddl, err := spansql.ParseDDL("", `CREATE TABLE users (id INT64, name STRING(MAX)) PRIMARY KEY (id)`)
+	if nil != err {
+		t.Fatal(err)
+	}
+	if err := srv.UpdateDDL(ddl); nil != err {
+		t.Fatal(err)
+	}
+
+	client, err := NewClientWithConfig(context.Background(), testDatabase,
+		spanner.ClientConfig{SessionPoolConfig: spanner.SessionPoolConfig{MinOpened: 0}},
+		option.WithEndpoint(srv.Addr),
+		option.WithoutAuthentication(),
+		option.WithGRPCDialOption(grpc.WithTransportCredentials(insecure.NewCredentials())),
+	)
+	if nil != err {
+		t.Fatal(err)
+	}
+	t.Cleanup(client.Close)
+	return client
+}
+
+func TestDatabaseName(t *testing.T) {
+	for session, want := range map[string]string{
+		"projects/p/instances/i/databases/mydb/sessions/abc": "mydb",
+		"projects/p/instances/i/databases/mydb":              "mydb",
+		"":                                                   "",
+		"garbage":                                            "",
+	} {
+		if got := databaseName(session); got != want {
+			t.Errorf("databaseName(%q) = %q, want %q", session, got, want)
+		}
+	}
+}
+
+func TestMutationsTable(t *testing.T) {
+	insert := func(table string) *spannerpb.Mutation {
+		return &spannerpb.Mutation{Operation: &spannerpb.Mutation_Insert{
+			Insert: &spannerpb.Mutation_Write{Table: table},
+		}}
+	}
+	del := func(table string) *spannerpb.Mutation {
+		return &spannerpb.Mutation{Operation: &spannerpb.Mutation_Delete_{
+			Delete: &spannerpb.Mutation_Delete{Table: table},
+		}}
+	}
+	if table := mutationsTable([]*spannerpb.Mutation{insert("users"), del("users")}); table != "users" {
+		t.Error(table)
+	}
+	if table := mutationsTable([]*spannerpb.Mutation{insert("users"), insert("orders")}); table != "" {
+		t.Error(table)
+	}
+	if table := mutationsTable(nil); table != "" {
+		t.Error(table)
+	}
+}
+
+func TestNoTransaction(t *testing.T) {
+	client := testClient(t)
+	_, err := client.Apply(context.Background(), []*spanner.Mutation{
+		spanner.Insert("users", []string{"id", "name"}, []interface{}{1, "alice"}),
+	})
+	if nil != err {
+		t.Fatal(err)
+	}
+}
+
+func TestApplyAndQuery(t *testing.T) {
+	client := testClient(t)
+	app := testApp()
+	txn := app.StartTransaction("spanner")
+	ctx := newrelic.NewContext(context.Background(), txn)
+
+	_, err := client.Apply(ctx, []*spanner.Mutation{
+		spanner.Insert("users", []string{"id", "name"}, []interface{}{1, "alice"}),
+	})
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	iter := client.Single().Query(ctx, spanner.Statement{SQL: "SELECT id, name FROM users"})
+	for {
+		_, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if nil != err {
+			t.Fatal(err)
+		}
+	}
+	iter.Stop()
+
+	_, err = client.Single().ReadRow(ctx, "users", spanner.Key{1}, []string{"name"})
+	if nil != err {
+		t.Fatal(err)
+	}
+	txn.End()
+
+	app.ExpectMetricsPresent(t, []internal.WantMetric{
+		{Name: "Datastore/Spanner/all", Scope: ""},
+		{Name: "Datastore/statement/Spanner/users/commit", Scope: ""},
+		{Name: "Datastore/statement/Spanner/users/commit", Scope: "OtherTransaction/Go/spanner"},
+		{Name: "Datastore/statement/Spanner/users/select", Scope: ""},
+		{Name: "Datastore/statement/Spanner/users/select", Scope: "OtherTransaction/Go/spanner"},
+		{Name: "Datastore/statement/Spanner/users/read", Scope: ""},
+		{Name: "Datastore/statement/Spanner/users/read", Scope: "OtherTransaction/Go/spanner"},
+	})
+}
+
+func TestReadWriteTransaction(t *testing.T) {
+	client := testClient(t)
+	app := testApp()
+	txn := app.StartTransaction("spanner")
+	ctx := newrelic.NewContext(context.Background(), txn)
+
+	_, err := client.ReadWriteTransaction(ctx, func(ctx context.Context, rw *spanner.ReadWriteTransaction) error {
+		return rw.BufferWrite([]*spanner.Mutation{
+			spanner.Insert("users", []string{"id", "name"}, []interface{}{2, "bob"}),
+		})
+	})
+	if nil != err {
+		t.Fatal(err)
+	}
+	txn.End()
+
+	app.ExpectMetricsPresent(t, []internal.WantMetric{
+		{Name: "Datastore/statement/Spanner/users/commit", Scope: "OtherTransaction/Go/spanner"},
+	})
+}
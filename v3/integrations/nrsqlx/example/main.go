@@ -0,0 +1,69 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/newrelic/go-agent/v3/integrations/nrsqlx"
+	"github.com/newrelic/go-agent/v3/newrelic"
+	"github.com/newrelic/go-agent/v3/newrelic/sqlparse"
+	"modernc.org/sqlite"
+)
+
+func init() {
+	sql.Register("nrsqlite", newrelic.InstrumentSQLDriver(&sqlite.Driver{}, newrelic.SQLDriverSegmentBuilder{
+		BaseSegment: newrelic.DatastoreSegment{Product: newrelic.DatastoreSQLite},
+		ParseQuery:  sqlparse.ParseQuery,
+	}))
+}
+
+type User struct {
+	Name     string `db:"name"`
+	Email    string `db:"email"`
+	Password string `db:"password"`
+}
+
+func main() {
+	app, err := newrelic.NewApplication(
+		newrelic.ConfigAppName("sqlx App"),
+		newrelic.ConfigLicense(os.Getenv("NEW_RELIC_LICENSE_KEY")),
+		newrelic.ConfigDebugLogger(os.Stdout),
+		func(cfg *newrelic.Config) {
+			// Record every query as a slow query so that the query
+			// parameters are visible.
+			cfg.DatastoreTracer.SlowQuery.Threshold = 0
+		},
+	)
+	if nil != err {
+		panic(err)
+	}
+	defer app.Shutdown(10 * time.Second)
+	app.WaitForConnection(5 * time.Second)
+
+	db, err := sqlx.Open("nrsqlite", ":memory:")
+	if nil != err {
+		panic(err)
+	}
+	defer db.Close()
+	db.MustExec("CREATE TABLE users (name TEXT, email TEXT, password TEXT)")
+
+	txn := app.StartTransaction("sqlx")
+	defer txn.End()
+	ctx := newrelic.NewContext(context.Background(), txn)
+
+	// The password parameter is redacted by default, and the email
+	// parameter is redacted using WithRedactedParameters.
+	_, err = nrsqlx.NamedExecContext(ctx, db,
+		"INSERT INTO users (name, email, password) VALUES (:name, :email, :password)",
+		User{Name: "gopher", Email: "gopher@example.com", Password: "hunter2"},
+		nrsqlx.WithRedactedParameters("email"))
+	if nil != err {
+		panic(err)
+	}
+}
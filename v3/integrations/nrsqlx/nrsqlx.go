@@ -0,0 +1,217 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package nrsqlx records the named query parameters of queries made using
+// https://github.com/jmoiron/sqlx.
+//
+// The database/sql driver integrations (nrpq, nrmysql, nrsqlite3, and others)
+// record the queries made using sqlx, but the driver only receives positional
+// arguments.  Use the functions in this package in place of the corresponding
+// sqlx named query functions to add the named parameters of the query, such as
+// ":email", to the QueryParameters of the datastore segment recorded by the
+// driver integration:
+//
+//	ctx := newrelic.NewContext(context.Background(), txn)
+//	_, err := nrsqlx.NamedExecContext(ctx, db,
+//		"INSERT INTO users (name, email) VALUES (:name, :email)", user)
+//
+// Query parameters are recorded in slow query traces and span events when
+// the DatastoreTracer.QueryParameters setting is enabled, and are never
+// recorded in high security mode.  The values of parameters which may contain
+// credentials, such as ":password", are redacted.  Use WithRedactedParameters
+// and WithRedactor to change which values are redacted.
+//
+// Full example:
+// https://github.com/newrelic/go-agent/blob/master/v3/integrations/nrsqlx/example/main.go
+package nrsqlx
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/newrelic/go-agent/v3/internal"
+	"github.com/newrelic/go-agent/v3/newrelic"
+)
+
+func init() { internal.TrackUsage("integration", "datastore", "sqlx") }
+
+// RedactedValue replaces the value of redacted query parameters.
+const RedactedValue = "[REDACTED]"
+
+// defaultRedactedSubstrings are the substrings of the parameter names whose
+// values are redacted by default.
+var defaultRedactedSubstrings = []string{
+	"password",
+	"passwd",
+	"secret",
+	"token",
+	"apikey",
+	"api_key",
+	"credential",
+}
+
+// Redactor returns the value to record for the named parameter.
+type Redactor func(name string, value interface{}) interface{}
+
+// DefaultRedactor redacts the values of parameters whose names contain
+// "password", "passwd", "secret", "token", "apikey", "api_key", or
+// "credential", ignoring case.
+func DefaultRedactor(name string, value interface{}) interface{} {
+	lower := strings.ToLower(name)
+	for _, s := range defaultRedactedSubstrings {
+		if strings.Contains(lower, s) {
+			return RedactedValue
+		}
+	}
+	return value
+}
+
+type config struct {
+	redacted map[string]bool
+	redactor Redactor
+}
+
+// Option configures the recording of query parameters.
+type Option func(*config)
+
+// WithRedactedParameters redacts the values of the named parameters, in
+// addition to the parameters redacted by the Redactor.  Names are matched
+// ignoring case.
+func WithRedactedParameters(names ...string) Option {
+	return func(c *config) {
+		for _, name := range names {
+			c.redacted[strings.ToLower(name)] = true
+		}
+	}
+}
+
+// WithRedactor replaces DefaultRedactor.
+func WithRedactor(redactor Redactor) Option {
+	return func(c *config) { c.redactor = redactor }
+}
+
+// QueryParameters returns the named parameters of query bound from arg, which
+// may be a struct or a map, with values redacted.  It returns nil if the
+// parameters cannot be bound, for example if arg is a slice used for a batch
+// insert.
+func QueryParameters(query string, arg interface{}, opts ...Option) map[string]interface{} {
+	c := config{
+		redacted: make(map[string]bool),
+		redactor: DefaultRedactor,
+	}
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	names := parameterNames(query)
+	if len(names) == 0 {
+		return nil
+	}
+	_, args, err := sqlx.BindNamed(sqlx.QUESTION, query, arg)
+	if nil != err || len(args) != len(names) {
+		return nil
+	}
+	params := make(map[string]interface{}, len(names))
+	for i, name := range names {
+		value := parameterValue(args[i])
+		switch {
+		case c.redacted[strings.ToLower(name)]:
+			value = RedactedValue
+		case nil != c.redactor:
+			value = c.redactor(name, value)
+		}
+		if nil != value {
+			params[name] = value
+		}
+	}
+	return params
+}
+
+// parameterValue converts an argument into a value which may be recorded as a
+// query parameter: a string, boolean, or number.
+func parameterValue(arg interface{}) interface{} {
+	if valuer, ok := arg.(driver.Valuer); ok {
+		v, err := valuer.Value()
+		if nil != err {
+			return nil
+		}
+		arg = v
+	}
+	switch v := arg.(type) {
+	case nil:
+		return nil
+	case string, bool,
+		int, int8, int16, int32, int64,
+		uint, uint8, uint16, uint32, uint64,
+		float32, float64:
+		return v
+	case []byte:
+		return string(v)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// parameterNames returns the names of the named parameters in query, in the
+// order they appear, following the rules used by sqlx: names start with a
+// colon and contain letters, digits, underscores, and periods, and "::" is an
+// escaped colon.
+func parameterNames(query string) []string {
+	var names []string
+	runes := []rune(query)
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != ':' {
+			continue
+		}
+		if i+1 < len(runes) && runes[i+1] == ':' {
+			i++
+			continue
+		}
+		j := i + 1
+		for j < len(runes) && isNameRune(runes[j]) {
+			j++
+		}
+		if j > i+1 {
+			names = append(names, string(runes[i+1:j]))
+		}
+		i = j - 1
+	}
+	return names
+}
+
+func isNameRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '.'
+}
+
+// Context returns a context which adds the named parameters of query bound
+// from arg to the datastore segment recorded by the driver integration.  Use
+// it with sqlx functions which are not wrapped by this package.
+func Context(ctx context.Context, query string, arg interface{}, opts ...Option) context.Context {
+	if nil == newrelic.FromContext(ctx) {
+		return ctx
+	}
+	if params := QueryParameters(query, arg, opts...); nil != params {
+		ctx = newrelic.WithQueryParameters(ctx, params)
+	}
+	return ctx
+}
+
+// NamedExecContext calls sqlx.NamedExecContext, recording the named
+// parameters of the query.
+func NamedExecContext(ctx context.Context, e sqlx.ExtContext, query string, arg interface{}, opts ...Option) (sql.Result, error) {
+	return sqlx.NamedExecContext(Context(ctx, query, arg, opts...), e, query, arg)
+}
+
+// NamedQueryContext calls sqlx.NamedQueryContext, recording the named
+// parameters of the query.
+func NamedQueryContext(ctx context.Context, e sqlx.ExtContext, query string, arg interface{}, opts ...Option) (*sqlx.Rows, error) {
+	return sqlx.NamedQueryContext(Context(ctx, query, arg, opts...), e, query, arg)
+}
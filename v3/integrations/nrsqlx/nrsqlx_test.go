@@ -0,0 +1,125 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package nrsqlx
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/newrelic/go-agent/v3/internal"
+	"github.com/newrelic/go-agent/v3/internal/integrationsupport"
+	"github.com/newrelic/go-agent/v3/newrelic"
+	"github.com/newrelic/go-agent/v3/newrelic/sqlparse"
+	"modernc.org/sqlite"
+)
+
+func init() {
+	sql.Register("nrsqlite-test", newrelic.InstrumentSQLDriver(&sqlite.Driver{}, newrelic.SQLDriverSegmentBuilder{
+		BaseSegment: newrelic.DatastoreSegment{Product: newrelic.DatastoreSQLite},
+		ParseQuery:  sqlparse.ParseQuery,
+	}))
+}
+
+type user struct {
+	Name     string `db:"name"`
+	Password string `db:"password"`
+	Age      int    `db:"age"`
+}
+
+func TestParameterNames(t *testing.T) {
+	for query, want := range map[string][]string{
+		"SELECT * FROM users": nil,
+		"SELECT * FROM users WHERE name = :name AND age > :age": {"name", "age"},
+		"INSERT INTO t (a) VALUES (:user.name)":                 {"user.name"},
+		"SELECT '10::00' FROM t WHERE a = :a":                   {"a"},
+		"SELECT * FROM t WHERE a = :a OR b = :a":                {"a", "a"},
+		"SELECT * FROM t WHERE a = ':'":                         nil,
+	} {
+		if got := parameterNames(query); !reflect.DeepEqual(got, want) {
+			t.Errorf("%q: got %v, want %v", query, got, want)
+		}
+	}
+}
+
+func TestQueryParameters(t *testing.T) {
+	query := "INSERT INTO users (name, password, age) VALUES (:name, :password, :age)"
+	params := QueryParameters(query, user{Name: "gopher", Password: "hunter2", Age: 13})
+	want := map[string]interface{}{"name": "gopher", "password": RedactedValue, "age": 13}
+	if !reflect.DeepEqual(params, want) {
+		t.Error(params)
+	}
+
+	params = QueryParameters(query, map[string]interface{}{"name": "gopher", "password": "hunter2", "age": 13},
+		WithRedactor(func(name string, value interface{}) interface{} { return value }),
+		WithRedactedParameters("NAME"))
+	want = map[string]interface{}{"name": RedactedValue, "password": "hunter2", "age": 13}
+	if !reflect.DeepEqual(params, want) {
+		t.Error(params)
+	}
+
+	if params := QueryParameters(query, map[string]interface{}{}); nil != params {
+		t.Error("unbound parameters should not be recorded", params)
+	}
+	if params := QueryParameters(query, []user{{}, {}}); nil != params {
+		t.Error("batch parameters should not be recorded", params)
+	}
+}
+
+func TestNamedExecContext(t *testing.T) {
+	db, err := sqlx.Open("nrsqlite-test", ":memory:")
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec("CREATE TABLE users (name TEXT, password TEXT, age INTEGER)"); nil != err {
+		t.Fatal(err)
+	}
+
+	app := integrationsupport.NewTestApp(integrationsupport.SampleEverythingReplyFn,
+		newrelic.ConfigCodeLevelMetricsEnabled(false),
+		func(cfg *newrelic.Config) {
+			cfg.DatastoreTracer.SlowQuery.Threshold = 0
+		})
+	txn := app.StartTransaction("txn")
+	ctx := newrelic.NewContext(context.Background(), txn)
+	_, err = NamedExecContext(ctx, db, "INSERT INTO users (name, password, age) VALUES (:name, :password, :age)",
+		user{Name: "gopher", Password: "hunter2", Age: 13})
+	if nil != err {
+		t.Fatal(err)
+	}
+	rows, err := NamedQueryContext(ctx, db, "SELECT name FROM users WHERE age = :age", map[string]interface{}{"age": 13})
+	if nil != err {
+		t.Fatal(err)
+	}
+	rows.Close()
+	txn.End()
+
+	app.ExpectSlowQueries(t, []internal.WantSlowQuery{
+		{
+			Count:      1,
+			MetricName: "Datastore/statement/SQLite/users/insert",
+			Query:      "'insert' on 'users' using 'SQLite'",
+			TxnName:    "OtherTransaction/Go/txn",
+			Params:     map[string]interface{}{"name": "gopher", "password": RedactedValue, "age": 13},
+		},
+		{
+			Count:      1,
+			MetricName: "Datastore/statement/SQLite/users/select",
+			Query:      "'select' on 'users' using 'SQLite'",
+			TxnName:    "OtherTransaction/Go/txn",
+			Params:     map[string]interface{}{"age": 13},
+		},
+	})
+}
+
+func TestContextNoTransaction(t *testing.T) {
+	ctx := context.Background()
+	if Context(ctx, "SELECT :a", map[string]interface{}{"a": 1}) != ctx {
+		t.Error("context should not be changed")
+	}
+}
@@ -0,0 +1,15 @@
+module github.com/newrelic/go-agent/v3/integrations/nrtestcontainers
+
+go 1.21
+
+require (
+	github.com/lib/pq v1.10.9
+	github.com/newrelic/go-agent/v3 v3.35.0
+	github.com/testcontainers/testcontainers-go v0.31.0
+	github.com/testcontainers/testcontainers-go/modules/kafka v0.31.0
+	github.com/testcontainers/testcontainers-go/modules/postgres v0.31.0
+	github.com/testcontainers/testcontainers-go/modules/redis v0.31.0
+)
+
+
+replace github.com/newrelic/go-agent/v3 => ../..
@@ -0,0 +1,210 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package nrtestcontainers is a test harness for validating datastore and
+// messaging instrumentation against real services run in containers using
+// https://github.com/testcontainers/testcontainers-go.
+//
+// Use StartPostgres, StartRedis, and StartKafka to start a service for the
+// duration of a test, and NewApp to create an application which records its
+// data in memory instead of sending it to New Relic.  Once the instrumented
+// code has run, use the App assertion methods to validate the recorded data:
+//
+//	func TestQuery(t *testing.T) {
+//		dsn := nrtestcontainers.StartPostgres(t)
+//		app := nrtestcontainers.NewApp(t)
+//
+//		db, err := sql.Open("nrpostgres", dsn)
+//		if nil != err {
+//			t.Fatal(err)
+//		}
+//		defer db.Close()
+//
+//		txn := app.StartTransaction("query")
+//		ctx := newrelic.NewContext(context.Background(), txn)
+//		db.QueryRowContext(ctx, "SELECT * FROM users WHERE id = $1", 1).Scan(&user)
+//		txn.End()
+//
+//		app.ExpectDatastoreCalls(nrtestcontainers.DatastoreCall{
+//			Product:    newrelic.DatastorePostgres,
+//			Collection: "users",
+//			Operation:  "select",
+//			Count:      1,
+//			TxnName:    "OtherTransaction/Go/query",
+//		})
+//	}
+//
+// Tests using containers are skipped when Docker is not available, and when
+// the -short flag is set.
+package nrtestcontainers
+
+import (
+	"testing"
+
+	"github.com/newrelic/go-agent/v3/internal"
+	"github.com/newrelic/go-agent/v3/internal/integrationsupport"
+	"github.com/newrelic/go-agent/v3/newrelic"
+)
+
+// App is an application which records its data in memory so that it can be
+// validated by tests.  Distributed tracing is enabled, every transaction is
+// sampled, and every datastore query is recorded as a slow query.
+type App struct {
+	*newrelic.Application
+	t      testing.TB
+	expect internal.Expect
+}
+
+// NewApp creates an App.  The options are applied after the App's default
+// configuration, so they may be used to change it.
+func NewApp(t testing.TB, opts ...newrelic.ConfigOption) *App {
+	opts = append([]newrelic.ConfigOption{
+		integrationsupport.ConfigFullTraces,
+		func(cfg *newrelic.Config) {
+			cfg.DatastoreTracer.SlowQuery.Threshold = 0
+		},
+	}, opts...)
+	app := integrationsupport.NewTestApp(integrationsupport.SampleEverythingReplyFn, opts...)
+	return &App{
+		Application: app.Application,
+		t:           t,
+		expect:      app.Expect,
+	}
+}
+
+// DatastoreCall is an expectation of the datastore segments recorded with
+// the same product, collection, and operation.
+type DatastoreCall struct {
+	Product newrelic.DatastoreProduct
+	// Collection is not validated if empty.
+	Collection string
+	Operation  string
+	// Count is the expected number of calls.  If zero, any number of calls
+	// is accepted.
+	Count int
+	// TxnName is the full name of the transaction which made the calls,
+	// for example "OtherTransaction/Go/query".  The transaction is not
+	// validated if empty.
+	TxnName string
+}
+
+func (c DatastoreCall) metrics() []internal.WantMetric {
+	var data []float64
+	if c.Count > 0 {
+		data = []float64{float64(c.Count)}
+	}
+	product := string(c.Product)
+	scoped := "Datastore/operation/" + product + "/" + c.Operation
+	metrics := []internal.WantMetric{
+		{Name: "Datastore/" + product + "/all", Data: data},
+		{Name: scoped, Data: data},
+	}
+	if "" != c.Collection {
+		scoped = "Datastore/statement/" + product + "/" + c.Collection + "/" + c.Operation
+		metrics = append(metrics, internal.WantMetric{Name: scoped, Data: data})
+	}
+	if "" != c.TxnName {
+		metrics = append(metrics, internal.WantMetric{Name: scoped, Scope: c.TxnName, Data: data})
+	}
+	return metrics
+}
+
+// ExpectDatastoreCalls validates that the datastore calls were recorded.
+// Other datastore calls may also have been recorded.
+func (a *App) ExpectDatastoreCalls(want ...DatastoreCall) {
+	a.t.Helper()
+	var metrics []internal.WantMetric
+	for _, c := range want {
+		metrics = append(metrics, c.metrics()...)
+	}
+	a.expect.ExpectMetricsPresent(a.t, metrics)
+}
+
+// MessageProduce is an expectation of the message segments recorded for
+// messages sent to the same destination.
+type MessageProduce struct {
+	// Library is the name of the messaging library, for example "Kafka".
+	Library         string
+	DestinationType newrelic.MessageDestinationType
+	// DestinationName is the name of the queue or topic, and should be
+	// empty for temporary destinations.
+	DestinationName string
+	// Count is the expected number of messages.  If zero, any number of
+	// messages is accepted.
+	Count int
+	// TxnName is the full name of the transaction which sent the
+	// messages.  The transaction is not validated if empty.
+	TxnName string
+}
+
+func (p MessageProduce) metrics() []internal.WantMetric {
+	var data []float64
+	if p.Count > 0 {
+		data = []float64{float64(p.Count)}
+	}
+	key := internal.MessageMetricKey{
+		Library:         p.Library,
+		DestinationType: string(p.DestinationType),
+		DestinationName: p.DestinationName,
+		DestinationTemp: "" == p.DestinationName,
+	}
+	metrics := []internal.WantMetric{{Name: key.Name(), Data: data}}
+	if "" != p.TxnName {
+		metrics = append(metrics, internal.WantMetric{Name: key.Name(), Scope: p.TxnName, Data: data})
+	}
+	return metrics
+}
+
+// ExpectMessagesProduced validates that the messages were recorded as sent.
+// Other messages may also have been recorded.
+func (a *App) ExpectMessagesProduced(want ...MessageProduce) {
+	a.t.Helper()
+	var metrics []internal.WantMetric
+	for _, p := range want {
+		metrics = append(metrics, p.metrics()...)
+	}
+	a.expect.ExpectMetricsPresent(a.t, metrics)
+}
+
+// SlowQuery is an expectation of a slow query.  Empty string fields are not
+// validated.
+type SlowQuery struct {
+	// Query is the query as recorded, and identifies the slow query.
+	Query string
+	// Count is the number of times the query was made.  If zero, one is
+	// expected.
+	Count int
+	// MetricName is the datastore statement or operation metric name, for
+	// example "Datastore/statement/Postgres/users/select".
+	MetricName   string
+	TxnName      string
+	DatabaseName string
+	Host         string
+	PortPathOrID string
+	// Params are the expected query parameters.
+	Params map[string]interface{}
+}
+
+// ExpectSlowQueries validates the slow queries.  Since every query is
+// recorded as a slow query, want must contain every distinct query made.
+func (a *App) ExpectSlowQueries(want ...SlowQuery) {
+	a.t.Helper()
+	queries := make([]internal.WantSlowQuery, len(want))
+	for i, q := range want {
+		count := q.Count
+		if 0 == count {
+			count = 1
+		}
+		queries[i] = internal.WantSlowQuery{
+			Count:        int32(count),
+			Query:        q.Query,
+			MetricName:   q.MetricName,
+			TxnName:      q.TxnName,
+			DatabaseName: q.DatabaseName,
+			Host:         q.Host,
+			PortPathOrID: q.PortPathOrID,
+			Params:       q.Params,
+		}
+	}
+	a.expect.ExpectSlowQueries(a.t, queries)
+}
@@ -0,0 +1,168 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package nrtestcontainers
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+
+	"github.com/lib/pq"
+	"github.com/newrelic/go-agent/v3/newrelic"
+	"github.com/newrelic/go-agent/v3/newrelic/sqlparse"
+)
+
+func init() {
+	sql.Register("nrpostgres-test", newrelic.InstrumentSQLDriver(&pq.Driver{}, newrelic.SQLDriverSegmentBuilder{
+		BaseSegment: newrelic.DatastoreSegment{Product: newrelic.DatastorePostgres},
+		ParseQuery:  sqlparse.ParseQuery,
+	}))
+}
+
+// recorder records the errors reported by assertions which are expected to
+// fail.
+type recorder struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Error(args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprint(args...))
+}
+
+func recordSegments(app *App) {
+	txn := app.StartTransaction("txn")
+	(&newrelic.DatastoreSegment{
+		StartTime:          txn.StartSegmentNow(),
+		Product:            newrelic.DatastorePostgres,
+		Collection:         "users",
+		Operation:          "select",
+		ParameterizedQuery: "SELECT * FROM users WHERE id = $1",
+	}).End()
+	(&newrelic.MessageProducerSegment{
+		StartTime:       txn.StartSegmentNow(),
+		Library:         "Kafka",
+		DestinationType: newrelic.MessageTopic,
+		DestinationName: "orders",
+	}).End()
+	txn.End()
+}
+
+func TestExpectations(t *testing.T) {
+	app := NewApp(t)
+	recordSegments(app)
+
+	app.ExpectDatastoreCalls(DatastoreCall{
+		Product:    newrelic.DatastorePostgres,
+		Collection: "users",
+		Operation:  "select",
+		Count:      1,
+		TxnName:    "OtherTransaction/Go/txn",
+	}, DatastoreCall{
+		Product:   newrelic.DatastorePostgres,
+		Operation: "select",
+	})
+	app.ExpectMessagesProduced(MessageProduce{
+		Library:         "Kafka",
+		DestinationType: newrelic.MessageTopic,
+		DestinationName: "orders",
+		Count:           1,
+		TxnName:         "OtherTransaction/Go/txn",
+	})
+	app.ExpectSlowQueries(SlowQuery{
+		Query:      "SELECT * FROM users WHERE id = $1",
+		MetricName: "Datastore/statement/Postgres/users/select",
+		TxnName:    "OtherTransaction/Go/txn",
+	})
+}
+
+func TestExpectationsFailure(t *testing.T) {
+	r := &recorder{TB: t}
+	app := NewApp(r)
+	recordSegments(app)
+
+	app.ExpectDatastoreCalls(DatastoreCall{
+		Product:    newrelic.DatastorePostgres,
+		Collection: "orders",
+		Operation:  "select",
+	})
+	if len(r.errors) != 1 {
+		t.Errorf("missing datastore call: %q", r.errors)
+	}
+
+	r.errors = nil
+	app.ExpectDatastoreCalls(DatastoreCall{
+		Product:   newrelic.DatastorePostgres,
+		Operation: "select",
+		Count:     2,
+	})
+	if len(r.errors) != 2 {
+		t.Errorf("wrong datastore call count: %q", r.errors)
+	}
+
+	r.errors = nil
+	app.ExpectMessagesProduced(MessageProduce{
+		Library:         "Kafka",
+		DestinationType: newrelic.MessageTopic,
+		DestinationName: "payments",
+	})
+	if len(r.errors) != 1 {
+		t.Errorf("missing message: %q", r.errors)
+	}
+
+	r.errors = nil
+	app.ExpectSlowQueries()
+	if len(r.errors) != 1 {
+		t.Errorf("unexpected slow query: %q", r.errors)
+	}
+}
+
+func TestPostgres(t *testing.T) {
+	dsn := StartPostgres(t)
+	app := NewApp(t)
+
+	db, err := sql.Open("nrpostgres-test", dsn)
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec("CREATE TABLE users (id INT, name TEXT)"); nil != err {
+		t.Fatal(err)
+	}
+
+	txn := app.StartTransaction("query")
+	ctx := newrelic.NewContext(context.Background(), txn)
+	if _, err := db.ExecContext(ctx, "INSERT INTO users (id, name) VALUES ($1, $2)", 1, "gopher"); nil != err {
+		t.Fatal(err)
+	}
+	var name string
+	if err := db.QueryRowContext(ctx, "SELECT name FROM users WHERE id = $1", 1).Scan(&name); nil != err {
+		t.Fatal(err)
+	}
+	txn.End()
+
+	app.ExpectDatastoreCalls(DatastoreCall{
+		Product:    newrelic.DatastorePostgres,
+		Collection: "users",
+		Operation:  "insert",
+		Count:      1,
+		TxnName:    "OtherTransaction/Go/query",
+	}, DatastoreCall{
+		Product:    newrelic.DatastorePostgres,
+		Collection: "users",
+		Operation:  "select",
+		Count:      1,
+		TxnName:    "OtherTransaction/Go/query",
+	})
+	app.ExpectSlowQueries(SlowQuery{
+		Query:      "INSERT INTO users (id, name) VALUES ($1, $2)",
+		MetricName: "Datastore/statement/Postgres/users/insert",
+	}, SlowQuery{
+		Query:      "SELECT name FROM users WHERE id = $1",
+		MetricName: "Datastore/statement/Postgres/users/select",
+	})
+}
@@ -0,0 +1,62 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/newrelic/go-agent/v3/integrations/nrtwirp"
+	"github.com/newrelic/go-agent/v3/newrelic"
+	"github.com/twitchtv/twirp"
+	"github.com/twitchtv/twirp/example"
+)
+
+type haberdasher struct{}
+
+func (haberdasher) MakeHat(ctx context.Context, size *example.Size) (*example.Hat, error) {
+	if size.Inches <= 0 {
+		// Returned as a 400 Bad Request.
+		return nil, twirp.InvalidArgumentError("Inches", "must be positive")
+	}
+	// The transaction is in the context, and can be used to create
+	// segments.
+	defer newrelic.FromContext(ctx).StartSegment("sew").End()
+	time.Sleep(10 * time.Millisecond)
+	return &example.Hat{Size: size.Inches, Color: "blue", Name: "bowler"}, nil
+}
+
+func main() {
+	app, err := newrelic.NewApplication(
+		newrelic.ConfigAppName("Twirp App"),
+		newrelic.ConfigLicense(os.Getenv("NEW_RELIC_LICENSE_KEY")),
+		newrelic.ConfigDebugLogger(os.Stdout),
+	)
+	if nil != err {
+		panic(err)
+	}
+
+	server := example.NewHaberdasherServer(haberdasher{},
+		twirp.WithServerHooks(nrtwirp.NewServerHooks()))
+	http.Handle(server.PathPrefix(), nrtwirp.WrapServer(app, server))
+
+	// The client sends a request to the server every few seconds inside of
+	// a transaction, to show distributed tracing between the two.
+	go func() {
+		client := example.NewHaberdasherProtobufClient("http://localhost:8000",
+			nrtwirp.WrapClient(&http.Client{}))
+		for {
+			time.Sleep(5 * time.Second)
+			txn := app.StartTransaction("make-hat")
+			hat, err := client.MakeHat(newrelic.NewContext(context.Background(), txn), &example.Size{Inches: 12})
+			txn.End()
+			fmt.Println(hat, err)
+		}
+	}()
+
+	http.ListenAndServe(":8000", nil)
+}
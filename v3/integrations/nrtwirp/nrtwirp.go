@@ -0,0 +1,155 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package nrtwirp instruments https://github.com/twitchtv/twirp.
+//
+// Use this package to instrument Twirp servers and clients.  To instrument a
+// server, add the hooks returned by NewServerHooks when creating the server,
+// and wrap the server using WrapServer:
+//
+//	server := pb.NewHaberdasherServer(&haberdasher{},
+//		twirp.WithServerHooks(nrtwirp.NewServerHooks()))
+//	http.Handle(server.PathPrefix(), nrtwirp.WrapServer(app, server))
+//
+// Each request is recorded as a web transaction named after the Twirp
+// service and method, for example
+// "WebTransaction/Go/twitch.twirp.example.Haberdasher/MakeHat".  Inbound
+// distributed tracing headers are accepted, and the transaction is added to
+// the request context.  Twirp writes the HTTP status code which corresponds
+// to the Twirp error code returned by a method, for example 404 for
+// twirp.NotFound, so errors are recorded using the agent's HTTP status code
+// settings.  The Twirp error code is added to the transaction as the
+// "twirp.errorCode" attribute.
+//
+// To instrument a client, wrap the HTTP client passed to the generated client
+// constructor using WrapClient:
+//
+//	client := pb.NewHaberdasherProtobufClient("http://localhost:8080",
+//		nrtwirp.WrapClient(&http.Client{}))
+//
+// Calls made with a context containing a transaction are recorded as
+// external segments, and distributed tracing headers are added to the
+// request.
+//
+// Full example:
+// https://github.com/newrelic/go-agent/blob/master/v3/integrations/nrtwirp/example/main.go
+package nrtwirp
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/newrelic/go-agent/v3/internal"
+	"github.com/newrelic/go-agent/v3/newrelic"
+	"github.com/twitchtv/twirp"
+)
+
+func init() { internal.TrackUsage("integration", "framework", "twirp") }
+
+// AttributeTwirpErrorCode is the custom attribute containing the Twirp error
+// code returned by the server.
+const AttributeTwirpErrorCode = "twirp.errorCode"
+
+// Server is the interface implemented by generated Twirp servers.
+type Server interface {
+	http.Handler
+	PathPrefix() string
+}
+
+// WrapServer returns an http.Handler which records each request served by
+// server as a transaction.  The transaction is named after the Twirp service
+// and method by the hooks returned by NewServerHooks, which must be added to
+// server.  If app is nil, server is returned unchanged.
+func WrapServer(app *newrelic.Application, server Server) http.Handler {
+	if nil == app {
+		return server
+	}
+	// Requests which are not routed to a method are named after the path
+	// prefix, to avoid creating a transaction name for every invalid
+	// path.
+	name := "POST " + server.PathPrefix()
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		txn := app.StartTransaction(name)
+		defer txn.End()
+
+		w = txn.SetWebResponse(w)
+		txn.SetWebRequestHTTP(r)
+		r = newrelic.RequestWithTransactionContext(r, txn)
+
+		server.ServeHTTP(w, r)
+	})
+}
+
+// NewServerHooks returns twirp.ServerHooks which name the transaction created
+// by WrapServer after the Twirp service and method, and record the Twirp
+// error code.  Use twirp.ChainHooks to combine them with other hooks.
+func NewServerHooks() *twirp.ServerHooks {
+	return &twirp.ServerHooks{
+		RequestRouted: func(ctx context.Context) (context.Context, error) {
+			if txn := newrelic.FromContext(ctx); nil != txn {
+				txn.SetName(methodName(ctx))
+			}
+			return ctx, nil
+		},
+		Error: func(ctx context.Context, err twirp.Error) context.Context {
+			if txn := newrelic.FromContext(ctx); nil != txn {
+				txn.AddAttribute(AttributeTwirpErrorCode, string(err.Code()))
+			}
+			return ctx
+		},
+	}
+}
+
+// methodName returns the name of the method in the form
+// "<package>.<service>/<method>".
+func methodName(ctx context.Context) string {
+	service, _ := twirp.ServiceName(ctx)
+	if pkg, ok := twirp.PackageName(ctx); ok && "" != pkg {
+		service = pkg + "." + service
+	}
+	method, _ := twirp.MethodName(ctx)
+	return service + "/" + method
+}
+
+// HTTPClient is the interface used by generated Twirp clients to send
+// requests.
+type HTTPClient interface {
+	Do(*http.Request) (*http.Response, error)
+}
+
+// WrapClient returns an HTTPClient which records requests sent using client
+// as external segments of the transaction in the request context, and adds
+// distributed tracing headers to the requests.
+func WrapClient(client HTTPClient) HTTPClient {
+	return &wrappedClient{original: client}
+}
+
+type wrappedClient struct {
+	original HTTPClient
+}
+
+func (c *wrappedClient) Do(req *http.Request) (*http.Response, error) {
+	txn := newrelic.FromContext(req.Context())
+	if nil == txn {
+		return c.original.Do(req)
+	}
+	seg := newrelic.StartExternalSegment(txn, req)
+	seg.Library = "Twirp"
+	seg.Procedure = clientProcedure(req.URL.Path)
+
+	resp, err := c.original.Do(req)
+	seg.Response = resp
+	seg.End()
+	return resp, err
+}
+
+// clientProcedure returns the "<package>.<service>/<method>" part of a Twirp
+// request path, which is "[<prefix>]/<package>.<service>/<method>".
+func clientProcedure(path string) string {
+	parts := strings.Split(path, "/")
+	if len(parts) < 2 {
+		return path
+	}
+	return strings.Join(parts[len(parts)-2:], "/")
+}
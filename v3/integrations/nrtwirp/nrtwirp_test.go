@@ -0,0 +1,209 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package nrtwirp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/newrelic/go-agent/v3/internal"
+	"github.com/newrelic/go-agent/v3/internal/integrationsupport"
+	"github.com/newrelic/go-agent/v3/newrelic"
+	"github.com/twitchtv/twirp"
+	"github.com/twitchtv/twirp/example"
+)
+
+type haberdasher struct{}
+
+func (haberdasher) MakeHat(ctx context.Context, size *example.Size) (*example.Hat, error) {
+	if size.Inches <= 0 {
+		return nil, twirp.InvalidArgumentError("Inches", "must be positive")
+	}
+	if size.Inches > 100 {
+		return nil, twirp.InternalError("out of fabric")
+	}
+	return &example.Hat{Size: size.Inches, Color: "blue", Name: "bowler"}, nil
+}
+
+func replyFn(reply *internal.ConnectReply) {
+	reply.SetSampleEverything()
+	reply.AccountID = "123"
+	reply.TrustedAccountKey = "123"
+	reply.PrimaryAppID = "456"
+}
+
+func testApp() integrationsupport.ExpectApp {
+	return integrationsupport.NewTestApp(replyFn,
+		newrelic.ConfigCodeLevelMetricsEnabled(false),
+		newrelic.ConfigDistributedTracerEnabled(true))
+}
+
+func newServer(t *testing.T, app *newrelic.Application) *httptest.Server {
+	server := example.NewHaberdasherServer(haberdasher{}, twirp.WithServerHooks(NewServerHooks()))
+	srv := httptest.NewServer(WrapServer(app, server))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestServer(t *testing.T) {
+	app := testApp()
+	srv := newServer(t, app.Application)
+	client := example.NewHaberdasherProtobufClient(srv.URL, srv.Client())
+
+	if _, err := client.MakeHat(context.Background(), &example.Size{Inches: 10}); nil != err {
+		t.Fatal(err)
+	}
+	app.ExpectTxnEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"name":             "WebTransaction/Go/twitch.twirp.example.Haberdasher/MakeHat",
+			"nr.apdexPerfZone": internal.MatchAnything,
+			"guid":             internal.MatchAnything,
+			"priority":         internal.MatchAnything,
+			"sampled":          internal.MatchAnything,
+			"traceId":          internal.MatchAnything,
+		},
+		UserAttributes: map[string]interface{}{},
+		AgentAttributes: map[string]interface{}{
+			"httpResponseCode":               "200",
+			"http.statusCode":                "200",
+			"request.method":                 "POST",
+			"request.uri":                    "/twirp/twitch.twirp.example.Haberdasher/MakeHat",
+			"request.headers.contentType":    "application/protobuf",
+			"request.headers.contentLength":  internal.MatchAnything,
+			"request.headers.host":           internal.MatchAnything,
+			"request.headers.accept":         "application/protobuf",
+			"response.headers.contentType":   "application/protobuf",
+			"response.headers.contentLength": internal.MatchAnything,
+		},
+	}})
+}
+
+func TestServerErrors(t *testing.T) {
+	app := testApp()
+	srv := newServer(t, app.Application)
+	client := example.NewHaberdasherProtobufClient(srv.URL, srv.Client())
+
+	_, err := client.MakeHat(context.Background(), &example.Size{Inches: 0})
+	if twerr, ok := err.(twirp.Error); !ok || twerr.Code() != twirp.InvalidArgument {
+		t.Fatal(err)
+	}
+	_, err = client.MakeHat(context.Background(), &example.Size{Inches: 1000})
+	if twerr, ok := err.(twirp.Error); !ok || twerr.Code() != twirp.Internal {
+		t.Fatal(err)
+	}
+
+	// Twirp maps invalid_argument to 400 and internal to 500, and both
+	// are recorded as errors by the default status code settings.
+	app.ExpectMetricsPresent(t, []internal.WantMetric{
+		{Name: "WebTransaction/Go/twitch.twirp.example.Haberdasher/MakeHat", Scope: "", Forced: true, Data: []float64{2}},
+		{Name: "Errors/WebTransaction/Go/twitch.twirp.example.Haberdasher/MakeHat", Scope: "", Forced: true, Data: []float64{2}},
+	})
+	app.ExpectErrorEvents(t, []internal.WantEvent{
+		{
+			Intrinsics: map[string]interface{}{
+				"error.class":     "400",
+				"error.message":   "Bad Request",
+				"transactionName": "WebTransaction/Go/twitch.twirp.example.Haberdasher/MakeHat",
+				"guid":            internal.MatchAnything,
+				"priority":        internal.MatchAnything,
+				"sampled":         internal.MatchAnything,
+				"spanId":          internal.MatchAnything,
+				"traceId":         internal.MatchAnything,
+			},
+			UserAttributes: map[string]interface{}{
+				AttributeTwirpErrorCode: "invalid_argument",
+			},
+		},
+		{
+			Intrinsics: map[string]interface{}{
+				"error.class":     "500",
+				"error.message":   "Internal Server Error",
+				"transactionName": "WebTransaction/Go/twitch.twirp.example.Haberdasher/MakeHat",
+				"guid":            internal.MatchAnything,
+				"priority":        internal.MatchAnything,
+				"sampled":         internal.MatchAnything,
+				"spanId":          internal.MatchAnything,
+				"traceId":         internal.MatchAnything,
+			},
+			UserAttributes: map[string]interface{}{
+				AttributeTwirpErrorCode: "internal",
+			},
+		},
+	})
+}
+
+func TestServerBadRoute(t *testing.T) {
+	app := testApp()
+	srv := newServer(t, app.Application)
+
+	resp, err := srv.Client().Post(srv.URL+"/twirp/twitch.twirp.example.Haberdasher/Unknown", "application/json", nil)
+	if nil != err {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Error(resp.StatusCode)
+	}
+	app.ExpectMetricsPresent(t, []internal.WantMetric{
+		{Name: "WebTransaction/Go/POST /twirp/twitch.twirp.example.Haberdasher/", Scope: "", Forced: true, Data: nil},
+	})
+}
+
+func TestWrapServerNilApp(t *testing.T) {
+	server := example.NewHaberdasherServer(haberdasher{})
+	if WrapServer(nil, server) != http.Handler(server) {
+		t.Error("server should not be wrapped")
+	}
+}
+
+func TestClient(t *testing.T) {
+	var traceparent string
+	server := example.NewHaberdasherServer(haberdasher{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+		server.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+
+	app := testApp()
+	txn := app.StartTransaction("client")
+	client := example.NewHaberdasherProtobufClient(srv.URL, WrapClient(srv.Client()))
+	ctx := newrelic.NewContext(context.Background(), txn)
+	if _, err := client.MakeHat(ctx, &example.Size{Inches: 10}); nil != err {
+		t.Fatal(err)
+	}
+	txn.End()
+
+	if "" == traceparent {
+		t.Error("distributed tracing headers missing")
+	}
+	app.ExpectMetricsPresent(t, []internal.WantMetric{
+		{Name: "External/all", Scope: "", Forced: true, Data: []float64{1}},
+		{Name: "External/" + srv.Listener.Addr().String() + "/Twirp/twitch.twirp.example.Haberdasher/MakeHat", Scope: "OtherTransaction/Go/client", Forced: false, Data: []float64{1}},
+	})
+}
+
+func TestClientNoTransaction(t *testing.T) {
+	srv := httptest.NewServer(example.NewHaberdasherServer(haberdasher{}))
+	defer srv.Close()
+
+	client := example.NewHaberdasherProtobufClient(srv.URL, WrapClient(srv.Client()))
+	if _, err := client.MakeHat(context.Background(), &example.Size{Inches: 10}); nil != err {
+		t.Fatal(err)
+	}
+}
+
+func TestClientProcedure(t *testing.T) {
+	for path, want := range map[string]string{
+		"/twirp/pkg.Service/Method": "pkg.Service/Method",
+		"/pkg.Service/Method":       "pkg.Service/Method",
+		"":                          "",
+	} {
+		if got := clientProcedure(path); got != want {
+			t.Errorf("%q: got %q, want %q", path, got, want)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/newrelic/go-agent/v3/integrations/nrwebsocket"
+	newrelic "github.com/newrelic/go-agent/v3/newrelic"
+)
+
+var upgrader = nrwebsocket.Upgrader{}
+
+func echo(ctx context.Context, conn *nrwebsocket.Conn, messageType int, msg []byte) {
+	txn := newrelic.FromContext(ctx)
+	if err := conn.WriteMessageSegment(txn, messageType, msg); err != nil {
+		txn.NoticeError(err)
+	}
+}
+
+func serve(conn *nrwebsocket.Conn) {
+	defer conn.Close()
+	for {
+		txn, messageType, msg, err := conn.ReadMessageTransaction()
+		if err != nil {
+			return
+		}
+		echo(newrelic.NewContext(context.Background(), txn), conn, messageType, msg)
+		txn.End()
+	}
+}
+
+func handler(w http.ResponseWriter, r *http.Request) {
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	// Serve the connection from a new goroutine so that the transaction
+	// of the upgrade request ends when the handler returns.
+	go serve(conn)
+}
+
+func main() {
+	app, err := newrelic.NewApplication(
+		newrelic.ConfigAppName("WebSocket App"),
+		newrelic.ConfigLicense(os.Getenv("NEW_RELIC_LICENSE_KEY")),
+		newrelic.ConfigDistributedTracerEnabled(true),
+		newrelic.ConfigDebugLogger(os.Stdout),
+	)
+	if nil != err {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	http.HandleFunc(newrelic.WrapHandleFunc(app, "/echo", handler))
+	http.ListenAndServe(":8000", nil)
+}
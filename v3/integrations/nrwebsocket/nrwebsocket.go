@@ -0,0 +1,209 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package nrwebsocket instruments https://github.com/gorilla/websocket
+// connections.
+//
+// Use this package in place of websocket.Upgrader to instrument the websocket
+// connections upgraded by an instrumented handler, for example a handler
+// wrapped using newrelic.WrapHandle:
+//
+//	upgrader := nrwebsocket.Upgrader{}
+//	http.HandleFunc(newrelic.WrapHandleFunc(app, "/ws", func(w http.ResponseWriter, r *http.Request) {
+//		conn, err := upgrader.Upgrade(w, r, nil)
+//		if err != nil {
+//			return
+//		}
+//		go serve(conn)
+//	}))
+//
+// Upgrade records the 101 Switching Protocols response on the transaction of
+// the upgrade request, which is otherwise lost when the connection is
+// hijacked.  The transaction ends when the handler returns, so handlers should
+// serve the connection from a new goroutine: otherwise, the transaction of the
+// upgrade request lasts for the lifetime of the connection.
+//
+// Messages read using Conn.ReadMessageTransaction are each recorded as a
+// background transaction named "WebSocket <name of the upgrade transaction>",
+// linked to the upgrade transaction using distributed tracing.  Messages read
+// and written within an existing transaction can be recorded as segments
+// using Conn.ReadMessageSegment and Conn.WriteMessageSegment.
+//
+// Once the connection is closed using Conn.Close, its duration and the number
+// of messages and bytes read and written are recorded as the custom metrics
+// "Custom/WebSocket/Connection/Duration",
+// "Custom/WebSocket/Connection/MessagesRead",
+// "Custom/WebSocket/Connection/MessagesWritten",
+// "Custom/WebSocket/Connection/BytesRead", and
+// "Custom/WebSocket/Connection/BytesWritten".
+//
+// Complete example:
+// https://github.com/newrelic/go-agent/tree/master/v3/integrations/nrwebsocket/example/main.go
+package nrwebsocket
+
+import (
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/newrelic/go-agent/v3/internal"
+	"github.com/newrelic/go-agent/v3/newrelic"
+)
+
+func init() { internal.TrackUsage("integration", "framework", "websocket") }
+
+// Custom attributes added to the transaction of the upgrade request.
+const (
+	AttributeWebSocketSubprotocol = "websocket.subprotocol"
+)
+
+// Names of the custom metrics recorded when a connection is closed.
+const (
+	metricDuration        = "WebSocket/Connection/Duration"
+	metricMessagesRead    = "WebSocket/Connection/MessagesRead"
+	metricMessagesWritten = "WebSocket/Connection/MessagesWritten"
+	metricBytesRead       = "WebSocket/Connection/BytesRead"
+	metricBytesWritten    = "WebSocket/Connection/BytesWritten"
+)
+
+// Upgrader wraps a websocket.Upgrader to instrument the connections it
+// upgrades.
+type Upgrader struct {
+	websocket.Upgrader
+
+	// Application records the message transactions and the metrics of
+	// connections upgraded from requests which do not have a transaction.
+	// If nil, the application of the transaction of the upgrade request is
+	// used.
+	Application *newrelic.Application
+}
+
+// Upgrade upgrades the HTTP server connection to the WebSocket protocol using
+// websocket.Upgrader.Upgrade, recording the response on the transaction of the
+// request.
+func (u *Upgrader) Upgrade(w http.ResponseWriter, r *http.Request, responseHeader http.Header) (*Conn, error) {
+	ws, err := u.Upgrader.Upgrade(w, r, responseHeader)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Conn{
+		Conn:    ws,
+		app:     u.Application,
+		name:    r.URL.Path,
+		headers: http.Header{},
+		start:   time.Now(),
+	}
+	if txn := newrelic.FromContext(r.Context()); txn != nil {
+		if c.app == nil {
+			c.app = txn.Application()
+		}
+		c.name = txn.Name()
+		if protocol := ws.Subprotocol(); protocol != "" {
+			txn.AddAttribute(AttributeWebSocketSubprotocol, protocol)
+		}
+		txn.InsertDistributedTraceHeaders(c.headers)
+		// The response is written to the hijacked connection, so the
+		// transaction does not see it.
+		txn.SetWebResponse(nil).WriteHeader(http.StatusSwitchingProtocols)
+	}
+	return c, nil
+}
+
+// Conn wraps a websocket.Conn to record the messages read and written using
+// ReadMessage and WriteMessage.  Messages read or written using other methods,
+// such as NextReader and NextWriter, are not counted.
+type Conn struct {
+	// The counters are accessed atomically, and are first to ensure their
+	// alignment on 32-bit platforms.
+	messagesRead    int64
+	messagesWritten int64
+	bytesRead       int64
+	bytesWritten    int64
+
+	*websocket.Conn
+
+	app     *newrelic.Application
+	name    string
+	headers http.Header
+	start   time.Time
+	closed  sync.Once
+}
+
+// ReadMessage reads the next message using websocket.Conn.ReadMessage.
+func (c *Conn) ReadMessage() (int, []byte, error) {
+	messageType, p, err := c.Conn.ReadMessage()
+	if err == nil {
+		atomic.AddInt64(&c.messagesRead, 1)
+		atomic.AddInt64(&c.bytesRead, int64(len(p)))
+	}
+	return messageType, p, err
+}
+
+// WriteMessage writes a message using websocket.Conn.WriteMessage.
+func (c *Conn) WriteMessage(messageType int, data []byte) error {
+	err := c.Conn.WriteMessage(messageType, data)
+	if err == nil {
+		atomic.AddInt64(&c.messagesWritten, 1)
+		atomic.AddInt64(&c.bytesWritten, int64(len(data)))
+	}
+	return err
+}
+
+// ReadMessageTransaction reads the next message and starts a background
+// transaction for handling it.  The transaction must be ended once the message
+// has been handled.  If reading fails, or if the connection has no
+// application, the returned transaction is nil.
+//
+//	for {
+//		txn, _, msg, err := conn.ReadMessageTransaction()
+//		if err != nil {
+//			break
+//		}
+//		handle(newrelic.NewContext(context.Background(), txn), msg)
+//		txn.End()
+//	}
+func (c *Conn) ReadMessageTransaction() (*newrelic.Transaction, int, []byte, error) {
+	messageType, p, err := c.ReadMessage()
+	if err != nil || c.app == nil {
+		return nil, messageType, p, err
+	}
+	txn := c.app.StartTransaction("WebSocket " + c.name)
+	txn.AcceptDistributedTraceHeaders(newrelic.TransportOther, c.headers)
+	return txn, messageType, p, nil
+}
+
+// ReadMessageSegment reads the next message, recording the time spent waiting
+// for it as the segment "WebSocket/ReadMessage" of the transaction.
+func (c *Conn) ReadMessageSegment(txn *newrelic.Transaction) (int, []byte, error) {
+	defer txn.StartSegment("WebSocket/ReadMessage").End()
+	return c.ReadMessage()
+}
+
+// WriteMessageSegment writes a message, recording the time spent writing it as
+// the segment "WebSocket/WriteMessage" of the transaction.
+func (c *Conn) WriteMessageSegment(txn *newrelic.Transaction, messageType int, data []byte) error {
+	defer txn.StartSegment("WebSocket/WriteMessage").End()
+	return c.WriteMessage(messageType, data)
+}
+
+// Close closes the underlying network connection and records the metrics of
+// the connection.  The metrics are only recorded the first time Close is
+// called.
+func (c *Conn) Close() error {
+	c.closed.Do(c.recordMetrics)
+	return c.Conn.Close()
+}
+
+func (c *Conn) recordMetrics() {
+	if c.app == nil {
+		return
+	}
+	c.app.RecordCustomMetric(metricDuration, time.Since(c.start).Seconds())
+	c.app.RecordCustomMetric(metricMessagesRead, float64(atomic.LoadInt64(&c.messagesRead)))
+	c.app.RecordCustomMetric(metricMessagesWritten, float64(atomic.LoadInt64(&c.messagesWritten)))
+	c.app.RecordCustomMetric(metricBytesRead, float64(atomic.LoadInt64(&c.bytesRead)))
+	c.app.RecordCustomMetric(metricBytesWritten, float64(atomic.LoadInt64(&c.bytesWritten)))
+}
@@ -0,0 +1,185 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package nrwebsocket
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/newrelic/go-agent/v3/internal"
+	"github.com/newrelic/go-agent/v3/internal/integrationsupport"
+	"github.com/newrelic/go-agent/v3/newrelic"
+)
+
+func replyFn(reply *internal.ConnectReply) {
+	reply.SetSampleEverything()
+	reply.AccountID = "123"
+	reply.TrustedAccountKey = "123"
+	reply.PrimaryAppID = "456"
+}
+
+// echo starts a server which echoes the first message it receives on each
+// connection, and returns a client connected to it.  done is closed once the
+// server has closed the connection and the handler has returned.  The
+// connection is served by the handler goroutine so that the transactions are
+// ended in order.
+func echo(t *testing.T, app *newrelic.Application, upgrader *Upgrader) (*websocket.Conn, <-chan struct{}) {
+	done := make(chan struct{})
+	var handler http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Error("unable to upgrade:", err)
+			return
+		}
+		defer conn.Close()
+		txn, messageType, msg, err := conn.ReadMessageTransaction()
+		if err != nil {
+			t.Error("unable to read message:", err)
+			return
+		}
+		if err := conn.WriteMessageSegment(txn, messageType, msg); err != nil {
+			t.Error("unable to write message:", err)
+		}
+		txn.End()
+	})
+	if app != nil {
+		_, handler = newrelic.WrapHandle(app, "/ws", handler)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer close(done)
+		handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+
+	dialer := websocket.Dialer{Subprotocols: []string{"chat"}}
+	client, _, err := dialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/ws", nil)
+	if err != nil {
+		t.Fatal("unable to dial:", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client, done
+}
+
+func roundTrip(t *testing.T, client *websocket.Conn, done <-chan struct{}) {
+	if err := client.WriteMessage(websocket.TextMessage, []byte("hello")); err != nil {
+		t.Fatal("unable to write message:", err)
+	}
+	_, msg, err := client.ReadMessage()
+	if err != nil || string(msg) != "hello" {
+		t.Error("incorrect echo:", string(msg), err)
+	}
+	<-done
+}
+
+func TestUpgrade(t *testing.T) {
+	app := integrationsupport.NewTestApp(replyFn, integrationsupport.DTEnabledCfgFn, newrelic.ConfigCodeLevelMetricsEnabled(false))
+	client, done := echo(t, app.Application, &Upgrader{
+		Upgrader: websocket.Upgrader{Subprotocols: []string{"chat"}},
+	})
+	roundTrip(t, client, done)
+
+	app.ExpectTxnEvents(t, []internal.WantEvent{
+		{
+			Intrinsics: map[string]interface{}{
+				"name":                     "OtherTransaction/Go/WebSocket GET /ws",
+				"guid":                     internal.MatchAnything,
+				"priority":                 internal.MatchAnything,
+				"sampled":                  internal.MatchAnything,
+				"traceId":                  internal.MatchAnything,
+				"parentId":                 internal.MatchAnything,
+				"parentSpanId":             internal.MatchAnything,
+				"parent.type":              "App",
+				"parent.account":           "123",
+				"parent.app":               "456",
+				"parent.transportType":     "Other",
+				"parent.transportDuration": internal.MatchAnything,
+			},
+		},
+		{
+			Intrinsics: map[string]interface{}{
+				"name":             "WebTransaction/Go/GET /ws",
+				"nr.apdexPerfZone": internal.MatchAnything,
+				"guid":             internal.MatchAnything,
+				"priority":         internal.MatchAnything,
+				"sampled":          internal.MatchAnything,
+				"traceId":          internal.MatchAnything,
+			},
+			UserAttributes: map[string]interface{}{
+				AttributeWebSocketSubprotocol: "chat",
+			},
+			AgentAttributes: map[string]interface{}{
+				"http.statusCode":      101,
+				"httpResponseCode":     "101",
+				"request.method":       "GET",
+				"request.uri":          "/ws",
+				"request.headers.host": internal.MatchAnything,
+			},
+		},
+	})
+	app.ExpectMetricsPresent(t, []internal.WantMetric{
+		{Name: "Custom/WebSocket/WriteMessage", Scope: "OtherTransaction/Go/WebSocket GET /ws", Forced: false, Data: []float64{1}},
+		{Name: "Custom/" + metricDuration, Scope: "", Forced: false, Data: []float64{1}},
+		{Name: "Custom/" + metricMessagesRead, Scope: "", Forced: false, Data: []float64{1, 1, 1, 1, 1, 1}},
+		{Name: "Custom/" + metricMessagesWritten, Scope: "", Forced: false, Data: []float64{1, 1, 1, 1, 1, 1}},
+		{Name: "Custom/" + metricBytesRead, Scope: "", Forced: false, Data: []float64{1, 5, 5, 5, 5, 25}},
+		{Name: "Custom/" + metricBytesWritten, Scope: "", Forced: false, Data: []float64{1, 5, 5, 5, 5, 25}},
+	})
+}
+
+func TestUpgradeWithoutTransaction(t *testing.T) {
+	app := integrationsupport.NewTestApp(replyFn, integrationsupport.DTEnabledCfgFn, newrelic.ConfigCodeLevelMetricsEnabled(false))
+	client, done := echo(t, nil, &Upgrader{Application: app.Application})
+	roundTrip(t, client, done)
+
+	app.ExpectTxnEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"name":     "OtherTransaction/Go/WebSocket /ws",
+			"guid":     internal.MatchAnything,
+			"priority": internal.MatchAnything,
+			"sampled":  internal.MatchAnything,
+			"traceId":  internal.MatchAnything,
+		},
+	}})
+	app.ExpectMetricsPresent(t, []internal.WantMetric{
+		{Name: "Custom/" + metricMessagesRead, Scope: "", Forced: false, Data: []float64{1, 1, 1, 1, 1, 1}},
+	})
+}
+
+func TestUpgradeWithoutApplication(t *testing.T) {
+	client, done := echo(t, nil, &Upgrader{})
+	roundTrip(t, client, done)
+}
+
+func TestUpgradeFailure(t *testing.T) {
+	app := integrationsupport.NewTestApp(replyFn, integrationsupport.DTEnabledCfgFn, newrelic.ConfigCodeLevelMetricsEnabled(false))
+	upgrader := &Upgrader{}
+	_, h := newrelic.WrapHandleFunc(app.Application, "/ws", func(w http.ResponseWriter, r *http.Request) {
+		if conn, err := upgrader.Upgrade(w, r, nil); err == nil || conn != nil {
+			t.Error("upgrade should fail")
+		}
+	})
+	h(httptest.NewRecorder(), httptest.NewRequest("GET", "/ws", nil))
+
+	app.ExpectTxnEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"name":             "WebTransaction/Go/GET /ws",
+			"nr.apdexPerfZone": internal.MatchAnything,
+			"guid":             internal.MatchAnything,
+			"priority":         internal.MatchAnything,
+			"sampled":          internal.MatchAnything,
+			"traceId":          internal.MatchAnything,
+		},
+		AgentAttributes: map[string]interface{}{
+			"http.statusCode":              400,
+			"httpResponseCode":             "400",
+			"request.method":               "GET",
+			"request.uri":                  "/ws",
+			"request.headers.host":         "example.com",
+			"response.headers.contentType": internal.MatchAnything,
+		},
+	}})
+}
@@ -101,7 +101,20 @@ type ConnectReply struct {
 		ErrorCollectorEnabled                *bool       `json:"error_collector.enabled"`
 		ErrorCollectorIgnoreStatusCodes      []int       `json:"error_collector.ignore_status_codes"`
 		ErrorCollectorExpectStatusCodes      []int       `json:"error_collector.expected_status_codes"`
+		ErrorCollectorExpectClasses          []string    `json:"error_collector.expected_classes"`
 		CrossApplicationTracerEnabled        *bool       `json:"cross_application_tracer.enabled"`
+		ErrorCollectorCaptureEvents          *bool       `json:"error_collector.capture_events"`
+		TransactionEventsEnabled             *bool       `json:"transaction_events.enabled"`
+		SpanEventsEnabled                    *bool       `json:"span_events.enabled"`
+		SlowSQLEnabled                       *bool       `json:"slow_sql.enabled"`
+		TransactionTracerExplainThreshold    *float64    `json:"transaction_tracer.explain_threshold"`
+		// AttributesInclude and AttributesExclude are added to the
+		// local attribute filters.
+		AttributesInclude []string `json:"attributes.include"`
+		AttributesExclude []string `json:"attributes.exclude"`
+		// SamplingTarget replaces the sampling target of the adaptive
+		// sampler.
+		SamplingTarget *uint64 `json:"distributed_tracing.sampler.adaptive_sampling_target"`
 	} `json:"agent_config"`
 
 	// Faster Event Harvest
@@ -0,0 +1,37 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package errorutil translates the errors returned by the handlers of web
+// frameworks, such as echo.HTTPError, fiber.Error, and the errors of a
+// gin.Context, into transaction errors.  The framework integrations determine
+// the response code of the error, and this package records it, so that every
+// framework applies the same rules:
+//
+//   - The response code is recorded as the http.statusCode attribute.
+//   - No error is recorded for codes below 400 or for the codes of
+//     ErrorCollector.IgnoreStatusCodes.
+//   - The error is recorded as expected for the codes of
+//     ErrorCollector.ExpectStatusCodes.
+//   - The error is recorded with the response code as its class and the
+//     message of the framework error, rather than the status text.
+package errorutil
+
+import (
+	"net/http"
+
+	"github.com/newrelic/go-agent/v3/internal"
+	"github.com/newrelic/go-agent/v3/newrelic"
+)
+
+// NoticeError records code and hdr, which may be nil, as the response code and
+// headers of the transaction and, if the code is an error, err as the error of
+// the transaction.  It does nothing if the response code has already been
+// recorded.
+func NoticeError(txn *newrelic.Transaction, code int, hdr http.Header, err error) {
+	if txn == nil {
+		return
+	}
+	if n, ok := txn.Private.(internal.ResponseErrorNoticer); ok {
+		n.NoticeResponseError(code, hdr, err)
+	}
+}
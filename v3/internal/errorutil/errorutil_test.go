@@ -0,0 +1,92 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package errorutil
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/newrelic/go-agent/v3/internal"
+	"github.com/newrelic/go-agent/v3/internal/integrationsupport"
+	"github.com/newrelic/go-agent/v3/newrelic"
+)
+
+func TestNoticeError(t *testing.T) {
+	app := integrationsupport.NewTestApp(nil, integrationsupport.BasicConfigFn, newrelic.ConfigCodeLevelMetricsEnabled(false))
+	txn := app.StartTransaction("hello")
+	NoticeError(txn, http.StatusTeapot, http.Header{"Content-Type": {"text/plain"}}, errors.New("I'm a teapot!"))
+	// The response code is only recorded once.
+	NoticeError(txn, http.StatusBadGateway, nil, errors.New("bad gateway"))
+	txn.SetWebResponse(nil).WriteHeader(http.StatusServiceUnavailable)
+	txn.End()
+
+	app.ExpectErrors(t, []internal.WantError{{
+		TxnName: "OtherTransaction/Go/hello",
+		Msg:     "I'm a teapot!",
+		Klass:   "418",
+	}})
+	app.ExpectErrorEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"error.class":     "418",
+			"error.message":   "I'm a teapot!",
+			"transactionName": "OtherTransaction/Go/hello",
+			"guid":            internal.MatchAnything,
+			"priority":        internal.MatchAnything,
+			"sampled":         internal.MatchAnything,
+			"traceId":         internal.MatchAnything,
+		},
+		AgentAttributes: map[string]interface{}{
+			"http.statusCode":              418,
+			"httpResponseCode":             "418",
+			"response.headers.contentType": "text/plain",
+		},
+	}})
+}
+
+func TestNoticeErrorNotAnError(t *testing.T) {
+	cfgfn := func(cfg *newrelic.Config) {
+		cfg.ErrorCollector.IgnoreStatusCodes = append(cfg.ErrorCollector.IgnoreStatusCodes, http.StatusConflict)
+	}
+	for _, code := range []int{http.StatusFound, http.StatusNotFound, http.StatusConflict} {
+		app := integrationsupport.NewTestApp(nil, integrationsupport.BasicConfigFn, cfgfn)
+		txn := app.StartTransaction("hello")
+		NoticeError(txn, code, nil, errors.New("oops"))
+		txn.End()
+
+		app.ExpectErrors(t, []internal.WantError{})
+	}
+}
+
+func TestNoticeErrorExpected(t *testing.T) {
+	cfgfn := func(cfg *newrelic.Config) {
+		cfg.ErrorCollector.ExpectStatusCodes = []int{http.StatusTooManyRequests}
+	}
+	app := integrationsupport.NewTestApp(nil, integrationsupport.BasicConfigFn, cfgfn)
+	txn := app.StartTransaction("hello")
+	NoticeError(txn, http.StatusTooManyRequests, nil, errors.New("slow down"))
+	txn.End()
+
+	app.ExpectErrors(t, []internal.WantError{{
+		TxnName: "OtherTransaction/Go/hello",
+		Msg:     "slow down",
+		Klass:   "429",
+	}})
+	app.ExpectErrorEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"error.class":     "429",
+			"error.message":   "slow down",
+			"error.expected":  true,
+			"transactionName": "OtherTransaction/Go/hello",
+			"guid":            internal.MatchAnything,
+			"priority":        internal.MatchAnything,
+			"sampled":         internal.MatchAnything,
+			"traceId":         internal.MatchAnything,
+		},
+	}})
+}
+
+func TestNoticeErrorNilTransaction(t *testing.T) {
+	NoticeError(nil, http.StatusInternalServerError, nil, errors.New("oops"))
+}
@@ -3,7 +3,10 @@
 
 package internal
 
-import "io"
+import (
+	"io"
+	"net/http"
+)
 
 // This file contains interfaces that are implemented by Transaction and
 // Application but not exposed as public methods so they will only be used in
@@ -39,3 +42,8 @@ func AddAgentSpanAttribute(txn interface{}, key string, val string) {
 		aa.AddAgentSpanAttribute(key, val)
 	}
 }
+
+// ResponseErrorNoticer is implemented by the Transaction.
+type ResponseErrorNoticer interface {
+	NoticeResponseError(code int, hdr http.Header, err error)
+}
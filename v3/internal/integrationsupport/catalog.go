@@ -0,0 +1,97 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package integrationsupport
+
+import (
+	"sort"
+	"time"
+
+	"github.com/newrelic/go-agent/v3/internal"
+	newrelic "github.com/newrelic/go-agent/v3/newrelic"
+)
+
+// RouteCatalogEventType is the type of the custom events recorded for the
+// routes of a route catalog.
+const RouteCatalogEventType = "HttpRouteCatalog"
+
+// catalogConnectTimeout is the time ReportCatalog waits for the application
+// to connect before giving up.
+const catalogConnectTimeout = 10 * time.Minute
+
+// ReportCatalog reports the catalog of the endpoints registered with a server,
+// such as the routes of a router, as custom events of the given type.  The
+// events are recorded in a new goroutine once the application is connected,
+// since events recorded before then are dropped.  The name describes the
+// catalog in the error logged if the application does not connect, for
+// example "gin route catalog".
+func ReportCatalog(app *newrelic.Application, name, eventType string, catalog []map[string]interface{}) {
+	if app == nil {
+		return
+	}
+	go func() {
+		if err := app.WaitForConnection(catalogConnectTimeout); err != nil {
+			if cfg, ok := app.Config(); ok && cfg.Logger != nil {
+				cfg.Logger.Error("unable to report the "+name, map[string]interface{}{
+					"reason": err.Error(),
+				})
+			}
+			return
+		}
+		RecordCatalog(app, eventType, catalog)
+	}()
+}
+
+// RecordCatalog records the catalog of the endpoints registered with a server
+// without waiting for the application to connect.
+func RecordCatalog(app *newrelic.Application, eventType string, catalog []map[string]interface{}) {
+	for _, params := range catalog {
+		app.RecordCustomEvent(eventType, params)
+	}
+}
+
+// Route is a route of a route catalog.  The pattern is the path of the route,
+// as used by the integration to name the transactions of the route.
+type Route struct {
+	Method  string
+	Pattern string
+}
+
+// RouteCatalog returns the catalog of the routes registered with a router of
+// the framework, sorted by pattern and method, for ReportCatalog.  Only the
+// methods and patterns of the routes are reported.
+func RouteCatalog(framework string, routes []Route) []map[string]interface{} {
+	sorted := make([]Route, len(routes))
+	copy(sorted, routes)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Pattern != sorted[j].Pattern {
+			return sorted[i].Pattern < sorted[j].Pattern
+		}
+		return sorted[i].Method < sorted[j].Method
+	})
+	catalog := make([]map[string]interface{}, len(sorted))
+	for i, r := range sorted {
+		catalog[i] = map[string]interface{}{
+			"framework": framework,
+			"method":    r.Method,
+			"pattern":   r.Pattern,
+		}
+	}
+	return catalog
+}
+
+// WantRouteCatalogEvent returns the custom event expected in tests for a route
+// of a route catalog.
+func WantRouteCatalogEvent(framework, method, pattern string) internal.WantEvent {
+	return internal.WantEvent{
+		Intrinsics: map[string]interface{}{
+			"type":      RouteCatalogEventType,
+			"timestamp": internal.MatchAnything,
+		},
+		UserAttributes: map[string]interface{}{
+			"framework": framework,
+			"method":    method,
+			"pattern":   pattern,
+		},
+	}
+}
@@ -0,0 +1,32 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package integrationsupport
+
+import (
+	"testing"
+
+	"github.com/newrelic/go-agent/v3/internal"
+)
+
+func TestRecordRouteCatalog(t *testing.T) {
+	app := NewBasicTestApp()
+	routes := []Route{
+		{Method: "POST", Pattern: "/users"},
+		{Method: "GET", Pattern: "/users/:id"},
+		{Method: "GET", Pattern: "/users"},
+	}
+	RecordCatalog(app.Application, RouteCatalogEventType, RouteCatalog("gin", routes))
+	app.ExpectCustomEvents(t, []internal.WantEvent{
+		WantRouteCatalogEvent("gin", "GET", "/users"),
+		WantRouteCatalogEvent("gin", "POST", "/users"),
+		WantRouteCatalogEvent("gin", "GET", "/users/:id"),
+	})
+	if routes[0].Method != "POST" {
+		t.Error("routes sorted in place:", routes)
+	}
+}
+
+func TestReportCatalogNil(t *testing.T) {
+	ReportCatalog(nil, "gin route catalog", RouteCatalogEventType, RouteCatalog("gin", nil))
+}
@@ -0,0 +1,58 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package integrationsupport
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+// PostgresExplainTimeout limits the time spent connecting to the database and
+// running the EXPLAIN statement capturing the plan of a Postgres query.
+const PostgresExplainTimeout = 5 * time.Second
+
+// postgresExplainOperations are the operations whose plan is captured.
+// EXPLAIN, unlike EXPLAIN ANALYZE, does not execute the statement.
+var postgresExplainOperations = map[string]bool{
+	"select": true,
+	"insert": true,
+	"update": true,
+	"delete": true,
+}
+
+var (
+	// planConditionRegex matches the lines of a plan which may contain the
+	// values of the query parameters, such as "Filter:" and "Index Cond:".
+	planConditionRegex = regexp.MustCompile(`^\s*(?:->\s*)?[\w\- ]*(?:Cond|Filter):`)
+	planStringRegex    = regexp.MustCompile(`'(?:[^']|'')*'`)
+	planNumberRegex    = regexp.MustCompile(`(^|[^\w$.])-?\d+(?:\.\d+)?(?:[eE][-+]?\d+)?\b`)
+)
+
+// PostgresExplainable returns true if the plan of a Postgres query with the
+// operation, as found by sqlparse.ParseQuery, may be captured.
+func PostgresExplainable(operation string) bool {
+	return postgresExplainOperations[strings.ToLower(operation)]
+}
+
+// PostgresPlan returns the plan made of the lines returned by a Postgres
+// EXPLAIN statement, with the literals of its conditions, which may be the
+// values of the query parameters, replaced with "?".
+func PostgresPlan(lines []string) string {
+	obfuscated := make([]string, len(lines))
+	for i, line := range lines {
+		obfuscated[i] = obfuscatePlanLine(line)
+	}
+	return strings.Join(obfuscated, "\n")
+}
+
+func obfuscatePlanLine(line string) string {
+	loc := planConditionRegex.FindStringIndex(line)
+	if loc == nil {
+		return line
+	}
+	cond := planStringRegex.ReplaceAllString(line[loc[1]:], "'?'")
+	cond = planNumberRegex.ReplaceAllString(cond, "$1?")
+	return line[:loc[1]] + cond
+}
@@ -0,0 +1,68 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package integrationsupport
+
+import "testing"
+
+func TestObfuscatePlanLine(t *testing.T) {
+	testcases := []struct {
+		line   string
+		expect string
+	}{
+		{
+			line:   "Seq Scan on mytable  (cost=0.00..25.88 rows=6 width=36)",
+			expect: "Seq Scan on mytable  (cost=0.00..25.88 rows=6 width=36)",
+		},
+		{
+			line:   "  Filter: (name = 'O''Brien'::text)",
+			expect: "  Filter: (name = '?'::text)",
+		},
+		{
+			line:   "  ->  Index Scan using mytable_pkey on mytable t1  (cost=0.15..8.17 rows=1 width=40)",
+			expect: "  ->  Index Scan using mytable_pkey on mytable t1  (cost=0.15..8.17 rows=1 width=40)",
+		},
+		{
+			line:   "        Index Cond: (t1.id = ANY ('{1,2}'::integer[]))",
+			expect: "        Index Cond: (t1.id = ANY ('?'::integer[]))",
+		},
+		{
+			line:   "  Recheck Cond: ((col2 > -12.5) AND (col2 < 1e3))",
+			expect: "  Recheck Cond: ((col2 > ?) AND (col2 < ?))",
+		},
+		{
+			line:   "  Filter: (id = $1)",
+			expect: "  Filter: (id = $1)",
+		},
+	}
+	for _, tc := range testcases {
+		if got := obfuscatePlanLine(tc.line); got != tc.expect {
+			t.Errorf("obfuscatePlanLine(%q) = %q, expected %q", tc.line, got, tc.expect)
+		}
+	}
+}
+
+func TestPostgresPlan(t *testing.T) {
+	plan := PostgresPlan([]string{
+		"Seq Scan on users  (cost=0.00..1.01 rows=1 width=36)",
+		"  Filter: (id = 42)",
+	})
+	if plan != "Seq Scan on users  (cost=0.00..1.01 rows=1 width=36)\n  Filter: (id = ?)" {
+		t.Error("incorrect plan:", plan)
+	}
+}
+
+func TestPostgresExplainable(t *testing.T) {
+	for op, explain := range map[string]bool{
+		"select": true,
+		"INSERT": true,
+		"update": true,
+		"delete": true,
+		"create": false,
+		"":       false,
+	} {
+		if PostgresExplainable(op) != explain {
+			t.Errorf("operation %q: expected %v", op, explain)
+		}
+	}
+}
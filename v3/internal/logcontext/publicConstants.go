@@ -17,6 +17,15 @@ const (
 	// LogTraceIDFieldName is the name of the trace ID field in the New Relic logging JSON
 	LogTraceIDFieldName = "trace.id"
 
+	// LogEntityGUIDFieldName is the name of the entity GUID field in the New Relic logging JSON
+	LogEntityGUIDFieldName = "entity.guid"
+
+	// LogEntityNameFieldName is the name of the entity name field in the New Relic logging JSON
+	LogEntityNameFieldName = "entity.name"
+
+	// LogHostnameFieldName is the name of the hostname field in the New Relic logging JSON
+	LogHostnameFieldName = "hostname"
+
 	// LogSeverityUnknown is the value the log severity should be set to if no log severity is known
 	LogSeverityUnknown = "UNKNOWN"
 
@@ -44,16 +44,37 @@ func (s ShimLogger) Debug(string, map[string]interface{}) {}
 // DebugEnabled allows ShimLogger to implement Logger.
 func (s ShimLogger) DebugEnabled() bool { return s.IsDebugEnabled }
 
+// Level is the minimum level of the messages written by a Logger created with
+// NewLevel.
+type Level int
+
+// The levels, from the most to the least verbose.
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
 type logFile struct {
-	l       *log.Logger
-	doDebug bool
+	l     *log.Logger
+	level Level
 }
 
 // New creates a basic Logger.
 func New(w io.Writer, doDebug bool) Logger {
+	if doDebug {
+		return NewLevel(w, LevelDebug)
+	}
+	return NewLevel(w, LevelInfo)
+}
+
+// NewLevel creates a basic Logger which writes the messages at or above the
+// level.
+func NewLevel(w io.Writer, level Level) Logger {
 	return &logFile{
-		l:       log.New(w, logPid, logFlags),
-		doDebug: doDebug,
+		l:     log.New(w, logPid, logFlags),
+		level: level,
 	}
 }
 
@@ -89,14 +110,72 @@ func (f *logFile) Error(msg string, ctx map[string]interface{}) {
 	f.fire("error", msg, ctx)
 }
 func (f *logFile) Warn(msg string, ctx map[string]interface{}) {
-	f.fire("warn", msg, ctx)
+	if f.level <= LevelWarn {
+		f.fire("warn", msg, ctx)
+	}
 }
 func (f *logFile) Info(msg string, ctx map[string]interface{}) {
-	f.fire("info", msg, ctx)
+	if f.level <= LevelInfo {
+		f.fire("info", msg, ctx)
+	}
 }
 func (f *logFile) Debug(msg string, ctx map[string]interface{}) {
-	if f.doDebug {
+	if f.level <= LevelDebug {
 		f.fire("debug", msg, ctx)
 	}
 }
-func (f *logFile) DebugEnabled() bool { return f.doDebug }
+func (f *logFile) DebugEnabled() bool { return f.level <= LevelDebug }
+
+type multiLogger []Logger
+
+// Multi creates a Logger which writes each message to every one of the
+// loggers.  Nil loggers are skipped, and the loggers of a Logger created by
+// Multi are added individually.  Debug is enabled if it is enabled for any of
+// the loggers.
+func Multi(loggers ...Logger) Logger {
+	var m multiLogger
+	for _, l := range loggers {
+		switch l := l.(type) {
+		case nil:
+		case multiLogger:
+			m = append(m, l...)
+		default:
+			m = append(m, l)
+		}
+	}
+	if len(m) == 1 {
+		return m[0]
+	}
+	return m
+}
+
+func (m multiLogger) Error(msg string, ctx map[string]interface{}) {
+	for _, l := range m {
+		l.Error(msg, ctx)
+	}
+}
+func (m multiLogger) Warn(msg string, ctx map[string]interface{}) {
+	for _, l := range m {
+		l.Warn(msg, ctx)
+	}
+}
+func (m multiLogger) Info(msg string, ctx map[string]interface{}) {
+	for _, l := range m {
+		l.Info(msg, ctx)
+	}
+}
+func (m multiLogger) Debug(msg string, ctx map[string]interface{}) {
+	for _, l := range m {
+		if l.DebugEnabled() {
+			l.Debug(msg, ctx)
+		}
+	}
+}
+func (m multiLogger) DebugEnabled() bool {
+	for _, l := range m {
+		if l.DebugEnabled() {
+			return true
+		}
+	}
+	return false
+}
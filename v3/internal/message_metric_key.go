@@ -10,6 +10,9 @@ type MessageMetricKey struct {
 	Consumer        bool
 	DestinationName string
 	DestinationTemp bool
+	// ConsumeSegment is set for segments which consume messages within a
+	// transaction, as opposed to transactions which consume a message.
+	ConsumeSegment bool
 }
 
 // Name returns the metric name value for this MessageMetricKey to be used for
@@ -22,6 +25,10 @@ type MessageMetricKey struct {
 // Consumers
 // OtherTransaction/Message/{Library}/{DestinationType}/Named/{Destination Name}
 // OtherTransaction/Message/{Library}/{DestinationType}/Temp
+//
+// Consume Segments
+// MessageBroker/{Library}/{Destination Type}/Consume/Named/{Destination Name}
+// MessageBroker/{Library}/{Destination Type}/Consume/Temp
 func (key MessageMetricKey) Name() string {
 	var destination string
 	if key.DestinationTemp {
@@ -37,7 +44,11 @@ func (key MessageMetricKey) Name() string {
 			"/" + key.DestinationType +
 			"/" + destination
 	}
+	action := "Produce"
+	if key.ConsumeSegment {
+		action = "Consume"
+	}
 	return "MessageBroker/" + key.Library +
 		"/" + key.DestinationType +
-		"/Produce/" + destination
+		"/" + action + "/" + destination
 }
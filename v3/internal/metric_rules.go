@@ -5,6 +5,8 @@ package internal
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"regexp"
 	"sort"
 	"strings"
@@ -61,8 +63,7 @@ func (rules *MetricRules) UnmarshalJSON(data []byte) (err error) {
 	valid := make(MetricRules, 0, len(raw))
 
 	for _, r := range raw {
-		re, err := regexp.Compile("(?i)" + r.RawExpr)
-		if err != nil {
+		if err := r.compile(); err != nil {
 			// TODO
 			// Warn("unable to compile rule", {
 			// 	"match_expression": r.RawExpr,
@@ -70,19 +71,6 @@ func (rules *MetricRules) UnmarshalJSON(data []byte) (err error) {
 			// })
 			continue
 		}
-
-		if transformReplacementAmbiguous.MatchString(r.OriginalReplacement) {
-			// TODO
-			// Warn("unable to transform replacement", {
-			// 	"match_expression": r.RawExpr,
-			// 	"replacement":      r.OriginalReplacement,
-			// })
-			continue
-		}
-
-		r.re = re
-		r.TransformedReplacement = transformReplacementRegex.ReplaceAllString(r.OriginalReplacement,
-			transformReplacementReplacement)
 		valid = append(valid, r)
 	}
 
@@ -92,6 +80,59 @@ func (rules *MetricRules) UnmarshalJSON(data []byte) (err error) {
 	return nil
 }
 
+var errAmbiguousReplacement = errors.New("ambiguous backreference in replacement")
+
+func (r *metricRule) compile() error {
+	re, err := regexp.Compile("(?i)" + r.RawExpr)
+	if err != nil {
+		return err
+	}
+	if transformReplacementAmbiguous.MatchString(r.OriginalReplacement) {
+		return errAmbiguousReplacement
+	}
+	r.re = re
+	r.TransformedReplacement = transformReplacementRegex.ReplaceAllString(r.OriginalReplacement,
+		transformReplacementReplacement)
+	return nil
+}
+
+// MetricRule is a rule configured locally rather than received in the
+// connect reply.
+type MetricRule struct {
+	MatchExpression string
+	Replacement     string
+	EachSegment     bool
+	ReplaceAll      bool
+	Terminate       bool
+	Ignore          bool
+}
+
+// NewMetricRules compiles locally configured rules, which are applied in the
+// order given.  Unlike the rules received in the connect reply, which are
+// skipped if invalid, an error is returned for an invalid rule.
+func NewMetricRules(configured []MetricRule) (MetricRules, error) {
+	if len(configured) == 0 {
+		return nil, nil
+	}
+	rules := make(MetricRules, 0, len(configured))
+	for i, c := range configured {
+		r := &metricRule{
+			Ignore:              c.Ignore,
+			EachSegment:         c.EachSegment,
+			ReplaceAll:          c.ReplaceAll,
+			Terminate:           c.Terminate,
+			Order:               i,
+			OriginalReplacement: c.Replacement,
+			RawExpr:             c.MatchExpression,
+		}
+		if err := r.compile(); err != nil {
+			return nil, fmt.Errorf("invalid rule %q: %v", c.MatchExpression, err)
+		}
+		rules = append(rules, r)
+	}
+	return rules, nil
+}
+
 // Len returns the number of rules.
 func (rules MetricRules) Len() int {
 	return len(rules)
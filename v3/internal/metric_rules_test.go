@@ -97,3 +97,39 @@ func TestBadMetricRulesJSON(t *testing.T) {
 		t.Fatal("missing bad json error")
 	}
 }
+
+func TestNewMetricRules(t *testing.T) {
+	rules, err := NewMetricRules([]MetricRule{
+		{MatchExpression: "^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$", Replacement: "{uuid}", EachSegment: true},
+		{MatchExpression: "^[0-9]+$", Replacement: "{id}", EachSegment: true},
+		{MatchExpression: "(.*)/healthz$", Ignore: true},
+		{MatchExpression: "^(WebTransaction/Go)/GET /v[0-9]+/(.*)", Replacement: "\\1/GET /${2}", Terminate: true},
+		{MatchExpression: "users", Replacement: "people", ReplaceAll: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		input, expected string
+	}{
+		{"WebTransaction/Go/GET /users/123", "WebTransaction/Go/GET /people/{id}"},
+		{"WebTransaction/Go/GET /users/6F9619FF-8B86-D011-B42D-00C04FC964FF", "WebTransaction/Go/GET /people/{uuid}"},
+		{"WebTransaction/Go/GET /healthz", ""},
+		{"WebTransaction/Go/GET /v2/users/42", "WebTransaction/Go/GET /users/{id}"},
+	} {
+		if out := rules.Apply(tc.input); out != tc.expected {
+			t.Errorf("Apply(%q) = %q, want %q", tc.input, out, tc.expected)
+		}
+	}
+}
+
+func TestNewMetricRulesInvalid(t *testing.T) {
+	for _, r := range []MetricRule{
+		{MatchExpression: "(unclosed", Replacement: "x"},
+		{MatchExpression: "(.*)/[^/]*.(css|js)", Replacement: "\\\\1/*.\\2"},
+	} {
+		if _, err := NewMetricRules([]MetricRule{r}); err == nil {
+			t.Errorf("invalid rule %q compiled", r.MatchExpression)
+		}
+	}
+}
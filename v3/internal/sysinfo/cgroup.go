@@ -0,0 +1,218 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package sysinfo
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrCgroupLimitsNotFound is returned if the cgroup of the process has
+	// no CPU or memory limit.
+	ErrCgroupLimitsNotFound = errors.New("cgroup limits not found")
+)
+
+// CgroupLimits are the limits of the cgroup of the process, such as the
+// limits of its container.  A zero value means there is no limit.
+type CgroupLimits struct {
+	// CPUs is the number of CPUs the cgroup may use, which may be
+	// fractional.
+	CPUs float64
+	// MemoryBytes is the maximum memory usage of the cgroup.
+	MemoryBytes uint64
+}
+
+// cgroupRoot is the mount point of the cgroup hierarchy.  With cgroup v2 it
+// holds the files of the cgroup of the process in containers, and with
+// cgroup v1 a directory per controller.
+const cgroupRoot = "/sys/fs/cgroup"
+
+// cgroupV1Unlimited is the lower bound of the memory limits which cgroup v1
+// reports for cgroups without a limit, which are the largest multiple of the
+// page size.
+const cgroupV1Unlimited = 1 << 62
+
+// GetCgroupLimits reads the CPU and memory limits of the cgroup of the
+// process, using the cgroup v2 unified hierarchy if it is mounted and the
+// cgroup v1 cpu and memory controllers otherwise.
+func GetCgroupLimits() (CgroupLimits, error) {
+	if "linux" != runtime.GOOS {
+		return CgroupLimits{}, ErrFeatureUnsupported
+	}
+	return readCgroupLimits(cgroupRoot)
+}
+
+// GetCgroupMemoryUsage reads the memory usage of the cgroup of the process,
+// excluding the inactive page cache which the kernel reclaims before
+// reaching the limit.  This is the working set compared to the limit by
+// Kubernetes.
+func GetCgroupMemoryUsage() (uint64, error) {
+	if "linux" != runtime.GOOS {
+		return 0, ErrFeatureUnsupported
+	}
+	return readCgroupMemoryUsage(cgroupRoot)
+}
+
+func readCgroupLimits(root string) (CgroupLimits, error) {
+	limits, err := readCgroupV2Limits(root)
+	if err != ErrCgroupLimitsNotFound {
+		return limits, err
+	}
+	return readCgroupV1Limits(root)
+}
+
+func readCgroupV2Limits(root string) (CgroupLimits, error) {
+	var limits CgroupLimits
+	found := false
+	if b, err := os.ReadFile(filepath.Join(root, "cpu.max")); err == nil {
+		cpus, err := parseCgroupCPUMax(string(b))
+		if err != nil {
+			return CgroupLimits{}, err
+		}
+		limits.CPUs = cpus
+		found = true
+	}
+	if b, err := os.ReadFile(filepath.Join(root, "memory.max")); err == nil {
+		bytes, err := parseCgroupMemoryMax(string(b))
+		if err != nil {
+			return CgroupLimits{}, err
+		}
+		limits.MemoryBytes = bytes
+		found = true
+	}
+	if !found || limits == (CgroupLimits{}) {
+		return CgroupLimits{}, ErrCgroupLimitsNotFound
+	}
+	return limits, nil
+}
+
+func readCgroupV1Limits(root string) (CgroupLimits, error) {
+	var limits CgroupLimits
+	if b, err := os.ReadFile(filepath.Join(root, "cpu", "cpu.cfs_quota_us")); err == nil {
+		period := "100000"
+		if p, err := os.ReadFile(filepath.Join(root, "cpu", "cpu.cfs_period_us")); err == nil {
+			period = string(p)
+		}
+		cpus, err := parseCgroupV1CPUQuota(string(b), period)
+		if err != nil {
+			return CgroupLimits{}, err
+		}
+		limits.CPUs = cpus
+	}
+	if b, err := os.ReadFile(filepath.Join(root, "memory", "memory.limit_in_bytes")); err == nil {
+		bytes, err := strconv.ParseUint(strings.TrimSpace(string(b)), 10, 64)
+		if err != nil {
+			return CgroupLimits{}, fmt.Errorf("invalid memory.limit_in_bytes: %v", err)
+		}
+		if bytes < cgroupV1Unlimited {
+			limits.MemoryBytes = bytes
+		}
+	}
+	if limits == (CgroupLimits{}) {
+		return CgroupLimits{}, ErrCgroupLimitsNotFound
+	}
+	return limits, nil
+}
+
+func readCgroupMemoryUsage(root string) (uint64, error) {
+	// cgroup v2 names the inactive page cache inactive_file, and cgroup v1
+	// total_inactive_file when it includes the descendants of the cgroup.
+	usageFile := filepath.Join(root, "memory.current")
+	statFile := filepath.Join(root, "memory.stat")
+	inactiveKey := "inactive_file"
+	if _, err := os.Stat(usageFile); err != nil {
+		usageFile = filepath.Join(root, "memory", "memory.usage_in_bytes")
+		statFile = filepath.Join(root, "memory", "memory.stat")
+		inactiveKey = "total_inactive_file"
+	}
+	b, err := os.ReadFile(usageFile)
+	if err != nil {
+		return 0, err
+	}
+	usage, err := strconv.ParseUint(strings.TrimSpace(string(b)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %v", filepath.Base(usageFile), err)
+	}
+	if b, err := os.ReadFile(statFile); err == nil {
+		if inactive := parseCgroupMemoryStat(string(b), inactiveKey); inactive < usage {
+			usage -= inactive
+		}
+	}
+	return usage, nil
+}
+
+// parseCgroupMemoryStat returns the value of a key of the memory.stat file,
+// which holds a key and a value in bytes per line, or 0 if it is missing.
+func parseCgroupMemoryStat(content, key string) uint64 {
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[0] == key {
+			v, _ := strconv.ParseUint(fields[1], 10, 64)
+			return v
+		}
+	}
+	return 0
+}
+
+// parseCgroupV1CPUQuota parses the content of the cpu.cfs_quota_us and the
+// cpu.cfs_period_us files of cgroup v1, which hold the quota and the period
+// of the cgroup in microseconds.  The quota is -1 if there is no limit.
+func parseCgroupV1CPUQuota(quota, period string) (float64, error) {
+	q, err := strconv.ParseFloat(strings.TrimSpace(quota), 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid cpu.cfs_quota_us: %v", err)
+	}
+	if q <= 0 {
+		return 0, nil
+	}
+	p, err := strconv.ParseFloat(strings.TrimSpace(period), 64)
+	if err != nil || p <= 0 {
+		return 0, fmt.Errorf("invalid cpu.cfs_period_us: %q", strings.TrimSpace(period))
+	}
+	return q / p, nil
+}
+
+// parseCgroupCPUMax parses the content of the cpu.max file, which holds the
+// quota and the period of the cgroup in microseconds, as in "50000 100000",
+// or "max" instead of the quota if there is no limit.
+func parseCgroupCPUMax(content string) (float64, error) {
+	fields := strings.Fields(content)
+	if len(fields) == 0 || len(fields) > 2 {
+		return 0, fmt.Errorf("invalid cpu.max: %q", content)
+	}
+	if fields[0] == "max" {
+		return 0, nil
+	}
+	quota, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid cpu.max quota: %v", err)
+	}
+	period := 100000.0
+	if len(fields) == 2 {
+		if period, err = strconv.ParseFloat(fields[1], 64); err != nil || period <= 0 {
+			return 0, fmt.Errorf("invalid cpu.max period: %q", fields[1])
+		}
+	}
+	return quota / period, nil
+}
+
+// parseCgroupMemoryMax parses the content of the memory.max file, which holds
+// the limit in bytes, or "max" if there is no limit.
+func parseCgroupMemoryMax(content string) (uint64, error) {
+	s := strings.TrimSpace(content)
+	if s == "max" {
+		return 0, nil
+	}
+	bytes, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid memory.max: %v", err)
+	}
+	return bytes, nil
+}
@@ -0,0 +1,124 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package sysinfo
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadCgroupV2Limits(t *testing.T) {
+	for _, tc := range []struct {
+		cpuMax    string
+		memoryMax string
+		want      CgroupLimits
+		wantErr   bool
+	}{
+		{cpuMax: "50000 100000\n", memoryMax: "536870912\n", want: CgroupLimits{CPUs: 0.5, MemoryBytes: 536870912}},
+		{cpuMax: "200000 100000\n", memoryMax: "max\n", want: CgroupLimits{CPUs: 2}},
+		{cpuMax: "max 100000\n", memoryMax: "1073741824\n", want: CgroupLimits{MemoryBytes: 1073741824}},
+		{memoryMax: "1048576", want: CgroupLimits{MemoryBytes: 1048576}},
+		{cpuMax: "max 100000\n", memoryMax: "max\n", wantErr: true},
+		{wantErr: true},
+		{cpuMax: "lots 100000", wantErr: true},
+		{cpuMax: "50000 0", wantErr: true},
+		{memoryMax: "-1", wantErr: true},
+	} {
+		root := t.TempDir()
+		for name, content := range map[string]string{"cpu.max": tc.cpuMax, "memory.max": tc.memoryMax} {
+			if content == "" {
+				continue
+			}
+			if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		got, err := readCgroupV2Limits(root)
+		if (err != nil) != tc.wantErr || got != tc.want {
+			t.Errorf("incorrect limits for %q %q: %+v %v", tc.cpuMax, tc.memoryMax, got, err)
+		}
+	}
+}
+
+func writeCgroupFiles(t *testing.T, files map[string]string) string {
+	root := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func TestReadCgroupV1Limits(t *testing.T) {
+	for _, tc := range []struct {
+		files   map[string]string
+		want    CgroupLimits
+		wantErr bool
+	}{
+		{files: map[string]string{
+			"cpu/cpu.cfs_quota_us":         "150000\n",
+			"cpu/cpu.cfs_period_us":        "100000\n",
+			"memory/memory.limit_in_bytes": "268435456\n",
+		}, want: CgroupLimits{CPUs: 1.5, MemoryBytes: 268435456}},
+		{files: map[string]string{
+			"cpu/cpu.cfs_quota_us":         "-1\n",
+			"cpu/cpu.cfs_period_us":        "100000\n",
+			"memory/memory.limit_in_bytes": "268435456\n",
+		}, want: CgroupLimits{MemoryBytes: 268435456}},
+		{files: map[string]string{
+			"cpu/cpu.cfs_quota_us":         "50000\n",
+			"memory/memory.limit_in_bytes": "9223372036854771712\n",
+		}, want: CgroupLimits{CPUs: 0.5}},
+		{files: map[string]string{
+			"cpu/cpu.cfs_quota_us":         "-1\n",
+			"memory/memory.limit_in_bytes": "9223372036854771712\n",
+		}, wantErr: true},
+		{files: map[string]string{
+			"cpu/cpu.cfs_quota_us":  "50000\n",
+			"cpu/cpu.cfs_period_us": "0\n",
+		}, wantErr: true},
+		{files: map[string]string{"memory/memory.limit_in_bytes": "lots"}, wantErr: true},
+		// cgroup v2 takes precedence.
+		{files: map[string]string{
+			"memory.max":                   "1048576\n",
+			"memory/memory.limit_in_bytes": "268435456\n",
+		}, want: CgroupLimits{MemoryBytes: 1048576}},
+	} {
+		got, err := readCgroupLimits(writeCgroupFiles(t, tc.files))
+		if (err != nil) != tc.wantErr || got != tc.want {
+			t.Errorf("incorrect limits for %v: %+v %v", tc.files, got, err)
+		}
+	}
+}
+
+func TestReadCgroupMemoryUsage(t *testing.T) {
+	for _, tc := range []struct {
+		files   map[string]string
+		want    uint64
+		wantErr bool
+	}{
+		{files: map[string]string{
+			"memory.current": "104857600\n",
+			"memory.stat":    "anon 52428800\nfile 52428800\ninactive_file 20971520\n",
+		}, want: 83886080},
+		{files: map[string]string{"memory.current": "104857600\n"}, want: 104857600},
+		{files: map[string]string{
+			"memory/memory.usage_in_bytes": "104857600\n",
+			"memory/memory.stat":           "inactive_file 1048576\ntotal_inactive_file 4194304\n",
+		}, want: 100663296},
+		{files: map[string]string{"memory.current": "max"}, wantErr: true},
+		{files: map[string]string{}, wantErr: true},
+	} {
+		got, err := readCgroupMemoryUsage(writeCgroupFiles(t, tc.files))
+		if (err != nil) != tc.wantErr || got != tc.want {
+			t.Errorf("incorrect usage for %v: %d %v", tc.files, got, err)
+		}
+	}
+}
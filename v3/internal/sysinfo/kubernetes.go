@@ -0,0 +1,93 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package sysinfo
+
+import (
+	"errors"
+	"os"
+	"regexp"
+	"strings"
+)
+
+var (
+	// ErrKubernetesNotFound is returned if the process is not running in a
+	// Kubernetes pod.
+	ErrKubernetesNotFound = errors.New("Kubernetes pod not found")
+)
+
+// KubernetesMetadata describes the Kubernetes pod running the process.
+// Fields which cannot be determined are empty.
+type KubernetesMetadata struct {
+	PodName     string
+	Namespace   string
+	Deployment  string
+	NodeName    string
+	ClusterName string
+}
+
+// kubernetesNamespaceFile holds the namespace of the pod in the service
+// account files mounted in pods.
+const kubernetesNamespaceFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+
+// The environment variables holding the metadata.  The NEW_RELIC_METADATA
+// variables are set by the Kubernetes metadata injection of New Relic, and
+// the others are the names commonly given to the Downward API fields in pod
+// specifications.  The first variable set is used.
+var (
+	kubernetesPodNameEnv     = []string{"NEW_RELIC_METADATA_KUBERNETES_POD_NAME", "POD_NAME", "MY_POD_NAME"}
+	kubernetesNamespaceEnv   = []string{"NEW_RELIC_METADATA_KUBERNETES_NAMESPACE_NAME", "POD_NAMESPACE", "MY_POD_NAMESPACE"}
+	kubernetesDeploymentEnv  = []string{"NEW_RELIC_METADATA_KUBERNETES_DEPLOYMENT_NAME"}
+	kubernetesNodeNameEnv    = []string{"NEW_RELIC_METADATA_KUBERNETES_NODE_NAME", "NODE_NAME", "MY_NODE_NAME"}
+	kubernetesClusterNameEnv = []string{"NEW_RELIC_METADATA_KUBERNETES_CLUSTER_NAME", "CLUSTER_NAME"}
+)
+
+// replicaSetPodName matches the names of the pods of a Deployment, which are
+// the name of the Deployment followed by the hash of its pod template and a
+// random suffix, both using the alphabet of Kubernetes generated names.
+var replicaSetPodName = regexp.MustCompile(`^(.+)-[bcdfghjklmnpqrstvwxz2456789]{6,10}-[bcdfghjklmnpqrstvwxz2456789]{5}$`)
+
+// GetKubernetesMetadata reads the metadata of the Kubernetes pod running the
+// process from environment variables, which are set using the Downward API,
+// and from the service account files mounted in the pod.  The pod name
+// defaults to the hostname of the pod, and the deployment is derived from the
+// pod name.  The cluster name is only known if it is set in the environment.
+func GetKubernetesMetadata(getenv func(string) string) (KubernetesMetadata, error) {
+	return readKubernetesMetadata(getenv, kubernetesNamespaceFile)
+}
+
+func readKubernetesMetadata(getenv func(string) string, namespaceFile string) (KubernetesMetadata, error) {
+	if getenv("KUBERNETES_SERVICE_HOST") == "" {
+		return KubernetesMetadata{}, ErrKubernetesNotFound
+	}
+	md := KubernetesMetadata{
+		PodName:     firstEnv(getenv, kubernetesPodNameEnv),
+		Namespace:   firstEnv(getenv, kubernetesNamespaceEnv),
+		Deployment:  firstEnv(getenv, kubernetesDeploymentEnv),
+		NodeName:    firstEnv(getenv, kubernetesNodeNameEnv),
+		ClusterName: firstEnv(getenv, kubernetesClusterNameEnv),
+	}
+	if md.PodName == "" {
+		md.PodName = getenv("HOSTNAME")
+	}
+	if md.Namespace == "" {
+		if b, err := os.ReadFile(namespaceFile); err == nil {
+			md.Namespace = strings.TrimSpace(string(b))
+		}
+	}
+	if md.Deployment == "" {
+		if m := replicaSetPodName.FindStringSubmatch(md.PodName); m != nil {
+			md.Deployment = m[1]
+		}
+	}
+	return md, nil
+}
+
+func firstEnv(getenv func(string) string, names []string) string {
+	for _, name := range names {
+		if v := strings.TrimSpace(getenv(name)); v != "" {
+			return v
+		}
+	}
+	return ""
+}
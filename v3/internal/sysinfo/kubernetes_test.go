@@ -0,0 +1,62 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package sysinfo
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadKubernetesMetadata(t *testing.T) {
+	namespaceFile := filepath.Join(t.TempDir(), "namespace")
+	if err := os.WriteFile(namespaceFile, []byte("payments\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		env  map[string]string
+		want KubernetesMetadata
+	}{
+		{
+			env: map[string]string{
+				"KUBERNETES_SERVICE_HOST": "10.0.0.1",
+				"HOSTNAME":                "checkout-7d4b9c6f8d-x2x7q",
+				"NODE_NAME":               "node-1",
+			},
+			want: KubernetesMetadata{PodName: "checkout-7d4b9c6f8d-x2x7q", Namespace: "payments", Deployment: "checkout", NodeName: "node-1"},
+		},
+		{
+			env: map[string]string{
+				"KUBERNETES_SERVICE_HOST":                "10.0.0.1",
+				"HOSTNAME":                               "ignored",
+				"POD_NAME":                               "ignored",
+				"NEW_RELIC_METADATA_KUBERNETES_POD_NAME": "worker-0",
+				"NEW_RELIC_METADATA_KUBERNETES_NAMESPACE_NAME":  "batch",
+				"NEW_RELIC_METADATA_KUBERNETES_DEPLOYMENT_NAME": "worker",
+				"NEW_RELIC_METADATA_KUBERNETES_NODE_NAME":       "node-2",
+				"NEW_RELIC_METADATA_KUBERNETES_CLUSTER_NAME":    "prod",
+			},
+			want: KubernetesMetadata{PodName: "worker-0", Namespace: "batch", Deployment: "worker", NodeName: "node-2", ClusterName: "prod"},
+		},
+		{
+			// The pods of StatefulSets have no deployment.
+			env: map[string]string{
+				"KUBERNETES_SERVICE_HOST": "10.0.0.1",
+				"POD_NAME":                "database-0",
+				"POD_NAMESPACE":           "storage",
+			},
+			want: KubernetesMetadata{PodName: "database-0", Namespace: "storage"},
+		},
+	} {
+		got, err := readKubernetesMetadata(func(name string) string { return tc.env[name] }, namespaceFile)
+		if err != nil || got != tc.want {
+			t.Errorf("incorrect metadata for %v: %+v %v", tc.env, got, err)
+		}
+	}
+
+	_, err := readKubernetesMetadata(func(string) string { return "" }, namespaceFile)
+	if err != ErrKubernetesNotFound {
+		t.Error("incorrect error outside of Kubernetes:", err)
+	}
+}
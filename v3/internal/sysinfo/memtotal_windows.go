@@ -8,6 +8,19 @@ import (
 	"unsafe"
 )
 
+// memoryStatusEx is the MEMORYSTATUSEX structure.
+type memoryStatusEx struct {
+	Length               uint32
+	MemoryLoad           uint32
+	TotalPhys            uint64
+	AvailPhys            uint64
+	TotalPageFile        uint64
+	AvailPageFile        uint64
+	TotalVirtual         uint64
+	AvailVirtual         uint64
+	AvailExtendedVirtual uint64
+}
+
 // PhysicalMemoryBytes returns the total amount of host memory.
 func PhysicalMemoryBytes() (uint64, error) {
 	// https://msdn.microsoft.com/en-us/library/windows/desktop/cc300158(v=vs.85).aspx
@@ -16,11 +29,22 @@ func PhysicalMemoryBytes() (uint64, error) {
 	proc := mod.NewProc("GetPhysicallyInstalledSystemMemory")
 	var memkb uint64
 
-	ret, _, err := proc.Call(uintptr(unsafe.Pointer(&memkb)))
+	ret, _, _ := proc.Call(uintptr(unsafe.Pointer(&memkb)))
 	// return value TRUE(1) succeeds, FAILED(0) fails
-	if ret != 1 {
-		return 0, err
+	if ret == 1 {
+		return memkb * 1024, nil
 	}
 
-	return memkb * 1024, nil
+	// GetPhysicallyInstalledSystemMemory reads the SMBIOS tables, which
+	// are not available in Windows containers and in some virtual
+	// machines.  The memory usable by the operating system is used
+	// instead.
+	// https://learn.microsoft.com/en-us/windows/win32/api/sysinfoapi/nf-sysinfoapi-globalmemorystatusex
+	status := memoryStatusEx{}
+	status.Length = uint32(unsafe.Sizeof(status))
+	ret, _, err := mod.NewProc("GlobalMemoryStatusEx").Call(uintptr(unsafe.Pointer(&status)))
+	if ret == 0 {
+		return 0, err
+	}
+	return status.TotalPhys, nil
 }
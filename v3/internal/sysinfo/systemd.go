@@ -0,0 +1,76 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package sysinfo
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+)
+
+var (
+	// ErrSystemdNotFound is returned if the process is not run by a
+	// systemd unit.
+	ErrSystemdNotFound = errors.New("systemd unit not found")
+)
+
+// SystemdUnit describes the systemd unit running the process.
+type SystemdUnit struct {
+	// Name is the name of the unit, such as "checkout.service".
+	Name string
+	// InvocationID identifies the run of the unit.
+	InvocationID string
+}
+
+// GetSystemdUnit detects the systemd unit running the process.  systemd sets
+// the INVOCATION_ID environment variable of the processes of units, and
+// places them in a control group named after the unit.
+func GetSystemdUnit(getenv func(string) string) (SystemdUnit, error) {
+	if "linux" != runtime.GOOS {
+		return SystemdUnit{}, ErrFeatureUnsupported
+	}
+	id := getenv("INVOCATION_ID")
+	if id == "" {
+		return SystemdUnit{}, ErrSystemdNotFound
+	}
+	f, err := os.Open("/proc/self/cgroup")
+	if err != nil {
+		return SystemdUnit{}, err
+	}
+	defer f.Close()
+	return SystemdUnit{Name: parseSystemdUnit(f), InvocationID: id}, nil
+}
+
+// systemdUnitSuffixes are the suffixes of the units which run processes.
+var systemdUnitSuffixes = []string{".service", ".scope"}
+
+// parseSystemdUnit returns the unit of the control group of the unified (v2)
+// hierarchy or of the systemd (v1) hierarchy, found in the
+// /proc/self/cgroup file, or "" if it is not found.
+//
+// Examples
+//
+//	0::/system.slice/checkout.service
+//	1:name=systemd:/user.slice/user-1000.slice/user@1000.service/app.slice/checkout.service
+func parseSystemdUnit(r io.Reader) string {
+	for scanner := bufio.NewScanner(r); scanner.Scan(); {
+		cols := strings.SplitN(scanner.Text(), ":", 3)
+		if len(cols) < 3 || (cols[1] != "" && cols[1] != "name=systemd") {
+			continue
+		}
+		// The innermost unit is the last element of the path.
+		elems := strings.Split(cols[2], "/")
+		for i := len(elems) - 1; i >= 0; i-- {
+			for _, suffix := range systemdUnitSuffixes {
+				if strings.HasSuffix(elems[i], suffix) {
+					return elems[i]
+				}
+			}
+		}
+	}
+	return ""
+}
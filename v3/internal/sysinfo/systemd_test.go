@@ -0,0 +1,38 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package sysinfo
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestParseSystemdUnit(t *testing.T) {
+	for _, tc := range []struct {
+		cgroup string
+		want   string
+	}{
+		{cgroup: "0::/system.slice/checkout.service\n", want: "checkout.service"},
+		{cgroup: "0::/user.slice/user-1000.slice/user@1000.service/app.slice/checkout.service", want: "checkout.service"},
+		{cgroup: "12:cpu,cpuacct:/docker/abc\n1:name=systemd:/system.slice/run-r1234.scope\n", want: "run-r1234.scope"},
+		{cgroup: "0::/system.slice/checkout.service/worker", want: "checkout.service"},
+		{cgroup: "0::/\n", want: ""},
+		{cgroup: "12:cpu,cpuacct:/system.slice/other.service\n", want: ""},
+	} {
+		if got := parseSystemdUnit(strings.NewReader(tc.cgroup)); got != tc.want {
+			t.Errorf("incorrect unit for %q: %q, want %q", tc.cgroup, got, tc.want)
+		}
+	}
+}
+
+func TestGetSystemdUnitWithoutInvocationID(t *testing.T) {
+	_, err := GetSystemdUnit(func(string) string { return "" })
+	if runtime.GOOS == "linux" && err != ErrSystemdNotFound {
+		t.Error("incorrect error:", err)
+	}
+	if runtime.GOOS != "linux" && err != ErrFeatureUnsupported {
+		t.Error("incorrect error:", err)
+	}
+}
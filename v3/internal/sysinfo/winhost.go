@@ -0,0 +1,25 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package sysinfo
+
+// WindowsHost describes how the process is hosted on Windows.
+type WindowsHost struct {
+	// SessionID is the Remote Desktop Services session of the process.
+	// Services run in session 0.
+	SessionID uint32
+	// ServiceName is the name of the Windows service run by the process,
+	// if any.
+	ServiceName string
+	// IISAppPool is the IIS application pool of the process, if it is
+	// hosted by IIS, for example using the HttpPlatformHandler module.
+	IISAppPool string
+	// Container is true if the process runs in a Windows container.
+	Container bool
+}
+
+// iisAppPool returns the IIS application pool, which IIS sets in the
+// environment of worker processes and of the processes they start.
+func iisAppPool(getenv func(string) string) string {
+	return getenv("APP_POOL_ID")
+}
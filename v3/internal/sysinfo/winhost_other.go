@@ -0,0 +1,11 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !windows
+
+package sysinfo
+
+// GetWindowsHost describes how the process is hosted on Windows.
+func GetWindowsHost() (WindowsHost, error) {
+	return WindowsHost{}, ErrFeatureUnsupported
+}
@@ -0,0 +1,30 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package sysinfo
+
+import (
+	"runtime"
+	"testing"
+)
+
+func TestIISAppPool(t *testing.T) {
+	env := map[string]string{"APP_POOL_ID": "DefaultAppPool"}
+	if pool := iisAppPool(func(key string) string { return env[key] }); pool != "DefaultAppPool" {
+		t.Error("incorrect application pool:", pool)
+	}
+	if pool := iisAppPool(func(string) string { return "" }); pool != "" {
+		t.Error("incorrect application pool outside IIS:", pool)
+	}
+}
+
+func TestGetWindowsHost(t *testing.T) {
+	_, err := GetWindowsHost()
+	if runtime.GOOS == "windows" {
+		if err != nil {
+			t.Error(err)
+		}
+	} else if err != ErrFeatureUnsupported {
+		t.Error("incorrect error:", err)
+	}
+}
@@ -0,0 +1,129 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package sysinfo
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	modadvapi32               = syscall.NewLazyDLL("advapi32.dll")
+	procOpenSCManagerW        = modadvapi32.NewProc("OpenSCManagerW")
+	procEnumServicesStatusExW = modadvapi32.NewProc("EnumServicesStatusExW")
+	procCloseServiceHandle    = modadvapi32.NewProc("CloseServiceHandle")
+	procProcessIDToSessionID  = syscall.NewLazyDLL("kernel32.dll").NewProc("ProcessIdToSessionId")
+)
+
+const (
+	scManagerEnumerateService = 0x0004
+	scEnumProcessInfo         = 0
+	serviceWin32              = 0x30
+	serviceActive             = 0x1
+	errorMoreData             = syscall.Errno(234)
+)
+
+// enumServiceStatusProcess is the ENUM_SERVICE_STATUS_PROCESSW structure.
+type enumServiceStatusProcess struct {
+	ServiceName          *uint16
+	DisplayName          *uint16
+	ServiceType          uint32
+	CurrentState         uint32
+	ControlsAccepted     uint32
+	Win32ExitCode        uint32
+	ServiceSpecificError uint32
+	CheckPoint           uint32
+	WaitHint             uint32
+	ProcessID            uint32
+	ServiceFlags         uint32
+}
+
+// GetWindowsHost describes how the process is hosted on Windows.
+func GetWindowsHost() (WindowsHost, error) {
+	pid := uint32(os.Getpid())
+	host := WindowsHost{
+		IISAppPool: iisAppPool(os.Getenv),
+		Container:  isWindowsContainer(),
+	}
+
+	// https://learn.microsoft.com/en-us/windows/win32/api/processthreadsapi/nf-processthreadsapi-processidtosessionid
+	ret, _, err := procProcessIDToSessionID.Call(uintptr(pid), uintptr(unsafe.Pointer(&host.SessionID)))
+	if ret == 0 {
+		return host, err
+	}
+	if host.SessionID == 0 {
+		name, err := serviceName(pid)
+		if err != nil {
+			return host, err
+		}
+		host.ServiceName = name
+	}
+	return host, nil
+}
+
+// serviceName returns the name of the active service run by the process, or
+// "" if the process does not run a service.
+func serviceName(pid uint32) (string, error) {
+	// https://learn.microsoft.com/en-us/windows/win32/api/winsvc/nf-winsvc-enumservicesstatusexw
+	h, _, err := procOpenSCManagerW.Call(0, 0, scManagerEnumerateService)
+	if h == 0 {
+		return "", err
+	}
+	defer procCloseServiceHandle.Call(h)
+
+	var needed, returned, resume uint32
+	buf := make([]byte, 64*1024)
+	for {
+		ret, _, err := procEnumServicesStatusExW.Call(h, scEnumProcessInfo, serviceWin32, serviceActive,
+			uintptr(unsafe.Pointer(&buf[0])), uintptr(len(buf)),
+			uintptr(unsafe.Pointer(&needed)), uintptr(unsafe.Pointer(&returned)),
+			uintptr(unsafe.Pointer(&resume)), 0)
+		if ret == 0 && err != errorMoreData {
+			return "", err
+		}
+		services := unsafe.Slice((*enumServiceStatusProcess)(unsafe.Pointer(&buf[0])), returned)
+		for _, s := range services {
+			if s.ProcessID == pid {
+				return utf16PtrToString(s.ServiceName), nil
+			}
+		}
+		if ret != 0 {
+			return "", nil
+		}
+		if int(needed) > len(buf) {
+			buf = make([]byte, needed)
+		}
+	}
+}
+
+// utf16PtrToString converts a NUL-terminated UTF-16 string.
+func utf16PtrToString(p *uint16) string {
+	if p == nil {
+		return ""
+	}
+	n := 0
+	for ptr := unsafe.Pointer(p); *(*uint16)(ptr) != 0; ptr = unsafe.Add(ptr, 2) {
+		n++
+	}
+	return syscall.UTF16ToString(unsafe.Slice(p, n))
+}
+
+// isWindowsContainer returns whether the process runs in a Windows container,
+// in which the ContainerType registry value is set.
+func isWindowsContainer() bool {
+	var key syscall.Handle
+	path, _ := syscall.UTF16PtrFromString(`SYSTEM\CurrentControlSet\Control`)
+	if err := syscall.RegOpenKeyEx(syscall.HKEY_LOCAL_MACHINE, path, 0, syscall.KEY_READ, &key); err != nil {
+		return false
+	}
+	defer syscall.RegCloseKey(key)
+
+	name, _ := syscall.UTF16PtrFromString("ContainerType")
+	var typ, value, size uint32 = 0, 0, 4
+	if err := syscall.RegQueryValueEx(key, name, nil, &typ, (*byte)(unsafe.Pointer(&value)), &size); err != nil {
+		return false
+	}
+	return typ == syscall.REG_DWORD && value != 0
+}
@@ -0,0 +1,168 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package utilization
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Azure Container Apps and Azure Functions do not provide the instance
+// metadata service, and are detected using the environment variables which
+// they set in their containers.
+
+type azureContainerApps struct {
+	Name     string `json:"name,omitempty"`
+	Revision string `json:"revision,omitempty"`
+	Replica  string `json:"replica,omitempty"`
+	Location string `json:"location,omitempty"`
+}
+
+type azureFunctions struct {
+	AppName        string `json:"appName,omitempty"`
+	SubscriptionID string `json:"subscriptionId,omitempty"`
+	ResourceGroup  string `json:"resourceGroup,omitempty"`
+	Location       string `json:"location,omitempty"`
+	InstanceID     string `json:"instanceId,omitempty"`
+}
+
+var (
+	errNoAzureContainerAppsVariables = errors.New("no Azure Container Apps environment variables present")
+	errNoAzureFunctionsVariables     = errors.New("no Azure Functions environment variables present")
+)
+
+func gatherAzureServerless(v *vendors, getenv func(string) string, warn func(string, error)) {
+	if aca, err := getAzureContainerApps(getenv); err == nil {
+		v.AzureContainerApps = aca
+	} else if err != errNoAzureContainerAppsVariables {
+		warn("azure container apps", err)
+	}
+	if af, err := getAzureFunctions(getenv); err == nil {
+		v.AzureFunctions = af
+	} else if err != errNoAzureFunctionsVariables {
+		warn("azure functions", err)
+	}
+}
+
+func getAzureContainerApps(getenv func(string) string) (*azureContainerApps, error) {
+	aca := &azureContainerApps{
+		Name:     getenv("CONTAINER_APP_NAME"),
+		Revision: getenv("CONTAINER_APP_REVISION"),
+		Replica:  getenv("CONTAINER_APP_REPLICA_NAME"),
+		Location: containerAppsLocation(getenv("CONTAINER_APP_ENV_DNS_SUFFIX")),
+	}
+	if aca.Name == "" {
+		return nil, errNoAzureContainerAppsVariables
+	}
+	if err := aca.validate(); err != nil {
+		return nil, err
+	}
+	return aca, nil
+}
+
+// containerAppsLocation returns the location of a Container Apps
+// environment from its DNS suffix, as in
+// "happyhill-70162bb9.eastus.azurecontainerapps.io".
+func containerAppsLocation(suffix string) string {
+	labels := strings.Split(strings.TrimSuffix(suffix, "."), ".")
+	if len(labels) < 3 || labels[len(labels)-2] != "azurecontainerapps" {
+		return ""
+	}
+	return labels[len(labels)-3]
+}
+
+func getAzureFunctions(getenv func(string) string) (*azureFunctions, error) {
+	// Go applications run on Azure Functions as custom handlers, which
+	// listen on the port given by the Functions host.
+	if getenv("FUNCTIONS_CUSTOMHANDLER_PORT") == "" || getenv("WEBSITE_SITE_NAME") == "" {
+		return nil, errNoAzureFunctionsVariables
+	}
+	af := &azureFunctions{
+		AppName:       getenv("WEBSITE_SITE_NAME"),
+		ResourceGroup: getenv("WEBSITE_RESOURCE_GROUP"),
+		Location:      strings.ToLower(strings.ReplaceAll(getenv("REGION_NAME"), " ", "")),
+		InstanceID:    getenv("WEBSITE_INSTANCE_ID"),
+	}
+	// The owner name is "{subscription}+{resource group}-{location}webspace",
+	// followed by "-Linux" for Linux plans.
+	if owner := getenv("WEBSITE_OWNER_NAME"); owner != "" {
+		if i := strings.Index(owner, "+"); i > 0 {
+			af.SubscriptionID = owner[:i]
+			if af.ResourceGroup == "" {
+				webspace := strings.TrimSuffix(strings.TrimSuffix(owner[i+1:], "-Linux"), "webspace")
+				if j := strings.LastIndex(webspace, "-"); j > 0 {
+					af.ResourceGroup = webspace[:j]
+				}
+			}
+		}
+	}
+	if err := af.validate(); err != nil {
+		return nil, err
+	}
+	return af, nil
+}
+
+// resourceID returns the Azure resource ID of the function app, or "" if
+// the subscription or the resource group is unknown.
+func (af *azureFunctions) resourceID() string {
+	if af.SubscriptionID == "" || af.ResourceGroup == "" {
+		return ""
+	}
+	return fmt.Sprintf("/subscriptions/%s/resourceGroups/%s/providers/Microsoft.Web/sites/%s",
+		af.SubscriptionID, af.ResourceGroup, af.AppName)
+}
+
+func (aca *azureContainerApps) validate() (err error) {
+	aca.Name, err = normalizeValue(aca.Name)
+	if err != nil {
+		return fmt.Errorf("Invalid name: %v", err)
+	}
+
+	aca.Revision, err = normalizeValue(aca.Revision)
+	if err != nil {
+		return fmt.Errorf("Invalid revision: %v", err)
+	}
+
+	aca.Replica, err = normalizeValue(aca.Replica)
+	if err != nil {
+		return fmt.Errorf("Invalid replica: %v", err)
+	}
+
+	aca.Location, err = normalizeValue(aca.Location)
+	if err != nil {
+		return fmt.Errorf("Invalid location: %v", err)
+	}
+
+	return
+}
+
+func (af *azureFunctions) validate() (err error) {
+	af.AppName, err = normalizeValue(af.AppName)
+	if err != nil {
+		return fmt.Errorf("Invalid app name: %v", err)
+	}
+
+	af.SubscriptionID, err = normalizeValue(af.SubscriptionID)
+	if err != nil {
+		return fmt.Errorf("Invalid subscription ID: %v", err)
+	}
+
+	af.ResourceGroup, err = normalizeValue(af.ResourceGroup)
+	if err != nil {
+		return fmt.Errorf("Invalid resource group: %v", err)
+	}
+
+	af.Location, err = normalizeValue(af.Location)
+	if err != nil {
+		return fmt.Errorf("Invalid location: %v", err)
+	}
+
+	af.InstanceID, err = normalizeValue(af.InstanceID)
+	if err != nil {
+		return fmt.Errorf("Invalid instance ID: %v", err)
+	}
+
+	return
+}
@@ -0,0 +1,97 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package utilization
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestGatherAzureServerless(t *testing.T) {
+	for _, tc := range []struct {
+		env      map[string]string
+		vendors  string
+		platform CloudPlatform
+	}{
+		{
+			env: map[string]string{
+				"CONTAINER_APP_NAME":           "checkout",
+				"CONTAINER_APP_REVISION":       "checkout--v42",
+				"CONTAINER_APP_REPLICA_NAME":   "checkout--v42-5d9f8c7b6-abcde",
+				"CONTAINER_APP_ENV_DNS_SUFFIX": "happyhill-70162bb9.eastus.azurecontainerapps.io",
+			},
+			vendors: `{"azure_container_apps":{"name":"checkout","revision":"checkout--v42",` +
+				`"replica":"checkout--v42-5d9f8c7b6-abcde","location":"eastus"}}`,
+			platform: CloudPlatform{
+				Provider:     "azure",
+				Platform:     "azure_container_apps",
+				Region:       "eastus",
+				FaasName:     "checkout",
+				FaasVersion:  "checkout--v42",
+				FaasInstance: "checkout--v42-5d9f8c7b6-abcde",
+			},
+		},
+		{
+			env: map[string]string{
+				"FUNCTIONS_CUSTOMHANDLER_PORT": "34567",
+				"WEBSITE_SITE_NAME":            "orders-func",
+				"WEBSITE_OWNER_NAME":           "a1b2c3d4-0000-1111-2222-333344445555+orders-rg-EastUSwebspace-Linux",
+				"REGION_NAME":                  "East US",
+				"WEBSITE_INSTANCE_ID":          "f0e1d2c3",
+			},
+			vendors: `{"azure_functions":{"appName":"orders-func","subscriptionId":"a1b2c3d4-0000-1111-2222-333344445555",` +
+				`"resourceGroup":"orders-rg","location":"eastus","instanceId":"f0e1d2c3"}}`,
+			platform: CloudPlatform{
+				Provider:     "azure",
+				Platform:     "azure_functions",
+				Region:       "eastus",
+				AccountID:    "a1b2c3d4-0000-1111-2222-333344445555",
+				ResourceID:   "/subscriptions/a1b2c3d4-0000-1111-2222-333344445555/resourceGroups/orders-rg/providers/Microsoft.Web/sites/orders-func",
+				FaasName:     "orders-func",
+				FaasInstance: "f0e1d2c3",
+			},
+		},
+		{
+			// App Service sets the same variables, but does not run
+			// custom handlers.
+			env: map[string]string{
+				"WEBSITE_SITE_NAME":  "orders-web",
+				"WEBSITE_OWNER_NAME": "a1b2c3d4-0000-1111-2222-333344445555+orders-rg-EastUSwebspace",
+			},
+			vendors: `{}`,
+		},
+	} {
+		getenv := func(key string) string { return tc.env[key] }
+		v := &vendors{}
+		gatherAzureServerless(v, getenv, func(datatype string, err error) {
+			t.Errorf("unexpected %s error: %v", datatype, err)
+		})
+		js, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(js) != tc.vendors {
+			t.Errorf("incorrect vendors:\n%s\n%s", js, tc.vendors)
+		}
+		if p := GetCloudPlatform(Config{DetectAzure: true}, getenv); p != tc.platform {
+			t.Errorf("incorrect platform: %+v", p)
+		}
+	}
+}
+
+func TestGatherAzureServerlessInvalid(t *testing.T) {
+	v := &vendors{}
+	var warned []string
+	gatherAzureServerless(v, func(key string) string {
+		if key == "CONTAINER_APP_NAME" {
+			return "checkout\x00"
+		}
+		return ""
+	}, func(datatype string, err error) {
+		warned = append(warned, datatype)
+	})
+	if v.AzureContainerApps != nil || len(warned) != 1 || warned[0] != "azure container apps" {
+		t.Errorf("incorrect handling of invalid name: %+v %v", v.AzureContainerApps, warned)
+	}
+}
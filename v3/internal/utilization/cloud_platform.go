@@ -0,0 +1,70 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package utilization
+
+// CloudPlatform describes the serverless container platform running the
+// process, using the values of the cloud and faas resource attributes of
+// OpenTelemetry.  Fields which are unknown are empty.
+type CloudPlatform struct {
+	// Provider is "gcp" or "azure".
+	Provider string
+	// Platform is "gcp_cloud_run", "azure_container_apps", or
+	// "azure_functions".
+	Platform   string
+	Region     string
+	AccountID  string
+	ResourceID string
+	// FaasName, FaasVersion, and FaasInstance are the service, the
+	// revision, and the replica running the process.
+	FaasName     string
+	FaasVersion  string
+	FaasInstance string
+}
+
+// GetCloudPlatform detects Cloud Run when config.DetectGCP is true, and Azure
+// Container Apps and Azure Functions custom handlers when config.DetectAzure
+// is true, using the environment variables of their containers.  The region
+// and the project of Cloud Run are only available from the metadata server,
+// and so are only part of the utilization data.
+func GetCloudPlatform(config Config, getenv func(string) string) CloudPlatform {
+	if config.DetectGCP {
+		if cr, err := getCloudRunFromEnv(getenv); err == nil {
+			p := CloudPlatform{
+				Provider:    "gcp",
+				Platform:    "gcp_cloud_run",
+				FaasName:    cr.Service,
+				FaasVersion: cr.Revision,
+			}
+			if cr.Service == "" {
+				p.FaasName = cr.Job
+				p.FaasVersion = cr.Execution
+			}
+			return p
+		}
+	}
+	if config.DetectAzure {
+		if aca, err := getAzureContainerApps(getenv); err == nil {
+			return CloudPlatform{
+				Provider:     "azure",
+				Platform:     "azure_container_apps",
+				Region:       aca.Location,
+				FaasName:     aca.Name,
+				FaasVersion:  aca.Revision,
+				FaasInstance: aca.Replica,
+			}
+		}
+		if af, err := getAzureFunctions(getenv); err == nil {
+			return CloudPlatform{
+				Provider:     "azure",
+				Platform:     "azure_functions",
+				Region:       af.Location,
+				AccountID:    af.SubscriptionID,
+				ResourceID:   af.resourceID(),
+				FaasName:     af.AppName,
+				FaasInstance: af.InstanceID,
+			}
+		}
+	}
+	return CloudPlatform{}
+}
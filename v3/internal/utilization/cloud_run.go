@@ -0,0 +1,161 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package utilization
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// gcpMetadataEndpoint is the root of the metadata server, which Cloud Run
+// provides to its containers like Compute Engine.
+const gcpMetadataEndpoint = "http://" + gcpHostname + "/computeMetadata/v1"
+
+// cloudRun describes a Cloud Run service or job.  Cloud Run sets the K_
+// variables in the containers of services and the CLOUD_RUN_ variables in
+// the containers of jobs.
+type cloudRun struct {
+	Service       string `json:"service,omitempty"`
+	Revision      string `json:"revision,omitempty"`
+	Configuration string `json:"configuration,omitempty"`
+	Job           string `json:"job,omitempty"`
+	Execution     string `json:"execution,omitempty"`
+	TaskIndex     string `json:"taskIndex,omitempty"`
+	ProjectID     string `json:"projectId,omitempty"`
+	Region        string `json:"region,omitempty"`
+}
+
+func gatherCloudRun(util *Data, client *http.Client) error {
+	cr, err := getCloudRun(client, os.Getenv, gcpMetadataEndpoint)
+	// The service or the job is reported even if the metadata server
+	// cannot be reached.
+	if cr != nil {
+		util.Vendors.CloudRun = cr
+	}
+	if _, ok := err.(unexpectedCloudRunErr); ok {
+		return err
+	}
+	return nil
+}
+
+type unexpectedCloudRunErr struct{ e error }
+
+func (e unexpectedCloudRunErr) Error() string {
+	return fmt.Sprintf("unexpected Cloud Run error: %v", e.e)
+}
+
+var (
+	errNoCloudRunVariables = errors.New("no Cloud Run environment variables present")
+)
+
+func getCloudRunFromEnv(getenv func(string) string) (*cloudRun, error) {
+	cr := &cloudRun{
+		Service:       getenv("K_SERVICE"),
+		Revision:      getenv("K_REVISION"),
+		Configuration: getenv("K_CONFIGURATION"),
+		Job:           getenv("CLOUD_RUN_JOB"),
+		Execution:     getenv("CLOUD_RUN_EXECUTION"),
+		TaskIndex:     getenv("CLOUD_RUN_TASK_INDEX"),
+	}
+	if cr.Service == "" && cr.Job == "" {
+		return nil, errNoCloudRunVariables
+	}
+	if err := cr.validate(); err != nil {
+		return nil, unexpectedCloudRunErr{e: err}
+	}
+	return cr, nil
+}
+
+func getCloudRun(client *http.Client, getenv func(string) string, endpoint string) (*cloudRun, error) {
+	cr, err := getCloudRunFromEnv(getenv)
+	if err != nil {
+		return nil, err
+	}
+
+	// The region is given as "projects/123456789012/regions/us-central1".
+	region, err := getGCPMetadata(client, endpoint+"/instance/region")
+	if err != nil {
+		return cr, err
+	}
+	projectID, err := getGCPMetadata(client, endpoint+"/project/project-id")
+	if err != nil {
+		return cr, err
+	}
+	if cr.Region, err = normalizeValue(stripGCPPrefix(region)); err != nil {
+		return cr, unexpectedCloudRunErr{e: fmt.Errorf("Invalid region: %v", err)}
+	}
+	if cr.ProjectID, err = normalizeValue(projectID); err != nil {
+		return cr, unexpectedCloudRunErr{e: fmt.Errorf("Invalid project ID: %v", err)}
+	}
+	return cr, nil
+}
+
+func getGCPMetadata(client *http.Client, url string) (value string, err error) {
+	// As with the other metadata endpoints, a panic in net/http caused by
+	// blocked requests is recovered.
+	defer func() {
+		if r := recover(); r != nil {
+			err = unexpectedCloudRunErr{e: errors.New("panic contacting GCP metadata server")}
+		}
+	}()
+
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Add("Metadata-Flavor", "Google")
+
+	response, err := client.Do(req)
+	if err != nil {
+		return "", unexpectedCloudRunErr{e: err}
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != 200 {
+		return "", unexpectedCloudRunErr{e: fmt.Errorf("response code %d", response.StatusCode)}
+	}
+	data, err := io.ReadAll(response.Body)
+	if err != nil {
+		return "", unexpectedCloudRunErr{e: err}
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+func (cr *cloudRun) validate() (err error) {
+	cr.Service, err = normalizeValue(cr.Service)
+	if err != nil {
+		return fmt.Errorf("Invalid service: %v", err)
+	}
+
+	cr.Revision, err = normalizeValue(cr.Revision)
+	if err != nil {
+		return fmt.Errorf("Invalid revision: %v", err)
+	}
+
+	cr.Configuration, err = normalizeValue(cr.Configuration)
+	if err != nil {
+		return fmt.Errorf("Invalid configuration: %v", err)
+	}
+
+	cr.Job, err = normalizeValue(cr.Job)
+	if err != nil {
+		return fmt.Errorf("Invalid job: %v", err)
+	}
+
+	cr.Execution, err = normalizeValue(cr.Execution)
+	if err != nil {
+		return fmt.Errorf("Invalid execution: %v", err)
+	}
+
+	cr.TaskIndex, err = normalizeValue(cr.TaskIndex)
+	if err != nil {
+		return fmt.Errorf("Invalid task index: %v", err)
+	}
+
+	return
+}
@@ -0,0 +1,92 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package utilization
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func cloudRunMetadataServer(status int) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/computeMetadata/v1/instance/region", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Metadata-Flavor") != "Google" {
+			w.WriteHeader(403)
+			return
+		}
+		w.WriteHeader(status)
+		w.Write([]byte("projects/123456789012/regions/us-central1"))
+	})
+	mux.HandleFunc("/computeMetadata/v1/project/project-id", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		w.Write([]byte("my-project"))
+	})
+	return httptest.NewServer(mux)
+}
+
+func cloudRunServiceEnv(key string) string {
+	return map[string]string{
+		"K_SERVICE":       "checkout",
+		"K_REVISION":      "checkout-00042-xyz",
+		"K_CONFIGURATION": "checkout",
+	}[key]
+}
+
+func TestGetCloudRun(t *testing.T) {
+	srv := cloudRunMetadataServer(200)
+	defer srv.Close()
+
+	cr, err := getCloudRun(srv.Client(), cloudRunServiceEnv, srv.URL+"/computeMetadata/v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	js, err := json.Marshal(cr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := `{"service":"checkout","revision":"checkout-00042-xyz","configuration":"checkout",` +
+		`"projectId":"my-project","region":"us-central1"}`
+	if string(js) != expect {
+		t.Errorf("incorrect Cloud Run data:\n%s\n%s", js, expect)
+	}
+
+	p := GetCloudPlatform(Config{DetectGCP: true}, cloudRunServiceEnv)
+	if p != (CloudPlatform{Provider: "gcp", Platform: "gcp_cloud_run", FaasName: "checkout", FaasVersion: "checkout-00042-xyz"}) {
+		t.Errorf("incorrect platform: %+v", p)
+	}
+	if p := GetCloudPlatform(Config{}, cloudRunServiceEnv); p != (CloudPlatform{}) {
+		t.Errorf("platform detected when disabled: %+v", p)
+	}
+}
+
+func TestGetCloudRunJob(t *testing.T) {
+	env := map[string]string{
+		"CLOUD_RUN_JOB":        "nightly-export",
+		"CLOUD_RUN_EXECUTION":  "nightly-export-abc12",
+		"CLOUD_RUN_TASK_INDEX": "3",
+	}
+	p := GetCloudPlatform(Config{DetectGCP: true}, func(key string) string { return env[key] })
+	if p != (CloudPlatform{Provider: "gcp", Platform: "gcp_cloud_run", FaasName: "nightly-export", FaasVersion: "nightly-export-abc12"}) {
+		t.Errorf("incorrect platform: %+v", p)
+	}
+}
+
+func TestGetCloudRunErrors(t *testing.T) {
+	if _, err := getCloudRun(http.DefaultClient, func(string) string { return "" }, gcpMetadataEndpoint); err != errNoCloudRunVariables {
+		t.Error("incorrect error without Cloud Run:", err)
+	}
+
+	// The service is known even if the metadata server fails.
+	srv := cloudRunMetadataServer(500)
+	defer srv.Close()
+	cr, err := getCloudRun(srv.Client(), cloudRunServiceEnv, srv.URL+"/computeMetadata/v1")
+	if _, ok := err.(unexpectedCloudRunErr); !ok {
+		t.Error("incorrect error for failing metadata server:", err)
+	}
+	if cr == nil || cr.Service != "checkout" || cr.Region != "" {
+		t.Errorf("incorrect Cloud Run data: %+v", cr)
+	}
+}
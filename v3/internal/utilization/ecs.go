@@ -0,0 +1,172 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package utilization
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// ecsMetadataEnv holds the URI of the version 4 task metadata endpoint, which
+// ECS sets in the containers of tasks using the EC2 and the Fargate launch
+// types.
+const ecsMetadataEnv = "ECS_CONTAINER_METADATA_URI_V4"
+
+type ecs struct {
+	DockerID           string  `json:"ecsDockerId,omitempty"`
+	TaskARN            string  `json:"ecsTaskArn,omitempty"`
+	Cluster            string  `json:"ecsCluster,omitempty"`
+	LaunchType         string  `json:"ecsLaunchType,omitempty"`
+	AvailabilityZone   string  `json:"availabilityZone,omitempty"`
+	TaskCPULimit       float64 `json:"ecsTaskCpuLimit,omitempty"`
+	TaskMemoryLimitMiB uint64  `json:"ecsTaskMemoryLimitMib,omitempty"`
+}
+
+// ecsContainerMetadata is the response of the container metadata endpoint.
+type ecsContainerMetadata struct {
+	DockerID string `json:"DockerId"`
+}
+
+// ecsTaskMetadata is the response of the task metadata endpoint.  The limits
+// are given in vCPUs and in MiB.
+type ecsTaskMetadata struct {
+	Cluster          string `json:"Cluster"`
+	TaskARN          string `json:"TaskARN"`
+	LaunchType       string `json:"LaunchType"`
+	AvailabilityZone string `json:"AvailabilityZone"`
+	Limits           struct {
+		CPU    float64 `json:"CPU"`
+		Memory uint64  `json:"Memory"`
+	} `json:"Limits"`
+}
+
+func gatherECS(util *Data, client *http.Client) error {
+	ecs, err := getECS(client, os.Getenv)
+	if err != nil {
+		// Only return the error here if it is unexpected to prevent
+		// warning customers who aren't running ECS.
+		if _, ok := err.(unexpectedECSErr); ok {
+			return err
+		}
+		return nil
+	}
+	util.Vendors.ECS = ecs
+
+	return nil
+}
+
+type unexpectedECSErr struct{ e error }
+
+func (e unexpectedECSErr) Error() string {
+	return fmt.Sprintf("unexpected ECS error: %v", e.e)
+}
+
+var (
+	errNoECSVariables = errors.New("no ECS environment variables present")
+)
+
+func getECS(client *http.Client, getenv func(string) string) (*ecs, error) {
+	uri := strings.TrimSuffix(getenv(ecsMetadataEnv), "/")
+	if uri == "" {
+		return nil, errNoECSVariables
+	}
+
+	var container ecsContainerMetadata
+	if err := getECSMetadata(client, uri, &container); err != nil {
+		return nil, err
+	}
+	var task ecsTaskMetadata
+	if err := getECSMetadata(client, uri+"/task", &task); err != nil {
+		return nil, err
+	}
+
+	e := &ecs{
+		DockerID:           container.DockerID,
+		TaskARN:            task.TaskARN,
+		Cluster:            task.Cluster,
+		LaunchType:         task.LaunchType,
+		AvailabilityZone:   task.AvailabilityZone,
+		TaskCPULimit:       task.Limits.CPU,
+		TaskMemoryLimitMiB: task.Limits.Memory,
+	}
+	if err := e.validate(); err != nil {
+		return nil, unexpectedECSErr{e: err}
+	}
+	return e, nil
+}
+
+func getECSMetadata(client *http.Client, url string, v interface{}) (err error) {
+	// As with the other metadata endpoints, a panic in net/http caused by
+	// blocked requests is recovered.
+	defer func() {
+		if r := recover(); r != nil {
+			err = unexpectedECSErr{e: errors.New("panic contacting ECS metadata endpoint")}
+		}
+	}()
+
+	response, err := client.Get(url)
+	if err != nil {
+		return unexpectedECSErr{e: err}
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != 200 {
+		return unexpectedECSErr{e: fmt.Errorf("response code %d", response.StatusCode)}
+	}
+	data, err := io.ReadAll(response.Body)
+	if err != nil {
+		return unexpectedECSErr{e: err}
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return unexpectedECSErr{e: err}
+	}
+	return nil
+}
+
+func (e *ecs) validate() (err error) {
+	e.DockerID, err = normalizeValue(e.DockerID)
+	if err != nil {
+		return fmt.Errorf("Invalid Docker ID: %v", err)
+	}
+
+	e.TaskARN, err = normalizeARN(e.TaskARN)
+	if err != nil {
+		return fmt.Errorf("Invalid task ARN: %v", err)
+	}
+
+	e.Cluster, err = normalizeARN(e.Cluster)
+	if err != nil {
+		return fmt.Errorf("Invalid cluster: %v", err)
+	}
+
+	e.LaunchType, err = normalizeValue(e.LaunchType)
+	if err != nil {
+		return fmt.Errorf("Invalid launch type: %v", err)
+	}
+
+	e.AvailabilityZone, err = normalizeValue(e.AvailabilityZone)
+	if err != nil {
+		return fmt.Errorf("Invalid availability zone: %v", err)
+	}
+
+	if e.TaskARN == "" {
+		err = errors.New("task ARN is unavailable")
+	}
+
+	return
+}
+
+// normalizeARN normalises an Amazon Resource Name like normalizeValue, but
+// also accepts the colons which separate its fields.
+func normalizeARN(s string) (string, error) {
+	if _, err := normalizeValue(strings.ReplaceAll(s, ":", "/")); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(s), nil
+}
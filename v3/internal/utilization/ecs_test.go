@@ -0,0 +1,100 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package utilization
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func ecsMetadataServer(status int, task string) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v4/abc", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		w.Write([]byte(`{"DockerId":"cd189a933e5849daa93386466019ab50-2495160603","Limits":{"CPU":2,"Memory":0}}`))
+	})
+	mux.HandleFunc("/v4/abc/task", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		w.Write([]byte(task))
+	})
+	return httptest.NewServer(mux)
+}
+
+func TestGetECS(t *testing.T) {
+	srv := ecsMetadataServer(200, `{
+		"Cluster": "arn:aws:ecs:us-west-2:111122223333:cluster/default",
+		"TaskARN": "arn:aws:ecs:us-west-2:111122223333:task/default/cd189a933e5849daa93386466019ab50",
+		"Family": "web",
+		"LaunchType": "FARGATE",
+		"AvailabilityZone": "us-west-2a",
+		"Limits": {"CPU": 0.25, "Memory": 512}
+	}`)
+	defer srv.Close()
+
+	e, err := getECS(srv.Client(), func(key string) string {
+		if key == ecsMetadataEnv {
+			return srv.URL + "/v4/abc/"
+		}
+		return ""
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	js, err := json.Marshal(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := `{"ecsDockerId":"cd189a933e5849daa93386466019ab50-2495160603",` +
+		`"ecsTaskArn":"arn:aws:ecs:us-west-2:111122223333:task/default/cd189a933e5849daa93386466019ab50",` +
+		`"ecsCluster":"arn:aws:ecs:us-west-2:111122223333:cluster/default",` +
+		`"ecsLaunchType":"FARGATE","availabilityZone":"us-west-2a",` +
+		`"ecsTaskCpuLimit":0.25,"ecsTaskMemoryLimitMib":512}`
+	if string(js) != expect {
+		t.Errorf("incorrect ECS data:\n%s\n%s", js, expect)
+	}
+}
+
+func TestGetECSErrors(t *testing.T) {
+	if _, err := getECS(http.DefaultClient, func(string) string { return "" }); err != errNoECSVariables {
+		t.Error("incorrect error without ECS:", err)
+	}
+	for _, tc := range []struct {
+		status int
+		task   string
+	}{
+		{status: 500, task: `{}`},
+		{status: 200, task: `{"TaskARN": 1}`},
+		{status: 200, task: `{"Cluster": "default"}`},
+		{status: 200, task: `{"TaskARN": "arn:aws:ecs:us-west-2:111122223333:task/default/a#b"}`},
+	} {
+		srv := ecsMetadataServer(tc.status, tc.task)
+		_, err := getECS(srv.Client(), func(string) string { return srv.URL + "/v4/abc" })
+		if _, ok := err.(unexpectedECSErr); !ok {
+			t.Errorf("incorrect error for %d %s: %v", tc.status, tc.task, err)
+		}
+		srv.Close()
+	}
+}
+
+func TestGatherKubernetesEKS(t *testing.T) {
+	env := map[string]string{
+		"KUBERNETES_SERVICE_HOST":     "10.96.0.1",
+		"AWS_ROLE_ARN":                "arn:aws:iam::111122223333:role/checkout",
+		"AWS_WEB_IDENTITY_TOKEN_FILE": "/var/run/secrets/eks.amazonaws.com/serviceaccount/token",
+	}
+	v := &vendors{}
+	gatherKubernetes(v, func(key string) string { return env[key] })
+	if v.Kubernetes == nil || v.Kubernetes.Host != "10.96.0.1" || v.Kubernetes.EKSRoleARN != "arn:aws:iam::111122223333:role/checkout" {
+		t.Errorf("incorrect Kubernetes data: %+v", v.Kubernetes)
+	}
+
+	delete(env, "AWS_WEB_IDENTITY_TOKEN_FILE")
+	v = &vendors{}
+	gatherKubernetes(v, func(key string) string { return env[key] })
+	if v.Kubernetes == nil || v.Kubernetes.EKSRoleARN != "" {
+		t.Errorf("incorrect Kubernetes data without IRSA: %+v", v.Kubernetes)
+	}
+}
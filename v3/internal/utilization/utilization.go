@@ -27,6 +27,7 @@ type Config struct {
 	DetectPCF         bool
 	DetectDocker      bool
 	DetectKubernetes  bool
+	DetectECS         bool
 	LogicalProcessors int
 	TotalRAMMIB       int
 	BillingHostname   string
@@ -52,6 +53,10 @@ type Data struct {
 	BootID            string    `json:"boot_id,omitempty"`
 	Config            *override `json:"config,omitempty"`
 	Vendors           *vendors  `json:"vendors,omitempty"`
+	// ContainerLimits are the cgroup limits of the container.
+	ContainerLimits *containerLimits `json:"container_limits,omitempty"`
+	// Windows describes how the process is hosted on Windows.
+	Windows *windowsHost `json:"windows,omitempty"`
 }
 
 var (
@@ -72,6 +77,21 @@ type docker struct {
 
 type kubernetes struct {
 	Host string `json:"kubernetes_service_host"`
+	// EKSRoleARN is the IAM role of the service account of the pod, when
+	// it uses IAM roles for service accounts on EKS.
+	EKSRoleARN string `json:"eks_role_arn,omitempty"`
+}
+
+type windowsHost struct {
+	SessionID   uint32 `json:"session_id"`
+	ServiceName string `json:"service_name,omitempty"`
+	IISAppPool  string `json:"iis_app_pool,omitempty"`
+	Container   bool   `json:"container,omitempty"`
+}
+
+type containerLimits struct {
+	CPUs      float64 `json:"cpus,omitempty"`
+	MemoryMiB uint64  `json:"memory_mib,omitempty"`
 }
 
 type vendors struct {
@@ -81,10 +101,17 @@ type vendors struct {
 	PCF        *pcf        `json:"pcf,omitempty"`
 	Docker     *docker     `json:"docker,omitempty"`
 	Kubernetes *kubernetes `json:"kubernetes,omitempty"`
+	ECS        *ecs        `json:"ecs,omitempty"`
+	// CloudRun, AzureContainerApps, and AzureFunctions describe the
+	// serverless container platforms.
+	CloudRun           *cloudRun           `json:"cloud_run,omitempty"`
+	AzureContainerApps *azureContainerApps `json:"azure_container_apps,omitempty"`
+	AzureFunctions     *azureFunctions     `json:"azure_functions,omitempty"`
 }
 
 func (v *vendors) AnySet() bool {
-	return v.AWS != nil || v.Azure != nil || v.GCP != nil || v.PCF != nil || v.Docker != nil || v.Kubernetes != nil
+	return v.AWS != nil || v.Azure != nil || v.GCP != nil || v.PCF != nil || v.Docker != nil || v.Kubernetes != nil || v.ECS != nil ||
+		v.CloudRun != nil || v.AzureContainerApps != nil || v.AzureFunctions != nil
 }
 func (v *vendors) isEmpty() bool {
 	return nil == v || *v == vendors{}
@@ -162,6 +189,13 @@ func gatherWithClient(config Config, lg logger.Logger, client *http.Client) *Dat
 		}()
 	}
 
+	// Azure Container Apps and Azure Functions are detected before the
+	// goroutines are spawned, since they use no network calls and Azure
+	// errors are only reported if no other vendor was detected.
+	if config.DetectAzure {
+		gatherAzureServerless(uDat.Vendors, os.Getenv, warnGatherError)
+	}
+
 	// Kick off gathering which requires network calls in goroutines.
 
 	if config.DetectAWS {
@@ -178,6 +212,11 @@ func gatherWithClient(config Config, lg logger.Logger, client *http.Client) *Dat
 
 	if config.DetectGCP {
 		goGather("gcp", gatherGCP)
+		goGather("cloud_run", gatherCloudRun)
+	}
+
+	if config.DetectECS {
+		goGather("ecs", gatherECS)
 	}
 
 	wg.Add(1)
@@ -211,6 +250,34 @@ func gatherWithClient(config Config, lg logger.Logger, client *http.Client) *Dat
 		}
 	}
 
+	if config.DetectDocker || config.DetectKubernetes || config.DetectECS {
+		if limits, err := sysinfo.GetCgroupLimits(); err != nil {
+			if err != sysinfo.ErrFeatureUnsupported &&
+				err != sysinfo.ErrCgroupLimitsNotFound {
+				warnGatherError("container limits", err)
+			}
+		} else {
+			uDat.ContainerLimits = &containerLimits{
+				CPUs:      limits.CPUs,
+				MemoryMiB: sysinfo.BytesToMebibytes(limits.MemoryBytes),
+			}
+		}
+	}
+
+	// Windows services and IIS applications run without the environment
+	// of an interactive session, and are described separately.
+	if host, err := sysinfo.GetWindowsHost(); err != sysinfo.ErrFeatureUnsupported {
+		if err != nil {
+			warnGatherError("windows", err)
+		}
+		uDat.Windows = &windowsHost{
+			SessionID:   host.SessionID,
+			ServiceName: host.ServiceName,
+			IISAppPool:  host.IISAppPool,
+			Container:   host.Container,
+		}
+	}
+
 	uDat.Hostname = config.Hostname
 
 	if bts, err := sysinfo.PhysicalMemoryBytes(); nil == err {
@@ -237,5 +304,12 @@ func gatherWithClient(config Config, lg logger.Logger, client *http.Client) *Dat
 func gatherKubernetes(v *vendors, getenv func(string) string) {
 	if host := getenv("KUBERNETES_SERVICE_HOST"); host != "" {
 		v.Kubernetes = &kubernetes{Host: host}
+		// IAM roles for service accounts are set up on EKS using these
+		// variables.
+		if getenv("AWS_WEB_IDENTITY_TOKEN_FILE") != "" {
+			if arn, err := normalizeARN(getenv("AWS_ROLE_ARN")); err == nil {
+				v.Kubernetes.EKSRoleARN = arn
+			}
+		}
 	}
 }
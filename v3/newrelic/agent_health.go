@@ -0,0 +1,167 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package newrelic
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// healthStatus is a status of the agent health monitoring specification.
+type healthStatus struct {
+	healthy bool
+	code    string
+	message string
+}
+
+var (
+	healthOK               = healthStatus{healthy: true, code: "NR-APM-000", message: "Healthy"}
+	healthInvalidLicense   = healthStatus{code: "NR-APM-001", message: "Invalid license key (HTTP status code 401)"}
+	healthForcedDisconnect = healthStatus{code: "NR-APM-003", message: "Forced disconnect received from New Relic (HTTP status code 410)"}
+	healthHTTPError        = healthStatus{code: "NR-APM-004", message: "HTTP error response code received from New Relic"}
+	healthDisabled         = healthStatus{code: "NR-APM-008", message: "Agent is disabled via configuration"}
+	healthConnectFailure   = healthStatus{code: "NR-APM-009", message: "Failed to connect to New Relic data collector"}
+	healthShutdown         = healthStatus{healthy: true, code: "NR-APM-099", message: "Agent has shutdown"}
+)
+
+// responseHealthStatus returns the health status corresponding to a response
+// of the collector.
+func responseHealthStatus(resp *rpmResponse) healthStatus {
+	switch {
+	case nil == resp.GetError():
+		return healthOK
+	case resp.statusCode == 401:
+		return healthInvalidLicense
+	case resp.IsDisconnect():
+		return healthForcedDisconnect
+	case resp.statusCode == 0:
+		return healthConnectFailure
+	default:
+		return healthStatus{code: healthHTTPError.code, message: fmt.Sprintf("%s (HTTP status code %d)", healthHTTPError.message, resp.statusCode)}
+	}
+}
+
+// agentHealth holds the health status of the application, written to the
+// health file.
+type agentHealth struct {
+	path      string
+	startTime time.Time
+
+	sync.Mutex
+	status healthStatus
+	// final is true once the status can no longer change.
+	final bool
+}
+
+func newAgentHealth(location string, now time.Time) (*agentHealth, error) {
+	dir := location
+	if strings.HasPrefix(location, "file:") {
+		u, err := url.Parse(location)
+		if err != nil {
+			return nil, err
+		}
+		dir = u.Path
+	}
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	return &agentHealth{
+		path:      filepath.Join(dir, "health-"+hex.EncodeToString(id)+".yml"),
+		startTime: now,
+		status:    healthOK,
+	}, nil
+}
+
+// set sets the health status, unless a final status has been set.
+func (h *agentHealth) set(status healthStatus, final bool) {
+	h.Lock()
+	defer h.Unlock()
+	if h.final {
+		return
+	}
+	h.status = status
+	h.final = final
+}
+
+// write writes the health file.  The file is written to a temporary file
+// which is renamed so that it is never read partially written.
+func (h *agentHealth) write(now time.Time, connected bool, lastHarvest time.Time) error {
+	h.Lock()
+	status := h.status
+	h.Unlock()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "healthy: %t\n", status.healthy)
+	fmt.Fprintf(&b, "status: %q\n", status.message)
+	fmt.Fprintf(&b, "last_error: %s\n", status.code)
+	fmt.Fprintf(&b, "start_time_unix_nano: %d\n", h.startTime.UnixNano())
+	fmt.Fprintf(&b, "status_time_unix_nano: %d\n", now.UnixNano())
+	fmt.Fprintf(&b, "connected: %t\n", connected)
+	if !lastHarvest.IsZero() {
+		fmt.Fprintf(&b, "last_successful_harvest_unix_nano: %d\n", lastHarvest.UnixNano())
+	}
+
+	if err := os.MkdirAll(filepath.Dir(h.path), 0755); err != nil {
+		return err
+	}
+	tmp := h.path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(b.String()), 0644); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, h.path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// setHealth sets the health status of the application, if the health file is
+// enabled.
+func (app *app) setHealth(status healthStatus) {
+	if app.health != nil {
+		app.health.set(status, false)
+	}
+}
+
+// writeHealth writes the health file, if it is enabled.
+func (app *app) writeHealth(now time.Time) {
+	if app.health == nil {
+		return
+	}
+	run, _ := app.getState()
+	app.stats.Lock()
+	lastHarvest := app.stats.lastHarvest
+	app.stats.Unlock()
+	if err := app.health.write(now, run.Reply.RunID != "", lastHarvest); err != nil {
+		app.Warn("unable to write health file", map[string]interface{}{
+			"file":  app.health.path,
+			"error": err.Error(),
+		})
+	}
+}
+
+// runHealthWriter writes the health file periodically until the application
+// shuts down.
+func runHealthWriter(app *app, period time.Duration) {
+	app.writeHealth(time.Now())
+	t := time.NewTicker(period)
+	for {
+		select {
+		case now := <-t.C:
+			app.writeHealth(now)
+		case <-app.shutdownStarted:
+			t.Stop()
+			return
+		}
+	}
+}
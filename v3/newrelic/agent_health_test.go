@@ -0,0 +1,176 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package newrelic
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/newrelic/go-agent/v3/internal"
+)
+
+func TestResponseHealthStatus(t *testing.T) {
+	for _, tc := range []struct {
+		resp *rpmResponse
+		code string
+	}{
+		{resp: newRPMResponse(nil).AddStatusCode(200), code: "NR-APM-000"},
+		{resp: newRPMResponse(nil).AddStatusCode(401), code: "NR-APM-001"},
+		{resp: newRPMResponse(nil).AddStatusCode(410), code: "NR-APM-003"},
+		{resp: newRPMResponse(nil).AddStatusCode(503), code: "NR-APM-004"},
+		{resp: newRPMResponse(errors.New("dial tcp: connection refused")), code: "NR-APM-009"},
+	} {
+		if status := responseHealthStatus(tc.resp); status.code != tc.code {
+			t.Errorf("incorrect status for %d %v: %+v", tc.resp.statusCode, tc.resp.GetError(), status)
+		}
+	}
+	if status := responseHealthStatus(newRPMResponse(nil).AddStatusCode(503)); status.message != "HTTP error response code received from New Relic (HTTP status code 503)" {
+		t.Error("incorrect message:", status.message)
+	}
+}
+
+func readHealthFile(t *testing.T, h *agentHealth) map[string]string {
+	data, err := os.ReadFile(h.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields := make(map[string]string)
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		kv := strings.SplitN(line, ": ", 2)
+		if len(kv) != 2 {
+			t.Fatal("invalid line:", line)
+		}
+		fields[kv[0]] = kv[1]
+	}
+	return fields
+}
+
+func TestAgentHealthWrite(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Unix(10, 0)
+	h, err := newAgentHealth("file://"+filepath.Join(dir, "health"), start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Dir(h.path) != filepath.Join(dir, "health") || !strings.HasPrefix(filepath.Base(h.path), "health-") || !strings.HasSuffix(h.path, ".yml") {
+		t.Fatal("incorrect path:", h.path)
+	}
+
+	h.set(healthInvalidLicense, false)
+	if err := h.write(time.Unix(20, 0), false, time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+	fields := readHealthFile(t, h)
+	want := map[string]string{
+		"healthy":               "false",
+		"status":                `"Invalid license key (HTTP status code 401)"`,
+		"last_error":            "NR-APM-001",
+		"start_time_unix_nano":  "10000000000",
+		"status_time_unix_nano": "20000000000",
+		"connected":             "false",
+	}
+	for k, v := range want {
+		if fields[k] != v {
+			t.Errorf("incorrect %s: %q, want %q", k, fields[k], v)
+		}
+	}
+	if len(fields) != len(want) {
+		t.Error("incorrect fields:", fields)
+	}
+
+	h.set(healthShutdown, true)
+	h.set(healthOK, false)
+	if err := h.write(time.Unix(30, 0), true, time.Unix(25, 0)); err != nil {
+		t.Fatal(err)
+	}
+	fields = readHealthFile(t, h)
+	if fields["healthy"] != "true" || fields["last_error"] != "NR-APM-099" || fields["connected"] != "true" || fields["last_successful_harvest_unix_nano"] != "25000000000" {
+		t.Error("incorrect fields after shutdown:", fields)
+	}
+}
+
+func TestAgentHealthDisabled(t *testing.T) {
+	dir := t.TempDir()
+	app := testApp(nil, ConfigAgentHealth(dir), t)
+	if app.app.health == nil {
+		t.Fatal("health not created")
+	}
+	fields := readHealthFile(t, app.app.health)
+	if fields["healthy"] != "false" || fields["last_error"] != "NR-APM-008" {
+		t.Error("incorrect fields for disabled agent:", fields)
+	}
+}
+
+func TestAgentHealthHarvest(t *testing.T) {
+	app := testApp(nil, ConfigAgentHealth(t.TempDir()), t)
+	health, err := newAgentHealth(t.TempDir(), time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	app.app.health = health
+	collector := &spoolCollector{statusCode: 503}
+	// The test application is disabled, and so has no collector client.
+	app.app.rpmControls = newRPMControls(app.app.config)
+	app.app.rpmControls.Client = &http.Client{Transport: collector}
+	reply := internal.ConnectReplyDefaults()
+	reply.RunID = "run1"
+	run := newAppRun(app.app.config, reply)
+
+	now := time.Now()
+	h := newHarvest(now, testHarvestCfgr)
+	h.Metrics.addCount("Custom/health", 1, forced)
+	app.app.doHarvest(h, now, run)
+	app.app.writeHealth(now)
+	if fields := readHealthFile(t, health); fields["healthy"] != "false" || fields["last_error"] != "NR-APM-004" {
+		t.Error("incorrect fields after harvest failure:", fields)
+	}
+
+	collector.respond(200)
+	app.app.doHarvest(h, now, run)
+	app.app.writeHealth(now)
+	if fields := readHealthFile(t, health); fields["healthy"] != "true" || fields["last_error"] != "NR-APM-000" || fields["last_successful_harvest_unix_nano"] == "" {
+		t.Error("incorrect fields after harvest success:", fields)
+	}
+}
+
+func TestAgentHealthConfig(t *testing.T) {
+	cfg := defaultConfig()
+	configFromEnvironment(func(name string) string {
+		switch name {
+		case "NEW_RELIC_AGENT_CONTROL_ENABLED":
+			return "true"
+		case "NEW_RELIC_AGENT_CONTROL_HEALTH_DELIVERY_LOCATION":
+			return "file:///tmp/health"
+		case "NEW_RELIC_AGENT_CONTROL_HEALTH_FREQUENCY":
+			return "10"
+		}
+		return ""
+	})(&cfg)
+	if !cfg.AgentHealth.Enabled || cfg.AgentHealth.DeliveryLocation != "file:///tmp/health" || cfg.AgentHealth.Frequency != 10*time.Second || cfg.Error != nil {
+		t.Errorf("incorrect config: %+v %v", cfg.AgentHealth, cfg.Error)
+	}
+
+	configFromEnvironment(func(name string) string {
+		if name == "NEW_RELIC_AGENT_CONTROL_HEALTH_FREQUENCY" {
+			return "soon"
+		}
+		return ""
+	})(&cfg)
+	if cfg.Error == nil {
+		t.Error("no error for invalid frequency")
+	}
+
+	cfg = defaultConfig()
+	cfg.License = "0123456789012345678901234567890123456789"
+	cfg.AppName = "app"
+	ConfigAgentHealth("")(&cfg)
+	if err := cfg.validate(); err != errAgentHealthDeliveryLocation {
+		t.Error("incorrect error for missing location:", err)
+	}
+}
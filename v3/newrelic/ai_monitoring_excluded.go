@@ -0,0 +1,35 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build newrelic_noaimonitoring || newrelic_minimal
+// +build newrelic_noaimonitoring newrelic_minimal
+
+package newrelic
+
+// aiMonitoringIncluded records whether AI Monitoring is included in the
+// build.  When it is excluded by the newrelic_noaimonitoring or the
+// newrelic_minimal build tag, AIMonitoring.Enabled is always false, which
+// disables the AI Monitoring integrations, and the methods below do nothing.
+const aiMonitoringIncluded = false
+
+// RecordLLMFeedbackEvent does nothing when AI Monitoring is excluded from the
+// build.
+func (app *Application) RecordLLMFeedbackEvent(trace_id string, rating any, category string, message string, metadata map[string]interface{}) {
+}
+
+// InvokeLLMTokenCountCallback returns a zero count and false when AI
+// Monitoring is excluded from the build.
+func (app *Application) InvokeLLMTokenCountCallback(model, content string) (int, bool) {
+	return 0, false
+}
+
+// HasLLMTokenCountCallback returns false when AI Monitoring is excluded from
+// the build.
+func (app *Application) HasLLMTokenCountCallback() bool {
+	return false
+}
+
+// SetLLMTokenCountCallback does nothing when AI Monitoring is excluded from
+// the build.
+func (app *Application) SetLLMTokenCountCallback(callbackFunction func(string, string) int) {
+}
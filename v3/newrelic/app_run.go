@@ -32,6 +32,14 @@ type appRun struct {
 	// between transactions.
 	rulesCache *rulesCache
 
+	// txnNameGuard limits the number of unique transaction names.  It is
+	// nil if the guard is disabled.
+	txnNameGuard *txnNameGuard
+
+	// tenantAccountant records the usage of each tenant.  It is nil if
+	// tenant accounting is disabled.
+	tenantAccountant *tenantAccountant
+
 	// harvestConfig contains configuration related to event limits and
 	// flexible harvest periods.  This field is created once at appRun
 	// creation.
@@ -40,6 +48,7 @@ type appRun struct {
 	// Error code caches for faster lookups O(1)
 	ignoreErrorCodesCache map[int]bool
 	expectErrorCodesCache map[int]bool
+	expectErrorClasses    map[string]bool
 	mu                    sync.RWMutex
 }
 
@@ -50,11 +59,11 @@ const (
 func newAppRun(config config, reply *internal.ConnectReply) *appRun {
 	run := &appRun{
 		Reply:                 reply,
-		AttributeConfig:       createAttributeConfig(config, reply.SecurityPolicies.AttributesInclude.Enabled()),
 		Config:                config,
 		rulesCache:            newRulesCache(txnNameCacheLimit),
 		ignoreErrorCodesCache: make(map[int]bool),
 		expectErrorCodesCache: make(map[int]bool),
+		expectErrorClasses:    make(map[string]bool),
 	}
 
 	// Overwrite local settings with any server-side-config settings
@@ -107,6 +116,46 @@ func newAppRun(config config, reply *internal.ConnectReply) *appRun {
 		run.mu.Unlock()
 	}
 
+	if v := run.Reply.ServerSideConfig.ErrorCollectorExpectClasses; v != nil {
+		run.Config.ErrorCollector.ExpectClasses = v
+	}
+	for _, class := range run.Config.ErrorCollector.ExpectClasses {
+		run.expectErrorClasses[class] = true
+	}
+	if v := run.Reply.ServerSideConfig.ErrorCollectorCaptureEvents; v != nil {
+		run.Config.ErrorCollector.CaptureEvents = *v
+	}
+	if v := run.Reply.ServerSideConfig.TransactionEventsEnabled; v != nil {
+		run.Config.TransactionEvents.Enabled = *v
+	}
+	if v := run.Reply.ServerSideConfig.SpanEventsEnabled; v != nil {
+		run.Config.SpanEvents.Enabled = *v
+	}
+	if v := run.Reply.ServerSideConfig.SlowSQLEnabled; v != nil {
+		run.Config.DatastoreTracer.SlowQuery.Enabled = *v
+	}
+	if v := run.Reply.ServerSideConfig.TransactionTracerExplainThreshold; v != nil {
+		run.Config.DatastoreTracer.SlowQuery.Threshold = internal.FloatSecondsToDuration(*v)
+	}
+	// The server-side attribute filters are added to the local filters
+	// rather than replacing them.  The server-side include patterns are
+	// ignored under high security mode and when a local exclude pattern
+	// covers them, so that attributes excluded locally are never sent.  New
+	// slices are allocated so that the input Config is not changed.
+	if v := run.Reply.ServerSideConfig.AttributesInclude; len(v) > 0 && !run.Config.HighSecurity {
+		include := append([]string(nil), run.Config.Attributes.Include...)
+		for _, pattern := range v {
+			if !excludedLocally(run.Config, pattern) {
+				include = append(include, pattern)
+			}
+		}
+		run.Config.Attributes.Include = include
+	}
+	if v := run.Reply.ServerSideConfig.AttributesExclude; len(v) > 0 {
+		run.Config.Attributes.Exclude = append(append([]string(nil), run.Config.Attributes.Exclude...), v...)
+	}
+	run.AttributeConfig = createAttributeConfig(run.Config, reply.SecurityPolicies.AttributesInclude.Enabled())
+
 	if !run.Reply.CollectErrorEvents {
 		run.Config.ErrorCollector.CaptureEvents = false
 	}
@@ -126,13 +175,28 @@ func newAppRun(config config, reply *internal.ConnectReply) *appRun {
 	if run.Config.DistributedTracer.Enabled {
 		run.Config.CrossApplicationTracer.Enabled = false
 	}
+	// The path hash of cross application tracing uses MD5, which is not
+	// approved by FIPS 140-2.
+	if run.Config.CollectorTLS.FIPSMode {
+		run.Config.CrossApplicationTracer.Enabled = false
+	}
+	// The server-side configuration and the configuration updates do not
+	// enable the features excluded from the build.
+	disableExcludedFeatures(&run.Config.Config)
+
+	run.txnNameGuard = newTxnNameGuard(run.Config)
+	run.tenantAccountant = newTenantAccountant(run.Config)
 
 	// Cache the first application name set on the config
 	run.firstAppName = strings.SplitN(config.AppName, ";", 2)[0]
 
+	samplingTarget := reply.SamplingTarget
+	if v := run.Reply.ServerSideConfig.SamplingTarget; v != nil {
+		samplingTarget = *v
+	}
 	run.adaptiveSampler = newAdaptiveSampler(
 		time.Duration(reply.SamplingTargetPeriodInSeconds)*time.Second,
-		reply.SamplingTarget,
+		samplingTarget,
 		time.Now())
 
 	if run.Reply.RunID != "" {
@@ -149,11 +213,39 @@ func newAppRun(config config, reply *internal.ConnectReply) *appRun {
 		MaxErrorEvents:  run.MaxErrorEvents(),
 		MaxSpanEvents:   run.MaxSpanEvents(),
 		LoggingConfig:   run.LoggingConfig(),
+
+		CustomEventQuotas: run.Config.CustomInsightsEvents.MaxSamplesPerEventType,
 	}
 
 	return run
 }
 
+// excludedLocally returns true if an exclude pattern of the local
+// configuration matches every attribute matched by the include pattern.
+// The exclude pattern then takes precedence over the include pattern.
+func excludedLocally(c config, include string) bool {
+	for _, attrs := range []AttributeDestinationConfig{
+		c.Attributes,
+		c.TransactionEvents.Attributes,
+		c.ErrorCollector.Attributes,
+		c.TransactionTracer.Attributes,
+		c.TransactionTracer.Segments.Attributes,
+		c.BrowserMonitoring.Attributes,
+		c.SpanEvents.Attributes,
+	} {
+		for _, exclude := range attrs.Exclude {
+			if exclude == include {
+				return true
+			}
+			if prefix := strings.TrimSuffix(exclude, "*"); prefix != exclude &&
+				strings.HasPrefix(strings.TrimSuffix(include, "*"), prefix) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func newPlaceholderAppRun(config config) *appRun {
 	reply := internal.ConnectReplyDefaults()
 	// Do no sampling if the app isn't connected:
@@ -208,6 +300,10 @@ func (run *appRun) responseCodeIsExpected(code int) bool {
 	return run.expectErrorCodesCache[code]
 }
 
+func (run *appRun) errorClassIsExpected(class string) bool {
+	return run.expectErrorClasses[class]
+}
+
 func (run *appRun) txnTraceThreshold(apdexThreshold time.Duration) time.Duration {
 	if run.Config.TransactionTracer.Threshold.IsApdexFailing {
 		return apdexFailingThreshold(apdexThreshold)
@@ -286,6 +382,9 @@ func (run *appRun) createTransactionName(input string, isWeb bool) string {
 		return name
 	}
 	name := internal.CreateFullTxnName(input, run.Reply, isWeb)
+	if name != "" {
+		name = run.Config.txnNameRules.Apply(name)
+	}
 	if name != "" {
 		// Note that we  don't cache situations where the rules say
 		// ignore.  It would increase complication (we would need to
@@ -80,6 +80,10 @@ func BenchmarkResponseCodeIsExpectedHit(b *testing.B) {
 }
 
 func TestCrossAppTracingEnabled(t *testing.T) {
+	if !crossAppTracingIncluded {
+		t.Skip("Cross Application Tracing is excluded from the build")
+	}
+
 	// CAT should NOT be enabled by default.
 	cfg := config{Config: defaultConfig()}
 	run := newAppRun(cfg, internal.ConnectReplyDefaults())
@@ -499,3 +503,117 @@ func TestCreateTransactionName(t *testing.T) {
 		t.Error("wanted:", want, "got:", out)
 	}
 }
+
+func TestCreateTransactionNameLocalRules(t *testing.T) {
+	c := defaultConfig()
+	c.Enabled = false
+	c.TransactionNameRules = []TransactionNameRule{
+		{MatchExpression: "^[0-9]+$", Replacement: "{id}", EachSegment: true},
+		{MatchExpression: "/healthz$", Ignore: true},
+	}
+	cfg, err := newInternalConfig(c, func(string) string { return "" }, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	run := newAppRun(cfg, internal.ConnectReplyDefaults())
+
+	want := "WebTransaction/Go/GET /users/{id}/orders/{id}"
+	if out := run.createTransactionName("GET /users/123/orders/45", true); out != want {
+		t.Error("wanted:", want, "got:", out)
+	}
+	if out := run.createTransactionName("GET /healthz", true); out != "" {
+		t.Error("wanted ignored transaction, got:", out)
+	}
+}
+
+func TestInvalidTransactionNameRule(t *testing.T) {
+	_, err := NewApplication(
+		ConfigAppName(sampleAppName),
+		ConfigLicense(testLicenseKey),
+		ConfigTransactionNameRules(TransactionNameRule{MatchExpression: "(unclosed"}),
+	)
+	if err == nil {
+		t.Error("application created with invalid transaction name rule")
+	}
+}
+
+func TestErrorClassIsExpected(t *testing.T) {
+	cfg := config{Config: defaultConfig()}
+	cfg.ErrorCollector.ExpectClasses = []string{"local.Error"}
+	reply := internal.ConnectReplyDefaults()
+	run := newAppRun(cfg, reply)
+	if !run.errorClassIsExpected("local.Error") || run.errorClassIsExpected("other.Error") {
+		t.Error(run.Config.ErrorCollector.ExpectClasses)
+	}
+
+	reply.ServerSideConfig.ErrorCollectorExpectClasses = []string{"server.Error"}
+	run = newAppRun(cfg, reply)
+	if run.errorClassIsExpected("local.Error") || !run.errorClassIsExpected("server.Error") {
+		t.Error(run.Config.ErrorCollector.ExpectClasses)
+	}
+}
+
+func TestServerSideConfigOverrides(t *testing.T) {
+	cfg := config{Config: defaultConfig()}
+	cfg.Attributes.Exclude = []string{"local"}
+	reply := internal.ConnectReplyDefaults()
+	if err := json.Unmarshal([]byte(`{"agent_config":{
+		"error_collector.capture_events": false,
+		"transaction_events.enabled": false,
+		"span_events.enabled": false,
+		"slow_sql.enabled": false,
+		"transaction_tracer.explain_threshold": 2.5,
+		"attributes.include": ["request.parameters.*"],
+		"attributes.exclude": ["server"],
+		"distributed_tracing.sampler.adaptive_sampling_target": 20
+	}}`), reply); err != nil {
+		t.Fatal(err)
+	}
+	run := newAppRun(cfg, reply)
+	if run.Config.ErrorCollector.CaptureEvents || run.Config.TransactionEvents.Enabled || run.Config.SpanEvents.Enabled || run.Config.DatastoreTracer.SlowQuery.Enabled {
+		t.Error("settings not disabled by server-side config")
+	}
+	if run.Config.DatastoreTracer.SlowQuery.Threshold != 2500*time.Millisecond {
+		t.Error(run.Config.DatastoreTracer.SlowQuery.Threshold)
+	}
+	if exclude := run.Config.Attributes.Exclude; len(exclude) != 2 || exclude[0] != "local" || exclude[1] != "server" {
+		t.Error(exclude)
+	}
+	if len(cfg.Attributes.Exclude) != 1 {
+		t.Error("input config changed:", cfg.Attributes.Exclude)
+	}
+	dests := applyAttributeConfig(run.AttributeConfig, "server", destAll)
+	if dests != destNone {
+		t.Error("server-side exclude not applied:", dests)
+	}
+	if dests := applyAttributeConfig(run.AttributeConfig, "request.parameters.id", destAll); dests&destTxnEvent == 0 {
+		t.Error("server-side include not applied:", dests)
+	}
+	if run.adaptiveSampler.target != 20 {
+		t.Error(run.adaptiveSampler.target)
+	}
+}
+
+func TestServerSideAttributesInclude(t *testing.T) {
+	cfg := config{Config: defaultConfig()}
+	cfg.Attributes.Exclude = []string{"request.*"}
+	cfg.TransactionEvents.Attributes.Exclude = []string{"secret"}
+	reply := internal.ConnectReplyDefaults()
+	reply.ServerSideConfig.AttributesInclude = []string{"request.parameters.*", "request.*", "secret", "sec*", "other.*"}
+	run := newAppRun(cfg, reply)
+	if include := run.Config.Attributes.Include; len(include) != 2 || include[0] != "sec*" || include[1] != "other.*" {
+		t.Error("incorrect include patterns:", include)
+	}
+	if dests := applyAttributeConfig(run.AttributeConfig, "request.parameters.id", destAll); dests != destNone {
+		t.Error("local exclude overridden:", dests)
+	}
+	if dests := applyAttributeConfig(run.AttributeConfig, "secret", destAll); dests&destTxnEvent != 0 {
+		t.Error("local exclude overridden:", dests)
+	}
+
+	cfg.HighSecurity = true
+	run = newAppRun(cfg, reply)
+	if include := run.Config.Attributes.Include; len(include) != 0 {
+		t.Error("server-side include applied under high security:", include)
+	}
+}
@@ -72,67 +72,34 @@ func (app *Application) RecordCustomEvent(eventType string, params map[string]in
 	}
 }
 
-// RecordLLMFeedbackEvent adds a LLM Feedback event.
-// An error is logged if eventType or params is invalid.
-func (app *Application) RecordLLMFeedbackEvent(trace_id string, rating any, category string, message string, metadata map[string]interface{}) {
-	if app == nil || app.app == nil {
-		return
-	}
-	CustomEventData := map[string]interface{}{
-		"trace_id":      trace_id,
-		"rating":        rating,
-		"category":      category,
-		"message":       message,
-		"ingest_source": "Go",
-	}
-	for k, v := range metadata {
-		CustomEventData[k] = v
-	}
-	// if rating is an int or string, record the event
-	err := app.app.RecordCustomEvent("LlmFeedbackMessage", CustomEventData)
-	if err != nil {
-		app.app.Error("unable to record custom event", map[string]interface{}{
-			"event-type": "LlmFeedbackMessage",
-			"reason":     err.Error(),
-		})
-	}
-}
-
-// InvokeLLMTokenCountCallback invokes the function registered previously as the callback
-// function to compute token counts to report for LLM transactions, if any. If there is
-// no current callback funtion, this simply returns a zero count and a false boolean value.
-// Otherwise, it returns the value returned by the callback and a true value.
+// SetErrorCallback registers a callback function which is called for every
+// error noticed by a transaction, including the errors recorded for panics
+// and HTTP response codes, before it is recorded.  It allows errors to be
+// filtered and fingerprinted without wrapping each call to
+// Transaction.NoticeError.  If the callback returns false for keep, the error
+// is dropped as if it had never been noticed.  If it returns a non-empty
+// groupLabel, the error is assigned to that error group in the Errors Inbox,
+// unless an ErrorGroupCallback configured in ErrorCollector.ErrorGroupCallback
+// assigns another group when the transaction ends.
 //
-// Although there's no harm in calling this method to invoke your callback function,
-// there is no need (or particular benefit) of doing so. This is called as needed internally
-// by the AI Monitoring integrations.
-func (app *Application) InvokeLLMTokenCountCallback(model, content string) (int, bool) {
-	if app == nil || app.app == nil || app.app.llmTokenCountCallback == nil {
-		return 0, false
-	}
-	return app.app.llmTokenCountCallback(model, content), true
-}
-
-// HasLLMTokenCountCallback returns true if there is currently a registered callback function
-// or false otherwise.
-func (app *Application) HasLLMTokenCountCallback() bool {
-	return app != nil && app.app != nil && app.app.llmTokenCountCallback != nil
-}
-
-// SetLLMTokenCountCallback registers a callback function which will be used by the AI Montoring
-// integration packages in cases where they are unable to determine the token counts directly.
-// You may call SetLLMTokenCountCallback multiple times. If you do, each call registers a new
-// callback function which replaces the previous one. Calling SetLLMTokenCountCallback(nil) removes
-// the callback function entirely.
+//	app.SetErrorCallback(func(info newrelic.ErrorInfo) (bool, string) {
+//		if info.Class == "*net.OpError" {
+//			return false, ""
+//		}
+//		return true, info.TransactionName
+//	})
 //
-// Your callback function will be passed two string parameters: model name and content. It must
-// return a single integer value which is the number of tokens to report. If it returns a value less
-// than or equal to zero, no token count report will be made (which includes the case where your
-// callback function was unable to determine the token count).
-func (app *Application) SetLLMTokenCountCallback(callbackFunction func(string, string) int) {
-	if app != nil && app.app != nil {
-		app.app.llmTokenCountCallback = callbackFunction
+// The callback is called with the transaction locked, so it must not call
+// the methods of the transaction.  Since the transaction has not ended,
+// ErrorInfo.TransactionName is the name the transaction has when the error
+// is noticed.  Calling SetErrorCallback(nil) removes the callback.
+func (app *Application) SetErrorCallback(callback func(ErrorInfo) (keep bool, groupLabel string)) {
+	if app == nil || app.app == nil {
+		return
 	}
+	app.app.errorCallbackLock.Lock()
+	defer app.app.errorCallbackLock.Unlock()
+	app.app.errorCallback = callback
 }
 
 // RecordCustomMetric records a custom metric.  The metric name you
@@ -155,6 +122,34 @@ func (app *Application) RecordCustomMetric(name string, value float64) {
 	}
 }
 
+// RecordHistogram records a value in a histogram, so that the distribution
+// of values such as latencies can be reported without averaging away their
+// percentiles.  The histogram is recorded as custom metrics: "Custom/<name>"
+// summarizes the values like RecordCustomMetric, and
+// "Custom/<name>/bucket/<bound>" counts the values less than or equal to
+// each bound and greater than the previous one.  Values greater than every
+// bound are counted in the "Custom/<name>/bucket/+Inf" metric.
+//
+// The bounds must be increasing, and are limited to 32.  Use the same bounds
+// every time a histogram is recorded.  If bounds is nil, default bounds
+// suited to latencies in seconds are used: 0.005, 0.01, 0.025, 0.05, 0.1,
+// 0.25, 0.5, 1, 2.5, 5, and 10.  Histograms are not currently supported in
+// serverless mode.
+//
+//	app.RecordHistogram("checkout/latency", time.Since(start).Seconds(), nil)
+func (app *Application) RecordHistogram(name string, value float64, bounds []float64) {
+	if app == nil || app.app == nil {
+		return
+	}
+	err := app.app.RecordHistogram(name, value, bounds)
+	if err != nil {
+		app.app.Error("unable to record histogram", map[string]interface{}{
+			"metric-name": name,
+			"reason":      err.Error(),
+		})
+	}
+}
+
 // RecordLog records the data from a single log line.
 // This consumes a LogData object that should be configured
 // with data taken from a logging framework.
@@ -227,7 +222,51 @@ func (app *Application) Config() (Config, bool) {
 	if app == nil || app.app == nil {
 		return defaultConfig(), false
 	}
-	return app.app.config.Config, true
+	return app.app.currentConfig().Config, true
+}
+
+// ConfigJSON returns the effective configuration of the application as
+// indented JSON, for debugging or support tickets.  Once the application has
+// connected, the configuration includes the server-side settings.  If
+// redactSecrets is true, the License and the SecurityPoliciesToken are
+// replaced by "[redacted]".  Functions, such as the callbacks, are omitted,
+// and the Logger, Transport, and HTTPClient are described by their types.
+func (app *Application) ConfigJSON(redactSecrets bool) ([]byte, error) {
+	if app == nil || app.app == nil {
+		return configJSON(defaultConfig(), redactSecrets)
+	}
+	cfg := app.app.currentConfig().Config
+	if run, _ := app.app.getState(); nil != run && run.Reply.RunID != "" {
+		cfg = run.Config.Config
+	}
+	return configJSON(cfg, redactSecrets)
+}
+
+// UpdateConfig changes the configuration of the application at runtime,
+// without restarting the process or creating a new Application.  The options
+// are applied to the configuration in effect.  Only a subset of the settings
+// may be changed:
+//
+//   - the Logger, for example to change the log level using
+//     ConfigDebugLogger
+//   - the attribute settings: Attributes and the Attributes of each
+//     destination
+//   - DistributedTracer.Sampler
+//   - ApplicationLogging.Forwarding, and the Enabled settings of
+//     ApplicationLogging.Metrics and ApplicationLogging.LocalDecorating.
+//     Forwarding.MaxSamplesStored applies once the application
+//     reconnects.
+//
+// An error is returned, and the configuration is left unchanged, if the
+// options change any other setting or set Config.Error.  The updated
+// configuration applies to the transactions started afterwards, and is kept
+// when the application reconnects.  See WatchConfigFile to update the
+// configuration when a file changes.
+func (app *Application) UpdateConfig(opts ...ConfigOption) error {
+	if app == nil || app.app == nil {
+		return nil
+	}
+	return app.app.updateConfig(opts)
 }
 func newApplication(app *app) *Application {
 	return &Application{
@@ -262,3 +301,38 @@ func NewApplication(opts ...ConfigOption) (*Application, error) {
 	}
 	return newApplication(newApp(cfg)), nil
 }
+
+// ValidateConfig applies the ConfigOption arguments to the default
+// configuration as NewApplication does, and returns every error which would
+// cause NewApplication to fail, or nil if the configuration is valid.  Unlike
+// NewApplication, which returns the first error, it continues after an
+// option sets Config.Error, so that every misconfiguration can be reported
+// at once:
+//
+//	if errs := newrelic.ValidateConfig(opts...); len(errs) > 0 {
+//		log.Fatalf("invalid New Relic configuration: %v", errs)
+//	}
+func ValidateConfig(opts ...ConfigOption) []error {
+	var errs []error
+	c := defaultConfig()
+	for _, fn := range opts {
+		if fn != nil {
+			fn(&c)
+			if c.Error != nil {
+				errs = append(errs, c.Error)
+				c.Error = nil
+			}
+		}
+	}
+	errs = append(errs, c.validationErrors()...)
+	if _, err := c.validateTraceObserverConfig(); err != nil {
+		errs = append(errs, err)
+	}
+	if _, err := compileTxnNameRules(c.TransactionNameRules); err != nil {
+		errs = append(errs, err)
+	}
+	if _, err := compileTxnNamePatterns(c.IgnoredTransactionNames); err != nil {
+		errs = append(errs, err)
+	}
+	return errs
+}
@@ -56,8 +56,26 @@ const (
 	AttributeErrorGroupName = "error.group.name"
 	// AttributeUserID tracks the user a transaction and its child events are impacting
 	AttributeUserID = "enduser.id"
+	// AttributeUserEmail is the email address of the user set using
+	// Transaction.SetUser and WithUserEmail.  It is disabled by default;
+	// add it to Config.Attributes.Include to record it.
+	AttributeUserEmail = "enduser.email"
+	// AttributeUserName is the name of the user set using
+	// Transaction.SetUser and WithUserName.  It is disabled by default;
+	// add it to Config.Attributes.Include to record it.
+	AttributeUserName = "enduser.name"
 	// AttributeLLM tracks LLM transactions
 	AttributeLLM = "llm"
+	// AttributeHTTPRequestMethod is the request's method, named following
+	// the OpenTelemetry semantic conventions.  It is only added if
+	// Config.SemanticConventions.HTTP is not SemanticConventionsLegacy,
+	// like AttributeHTTPResponseStatusCode and AttributeURLPath.
+	AttributeHTTPRequestMethod = "http.request.method"
+	// AttributeHTTPResponseStatusCode is the response status code for a
+	// web request, named following the OpenTelemetry semantic conventions.
+	AttributeHTTPResponseStatusCode = "http.response.status_code"
+	// AttributeURLPath is the path of the request's URL.
+	AttributeURLPath = "url.path"
 )
 
 // Attributes destined for Errors and Transaction Traces:
@@ -75,6 +93,9 @@ const (
 	// AttributeRequestReferer is the request's "Referer" header.  Query
 	// string parameters are removed.
 	AttributeRequestReferer = "request.headers.referer"
+	// AttributeBreadcrumbs contains the breadcrumbs recorded using
+	// Transaction.AddBreadcrumb.
+	AttributeBreadcrumbs = "breadcrumbs"
 )
 
 // AWS Lambda specific attributes:
@@ -85,6 +106,16 @@ const (
 	AttributeAWSLambdaEventSourceARN = "aws.lambda.eventSource.arn"
 )
 
+// AWS request correlation attributes:
+const (
+	// AttributeAWSTraceID is the request's "X-Amzn-Trace-Id" header, added
+	// by AWS Application Load Balancers and API Gateway.
+	AttributeAWSTraceID = "aws.traceId"
+	// AttributeAWSRequestContextID is the request ID of the requestContext
+	// of API Gateway and Lambda function URL events.
+	AttributeAWSRequestContextID = "aws.requestContext.requestId"
+)
+
 // Attributes for consumed message transactions:
 //
 // When a message is consumed (for example from Kafka or RabbitMQ), supported
@@ -140,6 +171,9 @@ const (
 	AttributeMessageCorrelationID = "message.correlationId"
 	// The headers of the message without CAT keys/values
 	AttributeMessageHeaders = "message.headers"
+	// The time in seconds the consumed message spent in the broker before
+	// the transaction started.  Set it using Transaction.SetMessageQueueTime.
+	AttributeMessageQueueTime = "message.queueTime"
 	// Host identifier of the message broker
 	AttributeServerAddress = "server.address"
 	// Port number of the message broker
@@ -148,6 +182,109 @@ const (
 	AttributeSpanKind = "span.kind"
 )
 
+// Attributes added to the transactions of BatchTransaction.  They contain the
+// counts of work units and bytes processed by the batch job.
+const (
+	// The number of work units processed by the batch job.
+	AttributeBatchProcessed = "batch.processed"
+	// The number of work units which the batch job failed to process.
+	AttributeBatchFailed = "batch.failed"
+	// The number of work units skipped by the batch job.
+	AttributeBatchSkipped = "batch.skipped"
+	// The number of bytes processed by the batch job.
+	AttributeBatchBytes = "batch.bytes"
+)
+
+// Attributes added to the transactions of SagaTransaction.  They contain the
+// outcome of the saga and the counts of its steps.
+const (
+	// The outcome of the saga: SagaOutcomeCompleted,
+	// SagaOutcomeCompensated, or SagaOutcomeFailed.
+	AttributeSagaOutcome = "saga.outcome"
+	// The number of steps of the saga which completed.
+	AttributeSagaStepsCompleted = "saga.steps.completed"
+	// The number of steps of the saga which failed.
+	AttributeSagaStepsFailed = "saga.steps.failed"
+	// The number of compensations run to roll back the saga.
+	AttributeSagaCompensations = "saga.compensations"
+	// The number of compensations of the saga which failed.
+	AttributeSagaCompensationsFailed = "saga.compensations.failed"
+)
+
+// Attributes added to the transactions which process a message from a
+// dead-letter queue using Transaction.AcceptDeadLetter.
+const (
+	// The name of the dead-letter queue.
+	AttributeDeadLetterQueue = "deadLetter.queue"
+	// The reason the message was sent to the dead-letter queue.
+	AttributeDeadLetterReason = "deadLetter.reason"
+	// The time in seconds the message spent in the dead-letter queue
+	// before the transaction started.
+	AttributeDeadLetterAge = "deadLetter.age"
+	// The number of times the processing of the message was attempted.
+	AttributeDeadLetterAttempts = "deadLetter.attempts"
+	// The ID of the trace of the transaction which failed to process the
+	// message.
+	AttributeDeadLetterTraceID = "deadLetter.originalTraceId"
+)
+
+// Attributes describing the time the Go runtime was busy during the
+// transaction, in seconds, added when
+// Config.RuntimeSampler.TransactionAttributes is enabled.  They are read from
+// the runtime/metrics of the whole process, and so are approximate.
+const (
+	// The total of the GC pauses, which stop every goroutine, during the
+	// transaction.
+	AttributeRuntimeGCPauseTime = "runtime.gcPauseTime"
+	// The total time the goroutines of the process waited to be scheduled
+	// during the transaction.  A high value relative to the duration of
+	// the transaction indicates that the process was short of CPU.
+	AttributeRuntimeSchedulerLatency = "runtime.schedulerLatency"
+)
+
+// Attributes describing the systemd unit running the application, added to
+// every transaction when Config.Utilization.DetectSystemd is enabled.
+const (
+	// The name of the unit, such as "checkout.service".
+	AttributeSystemdUnit = "systemd.unit"
+	// The invocation ID of the unit, which identifies its current run.
+	AttributeSystemdInvocationID = "systemd.invocationId"
+)
+
+// Attributes describing the serverless container platform running the
+// application, added to every transaction when it is detected: Cloud Run when
+// Config.Utilization.DetectGCP is true, and Azure Container Apps and Azure
+// Functions custom handlers when Config.Utilization.DetectAzure is true.  The
+// AttributeCloudRegion and AttributeCloudAccountID attributes are also added
+// when they are known.
+const (
+	// The cloud provider, "gcp" or "azure".
+	AttributeCloudProvider = "cloud.provider"
+	// The platform, "gcp_cloud_run", "azure_container_apps", or
+	// "azure_functions".
+	AttributeCloudPlatform = "cloud.platform"
+	// The ID of the resource running the application, such as the Azure
+	// resource ID of a function app.
+	AttributeCloudResourceID = "cloud.resource_id"
+	// The name of the service, job, container app, or function app.
+	AttributeFaasName = "faas.name"
+	// The revision or the execution running the application.
+	AttributeFaasVersion = "faas.version"
+	// The replica or the instance running the application.
+	AttributeFaasInstance = "faas.instance"
+)
+
+// Attributes describing the Kubernetes pod running the application, added to
+// every transaction, span, and log event when Config.KubernetesMetadata.Enabled
+// is true.
+const (
+	AttributeKubernetesPodName     = "k8s.pod.name"
+	AttributeKubernetesNamespace   = "k8s.namespace.name"
+	AttributeKubernetesDeployment  = "k8s.deployment.name"
+	AttributeKubernetesNodeName    = "k8s.node.name"
+	AttributeKubernetesClusterName = "k8s.cluster.name"
+)
+
 // Experimental OTEL Attributes for consumed message transactions
 const (
 	AttributeMessagingDestinationPublishName = "messaging.destination_publish.name"
@@ -171,6 +308,7 @@ const (
 	SpanAttributePeerHostname            = "peer.hostname"
 	SpanAttributeHTTPURL                 = "http.url"
 	SpanAttributeHTTPMethod              = "http.method"
+	SpanAttributeURLFull                 = "url.full"
 	SpanAttributeAWSOperation            = "aws.operation"
 	SpanAttributeAWSRegion               = "aws.region"
 	SpanAttributeErrorClass              = "error.class"
@@ -180,6 +318,18 @@ const (
 	SpanAttributeParentAccount           = "parent.account"
 	SpanAttributeParentTransportDuration = "parent.transportDuration"
 	SpanAttributeParentTransportType     = "parent.transportType"
+	// SpanAttributeCodeStacktrace is the abbreviated stack trace of a slow
+	// segment, added if Config.SpanEvents.StackTraces is enabled.
+	SpanAttributeCodeStacktrace = "code.stacktrace"
+	// SpanAttributeContextDeadlineRemaining is the time in seconds which
+	// remained before the deadline of the context of a segment started
+	// using Transaction.StartSegmentWithContext, or of a transaction
+	// started using WithContext, when it started.
+	SpanAttributeContextDeadlineRemaining = "context.deadline.remaining"
+	// SpanAttributeContextError is the error of the context of such a
+	// segment or transaction if the context was canceled or its deadline
+	// was exceeded when it ended.
+	SpanAttributeContextError = "context.error"
 
 	// Deprecated: This attribute is a duplicate of AttributeResponseCode and
 	// will be removed in a later release.
@@ -188,3 +338,97 @@ const (
 	// will be removed in a later release.
 	SpanAttributeAWSRequestID = "aws.requestId"
 )
+
+// DynamoDB specific span attributes:
+//
+// These attributes contain the capacity consumed by a DynamoDB operation.
+// They are added by the nrawssdk-v2 integration when the request sets
+// ReturnConsumedCapacity.
+const (
+	SpanAttributeAWSDynamoDBConsumedCapacity      = "aws.dynamodb.consumedCapacity"
+	SpanAttributeAWSDynamoDBConsumedReadCapacity  = "aws.dynamodb.consumedReadCapacity"
+	SpanAttributeAWSDynamoDBConsumedWriteCapacity = "aws.dynamodb.consumedWriteCapacity"
+)
+
+// External request timing span attributes:
+//
+// These attributes contain the time in seconds spent in each phase of an
+// external request.  They are added when the request is traced using
+// ExternalSegment.ClientTrace, or by NewRoundTripper when it is created using
+// WithClientTrace.
+const (
+	SpanAttributeHTTPDNSDuration      = "http.dnsDuration"
+	SpanAttributeHTTPConnectDuration  = "http.connectDuration"
+	SpanAttributeHTTPTLSDuration      = "http.tlsDuration"
+	SpanAttributeHTTPTimeToFirstByte  = "http.timeToFirstByte"
+	SpanAttributeHTTPConnectionReused = "http.connectionReused"
+)
+
+// gRPC client span attributes:
+//
+// These attributes describe the connection used by a gRPC client call.  They
+// are added by the nrgrpc integration, along with SpanAttributePeerAddress,
+// the address of the backend picked for the call.
+const (
+	SpanAttributeGRPCTarget                  = "grpc.target"
+	SpanAttributeGRPCConnectivityState       = "grpc.connectivityState"
+	SpanAttributeGRPCConnectivityStateChange = "grpc.connectivityStateChange"
+)
+
+// RPC span attributes:
+//
+// These attributes describe an RPC, following the OpenTelemetry semantic
+// conventions for RPC spans.  They are added to client segments by the
+// nrconnect integration, and, if Config.SemanticConventions.RPC is enabled,
+// to client segments and server transactions by the nrgrpc integration.  The
+// system is the RPC protocol, for example "connect_rpc" or "grpc", the
+// service is the fully qualified name of the service, and the method is the
+// name of the method.  The Connect error code, for example "not_found", is
+// only added if the call fails.  The gRPC status code is the numeric code
+// returned by a server.  The peer name and port are those of the server
+// called by a client, or of the client calling a server.
+const (
+	SpanAttributeRPCSystem           = "rpc.system"
+	SpanAttributeRPCService          = "rpc.service"
+	SpanAttributeRPCMethod           = "rpc.method"
+	SpanAttributeRPCConnectErrorCode = "rpc.connect_rpc.error_code"
+	SpanAttributeRPCGRPCStatusCode   = "rpc.grpc.status_code"
+	SpanAttributeNetPeerName         = "net.peer.name"
+	SpanAttributeNetPeerPort         = "net.peer.port"
+)
+
+// Redis cluster span attributes:
+//
+// These attributes describe the routing of a command sent to a Redis Cluster.
+// They are added by the nrredis-v9 integration, which also reports the
+// address of the node the command was sent to as the instance of the segment.
+// The redirect attribute is "MOVED" or "ASK" if the command was retried on
+// another node after a redirection.
+const (
+	SpanAttributeRedisSlot     = "db.redis.slot"
+	SpanAttributeRedisRedirect = "db.redis.redirect"
+)
+
+// Message consumer span attributes:
+//
+// These attributes describe the messages consumed by a MessageConsumerSegment.
+// The queue time is the time in seconds between the MessageTimestamp and the
+// start of the segment.
+const (
+	SpanAttributeMessageQueueTime = AttributeMessageQueueTime
+	SpanAttributeMessageCount     = "message.count"
+)
+
+// Message payload attributes:
+//
+// These attributes describe a message captured using
+// MessageProducerSegment.Payload, MessageConsumerSegment.Payload, or
+// Transaction.SetMessagePayload, when Config.MessagePayloads is enabled.  The
+// payload is a snippet of the body of the message, and the payload size is the
+// size of the whole body in bytes.  The headers of the message are recorded in
+// the AttributeMessageHeaders attribute.
+const (
+	AttributeMessageKey         = "message.key"
+	AttributeMessagePayload     = "message.payload"
+	AttributeMessagePayloadSize = "message.payloadSize"
+)
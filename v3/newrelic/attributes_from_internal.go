@@ -21,6 +21,9 @@ const (
 	// listed as span attributes to simplify code. It is not listed in the
 	// public attributes.go file for this reason to prevent confusion.
 	spanAttributeQueryParameters = "query_parameters"
+	// segmentAttributeExplainPlan is only added to transaction trace
+	// segments.
+	segmentAttributeExplainPlan = "explain_plan"
 
 	// The collector can only allow attributes to be a maximum of 256 bytes
 	maxAttributeLengthBytes = 256
@@ -47,6 +50,7 @@ var (
 		AttributeRequestUserAgent:                tracesDests,
 		AttributeRequestUserAgentDeprecated:      tracesDests,
 		AttributeRequestReferer:                  tracesDests,
+		AttributeBreadcrumbs:                     tracesDests,
 		AttributeRequestURI:                      usualDests,
 		AttributeResponseContentType:             usualDests,
 		AttributeResponseContentLength:           usualDests,
@@ -56,17 +60,57 @@ var (
 		AttributeAWSLambdaARN:                    usualDests,
 		AttributeAWSLambdaColdStart:              usualDests,
 		AttributeAWSLambdaEventSourceARN:         usualDests,
+		AttributeAWSTraceID:                      usualDests,
+		AttributeAWSRequestContextID:             usualDests,
 		AttributeMessageRoutingKey:               usualDests,
 		AttributeMessageQueueName:                usualDests,
 		AttributeMessageHeaders:                  usualDests,
+		AttributeMessageKey:                      usualDests,
+		AttributeMessagePayload:                  usualDests,
+		AttributeMessagePayloadSize:              usualDests,
+		AttributeMessageQueueTime:                usualDests,
 		AttributeMessageExchangeType:             destNone,
 		AttributeMessageReplyTo:                  destNone,
 		AttributeMessageCorrelationID:            destNone,
+		AttributeBatchProcessed:                  usualDests,
+		AttributeBatchFailed:                     usualDests,
+		AttributeBatchSkipped:                    usualDests,
+		AttributeBatchBytes:                      usualDests,
+		AttributeSagaOutcome:                     usualDests,
+		AttributeSagaStepsCompleted:              usualDests,
+		AttributeSagaStepsFailed:                 usualDests,
+		AttributeSagaCompensations:               usualDests,
+		AttributeSagaCompensationsFailed:         usualDests,
+		AttributeDeadLetterQueue:                 usualDests,
+		AttributeDeadLetterReason:                usualDests,
+		AttributeDeadLetterAge:                   usualDests,
+		AttributeDeadLetterAttempts:              usualDests,
+		AttributeDeadLetterTraceID:               usualDests,
+		AttributeRuntimeGCPauseTime:              usualDests,
+		AttributeRuntimeSchedulerLatency:         usualDests,
+		AttributeSystemdUnit:                     usualDests,
+		AttributeSystemdInvocationID:             usualDests,
+		AttributeCloudProvider:                   usualDests,
+		AttributeCloudPlatform:                   usualDests,
+		AttributeCloudResourceID:                 usualDests,
+		AttributeFaasName:                        usualDests,
+		AttributeFaasVersion:                     usualDests,
+		AttributeFaasInstance:                    usualDests,
+		AttributeKubernetesPodName:               usualDests,
+		AttributeKubernetesNamespace:             usualDests,
+		AttributeKubernetesDeployment:            usualDests,
+		AttributeKubernetesNodeName:              usualDests,
+		AttributeKubernetesClusterName:           usualDests,
 		AttributeCodeFunction:                    usualDests,
 		AttributeCodeNamespace:                   usualDests,
 		AttributeCodeFilepath:                    usualDests,
 		AttributeCodeLineno:                      usualDests,
 		AttributeUserID:                          usualDests,
+		AttributeUserEmail:                       destNone,
+		AttributeUserName:                        destNone,
+		AttributeHTTPRequestMethod:               usualDests,
+		AttributeHTTPResponseStatusCode:          usualDests,
+		AttributeURLPath:                         usualDests,
 		AttributeLLM:                             usualDests,
 		AttributeServerAddress:                   usualDests,
 		AttributeServerPort:                      usualDests,
@@ -81,7 +125,9 @@ var (
 		SpanAttributePeerHostname:            usualDests,
 		SpanAttributeHTTPURL:                 usualDests,
 		SpanAttributeHTTPMethod:              usualDests,
+		SpanAttributeURLFull:                 usualDests,
 		spanAttributeQueryParameters:         usualDests,
+		segmentAttributeExplainPlan:          usualDests,
 		SpanAttributeAWSOperation:            usualDests,
 		SpanAttributeAWSRegion:               usualDests,
 		SpanAttributeErrorClass:              usualDests,
@@ -91,6 +137,34 @@ var (
 		SpanAttributeParentAccount:           usualDests,
 		SpanAttributeParentTransportDuration: usualDests,
 		SpanAttributeParentTransportType:     usualDests,
+		SpanAttributeCodeStacktrace:          destSpan,
+
+		SpanAttributeContextDeadlineRemaining: usualDests,
+		SpanAttributeContextError:             usualDests,
+
+		SpanAttributeAWSDynamoDBConsumedCapacity:      usualDests,
+		SpanAttributeAWSDynamoDBConsumedReadCapacity:  usualDests,
+		SpanAttributeAWSDynamoDBConsumedWriteCapacity: usualDests,
+
+		SpanAttributeHTTPDNSDuration:      usualDests,
+		SpanAttributeHTTPConnectDuration:  usualDests,
+		SpanAttributeHTTPTLSDuration:      usualDests,
+		SpanAttributeHTTPTimeToFirstByte:  usualDests,
+		SpanAttributeHTTPConnectionReused: usualDests,
+
+		SpanAttributeGRPCTarget:                  usualDests,
+		SpanAttributeGRPCConnectivityState:       usualDests,
+		SpanAttributeGRPCConnectivityStateChange: usualDests,
+		SpanAttributeRPCSystem:                   usualDests,
+		SpanAttributeRPCService:                  usualDests,
+		SpanAttributeRPCMethod:                   usualDests,
+		SpanAttributeRPCConnectErrorCode:         usualDests,
+		SpanAttributeRPCGRPCStatusCode:           usualDests,
+		SpanAttributeNetPeerName:                 usualDests,
+		SpanAttributeNetPeerPort:                 usualDests,
+		SpanAttributeRedisSlot:                   usualDests,
+		SpanAttributeRedisRedirect:               usualDests,
+		SpanAttributeMessageCount:                usualDests,
 	}
 )
 
@@ -137,6 +211,24 @@ type attributeConfig struct {
 	// over modifiers appearing earlier.
 	wildcardModifiers []*attributeModifier
 	agentDests        map[string]destinationSet
+	httpConventions   SemanticConventionsMode
+}
+
+// legacyHTTPAttributes are the HTTP attributes which are not sent when
+// SemanticConventionsOpenTelemetry is used.
+var legacyHTTPAttributes = []string{
+	AttributeRequestMethod,
+	AttributeRequestURI,
+	AttributeResponseCode,
+	AttributeResponseCodeDeprecated,
+	SpanAttributeHTTPMethod,
+	SpanAttributeHTTPURL,
+}
+
+// addHTTPSemanticConventions returns whether the OpenTelemetry semantic
+// convention names of the HTTP attributes should be added.
+func (c *attributeConfig) addHTTPSemanticConventions() bool {
+	return c != nil && c.httpConventions != SemanticConventionsLegacy
 }
 
 type includeExclude struct {
@@ -242,6 +334,13 @@ func createAttributeConfig(input config, includeEnabled bool) *attributeConfig {
 		c.agentDests[name] = applyAttributeConfig(c, name, dest)
 	}
 
+	c.httpConventions = input.SemanticConventions.HTTP
+	if c.httpConventions == SemanticConventionsOpenTelemetry {
+		for _, name := range legacyHTTPAttributes {
+			c.agentDests[name] = destNone
+		}
+	}
+
 	return c
 }
 
@@ -588,6 +687,13 @@ func requestAgentAttributes(a *attributes, method string, hdrs http.Header, u *u
 		a.Agent.Add(AttributeRequestURI, safeURL(u), nil)
 	}
 
+	if a.config.addHTTPSemanticConventions() {
+		a.Agent.Add(AttributeHTTPRequestMethod, method, nil)
+		if nil != u {
+			a.Agent.Add(AttributeURLPath, u.Path, nil)
+		}
+	}
+
 	if nil == hdrs {
 		return
 	}
@@ -597,6 +703,7 @@ func requestAgentAttributes(a *attributes, method string, hdrs http.Header, u *u
 	a.Agent.Add(AttributeRequestUserAgentDeprecated, hdrs.Get("User-Agent"), nil)
 	a.Agent.Add(AttributeRequestReferer, safeURLFromString(hdrs.Get("Referer")), nil)
 	a.Agent.Add(AttributeRequestHost, host, nil)
+	a.Agent.Add(AttributeAWSTraceID, hdrs.Get(awsTraceHeader), nil)
 
 	if l := getContentLengthFromHeader(hdrs); l >= 0 {
 		a.Agent.Add(AttributeRequestContentLength, "", l)
@@ -637,4 +744,7 @@ func responseCodeAttribute(a *attributes, code int) {
 	}
 	a.Agent.Add(AttributeResponseCode, "", code)
 	a.Agent.Add(AttributeResponseCodeDeprecated, rc, nil)
+	if a.config.addHTTPSemanticConventions() {
+		a.Agent.Add(AttributeHTTPResponseStatusCode, "", code)
+	}
 }
@@ -0,0 +1,143 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package newrelic
+
+import (
+	"sync/atomic"
+)
+
+// batchSummary contains the work-unit counts of a BatchTransaction.
+type batchSummary struct {
+	processed int64
+	failed    int64
+	skipped   int64
+	bytes     int64
+}
+
+// batchMetricPrefix is the prefix of the metrics recorded for each
+// BatchTransaction.  The transaction name and the name of the count follow, eg.
+// "Batch/OtherTransaction/Go/nightly-import/Processed".
+const batchMetricPrefix = "Batch/"
+
+// BatchTransaction is a background transaction for a batch job, such as an ETL
+// job, which counts the work units processed, failed, and skipped and the bytes
+// processed by the job.  When the transaction ends, the counts are added to the
+// transaction as the attributes AttributeBatchProcessed,
+// AttributeBatchFailed, AttributeBatchSkipped, and AttributeBatchBytes, and
+// are recorded as the metrics:
+//
+//	Batch/{transaction name}/Processed
+//	Batch/{transaction name}/Failed
+//	Batch/{transaction name}/Skipped
+//	Batch/{transaction name}/Bytes
+//
+// The counts may be added in multiple goroutines:
+//
+//	batch := app.StartBatchTransaction("nightly-import")
+//	defer batch.End()
+//	for _, record := range records {
+//		if err := load(batch.Transaction(), record); err != nil {
+//			batch.AddFailed(1)
+//			continue
+//		}
+//		batch.AddProcessed(1)
+//		batch.AddBytes(int64(len(record)))
+//	}
+type BatchTransaction struct {
+	// The counts are accessed atomically, and are first to ensure their
+	// alignment on 32-bit platforms.
+	counts batchSummary
+
+	txn *Transaction
+}
+
+// StartBatchTransaction starts a background transaction with the given name
+// which counts the work units of a batch job.  The BatchTransaction must be
+// ended using BatchTransaction.End, rather than by ending its Transaction, for
+// the counts to be recorded.
+func (app *Application) StartBatchTransaction(name string, opts ...TraceOption) *BatchTransaction {
+	return &BatchTransaction{txn: app.StartTransaction(name, opts...)}
+}
+
+// Transaction returns the transaction of the batch job, to be used to create
+// segments, record errors, and add attributes.  It returns nil if the
+// BatchTransaction is nil.
+func (b *BatchTransaction) Transaction() *Transaction {
+	if nil == b {
+		return nil
+	}
+	return b.txn
+}
+
+// AddProcessed adds n work units to the count of processed work units.
+func (b *BatchTransaction) AddProcessed(n int) {
+	if nil != b {
+		atomic.AddInt64(&b.counts.processed, int64(n))
+	}
+}
+
+// AddFailed adds n work units to the count of failed work units.  It does not
+// record an error: use Transaction.NoticeError to do so.
+func (b *BatchTransaction) AddFailed(n int) {
+	if nil != b {
+		atomic.AddInt64(&b.counts.failed, int64(n))
+	}
+}
+
+// AddSkipped adds n work units to the count of skipped work units.
+func (b *BatchTransaction) AddSkipped(n int) {
+	if nil != b {
+		atomic.AddInt64(&b.counts.skipped, int64(n))
+	}
+}
+
+// AddBytes adds n bytes to the count of bytes processed.
+func (b *BatchTransaction) AddBytes(n int64) {
+	if nil != b {
+		atomic.AddInt64(&b.counts.bytes, n)
+	}
+}
+
+// End records the counts of the batch job and ends its transaction.  Counts
+// added after End are not recorded.
+func (b *BatchTransaction) End() {
+	if nil == b || nil == b.txn || nil == b.txn.thread {
+		return
+	}
+	summary := batchSummary{
+		processed: atomic.LoadInt64(&b.counts.processed),
+		failed:    atomic.LoadInt64(&b.counts.failed),
+		skipped:   atomic.LoadInt64(&b.counts.skipped),
+		bytes:     atomic.LoadInt64(&b.counts.bytes),
+	}
+	if err := b.txn.thread.setBatchSummary(summary); nil != err {
+		b.txn.thread.logAPIError(err, "end batch transaction", nil)
+		return
+	}
+	b.txn.End()
+}
+
+// setBatchSummary adds the counts of a batch job to the transaction.
+func (txn *txn) setBatchSummary(s batchSummary) error {
+	txn.Lock()
+	defer txn.Unlock()
+	if txn.finished {
+		return errAlreadyEnded
+	}
+	txn.Attrs.Agent.Add(AttributeBatchProcessed, "", s.processed)
+	txn.Attrs.Agent.Add(AttributeBatchFailed, "", s.failed)
+	txn.Attrs.Agent.Add(AttributeBatchSkipped, "", s.skipped)
+	txn.Attrs.Agent.Add(AttributeBatchBytes, "", s.bytes)
+	txn.batch = &s
+	return nil
+}
+
+// mergeIntoHarvest records the metrics of the batch job of a transaction.
+func (s *batchSummary) mergeIntoHarvest(name string, metrics *metricTable) {
+	prefix := batchMetricPrefix + name + "/"
+	metrics.addValue(prefix+"Processed", "", float64(s.processed), unforced)
+	metrics.addValue(prefix+"Failed", "", float64(s.failed), unforced)
+	metrics.addValue(prefix+"Skipped", "", float64(s.skipped), unforced)
+	metrics.addValue(prefix+"Bytes", "", float64(s.bytes), unforced)
+}
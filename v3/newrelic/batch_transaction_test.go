@@ -0,0 +1,76 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package newrelic
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/newrelic/go-agent/v3/internal"
+)
+
+func TestBatchTransaction(t *testing.T) {
+	app := testApp(nil, ConfigDistributedTracerEnabled(false), t)
+	batch := app.StartBatchTransaction("import")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			batch.AddProcessed(2)
+			batch.AddBytes(100)
+		}()
+	}
+	wg.Wait()
+	batch.AddFailed(1)
+	batch.AddSkipped(4)
+	batch.Transaction().AddAttribute("source", "s3")
+	batch.End()
+	batch.AddProcessed(1)
+
+	app.expectNoLoggedErrors(t)
+	app.ExpectTxnEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"name": "OtherTransaction/Go/import",
+		},
+		UserAttributes: map[string]interface{}{
+			"source": "s3",
+		},
+		AgentAttributes: map[string]interface{}{
+			AttributeBatchProcessed: 6,
+			AttributeBatchFailed:    1,
+			AttributeBatchSkipped:   4,
+			AttributeBatchBytes:     300,
+		},
+	}})
+	app.ExpectMetrics(t, append([]internal.WantMetric{
+		{Name: "Batch/OtherTransaction/Go/import/Processed", Scope: "", Forced: false, Data: []float64{1, 6, 6, 6, 6, 36}},
+		{Name: "Batch/OtherTransaction/Go/import/Failed", Scope: "", Forced: false, Data: []float64{1, 1, 1, 1, 1, 1}},
+		{Name: "Batch/OtherTransaction/Go/import/Skipped", Scope: "", Forced: false, Data: []float64{1, 4, 4, 4, 4, 16}},
+		{Name: "Batch/OtherTransaction/Go/import/Bytes", Scope: "", Forced: false, Data: []float64{1, 300, 300, 300, 300, 90000}},
+	}, []internal.WantMetric{
+		{Name: "OtherTransaction/Go/import", Scope: "", Forced: true, Data: nil},
+		{Name: "OtherTransaction/all", Scope: "", Forced: true, Data: nil},
+		{Name: "OtherTransactionTotalTime/Go/import", Scope: "", Forced: false, Data: nil},
+		{Name: "OtherTransactionTotalTime", Scope: "", Forced: true, Data: nil},
+	}...))
+}
+
+func TestBatchTransactionEnded(t *testing.T) {
+	app := testApp(nil, ConfigDistributedTracerEnabled(false), t)
+	batch := app.StartBatchTransaction("import")
+	batch.Transaction().End()
+	batch.End()
+	app.expectSingleLoggedError(t, "unable to end batch transaction", map[string]interface{}{
+		"reason": errAlreadyEnded.Error(),
+	})
+
+	var nilBatch *BatchTransaction
+	nilBatch.AddProcessed(1)
+	nilBatch.End()
+	if nilBatch.Transaction() != nil {
+		t.Error("nil batch has transaction")
+	}
+}
@@ -0,0 +1,149 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package newrelic
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+)
+
+// breadcrumb is a single entry recorded using Transaction.AddBreadcrumb.
+type breadcrumb struct {
+	When       time.Time
+	Category   string
+	Message    string
+	Attributes map[string]interface{}
+}
+
+var (
+	errTooManyBreadcrumbAttributes = fmt.Errorf("too many breadcrumb attributes: limit is %d",
+		attributeErrorLimit)
+)
+
+func newBreadcrumb(now time.Time, category, message string, attrs map[string]interface{}) (breadcrumb, error) {
+	b := breadcrumb{
+		When:     now,
+		Category: truncateStringValueIfLong(category),
+		Message:  truncateStringValueIfLong(message),
+	}
+	if len(attrs) > attributeErrorLimit {
+		return b, errTooManyBreadcrumbAttributes
+	}
+	if len(attrs) > 0 {
+		b.Attributes = make(map[string]interface{}, len(attrs))
+		for key, val := range attrs {
+			val, err := validateUserAttribute(key, val)
+			if nil != err {
+				return b, err
+			}
+			b.Attributes[key] = val
+		}
+	}
+	return b, nil
+}
+
+func (b *breadcrumb) WriteJSON(buf *bytes.Buffer) {
+	w := jsonFieldsWriter{buf: buf}
+	buf.WriteByte('{')
+	w.intField("timestamp", timeToIntMillis(b.When))
+	w.stringField("category", b.Category)
+	w.stringField("message", b.Message)
+	if len(b.Attributes) > 0 {
+		w.addKey("attributes")
+		buf.WriteByte('{')
+		aw := jsonFieldsWriter{buf: buf}
+		for key, val := range b.Attributes {
+			writeAttributeValueJSON(&aw, key, val)
+		}
+		buf.WriteByte('}')
+	}
+	buf.WriteByte('}')
+}
+
+// breadcrumbs is a bounded ring of the most recent breadcrumbs recorded in a
+// transaction.  Once the ring is full, the oldest breadcrumb is overwritten.
+type breadcrumbs struct {
+	ring  []breadcrumb
+	start int
+	max   int
+}
+
+func newBreadcrumbs(max int) *breadcrumbs {
+	return &breadcrumbs{
+		ring: make([]breadcrumb, 0, max),
+		max:  max,
+	}
+}
+
+func (bs *breadcrumbs) add(b breadcrumb) {
+	if bs.max <= 0 {
+		return
+	}
+	if len(bs.ring) < bs.max {
+		bs.ring = append(bs.ring, b)
+		return
+	}
+	bs.ring[bs.start] = b
+	bs.start = (bs.start + 1) % bs.max
+}
+
+func (bs *breadcrumbs) len() int {
+	if nil == bs {
+		return 0
+	}
+	return len(bs.ring)
+}
+
+// ordered returns the breadcrumbs from oldest to newest.
+func (bs *breadcrumbs) ordered() []breadcrumb {
+	if bs.len() == 0 {
+		return nil
+	}
+	out := make([]breadcrumb, 0, len(bs.ring))
+	out = append(out, bs.ring[bs.start:]...)
+	out = append(out, bs.ring[:bs.start]...)
+	return out
+}
+
+func writeBreadcrumbsJSON(buf *bytes.Buffer, crumbs []breadcrumb) {
+	buf.WriteByte('[')
+	for i := range crumbs {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		crumbs[i].WriteJSON(buf)
+	}
+	buf.WriteByte(']')
+}
+
+// WriteJSON writes the breadcrumbs as an array, oldest first.
+func (bs *breadcrumbs) WriteJSON(buf *bytes.Buffer) {
+	writeBreadcrumbsJSON(buf, bs.ordered())
+}
+
+// limitedJSONString returns the breadcrumbs as a JSON string no longer than
+// limit bytes.  The oldest breadcrumbs are dropped until the result fits so
+// that the string always contains valid JSON.
+func (bs *breadcrumbs) limitedJSONString(limit int) string {
+	crumbs := bs.ordered()
+	buf := &bytes.Buffer{}
+	for i := 0; i < len(crumbs); i++ {
+		buf.Reset()
+		writeBreadcrumbsJSON(buf, crumbs[i:])
+		if buf.Len() <= limit {
+			return buf.String()
+		}
+	}
+	return ""
+}
+
+// breadcrumbsEnabled returns true if the breadcrumbs should be written to
+// the destination provided.
+func breadcrumbsEnabled(bs *breadcrumbs, a *attributes, d destinationSet) bool {
+	if bs.len() == 0 || nil == a {
+		return false
+	}
+	return a.config.agentDests[AttributeBreadcrumbs]&d != 0
+}
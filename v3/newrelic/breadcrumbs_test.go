@@ -0,0 +1,112 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package newrelic
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBreadcrumbJSON(t *testing.T) {
+	b, err := newBreadcrumb(time.Unix(1417136460, 0), "cache", "miss", map[string]interface{}{
+		"key": "user:1",
+	})
+	if nil != err {
+		t.Fatal(err)
+	}
+	buf := &bytes.Buffer{}
+	b.WriteJSON(buf)
+	testExpectedJSON(t, `{
+		"timestamp":1417136460000,
+		"category":"cache",
+		"message":"miss",
+		"attributes":{"key":"user:1"}
+	}`, buf.String())
+}
+
+func TestBreadcrumbNoAttributes(t *testing.T) {
+	b, err := newBreadcrumb(time.Unix(1417136460, 0), "http", "retrying", nil)
+	if nil != err {
+		t.Fatal(err)
+	}
+	buf := &bytes.Buffer{}
+	b.WriteJSON(buf)
+	testExpectedJSON(t, `{"timestamp":1417136460000,"category":"http","message":"retrying"}`, buf.String())
+}
+
+func TestBreadcrumbInvalidAttribute(t *testing.T) {
+	_, err := newBreadcrumb(time.Now(), "cache", "miss", map[string]interface{}{
+		"invalid": struct{}{},
+	})
+	if nil == err {
+		t.Error("expected error for invalid attribute value")
+	}
+}
+
+func TestBreadcrumbTooManyAttributes(t *testing.T) {
+	attrs := make(map[string]interface{})
+	for i := 0; i <= attributeErrorLimit; i++ {
+		attrs[strconv.Itoa(i)] = i
+	}
+	_, err := newBreadcrumb(time.Now(), "cache", "miss", attrs)
+	if err != errTooManyBreadcrumbAttributes {
+		t.Error(err)
+	}
+}
+
+func TestBreadcrumbTruncation(t *testing.T) {
+	long := strings.Repeat("a", attributeValueLengthLimit+10)
+	b, err := newBreadcrumb(time.Now(), long, long, nil)
+	if nil != err {
+		t.Fatal(err)
+	}
+	if len(b.Category) != attributeValueLengthLimit || len(b.Message) != attributeValueLengthLimit {
+		t.Error(len(b.Category), len(b.Message))
+	}
+}
+
+func TestBreadcrumbsRing(t *testing.T) {
+	bs := newBreadcrumbs(3)
+	for i := 0; i < 5; i++ {
+		bs.add(breadcrumb{When: time.Unix(int64(i), 0), Category: "c", Message: strconv.Itoa(i)})
+	}
+	if bs.len() != 3 {
+		t.Fatal(bs.len())
+	}
+	buf := &bytes.Buffer{}
+	bs.WriteJSON(buf)
+	testExpectedJSON(t, `[
+		{"timestamp":2000,"category":"c","message":"2"},
+		{"timestamp":3000,"category":"c","message":"3"},
+		{"timestamp":4000,"category":"c","message":"4"}
+	]`, buf.String())
+}
+
+func TestBreadcrumbsNil(t *testing.T) {
+	var bs *breadcrumbs
+	if bs.len() != 0 {
+		t.Error(bs.len())
+	}
+	if breadcrumbsEnabled(bs, nil, destError) {
+		t.Error("nil breadcrumbs should not be enabled")
+	}
+}
+
+func TestBreadcrumbsLimitedJSONString(t *testing.T) {
+	bs := newBreadcrumbs(3)
+	for i := 0; i < 3; i++ {
+		bs.add(breadcrumb{When: time.Unix(int64(i), 0), Category: "c", Message: strconv.Itoa(i)})
+	}
+	js := bs.limitedJSONString(100)
+	testExpectedJSON(t, `[
+		{"timestamp":1000,"category":"c","message":"1"},
+		{"timestamp":2000,"category":"c","message":"2"}
+	]`, js)
+	if js := bs.limitedJSONString(10); js != "" {
+		t.Error(js)
+	}
+}
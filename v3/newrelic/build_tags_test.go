@@ -0,0 +1,136 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package newrelic
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// buildTagsProgram uses each of the features which can be excluded from the
+// build.
+const buildTagsProgram = "./testdata/buildtags"
+
+type buildTagsBinary struct {
+	size     int64
+	contents []byte
+	symbols  map[string]bool
+}
+
+func buildWithTags(t *testing.T, goTool, dir, tags string) buildTagsBinary {
+	t.Helper()
+	out := filepath.Join(dir, "default")
+	if tags != "" {
+		out = filepath.Join(dir, tags)
+	}
+	build := exec.Command(goTool, "build", "-tags", tags, "-o", out, buildTagsProgram)
+	if output, err := build.CombinedOutput(); err != nil {
+		t.Fatalf("unable to build with tags %q: %v\n%s", tags, err, output)
+	}
+	nm, err := exec.Command(goTool, "tool", "nm", out).Output()
+	if err != nil {
+		t.Fatalf("unable to list the symbols of the binary built with tags %q: %v", tags, err)
+	}
+	contents, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := buildTagsBinary{
+		size:     int64(len(contents)),
+		contents: contents,
+		symbols:  map[string]bool{},
+	}
+	for _, line := range strings.Split(string(nm), "\n") {
+		if fields := strings.Fields(line); len(fields) > 0 {
+			b.symbols[fields[len(fields)-1]] = true
+		}
+	}
+	return b
+}
+
+func TestBuildTagsExcludeFeatures(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds a binary for each build tag")
+	}
+	goTool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go tool not found")
+	}
+
+	const pkg = "github.com/newrelic/go-agent/v3/"
+	type testcase struct {
+		tags string
+		// symbols are the symbols of the code of the excluded features.
+		symbols []string
+		// strings are the strings used only by the excluded features,
+		// whose code is inlined.
+		strings []string
+	}
+	testcases := []testcase{
+		{tags: "newrelic_noinfinitetracing", symbols: []string{"google.golang.org/grpc.NewClient"}},
+		{tags: "newrelic_nocat", symbols: []string{pkg + "newrelic.(*txnCrossProcess).outboundCAT", pkg + "internal/cat.GeneratePathHash"}},
+		{tags: "newrelic_nologforwarding", symbols: []string{pkg + "newrelic.(*logEvent).WriteJSON"}},
+		{tags: "newrelic_noaimonitoring", strings: []string{"LlmFeedbackMessage"}},
+		{tags: "newrelic_nosecurity", symbols: []string{pkg + "newrelic.secureAgent", pkg + "newrelic.(*txn).setCsecData"}},
+	}
+	// newrelic_minimal excludes all of the features.
+	minimal := testcase{tags: "newrelic_minimal"}
+	for _, tc := range testcases {
+		minimal.symbols = append(minimal.symbols, tc.symbols...)
+		minimal.strings = append(minimal.strings, tc.strings...)
+	}
+	testcases = append(testcases, minimal)
+
+	dir := t.TempDir()
+	full := buildWithTags(t, goTool, dir, "")
+	for _, tc := range testcases {
+		b := buildWithTags(t, goTool, dir, tc.tags)
+		if b.size >= full.size {
+			t.Errorf("binary built with %s is not smaller: %d >= %d", tc.tags, b.size, full.size)
+		}
+		for _, sym := range tc.symbols {
+			if !full.symbols[sym] {
+				t.Errorf("symbol %s missing from the binary built without tags", sym)
+			}
+			if b.symbols[sym] {
+				t.Errorf("symbol %s not excluded by %s", sym, tc.tags)
+			}
+		}
+		for _, s := range tc.strings {
+			if !bytes.Contains(full.contents, []byte(s)) {
+				t.Errorf("string %s missing from the binary built without tags", s)
+			}
+			if bytes.Contains(b.contents, []byte(s)) {
+				t.Errorf("string %s not excluded by %s", s, tc.tags)
+			}
+		}
+	}
+}
+
+func TestDisableExcludedFeatures(t *testing.T) {
+	cfg := defaultConfig()
+	cfg.AppName = "my app"
+	cfg.License = testLicenseKey
+	cfg.CrossApplicationTracer.Enabled = true
+	cfg.AIMonitoring.Enabled = true
+	cfg.ApplicationLogging.Forwarding.Enabled = true
+	c, err := newInternalConfig(cfg, func(string) string { return "" }, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The features are only enabled when they are included in the build.
+	if c.CrossApplicationTracer.Enabled != crossAppTracingIncluded {
+		t.Error("incorrect CrossApplicationTracer.Enabled", c.CrossApplicationTracer.Enabled)
+	}
+	if c.AIMonitoring.Enabled != aiMonitoringIncluded {
+		t.Error("incorrect AIMonitoring.Enabled", c.AIMonitoring.Enabled)
+	}
+	if c.ApplicationLogging.Forwarding.Enabled != logForwardingIncluded {
+		t.Error("incorrect ApplicationLogging.Forwarding.Enabled", c.ApplicationLogging.Forwarding.Enabled)
+	}
+}
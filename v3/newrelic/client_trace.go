@@ -0,0 +1,165 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package newrelic
+
+import (
+	"crypto/tls"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// RoundTripperOption values provide optional parameters to NewRoundTripper.
+type RoundTripperOption func(*roundTripperConfig)
+
+type roundTripperConfig struct {
+	clientTrace bool
+}
+
+// WithClientTrace makes the http.RoundTripper returned by NewRoundTripper
+// record the time spent in each phase of the request on its external segment.
+// See ExternalSegment.ClientTrace for the attributes recorded.
+//
+//	client := &http.Client{
+//		Transport: newrelic.NewRoundTripper(nil, newrelic.WithClientTrace()),
+//	}
+func WithClientTrace() RoundTripperOption {
+	return func(cfg *roundTripperConfig) {
+		cfg.clientTrace = true
+	}
+}
+
+// ClientTrace returns an httptrace.ClientTrace which records the time spent in
+// each phase of the external request on the segment.  Add it to the context
+// of the request before performing the request:
+//
+//	segment := newrelic.StartExternalSegment(txn, req)
+//	req = req.WithContext(httptrace.WithClientTrace(req.Context(), segment.ClientTrace()))
+//	resp, err := client.Do(req)
+//	segment.Response = resp
+//	segment.End()
+//
+// The durations, in seconds, are added to the span event of the segment as
+// the attributes "http.dnsDuration", "http.connectDuration",
+// "http.tlsDuration", and "http.timeToFirstByte".  The time to first byte
+// is measured from when the request has been written to when the first byte of
+// the response is read, and so excludes the time spent connecting.  When an
+// idle connection is reused the request has no DNS lookup, connection, or TLS
+// handshake, and "http.connectionReused" is true.
+func (s *ExternalSegment) ClientTrace() *httptrace.ClientTrace {
+	if nil == s {
+		return &httptrace.ClientTrace{}
+	}
+	if nil == s.timings {
+		s.timings = &externalTimings{}
+	}
+	return s.timings.clientTrace()
+}
+
+// externalTimings records the phases of an external request.  The hooks of
+// an httptrace.ClientTrace may be called from different goroutines, and after
+// the request has completed, hence the lock.
+type externalTimings struct {
+	sync.Mutex
+	dnsStart     time.Time
+	connectStart time.Time
+	tlsStart     time.Time
+	wroteRequest time.Time
+
+	dns       time.Duration
+	connect   time.Duration
+	tls       time.Duration
+	firstByte time.Duration
+	gotConn   bool
+	reused    bool
+}
+
+func (et *externalTimings) clientTrace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) {
+			et.Lock()
+			defer et.Unlock()
+			et.dnsStart = time.Now()
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			et.Lock()
+			defer et.Unlock()
+			if !et.dnsStart.IsZero() {
+				et.dns = time.Since(et.dnsStart)
+			}
+		},
+		ConnectStart: func(network, addr string) {
+			et.Lock()
+			defer et.Unlock()
+			// Several addresses may be dialed: the connection time
+			// is measured from the first attempt.
+			if et.connectStart.IsZero() {
+				et.connectStart = time.Now()
+			}
+		},
+		ConnectDone: func(network, addr string, err error) {
+			et.Lock()
+			defer et.Unlock()
+			if nil == err && !et.connectStart.IsZero() {
+				et.connect = time.Since(et.connectStart)
+			}
+		},
+		TLSHandshakeStart: func() {
+			et.Lock()
+			defer et.Unlock()
+			et.tlsStart = time.Now()
+		},
+		TLSHandshakeDone: func(_ tls.ConnectionState, err error) {
+			et.Lock()
+			defer et.Unlock()
+			if nil == err && !et.tlsStart.IsZero() {
+				et.tls = time.Since(et.tlsStart)
+			}
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			et.Lock()
+			defer et.Unlock()
+			et.gotConn = true
+			et.reused = info.Reused
+		},
+		WroteRequest: func(httptrace.WroteRequestInfo) {
+			et.Lock()
+			defer et.Unlock()
+			et.wroteRequest = time.Now()
+		},
+		GotFirstResponseByte: func() {
+			et.Lock()
+			defer et.Unlock()
+			if !et.wroteRequest.IsZero() {
+				et.firstByte = time.Since(et.wroteRequest)
+			}
+		},
+	}
+}
+
+// addAttributes adds the durations of the phases which occurred to the
+// attributes.
+func (et *externalTimings) addAttributes(attrs *spanAttributeMap) {
+	if nil == et {
+		return
+	}
+	et.Lock()
+	defer et.Unlock()
+
+	if et.dns > 0 {
+		attrs.addFloat(SpanAttributeHTTPDNSDuration, et.dns.Seconds())
+	}
+	if et.connect > 0 {
+		attrs.addFloat(SpanAttributeHTTPConnectDuration, et.connect.Seconds())
+	}
+	if et.tls > 0 {
+		attrs.addFloat(SpanAttributeHTTPTLSDuration, et.tls.Seconds())
+	}
+	if et.firstByte > 0 {
+		attrs.addFloat(SpanAttributeHTTPTimeToFirstByte, et.firstByte.Seconds())
+	}
+	if et.gotConn {
+		attrs.addBool(SpanAttributeHTTPConnectionReused, et.reused)
+	}
+}
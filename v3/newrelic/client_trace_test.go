@@ -0,0 +1,147 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package newrelic
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httptrace"
+	"testing"
+	"time"
+
+	"github.com/newrelic/go-agent/v3/internal"
+)
+
+func TestRoundTripperWithClientTrace(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("hello"))
+	}))
+	defer srv.Close()
+
+	app := testApp(distributedTracingReplyFields, enableBetterCAT, t)
+	client := srv.Client()
+	client.Transport = NewRoundTripper(client.Transport, WithClientTrace())
+
+	txn := app.StartTransaction("hello")
+	for i := 0; i < 2; i++ {
+		req, err := http.NewRequest("GET", srv.URL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := client.Do(RequestWithTransactionContext(req, txn))
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Read the body so that the connection is reused.
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+	txn.End()
+
+	intrinsics := map[string]interface{}{
+		"name":          internal.MatchAnything,
+		"category":      "http",
+		"component":     "http",
+		"span.kind":     "client",
+		"parentId":      internal.MatchAnything,
+		"transactionId": internal.MatchAnything,
+	}
+	app.ExpectSpanEvents(t, []internal.WantEvent{
+		{
+			Intrinsics: intrinsics,
+			AgentAttributes: map[string]interface{}{
+				"http.url":              srv.URL,
+				"http.method":           "GET",
+				"http.statusCode":       200,
+				"http.connectDuration":  internal.MatchAnything,
+				"http.tlsDuration":      internal.MatchAnything,
+				"http.timeToFirstByte":  internal.MatchAnything,
+				"http.connectionReused": false,
+			},
+		},
+		{
+			Intrinsics: intrinsics,
+			AgentAttributes: map[string]interface{}{
+				"http.url":              srv.URL,
+				"http.method":           "GET",
+				"http.statusCode":       200,
+				"http.timeToFirstByte":  internal.MatchAnything,
+				"http.connectionReused": true,
+			},
+		},
+		{
+			Intrinsics: map[string]interface{}{
+				"name":             "OtherTransaction/Go/hello",
+				"category":         "generic",
+				"nr.entryPoint":    true,
+				"transaction.name": "OtherTransaction/Go/hello",
+				"transactionId":    internal.MatchAnything,
+			},
+		},
+	})
+}
+
+func TestRoundTripperWithoutClientTrace(t *testing.T) {
+	app := testApp(distributedTracingReplyFields, enableBetterCAT, t)
+	txn := app.StartTransaction("hello")
+	req, err := http.NewRequest("GET", "http://example.com/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &http.Client{
+		Transport: NewRoundTripper(roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+			if trace := httptrace.ContextClientTrace(r.Context()); trace != nil {
+				t.Error("unexpected client trace")
+			}
+			return &http.Response{StatusCode: 200}, nil
+		})),
+	}
+	if _, err := client.Do(RequestWithTransactionContext(req, txn)); err != nil {
+		t.Fatal(err)
+	}
+	txn.End()
+}
+
+func TestExternalTimingsAttributes(t *testing.T) {
+	var et *externalTimings
+	var attrs spanAttributeMap
+	et.addAttributes(&attrs)
+	if len(attrs) != 0 {
+		t.Error("unexpected attributes for nil timings:", attrs)
+	}
+
+	et = &externalTimings{}
+	trace := et.clientTrace()
+	trace.DNSStart(httptrace.DNSStartInfo{Host: "example.com"})
+	trace.DNSDone(httptrace.DNSDoneInfo{})
+	trace.GotConn(httptrace.GotConnInfo{})
+	trace.WroteRequest(httptrace.WroteRequestInfo{})
+	time.Sleep(time.Millisecond)
+	trace.GotFirstResponseByte()
+
+	et.addAttributes(&attrs)
+	if _, ok := attrs[SpanAttributeHTTPDNSDuration]; !ok {
+		t.Error("missing dns duration")
+	}
+	if _, ok := attrs[SpanAttributeHTTPTimeToFirstByte]; !ok {
+		t.Error("missing time to first byte")
+	}
+	if _, ok := attrs[SpanAttributeHTTPConnectDuration]; ok {
+		t.Error("unexpected connect duration")
+	}
+	if _, ok := attrs[SpanAttributeHTTPTLSDuration]; ok {
+		t.Error("unexpected tls duration")
+	}
+	if _, ok := attrs[SpanAttributeHTTPConnectionReused]; !ok {
+		t.Error("missing connection reused")
+	}
+}
+
+func TestNilExternalSegmentClientTrace(t *testing.T) {
+	var s *ExternalSegment
+	if trace := s.ClientTrace(); trace == nil {
+		t.Error("nil client trace")
+	}
+}
@@ -4,6 +4,7 @@
 package newrelic
 
 import (
+	"context"
 	"errors"
 	"reflect"
 	"runtime"
@@ -91,6 +92,17 @@ type traceOptSet struct {
 	IgnoredPrefixes  []string
 	PathPrefixes     []string
 	LocationCallback func() *CodeLocation
+
+	// The following are per-route options used by WrapHandle and
+	// WrapHandleFunc.
+	TransactionName    string
+	IgnoreTransaction  bool
+	SampledOverride    *bool
+	AttributeInjectors []AttributeInjector
+	TransactionNamer   TransactionNamer
+
+	// Context is set by WithContext.
+	Context context.Context
 }
 
 //
@@ -422,6 +434,21 @@ func withPreparedOptions(newOptions *traceOptSet) TraceOption {
 			if newOptions.PathPrefixes != nil {
 				o.PathPrefixes = newOptions.PathPrefixes
 			}
+			if newOptions.TransactionName != "" {
+				o.TransactionName = newOptions.TransactionName
+			}
+			if newOptions.IgnoreTransaction {
+				o.IgnoreTransaction = true
+			}
+			if newOptions.SampledOverride != nil {
+				o.SampledOverride = newOptions.SampledOverride
+			}
+			if newOptions.AttributeInjectors != nil {
+				o.AttributeInjectors = newOptions.AttributeInjectors
+			}
+			if newOptions.TransactionNamer != nil {
+				o.TransactionNamer = newOptions.TransactionNamer
+			}
 		}
 	}
 }
@@ -572,6 +599,10 @@ func resolveCLMTraceOptions(options []TraceOption) *traceOptSet {
 func reportCodeLevelMetrics(tOpts traceOptSet, run *appRun, setAttr func(string, string, interface{})) {
 	var location CodeLocation
 	var locationp *CodeLocation
+	var filter stackTraceFilter
+	if run != nil {
+		filter = newStackTraceFilter(&run.Config.Config)
+	}
 
 	if tOpts.LocationCallback != nil {
 		locationp = tOpts.LocationCallback()
@@ -603,6 +634,9 @@ func reportCodeLevelMetrics(tOpts traceOptSet, run *appRun, setAttr func(string,
 			// skip out to first non-agent frame, unless that IS the top-most frame
 			for moreToRead {
 				frame, moreToRead = frames.Next()
+				if moreToRead && filter.skip(frame.Function, frame.File) {
+					continue
+				}
 				if func() bool {
 					for _, eachPrefix := range tOpts.IgnoredPrefixes {
 						if strings.HasPrefix(frame.Function, eachPrefix) {
@@ -630,14 +664,19 @@ func reportCodeLevelMetrics(tOpts traceOptSet, run *appRun, setAttr func(string,
 	}
 
 	// scan for any requested suppression of leading parts of file pathnames
+	trimmed := false
 	if tOpts.PathPrefixes != nil {
 		for _, prefix := range tOpts.PathPrefixes {
 			if pi := strings.Index(location.FilePath, prefix); pi >= 0 {
 				location.FilePath = location.FilePath[pi:]
+				trimmed = true
 				break
 			}
 		}
 	}
+	if !trimmed {
+		location.FilePath = filter.filePath(location.FilePath)
+	}
 
 	ns := strings.LastIndex(location.Function, ".")
 	function := location.Function
@@ -313,12 +313,26 @@ var (
 
 // connectAttempt tries to connect an application.
 func connectAttempt(config config, cs rpmControls) (*internal.ConnectReply, *rpmResponse) {
+	preconnect, resp := preconnectAttempt(config, cs)
+	if resp.GetError() != nil {
+		return nil, resp
+	}
+	return connectToCollector(config, cs, preconnect)
+}
+
+// preconnectAttempt requests the collector and the security policies of the
+// application from the preconnect host.
+func preconnectAttempt(config config, cs rpmControls) (internal.PreconnectReply, *rpmResponse) {
+	var preconnect struct {
+		Preconnect internal.PreconnectReply `json:"return_value"`
+	}
+
 	preconnectData, err := json.Marshal([]preconnectRequest{{
 		SecurityPoliciesToken: config.SecurityPoliciesToken,
 		HighSecurity:          config.HighSecurity,
 	}})
 	if nil != err {
-		return nil, newRPMResponse(fmt.Errorf("unable to marshal preconnect data: %v", err))
+		return preconnect.Preconnect, newRPMResponse(fmt.Errorf("unable to marshal preconnect data: %v", err))
 	}
 
 	call := rpmCmd{
@@ -330,12 +344,9 @@ func connectAttempt(config config, cs rpmControls) (*internal.ConnectReply, *rpm
 
 	resp := collectorRequest(call, cs)
 	if resp.GetError() != nil {
-		return nil, resp
+		return preconnect.Preconnect, resp
 	}
 
-	var preconnect struct {
-		Preconnect internal.PreconnectReply `json:"return_value"`
-	}
 	err = json.Unmarshal(resp.body, &preconnect)
 	if nil != err {
 		resp := newRPMResponse(fmt.Errorf("unable to process preconnect reply: %v", err))
@@ -343,24 +354,32 @@ func connectAttempt(config config, cs rpmControls) (*internal.ConnectReply, *rpm
 			resp.DisconnectSecurityPolicy()
 		}
 		// Certain security policy errors must be treated as a disconnect.
-		return nil, resp
+		return preconnect.Preconnect, resp
 	}
+	return preconnect.Preconnect, resp
+}
 
-	js, err := config.createConnectJSON(preconnect.Preconnect.SecurityPolicies.PointerIfPopulated())
+// connectToCollector connects the application to the collector returned by
+// the preconnect.
+func connectToCollector(config config, cs rpmControls, preconnect internal.PreconnectReply) (*internal.ConnectReply, *rpmResponse) {
+	js, err := config.createConnectJSON(preconnect.SecurityPolicies.PointerIfPopulated())
 	if nil != err {
 		return nil, newRPMResponse(fmt.Errorf("unable to create connect data: %v", err))
 	}
 
-	call.Collector = preconnect.Preconnect.Collector
-	call.Data = js
-	call.Name = cmdConnect
+	call := rpmCmd{
+		Name:           cmdConnect,
+		Collector:      preconnect.Collector,
+		Data:           js,
+		MaxPayloadSize: internal.MaxPayloadSizeInBytes,
+	}
 
-	resp = collectorRequest(call, cs)
+	resp := collectorRequest(call, cs)
 	if resp.GetError() != nil {
 		return nil, resp
 	}
 
-	reply, err := internal.UnmarshalConnectReply(resp.body, preconnect.Preconnect)
+	reply, err := internal.UnmarshalConnectReply(resp.body, preconnect)
 	if nil != err {
 		return nil, newRPMResponse(err)
 	}
@@ -0,0 +1,71 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package newrelic
+
+import (
+	"crypto/tls"
+	"net/http"
+)
+
+// fipsCipherSuites are the TLS 1.2 cipher suites approved by FIPS 140-2 which
+// are supported by the New Relic servers.
+var fipsCipherSuites = []uint16{
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+}
+
+// fipsCurves are the elliptic curves approved by FIPS 140-2.
+var fipsCurves = []tls.CurveID{
+	tls.CurveP256,
+	tls.CurveP384,
+}
+
+// collectorTransport returns the transport used to communicate with the New
+// Relic servers, before the CollectorTLS settings are applied.
+func collectorTransport(c Config) http.RoundTripper {
+	if nil != c.HTTPClient {
+		if nil == c.HTTPClient.Transport {
+			return http.DefaultTransport
+		}
+		return c.HTTPClient.Transport
+	}
+	if nil != c.Transport {
+		return c.Transport
+	}
+	return collectorDefaultTransport
+}
+
+// collectorTLSTransport returns a copy of the transport using the
+// CollectorTLS settings, or the transport itself if there are none or it is
+// not an *http.Transport.
+func collectorTLSTransport(c Config, rt http.RoundTripper) http.RoundTripper {
+	transport, ok := rt.(*http.Transport)
+	if !ok || (!c.CollectorTLS.FIPSMode && nil == c.CollectorTLS.Config) {
+		return rt
+	}
+	transport = transport.Clone()
+	transport.TLSClientConfig = collectorTLSConfig(c, transport.TLSClientConfig)
+	return transport
+}
+
+// collectorTLSConfig returns the TLS configuration for the New Relic servers,
+// starting from CollectorTLS.Config if set, or the configuration of the
+// transport otherwise.
+func collectorTLSConfig(c Config, transportConfig *tls.Config) *tls.Config {
+	cfg := &tls.Config{}
+	if nil != c.CollectorTLS.Config {
+		cfg = c.CollectorTLS.Config.Clone()
+	} else if nil != transportConfig {
+		cfg = transportConfig.Clone()
+	}
+	if c.CollectorTLS.FIPSMode {
+		cfg.MinVersion = tls.VersionTLS12
+		cfg.MaxVersion = tls.VersionTLS12
+		cfg.CipherSuites = fipsCipherSuites
+		cfg.CurvePreferences = fipsCurves
+	}
+	return cfg
+}
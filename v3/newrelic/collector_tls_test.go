@@ -0,0 +1,137 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package newrelic
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/newrelic/go-agent/v3/internal"
+)
+
+func collectorClientTLSConfig(t *testing.T, client *http.Client) *tls.Config {
+	transport, ok := client.Transport.(*http.Transport)
+	if !ok {
+		t.Fatalf("incorrect transport: %T", client.Transport)
+	}
+	return transport.TLSClientConfig
+}
+
+func TestCollectorTLSFIPSMode(t *testing.T) {
+	cfg := defaultConfig()
+	ConfigFIPSMode(true)(&cfg)
+
+	client := newCollectorClient(cfg)
+	if client.Transport == collectorDefaultTransport {
+		t.Fatal("default transport modified instead of copied")
+	}
+	tlsConfig := collectorClientTLSConfig(t, client)
+	if tlsConfig.MinVersion != tls.VersionTLS12 || tlsConfig.MaxVersion != tls.VersionTLS12 {
+		t.Error("incorrect TLS versions:", tlsConfig.MinVersion, tlsConfig.MaxVersion)
+	}
+	if !reflect.DeepEqual(tlsConfig.CipherSuites, fipsCipherSuites) {
+		t.Error("incorrect cipher suites:", tlsConfig.CipherSuites)
+	}
+	if !reflect.DeepEqual(tlsConfig.CurvePreferences, fipsCurves) {
+		t.Error("incorrect curves:", tlsConfig.CurvePreferences)
+	}
+	if c := collectorDefaultTransport.TLSClientConfig; nil != c && (0 != c.MaxVersion || nil != c.CipherSuites) {
+		t.Errorf("default transport modified: %+v", c)
+	}
+}
+
+func TestCollectorTLSConfig(t *testing.T) {
+	pool := x509.NewCertPool()
+	custom := &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS13}
+	transport := &http.Transport{}
+	cfg := defaultConfig()
+	ConfigHTTPTransport(transport)(&cfg)
+	ConfigCollectorTLS(custom)(&cfg)
+
+	tlsConfig := collectorClientTLSConfig(t, newCollectorClient(cfg))
+	if tlsConfig == custom || tlsConfig.RootCAs != pool || tlsConfig.MinVersion != tls.VersionTLS13 {
+		t.Errorf("incorrect TLS config: %+v", tlsConfig)
+	}
+	if c := transport.TLSClientConfig; nil != c && nil != c.RootCAs {
+		t.Errorf("transport modified: %+v", c)
+	}
+
+	ConfigFIPSMode(true)(&cfg)
+	tlsConfig = collectorClientTLSConfig(t, newCollectorClient(cfg))
+	if tlsConfig.RootCAs != pool || tlsConfig.MinVersion != tls.VersionTLS12 {
+		t.Errorf("incorrect FIPS TLS config: %+v", tlsConfig)
+	}
+	if custom.MinVersion != tls.VersionTLS13 || nil != custom.CipherSuites {
+		t.Errorf("custom TLS config modified: %+v", custom)
+	}
+
+	js, err := json.Marshal(settings(cfg))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(js), `"CollectorTLS":{"Config":"*tls.Config","FIPSMode":true}`) {
+		t.Error("incorrect CollectorTLS setting:", string(js))
+	}
+}
+
+func TestCollectorTLSTransportConfig(t *testing.T) {
+	// The TLS config of the transport is kept when no config is given.
+	pool := x509.NewCertPool()
+	cfg := defaultConfig()
+	ConfigHTTPClient(&http.Client{Transport: &http.Transport{
+		TLSClientConfig: &tls.Config{RootCAs: pool},
+	}})(&cfg)
+	ConfigFIPSMode(true)(&cfg)
+	if tlsConfig := collectorClientTLSConfig(t, newCollectorClient(cfg)); tlsConfig.RootCAs != pool || tlsConfig.MaxVersion != tls.VersionTLS12 {
+		t.Errorf("incorrect TLS config: %+v", tlsConfig)
+	}
+
+	// A client without a transport uses a copy of the default transport.
+	cfg.HTTPClient = &http.Client{}
+	client := newCollectorClient(cfg)
+	if client.Transport == http.DefaultTransport {
+		t.Fatal("http.DefaultTransport used without the FIPS settings")
+	}
+	if tlsConfig := collectorClientTLSConfig(t, client); tlsConfig.MaxVersion != tls.VersionTLS12 {
+		t.Errorf("incorrect TLS config: %+v", tlsConfig)
+	}
+	ConfigFIPSMode(false)(&cfg)
+	if client := newCollectorClient(cfg); nil != client.Transport {
+		t.Error("transport set without CollectorTLS settings:", client.Transport)
+	}
+}
+
+func TestCollectorTLSValidate(t *testing.T) {
+	cfg := defaultConfig()
+	cfg.License = "0123456789012345678901234567890123456789"
+	cfg.AppName = "app"
+	cfg.Transport = roundTripperFunc(func(*http.Request) (*http.Response, error) { return nil, nil })
+	if err := cfg.validate(); nil != err {
+		t.Error("unexpected error without CollectorTLS settings:", err)
+	}
+	ConfigFIPSMode(true)(&cfg)
+	if err := cfg.validate(); err != errCollectorTLSTransport {
+		t.Error("incorrect error for custom transport:", err)
+	}
+	cfg.Transport = &http.Transport{}
+	if err := cfg.validate(); nil != err {
+		t.Error("unexpected error for *http.Transport:", err)
+	}
+}
+
+func TestCollectorTLSFIPSModeDisablesCAT(t *testing.T) {
+	cfg := defaultConfig()
+	cfg.DistributedTracer.Enabled = false
+	cfg.CrossApplicationTracer.Enabled = true
+	ConfigFIPSMode(true)(&cfg)
+	run := newAppRun(config{Config: cfg}, internal.ConnectReplyDefaults())
+	if run.Config.CrossApplicationTracer.Enabled {
+		t.Error("cross application tracing enabled in FIPS mode")
+	}
+}
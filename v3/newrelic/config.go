@@ -4,6 +4,7 @@
 package newrelic
 
 import (
+	"crypto/tls"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -62,6 +63,14 @@ type Config struct {
 	// https://docs.newrelic.com/docs/agents/manage-apm-agents/configuration/enable-configurable-security-policies
 	SecurityPoliciesToken string
 
+	// ServerSideConfigCallback, if set, is called when the application
+	// connects with server-side configuration, set in the New Relic UI,
+	// which differs from the server-side configuration of the previous
+	// connection.  The server-side settings override the local settings
+	// for the connection.  The callback is called by the goroutine which
+	// harvests the data, so it must return quickly.
+	ServerSideConfigCallback func(ServerSideConfigUpdate) `json:"-"`
+
 	// CustomInsightsEvents controls the behavior of
 	// Application.RecordCustomEvent.
 	//
@@ -73,6 +82,20 @@ type Config struct {
 		Enabled bool
 		// MaxSamplesStored sets the desired maximum custom event samples stored
 		MaxSamplesStored int
+		// AllowedEventTypes, if not empty, lists the only event types
+		// which RecordCustomEvent will collect.
+		AllowedEventTypes []string
+		// DeniedEventTypes lists the event types which RecordCustomEvent
+		// will not collect, even if they are allowed.
+		DeniedEventTypes []string
+		// MaxSamplesPerEventType limits the number of custom events of
+		// each type listed collected during a harvest cycle, so that a
+		// single event type cannot use all of MaxSamplesStored.
+		//
+		// The events which are not collected because of these settings
+		// are counted by the metric
+		// "Supportability/Events/Customer/Dropped/<event type>".
+		MaxSamplesPerEventType map[string]int
 	}
 
 	// TransactionEvents controls the behavior of transaction analytics
@@ -88,6 +111,87 @@ type Config struct {
 		MaxSamplesStored int
 	}
 
+	// TransactionNameCardinality guards against an explosion in the number
+	// of unique transaction names, for example when identifiers leak into
+	// names because transactions are named after request paths.  Path
+	// segments of transaction names which look like identifiers, such as
+	// numbers, UUIDs, and long hexadecimal strings, are replaced with "*"
+	// to find the parameterized form of each name.  Once more than
+	// MaxNamesPerPattern names share a parameterized form, transactions
+	// with any of those names are named using the parameterized form
+	// instead, for example "WebTransaction/Go/GET /users/*".  Once there are
+	// MaxNames unique names, transactions with new names are named "other",
+	// for example "WebTransaction/Go/other".  When names are collapsed, a
+	// warning identifying the parameterized form is logged and the
+	// supportability metric
+	// "Supportability/Go/TransactionNameCardinality/Collapsed/" followed by
+	// the parameterized form is recorded.
+	TransactionNameCardinality struct {
+		// Enabled controls whether the guard is enabled.  The default is
+		// false, since enabling it changes the names of existing
+		// transactions once there are too many.
+		Enabled bool
+		// MaxNames is the maximum number of unique transaction names.  The
+		// default is 2000.
+		MaxNames int
+		// MaxNamesPerPattern is the maximum number of unique transaction
+		// names which share a parameterized form.  The default is 100.
+		MaxNamesPerPattern int
+	}
+
+	// TransactionNameRules rewrite the names of transactions locally, in the
+	// same way as the transaction name rules sent by New Relic, for example
+	// to collapse the path parameters of applications which name
+	// transactions after the request URL.  The rules are applied in order to
+	// the full names of transactions, such as
+	// "WebTransaction/Go/GET /users/123", after the rules sent by New Relic.
+	// The following rule names that transaction
+	// "WebTransaction/Go/GET /users/{id}":
+	//
+	//	newrelic.TransactionNameRule{
+	//		MatchExpression: "^[0-9]+$",
+	//		Replacement:     "{id}",
+	//		EachSegment:     true,
+	//	}
+	TransactionNameRules []TransactionNameRule
+
+	// IgnoredTransactionNames lists patterns of the final names of the
+	// transactions to ignore, such as health and readiness probes, as if
+	// Transaction.Ignore had been called.  Patterns surrounded by slashes
+	// are regular expressions, and all others are globs in which "*"
+	// matches any sequence of characters and "?" matches any single
+	// character.  For example:
+	//
+	//	"WebTransaction/Go/GET /healthz"
+	//	"*/readyz"
+	//	"/^WebTransaction/Go/GET /(health|ready)z$/"
+	//
+	// Transactions whose names match are not sampled, so that they do not
+	// consume the sampling budget, and record no data when they end.
+	IgnoredTransactionNames []string
+
+	// TenantAccounting records the usage of each tenant of a multi-tenant
+	// application as metrics, for example to support chargeback.  When
+	// enabled, TenantCallback is called with each finished transaction to
+	// find its tenant.  The count and duration of the transactions of each
+	// tenant are recorded in the metric "Tenant/<tenant>/Transactions", and
+	// the count of transactions with errors in "Tenant/<tenant>/Errors".
+	// Once there are MaxTenants tenants, new tenants are recorded as the
+	// tenant "Other".
+	TenantAccounting struct {
+		// Enabled controls whether tenant accounting is enabled.  The
+		// default is false.
+		Enabled bool
+		// TenantCallback returns the tenant of a transaction, or an
+		// empty string if the transaction does not belong to a tenant.
+		// It is called by Transaction.End, in the goroutine ending the
+		// transaction.
+		TenantCallback `json:"-"`
+		// MaxTenants is the maximum number of tenants.  The default is
+		// 100.
+		MaxTenants int
+	}
+
 	// ErrorCollector controls the capture of errors.
 	ErrorCollector struct {
 		// Enabled controls whether errors are captured.  This setting
@@ -106,6 +210,12 @@ type Config struct {
 		// be silently captured without impacting any of those. Note that setting an error
 		// code as Ignored will prevent it from being collected, even if its expected.
 		ExpectStatusCodes []int
+		// ExpectClasses controls which error classes should not impact
+		// your error metrics, apdex score and alerts.  Errors whose class,
+		// as reported by ErrorClasser or the type of the error's cause,
+		// exactly matches one of these are captured as expected errors,
+		// as if they had been noticed using NoticeExpectedError.
+		ExpectClasses []string
 		// Attributes controls the attributes included with errors.
 		Attributes AttributeDestinationConfig
 		// RecordPanics controls whether or not a deferred
@@ -133,6 +243,28 @@ type Config struct {
 		ErrorGroupCallback `json:"-"`
 	}
 
+	// BackgroundApdex controls the Apdex scoring of background
+	// transactions, which are not scored by default.  Scored background
+	// transactions have the "nr.apdexPerfZone" attribute, and are recorded
+	// in the "ApdexOther" metrics so that they do not affect the Apdex
+	// score of web transactions.
+	//
+	// https://docs.newrelic.com/docs/apm/new-relic-apm/apdex/apdex-measure-user-satisfaction
+	BackgroundApdex struct {
+		// Enabled controls whether background transactions are given an
+		// Apdex score.
+		Enabled bool
+		// Threshold is the Apdex threshold of background transactions.  If
+		// it is zero, the Apdex threshold of the application is used.
+		Threshold time.Duration
+		// MessageThreshold is the Apdex threshold of background
+		// transactions which consume messages: transactions which called
+		// Transaction.SetMessageQueueTime, or which accepted distributed
+		// tracing headers using a message queue transport such as
+		// TransportKafka.  If it is zero, Threshold is used.
+		MessageThreshold time.Duration
+	}
+
 	// TransactionTracer controls the capture of transaction traces.
 	TransactionTracer struct {
 		// Enabled controls whether transaction traces are captured.
@@ -189,9 +321,33 @@ type Config struct {
 	HostDisplayName string
 
 	// Transport customizes communication with the New Relic servers.  This may
-	// be used to configure a proxy.
+	// be used to configure a proxy, a CA bundle, or a client certificate
+	// for mutual TLS.
 	Transport http.RoundTripper
 
+	// HTTPClient is the client used to communicate with the New Relic
+	// servers.  When set, Transport is ignored.  The agent uses its own
+	// timeout for requests if the Timeout of the client is zero.
+	HTTPClient *http.Client
+
+	// CollectorTLS controls the TLS settings used to communicate with the
+	// New Relic servers.  These settings apply to the default transport, to
+	// a Transport which is an *http.Transport, and to an HTTPClient whose
+	// Transport is nil or an *http.Transport.
+	CollectorTLS struct {
+		// FIPSMode restricts the connections to TLS 1.2 using the cipher
+		// suites and curves approved by FIPS 140-2.  It also disables
+		// cross application tracing, whose path hash uses MD5.  For the
+		// cryptography itself to use a validated module, build or run the
+		// application with the FIPS 140 mode of the Go runtime.
+		FIPSMode bool
+		// Config is the TLS configuration used for the connections, for
+		// example to set the trusted root certificates.  It is cloned
+		// before use.  When FIPSMode is true, its versions, cipher
+		// suites, and curves are replaced.
+		Config *tls.Config
+	}
+
 	// Utilization controls the detection and gathering of system
 	// information.
 	Utilization struct {
@@ -199,13 +355,14 @@ type Config struct {
 		// AWS.
 		DetectAWS bool
 		// DetectAzure controls whether the Application attempts to detect
-		// Azure.
+		// Azure, including Azure Container Apps and Azure Functions
+		// custom handlers.
 		DetectAzure bool
 		// DetectPCF controls whether the Application attempts to detect
 		// PCF.
 		DetectPCF bool
 		// DetectGCP controls whether the Application attempts to detect
-		// GCP.
+		// GCP, including Cloud Run.
 		DetectGCP bool
 		// DetectDocker controls whether the Application attempts to
 		// detect Docker.
@@ -213,6 +370,14 @@ type Config struct {
 		// DetectKubernetes controls whether the Application attempts to
 		// detect Kubernetes.
 		DetectKubernetes bool
+		// DetectECS controls whether the Application attempts to detect
+		// ECS, including Fargate, using the task metadata endpoint.
+		DetectECS bool
+		// DetectSystemd controls whether the Application attempts to
+		// detect the systemd unit running it, which is added to
+		// transactions as the AttributeSystemdUnit and
+		// AttributeSystemdInvocationID attributes.
+		DetectSystemd bool
 
 		// These settings provide system information when custom values
 		// are required.
@@ -221,6 +386,25 @@ type Config struct {
 		BillingHostname   string
 	}
 
+	// KubernetesMetadata controls the attributes describing the Kubernetes
+	// pod running the application, which correlate the application with
+	// the Kubernetes integration.  When enabled, the pod name, namespace,
+	// deployment, node, and cluster are added to every transaction, span,
+	// and log event.  They are read from the environment variables set by
+	// the Kubernetes metadata injection of New Relic, or from the
+	// POD_NAME, POD_NAMESPACE, and NODE_NAME variables set using the
+	// Downward API, and from the service account files of the pod.
+	KubernetesMetadata struct {
+		// Enabled controls whether the Kubernetes attributes are added.
+		// Default is false.
+		Enabled bool
+		// ClusterName is the name of the cluster, which is not
+		// available in pods.  If empty, it is read from the
+		// NEW_RELIC_METADATA_KUBERNETES_CLUSTER_NAME environment
+		// variable.
+		ClusterName string
+	}
+
 	// Heroku controls the behavior of Heroku specific features.
 	Heroku struct {
 		// UseDynoNames controls if Heroku dyno names are reported as the
@@ -262,6 +446,13 @@ type Config struct {
 	// https://docs.newrelic.com/docs/apm/distributed-tracing/getting-started/introduction-distributed-tracing
 	DistributedTracer struct {
 		Enabled bool
+		// AcceptAWSTraceHeader controls whether the trace ID of the
+		// X-Amzn-Trace-Id header, added to requests by AWS Application
+		// Load Balancers and API Gateway, is used as the trace ID of web
+		// transactions which did not receive New Relic or W3C trace
+		// context headers.  This links the transaction to the AWS X-Ray
+		// trace of the request.  The default is false.
+		AcceptAWSTraceHeader bool
 		// ExcludeNewRelicHeader allows you to choose whether to insert the New
 		// Relic Distributed Tracing header on outbound requests, which by
 		// default is emitted along with the W3C trace context headers.  Set
@@ -271,9 +462,24 @@ type Config struct {
 		// Disabling the New Relic header here does not prevent the agent from
 		// accepting *inbound* New Relic headers.
 		ExcludeNewRelicHeader bool
+		// ExcludedHosts is a list of hosts for which distributed tracing and
+		// cross application tracing headers are not added to outbound
+		// requests.  Use this for destinations such as third-party APIs which
+		// log or reject unknown request headers.  Entries are compared
+		// case-insensitively to the request's host without its port.  An
+		// entry beginning with "*." matches any subdomain of the remaining
+		// domain, eg. "*.example.com" matches "api.example.com".
+		ExcludedHosts []string
 		// ReservoirLimit sets the desired maximum span event reservoir limit
 		// for collecting span event data. The collector MAY override this value.
 		ReservoirLimit int
+		// Sampler, when set, is called to decide whether each transaction
+		// is sampled.  It may force transactions to be sampled or not
+		// sampled based on their name, attributes, and inbound priority,
+		// or defer to the inbound sampling decision and the adaptive
+		// sampler by returning SamplingDecisionDefault.  The WithSampled
+		// transaction option takes precedence over the Sampler.
+		Sampler Sampler `json:"-"`
 	}
 
 	// SpanEvents controls behavior relating to Span Events.  Span Events
@@ -282,6 +488,53 @@ type Config struct {
 		Enabled bool
 		// Attributes controls the attributes included on Spans.
 		Attributes AttributeDestinationConfig
+		// Aggregation controls the aggregation of identical span events
+		// within a transaction.  When enabled, span events which have
+		// the same parent, name, category, and key attributes, and
+		// which have no children, are combined into a single span event
+		// before they are sent.  The combined span event's duration is
+		// the sum of the durations, and its nr.aggregateCount attribute
+		// contains the number of span events it represents.  Span
+		// events containing errors are never combined.  This reduces
+		// the volume of span events created by chatty instrumentation,
+		// such as a query executed in a loop, at the cost of
+		// granularity.
+		Aggregation struct {
+			// Enabled controls whether span events are aggregated.
+			// The default is false.
+			Enabled bool
+			// KeyAttributes lists the attributes whose values must be
+			// equal for span events to be combined.  When empty, all
+			// attributes must be equal.
+			KeyAttributes []string
+		}
+		// StackTraces controls the capture of the stack traces of slow
+		// segments.  When enabled, the span event of a segment whose
+		// duration exceeds Threshold has a "code.stacktrace" attribute
+		// containing the abbreviated stack trace of the code which
+		// ended the segment, so that slow spans can be traced to a call
+		// path.  Capturing a stack trace is expensive, so the threshold
+		// should only be exceeded by few segments.
+		StackTraces struct {
+			// Enabled controls whether stack traces are captured.
+			// The default is false.
+			Enabled bool
+			// Threshold is the duration a segment must exceed for
+			// its stack trace to be captured.  The default is 500
+			// milliseconds.
+			Threshold time.Duration
+			// MaxFrames is the maximum number of frames of the
+			// stack trace.  The default is 5.  The stack trace is
+			// also limited to 255 bytes.
+			MaxFrames int
+		}
+		// ContextErrors controls whether the spans of segments started
+		// using Transaction.StartSegmentWithContext, and of
+		// transactions started using WithContext, are marked as errors
+		// when their context was canceled or its deadline was exceeded.
+		// The error class of such a span is "context.Canceled" or
+		// "context.DeadlineExceeded".  The default is false.
+		ContextErrors bool
 	}
 
 	// InfiniteTracing controls behavior related to Infinite Tracing tail based
@@ -331,6 +584,15 @@ type Config struct {
 			Enabled bool
 		}
 
+		// QuerySanitizer, if not nil, is applied to the query of each
+		// datastore segment before it is added to the span, the
+		// transaction trace segment, and the slow query trace.  It may be
+		// used to apply a custom redaction, such as collapsing the
+		// literals of IN lists, removing comments, or removing tenant
+		// identifiers.  QuerySanitizer is called while the transaction
+		// is locked, and so must not use the transaction.
+		QuerySanitizer func(query string) string `json:"-"`
+
 		// SlowQuery controls the capture of slow query traces.  Slow
 		// query traces show you instances of your slowest datastore
 		// segments.
@@ -338,6 +600,29 @@ type Config struct {
 			Enabled   bool
 			Threshold time.Duration
 		}
+
+		// ExplainPlan controls the capture of the query plans of slow
+		// queries by the integrations which support it, such as nrpgx
+		// and nrpgx5.  The plan of a query slower than Threshold is
+		// captured using a separate EXPLAIN statement, which is never
+		// EXPLAIN ANALYZE, and is added to the slow query trace and to
+		// the transaction trace segment.  The plans are captured in the
+		// background, one at a time, so that the slow queries are not
+		// made slower, and at most 10 plans are captured per
+		// transaction and per minute.  A plan which is not captured by
+		// the time its trace is sent is left out.  ExplainPlan is
+		// ignored in high security mode.
+		ExplainPlan struct {
+			Enabled   bool
+			Threshold time.Duration
+		}
+
+		// ConnectionPoolMetrics controls the sampling of the connection
+		// pool statistics of the databases registered using
+		// Application.MonitorDBStats.
+		ConnectionPoolMetrics struct {
+			Enabled bool
+		}
 	}
 
 	// Config Settings for Logs in Context features
@@ -349,15 +634,49 @@ type Config struct {
 	// Events, and Browser timing header.
 	Attributes AttributeDestinationConfig
 
+	// Expvar controls the reporting of the variables published using the
+	// expvar package.  The numeric values of the variables whose name is
+	// in Names or starts with one of Prefixes are sampled every minute and
+	// recorded as forced metrics named "Expvar/<name>".  The numeric
+	// values of map variables, such as expvar.Map or expvar.Func
+	// variables returning a map, are recorded as
+	// "Expvar/<name>/<key>" metrics.  Other values, such as strings and
+	// arrays, are ignored.
+	Expvar struct {
+		Enabled  bool
+		Names    []string
+		Prefixes []string
+	}
+
 	// RuntimeSampler controls the collection of runtime statistics like
 	// CPU/Memory usage, goroutine count, and GC pauses.
 	RuntimeSampler struct {
 		// Enabled controls whether runtime statistics are captured.
 		Enabled bool
+		// DetailedMetrics controls whether detailed runtime statistics
+		// are read from the runtime/metrics package and recorded each
+		// harvest: the distributions of GC pauses and scheduler
+		// latencies, the heap goal, the live heap, the number of cgo
+		// calls, and the memory classes.  These metrics are named
+		// "Go/Runtime/...", and like all runtime statistics can be
+		// graphed per instance.
+		DetailedMetrics bool
+		// TransactionAttributes controls whether the GC pause time and
+		// the scheduler latency of the process during each transaction
+		// are added to it as the AttributeRuntimeGCPauseTime and
+		// AttributeRuntimeSchedulerLatency attributes, to distinguish
+		// slow application code from a busy runtime.  The
+		// runtime/metrics are read at the start and the end of every
+		// transaction, which adds a few microseconds to each.  It does
+		// not require Enabled.
+		TransactionAttributes bool
 	}
 
 	// ServerlessMode contains fields which control behavior when running in
-	// AWS Lambda.
+	// AWS Lambda or on other serverless platforms, such as Google Cloud
+	// Functions and Cloud Run jobs.  Outside of AWS Lambda, use
+	// ServerlessHandler or ServerlessJob to write the data at the end of
+	// each invocation.
 	//
 	// https://docs.newrelic.com/docs/serverless-function-monitoring/aws-lambda-monitoring/get-started/introduction-new-relic-monitoring-aws-lambda
 	ServerlessMode struct {
@@ -446,6 +765,187 @@ type Config struct {
 		IgnoredPrefixes []string
 	}
 
+	// StackTraces controls the frames of the stack traces of errors and of
+	// the search for the function reported by CodeLevelMetrics, to reduce
+	// the size of the stack traces of deeply nested middleware.
+	StackTraces struct {
+		// MaxDepth is the maximum number of frames of an error stack
+		// trace.  It cannot exceed 100.
+		MaxDepth int
+		// SkipRuntimeFrames removes the frames of the runtime package,
+		// such as runtime.goexit, from error stack traces, and skips
+		// them when searching for the function reported by
+		// CodeLevelMetrics.
+		SkipRuntimeFrames bool
+		// SkipVendorFrames removes the frames of vendored packages,
+		// whose file paths contain "/vendor/", in the same way.
+		SkipVendorFrames bool
+		// TrimGOPATH removes the GOPATH or GOROOT from file paths, up
+		// to and including "/pkg/mod/" or "/src/", so that a file
+		// "/home/me/go/pkg/mod/github.com/x/y@v1.0.0/y.go" is reported as
+		// "github.com/x/y@v1.0.0/y.go".  The file paths reported by
+		// CodeLevelMetrics are only trimmed if none of its
+		// PathPrefixes is found.
+		TrimGOPATH bool
+	}
+
+	// SemanticConventions controls the attributes added by integrations
+	// following the OpenTelemetry semantic conventions, in addition to the
+	// attributes they normally add, so that data can be queried in the
+	// same way as the data of services instrumented with OpenTelemetry.
+	SemanticConventions struct {
+		// RPC controls whether the nrgrpc integration adds the rpc.*
+		// and net.peer.* attributes to the transactions of gRPC servers
+		// and to the external segments of gRPC clients.
+		RPC bool
+		// HTTP controls the names of the HTTP attributes of web
+		// transactions and external segments.  By default, only the
+		// legacy names, such as "request.method", are used.
+		// SemanticConventionsDuplicate adds the semantic convention
+		// names, such as "http.request.method", alongside them, and
+		// SemanticConventionsOpenTelemetry uses them instead.
+		HTTP SemanticConventionsMode
+	}
+
+	// GoroutineTransactions is an EXPERIMENTAL feature which binds
+	// transactions to goroutines, so that code which has neither the
+	// transaction nor a context containing it can use
+	// CurrentTransaction.  When enabled, a transaction is bound to the
+	// goroutine which starts it, to goroutines which resume it using
+	// Application.ResumeTransaction, and to goroutines which call
+	// Transaction.BindGoroutine, until it ends.
+	//
+	// Caveats: finding the ID of the current goroutine requires a stack
+	// trace, which makes starting transactions and calling
+	// CurrentTransaction slower.  A goroutine is bound to a single
+	// transaction: starting a second transaction in the same goroutine
+	// replaces the binding, and the goroutine has no transaction once
+	// the second transaction ends.  Goroutines started by the
+	// transaction's goroutine are not bound.  Passing the transaction in
+	// a context using NewContext remains the recommended approach.
+	GoroutineTransactions struct {
+		Enabled bool
+	}
+
+	// GoroutineBudget limits the number of goroutines run by the agent, for
+	// environments with strict per-process goroutine or memory budgets.
+	// When Max is positive, the features of the agent are given
+	// goroutines in the following order of priority, and the features
+	// which do not fit are turned off:
+	//
+	//	1. The processor, the connection to New Relic, and the harvest:
+	//	   3 goroutines, which always run, even if Max is lower.  A
+	//	   harvest is delayed until the previous one is sent, and the
+	//	   application logs are forwarded with the harvest.
+	//	2. Infinite Tracing: 3 goroutines.  Without them, span events are
+	//	   sent with the harvest.
+	//	3. Each of the SecondaryDestinations, in order: 2 goroutines.
+	//	4. The periodic writes of the AgentHealth file: 1 goroutine.
+	//	5. The RuntimeSampler: 1 goroutine.
+	//	6. The DatastoreTracer.ConnectionPoolMetrics: 1 goroutine.
+	//	7. The Expvar sampler: 1 goroutine.
+	//
+	// The features turned off are logged as a warning, and recorded as
+	// "Supportability/Go/GoroutineBudget/Excluded/<feature>" metrics.
+	// The goroutines started by integrations and by WatchConfigFile, and
+	// the goroutine capturing the query plans of
+	// DatastoreTracer.ExplainPlan, are not part of the budget.  Max is 0,
+	// meaning no limit, by default.
+	GoroutineBudget struct {
+		Max int
+	}
+
+	// TelemetryPause controls the data collected while the transmission of
+	// data is stopped by Application.PauseTelemetry.
+	TelemetryPause struct {
+		// DropData discards the data collected while telemetry is paused
+		// instead of buffering it until Application.ResumeTelemetry is
+		// called.
+		DropData bool
+	}
+
+	// HarvestSpool controls the spooling to disk of the data which could
+	// not be sent to New Relic because the collector was unreachable or
+	// asked for the data to be sent later.  When enabled, each such harvest
+	// payload is written to a gzip file in Directory instead of being kept
+	// in memory, and the files are sent, oldest first, once a harvest
+	// succeeds again.  Files left by a previous run of the application are
+	// sent the same way, so the data collected by edge deployments with
+	// intermittent networks survives restarts.  The oldest files are
+	// removed when the total size of the files exceeds MaxBytes.
+	HarvestSpool struct {
+		Enabled bool
+		// Directory is the directory holding the spooled payloads.  It
+		// is created if it does not exist, and is required when the
+		// spool is enabled.  The payloads are stored in a subdirectory
+		// named after a hash of the license key and the application
+		// name, so that applications may share the directory.
+		Directory string
+		// MaxBytes is the maximum total size of the spooled files.
+		MaxBytes int64
+	}
+
+	// SecondaryDestinations lists other New Relic accounts, possibly in
+	// other regions, to which selected data is also sent, for example to
+	// keep the metrics and errors of the application in a disaster
+	// recovery or compliance account.  The application connects to each
+	// destination separately, and the failures of a destination are
+	// logged without affecting the primary account or the other
+	// destinations:  data which a destination does not accept is
+	// dropped rather than retained or spooled.  Security policies are not
+	// supported by secondary destinations, and HighSecurity must match
+	// the setting of each account.  Secondary destinations are not used
+	// in ServerlessMode.
+	//
+	//	cfg.SecondaryDestinations = []newrelic.SecondaryDestination{{
+	//		License: os.Getenv("NEW_RELIC_DR_LICENSE_KEY"),
+	//		Data:    newrelic.SecondaryMetrics | newrelic.SecondaryErrors,
+	//	}}
+	SecondaryDestinations []SecondaryDestination
+
+	// MessagePayloads controls the capture of the key, the headers, and a
+	// snippet of the body of messages, set using
+	// MessageProducerSegment.Payload, MessageConsumerSegment.Payload, and
+	// Transaction.SetMessagePayload.  Messages often hold personal data, so
+	// the capture is disabled by default, and is disabled by HighSecurity.
+	MessagePayloads struct {
+		Enabled bool
+		// MaxBytes is the maximum size of the snippet of the body.  It
+		// may not exceed 255, the maximum size of attribute values.
+		MaxBytes int
+		// Redactor, if not nil, is applied to a copy of each message
+		// before it is recorded.  It may remove headers, or replace the
+		// key or the body, to comply with data policies.  Redactor is
+		// called while the transaction is locked, and so must not use
+		// the transaction.
+		Redactor func(*MessagePayload) `json:"-"`
+	}
+
+	// AgentHealth controls the health status file which the agent writes
+	// periodically, following the agent health monitoring specification,
+	// so that Kubernetes probes and New Relic agent control can detect an
+	// agent which is unable to send data.  The file is a YAML document:
+	//
+	//	healthy: true
+	//	status: Healthy
+	//	last_error: NR-APM-000
+	//	start_time_unix_nano: 1721235734000000000
+	//	status_time_unix_nano: 1721235739000000000
+	//	connected: true
+	//	last_successful_harvest_unix_nano: 1721235739000000000
+	//
+	// The last_error field holds the code of the last error, NR-APM-000 if
+	// the agent is healthy.
+	AgentHealth struct {
+		Enabled bool
+		// DeliveryLocation is the directory in which the file is
+		// written, either a path or a file:// URI.  The file is named
+		// health-{id}.yml, with an id unique to the application.
+		DeliveryLocation string
+		// Frequency is the period with which the file is written.
+		Frequency time.Duration
+	}
+
 	// ModuleDependencyMetrics controls reporting of the packages used to build the instrumented
 	// application, to help manage project dependencies.
 	ModuleDependencyMetrics struct {
@@ -463,6 +963,31 @@ type Config struct {
 	Security interface{} `json:"Security,omitempty"`
 }
 
+// SemanticConventionsMode controls whether attributes are named following the
+// OpenTelemetry semantic conventions, the legacy conventions of the agent, or
+// both.  See Config.SemanticConventions.
+type SemanticConventionsMode int
+
+// These constants are the values of SemanticConventionsMode.  When the
+// OpenTelemetry names are used instead of the legacy names, the legacy
+// attributes are still recorded, and so can be used by a TraceSampler or an
+// ErrorGroupCallback, but they are not sent to New Relic.
+//
+// The HTTP attributes affected are:
+//
+//	legacy name          semantic convention name     recorded on
+//	request.method       http.request.method          web transactions
+//	request.uri          url.path                     web transactions
+//	http.statusCode      http.response.status_code    web transactions, external spans
+//	httpResponseCode     http.response.status_code    web transactions
+//	http.method          http.request.method          external spans
+//	http.url             url.full                     external spans
+const (
+	SemanticConventionsLegacy SemanticConventionsMode = iota
+	SemanticConventionsDuplicate
+	SemanticConventionsOpenTelemetry
+)
+
 // CodeLevelMetricsScope is a bit-encoded value. Each such value describes
 // a trace type for which code-level metrics are to be collected and
 // reported.
@@ -593,6 +1118,63 @@ type ApplicationLogging struct {
 	}
 }
 
+// TransactionNameRule is a rule which rewrites the names of transactions.  See
+// Config.TransactionNameRules.
+type TransactionNameRule struct {
+	// MatchExpression is the regular expression matched against the
+	// transaction name, or against each segment of the name separated by
+	// "/" if EachSegment is set.  Matching is case-insensitive.
+	MatchExpression string
+	// Replacement replaces the first match, or every match if ReplaceAll
+	// is set.  It may refer to the submatches of MatchExpression as "\1"
+	// or "${1}".
+	Replacement string
+	// EachSegment applies the rule to each segment of the name.
+	EachSegment bool
+	// ReplaceAll replaces every match rather than only the first.
+	ReplaceAll bool
+	// Terminate stops the application of the rules which follow this rule
+	// when it matches.
+	Terminate bool
+	// Ignore ignores the transactions whose names match, as if
+	// Transaction.Ignore had been called.
+	Ignore bool
+}
+
+// SecondaryDestination is an account to which data is also sent.  See
+// Config.SecondaryDestinations.
+type SecondaryDestination struct {
+	// License is the license key of the account.
+	License string
+	// Host overrides the host of the New Relic servers of the account,
+	// which is derived from the region of the License by default.
+	Host string
+	// Data selects the data sent to the account.  If it is zero, the
+	// metrics and the errors are sent.
+	Data SecondaryData
+}
+
+// SecondaryData selects the data sent to a SecondaryDestination.  The values
+// are combined using "|".
+type SecondaryData int
+
+const (
+	// SecondaryMetrics selects the metrics.
+	SecondaryMetrics SecondaryData = 1 << iota
+	// SecondaryErrors selects the error traces and the error events.
+	SecondaryErrors
+	// SecondaryTransactionEvents selects the transaction events.
+	SecondaryTransactionEvents
+	// SecondaryCustomEvents selects the custom events.
+	SecondaryCustomEvents
+	// SecondaryLogEvents selects the log events.
+	SecondaryLogEvents
+	// SecondarySpanEvents selects the span events.
+	SecondarySpanEvents
+	// SecondaryTraces selects the transaction traces and the slow queries.
+	SecondaryTraces
+)
+
 // AttributeDestinationConfig controls the attributes sent to each destination.
 // For more information, see:
 // https://docs.newrelic.com/docs/agents/manage-apm-agents/agent-data/agent-attributes
@@ -630,6 +1212,9 @@ func defaultConfig() Config {
 	c.TransactionEvents.Enabled = true
 	c.TransactionEvents.Attributes.Enabled = true
 	c.TransactionEvents.MaxSamplesStored = internal.MaxTxnEvents
+	c.TransactionNameCardinality.MaxNames = defaultMaxTxnNames
+	c.TransactionNameCardinality.MaxNamesPerPattern = defaultMaxTxnNamesPerPattern
+	c.TenantAccounting.MaxTenants = defaultMaxTenants
 	c.HighSecurity = false
 	c.ErrorCollector.Enabled = true
 	c.ErrorCollector.CaptureEvents = true
@@ -646,6 +1231,8 @@ func defaultConfig() Config {
 	c.Utilization.DetectGCP = true
 	c.Utilization.DetectDocker = true
 	c.Utilization.DetectKubernetes = true
+	c.Utilization.DetectECS = true
+	c.Utilization.DetectSystemd = true
 	c.Attributes.Enabled = true
 	c.RuntimeSampler.Enabled = true
 
@@ -673,6 +1260,8 @@ func defaultConfig() Config {
 	c.DistributedTracer.ReservoirLimit = internal.MaxSpanEvents
 	c.SpanEvents.Enabled = true
 	c.SpanEvents.Attributes.Enabled = true
+	c.SpanEvents.StackTraces.Threshold = 500 * time.Millisecond
+	c.SpanEvents.StackTraces.MaxFrames = 5
 
 	c.DatastoreTracer.InstanceReporting.Enabled = true
 	c.DatastoreTracer.DatabaseNameReporting.Enabled = true
@@ -680,6 +1269,13 @@ func defaultConfig() Config {
 	c.DatastoreTracer.SlowQuery.Enabled = true
 	c.DatastoreTracer.SlowQuery.Threshold = 10 * time.Millisecond
 	c.DatastoreTracer.RawQuery.Enabled = false
+	c.DatastoreTracer.ExplainPlan.Enabled = false
+	c.DatastoreTracer.ExplainPlan.Threshold = 500 * time.Millisecond
+
+	c.HarvestSpool.MaxBytes = defaultHarvestSpoolMaxBytes
+	c.MessagePayloads.MaxBytes = attributeValueLengthLimit
+	c.AgentHealth.DeliveryLocation = defaultAgentHealthDeliveryLocation
+	c.AgentHealth.Frequency = defaultAgentHealthFrequency
 
 	c.ServerlessMode.ApdexThreshold = 500 * time.Millisecond
 	c.ServerlessMode.Enabled = false
@@ -699,6 +1295,8 @@ func defaultConfig() Config {
 	c.CodeLevelMetrics.RedactIgnoredPrefixes = true
 	c.CodeLevelMetrics.Scope = AllCLM
 
+	c.StackTraces.MaxDepth = maxStackTraceFrames
+
 	// Module Dependency Metrics
 	c.ModuleDependencyMetrics.Enabled = true
 	c.ModuleDependencyMetrics.RedactIgnoredPrefixes = true
@@ -717,35 +1315,64 @@ var (
 	errAppNameLimit                     = fmt.Errorf("max of %d rollup application names", appNameLimit)
 	errHighSecurityWithSecurityPolicies = errors.New("SecurityPoliciesToken and HighSecurity are incompatible; please ensure HighSecurity is set to false if SecurityPoliciesToken is a non-empty string and a security policy has been set for your account")
 	errInfTracingServerless             = errors.New("ServerlessMode cannot be used with Infinite Tracing")
+	errHarvestSpoolDirectory            = errors.New("HarvestSpool.Directory required when HarvestSpool.Enabled is true")
+	errCollectorTLSTransport            = errors.New("CollectorTLS requires the Transport to be an *http.Transport")
+	errAgentHealthDeliveryLocation      = errors.New("AgentHealth.DeliveryLocation required when AgentHealth.Enabled is true")
+	errSecondaryLicenseLen              = fmt.Errorf("SecondaryDestinations: license length is not %d", licenseLength)
 )
 
 // validate checks the config for improper fields.  If the config is invalid,
 // newrelic.NewApplication returns an error.
 func (c Config) validate() error {
+	if errs := c.validationErrors(); len(errs) > 0 {
+		return errs[0]
+	}
+	return nil
+}
+
+// validationErrors returns every error of the Config found by validate.
+func (c Config) validationErrors() []error {
+	var errs []error
 	if c.Enabled && !c.ServerlessMode.Enabled {
 		if len(c.License) != licenseLength {
-			return errLicenseLen
+			errs = append(errs, errLicenseLen)
 		}
 	} else {
 		// The License may be empty when the agent is not enabled.
 		if len(c.License) != licenseLength && len(c.License) != 0 {
-			return errLicenseLen
+			errs = append(errs, errLicenseLen)
 		}
 	}
 	if c.AppName == "" && c.Enabled && !c.ServerlessMode.Enabled {
-		return errAppNameMissing
+		errs = append(errs, errAppNameMissing)
 	}
 	if c.HighSecurity && c.SecurityPoliciesToken != "" {
-		return errHighSecurityWithSecurityPolicies
+		errs = append(errs, errHighSecurityWithSecurityPolicies)
 	}
 	if strings.Count(c.AppName, ";") >= appNameLimit {
-		return errAppNameLimit
+		errs = append(errs, errAppNameLimit)
 	}
 	if c.InfiniteTracing.TraceObserver.Host != "" && c.ServerlessMode.Enabled {
-		return errInfTracingServerless
+		errs = append(errs, errInfTracingServerless)
 	}
-
-	return nil
+	if c.HarvestSpool.Enabled && c.HarvestSpool.Directory == "" {
+		errs = append(errs, errHarvestSpoolDirectory)
+	}
+	if c.AgentHealth.Enabled && c.AgentHealth.DeliveryLocation == "" {
+		errs = append(errs, errAgentHealthDeliveryLocation)
+	}
+	if c.CollectorTLS.FIPSMode || nil != c.CollectorTLS.Config {
+		if _, ok := collectorTransport(c).(*http.Transport); !ok {
+			errs = append(errs, errCollectorTLSTransport)
+		}
+	}
+	for _, dest := range c.SecondaryDestinations {
+		if len(dest.License) != licenseLength {
+			errs = append(errs, errSecondaryLicenseLen)
+			break
+		}
+	}
+	return errs
 }
 
 func (c Config) validateTraceObserverConfig() (*observerURL, error) {
@@ -756,8 +1383,8 @@ func (c Config) validateTraceObserverConfig() (*observerURL, error) {
 		// them with a valid observerURL _or_ alert them to the failure to do so.
 		return nil, nil
 	}
-	if !versionSupports8T {
-		return nil, errUnsupportedVersion
+	if !infiniteTracingIncluded {
+		return nil, errInfiniteTracingExcluded
 	}
 	if !c.DistributedTracer.Enabled || !c.SpanEvents.Enabled {
 		return nil, errSpanOrDTDisabled
@@ -824,6 +1451,57 @@ func copyConfigReferenceFields(cfg Config) Config {
 		copy(ignored, cfg.ErrorCollector.IgnoreStatusCodes)
 		cp.ErrorCollector.IgnoreStatusCodes = ignored
 	}
+	if cfg.ErrorCollector.ExpectStatusCodes != nil {
+		expected := make([]int, len(cfg.ErrorCollector.ExpectStatusCodes))
+		copy(expected, cfg.ErrorCollector.ExpectStatusCodes)
+		cp.ErrorCollector.ExpectStatusCodes = expected
+	}
+	if cfg.ErrorCollector.ExpectClasses != nil {
+		classes := make([]string, len(cfg.ErrorCollector.ExpectClasses))
+		copy(classes, cfg.ErrorCollector.ExpectClasses)
+		cp.ErrorCollector.ExpectClasses = classes
+	}
+
+	if cfg.TransactionNameRules != nil {
+		rules := make([]TransactionNameRule, len(cfg.TransactionNameRules))
+		copy(rules, cfg.TransactionNameRules)
+		cp.TransactionNameRules = rules
+	}
+
+	if cfg.SecondaryDestinations != nil {
+		dests := make([]SecondaryDestination, len(cfg.SecondaryDestinations))
+		copy(dests, cfg.SecondaryDestinations)
+		cp.SecondaryDestinations = dests
+	}
+
+	if cfg.CustomInsightsEvents.AllowedEventTypes != nil {
+		types := make([]string, len(cfg.CustomInsightsEvents.AllowedEventTypes))
+		copy(types, cfg.CustomInsightsEvents.AllowedEventTypes)
+		cp.CustomInsightsEvents.AllowedEventTypes = types
+	}
+	if cfg.CustomInsightsEvents.DeniedEventTypes != nil {
+		types := make([]string, len(cfg.CustomInsightsEvents.DeniedEventTypes))
+		copy(types, cfg.CustomInsightsEvents.DeniedEventTypes)
+		cp.CustomInsightsEvents.DeniedEventTypes = types
+	}
+	if cfg.CustomInsightsEvents.MaxSamplesPerEventType != nil {
+		cp.CustomInsightsEvents.MaxSamplesPerEventType = make(map[string]int, len(cfg.CustomInsightsEvents.MaxSamplesPerEventType))
+		for eventType, max := range cfg.CustomInsightsEvents.MaxSamplesPerEventType {
+			cp.CustomInsightsEvents.MaxSamplesPerEventType[eventType] = max
+		}
+	}
+
+	if cfg.IgnoredTransactionNames != nil {
+		names := make([]string, len(cfg.IgnoredTransactionNames))
+		copy(names, cfg.IgnoredTransactionNames)
+		cp.IgnoredTransactionNames = names
+	}
+
+	if cfg.DistributedTracer.ExcludedHosts != nil {
+		hosts := make([]string, len(cfg.DistributedTracer.ExcludedHosts))
+		copy(hosts, cfg.DistributedTracer.ExcludedHosts)
+		cp.DistributedTracer.ExcludedHosts = hosts
+	}
 
 	cp.Attributes = copyDestConfig(cfg.Attributes)
 	cp.ErrorCollector.Attributes = copyDestConfig(cfg.ErrorCollector.Attributes)
@@ -831,6 +1509,11 @@ func copyConfigReferenceFields(cfg Config) Config {
 	cp.TransactionTracer.Attributes = copyDestConfig(cfg.TransactionTracer.Attributes)
 	cp.BrowserMonitoring.Attributes = copyDestConfig(cfg.BrowserMonitoring.Attributes)
 	cp.SpanEvents.Attributes = copyDestConfig(cfg.SpanEvents.Attributes)
+	if cfg.SpanEvents.Aggregation.KeyAttributes != nil {
+		keys := make([]string, len(cfg.SpanEvents.Aggregation.KeyAttributes))
+		copy(keys, cfg.SpanEvents.Aggregation.KeyAttributes)
+		cp.SpanEvents.Aggregation.KeyAttributes = keys
+	}
 	cp.TransactionTracer.Segments.Attributes = copyDestConfig(cfg.TransactionTracer.Segments.Attributes)
 
 	return cp
@@ -843,6 +1526,13 @@ func transportSetting(t http.RoundTripper) interface{} {
 	return fmt.Sprintf("%T", t)
 }
 
+func httpClientSetting(c *http.Client) interface{} {
+	if nil == c {
+		return nil
+	}
+	return transportSetting(c.Transport)
+}
+
 func loggerSetting(lg Logger) interface{} {
 	if nil == lg {
 		return nil
@@ -864,6 +1554,10 @@ func (s settings) MarshalJSON() ([]byte, error) {
 	c := Config(s)
 	transport := c.Transport
 	c.Transport = nil
+	client := c.HTTPClient
+	c.HTTPClient = nil
+	tlsConfig := c.CollectorTLS.Config
+	c.CollectorTLS.Config = nil
 	l := c.Logger
 	c.Logger = nil
 
@@ -879,7 +1573,18 @@ func (s settings) MarshalJSON() ([]byte, error) {
 	// The License field is not simply ignored by adding the `json:"-"` tag
 	// to it since we want to allow consumers to populate Config from JSON.
 	delete(fields, `License`)
+	if dests, ok := fields[`SecondaryDestinations`].([]interface{}); ok {
+		for _, dest := range dests {
+			if m, ok := dest.(map[string]interface{}); ok {
+				delete(m, `License`)
+			}
+		}
+	}
 	fields[`Transport`] = transportSetting(transport)
+	fields[`HTTPClient`] = httpClientSetting(client)
+	if collectorTLS, ok := fields[`CollectorTLS`].(map[string]interface{}); ok && nil != tlsConfig {
+		collectorTLS[`Config`] = fmt.Sprintf("%T", tlsConfig)
+	}
 	fields[`Logger`] = loggerSetting(l)
 
 	// Browser monitoring support.
@@ -912,6 +1617,44 @@ func (s settings) MarshalJSON() ([]byte, error) {
 	return json.Marshal(fields)
 }
 
+// redactedSecret replaces the secrets in the output of
+// Application.ConfigJSON.
+const redactedSecret = "[redacted]"
+
+// configJSON returns the indented settings of the Config, including its
+// secrets unless redactSecrets is true.
+func configJSON(c Config, redactSecrets bool) ([]byte, error) {
+	js, err := json.Marshal(settings(c))
+	if err != nil {
+		return nil, err
+	}
+	fields := make(map[string]interface{})
+	if err := json.Unmarshal(js, &fields); err != nil {
+		return nil, err
+	}
+	fields["License"] = c.License
+	if redactSecrets {
+		for _, name := range []string{"License", "SecurityPoliciesToken"} {
+			if s, _ := fields[name].(string); s != "" {
+				fields[name] = redactedSecret
+			}
+		}
+	}
+	if dests, ok := fields["SecondaryDestinations"].([]interface{}); ok {
+		for i, dest := range dests {
+			m, ok := dest.(map[string]interface{})
+			if !ok || i >= len(c.SecondaryDestinations) {
+				continue
+			}
+			m["License"] = c.SecondaryDestinations[i].License
+			if redactSecrets && c.SecondaryDestinations[i].License != "" {
+				m["License"] = redactedSecret
+			}
+		}
+	}
+	return json.MarshalIndent(fields, "", "  ")
+}
+
 // labels is used for connect JSON formatting.
 type labels map[string]string
 
@@ -1006,7 +1749,42 @@ type config struct {
 	// https://github.com/newrelic/go-agent/issues/127
 	metadata         map[string]string
 	hostname         string
+	systemdUnit      sysinfo.SystemdUnit
+	cloudPlatform    utilization.CloudPlatform
 	traceObserverURL *observerURL
+	// kubernetesAttributes describe the Kubernetes pod of the application
+	// when KubernetesMetadata is enabled.
+	kubernetesAttributes []kubernetesAttribute
+	// txnNameRules contains the compiled TransactionNameRules.
+	txnNameRules internal.MetricRules
+	// ignoredTxnNames contains the compiled patterns of
+	// IgnoredTransactionNames.
+	ignoredTxnNames txnNamePatterns
+	// customEventTypes contains the AllowedEventTypes and DeniedEventTypes
+	// of CustomInsightsEvents.
+	customEventTypes customEventTypeFilter
+	// goroutineBudget is the outcome of GoroutineBudget, set by
+	// applyGoroutineBudget.
+	goroutineBudget goroutineBudget
+}
+
+func compileTxnNameRules(rules []TransactionNameRule) (internal.MetricRules, error) {
+	configured := make([]internal.MetricRule, len(rules))
+	for i, r := range rules {
+		configured[i] = internal.MetricRule{
+			MatchExpression: r.MatchExpression,
+			Replacement:     r.Replacement,
+			EachSegment:     r.EachSegment,
+			ReplaceAll:      r.ReplaceAll,
+			Terminate:       r.Terminate,
+			Ignore:          r.Ignore,
+		}
+	}
+	compiled, err := internal.NewMetricRules(configured)
+	if err != nil {
+		return nil, fmt.Errorf("invalid transaction name rule: %v", err)
+	}
+	return compiled, nil
 }
 
 func (c Config) computeDynoHostname(getenv func(string) string) string {
@@ -1029,10 +1807,25 @@ func (c Config) computeDynoHostname(getenv func(string) string) string {
 	return dyno
 }
 
+// disableExcludedFeatures disables the features excluded from the build by
+// build tags, whatever their configuration.
+func disableExcludedFeatures(c *Config) {
+	if !aiMonitoringIncluded {
+		c.AIMonitoring.Enabled = false
+	}
+	if !crossAppTracingIncluded {
+		c.CrossApplicationTracer.Enabled = false
+	}
+	if !logForwardingIncluded {
+		c.ApplicationLogging.Forwarding.Enabled = false
+	}
+}
+
 func newInternalConfig(cfg Config, getenv func(string) string, environ []string) (config, error) {
 	// Copy maps and slices to prevent race conditions if a consumer changes
 	// them after calling NewApplication.
 	cfg = copyConfigReferenceFields(cfg)
+	disableExcludedFeatures(&cfg)
 	if err := cfg.validate(); nil != err {
 		return config{}, err
 	}
@@ -1040,6 +1833,14 @@ func newInternalConfig(cfg Config, getenv func(string) string, environ []string)
 	if err != nil {
 		return config{}, err
 	}
+	txnNameRules, err := compileTxnNameRules(cfg.TransactionNameRules)
+	if err != nil {
+		return config{}, err
+	}
+	ignoredTxnNames, err := compileTxnNamePatterns(cfg.IgnoredTransactionNames)
+	if err != nil {
+		return config{}, err
+	}
 	// Ensure that Logger is always set to avoid nil checks.
 	if nil == cfg.Logger {
 		cfg.Logger = logger.ShimLogger{}
@@ -1052,12 +1853,39 @@ func newInternalConfig(cfg Config, getenv func(string) string, environ []string)
 	} else {
 		hostname = "unknown"
 	}
-	return config{
+	var systemdUnit sysinfo.SystemdUnit
+	if cfg.Utilization.DetectSystemd {
+		systemdUnit, _ = sysinfo.GetSystemdUnit(getenv)
+	}
+	cloudPlatform := utilization.GetCloudPlatform(utilization.Config{
+		DetectGCP:   cfg.Utilization.DetectGCP,
+		DetectAzure: cfg.Utilization.DetectAzure,
+	}, getenv)
+	var kubernetesAttributes []kubernetesAttribute
+	if cfg.KubernetesMetadata.Enabled {
+		if md, err := sysinfo.GetKubernetesMetadata(getenv); err == nil {
+			if cfg.KubernetesMetadata.ClusterName != "" {
+				md.ClusterName = cfg.KubernetesMetadata.ClusterName
+			}
+			kubernetesAttributes = newKubernetesAttributes(md)
+		}
+	}
+	c := config{
 		Config:           cfg,
 		metadata:         gatherMetadata(environ),
 		hostname:         hostname,
+		systemdUnit:      systemdUnit,
+		cloudPlatform:    cloudPlatform,
 		traceObserverURL: obsURL,
-	}, nil
+		txnNameRules:     txnNameRules,
+		ignoredTxnNames:  ignoredTxnNames,
+		customEventTypes: newCustomEventTypeFilter(
+			cfg.CustomInsightsEvents.AllowedEventTypes,
+			cfg.CustomInsightsEvents.DeniedEventTypes),
+		kubernetesAttributes: kubernetesAttributes,
+	}
+	applyGoroutineBudget(&c)
+	return c, nil
 }
 
 func (c config) createConnectJSON(securityPolicies *internal.SecurityPolicies) ([]byte, error) {
@@ -1069,6 +1897,7 @@ func (c config) createConnectJSON(securityPolicies *internal.SecurityPolicies) (
 		DetectGCP:         c.Utilization.DetectGCP,
 		DetectDocker:      c.Utilization.DetectDocker,
 		DetectKubernetes:  c.Utilization.DetectKubernetes,
+		DetectECS:         c.Utilization.DetectECS,
 		LogicalProcessors: c.Utilization.LogicalProcessors,
 		TotalRAMMIB:       c.Utilization.TotalRAMMIB,
 		BillingHostname:   c.Utilization.BillingHostname,
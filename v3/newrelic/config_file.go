@@ -0,0 +1,258 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package newrelic
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// configFileVariablePattern matches the ${NAME} and ${NAME:-default}
+// references to environment variables of configuration files.
+var configFileVariablePattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+// ConfigFromFile populates the Config from a YAML or JSON configuration file.
+// Files whose name ends in .json are read as JSON, and the other files as
+// YAML.  The keys are the names of the fields of Config, matched without
+// regard to case, and the fields missing from the file are left unchanged:
+//
+//	AppName: ${SERVICE_NAME}
+//	License: ${NEW_RELIC_LICENSE_KEY}
+//	Labels:
+//	  team: checkout
+//	DistributedTracer:
+//	  Enabled: true
+//	Attributes:
+//	  Exclude: [request.headers.cookie]
+//	ApplicationLogging:
+//	  Forwarding:
+//	    MaxSamplesStored: ${LOG_SAMPLES:-10000}
+//
+// References to environment variables, written ${NAME}, are replaced by the
+// values of the variables in the string values of the file.
+// ${NAME:-default} is replaced by default if the variable is unset or empty.
+// A value made of references may set a number or a boolean, such as
+// MaxSamplesStored above, in which case the value of the variable must be a
+// JSON number or boolean.  The variables are expanded once the file is
+// parsed, and so their values cannot add settings to the file.  The durations
+// may be written as strings such as 500ms, and are numbers of nanoseconds
+// otherwise.  Fields such as the Logger and the callbacks cannot be set from
+// a file.  The YAML files may use block and single-line flow mappings and
+// sequences, but not block scalars, anchors, or tags.
+//
+// Config.Error is set if the file cannot be read or parsed, if it contains
+// keys which are not fields of Config, or if it refers to an unset
+// environment variable without a default.  Options which follow
+// ConfigFromFile override the settings of the file:
+//
+//	app, err := newrelic.NewApplication(
+//		newrelic.ConfigFromFile("/etc/newrelic/newrelic.yml"),
+//		newrelic.ConfigFromEnvironment(),
+//	)
+func ConfigFromFile(path string) ConfigOption {
+	return configFromFile(path, os.LookupEnv)
+}
+
+func configFromFile(path string, lookupEnv func(string) (string, bool)) ConfigOption {
+	return func(cfg *Config) {
+		if nil != cfg.Error {
+			return
+		}
+		if err := loadConfigFile(cfg, path, lookupEnv); err != nil {
+			cfg.Error = fmt.Errorf("unable to load configuration file %s: %v", path, err)
+		}
+	}
+}
+
+func loadConfigFile(cfg *Config, path string, lookupEnv func(string) (string, bool)) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	doc, err := parseConfigFile(path, data)
+	if err != nil {
+		return err
+	}
+	if nil == doc {
+		return nil
+	}
+	if _, ok := doc.(map[string]interface{}); !ok {
+		return fmt.Errorf("the document is not a mapping")
+	}
+	x := &configFileExpander{lookupEnv: lookupEnv}
+	v, err := x.expand(doc, reflect.TypeOf(*cfg))
+	if err != nil {
+		return err
+	}
+	if len(x.missing) > 0 {
+		names := make([]string, 0, len(x.missing))
+		for name := range x.missing {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("environment variables not set: %s", strings.Join(names, ", "))
+	}
+	js, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(js))
+	dec.DisallowUnknownFields()
+	return dec.Decode(cfg)
+}
+
+// parseConfigFile parses a JSON or YAML configuration file, depending on the
+// extension of its name.  The YAML scalars are left unresolved, since their
+// types depend on the fields they set.
+func parseConfigFile(path string, data []byte) (interface{}, error) {
+	if !strings.EqualFold(filepath.Ext(path), ".json") {
+		return parseYAML(string(data))
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	// The numbers are kept as written, since they are encoded again.
+	dec.UseNumber()
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// configFileExpander replaces the references to environment variables of the
+// values of a parsed configuration file.
+type configFileExpander struct {
+	lookupEnv func(string) (string, bool)
+	// missing contains the unset variables referred to without a default.
+	missing map[string]bool
+}
+
+// expand returns the value of the file setting a field of type t, with the
+// references to environment variables replaced.  t is nil if the type is
+// unknown.
+func (x *configFileExpander) expand(v interface{}, t reflect.Type) (interface{}, error) {
+	for nil != t && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch v := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, val := range v {
+			var vt reflect.Type
+			if nil != t && t.Kind() == reflect.Struct {
+				if f, ok := jsonFieldByName(t, key); ok {
+					vt = f.Type
+				}
+			} else if nil != t && t.Kind() == reflect.Map {
+				vt = t.Elem()
+			}
+			expanded, err := x.expand(val, vt)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", key, err)
+			}
+			out[key] = expanded
+		}
+		return out, nil
+	case []interface{}:
+		var et reflect.Type
+		if nil != t && (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) {
+			et = t.Elem()
+		}
+		out := make([]interface{}, len(v))
+		for i, val := range v {
+			expanded, err := x.expand(val, et)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = expanded
+		}
+		return out, nil
+	case yamlScalar:
+		// A plain scalar such as 123 sets a string field as written.
+		if v.quoted || (nil != t && t.Kind() == reflect.String) {
+			return x.expand(v.text, t)
+		}
+		value := v.resolve()
+		if s, ok := value.(string); ok {
+			return x.expand(s, t)
+		}
+		return value, nil
+	case string:
+		value := x.expandString(v)
+		if nil == t || t.Kind() == reflect.String {
+			return value, nil
+		}
+		if t == durationType {
+			if d, err := time.ParseDuration(value); err == nil {
+				return int64(d), nil
+			}
+		}
+		if value == v {
+			// Let encoding/json report the type mismatch.
+			return v, nil
+		}
+		// The value of the variables set a number or a boolean.
+		var scalar interface{}
+		if err := json.Unmarshal([]byte(value), &scalar); err != nil {
+			return nil, fmt.Errorf("invalid value %q", value)
+		}
+		switch scalar.(type) {
+		case bool, float64:
+			return json.RawMessage(strings.TrimSpace(value)), nil
+		}
+		return nil, fmt.Errorf("invalid value %q", value)
+	}
+	return v, nil
+}
+
+// expandString replaces the references to environment variables of a string.
+func (x *configFileExpander) expandString(s string) string {
+	return configFileVariablePattern.ReplaceAllStringFunc(s, func(ref string) string {
+		m := configFileVariablePattern.FindStringSubmatch(ref)
+		value, ok := x.lookupEnv(m[1])
+		if m[2] != "" {
+			if value == "" {
+				return m[3]
+			}
+			return value
+		}
+		if !ok {
+			if nil == x.missing {
+				x.missing = make(map[string]bool)
+			}
+			x.missing[m[1]] = true
+		}
+		return value
+	})
+}
+
+// jsonFieldByName returns the field of the struct set by the JSON key, which
+// encoding/json matches without regard to case.
+func jsonFieldByName(t reflect.Type, key string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		name := f.Name
+		if tag := strings.Split(f.Tag.Get("json"), ",")[0]; tag == "-" {
+			continue
+		} else if tag != "" {
+			name = tag
+		}
+		if strings.EqualFold(name, key) {
+			return f, true
+		}
+	}
+	return reflect.StructField{}, false
+}
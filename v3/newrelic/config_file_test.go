@@ -0,0 +1,223 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package newrelic
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeConfigFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func testLookupEnv(env map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		v, ok := env[name]
+		return v, ok
+	}
+}
+
+func TestConfigFromFile(t *testing.T) {
+	path := writeConfigFile(t, "newrelic.json", `{
+		"appName": "${SERVICE_NAME}",
+		"License": "${LICENSE}",
+		"Labels": {"team": "checkout", "zone": "${ZONE:-1}"},
+		"DistributedTracer": {"Enabled": "${DT_ENABLED}"},
+		"Attributes": {
+			"Exclude": ["request.headers.cookie", "request.headers.x-*"],
+			"Include": ["request.parameters.*"]
+		},
+		"BackgroundApdex": {"Threshold": "500ms", "MessageThreshold": 2000000000},
+		"TransactionNameRules": [{"MatchExpression": "^/users/[0-9]+", "Replacement": "/users/*"}],
+		"ErrorCollector": {"IgnoreStatusCodes": [404]},
+		"ApplicationLogging": {"Forwarding": {"MaxSamplesStored": "${LOG_SAMPLES:-2000}"}}
+	}`)
+	cfg := defaultConfig()
+	configFromFile(path, testLookupEnv(map[string]string{
+		"SERVICE_NAME": "checkout",
+		"LICENSE":      "0123456789012345678901234567890123456789",
+		"DT_ENABLED":   "true",
+	}))(&cfg)
+	if cfg.Error != nil {
+		t.Fatal(cfg.Error)
+	}
+	if cfg.AppName != "checkout" || cfg.License != "0123456789012345678901234567890123456789" {
+		t.Error("incorrect application:", cfg.AppName, cfg.License)
+	}
+	if len(cfg.Labels) != 2 || cfg.Labels["team"] != "checkout" || cfg.Labels["zone"] != "1" {
+		t.Error("incorrect labels:", cfg.Labels)
+	}
+	if !cfg.DistributedTracer.Enabled {
+		t.Error("distributed tracing not enabled")
+	}
+	if strings.Join(cfg.Attributes.Exclude, ",") != "request.headers.cookie,request.headers.x-*" ||
+		strings.Join(cfg.Attributes.Include, ",") != "request.parameters.*" {
+		t.Error("incorrect attributes:", cfg.Attributes)
+	}
+	if cfg.BackgroundApdex.Threshold != 500*time.Millisecond || cfg.BackgroundApdex.MessageThreshold != 2*time.Second {
+		t.Error("incorrect durations:", cfg.BackgroundApdex)
+	}
+	if len(cfg.TransactionNameRules) != 1 || cfg.TransactionNameRules[0].Replacement != "/users/*" {
+		t.Error("incorrect rules:", cfg.TransactionNameRules)
+	}
+	if len(cfg.ErrorCollector.IgnoreStatusCodes) != 1 || cfg.ErrorCollector.IgnoreStatusCodes[0] != 404 {
+		t.Error("incorrect status codes:", cfg.ErrorCollector.IgnoreStatusCodes)
+	}
+	if cfg.ApplicationLogging.Forwarding.MaxSamplesStored != 2000 {
+		t.Error("incorrect default:", cfg.ApplicationLogging.Forwarding.MaxSamplesStored)
+	}
+	if !cfg.TransactionEvents.Enabled {
+		t.Error("settings missing from the file changed")
+	}
+}
+
+func TestConfigFromYAMLFile(t *testing.T) {
+	path := writeConfigFile(t, "newrelic.yml", `
+# Application settings.
+appName: ${SERVICE_NAME}
+License: ${LICENSE}
+Labels:
+  team: checkout   # owner
+  "zone": 1
+DistributedTracer:
+  Enabled: ${DT_ENABLED}
+Attributes:
+  Exclude: [request.headers.cookie, "request.headers.x-*"]
+  Include:
+  - request.parameters.*
+BackgroundApdex:
+  Threshold: 500ms
+  MessageThreshold: 2000000000
+TransactionNameRules:
+  - MatchExpression: ^/users/[0-9]+
+    Replacement: /users/*
+ErrorCollector:
+  IgnoreStatusCodes: [404]
+ApplicationLogging:
+  Forwarding:
+    MaxSamplesStored: ${LOG_SAMPLES:-2000}
+`)
+	cfg := defaultConfig()
+	configFromFile(path, testLookupEnv(map[string]string{
+		"SERVICE_NAME": "checkout",
+		"LICENSE":      "0123456789012345678901234567890123456789",
+		"DT_ENABLED":   "true",
+	}))(&cfg)
+	if cfg.Error != nil {
+		t.Fatal(cfg.Error)
+	}
+	if cfg.AppName != "checkout" || cfg.License != "0123456789012345678901234567890123456789" {
+		t.Error("incorrect application:", cfg.AppName, cfg.License)
+	}
+	if len(cfg.Labels) != 2 || cfg.Labels["team"] != "checkout" || cfg.Labels["zone"] != "1" {
+		t.Error("incorrect labels:", cfg.Labels)
+	}
+	if !cfg.DistributedTracer.Enabled {
+		t.Error("distributed tracing not enabled")
+	}
+	if strings.Join(cfg.Attributes.Exclude, ",") != "request.headers.cookie,request.headers.x-*" ||
+		strings.Join(cfg.Attributes.Include, ",") != "request.parameters.*" {
+		t.Error("incorrect attributes:", cfg.Attributes)
+	}
+	if cfg.BackgroundApdex.Threshold != 500*time.Millisecond || cfg.BackgroundApdex.MessageThreshold != 2*time.Second {
+		t.Error("incorrect durations:", cfg.BackgroundApdex)
+	}
+	if len(cfg.TransactionNameRules) != 1 || cfg.TransactionNameRules[0].Replacement != "/users/*" {
+		t.Error("incorrect rules:", cfg.TransactionNameRules)
+	}
+	if len(cfg.ErrorCollector.IgnoreStatusCodes) != 1 || cfg.ErrorCollector.IgnoreStatusCodes[0] != 404 {
+		t.Error("incorrect status codes:", cfg.ErrorCollector.IgnoreStatusCodes)
+	}
+	if cfg.ApplicationLogging.Forwarding.MaxSamplesStored != 2000 {
+		t.Error("incorrect default:", cfg.ApplicationLogging.Forwarding.MaxSamplesStored)
+	}
+	if !cfg.TransactionEvents.Enabled {
+		t.Error("settings missing from the file changed")
+	}
+}
+
+func TestConfigFromFileVariablesCannotAddSettings(t *testing.T) {
+	path := writeConfigFile(t, "newrelic.json", `{"AppName": "${SERVICE_NAME}"}`)
+	cfg := defaultConfig()
+	configFromFile(path, testLookupEnv(map[string]string{
+		"SERVICE_NAME": `checkout", "HighSecurity": true, "x": "`,
+	}))(&cfg)
+	if cfg.Error != nil {
+		t.Fatal(cfg.Error)
+	}
+	if cfg.AppName != `checkout", "HighSecurity": true, "x": "` || cfg.HighSecurity {
+		t.Error("variable added settings:", cfg.AppName, cfg.HighSecurity)
+	}
+
+	path = writeConfigFile(t, "newrelic.yml", "AppName: ${SERVICE_NAME}\n")
+	cfg = defaultConfig()
+	configFromFile(path, testLookupEnv(map[string]string{
+		"SERVICE_NAME": "checkout\nHighSecurity: true",
+	}))(&cfg)
+	if cfg.Error != nil {
+		t.Fatal(cfg.Error)
+	}
+	if cfg.AppName != "checkout\nHighSecurity: true" || cfg.HighSecurity {
+		t.Error("variable added settings:", cfg.AppName, cfg.HighSecurity)
+	}
+}
+
+func TestConfigFromFileErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{name: "missing.json", want: "no such file"},
+		{name: "env.json", content: `{"AppName": "${SERVICE_NAME}", "License": "${LICENSE}", "HostDisplayName": "${LICENSE}"}`, want: "environment variables not set: LICENSE, SERVICE_NAME"},
+		{name: "syntax.json", content: `{"AppName": "app"`, want: "unexpected EOF"},
+		{name: "unknown.json", content: `{"DistributedTracer": {"Enable": true}}`, want: `unknown field "Enable"`},
+		{name: "duration.json", content: `{"BackgroundApdex": {"Threshold": "soon"}}`, want: "cannot unmarshal"},
+		{name: "type.json", content: `{"DistributedTracer": {"Enabled": "sometimes"}}`, want: "cannot unmarshal"},
+		{name: "variable.json", content: `{"DistributedTracer": {"Enabled": "${DT:-{}}"}}`, want: `DistributedTracer: Enabled: invalid value "{}"`},
+		{name: "list.json", content: `[{"AppName": "app"}]`, want: "the document is not a mapping"},
+		{name: "invalid.json", content: `{"AppName": 1}`, want: "cannot unmarshal"},
+		{name: "env.yml", content: "AppName: ${SERVICE_NAME}\nLicense: ${LICENSE}", want: "environment variables not set: LICENSE, SERVICE_NAME"},
+		{name: "indent.yml", content: "DistributedTracer:\n  Enabled: true\n    Other: 1", want: "line 3: unexpected indentation"},
+		{name: "block.yml", content: "AppName: |\n  app", want: "line 1: block scalars are not supported"},
+		{name: "flow.yml", content: "Labels: {team: checkout", want: "unterminated flow collection"},
+		{name: "unknown.yml", content: "DistributedTracer:\n  Enable: true", want: `unknown field "Enable"`},
+		{name: "duration.yml", content: "BackgroundApdex:\n  Threshold: soon", want: "cannot unmarshal"},
+		{name: "type.yml", content: "DistributedTracer:\n  Enabled: sometimes", want: "cannot unmarshal"},
+		{name: "variable.yml", content: "DistributedTracer:\n  Enabled: ${DT:-[]}", want: `DistributedTracer: Enabled: invalid value "[]"`},
+		{name: "list.yml", content: "- AppName: app", want: "the document is not a mapping"},
+	} {
+		path := filepath.Join(t.TempDir(), tc.name)
+		if tc.content != "" {
+			path = writeConfigFile(t, tc.name, tc.content)
+		}
+		cfg := defaultConfig()
+		configFromFile(path, testLookupEnv(nil))(&cfg)
+		if cfg.Error == nil || !strings.Contains(cfg.Error.Error(), tc.want) || !strings.Contains(cfg.Error.Error(), path) {
+			t.Errorf("incorrect error for %s: %v", tc.name, cfg.Error)
+		}
+	}
+}
+
+func TestConfigFromFileOverride(t *testing.T) {
+	path := writeConfigFile(t, "newrelic.json", `{"AppName": "from file", "License": "0123456789012345678901234567890123456789", "Enabled": false}`)
+	app, err := NewApplication(
+		ConfigFromFile(path),
+		ConfigAppName("from option"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg, _ := app.Config(); cfg.AppName != "from option" || cfg.License != "0123456789012345678901234567890123456789" {
+		t.Error("incorrect config:", cfg.AppName, cfg.License)
+	}
+}
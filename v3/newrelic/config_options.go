@@ -4,12 +4,17 @@
 package newrelic
 
 import (
+	"crypto/tls"
 	"fmt"
 	"io"
+	"net/http"
 	"os"
 	"strconv"
 	"strings"
+	"time"
 	"unicode/utf8"
+
+	"github.com/newrelic/go-agent/v3/internal/logger"
 )
 
 // ConfigOption configures the Config when provided to NewApplication.
@@ -36,6 +41,28 @@ func ConfigDistributedTracerEnabled(enabled bool) ConfigOption {
 	return func(cfg *Config) { cfg.DistributedTracer.Enabled = enabled }
 }
 
+// ConfigDistributedTracerAcceptAWSTraceHeader populates the Config's
+// DistributedTracer.AcceptAWSTraceHeader setting.  When enabled, the trace ID
+// of the X-Amzn-Trace-Id request header is used as the trace ID of web
+// transactions which did not receive trace context headers.
+func ConfigDistributedTracerAcceptAWSTraceHeader(enabled bool) ConfigOption {
+	return func(cfg *Config) { cfg.DistributedTracer.AcceptAWSTraceHeader = enabled }
+}
+
+// ConfigDistributedTracerExcludedHosts populates the Config's
+// DistributedTracer.ExcludedHosts setting.  Distributed tracing headers are
+// not added to outbound requests to these hosts.
+func ConfigDistributedTracerExcludedHosts(hosts ...string) ConfigOption {
+	return func(cfg *Config) { cfg.DistributedTracer.ExcludedHosts = hosts }
+}
+
+// ConfigDistributedTracerSampler populates the Config's
+// DistributedTracer.Sampler setting, which overrides the sampling decisions of
+// transactions.
+func ConfigDistributedTracerSampler(sampler Sampler) ConfigOption {
+	return func(cfg *Config) { cfg.DistributedTracer.Sampler = sampler }
+}
+
 // ConfigCustomInsightsEventsMaxSamplesStored alters the sample size allowing control
 // of how many custom events are stored in an agent for a given harvest cycle.
 // Alters the CustomInsightsEvents.MaxSamplesStored setting.
@@ -60,6 +87,53 @@ func ConfigDistributedTracerReservoirLimit(limit int) ConfigOption {
 	return func(cfg *Config) { cfg.DistributedTracer.ReservoirLimit = limit }
 }
 
+// ConfigSpanEventsAggregation enables the aggregation of identical span
+// events within a transaction.  Span events are combined when the values of
+// the keyAttributes are equal, or when all attributes are equal if no
+// keyAttributes are given.
+// Alters the SpanEvents.Aggregation setting.
+func ConfigSpanEventsAggregation(enabled bool, keyAttributes ...string) ConfigOption {
+	return func(cfg *Config) {
+		cfg.SpanEvents.Aggregation.Enabled = enabled
+		cfg.SpanEvents.Aggregation.KeyAttributes = keyAttributes
+	}
+}
+
+// ConfigTransactionNameCardinalityEnabled enables or disables the guard which
+// collapses transaction names when there are too many unique names.
+// Alters the TransactionNameCardinality.Enabled setting.
+func ConfigTransactionNameCardinalityEnabled(enabled bool) ConfigOption {
+	return func(cfg *Config) { cfg.TransactionNameCardinality.Enabled = enabled }
+}
+
+// ConfigTransactionNameCardinalityLimits sets the maximum number of unique
+// transaction names, and the maximum number of unique transaction names which
+// share a parameterized form, before transaction names are collapsed.
+// Alters the TransactionNameCardinality.MaxNames and
+// TransactionNameCardinality.MaxNamesPerPattern settings.
+func ConfigTransactionNameCardinalityLimits(maxNames, maxNamesPerPattern int) ConfigOption {
+	return func(cfg *Config) {
+		cfg.TransactionNameCardinality.MaxNames = maxNames
+		cfg.TransactionNameCardinality.MaxNamesPerPattern = maxNamesPerPattern
+	}
+}
+
+// ConfigTenantAccounting enables tenant accounting, using callback to find the
+// tenant of each transaction.  Alters the TenantAccounting.Enabled and
+// TenantAccounting.TenantCallback settings.
+func ConfigTenantAccounting(callback TenantCallback) ConfigOption {
+	return func(cfg *Config) {
+		cfg.TenantAccounting.Enabled = callback != nil
+		cfg.TenantAccounting.TenantCallback = callback
+	}
+}
+
+// ConfigTenantAccountingMaxTenants sets the maximum number of tenants recorded
+// by tenant accounting.  Alters the TenantAccounting.MaxTenants setting.
+func ConfigTenantAccountingMaxTenants(max int) ConfigOption {
+	return func(cfg *Config) { cfg.TenantAccounting.MaxTenants = max }
+}
+
 // ConfigAIMonitoringStreamingEnabled turns on or off the collection of AI Monitoring streaming mode metrics.
 func ConfigAIMonitoringStreamingEnabled(enabled bool) ConfigOption {
 	return func(cfg *Config) {
@@ -83,6 +157,58 @@ func ConfigDatastoreRawQuery(enabled bool) ConfigOption {
 	}
 }
 
+// ConfigDatastoreQuerySanitizer sets a function which is applied to the query
+// of each datastore segment before it is recorded.  See
+// Config.DatastoreTracer.QuerySanitizer.
+func ConfigDatastoreQuerySanitizer(sanitizer func(query string) string) ConfigOption {
+	return func(cfg *Config) {
+		cfg.DatastoreTracer.QuerySanitizer = sanitizer
+	}
+}
+
+// ConfigDatastoreExplainPlan enables or disables the capture of the query
+// plans of slow queries by the integrations which support it.
+func ConfigDatastoreExplainPlan(enabled bool) ConfigOption {
+	return func(cfg *Config) {
+		cfg.DatastoreTracer.ExplainPlan.Enabled = enabled
+	}
+}
+
+// ConfigDatastoreExplainPlanThreshold sets the duration above which the query
+// plans of slow queries are captured.
+func ConfigDatastoreExplainPlanThreshold(threshold time.Duration) ConfigOption {
+	return func(cfg *Config) {
+		cfg.DatastoreTracer.ExplainPlan.Threshold = threshold
+	}
+}
+
+// ConfigExpvarNames enables the reporting of the expvar variables with the
+// names given.  See Config.Expvar.
+func ConfigExpvarNames(names ...string) ConfigOption {
+	return func(cfg *Config) {
+		cfg.Expvar.Enabled = true
+		cfg.Expvar.Names = append(cfg.Expvar.Names, names...)
+	}
+}
+
+// ConfigExpvarPrefixes enables the reporting of the expvar variables whose
+// name starts with one of the prefixes given.  See Config.Expvar.
+func ConfigExpvarPrefixes(prefixes ...string) ConfigOption {
+	return func(cfg *Config) {
+		cfg.Expvar.Enabled = true
+		cfg.Expvar.Prefixes = append(cfg.Expvar.Prefixes, prefixes...)
+	}
+}
+
+// ConfigDatastoreConnectionPoolMetrics enables or disables the sampling of
+// the connection pool statistics of the databases registered using
+// Application.MonitorDBStats.
+func ConfigDatastoreConnectionPoolMetrics(enabled bool) ConfigOption {
+	return func(cfg *Config) {
+		cfg.DatastoreTracer.ConnectionPoolMetrics.Enabled = enabled
+	}
+}
+
 // ConfigCodeLevelMetricsIgnoredPrefix alters the way the Code Level Metrics
 // collection code searches for the right function to report for a given
 // telemetry trace. It will find the innermost function whose name does NOT
@@ -214,6 +340,270 @@ func ConfigCodeLevelMetricsPathPrefixes(prefix ...string) ConfigOption {
 	}
 }
 
+// ConfigStackTraceMaxDepth sets the maximum number of frames of the stack
+// traces of errors, which cannot exceed 100.
+func ConfigStackTraceMaxDepth(depth int) ConfigOption {
+	return func(cfg *Config) {
+		cfg.StackTraces.MaxDepth = depth
+	}
+}
+
+// ConfigStackTraceFrameFilters controls the removal of the frames of the
+// runtime package and of vendored packages from the stack traces of errors,
+// and whether the GOPATH or GOROOT is trimmed from their file paths.  See
+// Config.StackTraces.
+func ConfigStackTraceFrameFilters(skipRuntime, skipVendor, trimGOPATH bool) ConfigOption {
+	return func(cfg *Config) {
+		cfg.StackTraces.SkipRuntimeFrames = skipRuntime
+		cfg.StackTraces.SkipVendorFrames = skipVendor
+		cfg.StackTraces.TrimGOPATH = trimGOPATH
+	}
+}
+
+// ConfigTransactionNameRules adds rules which rewrite the names of
+// transactions locally to TransactionNameRules.  See
+// Config.TransactionNameRules.
+func ConfigTransactionNameRules(rules ...TransactionNameRule) ConfigOption {
+	return func(cfg *Config) {
+		cfg.TransactionNameRules = append(cfg.TransactionNameRules, rules...)
+	}
+}
+
+// ConfigIgnoreTransactions adds patterns of the final names of transactions
+// to ignore, such as health and readiness probes, to
+// IgnoredTransactionNames.  Patterns surrounded by slashes are regular
+// expressions, and all others are globs.  See
+// Config.IgnoredTransactionNames.
+func ConfigIgnoreTransactions(patterns ...string) ConfigOption {
+	return func(cfg *Config) {
+		cfg.IgnoredTransactionNames = append(cfg.IgnoredTransactionNames, patterns...)
+	}
+}
+
+// ConfigSpanEventsStackTraceThreshold enables the capture of the stack traces
+// of segments whose duration exceeds the threshold, which are added to their
+// span events.  See Config.SpanEvents.StackTraces.
+func ConfigSpanEventsStackTraceThreshold(threshold time.Duration) ConfigOption {
+	return func(cfg *Config) {
+		cfg.SpanEvents.StackTraces.Enabled = true
+		cfg.SpanEvents.StackTraces.Threshold = threshold
+	}
+}
+
+// ConfigSpanEventsContextErrors controls whether spans whose context was
+// canceled or exceeded its deadline are marked as errors.  See
+// Config.SpanEvents.ContextErrors.
+func ConfigSpanEventsContextErrors(enabled bool) ConfigOption {
+	return func(cfg *Config) {
+		cfg.SpanEvents.ContextErrors = enabled
+	}
+}
+
+// ConfigBackgroundApdex enables the Apdex scoring of background transactions
+// using the threshold provided, and the message threshold for transactions
+// which consume messages.  Zero thresholds fall back as described in
+// Config.BackgroundApdex.
+func ConfigBackgroundApdex(threshold, messageThreshold time.Duration) ConfigOption {
+	return func(cfg *Config) {
+		cfg.BackgroundApdex.Enabled = true
+		cfg.BackgroundApdex.Threshold = threshold
+		cfg.BackgroundApdex.MessageThreshold = messageThreshold
+	}
+}
+
+// ConfigRuntimeSamplerDetailedMetrics controls whether detailed runtime
+// statistics are recorded.  See Config.RuntimeSampler.DetailedMetrics.
+func ConfigRuntimeSamplerDetailedMetrics(enabled bool) ConfigOption {
+	return func(cfg *Config) {
+		cfg.RuntimeSampler.DetailedMetrics = enabled
+	}
+}
+
+// ConfigRuntimeSamplerTransactionAttributes controls whether the GC pause
+// time and the scheduler latency during each transaction are added to it.
+// See Config.RuntimeSampler.TransactionAttributes.
+func ConfigRuntimeSamplerTransactionAttributes(enabled bool) ConfigOption {
+	return func(cfg *Config) {
+		cfg.RuntimeSampler.TransactionAttributes = enabled
+	}
+}
+
+// ConfigGoroutineTransactionsEnabled enables the EXPERIMENTAL binding of
+// transactions to goroutines used by CurrentTransaction.  See
+// Config.GoroutineTransactions.
+func ConfigGoroutineTransactionsEnabled(enabled bool) ConfigOption {
+	return func(cfg *Config) {
+		cfg.GoroutineTransactions.Enabled = enabled
+	}
+}
+
+// ConfigSemanticConventionsRPC controls whether gRPC integrations add the
+// OpenTelemetry semantic convention attributes for RPCs.  See
+// Config.SemanticConventions.
+func ConfigSemanticConventionsRPC(enabled bool) ConfigOption {
+	return func(cfg *Config) {
+		cfg.SemanticConventions.RPC = enabled
+	}
+}
+
+// ConfigSemanticConventionsHTTP controls whether the HTTP attributes of web
+// transactions and external segments use their legacy names, their
+// OpenTelemetry semantic convention names, or both.  See
+// Config.SemanticConventions.
+func ConfigSemanticConventionsHTTP(mode SemanticConventionsMode) ConfigOption {
+	return func(cfg *Config) {
+		cfg.SemanticConventions.HTTP = mode
+	}
+}
+
+// ConfigCustomEventTypes sets the event types which RecordCustomEvent will
+// collect, and those it will not collect.  An empty allowed list allows every
+// type which is not denied.  See Config.CustomInsightsEvents.
+func ConfigCustomEventTypes(allowed, denied []string) ConfigOption {
+	return func(cfg *Config) {
+		cfg.CustomInsightsEvents.AllowedEventTypes = allowed
+		cfg.CustomInsightsEvents.DeniedEventTypes = denied
+	}
+}
+
+// ConfigCustomEventTypeQuota limits the number of custom events of the type
+// collected during a harvest cycle.  See
+// Config.CustomInsightsEvents.MaxSamplesPerEventType.
+func ConfigCustomEventTypeQuota(eventType string, max int) ConfigOption {
+	return func(cfg *Config) {
+		if cfg.CustomInsightsEvents.MaxSamplesPerEventType == nil {
+			cfg.CustomInsightsEvents.MaxSamplesPerEventType = make(map[string]int)
+		}
+		cfg.CustomInsightsEvents.MaxSamplesPerEventType[eventType] = max
+	}
+}
+
+// ConfigTelemetryPauseDropData controls whether the data collected while
+// telemetry is paused by Application.PauseTelemetry is discarded instead of
+// buffered.  See Config.TelemetryPause.
+func ConfigTelemetryPauseDropData(drop bool) ConfigOption {
+	return func(cfg *Config) {
+		cfg.TelemetryPause.DropData = drop
+	}
+}
+
+// ConfigHTTPTransport sets the transport used to communicate with the New
+// Relic servers, for example to use a proxy, a corporate CA bundle, or a
+// client certificate for mutual TLS:
+//
+//	cert, err := tls.LoadX509KeyPair("client.crt", "client.key")
+//	if err != nil {
+//		panic(err)
+//	}
+//	app, err := newrelic.NewApplication(
+//		newrelic.ConfigAppName("Example App"),
+//		newrelic.ConfigLicense(os.Getenv("NEW_RELIC_LICENSE_KEY")),
+//		newrelic.ConfigHTTPTransport(&http.Transport{
+//			Proxy:           http.ProxyURL(proxyURL),
+//			TLSClientConfig: &tls.Config{Certificates: []tls.Certificate{cert}},
+//		}),
+//	)
+//
+// See Config.Transport.
+func ConfigHTTPTransport(transport *http.Transport) ConfigOption {
+	return func(cfg *Config) {
+		if nil != transport {
+			cfg.Transport = transport
+		}
+	}
+}
+
+// ConfigHTTPClient sets the client used to communicate with the New Relic
+// servers.  See Config.HTTPClient.
+func ConfigHTTPClient(client *http.Client) ConfigOption {
+	return func(cfg *Config) {
+		cfg.HTTPClient = client
+	}
+}
+
+// ConfigCollectorTLS sets the TLS configuration used to communicate with the
+// New Relic servers, for example to trust a corporate CA bundle.  See
+// Config.CollectorTLS.
+func ConfigCollectorTLS(config *tls.Config) ConfigOption {
+	return func(cfg *Config) {
+		cfg.CollectorTLS.Config = config
+	}
+}
+
+// ConfigFIPSMode restricts the connections to the New Relic servers to the
+// TLS versions, cipher suites, and curves approved by FIPS 140-2, and
+// disables cross application tracing.  See Config.CollectorTLS.
+func ConfigFIPSMode(enabled bool) ConfigOption {
+	return func(cfg *Config) {
+		cfg.CollectorTLS.FIPSMode = enabled
+	}
+}
+
+// ConfigMessagePayloadsEnabled controls the capture of the key, the headers,
+// and a snippet of the body of messages.  See Config.MessagePayloads.
+func ConfigMessagePayloadsEnabled(enabled bool) ConfigOption {
+	return func(cfg *Config) {
+		cfg.MessagePayloads.Enabled = enabled
+	}
+}
+
+// ConfigMessagePayloadRedactor sets a function which is applied to a copy of
+// each message before it is recorded.  See Config.MessagePayloads.Redactor.
+func ConfigMessagePayloadRedactor(redactor func(*MessagePayload)) ConfigOption {
+	return func(cfg *Config) {
+		cfg.MessagePayloads.Redactor = redactor
+	}
+}
+
+// ConfigAgentHealth enables the health status file, written in the directory
+// given, either a path or a file:// URI.  See Config.AgentHealth.
+func ConfigAgentHealth(location string) ConfigOption {
+	return func(cfg *Config) {
+		cfg.AgentHealth.Enabled = true
+		cfg.AgentHealth.DeliveryLocation = location
+	}
+}
+
+// ConfigHarvestSpool enables the spooling to disk of the harvest payloads
+// which could not be sent to New Relic, in the directory given.  See
+// Config.HarvestSpool.
+func ConfigHarvestSpool(directory string) ConfigOption {
+	return func(cfg *Config) {
+		cfg.HarvestSpool.Enabled = true
+		cfg.HarvestSpool.Directory = directory
+	}
+}
+
+// ConfigSecondaryDestination adds an account to which the data selected is
+// also sent to SecondaryDestinations.  See Config.SecondaryDestinations.
+func ConfigSecondaryDestination(license string, data SecondaryData) ConfigOption {
+	return func(cfg *Config) {
+		cfg.SecondaryDestinations = append(cfg.SecondaryDestinations, SecondaryDestination{
+			License: license,
+			Data:    data,
+		})
+	}
+}
+
+// ConfigGoroutineBudget limits the number of goroutines run by the agent,
+// turning off the features which do not fit.  See Config.GoroutineBudget.
+// Defaults: max=0, meaning no limit
+func ConfigGoroutineBudget(max int) ConfigOption {
+	return func(cfg *Config) {
+		cfg.GoroutineBudget.Max = max
+	}
+}
+
+// ConfigKubernetesMetadataEnabled enables or disables the attributes
+// describing the Kubernetes pod running the application.  See
+// Config.KubernetesMetadata.
+// Defaults: enabled=false
+func ConfigKubernetesMetadataEnabled(enabled bool) ConfigOption {
+	return func(cfg *Config) {
+		cfg.KubernetesMetadata.Enabled = enabled
+	}
+}
+
 // ConfigAppLogForwardingEnabled enables or disables the collection
 // of logs from a user's application by the agent
 // Defaults: enabled=false
@@ -325,6 +715,24 @@ func ConfigModuleDependencyMetricsIgnoredPrefixes(prefix ...string) ConfigOption
 	}
 }
 
+// ConfigExpectedStatusCodes adds response codes to the list of codes which
+// are captured as expected errors, and so do not affect the error rate or
+// apdex score of your application.  See Config.ErrorCollector.ExpectStatusCodes.
+func ConfigExpectedStatusCodes(codes ...int) ConfigOption {
+	return func(cfg *Config) {
+		cfg.ErrorCollector.ExpectStatusCodes = append(cfg.ErrorCollector.ExpectStatusCodes, codes...)
+	}
+}
+
+// ConfigExpectedErrorClasses adds error classes to the list of classes which
+// are captured as expected errors, as if they had been noticed using
+// Transaction.NoticeExpectedError.  See Config.ErrorCollector.ExpectClasses.
+func ConfigExpectedErrorClasses(classes ...string) ConfigOption {
+	return func(cfg *Config) {
+		cfg.ErrorCollector.ExpectClasses = append(cfg.ErrorCollector.ExpectClasses, classes...)
+	}
+}
+
 // ConfigSetErrorGroupCallbackFunction set a callback function of type ErrorGroupCallback that will
 // be invoked against errors at harvest time. This function overrides the default grouping behavior
 // of errors into a custom, user defined group when set. Setting this may have performance implications
@@ -336,6 +744,15 @@ func ConfigSetErrorGroupCallbackFunction(callback ErrorGroupCallback) ConfigOpti
 	}
 }
 
+// ConfigServerSideConfigCallback sets a callback which is called when the
+// server-side configuration, set in the New Relic UI, changes.  See
+// Config.ServerSideConfigCallback.
+func ConfigServerSideConfigCallback(callback func(ServerSideConfigUpdate)) ConfigOption {
+	return func(cfg *Config) {
+		cfg.ServerSideConfigCallback = callback
+	}
+}
+
 // ConfigModuleDependencyMetricsRedactIgnoredPrefixes controls whether the names
 // of ignored module path prefixes should be redacted from the agent configuration data
 // reported and visible in the New Relic UI. Since one of the reasons these
@@ -357,6 +774,26 @@ func ConfigDebugLogger(w io.Writer) ConfigOption {
 	return ConfigLogger(NewDebugLogger(w))
 }
 
+// ConfigLogDestination adds a destination to the agent log which receives the
+// messages at or above the level.  Unlike ConfigLogger, ConfigInfoLogger, and
+// ConfigDebugLogger, which replace the Logger, each destination is added to
+// the Logger already configured, so that the agent log can be written to
+// several destinations with independent levels.  For example, to write
+// warnings and errors to standard error and every message to a file, which may
+// be rotated by the writer:
+//
+//	app, err := newrelic.NewApplication(
+//		newrelic.ConfigAppName("Example App"),
+//		newrelic.ConfigLicense(os.Getenv("NEW_RELIC_LICENSE_KEY")),
+//		newrelic.ConfigLogDestination(os.Stderr, newrelic.LogLevelWarn),
+//		newrelic.ConfigLogDestination(logFile, newrelic.LogLevelDebug),
+//	)
+func ConfigLogDestination(w io.Writer, level LogLevel) ConfigOption {
+	return func(cfg *Config) {
+		cfg.Logger = NewMultiLogger(cfg.Logger, logger.NewLevel(w, logger.Level(level)))
+	}
+}
+
 // ConfigFromEnvironment populates the config based on environment variables:
 //
 //		NEW_RELIC_APP_NAME                                			sets AppName
@@ -372,12 +809,16 @@ func ConfigDebugLogger(w io.Writer) ConfigOption {
 //	 	NEW_RELIC_CODE_LEVEL_METRICS_REDACT_IGNORED_PREFIXES 		sets CodeLevelMetrics.RedactIgnoredPrefixes to a boolean value
 //		NEW_RELIC_CODE_LEVEL_METRICS_IGNORED_PREFIX       			sets CodeLevelMetrics.IgnoredPrefixes using a comma-separated list
 //		NEW_RELIC_DISTRIBUTED_TRACING_ENABLED             			sets DistributedTracer.Enabled using strconv.ParseBool
+//		NEW_RELIC_DISTRIBUTED_TRACING_EXCLUDED_HOSTS      			sets DistributedTracer.ExcludedHosts using a comma-separated list
 //		NEW_RELIC_ENABLED                                 			sets Enabled using strconv.ParseBool
+//		NEW_RELIC_GOROUTINE_BUDGET_MAX                    			sets GoroutineBudget.Max using strconv.Atoi
 //		NEW_RELIC_HIGH_SECURITY                           			sets HighSecurity using strconv.ParseBool
 //		NEW_RELIC_HOST                                    			sets Host
 //		NEW_RELIC_INFINITE_TRACING_SPAN_EVENTS_QUEUE_SIZE 			sets InfiniteTracing.SpanEvents.QueueSize using strconv.Atoi
 //		NEW_RELIC_INFINITE_TRACING_TRACE_OBSERVER_PORT    			sets InfiniteTracing.TraceObserver.Port using strconv.Atoi
 //		NEW_RELIC_INFINITE_TRACING_TRACE_OBSERVER_HOST    			sets InfiniteTracing.TraceObserver.Host
+//		NEW_RELIC_KUBERNETES_METADATA_ENABLED             			sets KubernetesMetadata.Enabled using strconv.ParseBool
+//		NEW_RELIC_KUBERNETES_METADATA_CLUSTER_NAME        			sets KubernetesMetadata.ClusterName
 //		NEW_RELIC_LABELS                                  			sets Labels using a semi-colon delimited string of colon-separated pairs, eg. "Server:One;DataCenter:Primary"
 //		NEW_RELIC_LICENSE_KEY                             			sets License
 //		NEW_RELIC_LOG                                     			sets Logger to log to either "stdout" or "stderr" (filenames are not supported)
@@ -395,6 +836,9 @@ func ConfigDebugLogger(w io.Writer) ConfigOption {
 //		NEW_RELIC_AI_MONITORING_ENABLED								sets AIMonitoring.Enabled
 //		NEW_RELIC_AI_MONITORING_STREAMING_ENABLED					sets AIMonitoring.Streaming.Enabled
 //		NEW_RELIC_AI_MONITORING_RECORD_CONTENT_ENABLED				sets AIMonitoring.RecordContent.Enabled
+//		NEW_RELIC_AGENT_CONTROL_ENABLED								sets AgentHealth.Enabled
+//		NEW_RELIC_AGENT_CONTROL_HEALTH_DELIVERY_LOCATION			sets AgentHealth.DeliveryLocation
+//		NEW_RELIC_AGENT_CONTROL_HEALTH_FREQUENCY					sets AgentHealth.Frequency in seconds
 //
 // This function is strict and will assign Config.Error if any of the
 // environment variables cannot be parsed.
@@ -451,6 +895,9 @@ func configFromEnvironment(getenv func(string) string) ConfigOption {
 		assignInt(&cfg.Utilization.LogicalProcessors, "NEW_RELIC_UTILIZATION_LOGICAL_PROCESSORS")
 		assignInt(&cfg.Utilization.TotalRAMMIB, "NEW_RELIC_UTILIZATION_TOTAL_RAM_MIB")
 		assignInt(&cfg.InfiniteTracing.SpanEvents.QueueSize, "NEW_RELIC_INFINITE_TRACING_SPAN_EVENTS_QUEUE_SIZE")
+		assignInt(&cfg.GoroutineBudget.Max, "NEW_RELIC_GOROUTINE_BUDGET_MAX")
+		assignBool(&cfg.KubernetesMetadata.Enabled, "NEW_RELIC_KUBERNETES_METADATA_ENABLED")
+		assignString(&cfg.KubernetesMetadata.ClusterName, "NEW_RELIC_KUBERNETES_METADATA_CLUSTER_NAME")
 
 		// Application Logging Env Variables
 		assignBool(&cfg.ApplicationLogging.Enabled, "NEW_RELIC_APPLICATION_LOGGING_ENABLED")
@@ -461,6 +908,16 @@ func configFromEnvironment(getenv func(string) string) ConfigOption {
 		assignBool(&cfg.AIMonitoring.Enabled, "NEW_RELIC_AI_MONITORING_ENABLED")
 		assignBool(&cfg.AIMonitoring.Streaming.Enabled, "NEW_RELIC_AI_MONITORING_STREAMING_ENABLED")
 		assignBool(&cfg.AIMonitoring.RecordContent.Enabled, "NEW_RELIC_AI_MONITORING_RECORD_CONTENT_ENABLED")
+		assignBool(&cfg.AgentHealth.Enabled, "NEW_RELIC_AGENT_CONTROL_ENABLED")
+		assignString(&cfg.AgentHealth.DeliveryLocation, "NEW_RELIC_AGENT_CONTROL_HEALTH_DELIVERY_LOCATION")
+
+		if env := getenv("NEW_RELIC_AGENT_CONTROL_HEALTH_FREQUENCY"); env != "" {
+			if seconds, err := strconv.Atoi(env); nil == err && seconds > 0 {
+				cfg.AgentHealth.Frequency = time.Duration(seconds) * time.Second
+			} else {
+				cfg.Error = fmt.Errorf("invalid NEW_RELIC_AGENT_CONTROL_HEALTH_FREQUENCY value: %s", env)
+			}
+		}
 
 		if env := getenv("NEW_RELIC_LABELS"); env != "" {
 			if labels := getLabels(getenv("NEW_RELIC_LABELS")); len(labels) > 0 {
@@ -477,6 +934,10 @@ func configFromEnvironment(getenv func(string) string) ConfigOption {
 			cfg.Attributes.Exclude = strings.Split(env, ",")
 		}
 
+		if env := getenv("NEW_RELIC_DISTRIBUTED_TRACING_EXCLUDED_HOSTS"); env != "" {
+			cfg.DistributedTracer.ExcludedHosts = strings.Split(env, ",")
+		}
+
 		if env := getenv("NEW_RELIC_CODE_LEVEL_METRICS_SCOPE"); env != "" {
 			var ok bool
 			cfg.CodeLevelMetrics.Scope, ok = CodeLevelMetricsScopeLabelListToValue(env)
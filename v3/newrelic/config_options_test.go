@@ -4,8 +4,13 @@
 package newrelic
 
 import (
+	"bytes"
+	"encoding/json"
+	"net/http"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestConfigFromEnvironment(t *testing.T) {
@@ -45,6 +50,8 @@ func TestConfigFromEnvironment(t *testing.T) {
 			return "456"
 		case "NEW_RELIC_INFINITE_TRACING_SPAN_EVENTS_QUEUE_SIZE":
 			return "98765"
+		case "NEW_RELIC_GOROUTINE_BUDGET_MAX":
+			return "6"
 		case "NEW_RELIC_CODE_LEVEL_METRICS_SCOPE":
 			return "all"
 		case "NEW_RELIC_CODE_LEVEL_METRICS_PATH_PREFIX":
@@ -74,6 +81,7 @@ func TestConfigFromEnvironment(t *testing.T) {
 	expect.InfiniteTracing.TraceObserver.Host = "myhost.com"
 	expect.InfiniteTracing.TraceObserver.Port = 456
 	expect.InfiniteTracing.SpanEvents.QueueSize = 98765
+	expect.GoroutineBudget.Max = 6
 	expect.CodeLevelMetrics.Scope = AllCLM
 	expect.CodeLevelMetrics.PathPrefixes = []string{"/foo/bar", "/spam/spam/spam/frotz"}
 	expect.CodeLevelMetrics.IgnoredPrefixes = []string{"/a/b", "/c/d"}
@@ -173,6 +181,22 @@ func TestConfigFromEnvironmentAttributes(t *testing.T) {
 	}
 }
 
+func TestConfigFromEnvironmentExcludedHosts(t *testing.T) {
+	cfgOpt := configFromEnvironment(func(s string) string {
+		switch s {
+		case "NEW_RELIC_DISTRIBUTED_TRACING_EXCLUDED_HOSTS":
+			return "payments.example.com,*.example.org"
+		default:
+			return ""
+		}
+	})
+	cfg := defaultConfig()
+	cfgOpt(&cfg)
+	if !reflect.DeepEqual(cfg.DistributedTracer.ExcludedHosts, []string{"payments.example.com", "*.example.org"}) {
+		t.Error("incorrect config value:", cfg.DistributedTracer.ExcludedHosts)
+	}
+}
+
 func TestConfigFromEnvironmentInvalidBool(t *testing.T) {
 	cfgOpt := configFromEnvironment(func(s string) string {
 		switch s {
@@ -252,3 +276,129 @@ func TestConfigFromEnvironmentLabelsSuccess(t *testing.T) {
 		t.Error(cfg.Labels)
 	}
 }
+
+func TestConfigLogDestination(t *testing.T) {
+	var warnBuf, debugBuf bytes.Buffer
+	cfg := defaultConfig()
+	ConfigLogDestination(&warnBuf, LogLevelWarn)(&cfg)
+	ConfigLogDestination(&debugBuf, LogLevelDebug)(&cfg)
+
+	lg := cfg.Logger
+	if !lg.DebugEnabled() {
+		t.Error("debug not enabled")
+	}
+	lg.Error("error message", nil)
+	lg.Warn("warn message", nil)
+	lg.Info("info message", nil)
+	lg.Debug("debug message", nil)
+
+	warnLog, debugLog := warnBuf.String(), debugBuf.String()
+	for _, msg := range []string{"error message", "warn message"} {
+		if !strings.Contains(warnLog, msg) {
+			t.Errorf("%q missing from %q", msg, warnLog)
+		}
+	}
+	for _, msg := range []string{"info message", "debug message"} {
+		if strings.Contains(warnLog, msg) {
+			t.Errorf("%q unexpected in %q", msg, warnLog)
+		}
+	}
+	for _, msg := range []string{"error message", "warn message", "info message", "debug message"} {
+		if !strings.Contains(debugLog, msg) {
+			t.Errorf("%q missing from %q", msg, debugLog)
+		}
+	}
+}
+
+func TestConfigLogDestinationAddsToLogger(t *testing.T) {
+	var loggerBuf, errorBuf bytes.Buffer
+	cfg := defaultConfig()
+	ConfigInfoLogger(&loggerBuf)(&cfg)
+	ConfigLogDestination(&errorBuf, LogLevelError)(&cfg)
+
+	lg := cfg.Logger
+	if lg.DebugEnabled() {
+		t.Error("debug enabled")
+	}
+	lg.Warn("warn message", nil)
+	lg.Error("error message", nil)
+	if s := loggerBuf.String(); !strings.Contains(s, "warn message") || !strings.Contains(s, "error message") {
+		t.Error(s)
+	}
+	if s := errorBuf.String(); strings.Contains(s, "warn message") || !strings.Contains(s, "error message") {
+		t.Error(s)
+	}
+}
+
+func TestNewMultiLoggerSkipsNil(t *testing.T) {
+	var buf bytes.Buffer
+	lg := NewMultiLogger(nil, NewLogger(&buf), nil)
+	lg.Info("info message", nil)
+	if !strings.Contains(buf.String(), "info message") {
+		t.Error(buf.String())
+	}
+	// A single logger is not wrapped.
+	if loggerSetting(lg) != loggerSetting(NewLogger(&buf)) {
+		t.Error(loggerSetting(lg))
+	}
+	NewMultiLogger().Error("error message", nil)
+}
+
+func TestExpectedStatusCodesOption(t *testing.T) {
+	cfg := defaultConfig()
+	ConfigExpectedStatusCodes(404)(&cfg)
+	ConfigExpectedStatusCodes(409, 422)(&cfg)
+	if !reflect.DeepEqual(cfg.ErrorCollector.ExpectStatusCodes, []int{404, 409, 422}) {
+		t.Error(cfg.ErrorCollector.ExpectStatusCodes)
+	}
+}
+
+func TestConfigHTTPTransport(t *testing.T) {
+	transport := &http.Transport{}
+	cfg := defaultConfig()
+	ConfigHTTPTransport(transport)(&cfg)
+	if cfg.Transport != transport {
+		t.Error("transport not set:", cfg.Transport)
+	}
+	ConfigHTTPTransport(nil)(&cfg)
+	if cfg.Transport != transport {
+		t.Error("transport unset by nil transport:", cfg.Transport)
+	}
+	if client := newCollectorClient(cfg); client.Transport != transport || client.Timeout != collectorTimeout {
+		t.Errorf("incorrect collector client: %+v", client)
+	}
+}
+
+func TestConfigHTTPClient(t *testing.T) {
+	client := &http.Client{Transport: &http.Transport{}}
+	cfg := defaultConfig()
+	ConfigHTTPTransport(&http.Transport{})(&cfg)
+	ConfigHTTPClient(client)(&cfg)
+	if cfg.HTTPClient != client {
+		t.Error("client not set:", cfg.HTTPClient)
+	}
+
+	collectorClient := newCollectorClient(cfg)
+	if collectorClient == client || collectorClient.Transport != client.Transport || collectorClient.Timeout != collectorTimeout {
+		t.Errorf("incorrect collector client: %+v", collectorClient)
+	}
+	if client.Timeout != 0 {
+		t.Error("client modified:", client.Timeout)
+	}
+
+	client.Timeout = time.Second
+	if collectorClient := newCollectorClient(cfg); collectorClient.Timeout != time.Second {
+		t.Error("client timeout not used:", collectorClient.Timeout)
+	}
+	if usesDirectConnection(config{Config: cfg}, "collector.newrelic.com") {
+		t.Error("direct connection used with a client")
+	}
+
+	js, err := json.Marshal(settings(cfg))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(js), `"HTTPClient":"*http.Transport"`) {
+		t.Error("incorrect HTTPClient setting:", string(js))
+	}
+}
@@ -0,0 +1,290 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package newrelic
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sync/atomic"
+	"time"
+)
+
+var errConfigNotReloadable = errors.New("only the Logger, the attribute settings, DistributedTracer.Sampler, " +
+	"and the forwarding, metrics, and local decorating settings of ApplicationLogging may be updated")
+
+// reloadableLogger is the Logger of the application, which is replaced when
+// the Logger is updated using UpdateConfig.
+type reloadableLogger struct {
+	current atomic.Value // loggerHolder
+}
+
+// loggerHolder holds the Logger, as the values stored in an atomic.Value must
+// be of the same concrete type.
+type loggerHolder struct{ Logger }
+
+func newReloadableLogger(lg Logger) *reloadableLogger {
+	l := &reloadableLogger{}
+	l.set(lg)
+	return l
+}
+
+func (l *reloadableLogger) set(lg Logger)  { l.current.Store(loggerHolder{lg}) }
+func (l *reloadableLogger) logger() Logger { return l.current.Load().(loggerHolder).Logger }
+
+func (l *reloadableLogger) Error(msg string, c map[string]interface{}) { l.logger().Error(msg, c) }
+func (l *reloadableLogger) Warn(msg string, c map[string]interface{})  { l.logger().Warn(msg, c) }
+func (l *reloadableLogger) Info(msg string, c map[string]interface{})  { l.logger().Info(msg, c) }
+func (l *reloadableLogger) Debug(msg string, c map[string]interface{}) { l.logger().Debug(msg, c) }
+func (l *reloadableLogger) DebugEnabled() bool                         { return l.logger().DebugEnabled() }
+
+// checkReloadable returns an error if the updated Config changes settings
+// which cannot be changed at runtime.
+func checkReloadable(current, updated Config) error {
+	masked := updated
+	masked.Logger = current.Logger
+	masked.Attributes = current.Attributes
+	masked.TransactionEvents.Attributes = current.TransactionEvents.Attributes
+	masked.ErrorCollector.Attributes = current.ErrorCollector.Attributes
+	masked.TransactionTracer.Attributes = current.TransactionTracer.Attributes
+	masked.TransactionTracer.Segments.Attributes = current.TransactionTracer.Segments.Attributes
+	masked.BrowserMonitoring.Attributes = current.BrowserMonitoring.Attributes
+	masked.SpanEvents.Attributes = current.SpanEvents.Attributes
+	masked.DistributedTracer.Sampler = current.DistributedTracer.Sampler
+	masked.ApplicationLogging.Forwarding = current.ApplicationLogging.Forwarding
+	masked.ApplicationLogging.Metrics.Enabled = current.ApplicationLogging.Metrics.Enabled
+	masked.ApplicationLogging.LocalDecorating.Enabled = current.ApplicationLogging.LocalDecorating.Enabled
+
+	// The settings are compared using their JSON representation, which
+	// omits the functions, such as the callbacks, which cannot be
+	// compared.  The license keys are not part of it.
+	if masked.License != current.License || !reflect.DeepEqual(masked.SecondaryDestinations, current.SecondaryDestinations) {
+		return errConfigNotReloadable
+	}
+	before, err := json.Marshal(settings(current))
+	if err != nil {
+		return err
+	}
+	after, err := json.Marshal(settings(masked))
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(before, after) {
+		return errConfigNotReloadable
+	}
+	return nil
+}
+
+// copyAttributeSettings returns a copy of the Config whose attribute include
+// and exclude lists do not share memory with the original.
+func copyAttributeSettings(cfg Config) Config {
+	for _, attrs := range []*AttributeDestinationConfig{
+		&cfg.Attributes,
+		&cfg.TransactionEvents.Attributes,
+		&cfg.ErrorCollector.Attributes,
+		&cfg.TransactionTracer.Attributes,
+		&cfg.TransactionTracer.Segments.Attributes,
+		&cfg.BrowserMonitoring.Attributes,
+		&cfg.SpanEvents.Attributes,
+	} {
+		if nil != attrs.Include {
+			attrs.Include = append([]string(nil), attrs.Include...)
+		}
+		if nil != attrs.Exclude {
+			attrs.Exclude = append([]string(nil), attrs.Exclude...)
+		}
+	}
+	return cfg
+}
+
+// loggingConfigUpdate replaces the logging configuration of the log events
+// of the harvest, once the application logging settings are updated.
+type loggingConfigUpdate struct {
+	config loggingConfig
+}
+
+func (u loggingConfigUpdate) MergeIntoHarvest(h *harvest) {
+	u.config.maxLogEvents = h.LogEvents.config.maxLogEvents
+	h.LogEvents.config = u.config
+}
+
+// withConfig returns a copy of the run using the updated configuration.  Only
+// the configuration and the attribute settings are replaced: the sampler, the
+// transaction name caches, and the state of the transaction name guard and of
+// the tenant accounting are kept, since the transactions of the run share
+// them.  The server-side configuration still overrides the updated settings.
+func (run *appRun) withConfig(c config) *appRun {
+	derived := newAppRun(c, run.Reply)
+	return &appRun{
+		Reply:                 run.Reply,
+		AttributeConfig:       derived.AttributeConfig,
+		Config:                derived.Config,
+		firstAppName:          run.firstAppName,
+		adaptiveSampler:       run.adaptiveSampler,
+		rulesCache:            run.rulesCache,
+		txnNameGuard:          run.txnNameGuard,
+		tenantAccountant:      run.tenantAccountant,
+		harvestConfig:         run.harvestConfig,
+		ignoreErrorCodesCache: derived.ignoreErrorCodesCache,
+		expectErrorCodesCache: derived.expectErrorCodesCache,
+		expectErrorClasses:    derived.expectErrorClasses,
+	}
+}
+
+// currentConfig returns the configuration of the application, including the
+// updates made using UpdateConfig.
+func (app *app) currentConfig() config {
+	app.RLock()
+	defer app.RUnlock()
+	if nil != app.reloadedConfig {
+		return *app.reloadedConfig
+	}
+	return app.config
+}
+
+// updateConfig applies the options to the configuration of the application.
+// The runs of the application are replaced by runs using the updated
+// configuration, so that the transactions started afterwards use it.
+func (app *app) updateConfig(opts []ConfigOption) error {
+	app.updateConfigLock.Lock()
+	defer app.updateConfigLock.Unlock()
+
+	current := app.currentConfig()
+	// The options may modify the slices of the configuration in place, as
+	// json.Unmarshal does, and so they are applied to a copy.
+	cfg := copyAttributeSettings(copyConfigReferenceFields(current.Config))
+	for _, opt := range opts {
+		if nil != opt {
+			opt(&cfg)
+			if nil != cfg.Error {
+				return cfg.Error
+			}
+		}
+	}
+	if err := checkReloadable(current.Config, cfg); err != nil {
+		return err
+	}
+	if nil == cfg.Logger {
+		cfg.Logger = current.Logger
+	}
+	updated := current
+	updated.Config = cfg
+
+	app.Lock()
+	app.reloadedConfig = &updated
+	app.placeholderRun = app.placeholderRun.withConfig(updated)
+	if nil != app.run {
+		app.run = app.run.withConfig(updated)
+	}
+	run := app.run
+	if nil == run {
+		run = app.placeholderRun
+	}
+	app.Unlock()
+
+	if lg, ok := app.Logger.(*reloadableLogger); ok {
+		lg.set(cfg.Logger)
+	}
+	app.Consume(run.Reply.RunID, loggingConfigUpdate{config: run.LoggingConfig()})
+	app.Info("application configuration updated", map[string]interface{}{
+		"app": cfg.AppName,
+	})
+	return nil
+}
+
+// ConfigFromJSON sets the fields of the Config present in a JSON document,
+// such as:
+//
+//	{
+//		"Attributes": {"Exclude": ["request.headers.*"]},
+//		"ApplicationLogging": {"Forwarding": {"Enabled": false}}
+//	}
+//
+// The other fields are left unchanged.  The names of the fields are the names
+// of the fields of Config.  Fields such as the Logger cannot be set using
+// JSON.  Config.Error is set if the document is invalid.
+func ConfigFromJSON(data []byte) ConfigOption {
+	return func(cfg *Config) {
+		if err := json.Unmarshal(data, cfg); err != nil {
+			cfg.Error = fmt.Errorf("invalid JSON configuration: %v", err)
+		}
+	}
+}
+
+// WatchConfigFile updates the configuration of the application using
+// Application.UpdateConfig whenever the content of the file at path changes,
+// checking the file every period.  The file is read once when the watch
+// starts.  parse converts the content of the file into a ConfigOption.  If
+// parse is nil, the file is a JSON document read using ConfigFromJSON:
+//
+//	stop := newrelic.WatchConfigFile(app, "/etc/newrelic/reload.json", 30*time.Second, nil)
+//	defer stop()
+//
+// Each change is applied to the configuration in effect, and so removing a
+// setting from the file does not revert it.  Errors reading the file or
+// updating the configuration are logged.  The returned function stops the
+// watch.
+func WatchConfigFile(app *Application, path string, period time.Duration, parse func(data []byte) ConfigOption) (stop func()) {
+	if app == nil || app.app == nil || period <= 0 {
+		return func() {}
+	}
+	if nil == parse {
+		parse = ConfigFromJSON
+	}
+	w := &configFileWatcher{app: app, path: path, parse: parse}
+	done := make(chan struct{})
+	w.check()
+	go func() {
+		t := time.NewTicker(period)
+		defer t.Stop()
+		for {
+			select {
+			case <-t.C:
+				w.check()
+			case <-done:
+				return
+			case <-app.app.shutdownStarted:
+				return
+			}
+		}
+	}()
+	var stopped int32
+	return func() {
+		if atomic.CompareAndSwapInt32(&stopped, 0, 1) {
+			close(done)
+		}
+	}
+}
+
+type configFileWatcher struct {
+	app   *Application
+	path  string
+	parse func([]byte) ConfigOption
+	last  []byte
+}
+
+// check updates the configuration if the content of the file has changed.
+func (w *configFileWatcher) check() {
+	data, err := os.ReadFile(w.path)
+	if err != nil {
+		w.app.app.Warn("unable to read configuration file", map[string]interface{}{
+			"file":  w.path,
+			"error": err.Error(),
+		})
+		return
+	}
+	if nil != w.last && bytes.Equal(data, w.last) {
+		return
+	}
+	w.last = data
+	if err := w.app.UpdateConfig(w.parse(data)); err != nil {
+		w.app.app.Error("unable to update configuration", map[string]interface{}{
+			"file":  w.path,
+			"error": err.Error(),
+		})
+	}
+}
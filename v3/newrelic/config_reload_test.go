@@ -0,0 +1,144 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package newrelic
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/newrelic/go-agent/v3/internal"
+)
+
+func TestUpdateConfigAttributes(t *testing.T) {
+	app := testApp(nil, func(cfg *Config) {
+		cfg.DistributedTracer.Enabled = false
+	}, t)
+	txn := app.StartTransaction("hello")
+	txn.AddAttribute("secret", "value")
+	txn.End()
+
+	if err := app.UpdateConfig(func(cfg *Config) {
+		cfg.Attributes.Exclude = append(cfg.Attributes.Exclude, "secret")
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if cfg, _ := app.Config(); len(cfg.Attributes.Exclude) != 1 {
+		t.Error("incorrect config:", cfg.Attributes)
+	}
+	txn = app.StartTransaction("hello")
+	txn.AddAttribute("secret", "value")
+	txn.End()
+	app.expectNoLoggedErrors(t)
+	app.ExpectTxnEvents(t, []internal.WantEvent{
+		{
+			Intrinsics:     map[string]interface{}{"name": "OtherTransaction/Go/hello"},
+			UserAttributes: map[string]interface{}{"secret": "value"},
+		},
+		{
+			Intrinsics:     map[string]interface{}{"name": "OtherTransaction/Go/hello"},
+			UserAttributes: map[string]interface{}{},
+		},
+	})
+}
+
+func TestUpdateConfigKeepsRunState(t *testing.T) {
+	app := testApp(nil, func(cfg *Config) {
+		cfg.TransactionNameCardinality.Enabled = true
+	}, t)
+	before, _ := app.app.getState()
+	if err := app.UpdateConfig(func(cfg *Config) {
+		cfg.Attributes.Exclude = append(cfg.Attributes.Exclude, "secret")
+	}); err != nil {
+		t.Fatal(err)
+	}
+	after, _ := app.app.getState()
+	if after == before {
+		t.Fatal("run not replaced")
+	}
+	if len(after.Config.Attributes.Exclude) != 1 || after.AttributeConfig == before.AttributeConfig {
+		t.Error("attribute settings not updated:", after.Config.Attributes)
+	}
+	if after.adaptiveSampler != before.adaptiveSampler || after.rulesCache != before.rulesCache ||
+		after.txnNameGuard != before.txnNameGuard || nil == after.txnNameGuard {
+		t.Error("run state not kept")
+	}
+	if app.app.updatedRun(before) != after {
+		t.Error("replacement run not found")
+	}
+}
+
+func TestUpdateConfigNotReloadable(t *testing.T) {
+	app := testApp(nil, nil, t)
+	if err := app.UpdateConfig(ConfigAppName("other app")); err != errConfigNotReloadable {
+		t.Error("incorrect error:", err)
+	}
+	if err := app.UpdateConfig(func(cfg *Config) { cfg.Attributes.Enabled = false }, ConfigLicense("invalid")); err != errConfigNotReloadable {
+		t.Error("incorrect error:", err)
+	}
+	cfg, _ := app.Config()
+	if cfg.AppName != "my app" || !cfg.Attributes.Enabled {
+		t.Errorf("config changed: %s %t", cfg.AppName, cfg.Attributes.Enabled)
+	}
+	if err := app.UpdateConfig(ConfigFromJSON([]byte("{"))); err == nil || !strings.Contains(err.Error(), "invalid JSON configuration") {
+		t.Error("incorrect error:", err)
+	}
+}
+
+func TestUpdateConfigLogger(t *testing.T) {
+	app := testApp(nil, nil, t)
+	var buf bytes.Buffer
+	if err := app.UpdateConfig(ConfigDebugLogger(&buf)); err != nil {
+		t.Fatal(err)
+	}
+	app.app.Debug("after update", nil)
+	if !strings.Contains(buf.String(), "after update") {
+		t.Error("message not logged to the updated logger:", buf.String())
+	}
+}
+
+func TestUpdateConfigLogForwarding(t *testing.T) {
+	app := newTestApp(sampleEverythingReplyFn, configTestAppLogFn)
+	if err := app.UpdateConfig(ConfigAppLogForwardingEnabled(false)); err != nil {
+		t.Fatal(err)
+	}
+	app.RecordLog(LogData{
+		Severity: "Debug",
+		Message:  "Test Message",
+	})
+	app.ExpectLogEvents(t, []internal.WantLog{})
+}
+
+func TestWatchConfigFile(t *testing.T) {
+	app := testApp(nil, nil, t)
+	path := filepath.Join(t.TempDir(), "reload.json")
+	if err := os.WriteFile(path, []byte(`{"Attributes": {"Exclude": ["one"]}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	stop := WatchConfigFile(app.Application, path, 10*time.Millisecond, nil)
+	defer stop()
+	if cfg, _ := app.Config(); len(cfg.Attributes.Exclude) != 1 || cfg.Attributes.Exclude[0] != "one" {
+		t.Fatal("file not read when the watch starts:", cfg.Attributes)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"Attributes": {"Exclude": ["two"]}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if cfg, _ := app.Config(); len(cfg.Attributes.Exclude) == 1 && cfg.Attributes.Exclude[0] == "two" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("configuration not updated")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	stop()
+	stop()
+	app.expectNoLoggedErrors(t)
+}
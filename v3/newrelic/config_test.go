@@ -5,6 +5,7 @@ package newrelic
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
@@ -139,6 +140,7 @@ func TestCopyConfigReferenceFieldsPresent(t *testing.T) {
 					"Enabled": true
 				}
 			},
+			"AgentHealth":{"DeliveryLocation":"file:///newrelic/apm/health","Enabled":false,"Frequency":5000000000},
 			"AppName":"my appname",
 			"ApplicationLogging": {
 				"Enabled": true,
@@ -157,18 +159,25 @@ func TestCopyConfigReferenceFieldsPresent(t *testing.T) {
 				}
 			},
 			"Attributes":{"Enabled":true,"Exclude":["2"],"Include":["1"]},
+			"BackgroundApdex":{"Enabled":false,"MessageThreshold":0,"Threshold":0},
 			"BrowserMonitoring":{
 				"Attributes":{"Enabled":false,"Exclude":["10"],"Include":["9"]},
 				"Enabled":true
 			},
 			"CodeLevelMetrics":{"Enabled":true,"IgnoredPrefix":"","IgnoredPrefixes":null,"PathPrefix":"","PathPrefixes":null,"RedactIgnoredPrefixes":true,"RedactPathPrefixes":true,"Scope":"all"},
+			"CollectorTLS":{"Config":null,"FIPSMode":false},
 			"CrossApplicationTracer":{"Enabled":false},
 			"CustomInsightsEvents":{
+				"AllowedEventTypes":null,
+				"DeniedEventTypes":null,
 				"Enabled":true,
+				"MaxSamplesPerEventType":null,
 				"MaxSamplesStored":%d
 			},
 			"DatastoreTracer":{
+				"ConnectionPoolMetrics":{"Enabled":false},
 				"DatabaseNameReporting":{"Enabled":true},
+				"ExplainPlan":{"Enabled":false,"Threshold":500000000},
 				"InstanceReporting":{"Enabled":true},
 				"QueryParameters":{"Enabled":true},
 				"RawQuery":{"Enabled":false},
@@ -177,17 +186,23 @@ func TestCopyConfigReferenceFieldsPresent(t *testing.T) {
 					"Threshold":10000000
 				}
 			},
-			"DistributedTracer":{"Enabled":true,"ExcludeNewRelicHeader":false,"ReservoirLimit":%d},
+			"DistributedTracer":{"AcceptAWSTraceHeader":false,"Enabled":true,"ExcludeNewRelicHeader":false,"ExcludedHosts":null,"ReservoirLimit":%d},
 			"Enabled":true,
 			"Error":null,
 			"ErrorCollector":{
 				"Attributes":{"Enabled":true,"Exclude":["6"],"Include":["5"]},
 				"CaptureEvents":true,
 				"Enabled":true,
+				"ExpectClasses":null,
 				"ExpectStatusCodes":[500],
 				"IgnoreStatusCodes":[0,5,404,405],
 				"RecordPanics":false
 			},
+			"Expvar":{"Enabled":false,"Names":null,"Prefixes":null},
+			"GoroutineBudget":{"Max":0},
+			"GoroutineTransactions":{"Enabled":false},
+			"HTTPClient":null,
+			"HarvestSpool":{"Directory":"","Enabled":false,"MaxBytes":67108864},
 			"Heroku":{
 				"DynoNamePrefixesToShorten":["scheduler","run"],
 				"UseDynoNames":true
@@ -195,6 +210,7 @@ func TestCopyConfigReferenceFieldsPresent(t *testing.T) {
 			"HighSecurity":false,
 			"Host":"",
 			"HostDisplayName":"",
+			"IgnoredTransactionNames":null,
 			"InfiniteTracing": {
 				"SpanEvents": {"QueueSize":10000},
 				"TraceObserver": {
@@ -202,11 +218,15 @@ func TestCopyConfigReferenceFieldsPresent(t *testing.T) {
 					"Port": 443
                 }
 			},
+			"KubernetesMetadata":{"ClusterName":"","Enabled":false},
 			"Labels":{"zip":"zap"},
 			"Logger":"*logger.logFile",
+			"MessagePayloads":{"Enabled":false,"MaxBytes":255},
 			"ModuleDependencyMetrics":{"Enabled":true,"IgnoredPrefixes":null,"RedactIgnoredPrefixes":true},
-			"RuntimeSampler":{"Enabled":true},
+			"RuntimeSampler":{"DetailedMetrics":false,"Enabled":true,"TransactionAttributes":false},
+			"SecondaryDestinations":null,
 			"SecurityPoliciesToken":"",
+			"SemanticConventions":{"HTTP":0,"RPC":false},
 			"ServerlessMode":{
 				"AccountID":"",
 				"ApdexThreshold":500000000,
@@ -215,16 +235,24 @@ func TestCopyConfigReferenceFieldsPresent(t *testing.T) {
 				"TrustedAccountKey":""
 			},
 			"SpanEvents":{
+				"Aggregation":{"Enabled":false,"KeyAttributes":null},
 				"Attributes":{
 					"Enabled":true,"Exclude":["12"],"Include":["11"]
 				},
-				"Enabled":true
+				"ContextErrors":false,
+				"Enabled":true,
+				"StackTraces":{"Enabled":false,"MaxFrames":5,"Threshold":500000000}
 			},
+			"StackTraces":{"MaxDepth":100,"SkipRuntimeFrames":false,"SkipVendorFrames":false,"TrimGOPATH":false},
+			"TelemetryPause":{"DropData":false},
+			"TenantAccounting":{"Enabled":false,"MaxTenants":100},
 			"TransactionEvents":{
 				"Attributes":{"Enabled":true,"Exclude":["4"],"Include":["3"]},
 				"Enabled":true,
 				"MaxSamplesStored": %d
 			},
+			"TransactionNameCardinality":{"Enabled":false,"MaxNames":2000,"MaxNamesPerPattern":100},
+			"TransactionNameRules":null,
 			"TransactionTracer":{
 				"Attributes":{"Enabled":true,"Exclude":["8"],"Include":["7"]},
 				"Enabled":true,
@@ -244,9 +272,11 @@ func TestCopyConfigReferenceFieldsPresent(t *testing.T) {
 				"DetectAWS":true,
 				"DetectAzure":true,
 				"DetectDocker":true,
+				"DetectECS":true,
 				"DetectGCP":true,
 				"DetectKubernetes":true,
 				"DetectPCF":true,
+				"DetectSystemd":true,
 				"LogicalProcessors":0,
 				"TotalRAMMIB":0
 			},
@@ -347,6 +377,7 @@ func TestCopyConfigReferenceFieldsAbsent(t *testing.T) {
 					"Enabled": true
 				}
 			},
+			"AgentHealth":{"DeliveryLocation":"file:///newrelic/apm/health","Enabled":false,"Frequency":5000000000},
 			"AppName":"my appname",
 			"ApplicationLogging": {
 				"Enabled": true,
@@ -365,6 +396,7 @@ func TestCopyConfigReferenceFieldsAbsent(t *testing.T) {
 				}
 			},
 			"Attributes":{"Enabled":true,"Exclude":null,"Include":null},
+			"BackgroundApdex":{"Enabled":false,"MessageThreshold":0,"Threshold":0},
 			"BrowserMonitoring":{
 				"Attributes":{
 					"Enabled":false,
@@ -374,13 +406,19 @@ func TestCopyConfigReferenceFieldsAbsent(t *testing.T) {
 				"Enabled":true
 			},
 			"CodeLevelMetrics":{"Enabled":true,"IgnoredPrefix":"","IgnoredPrefixes":null,"PathPrefix":"","PathPrefixes":null,"RedactIgnoredPrefixes":true,"RedactPathPrefixes":true,"Scope":"all"},
+			"CollectorTLS":{"Config":null,"FIPSMode":false},
 			"CrossApplicationTracer":{"Enabled":false},
 			"CustomInsightsEvents":{
+				"AllowedEventTypes":null,
+				"DeniedEventTypes":null,
 				"Enabled":true,
+				"MaxSamplesPerEventType":null,
 				"MaxSamplesStored":%d
 			},
 			"DatastoreTracer":{
+				"ConnectionPoolMetrics":{"Enabled":false},
 				"DatabaseNameReporting":{"Enabled":true},
+				"ExplainPlan":{"Enabled":false,"Threshold":500000000},
 				"InstanceReporting":{"Enabled":true},
 				"QueryParameters":{"Enabled":true},
 				"RawQuery":{"Enabled":false},
@@ -389,17 +427,23 @@ func TestCopyConfigReferenceFieldsAbsent(t *testing.T) {
 					"Threshold":10000000
 				}
 			},
-			"DistributedTracer":{"Enabled":true,"ExcludeNewRelicHeader":false,"ReservoirLimit":%d},
+			"DistributedTracer":{"AcceptAWSTraceHeader":false,"Enabled":true,"ExcludeNewRelicHeader":false,"ExcludedHosts":null,"ReservoirLimit":%d},
 			"Enabled":true,
 			"Error":null,
 			"ErrorCollector":{
 				"Attributes":{"Enabled":true,"Exclude":null,"Include":null},
 				"CaptureEvents":true,
 				"Enabled":true,
+				"ExpectClasses":null,
 				"ExpectStatusCodes":null,
 				"IgnoreStatusCodes":null,
 				"RecordPanics":false
 			},
+			"Expvar":{"Enabled":false,"Names":null,"Prefixes":null},
+			"GoroutineBudget":{"Max":0},
+			"GoroutineTransactions":{"Enabled":false},
+			"HTTPClient":null,
+			"HarvestSpool":{"Directory":"","Enabled":false,"MaxBytes":67108864},
 			"Heroku":{
 				"DynoNamePrefixesToShorten":["scheduler","run"],
 				"UseDynoNames":true
@@ -407,6 +451,7 @@ func TestCopyConfigReferenceFieldsAbsent(t *testing.T) {
 			"HighSecurity":false,
 			"Host":"",
 			"HostDisplayName":"",
+			"IgnoredTransactionNames":null,
 			"InfiniteTracing": {
 				"SpanEvents": {"QueueSize":10000},
 				"TraceObserver": {
@@ -414,11 +459,15 @@ func TestCopyConfigReferenceFieldsAbsent(t *testing.T) {
 					"Port": 443
                 }
 			},
+			"KubernetesMetadata":{"ClusterName":"","Enabled":false},
 			"Labels":null,
 			"Logger":null,
+			"MessagePayloads":{"Enabled":false,"MaxBytes":255},
 			"ModuleDependencyMetrics":{"Enabled":true,"IgnoredPrefixes":null,"RedactIgnoredPrefixes":true},
-			"RuntimeSampler":{"Enabled":true},
+			"RuntimeSampler":{"DetailedMetrics":false,"Enabled":true,"TransactionAttributes":false},
+			"SecondaryDestinations":null,
 			"SecurityPoliciesToken":"",
+			"SemanticConventions":{"HTTP":0,"RPC":false},
 			"ServerlessMode":{
 				"AccountID":"",
 				"ApdexThreshold":500000000,
@@ -427,14 +476,22 @@ func TestCopyConfigReferenceFieldsAbsent(t *testing.T) {
 				"TrustedAccountKey":""
 			},
 			"SpanEvents":{
+				"Aggregation":{"Enabled":false,"KeyAttributes":null},
 				"Attributes":{"Enabled":true,"Exclude":null,"Include":null},
-				"Enabled":true
+				"ContextErrors":false,
+				"Enabled":true,
+				"StackTraces":{"Enabled":false,"MaxFrames":5,"Threshold":500000000}
 			},
+			"StackTraces":{"MaxDepth":100,"SkipRuntimeFrames":false,"SkipVendorFrames":false,"TrimGOPATH":false},
+			"TelemetryPause":{"DropData":false},
+			"TenantAccounting":{"Enabled":false,"MaxTenants":100},
 			"TransactionEvents":{
 				"Attributes":{"Enabled":true,"Exclude":null,"Include":null},
 				"Enabled":true,
 				"MaxSamplesStored": %d
 			},
+			"TransactionNameCardinality":{"Enabled":false,"MaxNames":2000,"MaxNamesPerPattern":100},
+			"TransactionNameRules":null,
 			"TransactionTracer":{
 				"Attributes":{"Enabled":true,"Exclude":null,"Include":null},
 				"Enabled":true,
@@ -454,9 +511,11 @@ func TestCopyConfigReferenceFieldsAbsent(t *testing.T) {
 				"DetectAWS":true,
 				"DetectAzure":true,
 				"DetectDocker":true,
+				"DetectECS":true,
 				"DetectGCP":true,
 				"DetectKubernetes":true,
 				"DetectPCF":true,
+				"DetectSystemd":true,
 				"LogicalProcessors":0,
 				"TotalRAMMIB":0
 			},
@@ -952,3 +1011,63 @@ func TestCLMJsonUnmarshalling(t *testing.T) {
 		}
 	}
 }
+
+func TestValidateConfig(t *testing.T) {
+	if errs := ValidateConfig(ConfigAppName("app"), ConfigLicense(testLicenseKey)); errs != nil {
+		t.Error("errors for a valid config:", errs)
+	}
+	errs := ValidateConfig(
+		ConfigLicense("short"),
+		func(cfg *Config) { cfg.Error = errors.New("option error") },
+		func(cfg *Config) {
+			cfg.HighSecurity = true
+			cfg.SecurityPoliciesToken = "token"
+			cfg.IgnoredTransactionNames = []string{"/(/"}
+		},
+	)
+	if len(errs) != 5 {
+		t.Fatal("incorrect errors:", errs)
+	}
+	for i, want := range []error{errors.New("option error"), errLicenseLen, errAppNameMissing, errHighSecurityWithSecurityPolicies} {
+		if errs[i].Error() != want.Error() {
+			t.Errorf("incorrect error %d: %v, want %v", i, errs[i], want)
+		}
+	}
+	if !strings.Contains(errs[4].Error(), "(") {
+		t.Error("incorrect pattern error:", errs[4])
+	}
+}
+
+func TestConfigJSON(t *testing.T) {
+	app := testApp(nil, func(cfg *Config) {
+		cfg.SecurityPoliciesToken = "token"
+	}, t)
+	for _, redact := range []bool{true, false} {
+		js, err := app.ConfigJSON(redact)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var fields map[string]interface{}
+		if err := json.Unmarshal(js, &fields); err != nil {
+			t.Fatal(err)
+		}
+		wantLicense, wantToken := testLicenseKey, "token"
+		if redact {
+			wantLicense, wantToken = "[redacted]", "[redacted]"
+		}
+		if fields["License"] != wantLicense || fields["SecurityPoliciesToken"] != wantToken {
+			t.Errorf("incorrect secrets with redact=%t: %v %v", redact, fields["License"], fields["SecurityPoliciesToken"])
+		}
+		if fields["AppName"] != "my app" {
+			t.Error("incorrect AppName:", fields["AppName"])
+		}
+		if redact && strings.Contains(string(js), testLicenseKey) {
+			t.Error("license in redacted config:", string(js))
+		}
+	}
+
+	var nilApp *Application
+	if js, err := nilApp.ConfigJSON(true); err != nil || !strings.Contains(string(js), `"License": ""`) {
+		t.Error("incorrect config of nil application:", string(js), err)
+	}
+}
@@ -0,0 +1,433 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package newrelic
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// parseYAML parses the subset of YAML used by configuration files into
+// map[string]interface{}, []interface{}, yamlScalar, and nil values.  Block mappings and sequences, flow
+// mappings and sequences written on a single line, quoted and plain scalars,
+// and comments are supported.  Block scalars, anchors, aliases, tags, and
+// multiple documents are not.
+func parseYAML(data string) (interface{}, error) {
+	var lines []yamlLine
+	for i, text := range strings.Split(strings.ReplaceAll(data, "\r\n", "\n"), "\n") {
+		content := strings.TrimRight(stripYAMLComment(text), " \t")
+		trimmed := strings.TrimLeft(content, " ")
+		if trimmed == "" {
+			continue
+		}
+		if strings.HasPrefix(trimmed, "\t") {
+			return nil, fmt.Errorf("line %d: tabs cannot be used for indentation", i+1)
+		}
+		if trimmed == "---" || trimmed == "..." {
+			if len(lines) > 0 && trimmed == "---" {
+				return nil, fmt.Errorf("line %d: multiple documents are not supported", i+1)
+			}
+			continue
+		}
+		lines = append(lines, yamlLine{
+			number: i + 1,
+			indent: len(content) - len(trimmed),
+			text:   trimmed,
+		})
+	}
+	if len(lines) == 0 {
+		return nil, nil
+	}
+	p := &yamlParser{lines: lines}
+	v, err := p.parseNode(lines[0].indent)
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.lines) {
+		return nil, p.errorf("unexpected indentation")
+	}
+	return v, nil
+}
+
+type yamlLine struct {
+	number int
+	indent int
+	text   string
+}
+
+type yamlParser struct {
+	lines []yamlLine
+	pos   int
+}
+
+func (p *yamlParser) errorf(format string, args ...interface{}) error {
+	line := p.lines[len(p.lines)-1].number
+	if p.pos < len(p.lines) {
+		line = p.lines[p.pos].number
+	}
+	return fmt.Errorf("line %d: %s", line, fmt.Sprintf(format, args...))
+}
+
+func isYAMLSequenceItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+// parseNode parses the mapping or sequence starting at the current line,
+// whose lines are indented by indent spaces.
+func (p *yamlParser) parseNode(indent int) (interface{}, error) {
+	if isYAMLSequenceItem(p.lines[p.pos].text) {
+		return p.parseSequence(indent)
+	}
+	if _, _, ok := splitYAMLKey(p.lines[p.pos].text); !ok {
+		if p.pos == len(p.lines)-1 || p.lines[p.pos+1].indent < indent {
+			p.pos++
+			return p.parseValue(p.lines[p.pos-1], p.lines[p.pos-1].text, false)
+		}
+		return nil, p.errorf("expected a mapping key")
+	}
+	return p.parseMapping(indent)
+}
+
+func (p *yamlParser) parseMapping(indent int) (interface{}, error) {
+	m := make(map[string]interface{})
+	for p.pos < len(p.lines) && p.lines[p.pos].indent == indent {
+		line := p.lines[p.pos]
+		if isYAMLSequenceItem(line.text) {
+			return nil, p.errorf("unexpected sequence item")
+		}
+		key, value, ok := splitYAMLKey(line.text)
+		if !ok {
+			return nil, p.errorf("expected a mapping key")
+		}
+		if _, dup := m[key]; dup {
+			return nil, p.errorf("duplicate key %q", key)
+		}
+		p.pos++
+		v, err := p.parseValue(line, value, true)
+		if err != nil {
+			return nil, err
+		}
+		m[key] = v
+	}
+	if p.pos < len(p.lines) && p.lines[p.pos].indent > indent {
+		return nil, p.errorf("unexpected indentation")
+	}
+	return m, nil
+}
+
+func (p *yamlParser) parseSequence(indent int) (interface{}, error) {
+	s := []interface{}{}
+	for p.pos < len(p.lines) && p.lines[p.pos].indent == indent && isYAMLSequenceItem(p.lines[p.pos].text) {
+		line := p.lines[p.pos]
+		rest := strings.TrimLeft(strings.TrimPrefix(line.text, "-"), " ")
+		if _, _, ok := splitYAMLKey(rest); ok || isYAMLSequenceItem(rest) {
+			// A mapping or sequence starting on the line of the item
+			// continues on the lines indented like its first entry.
+			p.lines[p.pos].indent = indent + len(line.text) - len(rest)
+			p.lines[p.pos].text = rest
+			v, err := p.parseNode(p.lines[p.pos].indent)
+			if err != nil {
+				return nil, err
+			}
+			s = append(s, v)
+			continue
+		}
+		p.pos++
+		v, err := p.parseValue(line, rest, false)
+		if err != nil {
+			return nil, err
+		}
+		s = append(s, v)
+	}
+	if p.pos < len(p.lines) && p.lines[p.pos].indent > indent {
+		return nil, p.errorf("unexpected indentation")
+	}
+	return s, nil
+}
+
+// parseValue parses the value of a mapping key or sequence item: either the
+// scalar or flow collection written on its line, or the node on the
+// following lines.  A sequence which is the value of a mapping key may be
+// indented like the key.
+func (p *yamlParser) parseValue(line yamlLine, value string, sameIndentSequence bool) (interface{}, error) {
+	if value != "" {
+		v, err := parseYAMLValue(value)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line.number, err)
+		}
+		return v, nil
+	}
+	indent := line.indent
+	if p.pos >= len(p.lines) {
+		return nil, nil
+	}
+	next := p.lines[p.pos]
+	if next.indent > indent || (sameIndentSequence && next.indent == indent && isYAMLSequenceItem(next.text)) {
+		return p.parseNode(next.indent)
+	}
+	return nil, nil
+}
+
+// splitYAMLKey splits a "key: value" line.
+func splitYAMLKey(text string) (key, value string, ok bool) {
+	if strings.HasPrefix(text, `"`) || strings.HasPrefix(text, "'") {
+		end := quotedYAMLEnd(text)
+		if end < 0 || end+1 >= len(text) || text[end+1] != ':' {
+			return "", "", false
+		}
+		rest := text[end+2:]
+		if rest != "" && rest[0] != ' ' {
+			return "", "", false
+		}
+		k, err := parseYAMLScalar(text[:end+1])
+		if err != nil {
+			return "", "", false
+		}
+		return k.text, strings.TrimSpace(rest), true
+	}
+	if strings.HasPrefix(text, "{") || strings.HasPrefix(text, "[") {
+		return "", "", false
+	}
+	for i := 0; i < len(text); i++ {
+		if text[i] == ':' && (i+1 == len(text) || text[i+1] == ' ') {
+			return strings.TrimSpace(text[:i]), strings.TrimSpace(text[i+1:]), true
+		}
+	}
+	return "", "", false
+}
+
+// quotedYAMLEnd returns the index of the quote closing the string starting
+// text, or -1.
+func quotedYAMLEnd(text string) int {
+	quote := text[0]
+	for i := 1; i < len(text); i++ {
+		switch {
+		case quote == '"' && text[i] == '\\':
+			i++
+		case quote == '\'' && text[i] == '\'' && i+1 < len(text) && text[i+1] == '\'':
+			i++
+		case text[i] == quote:
+			return i
+		}
+	}
+	return -1
+}
+
+// stripYAMLComment removes the comment at the end of the line, if any.
+func stripYAMLComment(text string) string {
+	var quote byte
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case quote == '"' && c == '\\':
+			i++
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			if i == 0 || strings.ContainsRune(" \t:[{,-", rune(text[i-1])) {
+				quote = c
+			}
+		case c == '#' && (i == 0 || text[i-1] == ' ' || text[i-1] == '\t'):
+			return text[:i]
+		}
+	}
+	return text
+}
+
+func parseYAMLValue(text string) (interface{}, error) {
+	switch text[0] {
+	case '|', '>':
+		return nil, fmt.Errorf("block scalars are not supported")
+	case '&', '*', '!':
+		return nil, fmt.Errorf("anchors, aliases, and tags are not supported")
+	case '[', '{':
+		f := &yamlFlowParser{text: text}
+		v, err := f.parse()
+		if err != nil {
+			return nil, err
+		}
+		f.skipSpaces()
+		if f.pos < len(f.text) {
+			return nil, fmt.Errorf("unexpected %q after flow collection", f.text[f.pos:])
+		}
+		return v, nil
+	}
+	return yamlScalarValue(text)
+}
+
+// yamlNumberPattern matches the numbers which are also valid JSON numbers.
+var yamlNumberPattern = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][-+]?[0-9]+)?$`)
+
+// yamlScalar is a scalar of a YAML document.  Its type is resolved once the
+// type of the field it sets is known, so that a plain scalar such as 123 may
+// set a string.
+type yamlScalar struct {
+	text   string
+	quoted bool
+}
+
+// resolve returns the value of the scalar using the YAML core schema.
+func (s yamlScalar) resolve() interface{} {
+	if s.quoted {
+		return s.text
+	}
+	switch s.text {
+	case "~", "null", "Null", "NULL":
+		return nil
+	case "true", "True", "TRUE":
+		return true
+	case "false", "False", "FALSE":
+		return false
+	}
+	if yamlNumberPattern.MatchString(s.text) {
+		return json.Number(s.text)
+	}
+	return s.text
+}
+
+// yamlScalarValue parses a scalar as a value of the document.
+func yamlScalarValue(text string) (interface{}, error) {
+	s, err := parseYAMLScalar(text)
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func parseYAMLScalar(text string) (yamlScalar, error) {
+	switch text[0] {
+	case '"':
+		if quotedYAMLEnd(text) != len(text)-1 {
+			return yamlScalar{}, fmt.Errorf("invalid quoted string %s", text)
+		}
+		s, err := strconv.Unquote(text)
+		if err != nil {
+			return yamlScalar{}, fmt.Errorf("invalid quoted string %s", text)
+		}
+		return yamlScalar{text: s, quoted: true}, nil
+	case '\'':
+		if quotedYAMLEnd(text) != len(text)-1 {
+			return yamlScalar{}, fmt.Errorf("invalid quoted string %s", text)
+		}
+		return yamlScalar{text: strings.ReplaceAll(text[1:len(text)-1], "''", "'"), quoted: true}, nil
+	}
+	return yamlScalar{text: text}, nil
+}
+
+// yamlFlowParser parses a flow collection, such as [a, b] or {a: 1}.
+type yamlFlowParser struct {
+	text string
+	pos  int
+}
+
+func (f *yamlFlowParser) skipSpaces() {
+	for f.pos < len(f.text) && f.text[f.pos] == ' ' {
+		f.pos++
+	}
+}
+
+func (f *yamlFlowParser) parse() (interface{}, error) {
+	f.skipSpaces()
+	if f.pos >= len(f.text) {
+		return nil, fmt.Errorf("unterminated flow collection")
+	}
+	switch f.text[f.pos] {
+	case '[':
+		f.pos++
+		s := []interface{}{}
+		for {
+			f.skipSpaces()
+			if f.pos < len(f.text) && f.text[f.pos] == ']' {
+				f.pos++
+				return s, nil
+			}
+			v, err := f.parse()
+			if err != nil {
+				return nil, err
+			}
+			s = append(s, v)
+			if err := f.separator(']'); err != nil {
+				return nil, err
+			}
+		}
+	case '{':
+		f.pos++
+		m := make(map[string]interface{})
+		for {
+			f.skipSpaces()
+			if f.pos < len(f.text) && f.text[f.pos] == '}' {
+				f.pos++
+				return m, nil
+			}
+			k, err := f.parse()
+			if err != nil {
+				return nil, err
+			}
+			key, ok := k.(yamlScalar)
+			if !ok {
+				return nil, fmt.Errorf("flow collections cannot be used as keys")
+			}
+			f.skipSpaces()
+			if f.pos >= len(f.text) || f.text[f.pos] != ':' {
+				return nil, fmt.Errorf("expected ':' after key %q", key.text)
+			}
+			f.pos++
+			v, err := f.parse()
+			if err != nil {
+				return nil, err
+			}
+			m[key.text] = v
+			if err := f.separator('}'); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return f.scalar()
+}
+
+// separator consumes the comma following an entry of a flow collection, or
+// leaves the closing character to be consumed.
+func (f *yamlFlowParser) separator(closing byte) error {
+	f.skipSpaces()
+	if f.pos >= len(f.text) {
+		return fmt.Errorf("unterminated flow collection")
+	}
+	switch f.text[f.pos] {
+	case ',':
+		f.pos++
+		return nil
+	case closing:
+		return nil
+	}
+	return fmt.Errorf("unexpected %q in flow collection", f.text[f.pos])
+}
+
+func (f *yamlFlowParser) scalar() (interface{}, error) {
+	start := f.pos
+	if c := f.text[f.pos]; c == '"' || c == '\'' {
+		end := quotedYAMLEnd(f.text[start:])
+		if end < 0 {
+			return nil, fmt.Errorf("unterminated quoted string")
+		}
+		f.pos = start + end + 1
+		return yamlScalarValue(f.text[start:f.pos])
+	}
+	for f.pos < len(f.text) {
+		c := f.text[f.pos]
+		if c == ',' || c == ']' || c == '}' || (c == ':' && (f.pos+1 == len(f.text) || f.text[f.pos+1] == ' ')) {
+			break
+		}
+		f.pos++
+	}
+	text := strings.TrimSpace(f.text[start:f.pos])
+	if text == "" {
+		return nil, fmt.Errorf("empty entry in flow collection")
+	}
+	return yamlScalarValue(text)
+}
@@ -0,0 +1,78 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package newrelic
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// resolveYAML replaces the scalars of a parsed document by their values.
+func resolveYAML(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, val := range v {
+			v[key] = resolveYAML(val)
+		}
+	case []interface{}:
+		for i, val := range v {
+			v[i] = resolveYAML(val)
+		}
+	case yamlScalar:
+		return v.resolve()
+	}
+	return v
+}
+
+func TestParseYAML(t *testing.T) {
+	for _, tc := range []struct {
+		input string
+		want  string
+	}{
+		{input: "", want: `null`},
+		{input: "---\na: 1\n...", want: `{"a":1}`},
+		{input: "a: b c # comment\nd: 'it''s'\ne: \"x\\ty\"", want: `{"a":"b c","d":"it's","e":"x\ty"}`},
+		{input: "a: [1, two, 'three, four', {b: true}]\nc: {}", want: `{"a":[1,"two","three, four",{"b":true}],"c":{}}`},
+		{input: "a:\n- 1\n- - 2\n  - 3\nb: ~", want: `{"a":[1,[2,3]],"b":null}`},
+		{input: "a:\n  - b: 1\n    c: 2\n  - d\n", want: `{"a":[{"b":1,"c":2},"d"]}`},
+		{input: "a:\n  b:\n    c: null\n  d: 1.5e3\ne: 0755\nf: http://host:80/path#x", want: `{"a":{"b":{"c":null},"d":1.5e3},"e":"0755","f":"http://host:80/path#x"}`},
+		{input: "\"a: b\": c\n'#': d", want: `{"#":"d","a: b":"c"}`},
+		{input: "- a\n- b", want: `["a","b"]`},
+	} {
+		v, err := parseYAML(tc.input)
+		if err != nil {
+			t.Errorf("unable to parse %q: %v", tc.input, err)
+			continue
+		}
+		js, err := json.Marshal(resolveYAML(v))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(js) != tc.want {
+			t.Errorf("incorrect value for %q: %s, want %s", tc.input, js, tc.want)
+		}
+	}
+}
+
+func TestParseYAMLErrors(t *testing.T) {
+	for _, tc := range []struct {
+		input string
+		want  string
+	}{
+		{input: "a: 1\n\tb: 2", want: "line 2: tabs cannot be used for indentation"},
+		{input: "a: 1\na: 2", want: `line 2: duplicate key "a"`},
+		{input: "a: 1\n- b", want: "line 2: unexpected sequence item"},
+		{input: "a:\n  - b\n  c: 1", want: "line 3: unexpected indentation"},
+		{input: "a: *ref", want: "line 1: anchors, aliases, and tags are not supported"},
+		{input: "a: [1, 2", want: "line 1: unterminated flow collection"},
+		{input: "a: [1] x", want: `line 1: unexpected "x" after flow collection`},
+		{input: `a: "b`, want: "line 1: invalid quoted string"},
+		{input: "a: 1\n---\nb: 2", want: "line 2: multiple documents are not supported"},
+	} {
+		if _, err := parseYAML(tc.input); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("incorrect error for %q: %v, want %s", tc.input, err, tc.want)
+		}
+	}
+}
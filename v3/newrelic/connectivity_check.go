@@ -0,0 +1,243 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package newrelic
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// The steps of a connectivity check, in the order they are run.
+const (
+	// ConnectivityStepConfig validates the configuration.
+	ConnectivityStepConfig = "config"
+	// ConnectivityStepDNS resolves the preconnect host.
+	ConnectivityStepDNS = "dns"
+	// ConnectivityStepTLS performs a TLS handshake with the preconnect host.
+	ConnectivityStepTLS = "tls"
+	// ConnectivityStepPreconnect requests the collector of the application
+	// from the preconnect host.
+	ConnectivityStepPreconnect = "preconnect"
+	// ConnectivityStepConnect connects the application to the collector.
+	ConnectivityStepConnect = "connect"
+	// ConnectivityStepHarvest sends a metric harvest to the collector.
+	ConnectivityStepHarvest = "harvest"
+)
+
+// connectivityCheckMetric is the metric sent by the test harvest.
+const connectivityCheckMetric = "Supportability/Go/ConnectivityCheck"
+
+// ConnectivityStep is the result of a step of a connectivity check.
+type ConnectivityStep struct {
+	// Name is one of the ConnectivityStep constants.
+	Name string `json:"name"`
+	// Endpoint is the host contacted by the step, if any.
+	Endpoint string `json:"endpoint,omitempty"`
+	// Duration is the time taken by the step.
+	Duration time.Duration `json:"duration"`
+	// Skipped is true if the step was not run.  The DNS and TLS steps are
+	// skipped when a Config.Transport, a Config.HTTPClient, or a proxy is
+	// used, since the connections are then made by the transport or the
+	// proxy.
+	Skipped bool `json:"skipped,omitempty"`
+	// Error describes the failure of the step, and is empty if the step
+	// succeeded.
+	Error string `json:"error,omitempty"`
+}
+
+// ConnectivityReport is the result of RunConnectivityCheck.  It is suitable
+// for encoding as JSON.
+type ConnectivityReport struct {
+	// Steps are the steps run, in order.  The check stops at the first
+	// failed step.
+	Steps []ConnectivityStep `json:"steps"`
+}
+
+// Passed returns true if every step of the check succeeded or was skipped.
+func (r *ConnectivityReport) Passed() bool {
+	return r.Err() == nil
+}
+
+// Err returns the failure of the check, or nil if the check passed.
+func (r *ConnectivityReport) Err() error {
+	if nil == r {
+		return errors.New("connectivity check not run")
+	}
+	for _, s := range r.Steps {
+		if s.Error != "" {
+			return fmt.Errorf("%s: %s", s.Name, s.Error)
+		}
+	}
+	return nil
+}
+
+// String returns a summary of the report with one line per step.
+func (r *ConnectivityReport) String() string {
+	if nil == r {
+		return ""
+	}
+	var b strings.Builder
+	for _, s := range r.Steps {
+		status := "ok"
+		if s.Skipped {
+			status = "skipped"
+		} else if s.Error != "" {
+			status = "failed: " + s.Error
+		}
+		fmt.Fprintf(&b, "%-10s %-40s %8s %s\n", s.Name, s.Endpoint, s.Duration.Round(time.Millisecond), status)
+	}
+	return b.String()
+}
+
+func (r *ConnectivityReport) record(name, endpoint string, start time.Time, err error) bool {
+	s := ConnectivityStep{
+		Name:     name,
+		Endpoint: endpoint,
+		Duration: time.Since(start),
+	}
+	if nil != err {
+		s.Error = err.Error()
+	}
+	r.Steps = append(r.Steps, s)
+	return nil == err
+}
+
+func (r *ConnectivityReport) skip(name, endpoint string) {
+	r.Steps = append(r.Steps, ConnectivityStep{
+		Name:     name,
+		Endpoint: endpoint,
+		Skipped:  true,
+	})
+}
+
+// RunConnectivityCheck checks that an application created with the same
+// options is able to report data to New Relic.  It resolves and performs a TLS
+// handshake with the preconnect host, connects the application, and sends a
+// harvest containing a single supportability metric.  It returns once every
+// step has succeeded or a step has failed, and is intended for operational
+// tooling and for preflight checks at startup:
+//
+//	report := newrelic.RunConnectivityCheck(
+//		newrelic.ConfigAppName("Example App"),
+//		newrelic.ConfigLicense(os.Getenv("NEW_RELIC_LICENSE_KEY")),
+//	)
+//	if err := report.Err(); err != nil {
+//		log.Fatalf("unable to reach New Relic: %v\n%s", err, report)
+//	}
+//
+// The check connects an application with the configured name, which appears in
+// New Relic like any other application.  Each step is subject to the timeout of
+// the collector requests of the agent, which is 20 seconds.
+func RunConnectivityCheck(opts ...ConfigOption) *ConnectivityReport {
+	report := &ConnectivityReport{}
+
+	start := time.Now()
+	c := defaultConfig()
+	for _, fn := range opts {
+		if fn != nil {
+			fn(&c)
+			if c.Error != nil {
+				report.record(ConnectivityStepConfig, "", start, c.Error)
+				return report
+			}
+		}
+	}
+	cfg, err := newInternalConfig(c, os.Getenv, os.Environ())
+	if !report.record(ConnectivityStepConfig, "", start, err) {
+		return report
+	}
+
+	host := cfg.preconnectHost()
+	if usesDirectConnection(cfg, host) {
+		if !report.checkDNS(host) || !report.checkTLS(host) {
+			return report
+		}
+	} else {
+		report.skip(ConnectivityStepDNS, host)
+		report.skip(ConnectivityStepTLS, host)
+	}
+
+	cs := newRPMControls(cfg)
+
+	start = time.Now()
+	preconnect, resp := preconnectAttempt(cfg, cs)
+	if !report.record(ConnectivityStepPreconnect, host, start, resp.GetError()) {
+		return report
+	}
+
+	start = time.Now()
+	reply, resp := connectToCollector(cfg, cs, preconnect)
+	if !report.record(ConnectivityStepConnect, preconnect.Collector, start, resp.GetError()) {
+		return report
+	}
+
+	start = time.Now()
+	mt := newMetricTable(maxMetrics, start)
+	mt.addSingleCount(connectivityCheckMetric, forced)
+	data, err := mt.Data(reply.RunID.String(), start)
+	if nil == err {
+		resp = collectorRequest(rpmCmd{
+			Name:              mt.EndpointMethod(),
+			Collector:         reply.Collector,
+			RunID:             reply.RunID.String(),
+			Data:              data,
+			RequestHeadersMap: reply.RequestHeadersMap,
+			MaxPayloadSize:    reply.MaxPayloadSizeInBytes,
+		}, cs)
+		err = resp.GetError()
+	}
+	report.record(ConnectivityStepHarvest, reply.Collector, start, err)
+	return report
+}
+
+// usesDirectConnection returns true if the agent connects to the host
+// directly, rather than through a custom transport or a proxy.
+func usesDirectConnection(cfg config, host string) bool {
+	if nil != cfg.Transport || nil != cfg.HTTPClient {
+		return false
+	}
+	req, err := http.NewRequest("POST", "https://"+host, nil)
+	if nil != err {
+		return false
+	}
+	proxy, err := http.ProxyFromEnvironment(req)
+	return nil == err && nil == proxy
+}
+
+// hostAndPort splits the preconnect host, which may include a port.
+func hostAndPort(host string) (string, string) {
+	if h, port, err := net.SplitHostPort(host); nil == err {
+		return h, port
+	}
+	return host, "443"
+}
+
+func (r *ConnectivityReport) checkDNS(host string) bool {
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), collectorTimeout)
+	defer cancel()
+	name, _ := hostAndPort(host)
+	_, err := net.DefaultResolver.LookupHost(ctx, name)
+	return r.record(ConnectivityStepDNS, host, start, err)
+}
+
+func (r *ConnectivityReport) checkTLS(host string) bool {
+	start := time.Now()
+	name, port := hostAndPort(host)
+	dialer := &net.Dialer{Timeout: collectorTimeout}
+	conn, err := tls.DialWithDialer(dialer, "tcp", net.JoinHostPort(name, port), &tls.Config{
+		ServerName: name,
+	})
+	if nil == err {
+		conn.Close()
+	}
+	return r.record(ConnectivityStepTLS, host, start, err)
+}
@@ -0,0 +1,164 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package newrelic
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+type connectivityMock struct {
+	harvest  endpointResult
+	commands []string
+	metrics  string
+}
+
+func (m *connectivityMock) RoundTrip(r *http.Request) (*http.Response, error) {
+	cmd := r.URL.Query().Get("method")
+	m.commands = append(m.commands, cmd)
+	switch cmd {
+	case cmdPreconnect:
+		return makeResponse(200, redirectBody), nil
+	case cmdConnect:
+		return makeResponse(200, connectBody), nil
+	case cmdMetrics:
+		if gz, err := gzip.NewReader(r.Body); err == nil {
+			b, _ := io.ReadAll(gz)
+			m.metrics = string(b)
+		}
+		return m.harvest.response, m.harvest.err
+	default:
+		return nil, errors.New("unknown cmd: " + cmd)
+	}
+}
+
+func connectivityCheckConfig(transport http.RoundTripper) ConfigOption {
+	return func(cfg *Config) {
+		cfg.AppName = "my app"
+		cfg.License = testLicenseKey
+		cfg.Transport = transport
+		cfg.Utilization.DetectAWS = false
+		cfg.Utilization.DetectAzure = false
+		cfg.Utilization.DetectDocker = false
+		cfg.Utilization.DetectECS = false
+		cfg.Utilization.DetectGCP = false
+		cfg.Utilization.DetectKubernetes = false
+		cfg.Utilization.DetectPCF = false
+		cfg.Utilization.DetectSystemd = false
+	}
+}
+
+func checkStepNames(t *testing.T, report *ConnectivityReport, names ...string) {
+	t.Helper()
+	if len(report.Steps) != len(names) {
+		t.Fatalf("expected steps %v, got %+v", names, report.Steps)
+	}
+	for i, s := range report.Steps {
+		if s.Name != names[i] {
+			t.Errorf("expected step %d to be %s, got %s", i, names[i], s.Name)
+		}
+	}
+}
+
+func TestConnectivityCheckSuccess(t *testing.T) {
+	mock := &connectivityMock{
+		harvest: endpointResult{response: makeResponse(202, "{}")},
+	}
+	report := RunConnectivityCheck(connectivityCheckConfig(mock))
+	if err := report.Err(); err != nil {
+		t.Fatal(err, report)
+	}
+	if !report.Passed() {
+		t.Error("check not passed")
+	}
+	checkStepNames(t, report,
+		ConnectivityStepConfig,
+		ConnectivityStepDNS,
+		ConnectivityStepTLS,
+		ConnectivityStepPreconnect,
+		ConnectivityStepConnect,
+		ConnectivityStepHarvest,
+	)
+	// The transport makes the connections.
+	if !report.Steps[1].Skipped || !report.Steps[2].Skipped {
+		t.Error("dns and tls steps not skipped", report)
+	}
+	if s := report.Steps[4]; s.Endpoint != "special_collector" {
+		t.Error(s.Endpoint)
+	}
+	if got := strings.Join(mock.commands, ","); got != "preconnect,connect,metric_data" {
+		t.Error(got)
+	}
+	if !strings.Contains(mock.metrics, `"my_agent_run_id"`) || !strings.Contains(mock.metrics, connectivityCheckMetric) {
+		t.Error(mock.metrics)
+	}
+}
+
+func TestConnectivityCheckHarvestFailure(t *testing.T) {
+	mock := &connectivityMock{
+		harvest: endpointResult{response: makeResponse(413, "")},
+	}
+	report := RunConnectivityCheck(connectivityCheckConfig(mock))
+	if report.Passed() {
+		t.Fatal("check passed", report)
+	}
+	if err := report.Err(); err == nil || !strings.HasPrefix(err.Error(), "harvest: ") {
+		t.Error(err)
+	}
+	if s := report.Steps[len(report.Steps)-1]; s.Endpoint != "special_collector" {
+		t.Error(s.Endpoint)
+	}
+}
+
+func TestConnectivityCheckStopsAtFailure(t *testing.T) {
+	mock := &connectivityMock{}
+	report := RunConnectivityCheck(connectivityCheckConfig(mock), func(cfg *Config) {
+		cfg.Transport = connectMock{
+			redirect: endpointResult{err: errors.New("connection refused")},
+		}
+	})
+	checkStepNames(t, report,
+		ConnectivityStepConfig,
+		ConnectivityStepDNS,
+		ConnectivityStepTLS,
+		ConnectivityStepPreconnect,
+	)
+	if err := report.Err(); err == nil || !strings.Contains(err.Error(), "connection refused") {
+		t.Error(err)
+	}
+}
+
+func TestConnectivityCheckInvalidConfig(t *testing.T) {
+	report := RunConnectivityCheck(ConfigAppName("my app"), ConfigLicense("invalid"))
+	checkStepNames(t, report, ConnectivityStepConfig)
+	if err := report.Err(); err == nil || !strings.Contains(err.Error(), errLicenseLen.Error()) {
+		t.Error(err)
+	}
+
+	var nilReport *ConnectivityReport
+	if nilReport.Passed() || nilReport.String() != "" {
+		t.Error("nil report passed")
+	}
+}
+
+func TestConnectivityReportString(t *testing.T) {
+	report := &ConnectivityReport{Steps: []ConnectivityStep{
+		{Name: ConnectivityStepConfig},
+		{Name: ConnectivityStepDNS, Endpoint: "collector.newrelic.com", Skipped: true},
+		{Name: ConnectivityStepPreconnect, Endpoint: "collector.newrelic.com", Error: "timeout"},
+	}}
+	s := report.String()
+	for _, want := range []string{"config", "skipped", "failed: timeout"} {
+		if !strings.Contains(s, want) {
+			t.Errorf("%q missing from %q", want, s)
+		}
+	}
+	if n := strings.Count(s, "\n"); n != 3 {
+		t.Error(s)
+	}
+}
@@ -52,3 +52,52 @@ func transactionFromRequestContext(req *http.Request) *Transaction {
 	}
 	return txn
 }
+
+type suppressOutboundHeadersKey struct{}
+
+// WithoutOutboundHeaders returns a new context.Context which prevents
+// StartExternalSegment and NewRoundTripper from adding distributed tracing
+// and cross application tracing headers to requests made using it.  Use it
+// for requests to destinations which should not receive these headers:
+//
+//	req = req.WithContext(newrelic.WithoutOutboundHeaders(req.Context()))
+//	resp, err := client.Do(req)
+//
+// The external segment is still recorded.  To suppress headers for every
+// request to a host, use the DistributedTracer.ExcludedHosts setting instead.
+func WithoutOutboundHeaders(ctx context.Context) context.Context {
+	return context.WithValue(ctx, suppressOutboundHeadersKey{}, true)
+}
+
+func outboundHeadersSuppressed(req *http.Request) bool {
+	if nil == req {
+		return false
+	}
+	suppressed, _ := req.Context().Value(suppressOutboundHeadersKey{}).(bool)
+	return suppressed
+}
+
+type queryParametersKey struct{}
+
+// WithQueryParameters returns a new context.Context which adds params to the
+// QueryParameters of the datastore segments created by drivers instrumented
+// using InstrumentSQLDriver or InstrumentSQLConnector, for calls made using
+// it.  Use it to record the parameters of a query which are not available to
+// the driver, such as the names of named parameters:
+//
+//	ctx = newrelic.WithQueryParameters(ctx, map[string]interface{}{"id": id})
+//	row := db.QueryRowContext(ctx, "SELECT name FROM users WHERE id = $1", id)
+//
+// Query parameters are subject to the same rules as
+// DatastoreSegment.QueryParameters.
+func WithQueryParameters(ctx context.Context, params map[string]interface{}) context.Context {
+	return context.WithValue(ctx, queryParametersKey{}, params)
+}
+
+func queryParametersFromContext(ctx context.Context) map[string]interface{} {
+	if nil == ctx {
+		return nil
+	}
+	params, _ := ctx.Value(queryParametersKey{}).(map[string]interface{})
+	return params
+}
@@ -0,0 +1,47 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package newrelic
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// WithContext records the deadline budget of the context on the root span of
+// the transaction, as described with Transaction.StartSegmentWithContext.
+//
+//	txn := app.StartTransaction("processJob", newrelic.WithContext(ctx))
+func WithContext(ctx context.Context) TraceOption {
+	return func(o *traceOptSet) {
+		o.Context = ctx
+	}
+}
+
+// contextErrorClass returns the error class of a span whose context is done.
+func contextErrorClass(err error) string {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return "context.DeadlineExceeded"
+	}
+	return "context.Canceled"
+}
+
+// addContextAttributes adds the deadline budget of a context to the
+// attributes of a span which started at start, and the error of the context
+// if it is done.  If markError is true, a span whose context is done is
+// marked as an error unless it already contains one.
+func addContextAttributes(attrs *spanAttributeMap, ctx context.Context, start time.Time, markError bool) {
+	if deadline, ok := ctx.Deadline(); ok {
+		attrs.addFloat(SpanAttributeContextDeadlineRemaining, deadline.Sub(start).Seconds())
+	}
+	err := ctx.Err()
+	if err == nil {
+		return
+	}
+	attrs.addString(SpanAttributeContextError, err.Error())
+	if _, hasError := (*attrs)[SpanAttributeErrorClass]; markError && !hasError {
+		attrs.addString(SpanAttributeErrorClass, contextErrorClass(err))
+		attrs.addString(SpanAttributeErrorMessage, err.Error())
+	}
+}
@@ -12,6 +12,10 @@ import (
 )
 
 func TestTxnCrossProcessInitFromHTTPRequest(t *testing.T) {
+	if !crossAppTracingIncluded {
+		t.Skip("Cross Application Tracing is excluded from the build")
+	}
+
 	txp := &txnCrossProcess{}
 	txp.Init(true, false, replyAccountOne)
 	if txp.IsInbound() {
@@ -0,0 +1,92 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package newrelic
+
+import "errors"
+
+var errCustomEventTypeNotAllowed = errors.New("custom event type not allowed")
+
+// customEventTypeFilter contains the AllowedEventTypes and DeniedEventTypes
+// of Config.CustomInsightsEvents.
+type customEventTypeFilter struct {
+	allowed map[string]bool
+	denied  map[string]bool
+}
+
+func newCustomEventTypeFilter(allowed, denied []string) customEventTypeFilter {
+	var f customEventTypeFilter
+	if len(allowed) > 0 {
+		f.allowed = make(map[string]bool, len(allowed))
+		for _, t := range allowed {
+			f.allowed[t] = true
+		}
+	}
+	if len(denied) > 0 {
+		f.denied = make(map[string]bool, len(denied))
+		for _, t := range denied {
+			f.denied[t] = true
+		}
+	}
+	return f
+}
+
+// permits returns true if custom events of the type may be recorded.
+func (f customEventTypeFilter) permits(eventType string) bool {
+	if f.denied[eventType] {
+		return false
+	}
+	return f.allowed == nil || f.allowed[eventType]
+}
+
+// droppedCustomEvent is a custom event which was not recorded because its
+// type is not permitted.  It is counted in the dropped metric of its type.
+type droppedCustomEvent struct {
+	eventType string
+}
+
+// MergeIntoHarvest implements Harvestable.
+func (e droppedCustomEvent) MergeIntoHarvest(h *harvest) {
+	h.CustomEvents.drop(e.eventType)
+}
+
+// customEventQuotas limits the number of custom events of each type recorded
+// during a harvest cycle, and counts the events dropped.
+type customEventQuotas struct {
+	limits  map[string]int
+	seen    map[string]int
+	dropped map[string]int
+}
+
+func newCustomEventQuotas(limits map[string]int) customEventQuotas {
+	return customEventQuotas{limits: limits}
+}
+
+// allow returns true if another event of the type may be recorded during
+// this harvest cycle.
+func (q *customEventQuotas) allow(eventType string) bool {
+	limit, ok := q.limits[eventType]
+	if !ok {
+		return true
+	}
+	if q.seen == nil {
+		q.seen = make(map[string]int)
+	}
+	q.seen[eventType]++
+	return q.seen[eventType] <= limit
+}
+
+func (q *customEventQuotas) drop(eventType string) {
+	if q.dropped == nil {
+		q.dropped = make(map[string]int)
+	}
+	q.dropped[eventType]++
+}
+
+// recordMetrics adds the number of events of each type dropped during this
+// harvest cycle to the metrics.
+func (q *customEventQuotas) recordMetrics(metrics *metricTable) {
+	for eventType, count := range q.dropped {
+		metrics.addCount(customEventsDropped+eventType, float64(count), forced)
+	}
+}
@@ -7,6 +7,7 @@ import "time"
 
 type customEvents struct {
 	*analyticsEvents
+	quotas customEventQuotas
 }
 
 func newCustomEvents(max int) *customEvents {
@@ -16,6 +17,10 @@ func newCustomEvents(max int) *customEvents {
 }
 
 func (cs *customEvents) Add(e *customEvent) {
+	if !cs.quotas.allow(e.eventType) {
+		cs.drop(e.eventType)
+		return
+	}
 	// For the Go Agent, customEvents are added to the application, not the transaction.
 	// As a result, customEvents do not inherit their priority from the transaction, though
 	// they are still sampled according to priority sampling.
@@ -23,6 +28,10 @@ func (cs *customEvents) Add(e *customEvent) {
 	cs.addEvent(analyticsEvent{priority, e})
 }
 
+func (cs *customEvents) drop(eventType string) {
+	cs.quotas.drop(eventType)
+}
+
 func (cs *customEvents) MergeIntoHarvest(h *harvest) {
 	h.CustomEvents.mergeFailed(cs.analyticsEvents)
 }
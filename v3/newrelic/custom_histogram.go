@@ -0,0 +1,69 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package newrelic
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+)
+
+// defaultHistogramBounds are the bucket bounds used by
+// Application.RecordHistogram when none are provided.  They suit latencies
+// recorded in seconds.
+var defaultHistogramBounds = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// maxHistogramBounds limits the number of buckets of a histogram, since each
+// bucket is a separate metric.
+const maxHistogramBounds = 32
+
+var (
+	errHistogramBoundsInvalid  = errors.New("invalid histogram bounds: bounds must be finite and increasing")
+	errHistogramBoundsTooLarge = fmt.Errorf("too many histogram bounds: limit is %d", maxHistogramBounds)
+)
+
+// validateHistogramBounds returns an error if the bounds cannot be used as
+// the upper bounds of histogram buckets.
+func validateHistogramBounds(bounds []float64) error {
+	if len(bounds) > maxHistogramBounds {
+		return errHistogramBoundsTooLarge
+	}
+	for i, b := range bounds {
+		if math.IsNaN(b) || math.IsInf(b, 0) {
+			return errHistogramBoundsInvalid
+		}
+		if i > 0 && b <= bounds[i-1] {
+			return errHistogramBoundsInvalid
+		}
+	}
+	return nil
+}
+
+// customHistogram is a value recorded using Application.RecordHistogram.
+type customHistogram struct {
+	RawInputName string
+	Value        float64
+	Bounds       []float64
+}
+
+// bucketName returns the name of the metric counting the values of the
+// histogram which are less than or equal to the bound of their bucket, and
+// greater than the bound of the previous bucket.  Values greater than every
+// bound are counted in the "+Inf" bucket.
+func (m customHistogram) bucketName() string {
+	i := sort.SearchFloat64s(m.Bounds, m.Value)
+	le := "+Inf"
+	if i < len(m.Bounds) {
+		le = strconv.FormatFloat(m.Bounds[i], 'g', -1, 64)
+	}
+	return customMetricName(m.RawInputName) + "/bucket/" + le
+}
+
+// MergeIntoHarvest implements Harvestable.
+func (m customHistogram) MergeIntoHarvest(h *harvest) {
+	h.Metrics.addValue(customMetricName(m.RawInputName), "", m.Value, unforced)
+	h.Metrics.addSingleCount(m.bucketName(), unforced)
+}
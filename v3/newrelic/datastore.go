@@ -32,4 +32,5 @@ const (
 	DatastoreTarantool     DatastoreProduct = "Tarantool"
 	DatastoreVoltDB        DatastoreProduct = "VoltDB"
 	DatastoreAerospike     DatastoreProduct = "Aerospike"
+	DatastoreSpanner       DatastoreProduct = "Spanner"
 )
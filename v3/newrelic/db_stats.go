@@ -0,0 +1,149 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package newrelic
+
+import (
+	"database/sql"
+	"sync"
+	"time"
+)
+
+// dbStatsMetricPrefix is followed by the product, the database name, and the
+// statistic.
+const dbStatsMetricPrefix = "DatastorePool/"
+
+// MonitorDBStats registers a database for the sampling of its connection pool
+// statistics, which requires DatastoreTracer.ConnectionPoolMetrics.Enabled.
+// The statistics returned by sql.DB.Stats are sampled every minute for the
+// lifetime of the application and recorded as metrics named after the product
+// and the database name:
+//
+//	DatastorePool/<product>/<database name>/OpenConnections
+//	DatastorePool/<product>/<database name>/InUse
+//	DatastorePool/<product>/<database name>/Idle
+//	DatastorePool/<product>/<database name>/WaitCount
+//	DatastorePool/<product>/<database name>/WaitDuration
+//
+// WaitCount and WaitDuration are the number of connections waited for and the
+// total time spent waiting for them since the previous sample.
+//
+//	db, err := sql.Open("nrpostgres", "host=localhost dbname=orders")
+//	if err != nil {
+//		panic(err)
+//	}
+//	app.MonitorDBStats(db, newrelic.DatastorePostgres, "orders")
+//
+// The database/sql package does not tell drivers about the sql.DB using them,
+// so each database opened using an instrumented driver must be registered.
+// Registering a database more than once has no effect.
+func (app *Application) MonitorDBStats(db *sql.DB, product DatastoreProduct, databaseName string) {
+	if app == nil || app.app == nil || db == nil {
+		return
+	}
+	if !app.app.config.DatastoreTracer.ConnectionPoolMetrics.Enabled {
+		app.app.Debug("connection pool metrics disabled, database not monitored", map[string]interface{}{
+			"product":       string(product),
+			"database_name": databaseName,
+		})
+		return
+	}
+	app.app.dbStats.add(db, product, databaseName)
+}
+
+// dbStatsMonitor holds the databases registered using MonitorDBStats.
+type dbStatsMonitor struct {
+	sync.Mutex
+	dbs []*monitoredDB
+}
+
+type monitoredDB struct {
+	db       *sql.DB
+	prefix   string
+	previous sql.DBStats
+}
+
+func (m *dbStatsMonitor) add(db *sql.DB, product DatastoreProduct, databaseName string) {
+	if product == "" {
+		product = "Unknown"
+	}
+	if databaseName == "" {
+		databaseName = "unknown"
+	}
+
+	m.Lock()
+	defer m.Unlock()
+
+	for _, mdb := range m.dbs {
+		if mdb.db == db {
+			return
+		}
+	}
+	m.dbs = append(m.dbs, &monitoredDB{
+		db:       db,
+		prefix:   dbStatsMetricPrefix + string(product) + "/" + databaseName + "/",
+		previous: db.Stats(),
+	})
+}
+
+// sample gathers the statistics of the registered databases.
+func (m *dbStatsMonitor) sample() dbStatsSample {
+	m.Lock()
+	defer m.Unlock()
+
+	sample := make(dbStatsSample, 0, len(m.dbs))
+	for _, mdb := range m.dbs {
+		current := mdb.db.Stats()
+		sample = append(sample, dbStats{
+			prefix:       mdb.prefix,
+			open:         current.OpenConnections,
+			inUse:        current.InUse,
+			idle:         current.Idle,
+			waitCount:    current.WaitCount - mdb.previous.WaitCount,
+			waitDuration: current.WaitDuration - mdb.previous.WaitDuration,
+		})
+		mdb.previous = current
+	}
+	return sample
+}
+
+// dbStats contains the connection pool statistics of a database for a period
+// of time.
+type dbStats struct {
+	prefix       string
+	open         int
+	inUse        int
+	idle         int
+	waitCount    int64
+	waitDuration time.Duration
+}
+
+// dbStatsSample contains the statistics of every registered database.
+type dbStatsSample []dbStats
+
+// MergeIntoHarvest implements Harvestable.
+func (s dbStatsSample) MergeIntoHarvest(h *harvest) {
+	for _, stats := range s {
+		h.Metrics.addValue(stats.prefix+"OpenConnections", "", float64(stats.open), forced)
+		h.Metrics.addValue(stats.prefix+"InUse", "", float64(stats.inUse), forced)
+		h.Metrics.addValue(stats.prefix+"Idle", "", float64(stats.idle), forced)
+		h.Metrics.addValue(stats.prefix+"WaitCount", "", float64(stats.waitCount), forced)
+		h.Metrics.addValue(stats.prefix+"WaitDuration", "", stats.waitDuration.Seconds(), forced)
+	}
+}
+
+func runDBStatsSampler(app *app, period time.Duration) {
+	t := time.NewTicker(period)
+	for {
+		select {
+		case <-t.C:
+			if sample := app.dbStats.sample(); len(sample) > 0 {
+				run, _ := app.getState()
+				app.Consume(run.Reply.RunID, sample)
+			}
+		case <-app.shutdownStarted:
+			t.Stop()
+			return
+		}
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package newrelic
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/newrelic/go-agent/v3/internal"
+)
+
+func TestMonitorDBStats(t *testing.T) {
+	app := testApp(nil, ConfigDatastoreConnectionPoolMetrics(true), t)
+	db := sql.OpenDB(InstrumentSQLConnector(testConnector{}, SQLDriverSegmentBuilder{}))
+	defer db.Close()
+
+	app.MonitorDBStats(db, DatastorePostgres, "orders")
+	app.MonitorDBStats(db, DatastorePostgres, "orders")
+
+	conn, err := db.Conn(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	sample := app.app.dbStats.sample()
+	if len(sample) != 1 {
+		t.Fatal("incorrect number of databases sampled:", len(sample))
+	}
+	run, _ := app.app.getState()
+	app.app.Consume(run.Reply.RunID, sample)
+
+	app.expectNoLoggedErrors(t)
+	app.ExpectMetricsPresent(t, []internal.WantMetric{
+		{Name: "DatastorePool/Postgres/orders/OpenConnections", Scope: "", Forced: true, Data: []float64{1, 1, 1, 1, 1, 1}},
+		{Name: "DatastorePool/Postgres/orders/InUse", Scope: "", Forced: true, Data: []float64{1, 1, 1, 1, 1, 1}},
+		{Name: "DatastorePool/Postgres/orders/Idle", Scope: "", Forced: true, Data: []float64{1, 0, 0, 0, 0, 0}},
+		{Name: "DatastorePool/Postgres/orders/WaitCount", Scope: "", Forced: true, Data: []float64{1, 0, 0, 0, 0, 0}},
+		{Name: "DatastorePool/Postgres/orders/WaitDuration", Scope: "", Forced: true, Data: []float64{1, 0, 0, 0, 0, 0}},
+	})
+}
+
+func TestMonitorDBStatsDisabled(t *testing.T) {
+	app := testApp(nil, nil, t)
+	db := sql.OpenDB(InstrumentSQLConnector(testConnector{}, SQLDriverSegmentBuilder{}))
+	defer db.Close()
+
+	app.MonitorDBStats(db, DatastorePostgres, "orders")
+	if sample := app.app.dbStats.sample(); len(sample) != 0 {
+		t.Error("database unexpectedly monitored:", sample)
+	}
+}
+
+func TestMonitorDBStatsNil(t *testing.T) {
+	var app *Application
+	app.MonitorDBStats(nil, DatastorePostgres, "orders")
+
+	app = testApp(nil, ConfigDatastoreConnectionPoolMetrics(true), t).Application
+	app.MonitorDBStats(nil, DatastorePostgres, "orders")
+	if sample := app.app.dbStats.sample(); len(sample) != 0 {
+		t.Error("nil database unexpectedly monitored:", sample)
+	}
+}
+
+func TestDBStatsSampleDeltas(t *testing.T) {
+	m := &dbStatsMonitor{}
+	db := sql.OpenDB(InstrumentSQLConnector(testConnector{}, SQLDriverSegmentBuilder{}))
+	defer db.Close()
+	m.add(db, "", "")
+
+	m.dbs[0].previous.WaitCount = -2
+	sample := m.sample()
+	if len(sample) != 1 {
+		t.Fatal("incorrect number of databases sampled:", len(sample))
+	}
+	if s := sample[0]; s.prefix != "DatastorePool/Unknown/unknown/" || s.waitCount != 2 {
+		t.Errorf("incorrect sample: %+v", s)
+	}
+	if s := m.sample()[0]; s.waitCount != 0 {
+		t.Errorf("incorrect second sample: %+v", s)
+	}
+}
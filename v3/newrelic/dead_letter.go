@@ -0,0 +1,167 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package newrelic
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Headers added to a message sent to a dead-letter queue by
+// InsertDeadLetterHeaders.
+const (
+	// DeadLetterReasonHeader holds the reason the message could not be
+	// processed.
+	DeadLetterReasonHeader = "Newrelic-Dead-Letter-Reason"
+	// DeadLetterTimeHeader holds the time the message was sent to the
+	// dead-letter queue, in milliseconds since the epoch.
+	DeadLetterTimeHeader = "Newrelic-Dead-Letter-Time"
+)
+
+// deadLetterMetricPrefix is the prefix of the metrics recorded for each
+// dead-letter queue, followed by the name of the queue.
+const deadLetterMetricPrefix = "DeadLetter/"
+
+// deadLetterSummary is the dead-lettered message processed by a transaction.
+type deadLetterSummary struct {
+	queue string
+	// age is the time the message spent in the dead-letter queue, or -1
+	// if it is not known.
+	age time.Duration
+}
+
+// DeadLetter is a message consumed from a dead-letter queue, passed to
+// AcceptDeadLetter.
+type DeadLetter struct {
+	// Queue is the name of the dead-letter queue.
+	Queue string
+	// Reason is the reason the message was sent to the dead-letter queue,
+	// such as the error returned by the consumer which failed to process
+	// it.  If it is empty, the DeadLetterReasonHeader header is used.
+	Reason string
+	// Headers are the headers of the message, including the distributed
+	// trace headers of the transaction which failed to process it.
+	Headers http.Header
+	// DeadLetteredAt is the time the message was sent to the dead-letter
+	// queue.  If it is zero, the DeadLetterTimeHeader header is used.
+	DeadLetteredAt time.Time
+	// Attempts is the number of times the processing of the message was
+	// attempted.  It is recorded if it is not zero.
+	Attempts int
+}
+
+// InsertDeadLetterHeaders adds the headers linking a message to the
+// transaction which failed to process it, before the message is sent to a
+// dead-letter queue: the distributed trace headers of the transaction, and
+// the DeadLetterReasonHeader and DeadLetterTimeHeader headers.  The headers
+// are read by AcceptDeadLetter in the transaction which processes the
+// dead-letter queue.
+//
+//	if err := process(msg); err != nil {
+//		txn.NoticeError(err)
+//		hdrs := http.Header{}
+//		txn.InsertDeadLetterHeaders(hdrs, err.Error())
+//		sendToDeadLetterQueue(msg, hdrs)
+//	}
+func (txn *Transaction) InsertDeadLetterHeaders(hdrs http.Header, reason string) {
+	if nil == hdrs {
+		return
+	}
+	txn.InsertDistributedTraceHeaders(hdrs)
+	if reason != "" {
+		hdrs.Set(DeadLetterReasonHeader, reason)
+	}
+	hdrs.Set(DeadLetterTimeHeader, strconv.FormatInt(time.Now().UnixNano()/int64(time.Millisecond), 10))
+}
+
+// AcceptDeadLetter links a transaction processing a message from a
+// dead-letter queue to the trace of the transaction which failed to process
+// it.  Call it early in a transaction which processes a single message.  The
+// distributed trace headers of the message are accepted using the
+// TransportQueue transport type, and the message is described by the
+// AttributeDeadLetterQueue, AttributeDeadLetterReason,
+// AttributeDeadLetterAge, AttributeDeadLetterAttempts, and
+// AttributeDeadLetterTraceID attributes of the transaction.
+//
+// Each message processed is counted in the metric
+// "DeadLetter/{queue}/Processed", and the time it spent in the dead-letter
+// queue is recorded in the metric "DeadLetter/{queue}/Age", when it is known.
+//
+//	txn := app.StartTransaction("orders-dlq")
+//	defer txn.End()
+//	txn.AcceptDeadLetter(newrelic.DeadLetter{
+//		Queue:   "orders-dlq",
+//		Headers: msg.Headers,
+//	})
+func (txn *Transaction) AcceptDeadLetter(dl DeadLetter) {
+	if txn == nil || txn.thread == nil {
+		return
+	}
+	hdrs := dl.Headers
+	if nil == hdrs {
+		hdrs = http.Header{}
+	}
+	if dl.Reason == "" {
+		dl.Reason = hdrs.Get(DeadLetterReasonHeader)
+	}
+	if dl.DeadLetteredAt.IsZero() {
+		if ms, err := strconv.ParseInt(hdrs.Get(DeadLetterTimeHeader), 10, 64); err == nil && ms > 0 {
+			dl.DeadLetteredAt = time.Unix(0, ms*int64(time.Millisecond))
+		}
+	}
+	var traceID string
+	// The traceparent header is "<version>-<trace id>-<parent id>-<flags>".
+	if parts := strings.Split(hdrs.Get(DistributedTraceW3CTraceParentHeader), "-"); len(parts) == 4 {
+		traceID = parts[1]
+	}
+	txn.thread.logAPIError(txn.thread.setDeadLetter(dl, traceID), "accept dead letter", nil)
+	if len(hdrs) > 0 {
+		txn.AcceptDistributedTraceHeaders(TransportQueue, hdrs)
+	}
+}
+
+// setDeadLetter adds the description of a dead-lettered message to the
+// transaction.
+func (txn *txn) setDeadLetter(dl DeadLetter, traceID string) error {
+	txn.Lock()
+	defer txn.Unlock()
+	if txn.finished {
+		return errAlreadyEnded
+	}
+	s := deadLetterSummary{queue: dl.Queue, age: -1}
+	if s.queue == "" {
+		s.queue = "Unknown"
+	}
+	txn.Attrs.Agent.Add(AttributeDeadLetterQueue, s.queue, nil)
+	if dl.Reason != "" {
+		txn.Attrs.Agent.Add(AttributeDeadLetterReason, truncateStringValueIfLong(dl.Reason), nil)
+	}
+	if !dl.DeadLetteredAt.IsZero() {
+		s.age = txn.Start.Sub(dl.DeadLetteredAt)
+		if s.age < 0 {
+			s.age = 0
+		}
+		txn.Attrs.Agent.Add(AttributeDeadLetterAge, "", s.age.Seconds())
+	}
+	if dl.Attempts != 0 {
+		txn.Attrs.Agent.Add(AttributeDeadLetterAttempts, "", dl.Attempts)
+	}
+	if traceID != "" {
+		txn.Attrs.Agent.Add(AttributeDeadLetterTraceID, traceID, nil)
+	}
+	txn.deadLetter = &s
+	return nil
+}
+
+// mergeIntoHarvest records the metrics of the dead-lettered message processed
+// by a transaction.
+func (s *deadLetterSummary) mergeIntoHarvest(metrics *metricTable) {
+	prefix := deadLetterMetricPrefix + s.queue + "/"
+	metrics.addSingleCount(prefix+"Processed", unforced)
+	if s.age >= 0 {
+		metrics.addDuration(prefix+"Age", "", s.age, s.age, unforced)
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package newrelic
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/newrelic/go-agent/v3/internal"
+)
+
+func TestAcceptDeadLetter(t *testing.T) {
+	app := testApp(distributedTracingReplyFields, enableW3COnly, t)
+	txn := app.StartTransaction("consume")
+	hdrs := http.Header{}
+	txn.InsertDeadLetterHeaders(hdrs, "invalid order")
+	traceID := txn.GetTraceMetadata().TraceID
+	txn.End()
+	if hdrs.Get(DeadLetterReasonHeader) != "invalid order" || hdrs.Get(DeadLetterTimeHeader) == "" {
+		t.Fatal("dead letter headers missing:", hdrs)
+	}
+
+	txn = app.StartTransaction("consume-dlq")
+	txn.AcceptDeadLetter(DeadLetter{
+		Queue:    "orders-dlq",
+		Headers:  hdrs,
+		Attempts: 3,
+	})
+	if id := txn.GetTraceMetadata().TraceID; id != traceID {
+		t.Errorf("trace not continued: got %s, want %s", id, traceID)
+	}
+	txn.End()
+
+	app.expectNoLoggedErrors(t)
+	app.ExpectMetricsPresent(t, []internal.WantMetric{
+		{Name: "DeadLetter/orders-dlq/Processed", Scope: "", Forced: false, Data: []float64{1, 0, 0, 0, 0, 0}},
+		{Name: "DeadLetter/orders-dlq/Age", Scope: "", Forced: false, Data: nil},
+	})
+	app.ExpectTxnEvents(t, []internal.WantEvent{
+		{
+			Intrinsics: map[string]interface{}{
+				"name":     "OtherTransaction/Go/consume",
+				"guid":     internal.MatchAnything,
+				"priority": internal.MatchAnything,
+				"sampled":  internal.MatchAnything,
+				"traceId":  traceID,
+			},
+		},
+		{
+			Intrinsics: map[string]interface{}{
+				"name":                     "OtherTransaction/Go/consume-dlq",
+				"guid":                     internal.MatchAnything,
+				"parentId":                 internal.MatchAnything,
+				"parentSpanId":             internal.MatchAnything,
+				"parent.type":              "App",
+				"parent.account":           "123",
+				"parent.app":               "456",
+				"parent.transportType":     "Queue",
+				"parent.transportDuration": internal.MatchAnything,
+				"priority":                 internal.MatchAnything,
+				"sampled":                  internal.MatchAnything,
+				"traceId":                  traceID,
+			},
+			AgentAttributes: map[string]interface{}{
+				AttributeDeadLetterQueue:    "orders-dlq",
+				AttributeDeadLetterReason:   "invalid order",
+				AttributeDeadLetterAge:      internal.MatchAnything,
+				AttributeDeadLetterAttempts: 3,
+				AttributeDeadLetterTraceID:  traceID,
+			},
+		},
+	})
+}
+
+func TestAcceptDeadLetterWithoutHeaders(t *testing.T) {
+	app := testApp(nil, func(cfg *Config) {
+		cfg.DistributedTracer.Enabled = false
+	}, t)
+	txn := app.StartTransaction("consume-dlq")
+	txn.AcceptDeadLetter(DeadLetter{
+		Reason:         "timeout",
+		DeadLetteredAt: time.Now().Add(-time.Minute),
+	})
+	txn.End()
+	txn.AcceptDeadLetter(DeadLetter{Queue: "late"})
+
+	app.ExpectErrors(t, []internal.WantError{})
+	app.ExpectMetricsPresent(t, []internal.WantMetric{
+		{Name: "DeadLetter/Unknown/Processed", Scope: "", Forced: false, Data: []float64{1, 0, 0, 0, 0, 0}},
+		{Name: "DeadLetter/Unknown/Age", Scope: "", Forced: false, Data: nil},
+	})
+	app.ExpectTxnEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"name": "OtherTransaction/Go/consume-dlq",
+		},
+		AgentAttributes: map[string]interface{}{
+			AttributeDeadLetterQueue:  "Unknown",
+			AttributeDeadLetterReason: "timeout",
+			AttributeDeadLetterAge:    internal.MatchAnything,
+		},
+	}})
+}
@@ -47,8 +47,34 @@ var (
 		`([a-f0-9]{32})-` + // traceId
 		`([a-f0-9]{16})-` + // parentId
 		`([a-f0-9]{2})(-.*)?$`) // flags
+	awsTraceRootRegex = regexp.MustCompile(`^1-` + // version
+		`([a-fA-F0-9]{8})-` + // epoch seconds
+		`([a-fA-F0-9]{24})$`) // unique identifier
 )
 
+// awsTraceHeader is added to requests by AWS Application Load Balancers and
+// API Gateway.
+const awsTraceHeader = "X-Amzn-Trace-Id"
+
+// awsTraceID returns the W3C trace ID equivalent to the Root field of an
+// X-Amzn-Trace-Id header, such as
+// "Root=1-5759e988-bd862e3fe1be46a994272793;Sampled=1", or an empty string if
+// the header does not contain a valid root.
+func awsTraceID(hdr string) string {
+	for _, field := range strings.Split(hdr, ";") {
+		key, val, ok := strings.Cut(strings.TrimSpace(field), "=")
+		if !ok || key != "Root" {
+			continue
+		}
+		m := awsTraceRootRegex.FindStringSubmatch(val)
+		if m == nil {
+			return ""
+		}
+		return strings.ToLower(m[1] + m[2])
+	}
+	return ""
+}
+
 // timestampMillis allows raw payloads to use exact times, and marshalled
 // payloads to use times in millis.
 type timestampMillis time.Time
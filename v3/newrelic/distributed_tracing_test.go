@@ -699,3 +699,23 @@ func Test_processTraceState_invalidEntry(t *testing.T) {
 		t.Errorf("expected invalidNRTraceState error but got %v", err)
 	}
 }
+
+func TestAWSTraceID(t *testing.T) {
+	testcases := []struct {
+		hdr    string
+		expect string
+	}{
+		{hdr: "Root=1-5759e988-bd862e3fe1be46a994272793", expect: "5759e988bd862e3fe1be46a994272793"},
+		{hdr: "Root=1-5759E988-BD862E3FE1BE46A994272793;Parent=53995c3f42cd8ad8;Sampled=1", expect: "5759e988bd862e3fe1be46a994272793"},
+		{hdr: "Self=1-67891234-12456789abcdef012345678; Root=1-67891233-abcdef012345678912345678", expect: "67891233abcdef012345678912345678"},
+		{hdr: "Root=2-5759e988-bd862e3fe1be46a994272793", expect: ""},
+		{hdr: "Root=1-5759e988-bd862e3fe1be46a99427279", expect: ""},
+		{hdr: "Parent=53995c3f42cd8ad8", expect: ""},
+		{hdr: "", expect: ""},
+	}
+	for _, tc := range testcases {
+		if actual := awsTraceID(tc.hdr); actual != tc.expect {
+			t.Errorf("awsTraceID(%q) = %q, expected %q", tc.hdr, actual, tc.expect)
+		}
+	}
+}
@@ -43,11 +43,14 @@ func (e *errorEvent) WriteJSON(buf *bytes.Buffer) {
 	userAttributesJSON(e.Attrs, buf, destError, e.errorData.ExtraAttributes)
 	buf.WriteByte(',')
 
+	additional := make(map[string]string)
 	if e.ErrorGroup != "" {
-		agentAttributesJSON(e.Attrs, buf, destError, map[string]string{AttributeErrorGroupName: e.ErrorGroup})
-	} else {
-		agentAttributesJSON(e.Attrs, buf, destError)
+		additional[AttributeErrorGroupName] = e.ErrorGroup
 	}
+	if breadcrumbsEnabled(e.Breadcrumbs, e.Attrs, destError) {
+		additional[AttributeBreadcrumbs] = e.Breadcrumbs.limitedJSONString(errorEventMessageLengthLimit)
+	}
+	agentAttributesJSON(e.Attrs, buf, destError, additional)
 
 	buf.WriteByte(']')
 }
@@ -14,6 +14,7 @@ type ErrorInfo struct {
 	errAttributes map[string]interface{}
 	txnAttributes *attributes
 	stackTrace    stackTrace
+	stackFilter   stackTraceFilter
 
 	// TransactionName is the formatted name of a transaction that is equivilent to how it appears in
 	// the New Relic UI. For example, user defined transactions will be named `OtherTransaction/Go/yourTxnName`.
@@ -71,7 +72,7 @@ func (e *ErrorInfo) GetErrorAttribute(attribute string) (interface{}, bool) {
 // data gathered from the Go runtime. Calling this function may be expensive since it allocates and
 // populates a new slice with stack trace data, and should be called only when needed.
 func (e *ErrorInfo) GetStackTraceFrames() []StacktraceFrame {
-	return e.stackTrace.frames()
+	return e.stackFilter.apply(e.stackTrace.frames())
 }
 
 // GetRequestURI returns the URI of the http request made during the parent transaction of this error. If no web request occured,
@@ -128,6 +129,11 @@ func (e *ErrorInfo) GetUserID() string {
 	return val.stringVal
 }
 
+// ErrorCallback is a user defined callback function registered using
+// Application.SetErrorCallback.  It returns whether the error is kept and the
+// error group to assign to the error, or an empty string for none.
+type ErrorCallback func(ErrorInfo) (keep bool, groupLabel string)
+
 // ErrorGroupCallback is a user defined callback function that takes an error as an input
 // and returns a string that will be applied to an error to put it in an error group.
 //
@@ -64,6 +64,8 @@ type errorData struct {
 	Klass           string
 	SpanID          string
 	Expect          bool
+	// StackFilter is applied to Stack when the error is written.
+	StackFilter stackTraceFilter
 }
 
 // txnError combines error data with information about a transaction.  txnError is used for
@@ -121,7 +123,13 @@ func (h *tracedError) WriteJSON(buf *bytes.Buffer) {
 		buf.WriteByte(',')
 		buf.WriteString(`"stack_trace"`)
 		buf.WriteByte(':')
-		h.Stack.WriteJSON(buf)
+		h.Stack.writeFilteredJSON(buf, h.StackFilter)
+	}
+	if breadcrumbsEnabled(h.Breadcrumbs, h.Attrs, destError) {
+		buf.WriteByte(',')
+		buf.WriteString(`"breadcrumbs"`)
+		buf.WriteByte(':')
+		h.Breadcrumbs.WriteJSON(buf)
 	}
 	buf.WriteByte('}')
 	buf.WriteByte(',')
@@ -185,27 +193,32 @@ func (errors harvestErrors) EndpointMethod() string {
 	return cmdErrorData
 }
 
-// applyErrorGroup applies the error group callback function to an errorData object. It will either consume the txn object
-// or the txnEvent in that order. If both are nil, nothing will happen.
-func (errData *errorData) applyErrorGroup(txnEvent *txnEvent) {
-	if txnEvent == nil || txnEvent.errGroupCallback == nil {
-		return
-	}
-
-	errorInfo := ErrorInfo{
-		txnAttributes:   txnEvent.Attrs,
-		TransactionName: txnEvent.FinalName,
+// errorInfo returns the ErrorInfo passed to user defined callbacks for the
+// error of a transaction with the given attributes and name.
+func (errData *errorData) errorInfo(txnAttrs *attributes, txnName string) ErrorInfo {
+	return ErrorInfo{
+		txnAttributes:   txnAttrs,
+		TransactionName: txnName,
 		errAttributes:   errData.ExtraAttributes,
 		stackTrace:      errData.Stack,
+		stackFilter:     errData.StackFilter,
 		Error:           errData.RawError,
 		TimeOccured:     errData.When,
 		Message:         errData.Msg,
 		Class:           errData.Klass,
 		Expected:        errData.Expect,
 	}
+}
+
+// applyErrorGroup applies the error group callback function to an errorData object. It will either consume the txn object
+// or the txnEvent in that order. If both are nil, nothing will happen.
+func (errData *errorData) applyErrorGroup(txnEvent *txnEvent) {
+	if txnEvent == nil || txnEvent.errGroupCallback == nil {
+		return
+	}
 
 	// If a user defined an error group callback function, execute it to generate the error group string.
-	errGroup := txnEvent.errGroupCallback(errorInfo)
+	errGroup := txnEvent.errGroupCallback(errData.errorInfo(txnEvent.Attrs, txnEvent.FinalName))
 
 	if errGroup != "" {
 		errData.ErrorGroup = errGroup
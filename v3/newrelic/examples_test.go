@@ -140,6 +140,21 @@ func ExampleMessageProducerSegment() {
 	seg.End()
 }
 
+func ExampleMessageConsumerSegment() {
+	txn := currentTransaction()
+	// poll messages here
+	seg := &newrelic.MessageConsumerSegment{
+		StartTime:        txn.StartSegmentNow(),
+		Library:          "Kafka",
+		DestinationType:  newrelic.MessageTopic,
+		DestinationName:  "orders",
+		MessageTimestamp: time.Now().Add(-time.Second), // timestamp of the oldest message
+		MessageCount:     10,
+	}
+	// process messages here
+	seg.End()
+}
+
 func ExampleError() {
 	txn := currentTransaction()
 	username := "gopher"
@@ -0,0 +1,96 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package newrelic
+
+import (
+	"bytes"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// queryPlan is the query plan of a slow datastore segment.  It is captured by
+// the explainPlanner after the segment has ended, and so it is empty until
+// the plan has been captured, and remains empty if the capture fails.
+type queryPlan struct {
+	sync.Mutex
+	plan explainPlan
+}
+
+func (p *queryPlan) get() explainPlan {
+	if nil == p {
+		return ""
+	}
+	p.Lock()
+	defer p.Unlock()
+	return p.plan
+}
+
+func (p *queryPlan) set(plan string) {
+	p.Lock()
+	defer p.Unlock()
+	p.plan = explainPlan(plan)
+}
+
+// WriteJSON writes the plan captured so far.
+func (p *queryPlan) WriteJSON(buf *bytes.Buffer) {
+	p.get().WriteJSON(buf)
+}
+
+// explainPlanner captures the query plans of slow datastore segments in a
+// goroutine of its own, so that the EXPLAIN statements are not run by the
+// goroutine ending the segment.  A single plan is captured at a time, and at
+// most maxHarvestExplainPlans are captured each harvest period, so that a
+// degraded datastore does not receive an EXPLAIN statement for each of its
+// slow queries.  The zero value is ready to use.
+type explainPlanner struct {
+	// busy is 1 while a plan is captured.  It must be accessed
+	// atomically.
+	busy int32
+	// periodStart and planned, the number of plans captured since
+	// periodStart, are only accessed by the holder of busy.
+	periodStart time.Time
+	planned     int
+	// running lets the tests wait for the capture of the plans.
+	running sync.WaitGroup
+}
+
+// capture starts the capture of the plan using explain, unless a plan is
+// already being captured or the limit of the harvest period is reached.  It
+// returns nil if the plan is not captured.  onError is called if explain
+// fails.
+func (p *explainPlanner) capture(now time.Time, explain func() (string, error), onError func(error)) *queryPlan {
+	if !atomic.CompareAndSwapInt32(&p.busy, 0, 1) {
+		return nil
+	}
+	if now.Sub(p.periodStart) >= fixedHarvestPeriod {
+		p.periodStart = now
+		p.planned = 0
+	}
+	if p.planned >= maxHarvestExplainPlans {
+		atomic.StoreInt32(&p.busy, 0)
+		return nil
+	}
+	p.planned++
+
+	plan := &queryPlan{}
+	p.running.Add(1)
+	go func() {
+		defer p.running.Done()
+		defer atomic.StoreInt32(&p.busy, 0)
+
+		s, err := explain()
+		if err != nil {
+			onError(err)
+			return
+		}
+		plan.set(s)
+	}()
+	return plan
+}
+
+// wait waits for the capture of the plan in progress, if any.
+func (p *explainPlanner) wait() {
+	p.running.Wait()
+}
@@ -0,0 +1,121 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package newrelic
+
+import (
+	"encoding/json"
+	"expvar"
+	"sort"
+	"strings"
+	"time"
+)
+
+// expvarMetricPrefix is followed by the name of the expvar variable, and the
+// keys of the value recorded for map variables.
+const expvarMetricPrefix = "Expvar/"
+
+// expvarSelector selects the expvar variables reported using Config.Expvar.
+type expvarSelector struct {
+	names    map[string]bool
+	prefixes []string
+}
+
+func newExpvarSelector(names, prefixes []string) expvarSelector {
+	s := expvarSelector{names: make(map[string]bool, len(names))}
+	for _, name := range names {
+		s.names[name] = true
+	}
+	for _, prefix := range prefixes {
+		if prefix != "" {
+			s.prefixes = append(s.prefixes, prefix)
+		}
+	}
+	return s
+}
+
+func (s expvarSelector) selects(name string) bool {
+	if s.names[name] {
+		return true
+	}
+	for _, prefix := range s.prefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// expvarValue is the value of a metric recorded from an expvar variable.
+type expvarValue struct {
+	name  string
+	value float64
+}
+
+// expvarSample contains the values recorded from the expvar variables.
+type expvarSample []expvarValue
+
+// sample gathers the numeric values of the selected expvar variables.  Each
+// variable is read using its String method, which returns JSON, so that every
+// type of variable is handled the same way.  Variables whose value is not
+// valid JSON are skipped.
+func (s expvarSelector) sample() expvarSample {
+	var sample expvarSample
+	expvar.Do(func(kv expvar.KeyValue) {
+		if !s.selects(kv.Key) {
+			return
+		}
+		var v interface{}
+		if err := json.Unmarshal([]byte(kv.Value.String()), &v); err != nil {
+			return
+		}
+		sample = sample.add(expvarMetricPrefix+kv.Key, v)
+	})
+	return sample
+}
+
+// add adds the numeric values of v, including the values nested in maps, to
+// the sample.
+func (sample expvarSample) add(name string, v interface{}) expvarSample {
+	if len(sample) >= maxExpvarMetrics {
+		return sample
+	}
+	switch v := v.(type) {
+	case float64:
+		sample = append(sample, expvarValue{name: name, value: v})
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			sample = sample.add(name+"/"+key, v[key])
+		}
+	}
+	return sample
+}
+
+// MergeIntoHarvest implements Harvestable.
+func (sample expvarSample) MergeIntoHarvest(h *harvest) {
+	for _, v := range sample {
+		h.Metrics.addValue(v.name, "", v.value, forced)
+	}
+}
+
+func runExpvarSampler(app *app, period time.Duration) {
+	selector := newExpvarSelector(app.config.Expvar.Names, app.config.Expvar.Prefixes)
+	t := time.NewTicker(period)
+	for {
+		select {
+		case <-t.C:
+			if sample := selector.sample(); len(sample) > 0 {
+				run, _ := app.getState()
+				app.Consume(run.Reply.RunID, sample)
+			}
+		case <-app.shutdownStarted:
+			t.Stop()
+			return
+		}
+	}
+}
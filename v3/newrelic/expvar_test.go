@@ -0,0 +1,80 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package newrelic
+
+import (
+	"expvar"
+	"testing"
+
+	"github.com/newrelic/go-agent/v3/internal"
+)
+
+func init() {
+	expvar.NewInt("nrtest.requests").Set(7)
+	expvar.NewFloat("nrtest.load").Set(0.5)
+	expvar.NewString("nrtest.version").Set("1.2.3")
+	m := expvar.NewMap("nrtest.queue")
+	m.Add("depth", 3)
+	m.Add("workers", 2)
+	expvar.Publish("nrtest.pool", expvar.Func(func() interface{} {
+		return map[string]interface{}{
+			"open":   4,
+			"name":   "primary",
+			"sizes":  []int{1, 2},
+			"nested": map[string]interface{}{"idle": 1},
+		}
+	}))
+	expvar.NewInt("other.requests").Set(9)
+}
+
+func TestExpvarSample(t *testing.T) {
+	app := testApp(nil, func(cfg *Config) {
+		ConfigExpvarNames("nrtest.requests", "nrtest.version")(cfg)
+		ConfigExpvarPrefixes("nrtest.q", "nrtest.p", "nrtest.l")(cfg)
+	}, t)
+
+	sample := newExpvarSelector(app.app.config.Expvar.Names, app.app.config.Expvar.Prefixes).sample()
+	run, _ := app.app.getState()
+	app.app.Consume(run.Reply.RunID, sample)
+
+	app.ExpectMetricsPresent(t, []internal.WantMetric{
+		{Name: "Expvar/nrtest.requests", Scope: "", Forced: true, Data: []float64{1, 7, 7, 7, 7, 49}},
+		{Name: "Expvar/nrtest.load", Scope: "", Forced: true, Data: []float64{1, 0.5, 0.5, 0.5, 0.5, 0.25}},
+		{Name: "Expvar/nrtest.queue/depth", Scope: "", Forced: true, Data: []float64{1, 3, 3, 3, 3, 9}},
+		{Name: "Expvar/nrtest.queue/workers", Scope: "", Forced: true, Data: []float64{1, 2, 2, 2, 2, 4}},
+		{Name: "Expvar/nrtest.pool/open", Scope: "", Forced: true, Data: []float64{1, 4, 4, 4, 4, 16}},
+		{Name: "Expvar/nrtest.pool/nested/idle", Scope: "", Forced: true, Data: []float64{1, 1, 1, 1, 1, 1}},
+	})
+	if len(sample) != 6 {
+		t.Errorf("incorrect number of values sampled: %+v", sample)
+	}
+}
+
+func TestExpvarSelector(t *testing.T) {
+	s := newExpvarSelector([]string{"memstats"}, []string{"", "nrtest."})
+	for name, want := range map[string]bool{
+		"memstats":        true,
+		"memstats.extra":  false,
+		"cmdline":         false,
+		"nrtest.requests": true,
+		"other.requests":  false,
+	} {
+		if got := s.selects(name); got != want {
+			t.Errorf("selects(%q) = %v, want %v", name, got, want)
+		}
+	}
+	if s := newExpvarSelector(nil, nil).sample(); len(s) != 0 {
+		t.Error("variables unexpectedly sampled:", s)
+	}
+}
+
+func TestExpvarSampleLimit(t *testing.T) {
+	values := make(map[string]interface{}, maxExpvarMetrics+10)
+	for i := 0; i < maxExpvarMetrics+10; i++ {
+		values[string(rune('a'+i%26))+string(rune('a'+i/26))] = float64(i)
+	}
+	if sample := expvarSample(nil).add("Expvar/large", values); len(sample) != maxExpvarMetrics {
+		t.Error("incorrect number of values sampled:", len(sample))
+	}
+}
@@ -0,0 +1,151 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package newrelic
+
+import "time"
+
+// The numbers of goroutines run at once by the agent and by its features.
+const (
+	// The processor, which merges the data of the transactions into the
+	// harvest, the goroutine connecting to New Relic, and the goroutine
+	// sending the harvest always run.
+	goroutinesRequired = 3
+	goroutinesHarvest  = 1
+	// The trace observer receives the responses and records its
+	// supportability metrics in goroutines besides the one sending spans.
+	goroutinesTraceObserver = 3
+	// A secondary destination connects and sends each harvest in its own
+	// goroutine.
+	goroutinesSecondaryDestination = 2
+	goroutinesSampler              = 1
+)
+
+// goroutineBudget is the outcome of Config.GoroutineBudget: the optional
+// goroutines the application may start, once the features which do not fit
+// in the budget are turned off.
+type goroutineBudget struct {
+	// max is Config.GoroutineBudget.Max, or 0 if there is no budget.
+	max int
+	// healthWriter is true if the agent health file may be written
+	// periodically.
+	healthWriter bool
+	// insufficient is true if the budget is lower than the goroutines the
+	// agent always runs.
+	insufficient bool
+	// excluded contains the names of the features turned off, for logging
+	// and supportability metrics.
+	excluded []string
+}
+
+// applyGoroutineBudget fits the goroutines of the features enabled in the
+// configuration into Config.GoroutineBudget.Max, in the order of priority
+// documented there, and turns off the features which do not fit.
+func applyGoroutineBudget(c *config) {
+	c.goroutineBudget = goroutineBudget{
+		healthWriter: true,
+	}
+	max := c.GoroutineBudget.Max
+	if max <= 0 || !c.Enabled || c.ServerlessMode.Enabled {
+		return
+	}
+	b := &c.goroutineBudget
+	b.max = max
+	remaining := max - goroutinesRequired
+	if remaining < 0 {
+		b.insufficient = true
+		remaining = 0
+	}
+	fits := func(feature string, n int) bool {
+		if n <= remaining {
+			remaining -= n
+			return true
+		}
+		b.excluded = append(b.excluded, feature)
+		return false
+	}
+
+	if nil != c.traceObserverURL && !fits("InfiniteTracing", goroutinesTraceObserver) {
+		c.traceObserverURL = nil
+	}
+	// A new slice is allocated so that the input Config is not changed.
+	var dests []SecondaryDestination
+	for _, dest := range c.SecondaryDestinations {
+		if fits("SecondaryDestinations", goroutinesSecondaryDestination) {
+			dests = append(dests, dest)
+		}
+	}
+	c.SecondaryDestinations = dests
+	if c.AgentHealth.Enabled {
+		b.healthWriter = fits("AgentHealth", goroutinesSampler)
+	}
+	if c.RuntimeSampler.Enabled && !fits("RuntimeSampler", goroutinesSampler) {
+		c.RuntimeSampler.Enabled = false
+	}
+	if c.DatastoreTracer.ConnectionPoolMetrics.Enabled && !fits("ConnectionPoolMetrics", goroutinesSampler) {
+		c.DatastoreTracer.ConnectionPoolMetrics.Enabled = false
+	}
+	if c.Expvar.Enabled && !fits("Expvar", goroutinesSampler) {
+		c.Expvar.Enabled = false
+	}
+}
+
+// startHarvest sends the data of the harvest which is ready in a new
+// goroutine.  When the goroutine budget leaves no room for it, because the
+// previous harvest is still being sent, the harvest is delayed: the data stays
+// in h and is sent by the next harvest.  The harvest is never sent in the
+// processor goroutine, since sending it may block until the processor merges
+// the data which could not be sent.
+func (app *app) startHarvest(h *harvest, now time.Time, run *appRun) {
+	if nil != app.harvestSlots {
+		select {
+		case app.harvestSlots <- struct{}{}:
+		default:
+			if h.timer.due(now) {
+				h.Metrics.addSingleCount(supportGoroutineBudgetHarvestDelayed, forced)
+			}
+			return
+		}
+	}
+	ready := app.readyHarvest(h, now)
+	if nil == ready {
+		if nil != app.harvestSlots {
+			<-app.harvestSlots
+		}
+		return
+	}
+	go func() {
+		if nil != app.harvestSlots {
+			defer func() { <-app.harvestSlots }()
+		}
+		app.doHarvest(ready, now, run)
+	}()
+}
+
+// logGoroutineBudget warns about the features turned off to fit the
+// goroutine budget.
+func (app *app) logGoroutineBudget() {
+	b := app.config.goroutineBudget
+	if b.insufficient {
+		app.Warn("goroutine budget is lower than the goroutines always run by the agent", map[string]interface{}{
+			"budget":   b.max,
+			"required": goroutinesRequired,
+		})
+	}
+	if len(b.excluded) > 0 {
+		app.Warn("features turned off to fit the goroutine budget", map[string]interface{}{
+			"budget":   b.max,
+			"features": b.excluded,
+		})
+	}
+}
+
+func createGoroutineBudgetMetrics(b goroutineBudget, metrics *metricTable) {
+	if b.max <= 0 {
+		return
+	}
+	metrics.addValue(supportGoroutineBudgetMax, "", float64(b.max), forced)
+	for _, feature := range b.excluded {
+		metrics.addSingleCount(supportGoroutineBudgetExcluded+feature, forced)
+	}
+}
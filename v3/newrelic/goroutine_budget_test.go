@@ -0,0 +1,136 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package newrelic
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/newrelic/go-agent/v3/internal"
+)
+
+func goroutineBudgetConfig(t *testing.T, max int) (Config, config) {
+	cfg := defaultConfig()
+	cfg.AppName = "my app"
+	cfg.License = testLicenseKey
+	cfg.GoroutineBudget.Max = max
+	cfg.SecondaryDestinations = []SecondaryDestination{
+		{License: "eu01xx0123456789012345678901234567890123"},
+		{License: "0123456789012345678901234567890123456789", Host: "collector.example.com"},
+	}
+	cfg.AgentHealth.Enabled = true
+	cfg.AgentHealth.DeliveryLocation = t.TempDir()
+	cfg.Expvar.Enabled = true
+	c, err := newInternalConfig(cfg, func(string) string { return "" }, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cfg, c
+}
+
+func TestApplyGoroutineBudget(t *testing.T) {
+	cfg, c := goroutineBudgetConfig(t, 6)
+	b := c.goroutineBudget
+	if b.max != 6 || !b.healthWriter || b.insufficient {
+		t.Errorf("incorrect budget: %+v", b)
+	}
+	if want := []string{"SecondaryDestinations", "RuntimeSampler", "Expvar"}; !reflect.DeepEqual(b.excluded, want) {
+		t.Error("incorrect excluded features:", b.excluded)
+	}
+	if len(c.SecondaryDestinations) != 1 || c.SecondaryDestinations[0].Host != "" {
+		t.Error("incorrect secondary destinations:", c.SecondaryDestinations)
+	}
+	if len(cfg.SecondaryDestinations) != 2 {
+		t.Error("input secondary destinations changed:", cfg.SecondaryDestinations)
+	}
+	if c.RuntimeSampler.Enabled || c.Expvar.Enabled {
+		t.Error("samplers not turned off")
+	}
+
+	_, c = goroutineBudgetConfig(t, 1)
+	b = c.goroutineBudget
+	if !b.insufficient || b.healthWriter || len(c.SecondaryDestinations) != 0 {
+		t.Errorf("incorrect budget: %+v", b)
+	}
+	if want := []string{"SecondaryDestinations", "SecondaryDestinations", "AgentHealth", "RuntimeSampler", "Expvar"}; !reflect.DeepEqual(b.excluded, want) {
+		t.Error("incorrect excluded features:", b.excluded)
+	}
+
+	_, c = goroutineBudgetConfig(t, 0)
+	b = c.goroutineBudget
+	if b.max != 0 || !b.healthWriter || len(b.excluded) != 0 || len(c.SecondaryDestinations) != 2 || !c.RuntimeSampler.Enabled {
+		t.Errorf("budget applied without maximum: %+v", b)
+	}
+}
+
+func TestStartHarvestDelayed(t *testing.T) {
+	app := testApp(nil, nil, t)
+	collector := &spoolCollector{statusCode: 503}
+	// The test application is disabled, and so has no collector client.
+	app.app.rpmControls = newRPMControls(app.app.config)
+	app.app.rpmControls.Client = &http.Client{Transport: collector}
+	app.app.harvestSlots = make(chan struct{}, goroutinesHarvest)
+	// The data which could not be sent is merged by the processor, played
+	// by the test, through dataChan, which is full.
+	app.app.testHarvest = nil
+	app.app.dataChan = make(chan appData, 1)
+	app.app.dataChan <- appData{}
+	app.app.shutdownStarted = make(chan struct{})
+	defer close(app.app.shutdownStarted)
+
+	_, c := goroutineBudgetConfig(t, 3)
+	reply := internal.ConnectReplyDefaults()
+	reply.RunID = "run1"
+	run := newAppRun(c, reply)
+	start := time.Now()
+	h := newHarvest(start, testHarvestCfgr)
+	h.Metrics.addCount("Custom/first", 1, forced)
+
+	// Neither harvest is sent in the processor goroutine, and so both calls
+	// return although the first harvest cannot give back its data.
+	now := start.Add(time.Hour)
+	app.app.startHarvest(h, now, run)
+	h.Metrics.addCount("Custom/second", 1, forced)
+	app.app.startHarvest(h, now.Add(time.Hour), run)
+	for _, name := range []string{"Custom/second", supportGoroutineBudgetHarvestDelayed} {
+		if _, ok := h.Metrics.metrics[metricID{Name: name}]; !ok {
+			t.Errorf("metric %s missing from the delayed harvest", name)
+		}
+	}
+
+	<-app.app.dataChan
+	timeout := time.After(10 * time.Second)
+	for len(app.app.harvestSlots) > 0 {
+		select {
+		case d := <-app.app.dataChan:
+			if d.id == run.Reply.RunID {
+				d.data.MergeIntoHarvest(h)
+			}
+		case <-time.After(10 * time.Millisecond):
+		case <-timeout:
+			t.Fatal("harvest not finished")
+		}
+	}
+	for len(app.app.dataChan) > 0 {
+		if d := <-app.app.dataChan; d.id == run.Reply.RunID {
+			d.data.MergeIntoHarvest(h)
+		}
+	}
+	if _, ok := h.Metrics.metrics[metricID{Name: "Custom/first"}]; !ok {
+		t.Error("data of the failed harvest not merged")
+	}
+	sent := strings.Join(collector.respond(503), "\n")
+	for _, name := range []string{
+		"Custom/first",
+		supportGoroutineBudgetMax,
+		supportGoroutineBudgetExcluded + "RuntimeSampler",
+	} {
+		if !strings.Contains(sent, `"`+name+`"`) {
+			t.Errorf("metric %s not sent: %s", name, sent)
+		}
+	}
+}
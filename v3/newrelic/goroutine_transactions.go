@@ -0,0 +1,91 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package newrelic
+
+import (
+	"bytes"
+	"errors"
+	"runtime"
+	"strconv"
+	"sync"
+)
+
+var errGoroutineTransactionsDisabled = errors.New("goroutine transactions disabled")
+
+// goroutineTransactions maps the IDs of goroutines to the Transaction
+// references bound to them when Config.GoroutineTransactions is enabled.
+var goroutineTransactions sync.Map
+
+// currentGoroutineID returns the ID of the calling goroutine, parsed from the
+// first line of its stack trace, "goroutine 123 [running]:".  The runtime
+// does not expose goroutine IDs, so this is slow compared to passing a
+// context, and is only used when Config.GoroutineTransactions is enabled.
+func currentGoroutineID() uint64 {
+	var buf [64]byte
+	b := bytes.TrimPrefix(buf[:runtime.Stack(buf[:], false)], []byte("goroutine "))
+	if i := bytes.IndexByte(b, ' '); i >= 0 {
+		b = b[:i]
+	}
+	id, _ := strconv.ParseUint(string(b), 10, 64)
+	return id
+}
+
+// CurrentTransaction returns the transaction bound to the calling goroutine,
+// or nil.  It is EXPERIMENTAL, and only returns transactions if
+// Config.GoroutineTransactions is enabled.  Prefer FromContext where a
+// context is available.
+func CurrentTransaction() *Transaction {
+	txn, _ := goroutineTransactions.Load(currentGoroutineID())
+	t, _ := txn.(*Transaction)
+	return t
+}
+
+// BindGoroutine binds the Transaction reference to the calling goroutine, so
+// that it is returned by CurrentTransaction.  It is EXPERIMENTAL, and has no
+// effect unless Config.GoroutineTransactions is enabled.  Transactions are
+// bound to the goroutine which starts them, and to the goroutine which calls
+// Application.ResumeTransaction, so BindGoroutine is only needed for
+// references returned by NewGoroutine:
+//
+//	go func(txn *newrelic.Transaction) {
+//		txn.BindGoroutine()
+//		doWork()
+//	}(txn.NewGoroutine())
+//
+// The binding is removed when the transaction ends.
+func (txn *Transaction) BindGoroutine() {
+	if txn == nil || txn.thread == nil {
+		return
+	}
+	txn.thread.logAPIError(txn.thread.bindGoroutine(txn, currentGoroutineID()), "bind goroutine", nil)
+}
+
+func (thd *thread) bindGoroutine(ref *Transaction, id uint64) error {
+	txn := thd.txn
+	txn.Lock()
+	defer txn.Unlock()
+
+	if txn.finished {
+		return errAlreadyEnded
+	}
+	if !txn.Config.GoroutineTransactions.Enabled {
+		return errGoroutineTransactionsDisabled
+	}
+	if txn.goroutines == nil {
+		txn.goroutines = make(map[uint64]*Transaction)
+	}
+	txn.goroutines[id] = ref
+	goroutineTransactions.Store(id, ref)
+	return nil
+}
+
+// unbindGoroutines removes the bindings of the transaction which have not been
+// replaced by the bindings of other transactions.  It must be called with the
+// transaction locked.
+func (txn *txn) unbindGoroutines() {
+	for id, ref := range txn.goroutines {
+		goroutineTransactions.CompareAndDelete(id, ref)
+	}
+	txn.goroutines = nil
+}
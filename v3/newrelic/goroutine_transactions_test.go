@@ -0,0 +1,95 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package newrelic
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestCurrentGoroutineID(t *testing.T) {
+	id := currentGoroutineID()
+	if id == 0 || id != currentGoroutineID() {
+		t.Fatal(id)
+	}
+	ch := make(chan uint64)
+	go func() { ch <- currentGoroutineID() }()
+	if other := <-ch; other == 0 || other == id {
+		t.Error(id, other)
+	}
+}
+
+func TestCurrentTransaction(t *testing.T) {
+	app := testApp(nil, ConfigGoroutineTransactionsEnabled(true), t)
+	txn := app.StartTransaction("hello")
+	if current := CurrentTransaction(); current != txn {
+		t.Fatal(current)
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func(txn *Transaction) {
+		defer wg.Done()
+		if CurrentTransaction() != nil {
+			t.Error("goroutine bound before BindGoroutine")
+		}
+		txn.BindGoroutine()
+		if current := CurrentTransaction(); current != txn {
+			t.Error(current)
+		}
+	}(txn.NewGoroutine())
+	token := txn.Token()
+	go func() {
+		defer wg.Done()
+		txn := app.ResumeTransaction(token)
+		if current := CurrentTransaction(); current != txn {
+			t.Error(current)
+		}
+	}()
+	wg.Wait()
+
+	txn.End()
+	app.expectNoLoggedErrors(t)
+	if current := CurrentTransaction(); current != nil {
+		t.Error(current)
+	}
+	if n := len(txn.thread.goroutines); n != 0 {
+		t.Error(n)
+	}
+}
+
+func TestCurrentTransactionDisabled(t *testing.T) {
+	app := testApp(nil, nil, t)
+	txn := app.StartTransaction("hello")
+	defer txn.End()
+	if current := CurrentTransaction(); current != nil {
+		t.Error(current)
+	}
+	txn.BindGoroutine()
+	app.expectSingleLoggedError(t, "unable to bind goroutine", map[string]interface{}{
+		"reason": errGoroutineTransactionsDisabled.Error(),
+	})
+	if current := CurrentTransaction(); current != nil {
+		t.Error(current)
+	}
+}
+
+func TestCurrentTransactionNested(t *testing.T) {
+	app := testApp(nil, ConfigGoroutineTransactionsEnabled(true), t)
+	outer := app.StartTransaction("outer")
+	inner := app.StartTransaction("inner")
+	if current := CurrentTransaction(); current != inner {
+		t.Error(current)
+	}
+	// Ending the outer transaction does not remove the binding of the
+	// inner transaction.
+	outer.End()
+	if current := CurrentTransaction(); current != inner {
+		t.Error(current)
+	}
+	inner.End()
+	if current := CurrentTransaction(); current != nil {
+		t.Error(current)
+	}
+}
@@ -57,6 +57,17 @@ func (timer *harvestTimer) ready(now time.Time) (ready harvestTypes) {
 	return
 }
 
+// due returns true if data is ready to be harvested, without resetting the
+// timer like ready does.
+func (timer *harvestTimer) due(now time.Time) bool {
+	for tp, period := range timer.periods {
+		if now.After(timer.lastHarvest[tp].Add(period)) {
+			return true
+		}
+	}
+	return false
+}
+
 // harvest contains collected data.
 type harvest struct {
 	timer *harvestTimer
@@ -91,8 +102,10 @@ func (h *harvest) Ready(now time.Time) *harvest {
 	if 0 != types&harvestCustomEvents {
 		h.Metrics.addCount(customEventsSeen, h.CustomEvents.NumSeen(), forced)
 		h.Metrics.addCount(customEventsSent, h.CustomEvents.NumSaved(), forced)
+		h.CustomEvents.quotas.recordMetrics(h.Metrics)
 		ready.CustomEvents = h.CustomEvents
 		h.CustomEvents = newCustomEvents(h.CustomEvents.capacity())
+		h.CustomEvents.quotas = newCustomEventQuotas(ready.CustomEvents.quotas.limits)
 	}
 	if 0 != types&harvestLogEvents {
 		h.LogEvents.RecordLoggingMetrics(h.Metrics)
@@ -179,11 +192,14 @@ type harvestConfig struct {
 	MaxCustomEvents  int
 	MaxErrorEvents   int
 	MaxTxnEvents     int
+	// CustomEventQuotas is the maximum number of custom events of each
+	// type recorded during a harvest cycle.
+	CustomEventQuotas map[string]int
 }
 
 // newHarvest returns a new Harvest.
 func newHarvest(now time.Time, configurer harvestConfig) *harvest {
-	return &harvest{
+	h := &harvest{
 		timer:        newHarvestTimer(now, configurer.ReportPeriods),
 		Metrics:      newMetricTable(maxMetrics, now),
 		ErrorTraces:  newHarvestErrors(maxHarvestErrors),
@@ -195,6 +211,8 @@ func newHarvest(now time.Time, configurer harvestConfig) *harvest {
 		TxnEvents:    newTxnEvents(configurer.MaxTxnEvents),
 		ErrorEvents:  newErrorEvents(configurer.MaxErrorEvents),
 	}
+	h.CustomEvents.quotas = newCustomEventQuotas(configurer.CustomEventQuotas)
+	return h
 }
 
 func createTrackUsageMetrics(metrics *metricTable) {
@@ -244,6 +262,7 @@ func (h *harvest) CreateFinalMetrics(run *appRun, to traceObserver) {
 	createTraceObserverMetrics(to, h.Metrics)
 	createTrackUsageMetrics(h.Metrics)
 	createAppLoggingSupportabilityMetrics(&hc.LoggingConfig, h.Metrics)
+	createGoroutineBudgetMetrics(run.Config.goroutineBudget, h.Metrics)
 
 	h.Metrics = h.Metrics.ApplyRules(reply.MetricRules)
 }
@@ -320,9 +339,15 @@ func createTxnMetrics(args *txnData, metrics *metricTable) {
 
 	// Apdex Metrics
 	if args.Zone != apdexNone {
-		metrics.addApdex(apdexRollup, "", args.ApdexThreshold, args.Zone, forced)
+		rollup, prefix := apdexRollup, apdexPrefix
+		if !args.IsWeb {
+			// Background transactions are kept apart from web
+			// transactions so they do not affect their score.
+			rollup, prefix = apdexOtherRollup, apdexOtherPrefix
+		}
+		metrics.addApdex(rollup, "", args.ApdexThreshold, args.Zone, forced)
 
-		mname := apdexPrefix + withoutFirstSegment
+		mname := prefix + withoutFirstSegment
 		metrics.addApdex(mname, "", args.ApdexThreshold, args.Zone, unforced)
 	}
 
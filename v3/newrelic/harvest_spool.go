@@ -0,0 +1,257 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package newrelic
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// harvestSpoolSuffix is the suffix of the names of the spooled payload files.
+const harvestSpoolSuffix = ".json.gz"
+
+var errHarvestSpoolPayloadTooLarge = errors.New("payload larger than HarvestSpool.MaxBytes")
+
+// harvestSpool stores the harvest payloads which could not be sent in gzip
+// files, named so that they sort in the order they were written.  The
+// endpoint and the agent run ID of each payload are stored in the name and
+// comment of the gzip header.
+type harvestSpool struct {
+	dir      string
+	maxBytes int64
+
+	sync.Mutex
+	seq int64
+
+	// replaying is 1 while the files are being sent.  It must be accessed
+	// atomically.
+	replaying int32
+}
+
+// harvestSpoolDir returns the directory of the application's spooled
+// payloads: a subdirectory of HarvestSpool.Directory named after a hash of the
+// license key and the application name.  Applications which share the
+// directory therefore never replay each other's payloads.
+func harvestSpoolDir(c config) string {
+	sum := sha256.Sum256([]byte(c.License + "\n" + c.AppName))
+	return filepath.Join(c.HarvestSpool.Directory, hex.EncodeToString(sum[:16]))
+}
+
+func newHarvestSpool(dir string, maxBytes int64) *harvestSpool {
+	if maxBytes <= 0 {
+		maxBytes = defaultHarvestSpoolMaxBytes
+	}
+	return &harvestSpool{dir: dir, maxBytes: maxBytes}
+}
+
+// write spools a payload, and removes the oldest files if the total size of
+// the files exceeds the maximum.
+func (s *harvestSpool) write(cmd, runID string, data []byte) error {
+	var buf bytes.Buffer
+	zw, err := gzip.NewWriterLevel(&buf, gzip.BestSpeed)
+	if err != nil {
+		return err
+	}
+	zw.Name = cmd
+	zw.Comment = runID
+	if _, err := zw.Write(data); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	if int64(buf.Len()) > s.maxBytes {
+		return errHarvestSpoolPayloadTooLarge
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return err
+	}
+	s.seq++
+	name := filepath.Join(s.dir, fmt.Sprintf("%020d-%06d%s", time.Now().UnixNano(), s.seq%1000000, harvestSpoolSuffix))
+	// The file is renamed once written so that a partially written file is
+	// never read.
+	if err := os.WriteFile(name+".tmp", buf.Bytes(), 0600); err != nil {
+		os.Remove(name + ".tmp")
+		return err
+	}
+	if err := os.Rename(name+".tmp", name); err != nil {
+		os.Remove(name + ".tmp")
+		return err
+	}
+	return s.trim()
+}
+
+// trim removes the oldest files until the total size of the files is within
+// the maximum.  It must be called with the lock held.
+func (s *harvestSpool) trim() error {
+	files, err := s.files()
+	if err != nil {
+		return err
+	}
+	var total int64
+	for i := len(files) - 1; i >= 0; i-- {
+		total += files[i].size
+		if total > s.maxBytes {
+			os.Remove(files[i].path)
+		}
+	}
+	return nil
+}
+
+type spooledFile struct {
+	path string
+	size int64
+}
+
+// files returns the spooled files, oldest first.
+func (s *harvestSpool) files() ([]spooledFile, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var files []spooledFile
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), harvestSpoolSuffix) {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, spooledFile{path: filepath.Join(s.dir, e.Name()), size: info.Size()})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].path < files[j].path })
+	return files, nil
+}
+
+// pending returns the paths of the spooled files, oldest first.
+func (s *harvestSpool) pending() ([]string, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	files, err := s.files()
+	if err != nil {
+		return nil, err
+	}
+	paths := make([]string, len(files))
+	for i, f := range files {
+		paths[i] = f.path
+	}
+	return paths, nil
+}
+
+// read returns the endpoint, the agent run ID, and the payload of a spooled
+// file.
+func (s *harvestSpool) read(path string) (cmd, runID string, data []byte, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", "", nil, err
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return "", "", nil, err
+	}
+	defer zr.Close()
+	data, err = io.ReadAll(zr)
+	if err != nil {
+		return "", "", nil, err
+	}
+	return zr.Name, zr.Comment, data, nil
+}
+
+func (s *harvestSpool) remove(path string) {
+	s.Lock()
+	defer s.Unlock()
+	os.Remove(path)
+}
+
+// replaceRunID replaces the agent run ID found at the start of most payloads,
+// which is no longer valid once the application has reconnected.
+func replaceRunID(data []byte, oldRunID, newRunID string) []byte {
+	prefix := []byte(`["` + oldRunID + `"`)
+	if oldRunID == "" || oldRunID == newRunID || !bytes.HasPrefix(data, prefix) {
+		return data
+	}
+	replaced := make([]byte, 0, len(data)-len(oldRunID)+len(newRunID))
+	replaced = append(replaced, `["`+newRunID+`"`...)
+	return append(replaced, data[len(prefix):]...)
+}
+
+// replaySpool sends at most maxSpoolReplayFiles of the spooled payloads,
+// oldest first, until a request fails in a way which means the collector is
+// still unavailable.  The remaining payloads are sent by the following
+// harvests, so that a full spool does not hold up the harvest.  Payloads
+// which are sent or rejected by the collector are removed.  Only one replay
+// runs at a time.
+func (app *app) replaySpool(run *appRun) {
+	s := app.spool
+	if !atomic.CompareAndSwapInt32(&s.replaying, 0, 1) {
+		return
+	}
+	defer atomic.StoreInt32(&s.replaying, 0)
+
+	paths, err := s.pending()
+	if err != nil {
+		app.Warn("unable to list spooled harvest data", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+	if len(paths) > maxSpoolReplayFiles {
+		paths = paths[:maxSpoolReplayFiles]
+	}
+	for _, path := range paths {
+		cmd, runID, data, err := s.read(path)
+		if err != nil {
+			app.Warn("unable to read spooled harvest data", map[string]interface{}{
+				"file":  path,
+				"error": err.Error(),
+			})
+			s.remove(path)
+			continue
+		}
+
+		call := rpmCmd{
+			Collector:         run.Reply.Collector,
+			RunID:             run.Reply.RunID.String(),
+			Name:              cmd,
+			Data:              replaceRunID(data, runID, run.Reply.RunID.String()),
+			RequestHeadersMap: run.Reply.RequestHeadersMap,
+			MaxPayloadSize:    run.Reply.MaxPayloadSizeInBytes,
+		}
+		resp := collectorRequest(call, app.rpmControls)
+		app.stats.recordHarvestRequest(cmd, resp.GetError() == nil, time.Now())
+
+		if resp.IsDisconnect() || resp.IsRestartException() || resp.ShouldSaveHarvestData() {
+			return
+		}
+		if resp.GetError() != nil {
+			app.Warn("spooled harvest data rejected", map[string]interface{}{
+				"cmd":   cmd,
+				"error": resp.GetError().Error(),
+			})
+		}
+		s.remove(path)
+	}
+}
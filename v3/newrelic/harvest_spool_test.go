@@ -0,0 +1,282 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package newrelic
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/newrelic/go-agent/v3/internal"
+	"github.com/newrelic/go-agent/v3/internal/logger"
+)
+
+func TestHarvestSpoolWriteRead(t *testing.T) {
+	s := newHarvestSpool(filepath.Join(t.TempDir(), "spool"), 0)
+	if err := s.write("metric_data", "run1", []byte(`["run1",1,2,[]]`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.write("error_data", "run1", []byte(`["run1",[]]`)); err != nil {
+		t.Fatal(err)
+	}
+	paths, err := s.pending()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) != 2 {
+		t.Fatal("incorrect number of spooled files:", paths)
+	}
+	cmd, runID, data, err := s.read(paths[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cmd != "metric_data" || runID != "run1" || string(data) != `["run1",1,2,[]]` {
+		t.Errorf("incorrect spooled payload: %s %s %s", cmd, runID, data)
+	}
+	s.remove(paths[0])
+	if paths, _ := s.pending(); len(paths) != 1 {
+		t.Error("spooled file not removed:", paths)
+	}
+}
+
+func TestHarvestSpoolTrim(t *testing.T) {
+	dir := t.TempDir()
+	s := newHarvestSpool(dir, 0)
+	if err := s.write("metric_data", "run1", []byte("oldest")); err != nil {
+		t.Fatal(err)
+	}
+	paths, _ := s.pending()
+	info, err := os.Stat(paths[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Only two files of this size fit.
+	s.maxBytes = 2*info.Size() + 1
+	for _, data := range []string{"middle", "newest"} {
+		if err := s.write("metric_data", "run1", []byte(data)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	paths, _ = s.pending()
+	if len(paths) != 2 {
+		t.Fatal("incorrect number of spooled files:", paths)
+	}
+	if _, _, data, _ := s.read(paths[0]); string(data) != "middle" {
+		t.Error("oldest file not removed:", string(data))
+	}
+
+	s.maxBytes = 1
+	if err := s.write("metric_data", "run1", []byte("large")); err != errHarvestSpoolPayloadTooLarge {
+		t.Error("incorrect error for large payload:", err)
+	}
+}
+
+func TestReplaceRunID(t *testing.T) {
+	for _, tc := range []struct {
+		data, oldRunID, want string
+	}{
+		{data: `["run1",1,2,[]]`, oldRunID: "run1", want: `["run2",1,2,[]]`},
+		{data: `["run2",1,2,[]]`, oldRunID: "run2", want: `["run2",1,2,[]]`},
+		{data: `[[["name"]]]`, oldRunID: "run1", want: `[[["name"]]]`},
+		{data: `["run10",[]]`, oldRunID: "run1", want: `["run10",[]]`},
+	} {
+		if got := string(replaceRunID([]byte(tc.data), tc.oldRunID, "run2")); got != tc.want {
+			t.Errorf("replaceRunID(%s, %s) = %s, want %s", tc.data, tc.oldRunID, got, tc.want)
+		}
+	}
+}
+
+// spoolCollector is a collector whose responses can be changed, recording the
+// run IDs and payloads of the requests it receives.
+type spoolCollector struct {
+	sync.Mutex
+	statusCode int
+	requests   []string
+}
+
+func (c *spoolCollector) RoundTrip(r *http.Request) (*http.Response, error) {
+	zr, err := gzip.NewReader(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, err
+	}
+	c.Lock()
+	defer c.Unlock()
+	c.requests = append(c.requests, r.URL.Query().Get("method")+" "+r.URL.Query().Get("run_id")+" "+string(body))
+	return &http.Response{
+		StatusCode: c.statusCode,
+		Body:       io.NopCloser(strings.NewReader("{}")),
+	}, nil
+}
+
+func (c *spoolCollector) respond(statusCode int) []string {
+	c.Lock()
+	defer c.Unlock()
+	requests := c.requests
+	c.requests = nil
+	c.statusCode = statusCode
+	return requests
+}
+
+func TestHarvestSpoolReplay(t *testing.T) {
+	dir := t.TempDir()
+	app := testApp(nil, ConfigHarvestSpool(dir), t)
+	collector := &spoolCollector{statusCode: 503}
+	app.app.rpmControls = rpmControls{
+		License: "the_license",
+		Client:  &http.Client{Transport: collector},
+		Logger:  logger.ShimLogger{},
+		GzipWriterPool: &sync.Pool{
+			New: func() interface{} {
+				return gzip.NewWriter(io.Discard)
+			},
+		},
+	}
+	newRun := func(runID string) *appRun {
+		reply := internal.ConnectReplyDefaults()
+		reply.RunID = internal.AgentRunID(runID)
+		return newAppRun(app.app.config, reply)
+	}
+
+	now := time.Now()
+	h := newHarvest(now, testHarvestCfgr)
+	h.Metrics.addCount("Custom/spooled", 1, forced)
+	app.app.doHarvest(h, now, newRun("run1"))
+
+	if requests := collector.respond(200); len(requests) != 1 || !strings.HasPrefix(requests[0], "metric_data run1 ") {
+		t.Fatal("incorrect requests:", requests)
+	}
+	paths, _ := app.app.spool.pending()
+	if len(paths) != 1 {
+		t.Fatal("payload not spooled:", paths)
+	}
+
+	// The spooled payload is sent after the first successful harvest, with
+	// the run ID of the new run.
+	app.app.doHarvest(newHarvest(now, testHarvestCfgr), now, newRun("run2"))
+	requests := collector.respond(200)
+	if len(requests) != 2 {
+		t.Fatal("incorrect requests:", requests)
+	}
+	if !strings.HasPrefix(requests[1], `metric_data run2 ["run2",`) || !strings.Contains(requests[1], "Custom/spooled") {
+		t.Error("spooled payload not replayed:", requests[1])
+	}
+	if paths, _ := app.app.spool.pending(); len(paths) != 0 {
+		t.Error("replayed payload not removed:", paths)
+	}
+}
+
+func TestHarvestSpoolReplayStopsWhenUnavailable(t *testing.T) {
+	app := testApp(nil, ConfigHarvestSpool(t.TempDir()), t)
+	collector := &spoolCollector{statusCode: 503}
+	// The test application is disabled, and so has no collector client.
+	app.app.rpmControls = newRPMControls(app.app.config)
+	app.app.rpmControls.Client = &http.Client{Transport: collector}
+	for _, data := range []string{`["run1",1]`, `["run1",2]`} {
+		if err := app.app.spool.write("metric_data", "run1", []byte(data)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	reply := internal.ConnectReplyDefaults()
+	reply.RunID = "run2"
+	app.app.replaySpool(newAppRun(app.app.config, reply))
+
+	if requests := collector.respond(400); len(requests) != 1 {
+		t.Error("replay not stopped after failure:", requests)
+	}
+	if paths, _ := app.app.spool.pending(); len(paths) != 2 {
+		t.Error("spooled files removed after failure:", paths)
+	}
+
+	// Payloads rejected by the collector are removed.
+	app.app.replaySpool(newAppRun(app.app.config, reply))
+	if requests := collector.respond(200); len(requests) != 2 {
+		t.Error("incorrect requests:", requests)
+	}
+	if paths, _ := app.app.spool.pending(); len(paths) != 0 {
+		t.Error("rejected payloads not removed:", paths)
+	}
+}
+
+func TestHarvestSpoolReplayLimit(t *testing.T) {
+	app := testApp(nil, ConfigHarvestSpool(t.TempDir()), t)
+	collector := &spoolCollector{statusCode: 200}
+	app.app.rpmControls = newRPMControls(app.app.config)
+	app.app.rpmControls.Client = &http.Client{Transport: collector}
+	for i := 0; i < maxSpoolReplayFiles+1; i++ {
+		if err := app.app.spool.write("metric_data", "run1", []byte(`["run1",1]`)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	reply := internal.ConnectReplyDefaults()
+	reply.RunID = "run2"
+	app.app.replaySpool(newAppRun(app.app.config, reply))
+	if requests := collector.respond(200); len(requests) != maxSpoolReplayFiles {
+		t.Error("incorrect number of requests:", len(requests))
+	}
+	if paths, _ := app.app.spool.pending(); len(paths) != 1 {
+		t.Error("incorrect number of remaining files:", paths)
+	}
+}
+
+func TestHarvestSpoolDir(t *testing.T) {
+	dir := t.TempDir()
+	cfg := func(license, appName string) config {
+		c := config{Config: defaultConfig()}
+		c.License = license
+		c.AppName = appName
+		c.HarvestSpool.Directory = dir
+		return c
+	}
+	a := harvestSpoolDir(cfg("license1", "app1"))
+	if filepath.Dir(a) != dir {
+		t.Error("spool not in a subdirectory of the directory:", a)
+	}
+	if a != harvestSpoolDir(cfg("license1", "app1")) {
+		t.Error("spool directory not stable")
+	}
+	if a == harvestSpoolDir(cfg("license1", "app2")) {
+		t.Error("applications with different names share a spool")
+	}
+	if a == harvestSpoolDir(cfg("license2", "app1")) {
+		t.Error("applications with different licenses share a spool")
+	}
+
+	// An application does not replay the payloads spooled by another
+	// application sharing the directory.
+	app := testApp(nil, ConfigHarvestSpool(dir), t)
+	if err := newHarvestSpool(a, 0).write("metric_data", "run1", []byte(`["run1",1]`)); err != nil {
+		t.Fatal(err)
+	}
+	if paths, _ := app.app.spool.pending(); len(paths) != 0 {
+		t.Error("payloads of another application pending:", paths)
+	}
+}
+
+func TestHarvestSpoolConfig(t *testing.T) {
+	cfg := defaultConfig()
+	cfg.License = "0123456789012345678901234567890123456789"
+	cfg.AppName = "app"
+	cfg.HarvestSpool.Enabled = true
+	if err := cfg.validate(); err != errHarvestSpoolDirectory {
+		t.Error("incorrect error for missing directory:", err)
+	}
+
+	app := testApp(nil, nil, t)
+	if app.app.spool != nil {
+		t.Error("spool created when disabled")
+	}
+}
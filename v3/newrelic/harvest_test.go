@@ -180,8 +180,8 @@ func TestCreateFinalMetrics(t *testing.T) {
 }
 
 func TestCreateFinalMetricsTraceObserver(t *testing.T) {
-	if !versionSupports8T {
-		t.Skip("go version does not support 8T")
+	if !infiniteTracingIncluded {
+		t.Skip("Infinite Tracing is excluded from the build")
 	}
 
 	replyJSON := []byte(`{"return_value":{}}`)
@@ -303,7 +303,43 @@ func TestHarvestCustomEventsReady(t *testing.T) {
 	})
 }
 
+func TestHarvestCustomEventQuotas(t *testing.T) {
+	now := time.Now()
+	fixedHarvestTypes := harvestMetricsTraces & harvestTxnEvents & harvestSpanEvents & harvestErrorEvents
+	h := newHarvest(now, harvestConfig{
+		ReportPeriods: map[harvestTypes]time.Duration{
+			fixedHarvestTypes:   fixedHarvestPeriod,
+			harvestCustomEvents: time.Second * 5,
+		},
+		MaxCustomEvents:   10,
+		CustomEventQuotas: map[string]int{"noisyEvent": 1},
+	})
+	for _, eventType := range []string{"noisyEvent", "noisyEvent", "noisyEvent", "myEvent"} {
+		ce, _ := createCustomEvent(eventType, nil, time.Now())
+		h.CustomEvents.Add(ce)
+	}
+	ready := h.Ready(now.Add(10 * time.Second))
+	if n := ready.CustomEvents.NumSaved(); n != 2 {
+		t.Error(n)
+	}
+	expectMetrics(t, h.Metrics, []internal.WantMetric{
+		{Name: customEventsSeen, Scope: "", Forced: true, Data: []float64{2, 0, 0, 0, 0, 0}},
+		{Name: customEventsSent, Scope: "", Forced: true, Data: []float64{2, 0, 0, 0, 0, 0}},
+		{Name: customEventsDropped + "noisyEvent", Scope: "", Forced: true, Data: []float64{2, 0, 0, 0, 0, 0}},
+	})
+	// The quota is reset for the next harvest cycle.
+	ce, _ := createCustomEvent("noisyEvent", nil, time.Now())
+	h.CustomEvents.Add(ce)
+	if n := h.CustomEvents.NumSaved(); n != 1 {
+		t.Error(n)
+	}
+}
+
 func TestHarvestLogEventsReady(t *testing.T) {
+	if !logForwardingIncluded {
+		t.Skip("log forwarding is excluded from the build")
+	}
+
 	now := time.Now()
 	fixedHarvestTypes := harvestMetricsTraces & harvestTxnEvents & harvestSpanEvents & harvestLogEvents
 	h := newHarvest(now, harvestConfig{
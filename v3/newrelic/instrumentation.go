@@ -4,7 +4,12 @@
 package newrelic
 
 import (
+	"context"
+	"io"
 	"net/http"
+	"net/http/httptrace"
+	"os"
+	"strings"
 
 	"github.com/newrelic/go-agent/v3/internal"
 )
@@ -34,6 +39,11 @@ import (
 // WrapHandle accepts zero or more TraceOption functions to allow additional options to be
 // manually added to the transaction trace generated, in the same fashion as StartTransaction
 // does. For example, this can be used to control code level metrics generated for this transaction.
+// The per-route options WithTransactionName, WithIgnore, WithSampled, and
+// WithAttributeInjector may also be used to customize the transactions
+// created for a single route:
+//
+//	http.Handle(newrelic.WrapHandle(app, "/healthz", healthHandler, newrelic.WithIgnore()))
 func WrapHandle(app *Application, pattern string, handler http.Handler, options ...TraceOption) (string, http.Handler) {
 	if app == nil {
 		return pattern, handler
@@ -48,40 +58,20 @@ func WrapHandle(app *Application, pattern string, handler http.Handler, options
 		secureAgent.SendEvent("API_END_POINTS", pattern, "*", internal.HandlerName(handler))
 	}
 
+	routeOptions := resolveTraceOptions(options)
+	name := routeOptions.TransactionName
+
 	return pattern, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		var tOptions *traceOptSet
-		var txnOptionList []TraceOption
-
-		if app.app != nil {
-			run, _ := app.app.getState()
-			if run != nil && run.Config.CodeLevelMetrics.Enabled {
-				tOptions = resolveCLMTraceOptions(options)
-				if tOptions != nil && !tOptions.SuppressCLM && (tOptions.DemandCLM || run.Config.CodeLevelMetrics.Scope == 0 || (run.Config.CodeLevelMetrics.Scope&TransactionCLM) != 0) {
-					// we are for sure collecting CLM here, so go to the trouble of collecting this code location if nothing else has yet.
-					if tOptions.LocationOverride == nil {
-						if loc, err := cache.FunctionLocation(handler, handler.ServeHTTP); err == nil {
-							WithCodeLocation(loc)(tOptions)
-						}
-					}
-				}
-			}
-		}
-		if tOptions == nil {
-			// we weren't able to curate the options above, so pass whatever we were given downstream
-			txnOptionList = options
-		} else {
-			txnOptionList = append(txnOptionList, withPreparedOptions(tOptions))
+		txnName := name
+		if txnName == "" {
+			txnName = r.Method + " " + pattern
 		}
-
-		txn := app.StartTransaction(r.Method+" "+pattern, txnOptionList...)
+		txn, w, r := startHandlerTransaction(app, txnName, w, r,
+			handlerTraceOptions(app, handler, cache, options, routeOptions), routeOptions)
 		defer txn.End()
 		if IsSecurityAgentPresent() {
 			txn.SetCsecAttributes(AttributeCsecRoute, pattern)
 		}
-		w = txn.SetWebResponse(w)
-		txn.SetWebRequestHTTP(r)
-
-		r = RequestWithTransactionContext(r, txn)
 
 		handler.ServeHTTP(w, r)
 		if IsSecurityAgentPresent() {
@@ -90,6 +80,52 @@ func WrapHandle(app *Application, pattern string, handler http.Handler, options
 	})
 }
 
+// handlerTraceOptions returns the options used to start the transaction for
+// a request served by handler, adding the handler's code location when code
+// level metrics are collected.
+func handlerTraceOptions(app *Application, handler http.Handler, cache *CachedCodeLocation, options []TraceOption, routeOptions *traceOptSet) []TraceOption {
+	var tOptions *traceOptSet
+
+	if app.app != nil {
+		run, _ := app.app.getState()
+		if run != nil && run.Config.CodeLevelMetrics.Enabled {
+			tOptions = resolveCLMTraceOptions(options)
+			if tOptions != nil && !tOptions.SuppressCLM && (tOptions.DemandCLM || run.Config.CodeLevelMetrics.Scope == 0 || (run.Config.CodeLevelMetrics.Scope&TransactionCLM) != 0) {
+				// we are for sure collecting CLM here, so go to the trouble of collecting this code location if nothing else has yet.
+				if tOptions.LocationOverride == nil {
+					if loc, err := cache.FunctionLocation(handler, handler.ServeHTTP); err == nil {
+						WithCodeLocation(loc)(tOptions)
+					}
+				}
+			}
+		}
+	}
+	if tOptions == nil {
+		// we weren't able to curate the options above, so pass whatever we were given downstream
+		return options
+	}
+	// resolveCLMTraceOptions may stop early, so restore the
+	// per-route options resolved above.
+	tOptions.IgnoreTransaction = routeOptions.IgnoreTransaction
+	tOptions.SampledOverride = routeOptions.SampledOverride
+	return []TraceOption{withPreparedOptions(tOptions)}
+}
+
+// startHandlerTransaction starts a web transaction for the request and
+// returns the transaction along with the response writer and request which
+// should be passed to the handler.
+func startHandlerTransaction(app *Application, name string, w http.ResponseWriter, r *http.Request, options []TraceOption, routeOptions *traceOptSet) (*Transaction, http.ResponseWriter, *http.Request) {
+	txn := app.StartTransaction(name, options...)
+	w = txn.SetWebResponse(w)
+	txn.SetWebRequestHTTP(r)
+	for _, injector := range routeOptions.AttributeInjectors {
+		for key, val := range injector(r) {
+			txn.AddAttribute(key, val)
+		}
+	}
+	return txn, w, RequestWithTransactionContext(r, txn)
+}
+
 // AddCodeLevelMetricsTraceOptions adds trace options to an existing slice of TraceOption objects depending on how code level metrics is configured
 // in your application.
 // Please call cache:=newrelic.NewCachedCodeLocation() before calling this function, and pass the cache to us in order to allow you to optimize the
@@ -161,6 +197,229 @@ func WrapHandleFunc(app *Application, pattern string, handler func(http.Response
 	return p, func(w http.ResponseWriter, r *http.Request) { h.ServeHTTP(w, r) }
 }
 
+// serverlessOutput is where ServerlessHandler and ServerlessJob write the
+// data recorded in ServerlessMode.  It is a variable for testing.
+var serverlessOutput io.Writer = os.Stdout
+
+// serverlessFlush writes the data recorded in ServerlessMode.  It does
+// nothing if ServerlessMode is not enabled.
+func serverlessFlush(app *Application) {
+	if nil != app.app {
+		app.app.ServerlessWrite("", serverlessOutput)
+	}
+}
+
+// ServerlessHandler instruments an HTTP handler running on a serverless
+// platform outside of AWS Lambda, such as a Google Cloud Functions (2nd gen)
+// function or a Cloud Run service, with ServerlessMode enabled.  Each request
+// is recorded as a transaction with the given name, and the data is written
+// to stdout as soon as the transaction ends, since the platform may throttle
+// or stop the instance once the response has been sent:
+//
+//	functions.HTTP("HelloHTTP", newrelic.ServerlessHandler(app, "HelloHTTP",
+//		http.HandlerFunc(helloHTTP)).ServeHTTP)
+//
+// The ServerlessHandler function is safe to call if app is nil.  If
+// ServerlessMode is not enabled, the data is harvested normally.
+func ServerlessHandler(app *Application, name string, handler http.Handler) http.Handler {
+	if nil == app {
+		return handler
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		txn, w, r := startHandlerTransaction(app, name, w, r, nil, resolveTraceOptions(nil))
+		defer serverlessFlush(app)
+		defer txn.End()
+
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// ServerlessJob instruments a job running on a serverless platform outside
+// of AWS Lambda, such as a Cloud Run job task, with ServerlessMode enabled.
+// The returned function records each run of the job as a background
+// transaction with the given name, records the error returned by the job, and
+// writes the data to stdout before returning:
+//
+//	run := newrelic.ServerlessJob(app, "nightly-export", export)
+//	if err := run(context.Background()); nil != err {
+//		os.Exit(1)
+//	}
+//
+// The transaction is added to the context passed to the job.  The
+// ServerlessJob function is safe to call if app is nil.  If ServerlessMode is
+// not enabled, the data is harvested normally.
+func ServerlessJob(app *Application, name string, job func(context.Context) error) func(context.Context) error {
+	if nil == app {
+		return job
+	}
+	return func(ctx context.Context) error {
+		txn := app.StartTransaction(name)
+		defer serverlessFlush(app)
+		defer txn.End()
+
+		err := job(NewContext(ctx, txn))
+		if nil != err {
+			txn.NoticeError(err)
+		}
+		return err
+	}
+}
+
+// AttributeInjector returns attributes to add to the transaction created for
+// a request.  See WithAttributeInjector.
+type AttributeInjector func(r *http.Request) map[string]interface{}
+
+// WithTransactionName sets the name of the transactions created by WrapHandle
+// and WrapHandleFunc.  By default, transactions are named using the request
+// method and the route pattern, such as "GET /users".
+func WithTransactionName(name string) TraceOption {
+	return func(o *traceOptSet) {
+		o.TransactionName = name
+	}
+}
+
+// WithIgnore prevents the data of the transactions created using this option
+// from being recorded, as if Transaction.Ignore had been called.  This is
+// useful for routes such as health checks which are not interesting to
+// monitor.
+func WithIgnore() TraceOption {
+	return func(o *traceOptSet) {
+		o.IgnoreTransaction = true
+	}
+}
+
+// WithSampled overrides the sampling decision of the transactions created
+// using this option.  Transactions with sampled set to true are always
+// sampled, and transactions with sampled set to false are never sampled, even
+// if an inbound distributed trace payload indicates otherwise.  This option has
+// no effect if distributed tracing is disabled.
+func WithSampled(sampled bool) TraceOption {
+	return func(o *traceOptSet) {
+		o.SampledOverride = &sampled
+	}
+}
+
+// WithAttributeInjector adds a function which is called with each request
+// handled by WrapHandle and WrapHandleFunc.  The attributes it returns are
+// added to the transaction using Transaction.AddAttribute.  This option may
+// be provided more than once.
+func WithAttributeInjector(injector AttributeInjector) TraceOption {
+	return func(o *traceOptSet) {
+		if nil != injector {
+			o.AttributeInjectors = append(o.AttributeInjectors, injector)
+		}
+	}
+}
+
+// TransactionNamer returns the name of the transaction created for a request
+// by NewMiddleware, or "" if it has no name for the request.  See
+// WithTransactionNamer.
+type TransactionNamer func(r *http.Request) string
+
+// WithTransactionNamer sets a function naming the transactions created by
+// NewMiddleware, once the request has been handled.  Use it with routers
+// other than http.ServeMux to name transactions after the route matched,
+// which the router usually stores in the request's context:
+//
+//	namer := func(r *http.Request) string {
+//		return r.Method + " " + chi.RouteContext(r.Context()).RoutePattern()
+//	}
+//	r.Use(newrelic.NewMiddleware(app, newrelic.WithTransactionNamer(namer)))
+//
+// The name returned by the namer takes precedence over the ServeMux pattern,
+// and the transaction keeps its name if the namer returns "".
+func WithTransactionNamer(namer TransactionNamer) TraceOption {
+	return func(o *traceOptSet) {
+		o.TransactionNamer = namer
+	}
+}
+
+// resolveTraceOptions evaluates every option in the list.  Unlike
+// resolveCLMTraceOptions, it does not stop when code level metrics are
+// suppressed.
+func resolveTraceOptions(options []TraceOption) *traceOptSet {
+	optSet := traceOptSet{}
+	for _, o := range options {
+		o(&optSet)
+	}
+	return &optSet
+}
+
+// NewMiddleware returns middleware which instruments each request handled by
+// the next handler with a Transaction.  It can be used with standard
+// middleware chains such as alice, negroni, or chi's Use method, so that
+// handlers do not have to be wrapped one at a time using WrapHandle:
+//
+//	mux := http.NewServeMux()
+//	mux.HandleFunc("GET /users/{id}", usersHandler)
+//	http.ListenAndServe(":8000", newrelic.NewMiddleware(app)(mux))
+//
+// Like WrapHandle, the middleware adds the Transaction to the request's
+// context.  Transactions are named using the request method only, since URL
+// paths may contain identifiers which would create too many unique
+// transaction names.  When built with Go 1.23 or later and the next handler
+// is an http.ServeMux, the transaction is renamed after the pattern matched
+// by the ServeMux once the request has been handled, such as
+// "GET /users/{id}".  With other routers, use WithTransactionNamer to name
+// transactions after the route matched, WithTransactionName, or call
+// Transaction.SetName in the handler.
+//
+// NewMiddleware accepts the same options as WrapHandle.  NewMiddleware is
+// safe to call if app is nil.
+func NewMiddleware(app *Application, options ...TraceOption) func(http.Handler) http.Handler {
+	routeOptions := resolveTraceOptions(options)
+	return func(next http.Handler) http.Handler {
+		if app == nil {
+			return next
+		}
+		cache := NewCachedCodeLocation()
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			name := routeOptions.TransactionName
+			if name == "" {
+				name = r.Method
+			}
+			txn, w, rr := startHandlerTransaction(app, name, w, r,
+				handlerTraceOptions(app, next, cache, options, routeOptions), routeOptions)
+			defer txn.End()
+
+			next.ServeHTTP(w, rr)
+			if routeOptions.TransactionName == "" {
+				if name := routeTransactionName(rr, routeOptions.TransactionNamer); name != "" {
+					txn.SetName(name)
+				}
+			}
+			if IsSecurityAgentPresent() {
+				secureAgent.SendEvent("RESPONSE_HEADER", w.Header())
+			}
+		})
+	}
+}
+
+// routeTransactionName returns the name of the route matched for a handled
+// request, using the namer if it has one, or the ServeMux pattern.  It
+// returns "" if the route is unknown.
+func routeTransactionName(r *http.Request, namer TransactionNamer) string {
+	if nil != namer {
+		if name := namer(r); name != "" {
+			return name
+		}
+	}
+	if pattern := requestPattern(r); pattern != "" {
+		return patternTransactionName(r.Method, pattern)
+	}
+	return ""
+}
+
+// patternTransactionName returns the transaction name for a ServeMux
+// pattern.  Patterns that do not begin with a method are prefixed with the
+// request method to match the names created by WrapHandle.
+func patternTransactionName(method, pattern string) string {
+	if i := strings.IndexAny(pattern, " \t"); i > 0 && !strings.Contains(pattern[:i], "/") {
+		return pattern
+	}
+	return method + " " + pattern
+}
+
 // WrapListen wraps an HTTP endpoint reference passed to functions like http.ListenAndServe,
 // which causes security scanning to be done for that incoming endpoint when vulnerability
 // scanning is enabled. It returns the endpoint string, so you can replace a call like
@@ -182,15 +441,26 @@ func WrapListen(endpoint string) string {
 // an external segment before delegating to the original http.RoundTripper
 // provided (or http.DefaultTransport if none is provided).  The
 // http.RoundTripper will look for a Transaction in the request's context
-// (using FromContext).
-func NewRoundTripper(original http.RoundTripper) http.RoundTripper {
+// (using FromContext).  Use WithClientTrace to also record the time spent in
+// each phase of the request.
+func NewRoundTripper(original http.RoundTripper, opts ...RoundTripperOption) http.RoundTripper {
 	if nil == original {
 		original = http.DefaultTransport
 	}
+	var cfg roundTripperConfig
+	for _, opt := range opts {
+		if nil != opt {
+			opt(&cfg)
+		}
+	}
 	return roundTripperFunc(func(request *http.Request) (*http.Response, error) {
 		// The specification of http.RoundTripper requires that the request is never modified.
 		request = cloneRequest(request)
 		segment := StartExternalSegment(nil, request)
+		if cfg.clientTrace && nil != segment.StartTime.thread {
+			ctx := httptrace.WithClientTrace(request.Context(), segment.ClientTrace())
+			request = request.WithContext(ctx)
+		}
 
 		response, err := original.RoundTrip(request)
 
@@ -192,7 +192,80 @@ func TestRoundTripper(t *testing.T) {
 	}})
 }
 
+func TestRoundTripperExcludedHosts(t *testing.T) {
+	app := testApp(distributedTracingReplyFields, func(cfg *Config) {
+		enableBetterCAT(cfg)
+		cfg.DistributedTracer.ExcludedHosts = []string{"payments.example.com", "*.example.org"}
+	}, t)
+	txn := app.StartTransaction("hello")
+	for url, excluded := range map[string]bool{
+		"http://payments.example.com/charge":      true,
+		"http://PAYMENTS.example.com:8080/charge": true,
+		"http://api.example.org/":                 true,
+		"http://example.org/":                     false,
+		"http://example.com/":                     false,
+	} {
+		req, err := http.NewRequest("GET", url, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		client := &http.Client{
+			Transport: NewRoundTripper(roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+				nrHdr := r.Header.Get(DistributedTraceNewRelicHeader)
+				tpHdr := r.Header.Get(DistributedTraceW3CTraceParentHeader)
+				if excluded && (nrHdr != "" || tpHdr != "") {
+					t.Error("headers unexpectedly present", url, r.Header)
+				}
+				if !excluded && (nrHdr == "" || tpHdr == "") {
+					t.Error("headers missing", url, r.Header)
+				}
+				return &http.Response{StatusCode: 200}, nil
+			})),
+		}
+		if _, err := client.Do(RequestWithTransactionContext(req, txn)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	txn.End()
+	app.ExpectMetricsPresent(t, []internal.WantMetric{
+		{Name: "External/payments.example.com/http/GET", Scope: "OtherTransaction/Go/hello"},
+		{Name: "External/api.example.org/http/GET", Scope: "OtherTransaction/Go/hello"},
+	})
+}
+
+func TestRoundTripperWithoutOutboundHeaders(t *testing.T) {
+	app := testApp(distributedTracingReplyFields, enableBetterCAT, t)
+	txn := app.StartTransaction("hello")
+	req, err := http.NewRequest("GET", "http://example.com/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req = req.WithContext(WithoutOutboundHeaders(NewContext(req.Context(), txn)))
+	client := &http.Client{
+		Transport: NewRoundTripper(roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+			if hdr := r.Header.Get(DistributedTraceNewRelicHeader); hdr != "" {
+				t.Error("newrelic header unexpectedly present", hdr)
+			}
+			if hdr := r.Header.Get(DistributedTraceW3CTraceParentHeader); hdr != "" {
+				t.Error("traceparent header unexpectedly present", hdr)
+			}
+			return &http.Response{StatusCode: 200}, nil
+		})),
+	}
+	if _, err := client.Do(req); err != nil {
+		t.Fatal(err)
+	}
+	txn.End()
+	app.ExpectMetricsPresent(t, []internal.WantMetric{
+		{Name: "External/example.com/http/GET", Scope: "OtherTransaction/Go/hello"},
+	})
+}
+
 func TestRoundTripperOldCAT(t *testing.T) {
+	if !crossAppTracingIncluded {
+		t.Skip("Cross Application Tracing is excluded from the build")
+	}
+
 	cfgfn := func(c *Config) {
 		c.DistributedTracer.Enabled = false
 		c.CrossApplicationTracer.Enabled = true
@@ -259,3 +332,189 @@ func TestRoundTripperRace(t *testing.T) {
 	go client.Do(req)
 	go client.Do(req)
 }
+
+func TestWrapHandleWithTransactionName(t *testing.T) {
+	app := testApp(nil, ConfigDistributedTracerEnabled(false), t)
+	mux := http.NewServeMux()
+	mux.Handle(WrapHandle(app.Application, helloPath, http.HandlerFunc(myErrorHandler),
+		WithTransactionName("hello-route")))
+	w := newCompatibleResponseRecorder()
+	mux.ServeHTTP(w, helloRequest)
+
+	app.ExpectErrors(t, []internal.WantError{{
+		TxnName: "WebTransaction/Go/hello-route",
+		Msg:     "my msg",
+		Klass:   "newrelic.myError",
+	}})
+}
+
+func TestWrapHandleFuncWithIgnore(t *testing.T) {
+	app := testApp(nil, ConfigDistributedTracerEnabled(false), t)
+	mux := http.NewServeMux()
+	mux.HandleFunc(WrapHandleFunc(app.Application, helloPath, myErrorHandler, WithIgnore()))
+	w := newCompatibleResponseRecorder()
+	mux.ServeHTTP(w, helloRequest)
+
+	if out := w.Body.String(); "my response" != out {
+		t.Error(out)
+	}
+	app.ExpectTxnEvents(t, []internal.WantEvent{})
+	app.ExpectErrors(t, []internal.WantError{})
+}
+
+func TestWrapHandleWithIgnoreAfterCodeLevelMetricsSuppressed(t *testing.T) {
+	app := testApp(nil, func(cfg *Config) {
+		cfg.DistributedTracer.Enabled = false
+		cfg.CodeLevelMetrics.Enabled = true
+	}, t)
+	mux := http.NewServeMux()
+	mux.Handle(WrapHandle(app.Application, helloPath, http.HandlerFunc(myErrorHandler),
+		WithoutCodeLevelMetrics(), WithIgnore()))
+	w := newCompatibleResponseRecorder()
+	mux.ServeHTTP(w, helloRequest)
+
+	app.ExpectTxnEvents(t, []internal.WantEvent{})
+}
+
+func TestWrapHandleWithAttributeInjector(t *testing.T) {
+	app := testApp(nil, ConfigDistributedTracerEnabled(false), t)
+	mux := http.NewServeMux()
+	mux.Handle(WrapHandle(app.Application, helloPath, http.HandlerFunc(myErrorHandler),
+		WithAttributeInjector(func(r *http.Request) map[string]interface{} {
+			return map[string]interface{}{"route.method": r.Method}
+		}),
+		WithAttributeInjector(func(r *http.Request) map[string]interface{} {
+			return map[string]interface{}{"route.host": r.Host}
+		})))
+	w := newCompatibleResponseRecorder()
+	mux.ServeHTTP(w, helloRequest)
+
+	app.ExpectTxnEvents(t, []internal.WantEvent{{
+		UserAttributes: map[string]interface{}{
+			"route.method": "GET",
+			"route.host":   helloRequest.Host,
+		},
+	}})
+}
+
+func TestWrapHandleWithSampledFalse(t *testing.T) {
+	app := testApp(distributedTracingReplyFields, enableBetterCAT, t)
+	mux := http.NewServeMux()
+	mux.Handle(WrapHandle(app.Application, helloPath, http.HandlerFunc(myErrorHandler),
+		WithSampled(false)))
+	w := newCompatibleResponseRecorder()
+	mux.ServeHTTP(w, helloRequest)
+
+	app.ExpectSpanEvents(t, []internal.WantEvent{})
+}
+
+func TestStartTransactionWithSampledTrue(t *testing.T) {
+	replyfn := func(reply *internal.ConnectReply) {
+		distributedTracingReplyFields(reply)
+		reply.SetSampleNothing()
+	}
+	app := testApp(replyfn, enableBetterCAT, t)
+	txn := app.StartTransaction("hello", WithSampled(true))
+	txn.End()
+
+	app.ExpectSpanEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"name":             "OtherTransaction/Go/hello",
+			"transaction.name": "OtherTransaction/Go/hello",
+			"sampled":          true,
+			"category":         "generic",
+			"priority":         internal.MatchAnything,
+			"guid":             internal.MatchAnything,
+			"transactionId":    internal.MatchAnything,
+			"nr.entryPoint":    true,
+			"traceId":          internal.MatchAnything,
+		},
+	}})
+}
+
+func TestNewMiddleware(t *testing.T) {
+	app := testApp(nil, ConfigDistributedTracerEnabled(false), t)
+	h := NewMiddleware(app.Application)(http.HandlerFunc(myErrorHandler))
+	w := newCompatibleResponseRecorder()
+	// helloRequest is not used, since the ServeMux tests set its pattern.
+	req, err := http.NewRequest("GET", "/hello", nil)
+	if nil != err {
+		t.Fatal(err)
+	}
+	h.ServeHTTP(w, req)
+
+	if out := w.Body.String(); "my response" != out {
+		t.Error(out)
+	}
+	// The URL path is not used, since it may contain identifiers.
+	app.ExpectErrors(t, []internal.WantError{{
+		TxnName: "WebTransaction/Go/GET",
+		Msg:     "my msg",
+		Klass:   "newrelic.myError",
+	}})
+}
+
+func TestNewMiddlewareWithTransactionNamer(t *testing.T) {
+	app := testApp(nil, ConfigDistributedTracerEnabled(false), t)
+	namer := func(r *http.Request) string {
+		if r.URL.Path == "/hello" {
+			return r.Method + " /{greeting}"
+		}
+		return ""
+	}
+	h := NewMiddleware(app.Application, WithTransactionNamer(namer))(http.HandlerFunc(myErrorHandler))
+	for _, path := range []string{"/hello", "/unknown"} {
+		req, err := http.NewRequest("GET", path, nil)
+		if nil != err {
+			t.Fatal(err)
+		}
+		h.ServeHTTP(newCompatibleResponseRecorder(), req)
+	}
+
+	app.ExpectErrors(t, []internal.WantError{{
+		TxnName: "WebTransaction/Go/GET /{greeting}",
+		Msg:     "my msg",
+		Klass:   "newrelic.myError",
+	}, {
+		TxnName: "WebTransaction/Go/GET",
+		Msg:     "my msg",
+		Klass:   "newrelic.myError",
+	}})
+}
+
+func TestNewMiddlewareWithTransactionName(t *testing.T) {
+	app := testApp(nil, ConfigDistributedTracerEnabled(false), t)
+	h := NewMiddleware(app.Application, WithTransactionName("hello-route"))(http.HandlerFunc(myErrorHandler))
+	h.ServeHTTP(newCompatibleResponseRecorder(), helloRequest)
+
+	app.ExpectErrors(t, []internal.WantError{{
+		TxnName: "WebTransaction/Go/hello-route",
+		Msg:     "my msg",
+		Klass:   "newrelic.myError",
+	}})
+}
+
+func TestNewMiddlewareNilApp(t *testing.T) {
+	h := NewMiddleware(nil)(http.HandlerFunc(myErrorHandler))
+	w := newCompatibleResponseRecorder()
+	h.ServeHTTP(w, helloRequest)
+	if out := w.Body.String(); "my response" != out {
+		t.Error(out)
+	}
+}
+
+func TestPatternTransactionName(t *testing.T) {
+	for _, tc := range []struct {
+		pattern string
+		want    string
+	}{
+		{pattern: "/users/{id}", want: "GET /users/{id}"},
+		{pattern: "GET /users/{id}", want: "GET /users/{id}"},
+		{pattern: "example.com/users/", want: "GET example.com/users/"},
+		{pattern: "POST example.com/users/", want: "POST example.com/users/"},
+	} {
+		if got := patternTransactionName("GET", tc.pattern); got != tc.want {
+			t.Errorf("patternTransactionName(%q) = %q, want %q", tc.pattern, got, tc.want)
+		}
+	}
+}
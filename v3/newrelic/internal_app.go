@@ -48,11 +48,18 @@ type app struct {
 	shutdownComplete chan struct{}
 
 	// Sends to these channels should not occur without a <-shutdownStarted
-	// select option to prevent deadlock.
+	// select option to prevent deadlock.  The channels are nil unless the
+	// application harvests data: see startHarvesting.  dataChan, like
+	// rpmControls, is nil until the application first connects.
 	dataChan           chan appData
 	collectorErrorChan chan rpmResponse
 	connectChan        chan *appRun
 
+	// harvestSlots holds a value per harvest sent in its own goroutine
+	// when Config.GoroutineBudget is set and the application has
+	// connected, and is nil otherwise.
+	harvestSlots chan struct{}
+
 	// This mutex protects both `run` and `err`, both of which should only
 	// be accessed using getState and setState.
 	sync.RWMutex
@@ -62,17 +69,63 @@ type app struct {
 	// err is non-nil if the application will never be connected again
 	// (disconnect, license exception, shutdown).
 	err error
+	// reloadedConfig is the configuration updated using UpdateConfig, or
+	// nil if it was never updated.  It should be accessed using
+	// currentConfig.
+	reloadedConfig *config
+
+	// updateConfigLock serializes the calls to UpdateConfig.
+	updateConfigLock sync.Mutex
 
 	// registered callback functions
 	llmTokenCountCallback func(string, string) int
 
+	// errorCallback is the ErrorCallback registered using
+	// SetErrorCallback.  It is protected by errorCallbackLock.
+	errorCallback     ErrorCallback
+	errorCallbackLock sync.RWMutex
+
+	// dbStats holds the databases registered using MonitorDBStats.
+	dbStats dbStatsMonitor
+
+	// stats holds the statistics exposed by MetricsHandler.
+	stats agentStats
+
+	// explainPlanner captures the query plans of slow datastore segments.
+	explainPlanner explainPlanner
+
+	// spool holds the harvest payloads which could not be sent, when
+	// HarvestSpool is enabled.
+	spool *harvestSpool
+
+	// health holds the status written to the health file, when
+	// AgentHealth is enabled.
+	health *agentHealth
+
+	// secondaryDestinations are the connections to the
+	// SecondaryDestinations, and secondaryHarvests tracks the harvests
+	// being sent to them.
+	secondaryDestinations []*secondaryDestination
+	secondaryHarvests     sync.WaitGroup
+
+	// serverSideSettings are the server-side settings of the last
+	// connection.  They are only accessed by the process goroutine.
+	serverSideSettings map[string]interface{}
+
 	serverless *serverlessHarvest
+
+	// telemetryPaused is 1 while the transmission of data is stopped by
+	// PauseTelemetry.  It must be accessed atomically.
+	telemetryPaused int32
 }
 
 func (app *app) doHarvest(h *harvest, harvestStart time.Time, run *appRun) {
 	h.CreateFinalMetrics(run, app.getObserver())
 
 	payloads := h.Payloads(app.config.DistributedTracer.Enabled)
+	app.harvestSecondaryDestinations(payloads, harvestStart)
+	unavailable := false
+	failed := false
 	for _, p := range payloads {
 		cmd := p.EndpointMethod()
 		var data []byte
@@ -112,6 +165,11 @@ func (app *app) doHarvest(h *harvest, harvestStart time.Time, run *appRun) {
 		}
 
 		resp := collectorRequest(call, app.rpmControls)
+		app.stats.recordHarvestRequest(cmd, resp.GetError() == nil, time.Now())
+		if resp.GetError() != nil {
+			failed = true
+			app.setHealth(responseHealthStatus(resp))
+		}
 
 		if resp.IsDisconnect() || resp.IsRestartException() {
 			select {
@@ -130,24 +188,61 @@ func (app *app) doHarvest(h *harvest, harvestStart time.Time, run *appRun) {
 		}
 
 		if resp.ShouldSaveHarvestData() {
+			unavailable = true
+			if app.spoolPayload(cmd, run, data) {
+				continue
+			}
 			app.Consume(run.Reply.RunID, p)
 		}
 	}
+
+	if !failed {
+		app.setHealth(healthOK)
+	}
+	if app.spool != nil && !unavailable {
+		app.replaySpool(run)
+	}
+}
+
+// spoolPayload writes a payload which could not be sent to the harvest spool,
+// and returns whether it was written.
+func (app *app) spoolPayload(cmd string, run *appRun, data []byte) bool {
+	if app.spool == nil {
+		return false
+	}
+	if err := app.spool.write(cmd, run.Reply.RunID.String(), data); err != nil {
+		app.Warn("unable to spool harvest data", map[string]interface{}{
+			"cmd":   cmd,
+			"error": err.Error(),
+		})
+		return false
+	}
+	return true
 }
 
 func (app *app) connectRoutine() {
+	if nil == app.rpmControls.Client {
+		// The collector client is created by the first connect
+		// attempt.  Later calls of connectRoutine, made after
+		// restart exceptions, reuse it.
+		app.rpmControls = newRPMControls(app.config)
+		app.rpmControls.Logger = app.Logger
+	}
 	attempts := 0
 	for {
-		reply, resp := connectAttempt(app.config, app.rpmControls)
+		cfg := app.currentConfig()
+		reply, resp := connectAttempt(cfg, app.rpmControls)
 
 		if reply != nil {
+			app.setHealth(healthOK)
 			select {
-			case app.connectChan <- newAppRun(app.config, reply):
+			case app.connectChan <- newAppRun(cfg, reply):
 			case <-app.shutdownStarted:
 			}
 			return
 		}
 
+		app.setHealth(responseHealthStatus(resp))
 		if resp.IsDisconnect() {
 			select {
 			case app.collectorErrorChan <- *resp:
@@ -241,10 +336,8 @@ func (app *app) process() {
 		select {
 		case <-harvestTicker.C:
 			if nil != run {
-				now := time.Now()
-				if ready := h.Ready(now); nil != ready {
-					go app.doHarvest(ready, now, run)
-				}
+				run = app.updatedRun(run)
+				app.startHarvest(h, time.Now(), run)
 			}
 		case d := <-app.dataChan:
 			if nil != run && run.Reply.RunID == d.id {
@@ -252,6 +345,9 @@ func (app *app) process() {
 			}
 		case timeout := <-app.initiateShutdown:
 			close(app.shutdownStarted)
+			if nil != run {
+				run = app.updatedRun(run)
+			}
 
 			// Remove the run before merging any final data to
 			// ensure a bounded number of receives from dataChan.
@@ -276,9 +372,20 @@ func (app *app) process() {
 						done = true
 					}
 				}
-				app.doHarvest(h, time.Now(), run)
+				if app.isTelemetryPaused() {
+					app.Info("telemetry paused, final harvest not sent", map[string]interface{}{
+						"app": app.config.AppName,
+					})
+				} else {
+					app.doHarvest(h, time.Now(), run)
+				}
 			}
+			app.secondaryHarvests.Wait()
 
+			if nil != app.health {
+				app.health.set(healthShutdown, true)
+				app.writeHealth(time.Now())
+			}
 			close(app.shutdownComplete)
 			app.setObserver(nil)
 			secureAgent.DeactivateSecurity()
@@ -290,6 +397,9 @@ func (app *app) process() {
 
 			if resp.IsDisconnect() {
 				app.setState(nil, resp.GetError())
+				if nil != app.health {
+					app.health.set(healthForcedDisconnect, true)
+				}
 				app.Error("application disconnected", map[string]interface{}{
 					"app": app.config.AppName,
 				})
@@ -301,6 +411,9 @@ func (app *app) process() {
 				go app.connectRoutine()
 			}
 		case run = <-app.connectChan:
+			if nil == app.dataChan {
+				app.startConnected()
+			}
 			if shouldUseTraceObserver(run.Config) {
 				app.connectTraceObserver(run.Reply)
 			} else if shouldUseTraceObserver(app.config) {
@@ -316,6 +429,7 @@ func (app *app) process() {
 				hostname:   app.config.hostname,
 				entityName: app.config.AppName,
 				entityGUID: run.Reply.EntityGUID,
+				kubernetes: app.config.kubernetesAttributes,
 			}
 
 			h = newHarvest(time.Now(), run.harvestConfig)
@@ -326,7 +440,10 @@ func (app *app) process() {
 				"run": run.Reply.RunID.String(),
 			})
 			processConnectMessages(run, app)
-			secureAgent.RefreshState(getLinkedMetaData(app))
+			app.notifyServerSideConfig(run)
+			if IsSecurityAgentPresent() {
+				secureAgent.RefreshState(getLinkedMetaData(app))
+			}
 		}
 	}
 }
@@ -361,12 +478,19 @@ func (app *app) Shutdown(timeout time.Duration) {
 }
 
 func runSampler(app *app, period time.Duration) {
-	previous := getSystemSample(time.Now(), app)
+	sample := func(now time.Time) *systemSample {
+		s := getSystemSample(now, app)
+		if app.config.RuntimeSampler.DetailedMetrics {
+			s.runtimeMetrics = readRuntimeMetrics()
+		}
+		return s
+	}
+	previous := sample(time.Now())
 	t := time.NewTicker(period)
 	for {
 		select {
 		case now := <-t.C:
-			current := getSystemSample(now, app)
+			current := sample(now)
 			run, _ := app.getState()
 			app.Consume(run.Reply.RunID, getSystemStats(systemSamples{
 				Previous: previous,
@@ -414,38 +538,60 @@ func (app *app) WaitForConnection(timeout time.Duration) error {
 	}
 }
 
-func newApp(c config) *app {
-	transport := c.Transport
-	if nil == transport {
-		transport = collectorDefaultTransport
+func newRPMControls(c config) rpmControls {
+	return rpmControls{
+		License: c.License,
+		Client:  newCollectorClient(c.Config),
+		Logger:  c.Logger,
+		GzipWriterPool: &sync.Pool{
+			New: func() interface{} {
+				return gzip.NewWriter(io.Discard)
+			},
+		},
+	}
+}
+
+// newCollectorClient returns the client used to communicate with the New
+// Relic servers: a copy of Config.HTTPClient if set, so that the timeout and
+// the transport can be set without modifying it, or a client using
+// Config.Transport.  The CollectorTLS settings are applied to the transport.
+func newCollectorClient(c Config) *http.Client {
+	transport := collectorTLSTransport(c, collectorTransport(c))
+	if nil != c.HTTPClient {
+		client := *c.HTTPClient
+		if 0 == client.Timeout {
+			client.Timeout = collectorTimeout
+		}
+		if nil != client.Transport || transport != http.DefaultTransport {
+			client.Transport = transport
+		}
+		return &client
 	}
+	return &http.Client{
+		Transport: transport,
+		Timeout:   collectorTimeout,
+	}
+}
+
+func newApp(c config) *app {
 	app := &app{
-		Logger:         c.Logger,
+		Logger:         newReloadableLogger(c.Logger),
 		config:         c,
 		placeholderRun: newPlaceholderAppRun(c),
-
-		// This channel must be buffered since Shutdown makes a
-		// non-blocking send attempt.
-		initiateShutdown: make(chan time.Duration, 1),
-
-		shutdownStarted:    make(chan struct{}),
-		shutdownComplete:   make(chan struct{}),
-		connectChan:        make(chan *appRun, 1),
-		collectorErrorChan: make(chan rpmResponse, 1),
-		dataChan:           make(chan appData, appDataChanSize),
-		rpmControls: rpmControls{
-			License: c.License,
-			Client: &http.Client{
-				Transport: transport,
-				Timeout:   collectorTimeout,
-			},
-			Logger: c.Logger,
-			GzipWriterPool: &sync.Pool{
-				New: func() interface{} {
-					return gzip.NewWriter(io.Discard)
-				},
-			},
-		},
+	}
+	if c.HarvestSpool.Enabled && !c.ServerlessMode.Enabled {
+		app.spool = newHarvestSpool(harvestSpoolDir(c), c.HarvestSpool.MaxBytes)
+	}
+	if c.AgentHealth.Enabled && !c.ServerlessMode.Enabled {
+		health, err := newAgentHealth(c.AgentHealth.DeliveryLocation, time.Now())
+		if err != nil {
+			app.Warn("unable to create health file", map[string]interface{}{
+				"location": c.AgentHealth.DeliveryLocation,
+				"error":    err.Error(),
+			})
+		} else {
+			app.health = health
+		}
 	}
 
 	app.Info("application created", map[string]interface{}{
@@ -461,17 +607,74 @@ func newApp(c config) *app {
 			app.run = newAppRun(c, reply)
 			app.serverless = newServerlessHarvest(c.Logger, os.Getenv)
 		} else {
-			go app.process()
-			go app.connectRoutine()
-			if app.config.RuntimeSampler.Enabled {
-				go runSampler(app, runtimeSamplerPeriod)
-			}
+			app.startHarvesting()
 		}
+	} else if nil != app.health {
+		app.health.set(healthDisabled, true)
+		app.writeHealth(time.Now())
 	}
 
 	return app
 }
 
+// startHarvesting allocates the channels used to connect to New Relic and
+// shut down, and starts the goroutines connecting the application and
+// processing its data.  It is only called for enabled applications which are
+// not in serverless mode, so that disabled applications, such as those
+// disabled using the environment, neither allocate them nor start the
+// goroutines.  The collector client is created by connectRoutine, and the
+// rest of the harvest state by startConnected, so that an application which
+// never connects does not allocate them either.
+func (app *app) startHarvesting() {
+	// This channel must be buffered since Shutdown makes a non-blocking
+	// send attempt.
+	app.initiateShutdown = make(chan time.Duration, 1)
+	app.shutdownStarted = make(chan struct{})
+	app.shutdownComplete = make(chan struct{})
+	app.connectChan = make(chan *appRun, 1)
+	app.collectorErrorChan = make(chan rpmResponse, 1)
+	if app.config.goroutineBudget.max > 0 {
+		app.logGoroutineBudget()
+	}
+
+	go app.process()
+	go app.connectRoutine()
+	if nil != app.health && app.config.goroutineBudget.healthWriter {
+		period := app.config.AgentHealth.Frequency
+		if period <= 0 {
+			period = defaultAgentHealthFrequency
+		}
+		go runHealthWriter(app, period)
+	}
+}
+
+// startConnected allocates the channel receiving the data of the
+// application and the harvest slots, connects the secondary destinations and
+// starts the samplers.  It is called by the process goroutine when the
+// application first connects.  Consume only sends to dataChan once the
+// application is connected, since the transactions and events of an
+// application which is not connected have no run ID.
+func (app *app) startConnected() {
+	app.dataChan = make(chan appData, appDataChanSize)
+	if app.config.goroutineBudget.max > 0 {
+		app.harvestSlots = make(chan struct{}, goroutinesHarvest)
+	}
+
+	app.secondaryDestinations = newSecondaryDestinations(app)
+	for _, d := range app.secondaryDestinations {
+		go d.connectRoutine(app)
+	}
+	if app.config.RuntimeSampler.Enabled {
+		go runSampler(app, runtimeSamplerPeriod)
+	}
+	if app.config.DatastoreTracer.ConnectionPoolMetrics.Enabled {
+		go runDBStatsSampler(app, dbStatsSamplerPeriod)
+	}
+	if app.config.Expvar.Enabled {
+		go runExpvarSampler(app, expvarSamplerPeriod)
+	}
+}
+
 func shouldUseTraceObserver(c config) bool {
 	return nil != c.traceObserverURL && c.SpanEvents.Enabled && c.DistributedTracer.Enabled
 }
@@ -490,6 +693,15 @@ func (app *app) HarvestTesting(replyfn func(*internal.ConnectReply)) {
 	app.testHarvest = newHarvest(time.Now(), app.placeholderRun.harvestConfig)
 }
 
+func (app *app) getErrorCallback() ErrorCallback {
+	if nil == app {
+		return nil
+	}
+	app.errorCallbackLock.RLock()
+	defer app.errorCallbackLock.RUnlock()
+	return app.errorCallback
+}
+
 func (app *app) getState() (*appRun, error) {
 	app.RLock()
 	defer app.RUnlock()
@@ -501,6 +713,17 @@ func (app *app) getState() (*appRun, error) {
 	return run, app.err
 }
 
+// updatedRun returns the run of the application replacing the given run once
+// the configuration is updated using UpdateConfig, or the given run if it was
+// not replaced.
+func (app *app) updatedRun(run *appRun) *appRun {
+	current, _ := app.getState()
+	if current.Reply == run.Reply {
+		return current
+	}
+	return run
+}
+
 func (app *app) setState(run *appRun, err error) {
 	app.Lock()
 	defer app.Unlock()
@@ -534,7 +757,11 @@ func (app *app) StartTransaction(name string, opts ...TraceOption) *Transaction
 		return nil
 	}
 	run, _ := app.getState()
-	return newTransaction(newTxn(app, run, name, opts...))
+	txn := newTransaction(newTxn(app, run, name, opts...))
+	if run.Config.GoroutineTransactions.Enabled {
+		txn.thread.bindGoroutine(txn, currentGoroutineID())
+	}
+	return txn
 }
 
 var (
@@ -561,6 +788,12 @@ func (app *app) RecordCustomEvent(eventType string, params map[string]interface{
 		return errCustomEventsDisabled
 	}
 
+	if !app.config.customEventTypes.permits(eventType) {
+		run, _ := app.getState()
+		app.Consume(run.Reply.RunID, droppedCustomEvent{eventType: eventType})
+		return errCustomEventTypeNotAllowed
+	}
+
 	if eventType == "LlmEmbedding" || eventType == "LlmChatCompletionSummary" || eventType == "LlmChatCompletionMessage" {
 		event, e = createCustomEventUnlimitedSize(eventType, params, time.Now())
 	} else {
@@ -596,6 +829,47 @@ func (app *app) RecordCustomMetric(name string, value float64) error {
 	if nil == app {
 		return nil
 	}
+	if err := app.validateCustomMetric(name, value); err != nil {
+		return err
+	}
+	run, _ := app.getState()
+	app.Consume(run.Reply.RunID, customMetric{
+		RawInputName: name,
+		Value:        value,
+	})
+	return nil
+}
+
+// RecordHistogram implements newrelic.Application's RecordHistogram.
+func (app *app) RecordHistogram(name string, value float64, bounds []float64) error {
+	if nil == app {
+		return nil
+	}
+	if err := app.validateCustomMetric(name, value); err != nil {
+		return err
+	}
+	if bounds == nil {
+		bounds = defaultHistogramBounds
+	} else {
+		if err := validateHistogramBounds(bounds); err != nil {
+			return err
+		}
+		// The bounds are used when the value is merged into the
+		// harvest, so they are copied in case the caller modifies them.
+		bounds = append([]float64(nil), bounds...)
+	}
+	run, _ := app.getState()
+	app.Consume(run.Reply.RunID, customHistogram{
+		RawInputName: name,
+		Value:        value,
+		Bounds:       bounds,
+	})
+	return nil
+}
+
+// validateCustomMetric returns an error if the custom metric cannot be
+// recorded.
+func (app *app) validateCustomMetric(name string, value float64) error {
 	if app.config.ServerlessMode.Enabled {
 		return errMetricServerless
 	}
@@ -608,11 +882,6 @@ func (app *app) RecordCustomMetric(name string, value float64) error {
 	if name == "" {
 		return errMetricNameEmpty
 	}
-	run, _ := app.getState()
-	app.Consume(run.Reply.RunID, customMetric{
-		RawInputName: name,
-		Value:        value,
-	})
 	return nil
 }
 
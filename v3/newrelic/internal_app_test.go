@@ -6,6 +6,7 @@ package newrelic
 import (
 	"errors"
 	"fmt"
+	"net/http"
 	"testing"
 	"time"
 
@@ -55,6 +56,84 @@ func TestEmptyApplication(t *testing.T) {
 	app.Shutdown(2 * time.Second)
 }
 
+func TestDisabledApplication(t *testing.T) {
+	app, err := NewApplication(
+		ConfigAppName("my app"),
+		ConfigLicense(testLicenseKey),
+		ConfigEnabled(false),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Disabled applications do not allocate the state used to connect and
+	// harvest, but can still be used and shut down.
+	if app.app.dataChan != nil || app.app.shutdownStarted != nil || app.app.rpmControls.Client != nil {
+		t.Error("harvest state allocated for disabled application")
+	}
+	txn := app.StartTransaction("hello")
+	txn.End()
+	app.RecordCustomEvent("myEventType", map[string]interface{}{"zip": "zap"})
+	if err := app.UpdateConfig(ConfigAppLogForwardingEnabled(false)); err != nil {
+		t.Error(err)
+	}
+	if err := app.WaitForConnection(time.Second); nil != err {
+		t.Error(err)
+	}
+	app.Shutdown(time.Second)
+}
+
+type failingTransport struct{}
+
+func (failingTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	return nil, errors.New("collector unreachable")
+}
+
+func TestApplicationNeverConnected(t *testing.T) {
+	app, err := NewApplication(
+		connectivityCheckConfig(failingTransport{}),
+		func(cfg *Config) {
+			cfg.SecondaryDestinations = []SecondaryDestination{
+				{License: "eu01xx0123456789012345678901234567890123"},
+			}
+		},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	txn := app.StartTransaction("hello")
+	txn.End()
+	app.RecordCustomEvent("myEventType", map[string]interface{}{"zip": "zap"})
+	app.Shutdown(time.Second)
+	// Applications which never connect do not allocate the state used to
+	// harvest.
+	if app.app.dataChan != nil || app.app.harvestSlots != nil || app.app.secondaryDestinations != nil {
+		t.Error("harvest state allocated for unconnected application")
+	}
+}
+
+func TestApplicationConnectAllocation(t *testing.T) {
+	mock := &connectivityMock{
+		harvest: endpointResult{response: makeResponse(202, "{}")},
+	}
+	app, err := NewApplication(
+		connectivityCheckConfig(mock),
+		func(cfg *Config) {
+			cfg.GoroutineBudget.Max = goroutinesRequired + goroutinesHarvest
+		},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := app.WaitForConnection(2 * time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if app.app.dataChan == nil || app.app.harvestSlots == nil || app.app.rpmControls.Client == nil {
+		t.Error("harvest state not allocated for connected application")
+	}
+	app.RecordCustomMetric("myMetric", 123.45)
+	app.Shutdown(2 * time.Second)
+}
+
 func TestConfigOptionError(t *testing.T) {
 	err := errors.New("myError")
 	app, got := NewApplication(
@@ -95,7 +174,6 @@ func newTestApp(replyfn func(*internal.ConnectReply), cfgFn ...ConfigOption) exp
 		},
 		ConfigAppName(sampleAppName),
 		ConfigLicense(testLicenseKey),
-		ConfigCodeLevelMetricsEnabled(false),
 	)
 
 	app, err := NewApplication(cfgFn...)
@@ -128,6 +206,10 @@ var configTestAppLogFn = func(cfg *Config) {
 }
 
 func TestRecordLog(t *testing.T) {
+	if !logForwardingIncluded {
+		t.Skip("log forwarding is excluded from the build")
+	}
+
 	testApp := newTestApp(
 		sampleEverythingReplyFn,
 		configTestAppLogFn,
@@ -9,6 +9,7 @@ import (
 	"net/http"
 	"net/url"
 	"testing"
+	"time"
 
 	"github.com/newrelic/go-agent/v3/internal"
 )
@@ -320,6 +321,49 @@ func TestAgentAttributes(t *testing.T) {
 	})
 }
 
+func TestAgentAttributesHTTPSemanticConventionsDuplicate(t *testing.T) {
+	semconv := map[string]interface{}{
+		AttributeHTTPRequestMethod:      "GET",
+		AttributeHTTPResponseStatusCode: 404,
+		AttributeURLPath:                "/hello",
+	}
+	agentAttributeTestcase(t, ConfigSemanticConventionsHTTP(SemanticConventionsDuplicate), AttributeExpect{
+		TxnEvent: UserAgent{
+			Agent: mergeAttributes(agent1, semconv),
+			User:  user1},
+		Error: UserAgent{
+			Agent: mergeAttributes(agent2, semconv),
+			User:  user1},
+	})
+}
+
+func TestAgentAttributesHTTPSemanticConventionsOpenTelemetry(t *testing.T) {
+	agent := map[string]interface{}{
+		AttributeHostDisplayName:        `my\host\display\name`,
+		AttributeResponseContentType:    `text/plain; charset=us-ascii`,
+		AttributeResponseContentLength:  345,
+		AttributeRequestAccept:          "text/plain",
+		AttributeRequestContentType:     "text/html; charset=utf-8",
+		AttributeRequestContentLength:   753,
+		AttributeRequestHost:            "my_domain.com",
+		AttributeHTTPRequestMethod:      "GET",
+		AttributeHTTPResponseStatusCode: 404,
+		AttributeURLPath:                "/hello",
+	}
+	agentAttributeTestcase(t, ConfigSemanticConventionsHTTP(SemanticConventionsOpenTelemetry), AttributeExpect{
+		TxnEvent: UserAgent{
+			Agent: agent,
+			User:  user1},
+		Error: UserAgent{
+			Agent: mergeAttributes(agent, map[string]interface{}{
+				AttributeRequestUserAgent:           "Mozilla/5.0",
+				AttributeRequestUserAgentDeprecated: "Mozilla/5.0",
+				AttributeRequestReferer:             "http://en.wikipedia.org/zip",
+			}),
+			User: user1},
+	})
+}
+
 func TestAttributesDisabled(t *testing.T) {
 	agentAttributeTestcase(t, func(cfg *Config) {
 		cfg.Attributes.Enabled = false
@@ -666,6 +710,145 @@ func TestMessageAttributes(t *testing.T) {
 	})
 }
 
+func TestSetMessageQueueTime(t *testing.T) {
+	app := testApp(nil, ConfigDistributedTracerEnabled(false), t)
+
+	txn := app.StartTransaction("hello1")
+	txn.SetMessageQueueTime(time.Now().Add(-time.Minute))
+	txn.End()
+
+	// The clock of the broker is ahead.
+	txn = app.StartTransaction("hello2")
+	txn.SetMessageQueueTime(time.Now().Add(time.Minute))
+	txn.End()
+
+	txn = app.StartTransaction("hello3")
+	txn.SetMessageQueueTime(time.Time{})
+	txn.End()
+
+	app.expectNoLoggedErrors(t)
+	app.ExpectTxnEvents(t, []internal.WantEvent{
+		{
+			UserAttributes: map[string]interface{}{},
+			AgentAttributes: map[string]interface{}{
+				"message.queueTime": internal.MatchAnything,
+			},
+			Intrinsics: map[string]interface{}{
+				"name": "OtherTransaction/Go/hello1",
+			},
+		},
+		{
+			UserAttributes: map[string]interface{}{},
+			AgentAttributes: map[string]interface{}{
+				"message.queueTime": 0.0,
+			},
+			Intrinsics: map[string]interface{}{
+				"name": "OtherTransaction/Go/hello2",
+			},
+		},
+		{
+			UserAttributes:  map[string]interface{}{},
+			AgentAttributes: map[string]interface{}{},
+			Intrinsics: map[string]interface{}{
+				"name": "OtherTransaction/Go/hello3",
+			},
+		},
+	})
+}
+
+func TestSetMessageQueueTimeTxnEnded(t *testing.T) {
+	app := testApp(nil, ConfigDistributedTracerEnabled(false), t)
+	txn := app.StartTransaction("hello")
+	txn.End()
+	txn.SetMessageQueueTime(time.Now())
+	app.expectSingleLoggedError(t, "unable to set message queue time", map[string]interface{}{
+		"reason": errAlreadyEnded.Error(),
+	})
+
+	var nilTxn *Transaction
+	nilTxn.SetMessageQueueTime(time.Now())
+}
+
+func TestSetUser(t *testing.T) {
+	app := testApp(distributedTracingReplyFields, func(cfg *Config) {
+		enableBetterCAT(cfg)
+		cfg.Attributes.Include = []string{AttributeUserName}
+	}, t)
+	txn := app.StartTransaction("hello")
+	txn.SetUser("gopher", WithUserEmail("gopher@example.com"), WithUserName("Gopher"))
+	txn.NoticeError(errors.New("zap"))
+	txn.End()
+
+	app.expectNoLoggedErrors(t)
+	user := map[string]interface{}{
+		AttributeUserID:   "gopher",
+		AttributeUserName: "Gopher",
+	}
+	app.ExpectTxnEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"name":     "OtherTransaction/Go/hello",
+			"error":    true,
+			"guid":     internal.MatchAnything,
+			"sampled":  internal.MatchAnything,
+			"traceId":  internal.MatchAnything,
+			"priority": internal.MatchAnything,
+		},
+		UserAttributes:  map[string]interface{}{},
+		AgentAttributes: user,
+	}})
+	app.ExpectErrorEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"error.class":     "*errors.errorString",
+			"error.message":   "zap",
+			"transactionName": "OtherTransaction/Go/hello",
+			"guid":            internal.MatchAnything,
+			"sampled":         internal.MatchAnything,
+			"spanId":          internal.MatchAnything,
+			"traceId":         internal.MatchAnything,
+			"priority":        internal.MatchAnything,
+		},
+		UserAttributes:  map[string]interface{}{},
+		AgentAttributes: user,
+	}})
+	app.ExpectSpanEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"name":             "OtherTransaction/Go/hello",
+			"transaction.name": "OtherTransaction/Go/hello",
+			"category":         "generic",
+			"nr.entryPoint":    true,
+		},
+		UserAttributes: map[string]interface{}{},
+		AgentAttributes: mergeAttributes(user, map[string]interface{}{
+			SpanAttributeErrorClass:   "*errors.errorString",
+			SpanAttributeErrorMessage: "zap",
+		}),
+	}})
+}
+
+func TestSetUserHighSecurity(t *testing.T) {
+	app := testApp(nil, func(cfg *Config) {
+		cfg.HighSecurity = true
+		cfg.DistributedTracer.Enabled = false
+		cfg.Attributes.Include = []string{AttributeUserEmail, AttributeUserName}
+	}, t)
+	txn := app.StartTransaction("hello")
+	txn.SetUser("gopher", WithUserEmail("gopher@example.com"), WithUserName("Gopher"))
+	txn.End()
+
+	app.expectSingleLoggedError(t, "unable to set user", map[string]interface{}{
+		"reason": errHighSecurityEnabled.Error(),
+	})
+	app.ExpectTxnEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"name": "OtherTransaction/Go/hello",
+		},
+		UserAttributes: map[string]interface{}{},
+		AgentAttributes: map[string]interface{}{
+			AttributeUserID: "gopher",
+		},
+	}})
+}
+
 func TestAddSpanAttr_BasicSegment_AllTypes(t *testing.T) {
 	app := testApp(distributedTracingReplyFields, enableBetterCAT, t)
 	txn := app.StartTransaction("txn")
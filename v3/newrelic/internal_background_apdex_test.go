@@ -0,0 +1,100 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package newrelic
+
+import (
+	"testing"
+	"time"
+
+	"github.com/newrelic/go-agent/v3/internal"
+)
+
+func TestBackgroundApdexDisabled(t *testing.T) {
+	app := testApp(nil, ConfigDistributedTracerEnabled(false), t)
+	txn := app.StartTransaction("hello")
+	txn.End()
+	app.expectNoLoggedErrors(t)
+	app.ExpectMetrics(t, backgroundMetrics)
+}
+
+func TestBackgroundApdex(t *testing.T) {
+	cfgfn := func(cfg *Config) {
+		cfg.DistributedTracer.Enabled = false
+		ConfigBackgroundApdex(time.Hour, 0)(cfg)
+	}
+	app := testApp(nil, cfgfn, t)
+	txn := app.StartTransaction("hello")
+	txn.End()
+	app.expectNoLoggedErrors(t)
+	app.ExpectMetrics(t, append([]internal.WantMetric{
+		{Name: "ApdexOther", Scope: "", Forced: true, Data: []float64{1, 0, 0, 3600, 3600, 0}},
+		{Name: "ApdexOther/Transaction/Go/hello", Scope: "", Forced: false, Data: []float64{1, 0, 0, 3600, 3600, 0}},
+	}, backgroundMetrics...))
+	app.ExpectTxnEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"name":             "OtherTransaction/Go/hello",
+			"nr.apdexPerfZone": "S",
+		},
+	}})
+}
+
+func TestBackgroundApdexMessageThreshold(t *testing.T) {
+	cfgfn := func(cfg *Config) {
+		cfg.DistributedTracer.Enabled = false
+		ConfigBackgroundApdex(time.Hour, time.Nanosecond)(cfg)
+	}
+	app := testApp(nil, cfgfn, t)
+	txn := app.StartTransaction("hello")
+	txn.SetMessageQueueTime(time.Now())
+	time.Sleep(time.Millisecond)
+	txn.End()
+	app.expectNoLoggedErrors(t)
+	app.ExpectMetrics(t, append([]internal.WantMetric{
+		{Name: "ApdexOther", Scope: "", Forced: true, Data: []float64{0, 0, 1, 1e-9, 1e-9, 0}},
+		{Name: "ApdexOther/Transaction/Go/hello", Scope: "", Forced: false, Data: []float64{0, 0, 1, 1e-9, 1e-9, 0}},
+	}, backgroundMetrics...))
+}
+
+func TestBackgroundApdexDefaultThreshold(t *testing.T) {
+	cfgfn := func(cfg *Config) {
+		cfg.DistributedTracer.Enabled = false
+		ConfigBackgroundApdex(0, 0)(cfg)
+	}
+	app := testApp(nil, cfgfn, t)
+	txn := app.StartTransaction("hello")
+	txn.SetMessageQueueTime(time.Now())
+	txn.End()
+	app.expectNoLoggedErrors(t)
+	app.ExpectMetrics(t, append([]internal.WantMetric{
+		{Name: "ApdexOther", Scope: "", Forced: true, Data: []float64{1, 0, 0, 0.5, 0.5, 0}},
+		{Name: "ApdexOther/Transaction/Go/hello", Scope: "", Forced: false, Data: []float64{1, 0, 0, 0.5, 0.5, 0}},
+	}, backgroundMetrics...))
+}
+
+func TestBackgroundApdexKeyTransaction(t *testing.T) {
+	replyfn := func(reply *internal.ConnectReply) {
+		reply.KeyTxnApdex = map[string]float64{"OtherTransaction/Go/hello": 2}
+	}
+	cfgfn := func(cfg *Config) {
+		cfg.DistributedTracer.Enabled = false
+		ConfigBackgroundApdex(time.Nanosecond, 0)(cfg)
+	}
+	app := testApp(replyfn, cfgfn, t)
+	txn := app.StartTransaction("hello")
+	txn.End()
+	app.expectNoLoggedErrors(t)
+	app.ExpectMetrics(t, append([]internal.WantMetric{
+		{Name: "ApdexOther", Scope: "", Forced: true, Data: []float64{1, 0, 0, 2, 2, 0}},
+		{Name: "ApdexOther/Transaction/Go/hello", Scope: "", Forced: false, Data: []float64{1, 0, 0, 2, 2, 0}},
+	}, backgroundMetrics...))
+}
+
+func TestTransportTypeIsMessageQueue(t *testing.T) {
+	if !TransportKafka.isMessageQueue() || !TransportAMQP.isMessageQueue() {
+		t.Error("message queue transport types not detected")
+	}
+	if TransportHTTP.isMessageQueue() || TransportOther.isMessageQueue() {
+		t.Error("non message queue transport types detected")
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package newrelic
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/newrelic/go-agent/v3/internal"
+)
+
+func TestAddBreadcrumbErrorEvent(t *testing.T) {
+	app := testApp(nil, ConfigDistributedTracerEnabled(false), t)
+	txn := app.StartTransaction("hello")
+	txn.AddBreadcrumb("cache", "miss", map[string]interface{}{"key": "user:1"})
+	app.expectNoLoggedErrors(t)
+	txn.NoticeError(errors.New("zap"))
+	txn.End()
+
+	app.ExpectErrorEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"error.class":     "*errors.errorString",
+			"error.message":   "zap",
+			"transactionName": "OtherTransaction/Go/hello",
+		},
+		AgentAttributes: map[string]interface{}{
+			AttributeBreadcrumbs: internal.MatchAnything,
+		},
+	}})
+}
+
+func TestAddBreadcrumbExcluded(t *testing.T) {
+	cfgfn := func(cfg *Config) {
+		cfg.DistributedTracer.Enabled = false
+		cfg.Attributes.Exclude = []string{AttributeBreadcrumbs}
+	}
+	app := testApp(nil, cfgfn, t)
+	txn := app.StartTransaction("hello")
+	txn.AddBreadcrumb("cache", "miss", nil)
+	txn.NoticeError(errors.New("zap"))
+	txn.End()
+
+	app.ExpectErrorEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"error.class":     "*errors.errorString",
+			"error.message":   "zap",
+			"transactionName": "OtherTransaction/Go/hello",
+		},
+		AgentAttributes: map[string]interface{}{},
+	}})
+}
+
+func TestAddBreadcrumbHighSecurity(t *testing.T) {
+	cfgfn := func(cfg *Config) {
+		cfg.HighSecurity = true
+		cfg.DistributedTracer.Enabled = false
+	}
+	app := testApp(nil, cfgfn, t)
+	txn := app.StartTransaction("hello")
+	txn.AddBreadcrumb("cache", "miss", nil)
+	app.expectSingleLoggedError(t, "unable to add breadcrumb", map[string]interface{}{
+		"reason": errHighSecurityEnabled.Error(),
+	})
+	txn.End()
+}
+
+func TestAddBreadcrumbSecurityPolicy(t *testing.T) {
+	replyfn := func(reply *internal.ConnectReply) {
+		reply.SecurityPolicies.CustomParameters.SetEnabled(false)
+	}
+	app := testApp(replyfn, ConfigDistributedTracerEnabled(false), t)
+	txn := app.StartTransaction("hello")
+	txn.AddBreadcrumb("cache", "miss", nil)
+	app.expectSingleLoggedError(t, "unable to add breadcrumb", map[string]interface{}{
+		"reason": errSecurityPolicy.Error(),
+	})
+	txn.End()
+}
+
+func TestAddBreadcrumbAfterEnd(t *testing.T) {
+	app := testApp(nil, ConfigDistributedTracerEnabled(false), t)
+	txn := app.StartTransaction("hello")
+	txn.End()
+	txn.AddBreadcrumb("cache", "miss", nil)
+	app.expectSingleLoggedError(t, "unable to add breadcrumb", map[string]interface{}{
+		"reason": errAlreadyEnded.Error(),
+	})
+}
+
+func TestAddBreadcrumbNilTransaction(t *testing.T) {
+	var txn *Transaction
+	txn.AddBreadcrumb("cache", "miss", nil)
+}
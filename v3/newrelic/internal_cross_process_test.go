@@ -69,6 +69,10 @@ func outboundCrossProcessResponse() http.Header {
 }
 
 func TestCrossProcessWriteHeaderSuccess(t *testing.T) {
+	if !crossAppTracingIncluded {
+		t.Skip("Cross Application Tracing is excluded from the build")
+	}
+
 	// Test that the CAT response header is present when the consumer uses
 	// txn.SetWebResponse().WriteHeader.
 	cfgFn := func(cfg *Config) {
@@ -101,6 +105,10 @@ func TestCrossProcessWriteHeaderSuccess(t *testing.T) {
 }
 
 func TestCrossProcessWriteSuccess(t *testing.T) {
+	if !crossAppTracingIncluded {
+		t.Skip("Cross Application Tracing is excluded from the build")
+	}
+
 	// Test that the CAT response header is present when the consumer uses
 	// txn.Write.
 	cfgFn := func(cfg *Config) {
@@ -131,6 +139,10 @@ func TestCrossProcessWriteSuccess(t *testing.T) {
 }
 
 func TestCrossProcessLocallyDisabled(t *testing.T) {
+	if !crossAppTracingIncluded {
+		t.Skip("Cross Application Tracing is excluded from the build")
+	}
+
 	// Test that the CAT can be disabled by local configuration.
 	cfgFn := func(cfg *Config) {
 		cfg.CrossApplicationTracer.Enabled = false
@@ -162,6 +174,10 @@ func TestCrossProcessLocallyDisabled(t *testing.T) {
 }
 
 func TestCrossProcessDisabledByServerSideConfig(t *testing.T) {
+	if !crossAppTracingIncluded {
+		t.Skip("Cross Application Tracing is excluded from the build")
+	}
+
 	// Test that the CAT can be disabled by server-side-config.
 	cfgFn := func(cfg *Config) {
 		cfg.DistributedTracer.Enabled = false
@@ -196,6 +212,10 @@ func TestCrossProcessDisabledByServerSideConfig(t *testing.T) {
 }
 
 func TestCrossProcessEnabledByServerSideConfig(t *testing.T) {
+	if !crossAppTracingIncluded {
+		t.Skip("Cross Application Tracing is excluded from the build")
+	}
+
 	// Test that the CAT can be enabled by server-side-config.
 	cfgFn := func(cfg *Config) {
 		cfg.CrossApplicationTracer.Enabled = false
@@ -2307,3 +2307,68 @@ func TestW3CHeaderCases(t *testing.T) {
 		})
 	}
 }
+
+func TestAcceptAWSTraceHeader(t *testing.T) {
+	app := testApp(distributedTracingReplyFields, func(cfg *Config) {
+		enableBetterCAT(cfg)
+		cfg.DistributedTracer.AcceptAWSTraceHeader = true
+	}, t)
+	txn := app.StartTransaction("hello")
+	txn.SetWebRequest(WebRequest{
+		Header: http.Header{
+			"X-Amzn-Trace-Id": []string{"Root=1-5759e988-bd862e3fe1be46a994272793;Sampled=1"},
+		},
+		Transport: TransportHTTP,
+	})
+	if traceID := txn.GetTraceMetadata().TraceID; traceID != "5759e988bd862e3fe1be46a994272793" {
+		t.Error("incorrect trace id:", traceID)
+	}
+	txn.End()
+
+	app.ExpectTxnEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"name":             "WebTransaction/Go/hello",
+			"guid":             internal.MatchAnything,
+			"priority":         internal.MatchAnything,
+			"sampled":          internal.MatchAnything,
+			"traceId":          "5759e988bd862e3fe1be46a994272793",
+			"nr.apdexPerfZone": internal.MatchAnything,
+		},
+		AgentAttributes: map[string]interface{}{
+			AttributeAWSTraceID: "Root=1-5759e988-bd862e3fe1be46a994272793;Sampled=1",
+		},
+	}})
+}
+
+func TestAcceptAWSTraceHeaderDisabled(t *testing.T) {
+	app := testApp(distributedTracingReplyFields, enableBetterCAT, t)
+	txn := app.StartTransaction("hello")
+	txn.SetWebRequest(WebRequest{
+		Header: http.Header{
+			"X-Amzn-Trace-Id": []string{"Root=1-5759e988-bd862e3fe1be46a994272793"},
+		},
+	})
+	if traceID := txn.GetTraceMetadata().TraceID; traceID == "5759e988bd862e3fe1be46a994272793" {
+		t.Error("aws trace id should not be accepted")
+	}
+	txn.End()
+}
+
+func TestAcceptAWSTraceHeaderInboundPayload(t *testing.T) {
+	app := testApp(distributedTracingReplyFields, func(cfg *Config) {
+		enableBetterCAT(cfg)
+		cfg.DistributedTracer.AcceptAWSTraceHeader = true
+	}, t)
+	txn := app.StartTransaction("hello")
+	txn.SetWebRequest(WebRequest{
+		Header: http.Header{
+			"X-Amzn-Trace-Id":                    []string{"Root=1-5759e988-bd862e3fe1be46a994272793"},
+			DistributedTraceW3CTraceParentHeader: []string{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+		},
+		Transport: TransportHTTP,
+	})
+	if traceID := txn.GetTraceMetadata().TraceID; traceID != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Error("incorrect trace id:", traceID)
+	}
+	txn.End()
+}
@@ -4,6 +4,7 @@
 package newrelic
 
 import (
+	"errors"
 	"runtime"
 	"strings"
 	"testing"
@@ -76,3 +77,27 @@ func TestStackTrace(t *testing.T) {
 		}
 	}
 }
+
+func TestStackTraceMaxDepth(t *testing.T) {
+	var frames []StacktraceFrame
+	app := testApp(nil, func(cfg *Config) {
+		cfg.StackTraces.MaxDepth = 2
+		cfg.StackTraces.SkipRuntimeFrames = true
+		cfg.ErrorCollector.ErrorGroupCallback = func(e ErrorInfo) string {
+			frames = e.GetStackTraceFrames()
+			return ""
+		}
+	}, t)
+	txn := app.StartTransaction("hello")
+	txn.NoticeError(errors.New("my msg"))
+	txn.End()
+
+	if len(frames) != 2 {
+		t.Fatal("incorrect number of frames", frames)
+	}
+	for _, frame := range frames {
+		if strings.HasPrefix(frame.Name, "runtime.") {
+			t.Error("runtime frame not removed", frame)
+		}
+	}
+}
@@ -735,3 +735,116 @@ func TestNoticeErrorPanicRecoverySpanID(t *testing.T) {
 	}})
 	app.ExpectMetrics(t, backgroundErrorMetricsUnknownCaller)
 }
+
+func TestErrorCallbackDropsError(t *testing.T) {
+	app := testApp(nil, ConfigDistributedTracerEnabled(false), t)
+	var got ErrorInfo
+	app.SetErrorCallback(func(info ErrorInfo) (bool, string) {
+		got = info
+		return false, ""
+	})
+	txn := app.StartTransaction("hello")
+	txn.NoticeError(myError{})
+	app.expectNoLoggedErrors(t)
+	txn.End()
+	if got.Class != "newrelic.myError" || got.Message != "my msg" || got.TransactionName != "OtherTransaction/Go/hello" {
+		t.Errorf("unexpected error info: %+v", got)
+	}
+	app.ExpectErrors(t, []internal.WantError{})
+	app.ExpectErrorEvents(t, []internal.WantEvent{})
+	app.ExpectMetrics(t, backgroundMetrics)
+}
+
+func TestErrorCallbackGroupLabel(t *testing.T) {
+	app := testApp(nil, ConfigDistributedTracerEnabled(false), t)
+	app.SetErrorCallback(func(info ErrorInfo) (bool, string) {
+		return true, "group-" + info.Class
+	})
+	txn := app.StartTransaction("hello")
+	txn.NoticeError(myError{})
+	app.expectNoLoggedErrors(t)
+	txn.End()
+	app.ExpectErrorEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"error.class":     "newrelic.myError",
+			"error.message":   "my msg",
+			"transactionName": "OtherTransaction/Go/hello",
+		},
+		AgentAttributes: map[string]interface{}{
+			AttributeErrorGroupName: "group-newrelic.myError",
+		},
+	}})
+	app.ExpectMetrics(t, backgroundErrorMetrics)
+}
+
+func TestErrorCallbackPanic(t *testing.T) {
+	app := testApp(nil, func(cfg *Config) {
+		enableRecordPanics(cfg)
+		cfg.DistributedTracer.Enabled = false
+	}, t)
+	var classes []string
+	app.SetErrorCallback(func(info ErrorInfo) (bool, string) {
+		classes = append(classes, info.Class)
+		return info.Class != PanicErrorClass, ""
+	})
+	txn := app.StartTransaction("hello")
+	deferEndPanic(txn, "oops")
+	if len(classes) != 1 || classes[0] != PanicErrorClass {
+		t.Error(classes)
+	}
+	app.ExpectErrors(t, []internal.WantError{})
+	app.ExpectMetrics(t, backgroundMetrics)
+}
+
+func TestErrorCallbackRemoved(t *testing.T) {
+	app := testApp(nil, ConfigDistributedTracerEnabled(false), t)
+	app.SetErrorCallback(func(info ErrorInfo) (bool, string) {
+		return false, ""
+	})
+	app.SetErrorCallback(nil)
+	txn := app.StartTransaction("hello")
+	txn.NoticeError(myError{})
+	txn.End()
+	app.ExpectMetrics(t, backgroundErrorMetrics)
+}
+
+func TestExpectedErrorClasses(t *testing.T) {
+	app := testApp(nil, func(cfg *Config) {
+		ConfigExpectedErrorClasses("newrelic.myError")(cfg)
+		cfg.DistributedTracer.Enabled = false
+	}, t)
+	var expected bool
+	app.SetErrorCallback(func(info ErrorInfo) (bool, string) {
+		expected = info.Expected
+		return true, ""
+	})
+	txn := app.StartTransaction("hello")
+	txn.NoticeError(myError{})
+	app.expectNoLoggedErrors(t)
+	txn.End()
+	if !expected {
+		t.Error("error info not marked as expected")
+	}
+	app.ExpectErrorEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"error.class":     "newrelic.myError",
+			"error.message":   "my msg",
+			"error.expected":  true,
+			"transactionName": "OtherTransaction/Go/hello",
+		},
+	}})
+	app.ExpectMetrics(t, append([]internal.WantMetric{
+		{Name: "ErrorsExpected/all", Scope: "", Forced: true, Data: singleCount},
+	}, backgroundMetrics...))
+}
+
+func TestExpectedErrorClassesNoMatch(t *testing.T) {
+	app := testApp(nil, func(cfg *Config) {
+		ConfigExpectedErrorClasses("newrelic.otherError")(cfg)
+		cfg.DistributedTracer.Enabled = false
+	}, t)
+	txn := app.StartTransaction("hello")
+	txn.NoticeError(myError{})
+	txn.End()
+	app.ExpectMetrics(t, backgroundErrorMetrics)
+}
@@ -370,6 +370,10 @@ func TestTraceSegmentAttributesSpecificallyExcluded(t *testing.T) {
 }
 
 func TestTraceSegmentAttributesDisabled(t *testing.T) {
+	if !crossAppTracingIncluded {
+		t.Skip("Cross Application Tracing is excluded from the build")
+	}
+
 	// Test that segment attributes can be disabled by Attributes.Enabled
 	// but backtrace and transaction_guid still appear.
 	cfgfn := func(cfg *Config) {
@@ -442,6 +446,10 @@ func TestTraceSegmentAttributesDisabled(t *testing.T) {
 }
 
 func TestTraceSegmentAttributesSpecificallyDisabled(t *testing.T) {
+	if !crossAppTracingIncluded {
+		t.Skip("Cross Application Tracing is excluded from the build")
+	}
+
 	// Test that segment attributes can be disabled by
 	// TransactionTracer.Segments.Attributes.Enabled but backtrace and
 	// transaction_guid still appear.
@@ -513,3 +521,62 @@ func TestTraceSegmentAttributesSpecificallyDisabled(t *testing.T) {
 		},
 	}})
 }
+
+func TestTraceSegmentCustomAttributes(t *testing.T) {
+	cfgfn := func(cfg *Config) {
+		cfg.TransactionTracer.Segments.Threshold = 0
+		cfg.TransactionTracer.Segments.StackTraceThreshold = 1 * time.Hour
+		cfg.TransactionTracer.Threshold.IsApdexFailing = false
+		cfg.TransactionTracer.Threshold.Duration = 0
+		cfg.TransactionTracer.Segments.Attributes.Exclude = []string{"span-only"}
+
+		// Custom segment attributes are added to traces without
+		// distributed tracing.
+		cfg.DistributedTracer.Enabled = false
+	}
+	app := testApp(nil, cfgfn, t)
+	txn := app.StartTransaction("hello")
+	basicSegment := txn.StartSegment("basic")
+	basicSegment.AddAttribute("customer", "acme")
+	basicSegment.AddAttribute("retries", 2)
+	basicSegment.AddAttribute("span-only", true)
+	basicSegment.End()
+	datastoreSegment := DatastoreSegment{
+		StartTime:          txn.StartSegmentNow(),
+		Product:            DatastoreMySQL,
+		Collection:         "mycollection",
+		Operation:          "myoperation",
+		ParameterizedQuery: "myquery",
+	}
+	// Custom attributes do not replace agent attributes.
+	datastoreSegment.AddAttribute(SpanAttributeDBStatement, "custom")
+	datastoreSegment.End()
+	txn.End()
+	app.expectNoLoggedErrors(t)
+	app.ExpectTxnTraces(t, []internal.WantTxnTrace{{
+		MetricName: "OtherTransaction/Go/hello",
+		Root: internal.WantTraceSegment{
+			SegmentName: "ROOT",
+			Attributes:  map[string]interface{}{},
+			Children: []internal.WantTraceSegment{{
+				SegmentName: "OtherTransaction/Go/hello",
+				Attributes:  map[string]interface{}{"exclusive_duration_millis": internal.MatchAnything},
+				Children: []internal.WantTraceSegment{
+					{
+						SegmentName: "Custom/basic",
+						Attributes: map[string]interface{}{
+							"customer": "acme",
+							"retries":  2,
+						},
+					},
+					{
+						SegmentName: "Datastore/statement/MySQL/mycollection/myoperation",
+						Attributes: map[string]interface{}{
+							"db.statement": "myquery",
+						},
+					},
+				},
+			}},
+		},
+	}})
+}
@@ -5,7 +5,10 @@ package newrelic
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
 	"time"
@@ -306,3 +309,95 @@ func TestServerlessConnectReply(t *testing.T) {
 		t.Error(reply.PrimaryAppID)
 	}
 }
+
+func withServerlessOutput(t *testing.T) *bytes.Buffer {
+	buf := &bytes.Buffer{}
+	orig := serverlessOutput
+	serverlessOutput = buf
+	t.Cleanup(func() { serverlessOutput = orig })
+	return buf
+}
+
+func TestServerlessHandler(t *testing.T) {
+	buf := withServerlessOutput(t)
+	cfgFn := func(cfg *Config) {
+		cfg.ServerlessMode.Enabled = true
+		cfg.DistributedTracer.Enabled = false
+	}
+	app := testApp(nil, cfgFn, t)
+	h := ServerlessHandler(app.Application, "HelloHTTP", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if nil == FromContext(r.Context()) {
+			t.Error("transaction missing from request context")
+		}
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	req, _ := http.NewRequest("GET", "/hello", nil)
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	_, data, err := parseServerlessPayload(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := string(data["analytic_event_data"]); !strings.Contains(v, `"name":"WebTransaction/Go/HelloHTTP"`) {
+		t.Error(v)
+	}
+	if v := string(data["analytic_event_data"]); !strings.Contains(v, `"http.statusCode":418`) {
+		t.Error(v)
+	}
+}
+
+func TestServerlessJob(t *testing.T) {
+	buf := withServerlessOutput(t)
+	cfgFn := func(cfg *Config) {
+		cfg.ServerlessMode.Enabled = true
+		cfg.DistributedTracer.Enabled = false
+	}
+	app := testApp(nil, cfgFn, t)
+	jobErr := errors.New("job failed")
+	run := ServerlessJob(app.Application, "export", func(ctx context.Context) error {
+		if nil == FromContext(ctx) {
+			t.Error("transaction missing from context")
+		}
+		return jobErr
+	})
+	if err := run(context.Background()); err != jobErr {
+		t.Error(err)
+	}
+
+	_, data, err := parseServerlessPayload(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := string(data["analytic_event_data"]); !strings.Contains(v, `"name":"OtherTransaction/Go/export"`) {
+		t.Error(v)
+	}
+	if v := string(data["error_event_data"]); !strings.Contains(v, `"error.message":"job failed"`) {
+		t.Error(v)
+	}
+}
+
+func TestServerlessJobNotServerless(t *testing.T) {
+	buf := withServerlessOutput(t)
+	app := testApp(nil, nil, t)
+	run := ServerlessJob(app.Application, "export", func(ctx context.Context) error { return nil })
+	if err := run(context.Background()); nil != err {
+		t.Error(err)
+	}
+	if 0 != buf.Len() {
+		t.Error(buf.String())
+	}
+	app.ExpectMetricsPresent(t, []internal.WantMetric{
+		{Name: "OtherTransaction/Go/export", Scope: "", Forced: true, Data: nil},
+	})
+}
+
+func TestServerlessNilApp(t *testing.T) {
+	job := func(ctx context.Context) error { return nil }
+	if err := ServerlessJob(nil, "export", job)(context.Background()); nil != err {
+		t.Error(err)
+	}
+	h := http.NotFoundHandler()
+	if ServerlessHandler(nil, "HelloHTTP", h) == nil {
+		t.Error("handler missing")
+	}
+}
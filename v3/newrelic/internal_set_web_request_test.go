@@ -149,6 +149,42 @@ func TestSetWebRequestHTTPRequest(t *testing.T) {
 	}})
 }
 
+func TestSetWebRequestHTTPClone(t *testing.T) {
+	// Test that SetWebRequestHTTPClone records the same attributes as
+	// SetWebRequestHTTP, unaffected by later changes to the request.
+	app := testApp(distributedTracingReplyFields, enableBetterCAT, t)
+	txn := app.StartTransaction("hello")
+	req := sampleHTTPRequest.Clone(sampleHTTPRequest.Context())
+	txn.SetWebRequestHTTPClone(req)
+	req.Header.Set("Accept", "modified")
+	req.URL.Path = "/modified"
+	app.expectNoLoggedErrors(t)
+	txn.End()
+	app.ExpectTxnEvents(t, []internal.WantEvent{{
+		AgentAttributes: sampleRequestAgentAttributes,
+		Intrinsics: map[string]interface{}{
+			"name":             "WebTransaction/Go/hello",
+			"guid":             internal.MatchAnything,
+			"sampled":          internal.MatchAnything,
+			"priority":         internal.MatchAnything,
+			"traceId":          internal.MatchAnything,
+			"nr.apdexPerfZone": internal.MatchAnything,
+		},
+	}})
+}
+
+func TestSnapshotHeader(t *testing.T) {
+	if h := snapshotHeader(nil); h != nil {
+		t.Error(h)
+	}
+	hdr := http.Header{"Accept": {"myaccept"}}
+	cp := snapshotHeader(hdr)
+	hdr.Set("Content-Type", "mycontent")
+	if len(cp) != 1 || cp.Get("Accept") != "myaccept" {
+		t.Error(cp)
+	}
+}
+
 func TestSetWebRequestAlreadyEnded(t *testing.T) {
 	// Test that SetWebRequest returns an error if called after
 	// Transaction.End.
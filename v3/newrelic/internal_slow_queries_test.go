@@ -4,6 +4,7 @@
 package newrelic
 
 import (
+	"errors"
 	"strings"
 	"testing"
 	"time"
@@ -889,3 +890,323 @@ func TestSlowQueryParamsInvalid(t *testing.T) {
 		Params:       map[string]interface{}{"number": 5},
 	}})
 }
+
+func TestSlowQueryExplainPlan(t *testing.T) {
+	replyfn := func(reply *internal.ConnectReply) {
+		reply.SetSampleEverything()
+	}
+	cfgfn := func(cfg *Config) {
+		cfg.DatastoreTracer.SlowQuery.Threshold = 0
+		cfg.DatastoreTracer.ExplainPlan.Enabled = true
+		cfg.DatastoreTracer.ExplainPlan.Threshold = 0
+		cfg.DistributedTracer.Enabled = false
+		cfg.TransactionTracer.Segments.Threshold = 0
+		cfg.TransactionTracer.Segments.StackTraceThreshold = 1 * time.Hour
+		cfg.TransactionTracer.Threshold.IsApdexFailing = false
+		cfg.TransactionTracer.Threshold.Duration = 0
+	}
+	app := testApp(replyfn, cfgfn, t)
+	txn := app.StartTransaction("hello")
+	explained := 0
+	s1 := DatastoreSegment{
+		StartTime:          txn.StartSegmentNow(),
+		Product:            DatastorePostgres,
+		Collection:         "users",
+		Operation:          "SELECT",
+		ParameterizedQuery: "SELECT * FROM users WHERE id = $1",
+		ExplainPlan: func() (string, error) {
+			explained++
+			return "Seq Scan on users\n  Filter: (id = ?)", nil
+		},
+	}
+	s1.End()
+	txn.End()
+	app.app.explainPlanner.wait()
+
+	if explained != 1 {
+		t.Error("query plan captured", explained, "times")
+	}
+	app.expectNoLoggedErrors(t)
+	app.ExpectSlowQueries(t, []internal.WantSlowQuery{{
+		Count:      1,
+		MetricName: "Datastore/statement/Postgres/users/SELECT",
+		Query:      "SELECT * FROM users WHERE id = $1",
+		TxnName:    "OtherTransaction/Go/hello",
+	}})
+	app.ExpectTxnTraces(t, []internal.WantTxnTrace{{
+		MetricName: "OtherTransaction/Go/hello",
+		Root: internal.WantTraceSegment{
+			SegmentName: "ROOT",
+			Attributes:  map[string]interface{}{},
+			Children: []internal.WantTraceSegment{{
+				SegmentName: "OtherTransaction/Go/hello",
+				Attributes:  map[string]interface{}{"exclusive_duration_millis": internal.MatchAnything},
+				Children: []internal.WantTraceSegment{{
+					SegmentName: "Datastore/statement/Postgres/users/SELECT",
+					Attributes: map[string]interface{}{
+						"db.statement": "SELECT * FROM users WHERE id = $1",
+						"explain_plan": internal.MatchAnything,
+					},
+				}},
+			}},
+		},
+	}})
+}
+
+func TestSlowQueryExplainPlanNotCaptured(t *testing.T) {
+	testcases := map[string]func(cfg *Config){
+		"disabled": func(cfg *Config) {
+			cfg.DatastoreTracer.ExplainPlan.Enabled = false
+		},
+		"below threshold": func(cfg *Config) {
+			cfg.DatastoreTracer.ExplainPlan.Threshold = 1 * time.Hour
+		},
+		"high security": func(cfg *Config) {
+			cfg.HighSecurity = true
+		},
+	}
+	for name, fn := range testcases {
+		t.Run(name, func(t *testing.T) {
+			cfgfn := func(cfg *Config) {
+				cfg.DatastoreTracer.SlowQuery.Threshold = 0
+				cfg.DatastoreTracer.ExplainPlan.Enabled = true
+				cfg.DatastoreTracer.ExplainPlan.Threshold = 0
+				cfg.DistributedTracer.Enabled = false
+				fn(cfg)
+			}
+			app := testApp(nil, cfgfn, t)
+			txn := app.StartTransaction("hello")
+			s1 := DatastoreSegment{
+				StartTime:          txn.StartSegmentNow(),
+				Product:            DatastorePostgres,
+				Collection:         "users",
+				Operation:          "SELECT",
+				ParameterizedQuery: "SELECT * FROM users WHERE id = $1",
+				ExplainPlan: func() (string, error) {
+					t.Error("query plan captured")
+					return "", nil
+				},
+			}
+			s1.End()
+			txn.End()
+		})
+	}
+}
+
+func TestSlowQueryExplainPlanAsync(t *testing.T) {
+	cfgfn := func(cfg *Config) {
+		cfg.DatastoreTracer.SlowQuery.Threshold = 0
+		cfg.DatastoreTracer.ExplainPlan.Enabled = true
+		cfg.DatastoreTracer.ExplainPlan.Threshold = 0
+		cfg.DistributedTracer.Enabled = false
+	}
+	app := testApp(nil, cfgfn, t)
+	txn := app.StartTransaction("hello")
+	release := make(chan struct{})
+	explain := func() (string, error) {
+		<-release
+		return "Seq Scan on users", nil
+	}
+	s1 := DatastoreSegment{
+		StartTime:          txn.StartSegmentNow(),
+		Product:            DatastorePostgres,
+		Collection:         "users",
+		Operation:          "SELECT",
+		ParameterizedQuery: "SELECT * FROM users WHERE id = $1",
+		ExplainPlan:        explain,
+	}
+	// The segment does not wait for the plan, and no other plan is
+	// captured while it is.
+	s1.End()
+	// The second query differs from the first so that its slow query,
+	// which has no plan, is not aggregated with the first.
+	s2 := s1
+	s2.StartTime = txn.StartSegmentNow()
+	s2.ParameterizedQuery = "SELECT * FROM users WHERE name = $1"
+	s2.ExplainPlan = func() (string, error) {
+		t.Error("query plan captured while another is captured")
+		return "", nil
+	}
+	s2.End()
+	txn.End()
+
+	// The plan is only written once captured.
+	js, err := app.app.testHarvest.SlowSQLs.Data("runID", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(js), "explain_plan") {
+		t.Error("query plan written before it was captured:", string(js))
+	}
+	close(release)
+	app.app.explainPlanner.wait()
+	js, err = app.app.testHarvest.SlowSQLs.Data("runID", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(js), `"explain_plan":[["QUERY PLAN"],[["Seq Scan on users"]]]`) {
+		t.Error("query plan missing:", string(js))
+	}
+}
+
+func TestSlowQueryExplainPlanLimits(t *testing.T) {
+	cfgfn := func(cfg *Config) {
+		cfg.DatastoreTracer.ExplainPlan.Enabled = true
+		cfg.DatastoreTracer.ExplainPlan.Threshold = 0
+	}
+	app := testApp(nil, cfgfn, t)
+	explained := 0
+	explainQueries := func(txn *Transaction, n int) {
+		for i := 0; i < n; i++ {
+			s := DatastoreSegment{
+				StartTime: txn.StartSegmentNow(),
+				Product:   DatastorePostgres,
+				Operation: "SELECT",
+				ExplainPlan: func() (string, error) {
+					explained++
+					return "Result", nil
+				},
+			}
+			s.End()
+			app.app.explainPlanner.wait()
+		}
+	}
+
+	txn := app.StartTransaction("first")
+	explainQueries(txn, maxTxnExplainPlans+1)
+	txn.End()
+	if explained != maxTxnExplainPlans {
+		t.Error("incorrect number of plans per transaction:", explained)
+	}
+
+	// The plans of all of the transactions count against the limit of the
+	// harvest period.
+	explained = 0
+	txn = app.StartTransaction("second")
+	explainQueries(txn, maxTxnExplainPlans)
+	txn.End()
+	if explained != maxHarvestExplainPlans-maxTxnExplainPlans {
+		t.Error("incorrect number of plans per harvest period:", explained)
+	}
+
+	app.app.explainPlanner.periodStart = time.Now().Add(-fixedHarvestPeriod)
+	explained = 0
+	txn = app.StartTransaction("third")
+	explainQueries(txn, 1)
+	txn.End()
+	if explained != 1 {
+		t.Error("limit not reset for the next harvest period:", explained)
+	}
+}
+
+func TestSlowQueryExplainPlanError(t *testing.T) {
+	cfgfn := func(cfg *Config) {
+		cfg.DatastoreTracer.SlowQuery.Threshold = 0
+		cfg.DatastoreTracer.ExplainPlan.Enabled = true
+		cfg.DatastoreTracer.ExplainPlan.Threshold = 0
+		cfg.DistributedTracer.Enabled = false
+	}
+	app := testApp(nil, cfgfn, t)
+	txn := app.StartTransaction("hello")
+	s1 := DatastoreSegment{
+		StartTime:          txn.StartSegmentNow(),
+		Product:            DatastorePostgres,
+		Collection:         "users",
+		Operation:          "SELECT",
+		ParameterizedQuery: "SELECT * FROM users WHERE id = $1",
+		ExplainPlan: func() (string, error) {
+			return "", errors.New("connection refused")
+		},
+	}
+	s1.End()
+	app.app.explainPlanner.wait()
+	app.expectSingleLoggedError(t, "unable to explain datastore query", map[string]interface{}{
+		"reason":     "connection refused",
+		"product":    DatastorePostgres,
+		"collection": "users",
+		"operation":  "SELECT",
+	})
+	txn.End()
+
+	app.ExpectSlowQueries(t, []internal.WantSlowQuery{{
+		Count:      1,
+		MetricName: "Datastore/statement/Postgres/users/SELECT",
+		Query:      "SELECT * FROM users WHERE id = $1",
+		TxnName:    "OtherTransaction/Go/hello",
+	}})
+}
+
+func TestSlowQueryQuerySanitizer(t *testing.T) {
+	cfgfn := func(cfg *Config) {
+		cfg.DatastoreTracer.SlowQuery.Threshold = 0
+		cfg.DatastoreTracer.QuerySanitizer = func(query string) string {
+			return strings.Replace(query, "IN ($1, $2, $3)", "IN (...)", 1)
+		}
+	}
+	app := testApp(distributedTracingReplyFields, cfgfn, t)
+	txn := app.StartTransaction("hello")
+	s1 := DatastoreSegment{
+		StartTime:          txn.StartSegmentNow(),
+		Product:            DatastorePostgres,
+		Collection:         "users",
+		Operation:          "SELECT",
+		ParameterizedQuery: "SELECT * FROM users WHERE id IN ($1, $2, $3)",
+	}
+	s1.End()
+	s2 := DatastoreSegment{
+		StartTime:  txn.StartSegmentNow(),
+		Product:    DatastorePostgres,
+		Collection: "users",
+		Operation:  "DELETE",
+	}
+	s2.End()
+	txn.End()
+
+	app.ExpectSlowQueries(t, []internal.WantSlowQuery{{
+		Count:      1,
+		MetricName: "Datastore/statement/Postgres/users/SELECT",
+		Query:      "SELECT * FROM users WHERE id IN (...)",
+		TxnName:    "OtherTransaction/Go/hello",
+	}, {
+		Count:      1,
+		MetricName: "Datastore/statement/Postgres/users/DELETE",
+		Query:      "'DELETE' on 'users' using 'Postgres'",
+		TxnName:    "OtherTransaction/Go/hello",
+	}})
+	app.ExpectSpanEvents(t, []internal.WantEvent{
+		{
+			Intrinsics: map[string]interface{}{
+				"name":      "Datastore/statement/Postgres/users/SELECT",
+				"category":  "datastore",
+				"component": "Postgres",
+				"span.kind": "client",
+				"parentId":  internal.MatchAnything,
+			},
+			AgentAttributes: map[string]interface{}{
+				"db.statement":  "SELECT * FROM users WHERE id IN (...)",
+				"db.collection": "users",
+			},
+		},
+		{
+			Intrinsics: map[string]interface{}{
+				"name":      "Datastore/statement/Postgres/users/DELETE",
+				"category":  "datastore",
+				"component": "Postgres",
+				"span.kind": "client",
+				"parentId":  internal.MatchAnything,
+			},
+			AgentAttributes: map[string]interface{}{
+				"db.statement":  "'DELETE' on 'users' using 'Postgres'",
+				"db.collection": "users",
+			},
+		},
+		{
+			Intrinsics: map[string]interface{}{
+				"name":             "OtherTransaction/Go/hello",
+				"category":         "generic",
+				"nr.entryPoint":    true,
+				"transaction.name": "OtherTransaction/Go/hello",
+			},
+		},
+	})
+}
@@ -4,8 +4,10 @@
 package newrelic
 
 import (
+	"context"
 	"net/http"
 	"testing"
+	"time"
 
 	"github.com/newrelic/go-agent/v3/internal"
 )
@@ -893,6 +895,173 @@ func TestSpanEventHTTPStatusCode(t *testing.T) {
 	})
 }
 
+func TestSpanEventHTTPSemanticConventions(t *testing.T) {
+	for mode, attrs := range map[SemanticConventionsMode]map[string]interface{}{
+		SemanticConventionsDuplicate: {
+			"http.url":                  "http://example.com",
+			"http.method":               "GET",
+			"http.statusCode":           200,
+			"url.full":                  "http://example.com",
+			"http.request.method":       "GET",
+			"http.response.status_code": 200,
+		},
+		SemanticConventionsOpenTelemetry: {
+			"url.full":                  "http://example.com",
+			"http.request.method":       "GET",
+			"http.response.status_code": 200,
+		},
+	} {
+		app := testApp(distributedTracingReplyFields, func(cfg *Config) {
+			enableBetterCAT(cfg)
+			cfg.SemanticConventions.HTTP = mode
+		}, t)
+		txn := app.StartTransaction("hello")
+		req, _ := http.NewRequest("GET", "http://example.com?ignore=me", nil)
+		s := StartExternalSegment(txn, req)
+		s.SetStatusCode(200)
+		s.End()
+		app.expectNoLoggedErrors(t)
+		txn.End()
+		app.ExpectSpanEvents(t, []internal.WantEvent{
+			{
+				Intrinsics: map[string]interface{}{
+					"parentId":  internal.MatchAnything,
+					"name":      "External/example.com/http/GET",
+					"category":  "http",
+					"component": "http",
+					"span.kind": "client",
+				},
+				UserAttributes:  map[string]interface{}{},
+				AgentAttributes: attrs,
+			},
+			{
+				Intrinsics: map[string]interface{}{
+					"name":             "OtherTransaction/Go/hello",
+					"transaction.name": "OtherTransaction/Go/hello",
+					"sampled":          true,
+					"category":         "generic",
+					"nr.entryPoint":    true,
+				},
+				UserAttributes:  map[string]interface{}{},
+				AgentAttributes: map[string]interface{}{},
+			},
+		})
+	}
+}
+
+func TestSpanEventStackTrace(t *testing.T) {
+	app := testApp(distributedTracingReplyFields, func(cfg *Config) {
+		enableBetterCAT(cfg)
+		ConfigSpanEventsStackTraceThreshold(time.Nanosecond)(cfg)
+	}, t)
+	txn := app.StartTransaction("hello")
+	slow := txn.StartSegment("slow")
+	time.Sleep(time.Millisecond)
+	slow.End()
+	app.expectNoLoggedErrors(t)
+	txn.End()
+	app.ExpectSpanEvents(t, []internal.WantEvent{
+		{
+			Intrinsics: map[string]interface{}{
+				"parentId": internal.MatchAnything,
+				"name":     "Custom/slow",
+				"category": "generic",
+			},
+			UserAttributes: map[string]interface{}{},
+			AgentAttributes: map[string]interface{}{
+				"code.stacktrace": internal.MatchAnything,
+			},
+		},
+		{
+			Intrinsics: map[string]interface{}{
+				"name":             "OtherTransaction/Go/hello",
+				"transaction.name": "OtherTransaction/Go/hello",
+				"sampled":          true,
+				"category":         "generic",
+				"nr.entryPoint":    true,
+			},
+			UserAttributes:  map[string]interface{}{},
+			AgentAttributes: map[string]interface{}{},
+		},
+	})
+}
+
+func TestSpanEventContextDeadline(t *testing.T) {
+	app := testApp(distributedTracingReplyFields, func(cfg *Config) {
+		enableBetterCAT(cfg)
+		cfg.SpanEvents.ContextErrors = true
+	}, t)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	txn := app.StartTransaction("hello", WithContext(ctx))
+	s := txn.StartSegmentWithContext(ctx, "segment")
+	cancel()
+	s.End()
+	app.expectNoLoggedErrors(t)
+	txn.End()
+	app.ExpectSpanEvents(t, []internal.WantEvent{
+		{
+			Intrinsics: map[string]interface{}{
+				"parentId": internal.MatchAnything,
+				"name":     "Custom/segment",
+				"category": "generic",
+			},
+			UserAttributes: map[string]interface{}{},
+			AgentAttributes: map[string]interface{}{
+				"context.deadline.remaining": internal.MatchAnything,
+				"context.error":              "context canceled",
+				"error.class":                "context.Canceled",
+				"error.message":              "context canceled",
+			},
+		},
+		{
+			Intrinsics: map[string]interface{}{
+				"name":             "OtherTransaction/Go/hello",
+				"transaction.name": "OtherTransaction/Go/hello",
+				"sampled":          true,
+				"category":         "generic",
+				"nr.entryPoint":    true,
+			},
+			UserAttributes: map[string]interface{}{},
+			AgentAttributes: map[string]interface{}{
+				"context.deadline.remaining": internal.MatchAnything,
+				"context.error":              "context canceled",
+				"error.class":                "context.Canceled",
+				"error.message":              "context canceled",
+			},
+		},
+	})
+}
+
+func TestAddContextAttributes(t *testing.T) {
+	start := time.Now()
+	ctx, cancel := context.WithDeadline(context.Background(), start.Add(2*time.Second))
+	defer cancel()
+
+	var attrs spanAttributeMap
+	addContextAttributes(&attrs, ctx, start, true)
+	if len(attrs) != 1 {
+		t.Fatal(attrs)
+	}
+	if remaining := attrs[SpanAttributeContextDeadlineRemaining]; remaining != floatJSONWriter(2) {
+		t.Error(remaining)
+	}
+
+	ctx, cancel = context.WithDeadline(context.Background(), start)
+	defer cancel()
+	attrs = spanAttributeMap{}
+	attrs.addString(SpanAttributeErrorClass, "myClass")
+	addContextAttributes(&attrs, ctx, start, true)
+	if class := attrs[SpanAttributeErrorClass]; class != stringJSONWriter("myClass") {
+		t.Error(class)
+	}
+	if msg := attrs[SpanAttributeContextError]; msg != stringJSONWriter("context deadline exceeded") {
+		t.Error(msg)
+	}
+	if class := contextErrorClass(ctx.Err()); class != "context.DeadlineExceeded" {
+		t.Error(class)
+	}
+}
+
 func TestSpanEvent_TxnCustomAttrsAreCopied(t *testing.T) {
 	app := testApp(distributedTracingReplyFields, enableBetterCAT, t)
 	txn := app.StartTransaction("hello")
@@ -1160,3 +1329,52 @@ func TestAddSpanAttributeSecurityPolicyDisablesParameters(t *testing.T) {
 		},
 	})
 }
+
+func TestSpanEventsAggregation(t *testing.T) {
+	replyfn := func(reply *internal.ConnectReply) {
+		reply.SetSampleEverything()
+	}
+	cfgfn := func(cfg *Config) {
+		cfg.DistributedTracer.Enabled = true
+		cfg.SpanEvents.Aggregation.Enabled = true
+	}
+	app := testApp(replyfn, cfgfn, t)
+	txn := app.StartTransaction("hello")
+	for i := 0; i < 3; i++ {
+		txn.StartSegment("mySegment").End()
+	}
+	txn.End()
+	app.ExpectSpanEvents(t, []internal.WantEvent{
+		{
+			Intrinsics: map[string]interface{}{
+				"name":          "Custom/mySegment",
+				"sampled":       true,
+				"category":      "generic",
+				"priority":      internal.MatchAnything,
+				"guid":          internal.MatchAnything,
+				"transactionId": internal.MatchAnything,
+				"traceId":       internal.MatchAnything,
+				"parentId":      internal.MatchAnything,
+			},
+			UserAttributes: map[string]interface{}{},
+			AgentAttributes: map[string]interface{}{
+				"nr.aggregateCount": 3,
+			},
+		},
+		{
+			Intrinsics: map[string]interface{}{
+				"name":             "OtherTransaction/Go/hello",
+				"transaction.name": "OtherTransaction/Go/hello",
+				"sampled":          true,
+				"category":         "generic",
+				"priority":         internal.MatchAnything,
+				"guid":             internal.MatchAnything,
+				"transactionId":    internal.MatchAnything,
+				"nr.entryPoint":    true,
+				"traceId":          internal.MatchAnything,
+			},
+			UserAttributes:  map[string]interface{}{},
+			AgentAttributes: map[string]interface{}{},
+		},
+	})
+}
@@ -0,0 +1,83 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package newrelic
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/newrelic/go-agent/v3/internal"
+)
+
+func TestStartSpan(t *testing.T) {
+	app := testApp(distributedTracingReplyFields, enableBetterCAT, t)
+	span := app.StartSpan("cache refresh")
+	span.AddAttribute("entries", 12)
+	span.NoticeError(errors.New("cache unavailable"))
+	span.End()
+	span.End()
+	span.AddAttribute("late", true)
+
+	app.ExpectSpanEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"name":          "cache refresh",
+			"category":      "generic",
+			"span.kind":     "internal",
+			"nr.entryPoint": true,
+		},
+		UserAttributes: map[string]interface{}{
+			"entries": 12,
+		},
+		AgentAttributes: map[string]interface{}{
+			"error.class":   "*errors.errorString",
+			"error.message": "cache unavailable",
+		},
+	}})
+	app.ExpectTxnEvents(t, []internal.WantEvent{})
+	app.ExpectErrors(t, []internal.WantError{})
+}
+
+func TestStartSpanDistributedTracingDisabled(t *testing.T) {
+	app := testApp(distributedTracingReplyFields, func(cfg *Config) {
+		cfg.DistributedTracer.Enabled = false
+	}, t)
+	span := app.StartSpan("cache refresh")
+	span.AddAttribute("entries", 12)
+	span.End()
+
+	app.ExpectSpanEvents(t, []internal.WantEvent{})
+}
+
+func TestStartSpanHighSecurity(t *testing.T) {
+	app := testApp(distributedTracingReplyFields, func(cfg *Config) {
+		enableBetterCAT(cfg)
+		cfg.HighSecurity = true
+	}, t)
+	span := app.StartSpan("cache refresh")
+	span.AddAttribute("entries", 12)
+	span.NoticeError(errors.New("cache unavailable"))
+	span.End()
+
+	app.ExpectSpanEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"name":          "cache refresh",
+			"category":      "generic",
+			"span.kind":     "internal",
+			"nr.entryPoint": true,
+		},
+		UserAttributes: map[string]interface{}{},
+		AgentAttributes: map[string]interface{}{
+			"error.class":   "*errors.errorString",
+			"error.message": highSecurityErrorMsg,
+		},
+	}})
+}
+
+func TestStartSpanNil(t *testing.T) {
+	var app *Application
+	span := app.StartSpan("cache refresh")
+	span.AddAttribute("entries", 12)
+	span.NoticeError(errors.New("cache unavailable"))
+	span.End()
+}
@@ -76,6 +76,10 @@ func inboundSyntheticsRequestBuilder(oldCatEnabled bool, betterCatEnabled bool)
 }
 
 func TestSyntheticsOldCAT(t *testing.T) {
+	if !crossAppTracingIncluded {
+		t.Skip("Cross Application Tracing is excluded from the build")
+	}
+
 	cfgFn := func(cfg *Config) {
 		cfg.CrossApplicationTracer.Enabled = true
 		cfg.DistributedTracer.Enabled = false
@@ -12,6 +12,7 @@ import (
 	"net/http/httptest"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -290,6 +291,10 @@ func testApp(replyfn func(*internal.ConnectReply), cfgfn func(*Config), t testin
 }
 
 func TestRecordLLMFeedbackEventSuccess(t *testing.T) {
+	if !aiMonitoringIncluded {
+		t.Skip("AI Monitoring is excluded from the build")
+	}
+
 	app := testApp(nil, nil, t)
 	app.RecordLLMFeedbackEvent("traceid", "5", "informative", "message", validParams)
 	app.expectNoLoggedErrors(t)
@@ -376,6 +381,41 @@ func TestRecordCustomEventRemoteDisable(t *testing.T) {
 	app.ExpectCustomEvents(t, []internal.WantEvent{})
 }
 
+func TestRecordCustomEventTypeNotAllowed(t *testing.T) {
+	cfgfn := func(cfg *Config) {
+		cfg.CustomInsightsEvents.AllowedEventTypes = []string{"myType", "otherType"}
+		cfg.CustomInsightsEvents.DeniedEventTypes = []string{"otherType"}
+	}
+	app := testApp(nil, cfgfn, t)
+	for _, eventType := range []string{"myType", "otherType", "unknownType"} {
+		err := app.app.RecordCustomEvent(eventType, validParams)
+		if (eventType == "myType") != (err == nil) {
+			t.Error(eventType, err)
+		}
+	}
+	app.ExpectCustomEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"type":      "myType",
+			"timestamp": internal.MatchAnything,
+		},
+		UserAttributes: validParams,
+	}})
+	if dropped := app.app.testHarvest.CustomEvents.quotas.dropped; dropped["otherType"] != 1 || dropped["unknownType"] != 1 {
+		t.Error(dropped)
+	}
+}
+
+func TestCustomEventTypeFilter(t *testing.T) {
+	f := newCustomEventTypeFilter(nil, []string{"denied"})
+	if !f.permits("myType") || f.permits("denied") {
+		t.Error(f)
+	}
+	f = newCustomEventTypeFilter([]string{"allowed"}, nil)
+	if !f.permits("allowed") || f.permits("myType") {
+		t.Error(f)
+	}
+}
+
 func TestRecordCustomMetricSuccess(t *testing.T) {
 	app := testApp(nil, nil, t)
 	app.RecordCustomMetric("myMetric", 123.0)
@@ -386,6 +426,57 @@ func TestRecordCustomMetricSuccess(t *testing.T) {
 	})
 }
 
+func TestRecordHistogram(t *testing.T) {
+	app := testApp(nil, nil, t)
+	bounds := []float64{1, 10}
+	app.RecordHistogram("myHistogram", 0.5, bounds)
+	app.RecordHistogram("myHistogram", 1, bounds)
+	app.RecordHistogram("myHistogram", 5, bounds)
+	app.RecordHistogram("myHistogram", 50, bounds)
+	app.expectNoLoggedErrors(t)
+	app.ExpectMetrics(t, []internal.WantMetric{
+		{Name: "Custom/myHistogram", Scope: "", Forced: false, Data: []float64{4, 56.5, 56.5, 0.5, 50, 0.25 + 1 + 25 + 2500}},
+		{Name: "Custom/myHistogram/bucket/1", Scope: "", Forced: false, Data: []float64{2, 0, 0, 0, 0, 0}},
+		{Name: "Custom/myHistogram/bucket/10", Scope: "", Forced: false, Data: []float64{1, 0, 0, 0, 0, 0}},
+		{Name: "Custom/myHistogram/bucket/+Inf", Scope: "", Forced: false, Data: []float64{1, 0, 0, 0, 0, 0}},
+	})
+}
+
+func TestRecordHistogramDefaultBounds(t *testing.T) {
+	app := testApp(nil, nil, t)
+	app.RecordHistogram("myHistogram", 0.3, nil)
+	app.expectNoLoggedErrors(t)
+	app.ExpectMetrics(t, []internal.WantMetric{
+		{Name: "Custom/myHistogram", Scope: "", Forced: false, Data: nil},
+		{Name: "Custom/myHistogram/bucket/0.5", Scope: "", Forced: false, Data: []float64{1, 0, 0, 0, 0, 0}},
+	})
+}
+
+func TestRecordHistogramInvalidBounds(t *testing.T) {
+	app := testApp(nil, nil, t)
+	app.RecordHistogram("myHistogram", 1, []float64{10, 1})
+	app.expectSingleLoggedError(t, "unable to record histogram", map[string]interface{}{
+		"metric-name": "myHistogram",
+		"reason":      errHistogramBoundsInvalid.Error(),
+	})
+	app.ExpectMetrics(t, []internal.WantMetric{})
+}
+
+func TestValidateHistogramBounds(t *testing.T) {
+	if err := validateHistogramBounds(defaultHistogramBounds); err != nil {
+		t.Error(err)
+	}
+	if err := validateHistogramBounds([]float64{1, 1}); err != errHistogramBoundsInvalid {
+		t.Error(err)
+	}
+	if err := validateHistogramBounds([]float64{1, math.Inf(1)}); err != errHistogramBoundsInvalid {
+		t.Error(err)
+	}
+	if err := validateHistogramBounds(make([]float64, maxHistogramBounds+1)); err != errHistogramBoundsTooLarge {
+		t.Error(err)
+	}
+}
+
 func TestRecordCustomMetricNameEmpty(t *testing.T) {
 	app := testApp(nil, nil, t)
 	app.RecordCustomMetric("", 123.0)
@@ -1313,6 +1404,86 @@ func TestTraceSegmentNilErr(t *testing.T) {
 	}, webMetrics...))
 }
 
+func TestTraceSampledSegment(t *testing.T) {
+	app := testApp(nil, ConfigDistributedTracerEnabled(false), t)
+	sampler := NewSegmentSampler("hot", 5)
+	txn := app.StartTransaction("hello")
+	txn.SetWebRequestHTTP(helloRequest)
+	var recorded int
+	for i := 0; i < 10; i++ {
+		s := sampler.Start(txn)
+		if nil != s {
+			recorded++
+		}
+		s.End()
+	}
+	if recorded != 2 {
+		t.Error(recorded)
+	}
+	app.expectNoLoggedErrors(t)
+	txn.End()
+	scope := "WebTransaction/Go/hello"
+	app.ExpectMetrics(t, append([]internal.WantMetric{
+		{Name: "Custom/hot", Scope: "", Forced: false, Data: nil},
+		{Name: "Custom/hot", Scope: scope, Forced: false, Data: nil},
+	}, webMetrics...))
+	app.ExpectMetricsPresent(t, []internal.WantMetric{
+		{Name: "Custom/hot", Scope: scope, Forced: false, Data: []float64{10}},
+	})
+}
+
+func TestTraceSampledSegmentConcurrent(t *testing.T) {
+	app := testApp(nil, ConfigDistributedTracerEnabled(false), t)
+	sampler := NewSegmentSampler("hot", 10)
+	txn := app.StartTransaction("hello")
+	var recorded int64
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			gtxn := txn.NewGoroutine()
+			for i := 0; i < 25; i++ {
+				if s := sampler.Start(gtxn); nil != s {
+					atomic.AddInt64(&recorded, 1)
+					s.End()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	txn.End()
+	// The calls of every goroutine are counted together.
+	if recorded != 10 {
+		t.Error(recorded)
+	}
+}
+
+func TestTraceSampledSegmentNil(t *testing.T) {
+	var txn *Transaction
+	NewSegmentSampler("hot", 5).Start(txn).End()
+	NewSegmentSampler("hot", 1).Start(txn).End()
+	var sampler *SegmentSampler
+	sampler.Start(txn).End()
+}
+
+func TestTraceSampledSegmentRateOne(t *testing.T) {
+	app := testApp(nil, ConfigDistributedTracerEnabled(false), t)
+	sampler := NewSegmentSampler("hot", 0)
+	txn := app.StartTransaction("hello")
+	for i := 0; i < 3; i++ {
+		s := sampler.Start(txn)
+		if nil == s {
+			t.Fatal("call not recorded")
+		}
+		s.End()
+	}
+	txn.End()
+	app.ExpectMetricsPresent(t, []internal.WantMetric{
+		{Name: "Custom/hot", Scope: "", Forced: false, Data: []float64{3}},
+	})
+}
+
 func TestTraceSegmentOutOfOrder(t *testing.T) {
 	app := testApp(nil, ConfigDistributedTracerEnabled(false), t)
 	txn := app.StartTransaction("hello")
@@ -1359,6 +1530,36 @@ func TestTraceSegmentEndedBeforeEndSegment(t *testing.T) {
 	app.ExpectMetrics(t, webMetrics)
 }
 
+func TestTraceSegmentPauseResume(t *testing.T) {
+	app := testApp(nil, ConfigDistributedTracerEnabled(false), t)
+	txn := app.StartTransaction("hello")
+	txn.SetWebRequestHTTP(helloRequest)
+	s := txn.StartSegment("segment")
+	s.Pause()
+	s.Resume()
+	app.expectNoLoggedErrors(t)
+	s.End()
+	s.Pause()
+	app.expectSingleLoggedError(t, "unable to pause segment", map[string]interface{}{
+		"reason": errSegmentNotInProgress.Error(),
+		"name":   "segment",
+	})
+	txn.End()
+	s.Resume()
+	app.expectSingleLoggedError(t, "unable to resume segment", map[string]interface{}{
+		"reason": errAlreadyEnded.Error(),
+		"name":   "segment",
+	})
+	app.ExpectMetrics(t, append([]internal.WantMetric{
+		{Name: "Custom/segment", Scope: "", Forced: false, Data: nil},
+		{Name: "Custom/segment", Scope: "WebTransaction/Go/hello", Forced: false, Data: nil},
+	}, webMetrics...))
+
+	var nilSegment *Segment
+	nilSegment.Pause()
+	nilSegment.Resume()
+}
+
 func TestTraceSegmentPanic(t *testing.T) {
 	app := testApp(nil, ConfigDistributedTracerEnabled(false), t)
 	txn := app.StartTransaction("hello")
@@ -2418,3 +2619,139 @@ func TestMessageProducerSegmentNilSegment(t *testing.T) {
 	var s *MessageProducerSegment
 	s.End()
 }
+
+func TestMessageConsumerSegmentBasic(t *testing.T) {
+	replyfn := func(reply *internal.ConnectReply) {
+		reply.SetSampleEverything()
+	}
+	cfgfn := func(cfg *Config) {
+		cfg.DistributedTracer.Enabled = true
+	}
+	app := testApp(replyfn, cfgfn, t)
+	txn := app.StartTransaction("hello")
+	s := MessageConsumerSegment{
+		StartTime:        txn.StartSegmentNow(),
+		Library:          "Kafka",
+		DestinationType:  MessageTopic,
+		DestinationName:  "orders",
+		MessageTimestamp: time.Now().Add(-2 * time.Second),
+		MessageCount:     3,
+	}
+	s.End()
+	app.expectNoLoggedErrors(t)
+	txn.End()
+	app.ExpectMetrics(t, []internal.WantMetric{
+		{Name: "OtherTransaction/Go/hello", Scope: "", Forced: true, Data: nil},
+		{Name: "OtherTransaction/all", Scope: "", Forced: true, Data: nil},
+		{Name: "OtherTransactionTotalTime", Scope: "", Forced: true, Data: nil},
+		{Name: "OtherTransactionTotalTime/Go/hello", Scope: "", Forced: false, Data: nil},
+		{Name: "DurationByCaller/Unknown/Unknown/Unknown/Unknown/all", Scope: "", Forced: false, Data: nil},
+		{Name: "DurationByCaller/Unknown/Unknown/Unknown/Unknown/allOther", Scope: "", Forced: false, Data: nil},
+		{Name: "MessageBroker/Kafka/Topic/Consume/Named/orders", Scope: "", Forced: false, Data: nil},
+		{Name: "MessageBroker/Kafka/Topic/Consume/Named/orders", Scope: "OtherTransaction/Go/hello", Forced: false, Data: nil},
+	})
+	app.ExpectSpanEvents(t, []internal.WantEvent{
+		{
+			Intrinsics: map[string]interface{}{
+				"parentId": internal.MatchAnything,
+				"name":     "MessageBroker/Kafka/Topic/Consume/Named/orders",
+				"category": "generic",
+			},
+			UserAttributes: map[string]interface{}{},
+			AgentAttributes: map[string]interface{}{
+				"message.queueTime": internal.MatchAnything,
+				"message.count":     3,
+			},
+		},
+		{
+			Intrinsics: map[string]interface{}{
+				"name":             "OtherTransaction/Go/hello",
+				"transaction.name": "OtherTransaction/Go/hello",
+				"sampled":          true,
+				"category":         "generic",
+				"nr.entryPoint":    true,
+			},
+			UserAttributes:  map[string]interface{}{},
+			AgentAttributes: map[string]interface{}{},
+		},
+	})
+}
+
+func TestMessageConsumerSegmentTemp(t *testing.T) {
+	replyfn := func(reply *internal.ConnectReply) {
+		reply.SetSampleEverything()
+	}
+	cfgfn := func(cfg *Config) {
+		cfg.DistributedTracer.Enabled = true
+	}
+	app := testApp(replyfn, cfgfn, t)
+	txn := app.StartTransaction("hello")
+	s := MessageConsumerSegment{
+		StartTime:            txn.StartSegmentNow(),
+		Library:              "RabbitMQ",
+		DestinationTemporary: true,
+		DestinationName:      "myQueue0123456789",
+		// A timestamp after the start of the segment, due to clock skew,
+		// is recorded as no queue time.
+		MessageTimestamp: time.Now().Add(time.Hour),
+	}
+	s.End()
+	app.expectNoLoggedErrors(t)
+	txn.End()
+	app.ExpectMetricsPresent(t, []internal.WantMetric{
+		{Name: "MessageBroker/RabbitMQ/Queue/Consume/Temp", Scope: "", Forced: false, Data: nil},
+		{Name: "MessageBroker/RabbitMQ/Queue/Consume/Temp", Scope: "OtherTransaction/Go/hello", Forced: false, Data: nil},
+	})
+	app.ExpectSpanEvents(t, []internal.WantEvent{
+		{
+			Intrinsics: map[string]interface{}{
+				"parentId": internal.MatchAnything,
+				"name":     "MessageBroker/RabbitMQ/Queue/Consume/Temp",
+				"category": "generic",
+			},
+			UserAttributes: map[string]interface{}{},
+			AgentAttributes: map[string]interface{}{
+				"message.queueTime": 0.0,
+			},
+		},
+		{
+			Intrinsics: map[string]interface{}{
+				"name":             "OtherTransaction/Go/hello",
+				"transaction.name": "OtherTransaction/Go/hello",
+				"sampled":          true,
+				"category":         "generic",
+				"nr.entryPoint":    true,
+			},
+			UserAttributes:  map[string]interface{}{},
+			AgentAttributes: map[string]interface{}{},
+		},
+	})
+}
+
+func TestMessageConsumerSegmentTxnEnded(t *testing.T) {
+	app := testApp(nil, ConfigDistributedTracerEnabled(false), t)
+	txn := app.StartTransaction("hello")
+	s := MessageConsumerSegment{
+		StartTime:       txn.StartSegmentNow(),
+		Library:         "RabbitMQ",
+		DestinationName: "myQueue",
+	}
+	txn.End()
+	s.End()
+	app.expectSingleLoggedError(t, "unable to end message consumer segment", map[string]interface{}{
+		"reason": errAlreadyEnded.Error(),
+	})
+}
+
+func TestMessageConsumerSegmentNilSegment(t *testing.T) {
+	var s *MessageConsumerSegment
+	s.End()
+	s.AddAttribute("key", "val")
+
+	var txn *Transaction
+	s = &MessageConsumerSegment{
+		StartTime: txn.StartSegmentNow(),
+		Library:   "RabbitMQ",
+	}
+	s.End()
+}
@@ -4,6 +4,7 @@
 package newrelic
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log"
@@ -12,6 +13,7 @@ import (
 	"reflect"
 	"runtime"
 	"runtime/debug"
+	"strings"
 	"sync"
 	"time"
 
@@ -30,13 +32,63 @@ type txn struct {
 	finished           bool
 	numPayloadsCreated uint32
 	sampledCalculated  bool
+	// sampledOverride is true when the sampling decision was set using
+	// the WithSampled option and must not be changed.
+	sampledOverride bool
+	// inboundSampled is true when the sampling decision was received in an
+	// inbound payload and may be changed by the configured Sampler.
+	inboundSampled bool
+	// priorityBoost is the boost of the priority set using
+	// SetSamplingPriority.
+	priorityBoost priority
 
 	ignore bool
 
+	// nameGuardMetric is the supportability metric recorded when the
+	// transaction name was collapsed by the txnNameGuard.
+	nameGuardMetric string
+
+	// explainPlans is the number of query plans captured by the
+	// transaction's datastore segments.
+	explainPlans int
+
+	// batch contains the counts of the batch job when the transaction was
+	// started using StartBatchTransaction.
+	batch *batchSummary
+
+	// saga contains the outcome of the saga when the transaction was
+	// started using StartSagaTransaction.
+	saga *sagaSummary
+
+	// tenant is the tenant of the transaction when tenant accounting is
+	// enabled.
+	tenant *txnTenant
+
+	// deadLetter contains the dead-lettered message processed by the
+	// transaction, set using AcceptDeadLetter.
+	deadLetter *deadLetterSummary
+
 	// wroteHeader prevents capturing multiple response code errors if the
 	// user erroneously calls WriteHeader multiple times.
 	wroteHeader bool
 
+	// ctx is the context set using WithContext, whose deadline budget is
+	// recorded on the root span.
+	ctx context.Context
+
+	// goroutines contains the Transaction references bound to goroutines
+	// by ID when Config.GoroutineTransactions is enabled.
+	goroutines map[uint64]*Transaction
+
+	// runtimeAtStart is the reading of the runtime/metrics at the start of
+	// the transaction when Config.RuntimeSampler.TransactionAttributes is
+	// enabled.
+	runtimeAtStart *txnRuntimeReading
+
+	// consumesMessages is true when the transaction consumes a message,
+	// which gives background transactions the message Apdex threshold.
+	consumesMessages bool
+
 	txnData
 
 	mainThread   tracingThread
@@ -112,10 +164,14 @@ func newTxn(app *app, run *appRun, name string, opts ...TraceOption) *thread {
 	for _, o := range opts {
 		o(&txnOpts)
 	}
+	if run.Config.RuntimeSampler.TransactionAttributes {
+		txn.runtimeAtStart = readTxnRuntimeMetrics()
+	}
 	txn.markStart(time.Now())
 
 	txn.Name = name
 	txn.Attrs = newAttributes(run.AttributeConfig)
+	txn.ctx = txnOpts.Context
 
 	if !txnOpts.SuppressCLM && run.Config.CodeLevelMetrics.Enabled && (txnOpts.DemandCLM || run.Config.CodeLevelMetrics.Scope == 0 || (run.Config.CodeLevelMetrics.Scope&TransactionCLM) != 0) {
 		reportCodeLevelMetrics(txnOpts, run, txn.Attrs.Agent.Add)
@@ -130,12 +186,37 @@ func newTxn(app *app, run *appRun, name string, opts ...TraceOption) *thread {
 		txn.BetterCAT.Priority = newPriorityFromRandom(txn.TraceIDGenerator.Float32)
 		txn.ShouldCollectSpanEvents = txn.shouldCollectSpanEvents
 		txn.ShouldCreateSpanGUID = txn.shouldCreateSpanGUID
+		if nil != txnOpts.SampledOverride {
+			txn.sampledOverride = true
+			txn.BetterCAT.Sampled = *txnOpts.SampledOverride
+			if txn.BetterCAT.Sampled {
+				txn.BetterCAT.Priority += 1.0
+			}
+			txn.sampledCalculated = true
+		}
 	}
+	txn.ignore = txnOpts.IgnoreTransaction
 
 	txn.Attrs.Agent.Add(AttributeHostDisplayName, txn.Config.HostDisplayName, nil)
+	txn.Attrs.Agent.Add(AttributeSystemdUnit, txn.Config.systemdUnit.Name, nil)
+	txn.Attrs.Agent.Add(AttributeSystemdInvocationID, txn.Config.systemdUnit.InvocationID, nil)
+	txn.Attrs.Agent.Add(AttributeCloudProvider, txn.Config.cloudPlatform.Provider, nil)
+	txn.Attrs.Agent.Add(AttributeCloudPlatform, txn.Config.cloudPlatform.Platform, nil)
+	txn.Attrs.Agent.Add(AttributeCloudRegion, txn.Config.cloudPlatform.Region, nil)
+	txn.Attrs.Agent.Add(AttributeCloudAccountID, txn.Config.cloudPlatform.AccountID, nil)
+	txn.Attrs.Agent.Add(AttributeCloudResourceID, txn.Config.cloudPlatform.ResourceID, nil)
+	txn.Attrs.Agent.Add(AttributeFaasName, txn.Config.cloudPlatform.FaasName, nil)
+	txn.Attrs.Agent.Add(AttributeFaasVersion, txn.Config.cloudPlatform.FaasVersion, nil)
+	txn.Attrs.Agent.Add(AttributeFaasInstance, txn.Config.cloudPlatform.FaasInstance, nil)
+	for _, attr := range txn.Config.kubernetesAttributes {
+		txn.Attrs.Agent.Add(attr.name, attr.value, nil)
+	}
 	txn.TxnTrace.Enabled = txn.Config.TransactionTracer.Enabled
 	txn.TxnTrace.SegmentThreshold = txn.Config.TransactionTracer.Segments.Threshold
 	txn.TxnTrace.StackTraceThreshold = txn.Config.TransactionTracer.Segments.StackTraceThreshold
+	if txn.Config.SpanEvents.StackTraces.Enabled {
+		txn.SpanStackTraces = newSpanStackTraces(&txn.Config.Config)
+	}
 	txn.SlowQueriesEnabled = txn.Config.DatastoreTracer.SlowQuery.Enabled
 	txn.SlowQueryThreshold = txn.Config.DatastoreTracer.SlowQuery.Threshold
 
@@ -201,14 +282,49 @@ func (txn *txn) lazilyCalculateSampled() bool {
 	if txn.sampledCalculated {
 		return txn.BetterCAT.Sampled
 	}
+	txn.sampledCalculated = true
+	// Transactions ignored by name are not sampled so that they do not
+	// consume the sampling budget of the adaptive sampler.
+	if txn.ignoredByName() {
+		txn.BetterCAT.Sampled = false
+		return false
+	}
+	switch txn.samplerDecision() {
+	case SamplingDecisionSample:
+		txn.BetterCAT.Sampled = true
+		if txn.BetterCAT.Priority-txn.priorityBoost < 1.0 {
+			txn.BetterCAT.Priority += 1.0
+		}
+		return true
+	case SamplingDecisionDrop:
+		txn.BetterCAT.Sampled = false
+		return false
+	}
+	if txn.inboundSampled {
+		return txn.BetterCAT.Sampled
+	}
 	txn.BetterCAT.Sampled = txn.appRun.adaptiveSampler.computeSampled(txn.BetterCAT.Priority.Float32(), time.Now())
 	if txn.BetterCAT.Sampled {
 		txn.BetterCAT.Priority += 1.0
 	}
-	txn.sampledCalculated = true
 	return txn.BetterCAT.Sampled
 }
 
+// snapshotHeader returns a copy of the header map, so that the agent reads
+// the headers from a map which the caller cannot modify concurrently.  The
+// value slices are shared, since they are not usually modified in place.  It
+// returns nil if the header is nil.
+func snapshotHeader(h http.Header) http.Header {
+	if h == nil {
+		return nil
+	}
+	cp := make(http.Header, len(h))
+	for key, values := range h {
+		cp[key] = values
+	}
+	return cp
+}
+
 func (txn *txn) SetWebRequest(r WebRequest) error {
 	txn.Lock()
 	defer txn.Unlock()
@@ -224,6 +340,7 @@ func (txn *txn) SetWebRequest(r WebRequest) error {
 	if nil != h {
 		txn.Queuing = queueDuration(h, txn.Start)
 		txn.acceptDistributedTraceHeadersLocked(r.Transport, h)
+		txn.acceptAWSTraceHeaderLocked(h)
 		txn.CrossProcess.InboundHTTPRequest(h)
 	}
 
@@ -282,13 +399,30 @@ func (txn *txn) freezeName() {
 		return
 	}
 	txn.FinalName = txn.appRun.createTransactionName(txn.Name, txn.IsWeb)
-	if txn.FinalName == "" {
+	if txn.FinalName == "" || txn.Config.ignoredTxnNames.match(txn.FinalName) {
 		txn.ignore = true
+		return
 	}
+	txn.FinalName, txn.nameGuardMetric = txn.appRun.txnNameGuard.guard(txn.FinalName)
 }
 
 func (txn *txn) getsApdex() bool {
-	return txn.IsWeb
+	return txn.IsWeb || txn.Config.BackgroundApdex.Enabled
+}
+
+// apdexThreshold returns the Apdex threshold of the transaction.  Key
+// transaction thresholds take precedence over the background thresholds.
+func (txn *txn) apdexThreshold() time.Duration {
+	if _, ok := txn.Reply.KeyTxnApdex[txn.FinalName]; ok || txn.IsWeb || !txn.Config.BackgroundApdex.Enabled {
+		return internal.CalculateApdexThreshold(txn.Reply, txn.FinalName)
+	}
+	if txn.consumesMessages && txn.Config.BackgroundApdex.MessageThreshold > 0 {
+		return txn.Config.BackgroundApdex.MessageThreshold
+	}
+	if txn.Config.BackgroundApdex.Threshold > 0 {
+		return txn.Config.BackgroundApdex.Threshold
+	}
+	return internal.CalculateApdexThreshold(txn.Reply, txn.FinalName)
 }
 
 func (txn *txn) shouldSaveTrace() bool {
@@ -306,11 +440,27 @@ func (txn *txn) MergeIntoHarvest(h *harvest) {
 	if txn.BetterCAT.Enabled {
 		priority = txn.BetterCAT.Priority
 	} else {
-		priority = newPriority()
+		priority = newPriority() + txn.priorityBoost
 	}
 
 	createTxnMetrics(&txn.txnData, h.Metrics)
 	mergeBreakdownMetrics(&txn.txnData, h.Metrics)
+	if txn.nameGuardMetric != "" {
+		h.Metrics.addSingleCount(txn.nameGuardMetric, forced)
+	}
+	if txn.nameGuardMetric == txnNameCollapsedMetric {
+		h.Metrics.addSingleCount(txnNamePatternMetricPrefix+txn.FinalName, forced)
+	}
+	if nil != txn.batch {
+		txn.batch.mergeIntoHarvest(txn.FinalName, h.Metrics)
+	}
+	if nil != txn.saga {
+		txn.saga.mergeIntoHarvest(txn.FinalName, h.Metrics)
+	}
+	if nil != txn.deadLetter {
+		txn.deadLetter.mergeIntoHarvest(h.Metrics)
+	}
+	txn.tenant.mergeIntoHarvest(&txn.txnData, h.Metrics)
 
 	// Dump log events into harvest
 	// Note: this will create a surge of log events that could affect sampling.
@@ -394,6 +544,37 @@ func headersJustWritten(thd *thread, code int, hdr http.Header) {
 	}
 }
 
+// NoticeResponseError records the response code and headers of the
+// transaction, like headersJustWritten, with err as the error of the response
+// if the code is an error.  It is used by the integrations of frameworks whose handlers return
+// errors, so that the error is recorded once with its message.
+func (thd *thread) NoticeResponseError(code int, hdr http.Header, err error) {
+	txn := thd.txn
+	txn.Lock()
+	defer txn.Unlock()
+
+	if txn.finished {
+		return
+	}
+	if txn.wroteHeader {
+		return
+	}
+	txn.wroteHeader = true
+
+	responseHeaderAttributes(txn.Attrs, hdr)
+	responseCodeAttribute(txn.Attrs, code)
+
+	if txn.appRun.responseCodeIsError(code) {
+		e := txnErrorFromResponseCode(time.Now(), code)
+		if nil != err {
+			e.Msg = err.Error()
+		}
+		e.Stack = getStackTrace()
+		e.Expect = txn.appRun.responseCodeIsExpected(code)
+		thd.noticeErrorInternal(e, err, e.Expect)
+	}
+}
+
 func (txn *txn) responseHeader(hdr http.Header) http.Header {
 	txn.Lock()
 	defer txn.Unlock()
@@ -445,6 +626,7 @@ func (thd *thread) End(recovered interface{}) error {
 	}
 
 	txn.finished = true
+	txn.unbindGoroutines()
 
 	// It used to be the case that panic(nil) would cause recover() to return nil,
 	// which we test for here. However, that is no longer the case, hence the extra
@@ -459,6 +641,13 @@ func (thd *thread) End(recovered interface{}) error {
 	}
 
 	txn.markEnd(time.Now(), thd.thread)
+	if nil != txn.runtimeAtStart {
+		end := readTxnRuntimeMetrics()
+		addTxnRuntimeAttributes(txn.Attrs.Agent, txn.runtimeAtStart, end)
+		end.release()
+		txn.runtimeAtStart.release()
+		txn.runtimeAtStart = nil
+	}
 	txn.freezeName()
 	// Make a sampling decision if there have been no segments or outbound
 	// payloads.
@@ -473,7 +662,7 @@ func (thd *thread) End(recovered interface{}) error {
 
 	// Assign apdexThreshold regardless of whether or not the transaction
 	// gets apdex since it may be used to calculate the trace threshold.
-	txn.ApdexThreshold = internal.CalculateApdexThreshold(txn.Reply, txn.FinalName)
+	txn.ApdexThreshold = txn.apdexThreshold()
 
 	if txn.getsApdex() {
 		if txn.HasErrors() && txn.NoticeErrors() {
@@ -511,6 +700,9 @@ func (thd *thread) End(recovered interface{}) error {
 			root.AgentAttributes.addString(SpanAttributeErrorClass, txn.rootSpanErrData.Klass)
 			root.AgentAttributes.addString(SpanAttributeErrorMessage, scrubbedErrorMessage(txn.rootSpanErrData.Msg, txn))
 		}
+		if txn.ctx != nil {
+			addContextAttributes(&root.AgentAttributes, txn.ctx, txn.Start, txn.Config.SpanEvents.ContextErrors)
+		}
 
 		if p := txn.BetterCAT.Inbound; nil != p {
 			root.ParentID = txn.BetterCAT.Inbound.ID
@@ -527,10 +719,28 @@ func (thd *thread) End(recovered interface{}) error {
 		root.AgentAttributes = txn.Attrs.filterSpanAttributes(root.AgentAttributes, destSpan)
 		txn.SpanEvents = append(txn.SpanEvents, root)
 
+		if txn.Config.SpanEvents.Aggregation.Enabled {
+			txn.SpanEvents = aggregateSpanEvents(txn.SpanEvents, txn.Config.SpanEvents.Aggregation.KeyAttributes)
+		}
+
+		// The root span has the Kubernetes attributes as agent
+		// attributes of the transaction, and the other spans are given
+		// the same attributes, if they are not excluded.
+		var kubernetesAttrs spanAttributeMap
+		for _, attr := range txn.Config.kubernetesAttributes {
+			kubernetesAttrs.addString(attr.name, attr.value)
+		}
+		kubernetesAttrs = txn.Attrs.filterSpanAttributes(kubernetesAttrs, destSpan)
+
 		// Add transaction tracing fields to span events at the end of
 		// the transaction since we could accept payload after the early
 		// segments occur.
 		for _, evt := range txn.SpanEvents {
+			if !evt.IsEntrypoint {
+				for key, val := range kubernetesAttrs {
+					evt.AgentAttributes.add(key, val)
+				}
+			}
 			evt.TraceID = txn.BetterCAT.TraceID
 			evt.TransactionID = txn.TxnID
 			evt.Sampled = txn.BetterCAT.Sampled
@@ -539,6 +749,8 @@ func (thd *thread) End(recovered interface{}) error {
 	}
 
 	if !txn.ignore {
+		txn.tenant = txn.appRun.tenantAccountant.tenantOf(&txn.txnData)
+		txn.app.stats.recordTxn(txn.IsWeb, len(txn.Errors))
 		txn.app.Consume(txn.Reply.RunID, txn)
 		if observer := txn.app.getObserver(); nil != observer {
 			for _, evt := range txn.SpanEvents {
@@ -570,6 +782,82 @@ func (txn *txn) AddUserID(userID string) error {
 	return nil
 }
 
+func (txn *txn) AddUser(user userInfo) error {
+	txn.Lock()
+	defer txn.Unlock()
+	if txn.finished {
+		return errAlreadyEnded
+	}
+
+	txn.Attrs.Agent.Add(AttributeUserID, user.id, nil)
+	if user.email == "" && user.name == "" {
+		return nil
+	}
+	if txn.Config.HighSecurity {
+		return errHighSecurityEnabled
+	}
+	if user.email != "" {
+		txn.Attrs.Agent.Add(AttributeUserEmail, user.email, nil)
+	}
+	if user.name != "" {
+		txn.Attrs.Agent.Add(AttributeUserName, user.name, nil)
+	}
+	return nil
+}
+
+func (txn *txn) SetMessageQueueTime(enqueued time.Time) error {
+	txn.Lock()
+	defer txn.Unlock()
+	if txn.finished {
+		return errAlreadyEnded
+	}
+
+	txn.consumesMessages = true
+	queueTime := txn.Start.Sub(enqueued)
+	if queueTime < 0 {
+		queueTime = 0
+	}
+	txn.Attrs.Agent.Add(AttributeMessageQueueTime, "", queueTime.Seconds())
+	return nil
+}
+
+// SetSamplingPriority replaces the boost of the priority of the
+// transaction.
+func (txn *txn) SetSamplingPriority(boost float32) error {
+	txn.Lock()
+	defer txn.Unlock()
+	if txn.finished {
+		return errAlreadyEnded
+	}
+	if !(boost >= 0 && boost <= 1) {
+		return errPriorityBoost
+	}
+
+	if txn.BetterCAT.Enabled {
+		txn.BetterCAT.Priority += priority(boost) - txn.priorityBoost
+	}
+	txn.priorityBoost = priority(boost)
+	return nil
+}
+
+func (txn *txn) SetMessagePayload(p MessagePayload) error {
+	txn.Lock()
+	defer txn.Unlock()
+	if txn.finished {
+		return errAlreadyEnded
+	}
+
+	for k, v := range messagePayloadAttributes(txn.Config, &p) {
+		switch val := v.(type) {
+		case stringJSONWriter:
+			txn.Attrs.Agent.Add(k, string(val), nil)
+		case intJSONWriter:
+			txn.Attrs.Agent.Add(k, "", int(val))
+		}
+	}
+	return nil
+}
+
 func (txn *txn) AddAttribute(name string, value interface{}) error {
 	txn.Lock()
 	defer txn.Unlock()
@@ -589,6 +877,43 @@ func (txn *txn) AddAttribute(name string, value interface{}) error {
 	return addUserAttribute(txn.Attrs, name, value, destAll)
 }
 
+func (txn *txn) AddBreadcrumb(category, message string, attrs map[string]interface{}) error {
+	txn.Lock()
+	defer txn.Unlock()
+
+	if txn.Config.HighSecurity {
+		return errHighSecurityEnabled
+	}
+
+	if !txn.Reply.SecurityPolicies.CustomParameters.Enabled() {
+		return errSecurityPolicy
+	}
+
+	if txn.finished {
+		return errAlreadyEnded
+	}
+
+	b, err := newBreadcrumb(time.Now(), category, message, attrs)
+	if nil != err {
+		return err
+	}
+	if nil == txn.Breadcrumbs {
+		txn.Breadcrumbs = newBreadcrumbs(maxTxnBreadcrumbs)
+	}
+	txn.Breadcrumbs.add(b)
+	return nil
+}
+
+func (txn *txn) AddTiming(name string, d time.Duration) error {
+	txn.Lock()
+	defer txn.Unlock()
+
+	if txn.finished {
+		return errAlreadyEnded
+	}
+	return txn.Timings.add(name, d)
+}
+
 var (
 	errorsDisabled        = errors.New("errors disabled")
 	errNilError           = errors.New("nil error")
@@ -596,6 +921,7 @@ var (
 	errSecurityPolicy     = errors.New("disabled by security policy")
 	errTransactionIgnored = errors.New("transaction has been ignored")
 	errBrowserDisabled    = errors.New("browser disabled by local configuration")
+	errPriorityBoost      = errors.New("sampling priority boost must be between 0 and 1")
 )
 
 const (
@@ -609,6 +935,25 @@ func (thd *thread) noticeErrorInternal(errData errorData, err error, expect bool
 		return errorsDisabled
 	}
 
+	errData.RawError = err
+	errData.StackFilter = newStackTraceFilter(&txn.Config.Config)
+
+	if !expect && txn.appRun.errorClassIsExpected(errData.Klass) {
+		expect = true
+		errData.Expect = true
+	}
+
+	if cb := txn.app.getErrorCallback(); cb != nil {
+		name := txn.appRun.createTransactionName(txn.Name, txn.IsWeb)
+		keep, group := cb(errData.errorInfo(txn.Attrs, name))
+		if !keep {
+			return nil
+		}
+		if group != "" {
+			errData.ErrorGroup = group
+		}
+	}
+
 	if !expect {
 		thd.noticeErrors = true
 	} else {
@@ -619,8 +964,6 @@ func (thd *thread) noticeErrorInternal(errData errorData, err error, expect bool
 		txn.Errors = newTxnErrors(maxTxnErrors)
 	}
 
-	errData.RawError = err
-
 	if txn.shouldCollectSpanEvents() {
 		errData.SpanID = txn.CurrentSpanIdentifier(thd.thread)
 		addErrorAttrs(thd, errData)
@@ -913,17 +1256,41 @@ func endBasic(s *Segment) error {
 	if txn.finished {
 		err = errAlreadyEnded
 	} else {
-		err = endBasicSegment(&txn.txnData, thd.thread, s.StartTime.start, time.Now(), s.Name)
+		if s.ctx != nil && txn.shouldCollectSpanEvents() {
+			if frame, e := thd.thread.inProgressFrame(s.StartTime.start); e == nil {
+				addContextAttributes(&frame.agentAttributes, s.ctx, frame.Time, txn.Config.SpanEvents.ContextErrors)
+			}
+		}
+		err = endSampledBasicSegment(&txn.txnData, thd.thread, s.StartTime.start, time.Now(), s.Name, s.sampleRate)
 	}
 	txn.Unlock()
 	return err
 }
 
+// pauseBasic pauses or resumes a basic segment using the pause method of the
+// thread, either pauseSegment or resumeSegment.
+func pauseBasic(s *Segment, pause func(*tracingThread, segmentStartTime, time.Time) error) error {
+	thd := s.StartTime.thread
+	if nil == thd {
+		return nil
+	}
+	txn := thd.txn
+	txn.Lock()
+	defer txn.Unlock()
+
+	if txn.finished {
+		return errAlreadyEnded
+	}
+	return pause(thd.thread, s.StartTime.start, time.Now())
+}
+
 func endDatastore(s *DatastoreSegment) error {
 	thd := s.StartTime.thread
 	if nil == thd {
 		return nil
 	}
+	now := time.Now()
+
 	txn := thd.txn
 	txn.Lock()
 	defer txn.Unlock()
@@ -931,6 +1298,7 @@ func endDatastore(s *DatastoreSegment) error {
 	if txn.finished {
 		return errAlreadyEnded
 	}
+	plan := explainDatastore(s, now)
 	if txn.Config.HighSecurity {
 		s.QueryParameters = nil
 	}
@@ -946,6 +1314,9 @@ func endDatastore(s *DatastoreSegment) error {
 			s.ParameterizedQuery = ""
 		}
 	}
+	if fn := txn.Config.DatastoreTracer.QuerySanitizer; fn != nil && s.ParameterizedQuery != "" {
+		s.ParameterizedQuery = fn(s.ParameterizedQuery)
+	}
 	if !txn.Config.DatastoreTracer.DatabaseNameReporting.Enabled {
 		s.DatabaseName = ""
 	}
@@ -957,7 +1328,7 @@ func endDatastore(s *DatastoreSegment) error {
 		TxnData:            &txn.txnData,
 		Thread:             thd.thread,
 		Start:              s.StartTime.start,
-		Now:                time.Now(),
+		Now:                now,
 		Product:            string(s.Product),
 		Collection:         s.Collection,
 		Operation:          s.Operation,
@@ -967,9 +1338,48 @@ func endDatastore(s *DatastoreSegment) error {
 		PortPathOrID:       s.PortPathOrID,
 		Database:           s.DatabaseName,
 		ThisHost:           txn.appRun.Config.hostname,
+		ExplainPlan:        plan,
 	})
 }
 
+// explainDatastore starts the capture of the query plan of the datastore
+// segment if it should be captured, and returns the plan to which it is
+// written.  The plan is captured by the explainPlanner of the application,
+// since it requires a round trip to the datastore.  It must be called with the
+// transaction lock held.
+func explainDatastore(s *DatastoreSegment, now time.Time) *queryPlan {
+	if nil == s.ExplainPlan {
+		return nil
+	}
+	thd := s.StartTime.thread
+	txn := thd.txn
+
+	cfg := txn.Config.DatastoreTracer.ExplainPlan
+	if !cfg.Enabled || txn.Config.HighSecurity || nil == txn.app {
+		return nil
+	}
+	if txn.Reply.SecurityPolicies.RecordSQL.IsSet() && !txn.Reply.SecurityPolicies.RecordSQL.Enabled() {
+		return nil
+	}
+	if txn.explainPlans >= maxTxnExplainPlans {
+		return nil
+	}
+	if start, ok := thd.thread.segmentStart(s.StartTime.start); !ok || now.Sub(start) < cfg.Threshold {
+		return nil
+	}
+	plan := txn.app.explainPlanner.capture(now, s.ExplainPlan, func(err error) {
+		thd.logAPIError(err, "explain datastore query", map[string]interface{}{
+			"product":    s.Product,
+			"collection": s.Collection,
+			"operation":  s.Operation,
+		})
+	})
+	if nil != plan {
+		txn.explainPlans++
+	}
+	return plan
+}
+
 func externalSegmentMethod(s *ExternalSegment) string {
 	if s.Procedure != "" {
 		return s.Procedure
@@ -1033,6 +1443,7 @@ func endExternal(s *ExternalSegment) error {
 		Library:    s.Library,
 		Method:     externalSegmentMethod(s),
 		StatusCode: s.statusCode,
+		Timings:    s.timings,
 	})
 }
 
@@ -1063,6 +1474,41 @@ func endMessage(s *MessageProducerSegment) error {
 		DestinationName: s.DestinationName,
 		DestinationType: string(s.DestinationType),
 		DestinationTemp: s.DestinationTemporary,
+		Attributes:      messagePayloadAttributes(txn.Config, s.Payload),
+	})
+}
+
+func endMessageConsumer(s *MessageConsumerSegment) error {
+	thd := s.StartTime.thread
+	if nil == thd {
+		return nil
+	}
+	txn := thd.txn
+	txn.Lock()
+	defer txn.Unlock()
+
+	if txn.finished {
+		return errAlreadyEnded
+	}
+
+	if s.DestinationType == "" {
+		s.DestinationType = MessageQueue
+	}
+
+	return endMessageSegment(endMessageParams{
+		TxnData:          &txn.txnData,
+		Thread:           thd.thread,
+		Start:            s.StartTime.start,
+		Now:              time.Now(),
+		Library:          s.Library,
+		Logger:           txn.Config.Logger,
+		DestinationName:  s.DestinationName,
+		DestinationType:  string(s.DestinationType),
+		DestinationTemp:  s.DestinationTemporary,
+		Consume:          true,
+		MessageTimestamp: s.MessageTimestamp,
+		MessageCount:     s.MessageCount,
+		Attributes:       messagePayloadAttributes(txn.Config, s.Payload),
 	})
 }
 
@@ -1094,9 +1540,14 @@ func oldCATOutboundHeaders(txn *txn) http.Header {
 func outboundHeaders(s *ExternalSegment) http.Header {
 	thd := s.StartTime.thread
 
-	if nil == thd {
+	if nil == thd || s.SuppressOutboundHeaders {
 		return http.Header{}
 	}
+	if hosts := thd.Config.DistributedTracer.ExcludedHosts; len(hosts) > 0 {
+		if u, _ := externalSegmentURL(s); nil != u && hostExcluded(hosts, u.Hostname()) {
+			return http.Header{}
+		}
+	}
 	txn := thd.txn
 	hdr := oldCATOutboundHeaders(txn)
 
@@ -1107,6 +1558,26 @@ func outboundHeaders(s *ExternalSegment) http.Header {
 	return hdr
 }
 
+// hostExcluded returns true if the host matches one of the excluded hosts.
+// Entries beginning with "*." match any subdomain of the remaining domain.
+func hostExcluded(excluded []string, host string) bool {
+	if host == "" {
+		return false
+	}
+	for _, e := range excluded {
+		e = strings.TrimSpace(e)
+		if strings.HasPrefix(e, "*.") {
+			domain := e[1:]
+			if len(host) > len(domain) && strings.EqualFold(host[len(host)-len(domain):], domain) {
+				return true
+			}
+		} else if strings.EqualFold(host, e) {
+			return true
+		}
+	}
+	return false
+}
+
 const (
 	maxSampledDistributedPayloads = 35
 )
@@ -1211,6 +1682,10 @@ func (txn *txn) AcceptDistributedTraceHeaders(t TransportType, hdrs http.Header)
 
 func (txn *txn) acceptDistributedTraceHeadersLocked(t TransportType, hdrs http.Header) error {
 
+	if t.isMessageQueue() {
+		txn.consumesMessages = true
+	}
+
 	if !txn.BetterCAT.Enabled {
 		return errInboundPayloadDTDisabled
 	}
@@ -1268,14 +1743,19 @@ func (txn *txn) acceptDistributedTraceHeadersLocked(t TransportType, hdrs http.H
 		return errTrustedAccountKey
 	}
 
-	if payload.Priority != 0 {
-		txn.BetterCAT.Priority = payload.Priority
+	if payload.Priority != 0 && !txn.sampledOverride {
+		txn.BetterCAT.Priority = payload.Priority + txn.priorityBoost
 	}
 
 	// a nul payload.Sampled means the a field wasn't provided
-	if nil != payload.Sampled {
+	if nil != payload.Sampled && !txn.sampledOverride {
 		txn.BetterCAT.Sampled = *payload.Sampled
-		txn.sampledCalculated = true
+		if nil != txn.Config.DistributedTracer.Sampler {
+			// The Sampler may override the inbound decision.
+			txn.inboundSampled = true
+		} else {
+			txn.sampledCalculated = true
+		}
 	}
 
 	txn.BetterCAT.Inbound = payload
@@ -1288,6 +1768,21 @@ func (txn *txn) acceptDistributedTraceHeadersLocked(t TransportType, hdrs http.H
 	return nil
 }
 
+// acceptAWSTraceHeaderLocked uses the trace ID of the X-Amzn-Trace-Id header
+// as the trace ID of the transaction, if enabled and if the transaction did not
+// accept a distributed trace payload.
+func (txn *txn) acceptAWSTraceHeaderLocked(hdrs http.Header) {
+	if !txn.Config.DistributedTracer.AcceptAWSTraceHeader || !txn.BetterCAT.Enabled {
+		return
+	}
+	if txn.BetterCAT.Inbound != nil || txn.numPayloadsCreated > 0 {
+		return
+	}
+	if traceID := awsTraceID(hdrs.Get(awsTraceHeader)); traceID != "" {
+		txn.BetterCAT.TraceID = traceID
+	}
+}
+
 func (txn *txn) Application() *Application {
 	return newApplication(txn.app)
 }
@@ -1306,7 +1801,8 @@ func (thd *thread) AddUserSpanAttribute(key string, val interface{}) error {
 	txn.Lock()
 	defer txn.Unlock()
 
-	if outputDests := applyAttributeConfig(thd.Attrs.config, key, destSpan); outputDests == 0 {
+	outputDests := applyAttributeConfig(thd.Attrs.config, key, destSpan|destSegment)
+	if outputDests == 0 {
 		return nil
 	}
 
@@ -1318,10 +1814,16 @@ func (thd *thread) AddUserSpanAttribute(key string, val interface{}) error {
 		return errSecurityPolicy
 	}
 
-	thd.thread.AddUserSpanAttribute(key, val)
+	thd.thread.AddUserSpanAttribute(key, val, outputDests)
 	return nil
 }
 
+var (
+	// Ensure that thread implements ResponseErrorNoticer to avoid breaking
+	// integration package type assertions.
+	_ internal.ResponseErrorNoticer = &thread{}
+)
+
 var (
 	// Ensure that txn implements AddAgentAttributer to avoid breaking
 	// integration package type assertions.
@@ -5,13 +5,17 @@ package newrelic
 
 import (
 	"errors"
+	"math"
 	"net/http"
+	"os"
 	"reflect"
+	"runtime"
 	"testing"
 	"time"
 
 	"github.com/newrelic/go-agent/v3/internal"
 	"github.com/newrelic/go-agent/v3/internal/cat"
+	"github.com/newrelic/go-agent/v3/internal/sysinfo"
 )
 
 func TestShouldSaveTrace(t *testing.T) {
@@ -981,3 +985,218 @@ func TestErrAttrsAddedWhenPanic(t *testing.T) {
 		},
 	})
 }
+
+func TestRuntimeTransactionAttributes(t *testing.T) {
+	app := testApp(nil, func(cfg *Config) {
+		cfg.DistributedTracer.Enabled = false
+		cfg.Utilization.DetectSystemd = false
+		cfg.RuntimeSampler.TransactionAttributes = true
+	}, t)
+	txn := app.StartTransaction("hello")
+	runtime.GC()
+	txn.End()
+	app.ExpectTxnEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{"name": "OtherTransaction/Go/hello"},
+		AgentAttributes: map[string]interface{}{
+			AttributeRuntimeGCPauseTime:      internal.MatchAnything,
+			AttributeRuntimeSchedulerLatency: internal.MatchAnything,
+		},
+	}})
+
+	app = testApp(nil, func(cfg *Config) {
+		cfg.DistributedTracer.Enabled = false
+		cfg.Utilization.DetectSystemd = false
+	}, t)
+	app.StartTransaction("hello").End()
+	app.ExpectTxnEvents(t, []internal.WantEvent{{
+		Intrinsics:      map[string]interface{}{"name": "OtherTransaction/Go/hello"},
+		AgentAttributes: map[string]interface{}{},
+	}})
+}
+
+func TestSetSamplingPriority(t *testing.T) {
+	app := testApp(nil, nil, t)
+	txn := app.StartTransaction("hello")
+	start := txn.thread.BetterCAT.Priority
+	closeTo := func(p, want priority) bool { return math.Abs(float64(p-want)) < 1e-6 }
+	txn.SetSamplingPriority(0.5)
+	if p := txn.thread.BetterCAT.Priority; !closeTo(p, start+0.5) {
+		t.Errorf("incorrect priority: %v, want %v", p, start+0.5)
+	}
+	txn.SetSamplingPriority(0.25)
+	if p := txn.thread.BetterCAT.Priority; !closeTo(p, start+0.25) {
+		t.Errorf("boost not replaced: %v, want %v", p, start+0.25)
+	}
+	if err := txn.thread.SetSamplingPriority(2); err != errPriorityBoost {
+		t.Error("incorrect error for invalid boost:", err)
+	}
+	txn.End()
+	if err := txn.thread.SetSamplingPriority(1); err != errAlreadyEnded {
+		t.Error("incorrect error after end:", err)
+	}
+}
+
+func TestSetSamplingPriorityRetained(t *testing.T) {
+	app := testApp(replyFn, func(cfg *Config) {
+		cfg.DistributedTracer.Enabled = false
+		cfg.TransactionEvents.MaxSamplesStored = 1
+	}, t)
+	for i := 0; i < 10; i++ {
+		txn := app.StartTransaction("other")
+		txn.End()
+		if i == 5 {
+			txn := app.StartTransaction("payment")
+			txn.SetSamplingPriority(1)
+			txn.End()
+		}
+	}
+	app.expectNoLoggedErrors(t)
+	app.ExpectTxnEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"name": "OtherTransaction/Go/payment",
+		},
+	}})
+}
+
+func TestSystemdAttributes(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("systemd is only detected on linux")
+	}
+	t.Setenv("INVOCATION_ID", "0f8e0f8e0f8e4f8e8f8e0f8e0f8e0f8e")
+	unit, err := sysinfo.GetSystemdUnit(os.Getenv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		AttributeSystemdInvocationID: "0f8e0f8e0f8e4f8e8f8e0f8e0f8e0f8e",
+	}
+	if unit.Name != "" {
+		want[AttributeSystemdUnit] = unit.Name
+	}
+
+	app := testApp(nil, func(cfg *Config) {
+		cfg.DistributedTracer.Enabled = false
+	}, t)
+	app.StartTransaction("hello").End()
+	app.ExpectTxnEvents(t, []internal.WantEvent{{
+		Intrinsics:      map[string]interface{}{"name": "OtherTransaction/Go/hello"},
+		AgentAttributes: want,
+	}})
+
+	app = testApp(nil, func(cfg *Config) {
+		cfg.DistributedTracer.Enabled = false
+		cfg.Utilization.DetectSystemd = false
+	}, t)
+	app.StartTransaction("hello").End()
+	app.ExpectTxnEvents(t, []internal.WantEvent{{
+		Intrinsics:      map[string]interface{}{"name": "OtherTransaction/Go/hello"},
+		AgentAttributes: map[string]interface{}{},
+	}})
+}
+
+func TestKubernetesAttributes(t *testing.T) {
+	t.Setenv("KUBERNETES_SERVICE_HOST", "10.0.0.1")
+	t.Setenv("POD_NAME", "checkout-7d4b9c6f8d-x2x7q")
+	t.Setenv("POD_NAMESPACE", "payments")
+	want := map[string]interface{}{
+		AttributeKubernetesPodName:     "checkout-7d4b9c6f8d-x2x7q",
+		AttributeKubernetesNamespace:   "payments",
+		AttributeKubernetesDeployment:  "checkout",
+		AttributeKubernetesClusterName: "prod",
+	}
+
+	app := testApp(sampleEverythingReplyFn, func(cfg *Config) {
+		cfg.Utilization.DetectSystemd = false
+		cfg.KubernetesMetadata.Enabled = true
+		cfg.KubernetesMetadata.ClusterName = "prod"
+	}, t)
+	txn := app.StartTransaction("hello")
+	txn.StartSegment("mySegment").End()
+	txn.End()
+	app.ExpectTxnEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"name":     "OtherTransaction/Go/hello",
+			"guid":     internal.MatchAnything,
+			"traceId":  internal.MatchAnything,
+			"priority": internal.MatchAnything,
+			"sampled":  true,
+		},
+		AgentAttributes: want,
+	}})
+	app.ExpectSpanEvents(t, []internal.WantEvent{
+		{
+			Intrinsics: map[string]interface{}{
+				"name":          "Custom/mySegment",
+				"category":      "generic",
+				"sampled":       true,
+				"priority":      internal.MatchAnything,
+				"guid":          internal.MatchAnything,
+				"transactionId": internal.MatchAnything,
+				"traceId":       internal.MatchAnything,
+				"parentId":      internal.MatchAnything,
+			},
+			UserAttributes:  map[string]interface{}{},
+			AgentAttributes: want,
+		},
+		{
+			Intrinsics: map[string]interface{}{
+				"name":             "OtherTransaction/Go/hello",
+				"transaction.name": "OtherTransaction/Go/hello",
+				"category":         "generic",
+				"nr.entryPoint":    true,
+				"sampled":          true,
+				"priority":         internal.MatchAnything,
+				"guid":             internal.MatchAnything,
+				"transactionId":    internal.MatchAnything,
+				"traceId":          internal.MatchAnything,
+			},
+			UserAttributes:  map[string]interface{}{},
+			AgentAttributes: want,
+		},
+	})
+
+	app = testApp(nil, func(cfg *Config) {
+		cfg.DistributedTracer.Enabled = false
+		cfg.Utilization.DetectSystemd = false
+	}, t)
+	app.StartTransaction("hello").End()
+	app.ExpectTxnEvents(t, []internal.WantEvent{{
+		Intrinsics:      map[string]interface{}{"name": "OtherTransaction/Go/hello"},
+		AgentAttributes: map[string]interface{}{},
+	}})
+}
+
+func TestCloudPlatformAttributes(t *testing.T) {
+	t.Setenv("CONTAINER_APP_NAME", "checkout")
+	t.Setenv("CONTAINER_APP_REVISION", "checkout--v42")
+	t.Setenv("CONTAINER_APP_REPLICA_NAME", "checkout--v42-5d9f8c7b6-abcde")
+	t.Setenv("CONTAINER_APP_ENV_DNS_SUFFIX", "happyhill-70162bb9.eastus.azurecontainerapps.io")
+
+	app := testApp(nil, func(cfg *Config) {
+		cfg.DistributedTracer.Enabled = false
+		cfg.Utilization.DetectSystemd = false
+	}, t)
+	app.StartTransaction("hello").End()
+	app.ExpectTxnEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{"name": "OtherTransaction/Go/hello"},
+		AgentAttributes: map[string]interface{}{
+			AttributeCloudProvider: "azure",
+			AttributeCloudPlatform: "azure_container_apps",
+			AttributeCloudRegion:   "eastus",
+			AttributeFaasName:      "checkout",
+			AttributeFaasVersion:   "checkout--v42",
+			AttributeFaasInstance:  "checkout--v42-5d9f8c7b6-abcde",
+		},
+	}})
+
+	app = testApp(nil, func(cfg *Config) {
+		cfg.DistributedTracer.Enabled = false
+		cfg.Utilization.DetectSystemd = false
+		cfg.Utilization.DetectAzure = false
+	}, t)
+	app.StartTransaction("hello").End()
+	app.ExpectTxnEvents(t, []internal.WantEvent{{
+		Intrinsics:      map[string]interface{}{"name": "OtherTransaction/Go/hello"},
+		AgentAttributes: map[string]interface{}{},
+	}})
+}
@@ -0,0 +1,31 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package newrelic
+
+import "github.com/newrelic/go-agent/v3/internal/sysinfo"
+
+// kubernetesAttribute is an attribute describing the Kubernetes pod of the
+// application.
+type kubernetesAttribute struct {
+	name  string
+	value string
+}
+
+// newKubernetesAttributes returns the attributes of the metadata which are
+// known, in a fixed order.
+func newKubernetesAttributes(md sysinfo.KubernetesMetadata) []kubernetesAttribute {
+	var attrs []kubernetesAttribute
+	for _, attr := range []kubernetesAttribute{
+		{name: AttributeKubernetesPodName, value: md.PodName},
+		{name: AttributeKubernetesNamespace, value: md.Namespace},
+		{name: AttributeKubernetesDeployment, value: md.Deployment},
+		{name: AttributeKubernetesNodeName, value: md.NodeName},
+		{name: AttributeKubernetesClusterName, value: md.ClusterName},
+	} {
+		if attr.value != "" {
+			attrs = append(attrs, attr)
+		}
+	}
+	return attrs
+}
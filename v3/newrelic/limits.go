@@ -26,6 +26,15 @@ const (
 	// transaction.
 	maxTxnErrors      = 5
 	maxTxnSlowQueries = 10
+	// maxTxnExplainPlans is the maximum number of query plans captured per
+	// transaction.
+	maxTxnExplainPlans = 10
+	// maxTxnBreadcrumbs is the maximum number of breadcrumbs retained per
+	// transaction.  Older breadcrumbs are discarded first.
+	maxTxnBreadcrumbs = 10
+	// maxTxnTimings is the maximum number of distinct timings recorded per
+	// transaction using Transaction.AddTiming.
+	maxTxnTimings = 16
 
 	startingTxnTraceNodes = 16
 	maxTxnTraceNodes      = 256
@@ -36,6 +45,9 @@ const (
 	maxSyntheticsTraces = 20
 	maxHarvestErrors    = 20
 	maxHarvestSlowSQLs  = 10
+	// maxHarvestExplainPlans is the maximum number of query plans captured
+	// per harvest period, across all of the transactions.
+	maxHarvestExplainPlans = 10
 
 	errorEventMessageLengthLimit = 4096
 	// attributes
@@ -55,4 +67,22 @@ const (
 	// be changed without notifying customers that they must update all
 	// instance simultaneously for valid runtime metrics.
 	runtimeSamplerPeriod = 60 * time.Second
+	// dbStatsSamplerPeriod is the period of the database connection pool
+	// sampler.
+	dbStatsSamplerPeriod = 60 * time.Second
+	// expvarSamplerPeriod is the period of the expvar sampler.
+	expvarSamplerPeriod = 60 * time.Second
+	// maxExpvarMetrics is the maximum number of metrics recorded from the
+	// expvar variables each sample.
+	maxExpvarMetrics = 500
+	// defaultHarvestSpoolMaxBytes is the default maximum total size of the
+	// harvest payloads spooled to disk.
+	defaultHarvestSpoolMaxBytes = 64 * 1024 * 1024
+	// maxSpoolReplayFiles is the maximum number of spooled harvest payloads
+	// sent each harvest.
+	maxSpoolReplayFiles = 20
+	// defaultAgentHealthDeliveryLocation and defaultAgentHealthFrequency
+	// are the defaults of the agent health monitoring specification.
+	defaultAgentHealthDeliveryLocation = "file:///newrelic/apm/health"
+	defaultAgentHealthFrequency        = 5 * time.Second
 )
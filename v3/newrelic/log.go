@@ -42,3 +42,29 @@ func NewLogger(w io.Writer) Logger {
 func NewDebugLogger(w io.Writer) Logger {
 	return logger.New(w, true)
 }
+
+// LogLevel is the minimum level of the messages written to a log destination
+// added with ConfigLogDestination.
+type LogLevel int
+
+// These log levels are used with ConfigLogDestination.
+const (
+	LogLevelDebug = LogLevel(logger.LevelDebug)
+	LogLevelInfo  = LogLevel(logger.LevelInfo)
+	LogLevelWarn  = LogLevel(logger.LevelWarn)
+	LogLevelError = LogLevel(logger.LevelError)
+)
+
+// NewMultiLogger creates a Logger which writes each message to every one of
+// the loggers, for example to combine a logging integration with a log file.
+// Nil loggers are skipped.  Debug messages are written to the loggers for
+// which DebugEnabled returns true.
+func NewMultiLogger(loggers ...Logger) Logger {
+	ls := make([]logger.Logger, 0, len(loggers))
+	for _, l := range loggers {
+		if nil != l {
+			ls = append(ls, l)
+		}
+	}
+	return logger.Multi(ls...)
+}
@@ -5,6 +5,7 @@ package newrelic
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"strings"
@@ -40,41 +41,6 @@ type LogData struct {
 	Attributes map[string]any // Optional: a key value pair with a string key, and any value. This can be used for categorizing logs in the UI.
 }
 
-// writeJSON prepares JSON in the format expected by the collector.
-func (e *logEvent) WriteJSON(buf *bytes.Buffer) {
-	w := jsonFieldsWriter{buf: buf}
-	buf.WriteByte('{')
-	w.stringField(logcontext.LogSeverityFieldName, e.severity)
-	w.stringField(logcontext.LogMessageFieldName, e.message)
-
-	if len(e.spanID) > 0 {
-		w.stringField(logcontext.LogSpanIDFieldName, e.spanID)
-	}
-	if len(e.traceID) > 0 {
-		w.stringField(logcontext.LogTraceIDFieldName, e.traceID)
-	}
-
-	w.needsComma = false
-	buf.WriteByte(',')
-	w.intField(logcontext.LogTimestampFieldName, e.timestamp)
-	if e.attributes != nil && len(e.attributes) > 0 {
-		buf.WriteString(`,"attributes":{`)
-		w := jsonFieldsWriter{buf: buf}
-		for key, val := range e.attributes {
-			writeAttributeValueJSON(&w, key, val)
-		}
-		buf.WriteByte('}')
-	}
-	buf.WriteByte('}')
-}
-
-// MarshalJSON is used for testing.
-func (e *logEvent) MarshalJSON() ([]byte, error) {
-	buf := bytes.NewBuffer(make([]byte, 0, logcontext.AverageLogSizeEstimate))
-	e.WriteJSON(buf)
-	return buf.Bytes(), nil
-}
-
 var (
 	errNilLogData         = errors.New("log data can not be nil")
 	errLogMessageTooLarge = fmt.Errorf("log message can not exceed %d bytes", MaxLogLength)
@@ -127,8 +93,10 @@ var (
 )
 
 type logEnricherConfig struct {
-	app *Application
-	txn *Transaction
+	app        *Application
+	txn        *Transaction
+	jsonFields bool
+	eachLine   bool
 }
 
 // EnricherOption is a function that configures the enricher based on the source of data it receives.
@@ -144,6 +112,22 @@ func FromTxn(txn *Transaction) EnricherOption {
 	return func(cfg *logEnricherConfig) { cfg.txn = txn }
 }
 
+// WithJSONDecoration configures the log enricher to add the linking metadata
+// to logs which are JSON objects as JSON fields, rather than appending it to
+// the end of the log, which would make the JSON invalid.  Logs which are not
+// JSON objects are decorated as usual.
+func WithJSONDecoration() EnricherOption {
+	return func(cfg *logEnricherConfig) { cfg.jsonFields = true }
+}
+
+// WithMultilineDecoration configures the log enricher to append the linking
+// metadata to each line of a multi-line log, such as a log containing a stack
+// trace, rather than only to the last line.  This allows tools which read logs
+// line by line to link every line.
+func WithMultilineDecoration() EnricherOption {
+	return func(cfg *logEnricherConfig) { cfg.eachLine = true }
+}
+
 type linkingMetadata struct {
 	traceID    string
 	spanID     string
@@ -154,9 +138,11 @@ type linkingMetadata struct {
 
 // EnrichLog appends newrelic linking metadata to a log stored in a byte buffer.
 // This should only be used by plugins built for frameworks.
-func EnrichLog(buf *bytes.Buffer, opts EnricherOption) error {
+func EnrichLog(buf *bytes.Buffer, opts ...EnricherOption) error {
 	config := logEnricherConfig{}
-	opts(&config)
+	for _, opt := range opts {
+		opt(&config)
+	}
 
 	if buf == nil {
 		return ErrNilLogBuffer
@@ -200,14 +186,31 @@ func EnrichLog(buf *bytes.Buffer, opts EnricherOption) error {
 	md.hostname = app.app.config.hostname
 
 	if reply.Config.ApplicationLogging.Enabled && reply.Config.ApplicationLogging.LocalDecorating.Enabled {
-		md.appendLinkingMetadata(buf)
+		md.decorate(buf, &config)
 	}
 
 	return nil
 }
 
+// decorate adds the linking metadata to the log in the way chosen by the
+// enricher options.
+func (md *linkingMetadata) decorate(buf *bytes.Buffer, config *logEnricherConfig) {
+	switch {
+	case config.jsonFields && isJSONObject(buf.Bytes()):
+		md.appendJSONFields(buf)
+	case config.eachLine:
+		md.appendLinkingMetadataToLines(buf)
+	default:
+		md.appendLinkingMetadata(buf)
+	}
+}
+
+func (md *linkingMetadata) isComplete() bool {
+	return md.entityGUID != "" && md.entityName != "" && md.hostname != ""
+}
+
 func (md *linkingMetadata) appendLinkingMetadata(buf *bytes.Buffer) {
-	if md.entityGUID == "" || md.entityName == "" || md.hostname == "" {
+	if !md.isComplete() {
 		return
 	}
 
@@ -236,3 +239,66 @@ func addDynamicSpacing(buf *bytes.Buffer) {
 		buf.WriteByte(' ')
 	}
 }
+
+// appendLinkingMetadataToLines appends the linking metadata to each non-blank
+// line of the log, keeping any carriage return at the end of the line.
+func (md *linkingMetadata) appendLinkingMetadataToLines(buf *bytes.Buffer) {
+	if !md.isComplete() {
+		return
+	}
+
+	lines := bytes.Split(buf.Bytes(), []byte{'\n'})
+	out := bytes.NewBuffer(make([]byte, 0, 2*buf.Len()))
+	for i, line := range lines {
+		if i > 0 {
+			out.WriteByte('\n')
+		}
+		cr := bytes.HasSuffix(line, []byte{'\r'})
+		if cr {
+			line = line[:len(line)-1]
+		}
+		out.Write(line)
+		if len(bytes.TrimSpace(line)) > 0 {
+			md.appendLinkingMetadata(out)
+		}
+		if cr {
+			out.WriteByte('\r')
+		}
+	}
+
+	buf.Reset()
+	buf.Write(out.Bytes())
+}
+
+// isJSONObject returns true if the log is a single JSON object.
+func isJSONObject(log []byte) bool {
+	trimmed := bytes.TrimSpace(log)
+	return len(trimmed) > 1 && trimmed[0] == '{' && trimmed[len(trimmed)-1] == '}' && json.Valid(trimmed)
+}
+
+// appendJSONFields adds the linking metadata to a log which is a JSON object
+// as fields of the object.  The trace and span IDs are omitted if empty.
+func (md *linkingMetadata) appendJSONFields(buf *bytes.Buffer) {
+	if !md.isComplete() {
+		return
+	}
+
+	log := buf.Bytes()
+	start := bytes.IndexByte(log, '{')
+	end := bytes.LastIndexByte(log, '}')
+	empty := len(bytes.TrimSpace(log[start+1:end])) == 0
+	tail := append([]byte(nil), log[end:]...)
+
+	buf.Truncate(end)
+	w := jsonFieldsWriter{buf: buf, needsComma: !empty}
+	w.stringField(logcontext.LogEntityGUIDFieldName, md.entityGUID)
+	w.stringField(logcontext.LogEntityNameFieldName, md.entityName)
+	w.stringField(logcontext.LogHostnameFieldName, md.hostname)
+	if md.traceID != "" {
+		w.stringField(logcontext.LogTraceIDFieldName, md.traceID)
+	}
+	if md.spanID != "" {
+		w.stringField(logcontext.LogSpanIDFieldName, md.spanID)
+	}
+	buf.Write(tail)
+}
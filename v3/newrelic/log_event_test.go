@@ -2,8 +2,10 @@ package newrelic
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"math/rand"
+	"reflect"
 	"testing"
 	"time"
 
@@ -11,24 +13,6 @@ import (
 	"github.com/newrelic/go-agent/v3/internal/sysinfo"
 )
 
-func TestWriteJSON(t *testing.T) {
-	event := logEvent{
-		severity:  "INFO",
-		message:   "test message",
-		timestamp: 123456,
-	}
-	actual, err := event.MarshalJSON()
-	if err != nil {
-		t.Error(err)
-	}
-
-	expect := `{"level":"INFO","message":"test message","timestamp":123456}`
-	actualString := string(actual)
-	if expect != actualString {
-		t.Errorf("Log json did not build correctly: expecting %s, got %s", expect, actualString)
-	}
-}
-
 func TestToLogEvent(t *testing.T) {
 	type testcase struct {
 		name          string
@@ -122,26 +106,6 @@ func randomString(n int) string {
 	return string(b)
 }
 
-func TestWriteJSONWithTrace(t *testing.T) {
-	event := logEvent{
-		severity:  "INFO",
-		message:   "test message",
-		timestamp: 123456,
-		traceID:   "123Ad234",
-		spanID:    "adf3441",
-	}
-	actual, err := event.MarshalJSON()
-	if err != nil {
-		t.Error(err)
-	}
-
-	expect := `{"level":"INFO","message":"test message","span.id":"adf3441","trace.id":"123Ad234","timestamp":123456}`
-	actualString := string(actual)
-	if expect != actualString {
-		t.Errorf("Log json did not build correctly: expecting %s, got %s", expect, actualString)
-	}
-}
-
 func BenchmarkToLogEvent(b *testing.B) {
 	data := LogData{
 		Timestamp: 123456,
@@ -178,28 +142,6 @@ func BenchmarkRecordLog(b *testing.B) {
 	}
 }
 
-func BenchmarkWriteJSON(b *testing.B) {
-	data := LogData{
-		Timestamp: 123456,
-		Severity:  "INFO",
-		Message:   "This is a log message that represents an estimate for how long the average log message is. The average log payload is 700 bytes.",
-	}
-
-	event, err := data.toLogEvent()
-	if err != nil {
-		b.Fail()
-	}
-
-	buf := bytes.NewBuffer(make([]byte, 0, logcontext.AverageLogSizeEstimate))
-
-	b.ReportAllocs()
-	b.ResetTimer()
-
-	for n := 0; n < b.N; n++ {
-		event.WriteJSON(buf)
-	}
-}
-
 var (
 	host, _ = sysinfo.Hostname()
 )
@@ -296,6 +238,117 @@ func TestEnrichLogFromTxnDisabled(t *testing.T) {
 	})
 }
 
+func TestAppendLinkingMetadataDecorationModes(t *testing.T) {
+	md := linkingMetadata{
+		traceID:    "trace",
+		spanID:     "span",
+		entityGUID: "guid",
+		hostname:   "host",
+		entityName: "name",
+	}
+	const linking = "NR-LINKING|guid|host|trace|span|name|"
+
+	testcases := []struct {
+		name   string
+		log    string
+		config logEnricherConfig
+		expect string
+	}{
+		{
+			name:   "plain",
+			log:    "hello",
+			expect: "hello " + linking,
+		},
+		{
+			name:   "multi-line appends to the last line by default",
+			log:    "hello\nworld",
+			expect: "hello\nworld " + linking,
+		},
+		{
+			name:   "multi-line each line",
+			log:    "hello\r\n\n\tat main.go:1",
+			config: logEnricherConfig{eachLine: true},
+			expect: "hello " + linking + "\r\n\n\tat main.go:1 " + linking,
+		},
+		{
+			name:   "json appends by default",
+			log:    `{"message":"hello"}`,
+			expect: `{"message":"hello"} ` + linking,
+		},
+		{
+			name:   "json fields",
+			log:    `{"message":"hello\nworld"}`,
+			config: logEnricherConfig{jsonFields: true, eachLine: true},
+			expect: `{"message":"hello\nworld","entity.guid":"guid","entity.name":"name","hostname":"host","trace.id":"trace","span.id":"span"}`,
+		},
+		{
+			name:   "json fields multi-line object",
+			log:    "{\n  \"message\": \"hello\"\n}\t",
+			config: logEnricherConfig{jsonFields: true},
+			expect: "{\n  \"message\": \"hello\"\n" + `,"entity.guid":"guid","entity.name":"name","hostname":"host","trace.id":"trace","span.id":"span"}` + "\t",
+		},
+		{
+			name:   "json fields empty object",
+			log:    `{ }`,
+			config: logEnricherConfig{jsonFields: true},
+			expect: `{ "entity.guid":"guid","entity.name":"name","hostname":"host","trace.id":"trace","span.id":"span"}`,
+		},
+		{
+			name:   "invalid json is appended",
+			log:    `{"message":}`,
+			config: logEnricherConfig{jsonFields: true},
+			expect: `{"message":} ` + linking,
+		},
+	}
+
+	for _, tc := range testcases {
+		buf := bytes.NewBufferString(tc.log)
+		md.decorate(buf, &tc.config)
+		if actual := buf.String(); actual != tc.expect {
+			t.Errorf("%s: incorrect decoration:\nexpect: %q\nactual: %q", tc.name, tc.expect, actual)
+		}
+	}
+}
+
+func TestEnrichLogJSONDecoration(t *testing.T) {
+	testApp := newTestApp(
+		sampleEverythingReplyFn,
+		func(cfg *Config) {
+			cfg.Enabled = false
+			cfg.ApplicationLogging.Enabled = true
+			cfg.ApplicationLogging.Forwarding.Enabled = false
+			cfg.ApplicationLogging.LocalDecorating.Enabled = true
+		},
+	)
+	txn := testApp.Application.StartTransaction("test transaction")
+	defer txn.End()
+	buf := bytes.NewBufferString(`{"message":"hello"}`)
+	if err := EnrichLog(buf, FromTxn(txn), WithJSONDecoration()); err != nil {
+		t.Fatal(err)
+	}
+
+	var fields map[string]string
+	if err := json.Unmarshal(buf.Bytes(), &fields); err != nil {
+		t.Fatalf("decorated log is not valid JSON: %v: %s", err, buf.String())
+	}
+	state, err := testApp.app.getState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	md := txn.GetLinkingMetadata()
+	expect := map[string]string{
+		"message":     "hello",
+		"entity.guid": state.Reply.EntityGUID,
+		"entity.name": testApp.app.config.AppName,
+		"hostname":    host,
+		"trace.id":    md.TraceID,
+		"span.id":     md.SpanID,
+	}
+	if !reflect.DeepEqual(fields, expect) {
+		t.Errorf("incorrect fields: expect %v, actual %v", expect, fields)
+	}
+}
+
 func BenchmarkAppendLinkingMetadata(b *testing.B) {
 	buf := bytes.NewBuffer([]byte("test log message"))
 	md := linkingMetadata{
@@ -4,18 +4,15 @@
 package newrelic
 
 import (
-	"bytes"
 	"container/heap"
 	"time"
-
-	"github.com/newrelic/go-agent/v3/internal/jsonx"
-	"github.com/newrelic/go-agent/v3/internal/logcontext"
 )
 
 type commonAttributes struct {
 	entityGUID string
 	entityName string
 	hostname   string
+	kubernetes []kubernetesAttribute
 }
 
 type logEvents struct {
@@ -141,54 +138,6 @@ func (events *logEvents) Merge(other *logEvents) {
 	events.numSeen = int(allSeen)
 }
 
-func (events *logEvents) CollectorJSON(agentRunID string) ([]byte, error) {
-	if len(events.logs) == 0 {
-		return nil, nil
-	}
-
-	estimate := logcontext.AverageLogSizeEstimate * len(events.logs)
-	buf := bytes.NewBuffer(make([]byte, 0, estimate))
-
-	if events.numSeen == 0 {
-		return nil, nil
-	}
-
-	buf.WriteByte('[')
-	buf.WriteByte('{')
-	buf.WriteString(`"common":`)
-	buf.WriteByte('{')
-	buf.WriteString(`"attributes":`)
-	buf.WriteByte('{')
-	buf.WriteString(`"entity.guid":`)
-	jsonx.AppendString(buf, events.entityGUID)
-	buf.WriteByte(',')
-	buf.WriteString(`"entity.name":`)
-	jsonx.AppendString(buf, events.entityName)
-	buf.WriteByte(',')
-	buf.WriteString(`"hostname":`)
-	jsonx.AppendString(buf, events.hostname)
-	buf.WriteByte('}')
-	buf.WriteByte('}')
-	buf.WriteByte(',')
-	buf.WriteString(`"logs":`)
-	buf.WriteByte('[')
-	for i, e := range events.logs {
-		// If severity is empty string, then this is not a user provided entry, and is empty.
-		// Do not write json to buffer in this case.
-		if e.severity != "" {
-			e.WriteJSON(buf)
-			if i != len(events.logs)-1 {
-				buf.WriteByte(',')
-			}
-		}
-
-	}
-	buf.WriteByte(']')
-	buf.WriteByte('}')
-	buf.WriteByte(']')
-	return buf.Bytes(), nil
-}
-
 // split splits the events into two.  NOTE! The two event pools are not valid
 // priority queues, and should only be used to create JSON, not for adding any
 // events.
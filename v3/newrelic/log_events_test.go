@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/newrelic/go-agent/v3/internal"
+	"github.com/newrelic/go-agent/v3/internal/sysinfo"
 )
 
 var (
@@ -48,6 +49,10 @@ func sampleLogEvent(priority priority, severity, message string, attributes map[
 }
 
 func TestBasicLogEvents(t *testing.T) {
+	if !logForwardingIncluded {
+		t.Skip("log forwarding is excluded from the build")
+	}
+
 	events := newLogEvents(testCommonAttributes, loggingConfigEnabled(5))
 	events.Add(sampleLogEvent(0.5, infoLevel, "message1", nil))
 	events.Add(sampleLogEvent(0.5, infoLevel, "message2", nil))
@@ -72,6 +77,33 @@ func TestBasicLogEvents(t *testing.T) {
 	}
 }
 
+func TestLogEventsKubernetesAttributes(t *testing.T) {
+	if !logForwardingIncluded {
+		t.Skip("log forwarding is excluded from the build")
+	}
+
+	common := testCommonAttributes
+	common.kubernetes = newKubernetesAttributes(sysinfo.KubernetesMetadata{
+		PodName:   "checkout-7d4b9c6f8d-x2x7q",
+		Namespace: "payments",
+	})
+	events := newLogEvents(common, loggingConfigEnabled(5))
+	events.Add(sampleLogEvent(0.5, infoLevel, "message1", nil))
+
+	json, err := events.CollectorJSON(agentRunID)
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	expected := `[{"common":{"attributes":{"entity.guid":"testGUID","entity.name":"testEntityName","hostname":"testHostname",` +
+		`"k8s.pod.name":"checkout-7d4b9c6f8d-x2x7q","k8s.namespace.name":"payments"}},"logs":[` +
+		`{"level":"INFO","message":"message1","timestamp":123456}]}]`
+
+	if string(json) != expected {
+		t.Error(string(json), expected)
+	}
+}
+
 type testStruct struct {
 	A string
 	B int
@@ -83,6 +115,10 @@ type c struct {
 }
 
 func TestBasicLogEventWithAttributes(t *testing.T) {
+	if !logForwardingIncluded {
+		t.Skip("log forwarding is excluded from the build")
+	}
+
 	st := testStruct{
 		A: "a",
 		B: 1,
@@ -140,6 +176,10 @@ func TestEmptyLogEvents(t *testing.T) {
 
 // The events with the highest priority should make it: a, c, e
 func TestSamplingLogEvents(t *testing.T) {
+	if !logForwardingIncluded {
+		t.Skip("log forwarding is excluded from the build")
+	}
+
 	events := newLogEvents(testCommonAttributes, loggingConfigEnabled(3))
 
 	events.Add(sampleLogEvent(0.999999, infoLevel, "a", nil))
@@ -189,6 +229,10 @@ func TestMergeEmptyLogEvents(t *testing.T) {
 }
 
 func TestMergeFullLogEvents(t *testing.T) {
+	if !logForwardingIncluded {
+		t.Skip("log forwarding is excluded from the build")
+	}
+
 	e1 := newLogEvents(testCommonAttributes, loggingConfigEnabled(2))
 	e2 := newLogEvents(testCommonAttributes, loggingConfigEnabled(3))
 
@@ -224,6 +268,10 @@ func TestMergeFullLogEvents(t *testing.T) {
 }
 
 func TestLogEventMergeFailedSuccess(t *testing.T) {
+	if !logForwardingIncluded {
+		t.Skip("log forwarding is excluded from the build")
+	}
+
 	e1 := newLogEvents(testCommonAttributes, loggingConfigEnabled(2))
 	e2 := newLogEvents(testCommonAttributes, loggingConfigEnabled(3))
 
@@ -262,6 +310,10 @@ func TestLogEventMergeFailedSuccess(t *testing.T) {
 }
 
 func TestLogEventMergeFailedLimitReached(t *testing.T) {
+	if !logForwardingIncluded {
+		t.Skip("log forwarding is excluded from the build")
+	}
+
 	e1 := newLogEvents(testCommonAttributes, loggingConfigEnabled(2))
 	e2 := newLogEvents(testCommonAttributes, loggingConfigEnabled(3))
 
@@ -301,6 +353,10 @@ func TestLogEventMergeFailedLimitReached(t *testing.T) {
 }
 
 func TestLogEventsSplitFull(t *testing.T) {
+	if !logForwardingIncluded {
+		t.Skip("log forwarding is excluded from the build")
+	}
+
 	events := newLogEvents(testCommonAttributes, loggingConfigEnabled(10))
 	for i := 0; i < 15; i++ {
 		priority := priority(float32(i) / 10.0)
@@ -340,6 +396,10 @@ func TestLogEventsSplitFull(t *testing.T) {
 // TODO: When miniumu supported go version is 1.18, make an event heap in GO generics and remove all this duplicate code
 // interfaces are too slow :(
 func TestLogEventsSplitNotFullOdd(t *testing.T) {
+	if !logForwardingIncluded {
+		t.Skip("log forwarding is excluded from the build")
+	}
+
 	events := newLogEvents(testCommonAttributes, loggingConfigEnabled(10))
 	for i := 0; i < 7; i++ {
 		priority := priority(float32(i) / 10.0)
@@ -370,6 +430,10 @@ func TestLogEventsSplitNotFullOdd(t *testing.T) {
 }
 
 func TestLogEventsSplitNotFullEven(t *testing.T) {
+	if !logForwardingIncluded {
+		t.Skip("log forwarding is excluded from the build")
+	}
+
 	events := newLogEvents(testCommonAttributes, loggingConfigEnabled(10))
 	for i := 0; i < 8; i++ {
 		priority := priority(float32(i) / 10.0)
@@ -0,0 +1,107 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !newrelic_nologforwarding && !newrelic_minimal
+// +build !newrelic_nologforwarding,!newrelic_minimal
+
+package newrelic
+
+import (
+	"bytes"
+
+	"github.com/newrelic/go-agent/v3/internal/jsonx"
+	"github.com/newrelic/go-agent/v3/internal/logcontext"
+)
+
+// logForwardingIncluded records whether the forwarding of log events is
+// included in the build.
+const logForwardingIncluded = true
+
+// writeJSON prepares JSON in the format expected by the collector.
+func (e *logEvent) WriteJSON(buf *bytes.Buffer) {
+	w := jsonFieldsWriter{buf: buf}
+	buf.WriteByte('{')
+	w.stringField(logcontext.LogSeverityFieldName, e.severity)
+	w.stringField(logcontext.LogMessageFieldName, e.message)
+
+	if len(e.spanID) > 0 {
+		w.stringField(logcontext.LogSpanIDFieldName, e.spanID)
+	}
+	if len(e.traceID) > 0 {
+		w.stringField(logcontext.LogTraceIDFieldName, e.traceID)
+	}
+
+	w.needsComma = false
+	buf.WriteByte(',')
+	w.intField(logcontext.LogTimestampFieldName, e.timestamp)
+	if e.attributes != nil && len(e.attributes) > 0 {
+		buf.WriteString(`,"attributes":{`)
+		w := jsonFieldsWriter{buf: buf}
+		for key, val := range e.attributes {
+			writeAttributeValueJSON(&w, key, val)
+		}
+		buf.WriteByte('}')
+	}
+	buf.WriteByte('}')
+}
+
+// MarshalJSON is used for testing.
+func (e *logEvent) MarshalJSON() ([]byte, error) {
+	buf := bytes.NewBuffer(make([]byte, 0, logcontext.AverageLogSizeEstimate))
+	e.WriteJSON(buf)
+	return buf.Bytes(), nil
+}
+
+func (events *logEvents) CollectorJSON(agentRunID string) ([]byte, error) {
+	if len(events.logs) == 0 {
+		return nil, nil
+	}
+
+	estimate := logcontext.AverageLogSizeEstimate * len(events.logs)
+	buf := bytes.NewBuffer(make([]byte, 0, estimate))
+
+	if events.numSeen == 0 {
+		return nil, nil
+	}
+
+	buf.WriteByte('[')
+	buf.WriteByte('{')
+	buf.WriteString(`"common":`)
+	buf.WriteByte('{')
+	buf.WriteString(`"attributes":`)
+	buf.WriteByte('{')
+	buf.WriteString(`"entity.guid":`)
+	jsonx.AppendString(buf, events.entityGUID)
+	buf.WriteByte(',')
+	buf.WriteString(`"entity.name":`)
+	jsonx.AppendString(buf, events.entityName)
+	buf.WriteByte(',')
+	buf.WriteString(`"hostname":`)
+	jsonx.AppendString(buf, events.hostname)
+	for _, attr := range events.kubernetes {
+		buf.WriteByte(',')
+		jsonx.AppendString(buf, attr.name)
+		buf.WriteByte(':')
+		jsonx.AppendString(buf, attr.value)
+	}
+	buf.WriteByte('}')
+	buf.WriteByte('}')
+	buf.WriteByte(',')
+	buf.WriteString(`"logs":`)
+	buf.WriteByte('[')
+	for i, e := range events.logs {
+		// If severity is empty string, then this is not a user provided entry, and is empty.
+		// Do not write json to buffer in this case.
+		if e.severity != "" {
+			e.WriteJSON(buf)
+			if i != len(events.logs)-1 {
+				buf.WriteByte(',')
+			}
+		}
+
+	}
+	buf.WriteByte(']')
+	buf.WriteByte('}')
+	buf.WriteByte(']')
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,20 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build newrelic_nologforwarding || newrelic_minimal
+// +build newrelic_nologforwarding newrelic_minimal
+
+package newrelic
+
+// logForwardingIncluded records whether the forwarding of log events is
+// included in the build.  When it is excluded by the newrelic_nologforwarding
+// or the newrelic_minimal build tag, ApplicationLogging.Forwarding.Enabled is
+// always false, and the logs are not recorded as log events.  The logging
+// metrics and the local decorating are still supported.
+const logForwardingIncluded = false
+
+// CollectorJSON returns no payload when the forwarding of log events is
+// excluded from the build.
+func (events *logEvents) CollectorJSON(agentRunID string) ([]byte, error) {
+	return nil, nil
+}
@@ -0,0 +1,74 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !newrelic_nologforwarding && !newrelic_minimal
+// +build !newrelic_nologforwarding,!newrelic_minimal
+
+package newrelic
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/newrelic/go-agent/v3/internal/logcontext"
+)
+
+func TestWriteJSON(t *testing.T) {
+	event := logEvent{
+		severity:  "INFO",
+		message:   "test message",
+		timestamp: 123456,
+	}
+	actual, err := event.MarshalJSON()
+	if err != nil {
+		t.Error(err)
+	}
+
+	expect := `{"level":"INFO","message":"test message","timestamp":123456}`
+	actualString := string(actual)
+	if expect != actualString {
+		t.Errorf("Log json did not build correctly: expecting %s, got %s", expect, actualString)
+	}
+}
+
+func TestWriteJSONWithTrace(t *testing.T) {
+	event := logEvent{
+		severity:  "INFO",
+		message:   "test message",
+		timestamp: 123456,
+		traceID:   "123Ad234",
+		spanID:    "adf3441",
+	}
+	actual, err := event.MarshalJSON()
+	if err != nil {
+		t.Error(err)
+	}
+
+	expect := `{"level":"INFO","message":"test message","span.id":"adf3441","trace.id":"123Ad234","timestamp":123456}`
+	actualString := string(actual)
+	if expect != actualString {
+		t.Errorf("Log json did not build correctly: expecting %s, got %s", expect, actualString)
+	}
+}
+
+func BenchmarkWriteJSON(b *testing.B) {
+	data := LogData{
+		Timestamp: 123456,
+		Severity:  "INFO",
+		Message:   "This is a log message that represents an estimate for how long the average log message is. The average log payload is 700 bytes.",
+	}
+
+	event, err := data.toLogEvent()
+	if err != nil {
+		b.Fail()
+	}
+
+	buf := bytes.NewBuffer(make([]byte, 0, logcontext.AverageLogSizeEstimate))
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for n := 0; n < b.N; n++ {
+		event.WriteJSON(buf)
+	}
+}
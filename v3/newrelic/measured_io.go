@@ -0,0 +1,130 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package newrelic
+
+import (
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Attributes added to the segments of MeasuredReader and MeasuredWriter.
+const (
+	measuredBytesRead     = "io.bytesRead"
+	measuredReadDuration  = "io.readDuration"
+	measuredBytesWritten  = "io.bytesWritten"
+	measuredWriteDuration = "io.writeDuration"
+)
+
+// MeasuredReader wraps an io.Reader to record the reading of a stream, such as
+// a large download or upload body, as a segment of a transaction.  The segment
+// starts when the MeasuredReader is created and ends when it is closed.  The
+// number of bytes read and the time spent in Read, in seconds, are added to the
+// segment as the attributes "io.bytesRead" and "io.readDuration": the
+// difference between the duration of the segment and the time spent in Read
+// is the time spent processing the data read.
+//
+//	body := newrelic.NewMeasuredReader(txn, "download", resp.Body)
+//	defer body.Close()
+//	_, err := io.Copy(file, body)
+type MeasuredReader struct {
+	// The counters are accessed atomically, and are first to ensure their
+	// alignment on 32-bit platforms.
+	bytes    int64
+	duration int64
+
+	reader  io.Reader
+	segment *Segment
+	closed  sync.Once
+}
+
+// NewMeasuredReader starts a segment with the given name and returns a
+// MeasuredReader which reads from r.  If txn is nil, the reads are not
+// recorded.
+func NewMeasuredReader(txn *Transaction, name string, r io.Reader) *MeasuredReader {
+	return &MeasuredReader{
+		reader:  r,
+		segment: txn.StartSegment(name),
+	}
+}
+
+// Read reads from the underlying io.Reader.
+func (r *MeasuredReader) Read(p []byte) (int, error) {
+	start := time.Now()
+	n, err := r.reader.Read(p)
+	atomic.AddInt64(&r.duration, int64(time.Since(start)))
+	atomic.AddInt64(&r.bytes, int64(n))
+	return n, err
+}
+
+// Close ends the segment, and closes the underlying io.Reader if it
+// implements io.Closer.  The segment is only ended the first time Close is
+// called.
+func (r *MeasuredReader) Close() error {
+	r.closed.Do(func() {
+		r.segment.AddAttribute(measuredBytesRead, atomic.LoadInt64(&r.bytes))
+		r.segment.AddAttribute(measuredReadDuration, time.Duration(atomic.LoadInt64(&r.duration)).Seconds())
+		r.segment.End()
+	})
+	if c, ok := r.reader.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// MeasuredWriter wraps an io.Writer to record the writing of a stream, such as
+// a large response or a streaming copy, as a segment of a transaction.  The
+// segment starts when the MeasuredWriter is created and ends when it is
+// closed.  The number of bytes written and the time spent in Write, in
+// seconds, are added to the segment as the attributes "io.bytesWritten" and
+// "io.writeDuration".
+//
+//	w := newrelic.NewMeasuredWriter(txn, "upload", file)
+//	defer w.Close()
+//	_, err := io.Copy(w, r.Body)
+type MeasuredWriter struct {
+	// The counters are accessed atomically, and are first to ensure their
+	// alignment on 32-bit platforms.
+	bytes    int64
+	duration int64
+
+	writer  io.Writer
+	segment *Segment
+	closed  sync.Once
+}
+
+// NewMeasuredWriter starts a segment with the given name and returns a
+// MeasuredWriter which writes to w.  If txn is nil, the writes are not
+// recorded.
+func NewMeasuredWriter(txn *Transaction, name string, w io.Writer) *MeasuredWriter {
+	return &MeasuredWriter{
+		writer:  w,
+		segment: txn.StartSegment(name),
+	}
+}
+
+// Write writes to the underlying io.Writer.
+func (w *MeasuredWriter) Write(p []byte) (int, error) {
+	start := time.Now()
+	n, err := w.writer.Write(p)
+	atomic.AddInt64(&w.duration, int64(time.Since(start)))
+	atomic.AddInt64(&w.bytes, int64(n))
+	return n, err
+}
+
+// Close ends the segment, and closes the underlying io.Writer if it
+// implements io.Closer.  The segment is only ended the first time Close is
+// called.
+func (w *MeasuredWriter) Close() error {
+	w.closed.Do(func() {
+		w.segment.AddAttribute(measuredBytesWritten, atomic.LoadInt64(&w.bytes))
+		w.segment.AddAttribute(measuredWriteDuration, time.Duration(atomic.LoadInt64(&w.duration)).Seconds())
+		w.segment.End()
+	})
+	if c, ok := w.writer.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package newrelic
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/newrelic/go-agent/v3/internal"
+)
+
+type closeRecorder struct {
+	bytes.Buffer
+	closed int
+}
+
+func (c *closeRecorder) Close() error {
+	c.closed++
+	return nil
+}
+
+func TestMeasuredReaderWriter(t *testing.T) {
+	app := testApp(distributedTracingReplyFields, enableBetterCAT, t)
+	txn := app.StartTransaction("hello")
+
+	dst := &closeRecorder{}
+	r := NewMeasuredReader(txn, "download", strings.NewReader("hello world"))
+	w := NewMeasuredWriter(txn, "upload", dst)
+	if n, err := io.Copy(w, r); err != nil || n != 11 {
+		t.Fatal(n, err)
+	}
+	if err := w.Close(); err != nil {
+		t.Error(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Error(err)
+	}
+	if err := r.Close(); err != nil {
+		t.Error(err)
+	}
+	txn.End()
+
+	if dst.closed != 2 || dst.String() != "hello world" {
+		t.Error("incorrect writer", dst.closed, dst.String())
+	}
+	app.expectNoLoggedErrors(t)
+	app.ExpectMetricsPresent(t, []internal.WantMetric{
+		{Name: "Custom/download", Scope: "OtherTransaction/Go/hello", Forced: false, Data: []float64{1}},
+		{Name: "Custom/upload", Scope: "OtherTransaction/Go/hello", Forced: false, Data: []float64{1}},
+	})
+	app.ExpectSpanEvents(t, []internal.WantEvent{
+		{
+			Intrinsics: map[string]interface{}{
+				"name":          "Custom/upload",
+				"category":      "generic",
+				"parentId":      internal.MatchAnything,
+				"transactionId": internal.MatchAnything,
+			},
+			UserAttributes: map[string]interface{}{
+				"io.bytesWritten":  11,
+				"io.writeDuration": internal.MatchAnything,
+			},
+		},
+		{
+			Intrinsics: map[string]interface{}{
+				"name":          "Custom/download",
+				"category":      "generic",
+				"parentId":      internal.MatchAnything,
+				"transactionId": internal.MatchAnything,
+			},
+			UserAttributes: map[string]interface{}{
+				"io.bytesRead":    11,
+				"io.readDuration": internal.MatchAnything,
+			},
+		},
+		{
+			Intrinsics: map[string]interface{}{
+				"name":             "OtherTransaction/Go/hello",
+				"category":         "generic",
+				"nr.entryPoint":    true,
+				"transaction.name": "OtherTransaction/Go/hello",
+				"transactionId":    internal.MatchAnything,
+			},
+		},
+	})
+}
+
+func TestMeasuredReaderNilTransaction(t *testing.T) {
+	r := NewMeasuredReader(nil, "download", strings.NewReader("hello"))
+	b, err := io.ReadAll(r)
+	if err != nil || string(b) != "hello" {
+		t.Error(string(b), err)
+	}
+	if err := r.Close(); err != nil {
+		t.Error(err)
+	}
+
+	w := NewMeasuredWriter(nil, "upload", io.Discard)
+	if n, err := w.Write([]byte("hello")); err != nil || n != 5 {
+		t.Error(n, err)
+	}
+	if err := w.Close(); err != nil {
+		t.Error(err)
+	}
+}
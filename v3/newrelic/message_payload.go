@@ -0,0 +1,77 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package newrelic
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"unicode/utf8"
+)
+
+// MessagePayload is a message produced or consumed, captured when
+// Config.MessagePayloads is enabled.
+type MessagePayload struct {
+	// Key is the key of the message, such as the key of a Kafka record or
+	// the routing key of a RabbitMQ message.
+	Key string
+	// Headers are the headers of the message.  Do not include the
+	// distributed tracing headers.
+	Headers map[string]string
+	// Body is the body of the message.  Only a snippet of the body is
+	// recorded.  Bodies which are not valid UTF-8 are recorded in base64.
+	Body []byte
+}
+
+// messagePayloadAttributes returns the attributes describing a message, or
+// nil if the capture of message payloads is disabled.  It must be called with
+// the transaction locked.
+func messagePayloadAttributes(c config, p *MessagePayload) spanAttributeMap {
+	if nil == p || !c.MessagePayloads.Enabled || c.HighSecurity {
+		return nil
+	}
+	msg := MessagePayload{Key: p.Key, Body: p.Body}
+	if len(p.Headers) > 0 {
+		msg.Headers = make(map[string]string, len(p.Headers))
+		for k, v := range p.Headers {
+			msg.Headers[k] = v
+		}
+	}
+	if fn := c.MessagePayloads.Redactor; nil != fn {
+		fn(&msg)
+	}
+
+	var attrs spanAttributeMap
+	attrs.addString(AttributeMessageKey, truncateStringValueIfLong(msg.Key))
+	if len(msg.Headers) > 0 {
+		if js, err := json.Marshal(msg.Headers); nil == err {
+			attrs.addString(AttributeMessageHeaders, truncateStringValueIfLong(string(js)))
+		}
+	}
+	if nil != msg.Body {
+		attrs.addString(AttributeMessagePayload, messagePayloadSnippet(msg.Body, c.MessagePayloads.MaxBytes))
+		attrs.addInt(AttributeMessagePayloadSize, len(msg.Body))
+	}
+	return attrs
+}
+
+// messagePayloadSnippet returns the start of the body, of at most maxBytes
+// bytes.
+func messagePayloadSnippet(body []byte, maxBytes int) string {
+	if maxBytes <= 0 || maxBytes > attributeValueLengthLimit {
+		maxBytes = attributeValueLengthLimit
+	}
+	start := body
+	if len(start) > maxBytes+utf8.UTFMax {
+		start = start[:maxBytes+utf8.UTFMax]
+	}
+	if snippet := stringLengthByteLimit(string(start), maxBytes); utf8.ValidString(snippet) {
+		return snippet
+	}
+	// Each 3 bytes are encoded as 4 characters.
+	n := maxBytes / 4 * 3
+	if len(body) < n {
+		n = len(body)
+	}
+	return base64.StdEncoding.EncodeToString(body[:n])
+}
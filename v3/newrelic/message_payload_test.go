@@ -0,0 +1,161 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package newrelic
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/newrelic/go-agent/v3/internal"
+)
+
+func TestMessageProducerSegmentPayload(t *testing.T) {
+	app := testApp(func(reply *internal.ConnectReply) {
+		reply.SetSampleEverything()
+	}, func(cfg *Config) {
+		ConfigMessagePayloadsEnabled(true)(cfg)
+		cfg.MessagePayloads.MaxBytes = 8
+		ConfigMessagePayloadRedactor(func(p *MessagePayload) {
+			delete(p.Headers, "authorization")
+		})(cfg)
+	}, t)
+	headers := map[string]string{"authorization": "secret", "content-type": "application/json"}
+	txn := app.StartTransaction("hello")
+	s := MessageProducerSegment{
+		StartTime:       txn.StartSegmentNow(),
+		Library:         "Kafka",
+		DestinationType: MessageTopic,
+		DestinationName: "orders",
+		Payload: &MessagePayload{
+			Key:     "order-1",
+			Headers: headers,
+			Body:    []byte(`{"order":1,"total":10}`),
+		},
+	}
+	s.End()
+	txn.End()
+	app.expectNoLoggedErrors(t)
+	if _, ok := headers["authorization"]; !ok {
+		t.Error("headers of the message modified by the redactor")
+	}
+	app.ExpectSpanEvents(t, []internal.WantEvent{
+		{
+			Intrinsics: map[string]interface{}{
+				"parentId": internal.MatchAnything,
+				"name":     "MessageBroker/Kafka/Topic/Produce/Named/orders",
+				"category": "generic",
+			},
+			UserAttributes: map[string]interface{}{},
+			AgentAttributes: map[string]interface{}{
+				AttributeMessageKey:         "order-1",
+				AttributeMessageHeaders:     `{"content-type":"application/json"}`,
+				AttributeMessagePayload:     `{"order"`,
+				AttributeMessagePayloadSize: 22,
+			},
+		},
+		{
+			Intrinsics: map[string]interface{}{
+				"name":             "OtherTransaction/Go/hello",
+				"transaction.name": "OtherTransaction/Go/hello",
+				"sampled":          true,
+				"category":         "generic",
+				"nr.entryPoint":    true,
+			},
+			UserAttributes:  map[string]interface{}{},
+			AgentAttributes: map[string]interface{}{},
+		},
+	})
+}
+
+func TestMessagePayloadDisabled(t *testing.T) {
+	for _, cfgfn := range []func(*Config){
+		nil,
+		func(cfg *Config) {
+			cfg.MessagePayloads.Enabled = true
+			cfg.HighSecurity = true
+		},
+	} {
+		app := testApp(func(reply *internal.ConnectReply) {
+			reply.SetSampleEverything()
+		}, cfgfn, t)
+		txn := app.StartTransaction("hello")
+		s := MessageConsumerSegment{
+			StartTime:       txn.StartSegmentNow(),
+			Library:         "Kafka",
+			DestinationType: MessageTopic,
+			DestinationName: "orders",
+			Payload:         &MessagePayload{Key: "order-1", Body: []byte("body")},
+		}
+		s.End()
+		txn.SetMessagePayload(MessagePayload{Key: "order-1", Body: []byte("body")})
+		txn.End()
+		app.expectNoLoggedErrors(t)
+		app.ExpectSpanEvents(t, []internal.WantEvent{
+			{
+				Intrinsics: map[string]interface{}{
+					"parentId": internal.MatchAnything,
+					"name":     "MessageBroker/Kafka/Topic/Consume/Named/orders",
+					"category": "generic",
+				},
+				UserAttributes:  map[string]interface{}{},
+				AgentAttributes: map[string]interface{}{},
+			},
+			{
+				Intrinsics: map[string]interface{}{
+					"name":             "OtherTransaction/Go/hello",
+					"transaction.name": "OtherTransaction/Go/hello",
+					"sampled":          true,
+					"category":         "generic",
+					"nr.entryPoint":    true,
+				},
+				UserAttributes:  map[string]interface{}{},
+				AgentAttributes: map[string]interface{}{},
+			},
+		})
+	}
+}
+
+func TestSetMessagePayload(t *testing.T) {
+	app := testApp(nil, func(cfg *Config) {
+		cfg.DistributedTracer.Enabled = false
+		ConfigMessagePayloadsEnabled(true)(cfg)
+		ConfigMessagePayloadRedactor(func(p *MessagePayload) {
+			p.Body = []byte("[redacted]")
+		})(cfg)
+	}, t)
+	txn := app.StartTransaction("hello")
+	txn.SetMessagePayload(MessagePayload{Key: "order-1", Body: []byte("card 4111111111111111")})
+	txn.End()
+	app.expectNoLoggedErrors(t)
+	app.ExpectTxnEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"name": "OtherTransaction/Go/hello",
+		},
+		AgentAttributes: map[string]interface{}{
+			AttributeMessageKey:         "order-1",
+			AttributeMessagePayload:     "[redacted]",
+			AttributeMessagePayloadSize: 10,
+		},
+	}})
+}
+
+func TestMessagePayloadSnippet(t *testing.T) {
+	for _, tc := range []struct {
+		body     string
+		maxBytes int
+		want     string
+	}{
+		{body: "hello", maxBytes: 10, want: "hello"},
+		{body: "hello world", maxBytes: 5, want: "hello"},
+		{body: "héllo", maxBytes: 2, want: "h"},
+		{body: "\xff\xfe\xfd\x00", maxBytes: 8, want: "//79AA=="},
+		{body: "\xff\xfe\xfd\x00\x01\x02\x03", maxBytes: 4, want: "//79"},
+		{body: strings.Repeat("a", 300), maxBytes: 0, want: strings.Repeat("a", 255)},
+		{body: strings.Repeat("a", 300), maxBytes: 1000, want: strings.Repeat("a", 255)},
+	} {
+		if got := messagePayloadSnippet([]byte(tc.body), tc.maxBytes); got != tc.want {
+			t.Errorf("messagePayloadSnippet(%q, %d) = %q, want %q", tc.body, tc.maxBytes, got, tc.want)
+		}
+	}
+}
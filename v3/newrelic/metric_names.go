@@ -6,8 +6,10 @@ package newrelic
 import "fmt"
 
 const (
-	apdexRollup = "Apdex"
-	apdexPrefix = "Apdex/"
+	apdexRollup      = "Apdex"
+	apdexPrefix      = "Apdex/"
+	apdexOtherRollup = "ApdexOther"
+	apdexOtherPrefix = "ApdexOther/Transaction/"
 
 	webRollup        = "WebTransaction"
 	backgroundRollup = "OtherTransaction/all"
@@ -31,6 +33,10 @@ const (
 	// https://newrelic.atlassian.net/wiki/display/eng/Custom+Events+in+New+Relic+Agents
 	customEventsSeen = "Supportability/Events/Customer/Seen"
 	customEventsSent = "Supportability/Events/Customer/Sent"
+	// customEventsDropped is the prefix of the metric counting the custom
+	// events of a type which were dropped because the type is not
+	// permitted or its quota was exceeded.
+	customEventsDropped = "Supportability/Events/Customer/Dropped/"
 
 	// https://source.datanerd.us/agents/agent-specs/blob/master/Transaction-Events-PORTED.md
 	txnEventsSeen = "Supportability/AnalyticsEvents/TotalEventsSeen"
@@ -57,6 +63,21 @@ const (
 	gcPauseFraction      = "GC/System/Pause Fraction"
 	gcPauses             = "GC/System/Pauses"
 
+	// Container metrics, recorded when the cgroup of the process has a
+	// memory limit.
+	containerMemoryUsed        = "Memory/Container/Used"
+	containerMemoryLimit       = "Memory/Container/Limit"
+	containerMemoryUtilization = "Memory/Container/Utilization"
+
+	// Detailed runtime metrics, recorded when
+	// Config.RuntimeSampler.DetailedMetrics is enabled.
+	runtimeGCPauses         = "Go/Runtime/GC/Pauses"
+	runtimeGCHeapGoal       = "Go/Runtime/GC/HeapGoal"
+	runtimeGCHeapLive       = "Go/Runtime/GC/HeapLive"
+	runtimeCgoCalls         = "Go/Runtime/CgoCalls"
+	runtimeSchedLatency     = "Go/Runtime/Scheduler/Latency"
+	runtimeMemoryClassesPfx = "Go/Runtime/Memory/Classes/"
+
 	// Configurable event harvest supportability metrics
 	supportReportPeriod     = "Supportability/EventHarvest/ReportPeriod"
 	supportTxnEventLimit    = "Supportability/EventHarvest/AnalyticEventData/HarvestLimit"
@@ -81,6 +102,15 @@ const (
 	logEventsSent = "Supportability/Logging/Forwarding/Sent"
 )
 
+const (
+	// Supportability of Config.GoroutineBudget (once per harvest)
+	supportGoroutineBudgetMax      = "Supportability/Go/GoroutineBudget/Max"
+	supportGoroutineBudgetExcluded = "Supportability/Go/GoroutineBudget/Excluded/"
+	// Recorded each second a harvest is delayed since the previous one is
+	// still being sent.
+	supportGoroutineBudgetHarvestDelayed = "Supportability/Go/GoroutineBudget/HarvestDelayed"
+)
+
 func supportMetric(metrics *metricTable, b bool, metricName string) {
 	if b {
 		metrics.addSingleCount(metricName, forced)
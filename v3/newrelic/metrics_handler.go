@@ -0,0 +1,203 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package newrelic
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// metricsHandlerContentType is the content type of the Prometheus text
+// exposition format.
+const metricsHandlerContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// Event types used as the "type" label of the event metrics of
+// MetricsHandler.
+var agentStatsEventTypes = []string{"custom", "error", "log", "span", "transaction"}
+
+// agentStats holds the statistics exposed by MetricsHandler.  They are
+// cumulative over the lifetime of the application.
+type agentStats struct {
+	webTxns   atomic.Int64
+	otherTxns atomic.Int64
+	txnErrors atomic.Int64
+
+	sync.Mutex
+	// harvests holds the number of harvest requests by endpoint and
+	// result.
+	harvests      map[harvestRequestKey]int64
+	lastHarvest   time.Time
+	eventsSeen    map[string]float64
+	eventsDropped map[string]float64
+}
+
+type harvestRequestKey struct {
+	endpoint string
+	success  bool
+}
+
+// recordTxn records the end of a transaction which was not ignored.
+func (s *agentStats) recordTxn(isWeb bool, errors int) {
+	if isWeb {
+		s.webTxns.Add(1)
+	} else {
+		s.otherTxns.Add(1)
+	}
+	s.txnErrors.Add(int64(errors))
+}
+
+// recordHarvestRequest records the result of a harvest request.
+func (s *agentStats) recordHarvestRequest(endpoint string, success bool, now time.Time) {
+	s.Lock()
+	defer s.Unlock()
+	if s.harvests == nil {
+		s.harvests = make(map[harvestRequestKey]int64)
+	}
+	s.harvests[harvestRequestKey{endpoint: endpoint, success: success}]++
+	if success {
+		s.lastHarvest = now
+	}
+}
+
+// recordEvents records the events seen and dropped by the event pools of a
+// harvest ready to be sent.  Events are dropped when more are seen than a
+// pool can hold.
+func (s *agentStats) recordEvents(ready *harvest) {
+	if ready == nil {
+		return
+	}
+	s.Lock()
+	defer s.Unlock()
+	if s.eventsSeen == nil {
+		s.eventsSeen = make(map[string]float64)
+		s.eventsDropped = make(map[string]float64)
+	}
+	add := func(eventType string, seen, saved float64) {
+		s.eventsSeen[eventType] += seen
+		s.eventsDropped[eventType] += seen - saved
+	}
+	if ready.CustomEvents != nil {
+		add("custom", ready.CustomEvents.NumSeen(), ready.CustomEvents.NumSaved())
+	}
+	if ready.ErrorEvents != nil {
+		add("error", ready.ErrorEvents.NumSeen(), ready.ErrorEvents.NumSaved())
+	}
+	if ready.LogEvents != nil {
+		add("log", ready.LogEvents.NumSeen(), ready.LogEvents.NumSaved())
+	}
+	if ready.SpanEvents != nil {
+		add("span", ready.SpanEvents.NumSeen(), ready.SpanEvents.NumSaved())
+	}
+	if ready.TxnEvents != nil {
+		add("transaction", ready.TxnEvents.NumSeen(), ready.TxnEvents.NumSaved())
+	}
+}
+
+// MetricsHandler returns an http.Handler exposing the agent's own view of the
+// application in the Prometheus text format, so that local dashboards and
+// alerts keep working when data cannot be sent to New Relic.  Mount it on a
+// private port or path:
+//
+//	http.Handle("/metrics", newrelic.MetricsHandler(app))
+//
+// The following metrics are exposed, cumulative since the application was
+// created:
+//
+//	newrelic_agent_transactions_total{type="web|other"}
+//	newrelic_agent_transaction_errors_total
+//	newrelic_agent_harvest_requests_total{endpoint="...",result="success|failure"}
+//	newrelic_agent_last_successful_harvest_timestamp_seconds
+//	newrelic_agent_events_seen_total{type="custom|error|log|span|transaction"}
+//	newrelic_agent_events_dropped_total{type="custom|error|log|span|transaction"}
+//	newrelic_agent_connected
+//	newrelic_agent_telemetry_paused
+//
+// The error rate is the rate of newrelic_agent_transaction_errors_total
+// divided by the rate of newrelic_agent_transactions_total.  Events are
+// dropped when more are recorded during a harvest cycle than the limits of
+// the harvest allow, and are counted when their harvest cycle ends.
+func MetricsHandler(app *Application) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var buf bytes.Buffer
+		if app != nil && app.app != nil {
+			app.app.writeAgentStats(&buf)
+		}
+		w.Header().Set("Content-Type", metricsHandlerContentType)
+		w.Write(buf.Bytes())
+	})
+}
+
+func (app *app) writeAgentStats(buf *bytes.Buffer) {
+	s := &app.stats
+
+	writeMetricHeader(buf, "newrelic_agent_transactions_total", "counter", "Transactions ended, by type.")
+	fmt.Fprintf(buf, "newrelic_agent_transactions_total{type=\"web\"} %d\n", s.webTxns.Load())
+	fmt.Fprintf(buf, "newrelic_agent_transactions_total{type=\"other\"} %d\n", s.otherTxns.Load())
+
+	writeMetricHeader(buf, "newrelic_agent_transaction_errors_total", "counter", "Errors noticed by transactions.")
+	fmt.Fprintf(buf, "newrelic_agent_transaction_errors_total %d\n", s.txnErrors.Load())
+
+	s.Lock()
+	keys := make([]harvestRequestKey, 0, len(s.harvests))
+	for key := range s.harvests {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].endpoint != keys[j].endpoint {
+			return keys[i].endpoint < keys[j].endpoint
+		}
+		return keys[i].success && !keys[j].success
+	})
+	writeMetricHeader(buf, "newrelic_agent_harvest_requests_total", "counter", "Harvest requests sent to New Relic, by endpoint and result.")
+	for _, key := range keys {
+		result := "failure"
+		if key.success {
+			result = "success"
+		}
+		fmt.Fprintf(buf, "newrelic_agent_harvest_requests_total{endpoint=%q,result=%q} %d\n", key.endpoint, result, s.harvests[key])
+	}
+
+	writeMetricHeader(buf, "newrelic_agent_last_successful_harvest_timestamp_seconds", "gauge", "Time of the last successful harvest request, or 0 if none succeeded.")
+	var lastHarvest float64
+	if !s.lastHarvest.IsZero() {
+		lastHarvest = float64(s.lastHarvest.UnixNano()) / float64(time.Second)
+	}
+	fmt.Fprintf(buf, "newrelic_agent_last_successful_harvest_timestamp_seconds %s\n", formatStatValue(lastHarvest))
+
+	writeMetricHeader(buf, "newrelic_agent_events_seen_total", "counter", "Events recorded, by type.")
+	for _, eventType := range agentStatsEventTypes {
+		fmt.Fprintf(buf, "newrelic_agent_events_seen_total{type=%q} %s\n", eventType, formatStatValue(s.eventsSeen[eventType]))
+	}
+	writeMetricHeader(buf, "newrelic_agent_events_dropped_total", "counter", "Events dropped because the harvest limits were reached, by type.")
+	for _, eventType := range agentStatsEventTypes {
+		fmt.Fprintf(buf, "newrelic_agent_events_dropped_total{type=%q} %s\n", eventType, formatStatValue(s.eventsDropped[eventType]))
+	}
+	s.Unlock()
+
+	var connected, paused int
+	if run, _ := app.getState(); run.Reply.RunID != "" {
+		connected = 1
+	}
+	if app.isTelemetryPaused() {
+		paused = 1
+	}
+	writeMetricHeader(buf, "newrelic_agent_connected", "gauge", "Whether the agent is connected to New Relic.")
+	fmt.Fprintf(buf, "newrelic_agent_connected %d\n", connected)
+	writeMetricHeader(buf, "newrelic_agent_telemetry_paused", "gauge", "Whether the transmission of data is paused by PauseTelemetry.")
+	fmt.Fprintf(buf, "newrelic_agent_telemetry_paused %d\n", paused)
+}
+
+func writeMetricHeader(buf *bytes.Buffer, name, typ, help string) {
+	fmt.Fprintf(buf, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+func formatStatValue(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
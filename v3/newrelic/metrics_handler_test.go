@@ -0,0 +1,100 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package newrelic
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/newrelic/go-agent/v3/internal"
+)
+
+func serveMetrics(t *testing.T, app *Application) string {
+	t.Helper()
+	w := httptest.NewRecorder()
+	MetricsHandler(app).ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	if w.Code != http.StatusOK {
+		t.Fatal("incorrect status code:", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != metricsHandlerContentType {
+		t.Error("incorrect content type:", ct)
+	}
+	return w.Body.String()
+}
+
+func expectMetricLines(t *testing.T, body string, lines ...string) {
+	t.Helper()
+	for _, line := range lines {
+		if !strings.Contains(body, "\n"+line+"\n") {
+			t.Errorf("line %q missing from:\n%s", line, body)
+		}
+	}
+}
+
+func TestMetricsHandler(t *testing.T) {
+	app := testApp(func(reply *internal.ConnectReply) {
+		reply.RunID = "run"
+	}, nil, t)
+
+	txn := app.StartTransaction("hello")
+	txn.SetWebRequestHTTP(nil)
+	txn.NoticeError(errors.New("oops"))
+	txn.End()
+	app.StartTransaction("background").End()
+
+	ready := &harvest{CustomEvents: newCustomEvents(1)}
+	for i := 0; i < 3; i++ {
+		event, err := createCustomEvent("MyEvent", nil, time.Now())
+		if err != nil {
+			t.Fatal(err)
+		}
+		ready.CustomEvents.Add(event)
+	}
+	app.app.stats.recordEvents(ready)
+
+	now := time.Unix(1500000000, 0)
+	app.app.stats.recordHarvestRequest("metric_data", true, now)
+	app.app.stats.recordHarvestRequest("metric_data", false, now.Add(time.Minute))
+	app.app.stats.recordHarvestRequest("analytic_event_data", true, now)
+
+	body := serveMetrics(t, app.Application)
+	expectMetricLines(t, body,
+		"# TYPE newrelic_agent_transactions_total counter",
+		`newrelic_agent_transactions_total{type="web"} 1`,
+		`newrelic_agent_transactions_total{type="other"} 1`,
+		"newrelic_agent_transaction_errors_total 1",
+		`newrelic_agent_harvest_requests_total{endpoint="analytic_event_data",result="success"} 1`,
+		`newrelic_agent_harvest_requests_total{endpoint="metric_data",result="success"} 1`,
+		`newrelic_agent_harvest_requests_total{endpoint="metric_data",result="failure"} 1`,
+		"newrelic_agent_last_successful_harvest_timestamp_seconds 1500000000",
+		`newrelic_agent_events_seen_total{type="custom"} 3`,
+		`newrelic_agent_events_dropped_total{type="custom"} 2`,
+		`newrelic_agent_events_dropped_total{type="span"} 0`,
+		"newrelic_agent_connected 1",
+		"newrelic_agent_telemetry_paused 0",
+	)
+}
+
+func TestMetricsHandlerIgnoredTransaction(t *testing.T) {
+	app := testApp(nil, nil, t)
+	txn := app.StartTransaction("hello")
+	txn.Ignore()
+	txn.End()
+
+	expectMetricLines(t, serveMetrics(t, app.Application),
+		`newrelic_agent_transactions_total{type="other"} 0`,
+		"newrelic_agent_last_successful_harvest_timestamp_seconds 0",
+		"newrelic_agent_connected 0",
+	)
+}
+
+func TestMetricsHandlerNilApplication(t *testing.T) {
+	if body := serveMetrics(t, nil); body != "" {
+		t.Error("unexpected metrics for nil application:", body)
+	}
+}
@@ -0,0 +1,120 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package newrelic
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// outboxSegmentPrefix is followed by the destination and the
+	// operation.
+	outboxSegmentPrefix = "Outbox/"
+	// outboxEnqueuedAtKey is the key of the trace context holding the time
+	// the outbox row was written, in milliseconds since the epoch.
+	outboxEnqueuedAtKey = "nr-outbox-enqueued-at"
+)
+
+// StartOutboxEnqueueSegment supports the transactional outbox pattern, in
+// which a message is written to an outbox table in the same database
+// transaction as the change it describes, and published later by a separate
+// dispatcher.  Call it when writing the outbox row: it starts a segment named
+// "Outbox/<destination>/Enqueue" and returns the trace context to store in
+// the row, as a JSON string.  End the segment once the row is written.
+//
+//	seg, traceContext := txn.StartOutboxEnqueueSegment("orders")
+//	_, err := tx.Exec("INSERT INTO outbox (topic, payload, trace_context) VALUES ($1, $2, $3)",
+//		"orders", payload, traceContext)
+//	seg.End()
+//
+// The trace context holds the distributed trace headers of the segment and
+// the time the row was written.  The dispatcher passes it to
+// AcceptOutboxTraceContext or StartOutboxPublishSegment when publishing the
+// message, so that the trace continues across the outbox.
+func (txn *Transaction) StartOutboxEnqueueSegment(destination string) (*Segment, string) {
+	seg := txn.StartSegment(outboxSegmentPrefix + destination + "/Enqueue")
+	hdrs := http.Header{}
+	txn.InsertDistributedTraceHeaders(hdrs)
+
+	traceContext := map[string]string{
+		outboxEnqueuedAtKey: strconv.FormatInt(time.Now().UnixNano()/int64(time.Millisecond), 10),
+	}
+	for key := range hdrs {
+		traceContext[strings.ToLower(key)] = hdrs.Get(key)
+	}
+	js, _ := json.Marshal(traceContext)
+	return seg, string(js)
+}
+
+// parseOutboxTraceContext returns the distributed trace headers and the time
+// the outbox row was written from a trace context returned by
+// StartOutboxEnqueueSegment.  The time is zero if it is missing.
+func parseOutboxTraceContext(traceContext string) (http.Header, time.Time, error) {
+	hdrs, err := DistributedTraceHeadersFromJSON(traceContext)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	var enqueued time.Time
+	if ms, err := strconv.ParseInt(hdrs.Get(outboxEnqueuedAtKey), 10, 64); err == nil && ms > 0 {
+		enqueued = time.Unix(0, ms*int64(time.Millisecond))
+	}
+	hdrs.Del(outboxEnqueuedAtKey)
+	return hdrs, enqueued, nil
+}
+
+// AcceptOutboxTraceContext continues the trace of the transaction which wrote
+// an outbox row, from the trace context returned by
+// StartOutboxEnqueueSegment and stored in the row.  Call it early in a
+// dispatcher transaction which publishes a single message.  The headers are
+// accepted using the TransportQueue transport type, and the time the row
+// spent in the outbox is recorded as the message.queueTime attribute of the
+// transaction.  An error is returned if the trace context is not valid JSON.
+//
+// A transaction continues at most one trace.  A dispatcher publishing a batch
+// of messages should use StartOutboxPublishSegment for each message instead.
+func (txn *Transaction) AcceptOutboxTraceContext(traceContext string) error {
+	hdrs, enqueued, err := parseOutboxTraceContext(traceContext)
+	if err != nil {
+		return err
+	}
+	txn.SetMessageQueueTime(enqueued)
+	txn.AcceptDistributedTraceHeaders(TransportQueue, hdrs)
+	return nil
+}
+
+// StartOutboxPublishSegment starts a segment named
+// "Outbox/<destination>/Publish" for the publishing of a message read from
+// the outbox, and links it to the trace of the transaction which wrote the
+// row using the trace context returned by StartOutboxEnqueueSegment.  The
+// trace is linked rather than continued, so this works for dispatchers
+// publishing a batch of messages from different traces in one transaction.
+//
+// The segment records the outbox.traceId and outbox.parentId attributes,
+// identifying the trace and the enqueue span, and the outbox.queueTime
+// attribute, the seconds the row spent in the outbox.  A trace context which
+// is not valid is ignored.
+func (txn *Transaction) StartOutboxPublishSegment(destination, traceContext string) *Segment {
+	seg := txn.StartSegment(outboxSegmentPrefix + destination + "/Publish")
+	hdrs, enqueued, err := parseOutboxTraceContext(traceContext)
+	if err != nil {
+		return seg
+	}
+	// The traceparent header is "<version>-<trace id>-<parent id>-<flags>".
+	if parts := strings.Split(hdrs.Get(DistributedTraceW3CTraceParentHeader), "-"); len(parts) == 4 {
+		seg.AddAttribute("outbox.traceId", parts[1])
+		seg.AddAttribute("outbox.parentId", parts[2])
+	}
+	if !enqueued.IsZero() {
+		queueTime := time.Since(enqueued)
+		if queueTime < 0 {
+			queueTime = 0
+		}
+		seg.AddAttribute("outbox.queueTime", queueTime.Seconds())
+	}
+	return seg
+}
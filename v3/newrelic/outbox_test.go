@@ -0,0 +1,153 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package newrelic
+
+import (
+	"encoding/json"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/newrelic/go-agent/v3/internal"
+)
+
+func TestOutboxEnqueueTraceContext(t *testing.T) {
+	app := testApp(distributedTracingReplyFields, enableW3COnly, t)
+	txn := app.StartTransaction("enqueue")
+	seg, traceContext := txn.StartOutboxEnqueueSegment("orders")
+	seg.End()
+	txn.End()
+
+	var fields map[string]string
+	if err := json.Unmarshal([]byte(traceContext), &fields); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"traceparent", "tracestate", outboxEnqueuedAtKey} {
+		if fields[key] == "" {
+			t.Errorf("%s missing from trace context %s", key, traceContext)
+		}
+	}
+	if len(fields) != 3 {
+		t.Errorf("unexpected fields in trace context %s", traceContext)
+	}
+
+	app.expectNoLoggedErrors(t)
+	app.ExpectMetricsPresent(t, []internal.WantMetric{
+		{Name: "Custom/Outbox/orders/Enqueue", Scope: "OtherTransaction/Go/enqueue", Forced: false, Data: nil},
+	})
+}
+
+func TestAcceptOutboxTraceContext(t *testing.T) {
+	app := testApp(distributedTracingReplyFields, enableW3COnly, t)
+	txn := app.StartTransaction("enqueue")
+	seg, traceContext := txn.StartOutboxEnqueueSegment("orders")
+	seg.End()
+	traceID := txn.GetTraceMetadata().TraceID
+	txn.End()
+
+	txn = app.StartTransaction("dispatch")
+	if err := txn.AcceptOutboxTraceContext(traceContext); err != nil {
+		t.Fatal(err)
+	}
+	if id := txn.GetTraceMetadata().TraceID; id != traceID {
+		t.Errorf("trace not continued: got %s, want %s", id, traceID)
+	}
+	txn.End()
+
+	app.expectNoLoggedErrors(t)
+	app.ExpectMetricsPresent(t, []internal.WantMetric{
+		{Name: "Supportability/TraceContext/Accept/Success", Scope: "", Forced: true, Data: nil},
+	})
+	app.ExpectTxnEvents(t, []internal.WantEvent{
+		{
+			Intrinsics: map[string]interface{}{
+				"name":     "OtherTransaction/Go/enqueue",
+				"guid":     internal.MatchAnything,
+				"priority": internal.MatchAnything,
+				"sampled":  internal.MatchAnything,
+				"traceId":  traceID,
+			},
+		},
+		{
+			Intrinsics: map[string]interface{}{
+				"name":                     "OtherTransaction/Go/dispatch",
+				"guid":                     internal.MatchAnything,
+				"parentId":                 internal.MatchAnything,
+				"parentSpanId":             internal.MatchAnything,
+				"parent.type":              "App",
+				"parent.account":           "123",
+				"parent.app":               "456",
+				"parent.transportType":     "Queue",
+				"parent.transportDuration": internal.MatchAnything,
+				"priority":                 internal.MatchAnything,
+				"sampled":                  internal.MatchAnything,
+				"traceId":                  traceID,
+			},
+			AgentAttributes: map[string]interface{}{
+				"message.queueTime": internal.MatchAnything,
+			},
+		},
+	})
+}
+
+func TestAcceptOutboxTraceContextInvalid(t *testing.T) {
+	app := testApp(distributedTracingReplyFields, enableW3COnly, t)
+	txn := app.StartTransaction("dispatch")
+	if err := txn.AcceptOutboxTraceContext("not json"); err == nil {
+		t.Error("no error returned for invalid trace context")
+	}
+	txn.End()
+	app.expectNoLoggedErrors(t)
+}
+
+func TestStartOutboxPublishSegment(t *testing.T) {
+	app := testApp(distributedTracingReplyFields, enableW3COnly, t)
+	traceContext := `{"traceparent":"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",` +
+		`"nr-outbox-enqueued-at":"` + formatMillis(time.Now().Add(-time.Minute)) + `"}`
+
+	txn := app.StartTransaction("dispatch")
+	txn.StartOutboxPublishSegment("orders", traceContext).End()
+	txn.StartOutboxPublishSegment("orders", "not json").End()
+	txn.End()
+
+	app.expectNoLoggedErrors(t)
+	app.ExpectSpanEvents(t, []internal.WantEvent{
+		{
+			Intrinsics: map[string]interface{}{
+				"name":     "Custom/Outbox/orders/Publish",
+				"category": "generic",
+				"parentId": internal.MatchAnything,
+			},
+			UserAttributes: map[string]interface{}{
+				"outbox.traceId":   "4bf92f3577b34da6a3ce929d0e0e4736",
+				"outbox.parentId":  "00f067aa0ba902b7",
+				"outbox.queueTime": internal.MatchAnything,
+			},
+			AgentAttributes: map[string]interface{}{},
+		},
+		{
+			Intrinsics: map[string]interface{}{
+				"name":     "Custom/Outbox/orders/Publish",
+				"category": "generic",
+				"parentId": internal.MatchAnything,
+			},
+			UserAttributes:  map[string]interface{}{},
+			AgentAttributes: map[string]interface{}{},
+		},
+		{
+			Intrinsics: map[string]interface{}{
+				"name":             "OtherTransaction/Go/dispatch",
+				"transaction.name": "OtherTransaction/Go/dispatch",
+				"category":         "generic",
+				"nr.entryPoint":    true,
+			},
+			UserAttributes:  map[string]interface{}{},
+			AgentAttributes: map[string]interface{}{},
+		},
+	})
+}
+
+func formatMillis(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano()/int64(time.Millisecond), 10)
+}
@@ -0,0 +1,118 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package newrelic
+
+import (
+	"net/http"
+	"strings"
+)
+
+// TextMapCarrier is the storage of the distributed trace context propagated by
+// a Propagator, such as the headers of a Kafka message, gRPC metadata, or the
+// fields of a custom protocol.
+type TextMapCarrier interface {
+	// Get returns the value of the key, or "" if there is none.
+	Get(key string) string
+	// Set sets the value of the key.
+	Set(key, value string)
+	// Keys returns the keys of the carrier.  It is used to find keys
+	// whose case differs from the keys written by Propagator.Inject.
+	Keys() []string
+}
+
+// MapCarrier is a TextMapCarrier backed by a map.
+type MapCarrier map[string]string
+
+// Get returns the value of the key.
+func (c MapCarrier) Get(key string) string { return c[key] }
+
+// Set sets the value of the key.
+func (c MapCarrier) Set(key, value string) { c[key] = value }
+
+// Keys returns the keys of the map.
+func (c MapCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// propagatedHeaders are the distributed trace headers written by
+// Transaction.InsertDistributedTraceHeaders.
+var propagatedHeaders = []string{
+	DistributedTraceNewRelicHeader,
+	DistributedTraceW3CTraceParentHeader,
+	DistributedTraceW3CTraceStateHeader,
+}
+
+// Propagator injects the distributed trace context of a transaction into a
+// TextMapCarrier and extracts it from one, so that transactions can be linked
+// across transports which do not use http.Header.  The keys written are the
+// lowercase names of the headers written by
+// Transaction.InsertDistributedTraceHeaders: "newrelic", "traceparent", and
+// "tracestate".
+//
+//	p := newrelic.Propagator{Transport: newrelic.TransportKafka}
+//
+//	// producer
+//	carrier := newrelic.MapCarrier{}
+//	p.Inject(txn, carrier)
+//
+//	// consumer
+//	p.Extract(txn, carrier)
+type Propagator struct {
+	// Transport is the transport type of the trace context accepted by
+	// Extract.  TransportUnknown is used if it is empty.
+	Transport TransportType
+}
+
+// Inject sets the distributed trace context of the transaction in the
+// carrier.  Like Transaction.InsertDistributedTraceHeaders, it should be
+// called for every outbound message since the context contains a timestamp.
+func (p Propagator) Inject(txn *Transaction, carrier TextMapCarrier) {
+	if txn == nil || carrier == nil {
+		return
+	}
+	hdrs := http.Header{}
+	txn.InsertDistributedTraceHeaders(hdrs)
+	for _, h := range propagatedHeaders {
+		if v := hdrs.Get(h); v != "" {
+			carrier.Set(strings.ToLower(h), v)
+		}
+	}
+}
+
+// Extract links the transaction to the transaction which injected the
+// distributed trace context in the carrier, in the same way as
+// Transaction.AcceptDistributedTraceHeaders.  Keys are matched
+// case-insensitively.
+func (p Propagator) Extract(txn *Transaction, carrier TextMapCarrier) {
+	if txn == nil || carrier == nil {
+		return
+	}
+	hdrs := http.Header{}
+	for _, h := range propagatedHeaders {
+		if v := carrierValue(carrier, h); v != "" {
+			hdrs.Set(h, v)
+		}
+	}
+	t := p.Transport
+	if t == "" {
+		t = TransportUnknown
+	}
+	txn.AcceptDistributedTraceHeaders(t, hdrs)
+}
+
+func carrierValue(carrier TextMapCarrier, key string) string {
+	if v := carrier.Get(strings.ToLower(key)); v != "" {
+		return v
+	}
+	for _, k := range carrier.Keys() {
+		if strings.EqualFold(k, key) {
+			return carrier.Get(k)
+		}
+	}
+	return ""
+}
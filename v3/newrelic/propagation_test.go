@@ -0,0 +1,63 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package newrelic
+
+import (
+	"testing"
+
+	"github.com/newrelic/go-agent/v3/internal"
+)
+
+func TestPropagatorInjectExtract(t *testing.T) {
+	app := testApp(distributedTracingReplyFields, enableBetterCAT, t)
+	p := Propagator{Transport: TransportKafka}
+
+	carrier := MapCarrier{}
+	p.Inject(app.StartTransaction("producer"), carrier)
+	for _, key := range []string{"newrelic", "traceparent", "tracestate"} {
+		if carrier[key] == "" {
+			t.Errorf("key %q not injected: %v", key, carrier)
+		}
+	}
+
+	txn := app.StartTransaction("hello")
+	p.Extract(txn, carrier)
+	txn.End()
+	app.expectNoLoggedErrors(t)
+	app.ExpectMetricsPresent(t, []internal.WantMetric{
+		{Name: "DurationByCaller/App/123/456/Kafka/all", Scope: "", Forced: false, Data: nil},
+		{Name: "TransportDuration/App/123/456/Kafka/all", Scope: "", Forced: false, Data: nil},
+		{Name: "Supportability/TraceContext/Accept/Success", Scope: "", Forced: true, Data: singleCount},
+	})
+}
+
+func TestPropagatorExtractCaseInsensitive(t *testing.T) {
+	app := testApp(distributedTracingReplyFields, enableBetterCAT, t)
+	hdrs := getDTHeaders(app.Application)
+	carrier := MapCarrier{}
+	for k := range hdrs {
+		carrier[k] = hdrs.Get(k)
+	}
+
+	txn := app.StartTransaction("hello")
+	Propagator{}.Extract(txn, carrier)
+	txn.End()
+	app.expectNoLoggedErrors(t)
+	app.ExpectMetricsPresent(t, []internal.WantMetric{
+		{Name: "DurationByCaller/App/123/456/Unknown/all", Scope: "", Forced: false, Data: nil},
+	})
+}
+
+func TestPropagatorNil(t *testing.T) {
+	var txn *Transaction
+	carrier := MapCarrier{}
+	Propagator{}.Inject(txn, carrier)
+	Propagator{}.Extract(txn, carrier)
+	if len(carrier) != 0 {
+		t.Error(carrier)
+	}
+	app := testApp(distributedTracingReplyFields, enableBetterCAT, t)
+	Propagator{}.Inject(app.StartTransaction("hello"), nil)
+	Propagator{}.Extract(app.StartTransaction("hello"), nil)
+}
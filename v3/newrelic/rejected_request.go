@@ -0,0 +1,105 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package newrelic
+
+import (
+	"errors"
+	"net/http"
+	"time"
+)
+
+// Reasons for rejecting requests, for use with
+// Application.RecordRejectedRequest.  Any other reason may be used.
+const (
+	RejectedRequestUnauthorized = "Unauthorized"
+	RejectedRequestForbidden    = "Forbidden"
+	RejectedRequestBodyTooLarge = "BodyTooLarge"
+	RejectedRequestRateLimited  = "RateLimited"
+	RejectedRequestBadRequest   = "BadRequest"
+)
+
+const (
+	// rejectedRequestEventType is the type of the custom events recorded
+	// by RecordRejectedRequest.
+	rejectedRequestEventType = "RejectedRequest"
+	// rejectedRequestMetric is followed by "all" or by the reason.
+	rejectedRequestMetric = "RejectedRequest/"
+
+	rejectedRequestReason = "reason"
+)
+
+var errRejectedRequestReason = errors.New("missing rejected request reason")
+
+// RecordRejectedRequest records an inbound request which was rejected before
+// reaching its handler, for example by authentication, request size, or rate
+// limiting middleware.  Such requests do not have a transaction.  The request
+// is recorded as a custom event of type "RejectedRequest" with the reason, the
+// status code of the response, and the method, URI, and host of the request,
+// and is counted in the metrics "RejectedRequest/all" and
+// "RejectedRequest/<reason>".
+//
+//	if !authorized(r) {
+//		app.RecordRejectedRequest(r, http.StatusUnauthorized, newrelic.RejectedRequestUnauthorized)
+//		w.WriteHeader(http.StatusUnauthorized)
+//		return
+//	}
+//
+// The events are subject to the CustomInsightsEvents configuration.  An error
+// is logged if the event cannot be recorded.
+func (app *Application) RecordRejectedRequest(r *http.Request, statusCode int, reason string) {
+	if app == nil || app.app == nil {
+		return
+	}
+	if err := app.app.recordRejectedRequest(r, statusCode, reason, time.Now()); err != nil {
+		app.app.Error("unable to record rejected request", map[string]interface{}{
+			"reason": err.Error(),
+		})
+	}
+}
+
+// rejectedRequest records the event and metrics of a rejected request.
+type rejectedRequest struct {
+	event  *customEvent
+	reason string
+}
+
+func (rr *rejectedRequest) MergeIntoHarvest(h *harvest) {
+	h.CustomEvents.Add(rr.event)
+	h.Metrics.addSingleCount(rejectedRequestMetric+"all", unforced)
+	h.Metrics.addSingleCount(rejectedRequestMetric+rr.reason, unforced)
+}
+
+func (app *app) recordRejectedRequest(r *http.Request, statusCode int, reason string, now time.Time) error {
+	if reason == "" {
+		return errRejectedRequestReason
+	}
+	if !app.config.CustomInsightsEvents.Enabled {
+		return errCustomEventsDisabled
+	}
+
+	params := map[string]interface{}{
+		rejectedRequestReason: reason,
+	}
+	if statusCode != 0 {
+		params[AttributeResponseCode] = statusCode
+	}
+	if r != nil {
+		params[AttributeRequestMethod] = r.Method
+		params[AttributeRequestHost] = r.Host
+		if r.URL != nil {
+			params[AttributeRequestURI] = safeURL(r.URL)
+		}
+	}
+	event, err := createCustomEvent(rejectedRequestEventType, params, now)
+	if err != nil {
+		return err
+	}
+
+	run, _ := app.getState()
+	if !run.Reply.CollectCustomEvents {
+		return errCustomEventsRemoteDisabled
+	}
+	app.Consume(run.Reply.RunID, &rejectedRequest{event: event, reason: reason})
+	return nil
+}
@@ -0,0 +1,93 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package newrelic
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/newrelic/go-agent/v3/internal"
+)
+
+func TestRecordRejectedRequest(t *testing.T) {
+	app := testApp(nil, nil, t)
+	r := httptest.NewRequest("POST", "https://example.com/upload?token=secret", nil)
+	app.RecordRejectedRequest(r, http.StatusRequestEntityTooLarge, RejectedRequestBodyTooLarge)
+	app.RecordRejectedRequest(r, 0, RejectedRequestRateLimited)
+	app.expectNoLoggedErrors(t)
+
+	app.ExpectCustomEvents(t, []internal.WantEvent{
+		{
+			Intrinsics: map[string]interface{}{
+				"type":      "RejectedRequest",
+				"timestamp": internal.MatchAnything,
+			},
+			UserAttributes: map[string]interface{}{
+				"reason":               "BodyTooLarge",
+				"http.statusCode":      413,
+				"request.method":       "POST",
+				"request.uri":          "https://example.com/upload",
+				"request.headers.host": "example.com",
+			},
+		},
+		{
+			Intrinsics: map[string]interface{}{
+				"type":      "RejectedRequest",
+				"timestamp": internal.MatchAnything,
+			},
+			UserAttributes: map[string]interface{}{
+				"reason":               "RateLimited",
+				"request.method":       "POST",
+				"request.uri":          "https://example.com/upload",
+				"request.headers.host": "example.com",
+			},
+		},
+	})
+	app.ExpectMetrics(t, []internal.WantMetric{
+		{Name: "RejectedRequest/all", Scope: "", Forced: false, Data: []float64{2, 0, 0, 0, 0, 0}},
+		{Name: "RejectedRequest/BodyTooLarge", Scope: "", Forced: false, Data: []float64{1, 0, 0, 0, 0, 0}},
+		{Name: "RejectedRequest/RateLimited", Scope: "", Forced: false, Data: []float64{1, 0, 0, 0, 0, 0}},
+	})
+}
+
+func TestRecordRejectedRequestNilRequest(t *testing.T) {
+	app := testApp(nil, nil, t)
+	app.RecordRejectedRequest(nil, http.StatusUnauthorized, RejectedRequestUnauthorized)
+	app.expectNoLoggedErrors(t)
+	app.ExpectCustomEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"type":      "RejectedRequest",
+			"timestamp": internal.MatchAnything,
+		},
+		UserAttributes: map[string]interface{}{
+			"reason":          "Unauthorized",
+			"http.statusCode": 401,
+		},
+	}})
+}
+
+func TestRecordRejectedRequestMissingReason(t *testing.T) {
+	app := testApp(nil, nil, t)
+	app.RecordRejectedRequest(helloRequest, http.StatusForbidden, "")
+	app.expectSingleLoggedError(t, "unable to record rejected request", map[string]interface{}{
+		"reason": errRejectedRequestReason.Error(),
+	})
+	app.ExpectCustomEvents(t, []internal.WantEvent{})
+}
+
+func TestRecordRejectedRequestEventsDisabled(t *testing.T) {
+	cfgfn := func(cfg *Config) { cfg.CustomInsightsEvents.Enabled = false }
+	app := testApp(nil, cfgfn, t)
+	app.RecordRejectedRequest(helloRequest, http.StatusForbidden, RejectedRequestForbidden)
+	app.expectSingleLoggedError(t, "unable to record rejected request", map[string]interface{}{
+		"reason": errCustomEventsDisabled.Error(),
+	})
+	app.ExpectCustomEvents(t, []internal.WantEvent{})
+}
+
+func TestRecordRejectedRequestNilApplication(t *testing.T) {
+	var app *Application
+	app.RecordRejectedRequest(helloRequest, http.StatusForbidden, RejectedRequestForbidden)
+}
@@ -0,0 +1,15 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build go1.23
+// +build go1.23
+
+package newrelic
+
+import "net/http"
+
+// requestPattern returns the pattern matched by the http.ServeMux which
+// handled the request.
+func requestPattern(r *http.Request) string {
+	return r.Pattern
+}
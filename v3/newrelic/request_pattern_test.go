@@ -0,0 +1,37 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build go1.23
+// +build go1.23
+
+// The module's go directive predates Go 1.22, so enable the enhanced
+// ServeMux patterns explicitly.
+//go:debug httpmuxgo121=0
+
+package newrelic
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/newrelic/go-agent/v3/internal"
+)
+
+func TestNewMiddlewareServeMuxPattern(t *testing.T) {
+	app := testApp(nil, ConfigDistributedTracerEnabled(false), t)
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /users/{id}", myErrorHandler)
+	h := NewMiddleware(app.Application)(mux)
+
+	req, err := http.NewRequest("GET", "/users/123", nil)
+	if nil != err {
+		t.Fatal(err)
+	}
+	h.ServeHTTP(newCompatibleResponseRecorder(), req)
+
+	app.ExpectErrors(t, []internal.WantError{{
+		TxnName: "WebTransaction/Go/GET /users/{id}",
+		Msg:     "my msg",
+		Klass:   "newrelic.myError",
+	}})
+}
@@ -18,9 +18,10 @@ type rulesCacheKey struct {
 	inputName string
 }
 
+// newRulesCache returns an empty cache.  The map is allocated by the first
+// set, since the runs of disabled applications may never name a transaction.
 func newRulesCache(maxCacheSize int) *rulesCache {
 	return &rulesCache{
-		cache:        make(map[rulesCacheKey]string, maxCacheSize),
 		maxCacheSize: maxCacheSize,
 	}
 }
@@ -48,6 +49,9 @@ func (cache *rulesCache) set(inputName string, isWeb bool, finalName string) {
 	if len(cache.cache) >= cache.maxCacheSize {
 		return
 	}
+	if nil == cache.cache {
+		cache.cache = make(map[rulesCacheKey]string, cache.maxCacheSize)
+	}
 	cache.cache[rulesCacheKey{
 		inputName: inputName,
 		isWeb:     isWeb,
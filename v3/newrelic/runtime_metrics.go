@@ -0,0 +1,286 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package newrelic
+
+import (
+	"math"
+	"runtime/metrics"
+	"strings"
+	"sync"
+)
+
+// Names of the runtime/metrics read by the sampler.
+const (
+	rmGCPauses      = "/sched/pauses/total/gc:seconds"
+	rmGCPausesOld   = "/gc/pauses:seconds"
+	rmHeapGoal      = "/gc/heap/goal:bytes"
+	rmHeapLive      = "/gc/heap/live:bytes"
+	rmCgoCalls      = "/cgo/go-to-c-calls:calls"
+	rmSchedLatency  = "/sched/latencies:seconds"
+	rmMemoryClasses = "/memory/classes/"
+)
+
+var (
+	runtimeMetricsOnce  sync.Once
+	runtimeMetricsNames []string
+)
+
+// supportedRuntimeMetrics returns the names of the runtime/metrics recorded
+// which are supported by the running version of Go.  The GC pause metric was
+// renamed in Go 1.22, so the old name is only used when the new one is not
+// supported.
+func supportedRuntimeMetrics() []string {
+	runtimeMetricsOnce.Do(func() {
+		supported := make(map[string]bool)
+		var classes []string
+		for _, d := range metrics.All() {
+			supported[d.Name] = true
+			if strings.HasPrefix(d.Name, rmMemoryClasses) {
+				classes = append(classes, d.Name)
+			}
+		}
+		pauses := rmGCPauses
+		if !supported[pauses] {
+			pauses = rmGCPausesOld
+		}
+		for _, name := range []string{pauses, rmHeapGoal, rmHeapLive, rmCgoCalls, rmSchedLatency} {
+			if supported[name] {
+				runtimeMetricsNames = append(runtimeMetricsNames, name)
+			}
+		}
+		runtimeMetricsNames = append(runtimeMetricsNames, classes...)
+	})
+	return runtimeMetricsNames
+}
+
+// readRuntimeMetrics reads the supported runtime/metrics.
+func readRuntimeMetrics() map[string]metrics.Value {
+	names := supportedRuntimeMetrics()
+	samples := make([]metrics.Sample, len(names))
+	for i, name := range names {
+		samples[i].Name = name
+	}
+	metrics.Read(samples)
+	values := make(map[string]metrics.Value, len(samples))
+	for _, s := range samples {
+		values[s.Name] = s.Value
+	}
+	return values
+}
+
+// runtimeMetric is a metric created from the runtime/metrics.
+type runtimeMetric struct {
+	name string
+	data metricData
+}
+
+func runtimeMetricValue(name string, value float64) runtimeMetric {
+	return runtimeMetric{name: name, data: metricData{
+		countSatisfied: 1,
+		totalTolerated: value,
+		min:            value,
+		max:            value,
+		sumSquares:     value * value,
+	}}
+}
+
+// getRuntimeMetrics creates the metrics for the period between two readings
+// of the runtime/metrics.  Cumulative metrics like the GC pause distribution
+// are recorded as the change since the previous reading.
+func getRuntimeMetrics(previous, current map[string]metrics.Value) []runtimeMetric {
+	var ms []runtimeMetric
+	for name, cur := range current {
+		prev := previous[name]
+		switch {
+		case name == rmGCPauses || name == rmGCPausesOld:
+			ms = append(ms, histogramMetrics(runtimeGCPauses, prev, cur)...)
+		case name == rmSchedLatency:
+			ms = append(ms, histogramMetrics(runtimeSchedLatency, prev, cur)...)
+		case name == rmHeapGoal && cur.Kind() == metrics.KindUint64:
+			ms = append(ms, runtimeMetricValue(runtimeGCHeapGoal, bytesToMebibytesFloat(cur.Uint64())))
+		case name == rmHeapLive && cur.Kind() == metrics.KindUint64:
+			ms = append(ms, runtimeMetricValue(runtimeGCHeapLive, bytesToMebibytesFloat(cur.Uint64())))
+		case name == rmCgoCalls && cur.Kind() == metrics.KindUint64:
+			var calls uint64
+			if prev.Kind() == metrics.KindUint64 && cur.Uint64() >= prev.Uint64() {
+				calls = cur.Uint64() - prev.Uint64()
+			}
+			ms = append(ms, runtimeMetricValue(runtimeCgoCalls, float64(calls)))
+		case strings.HasPrefix(name, rmMemoryClasses) && cur.Kind() == metrics.KindUint64:
+			class := strings.TrimPrefix(name, rmMemoryClasses)
+			class = strings.TrimSuffix(class, ":bytes")
+			ms = append(ms, runtimeMetricValue(runtimeMemoryClassesPfx+class, bytesToMebibytesFloat(cur.Uint64())))
+		}
+	}
+	return ms
+}
+
+// histogramMetrics creates the metrics describing the values added to a
+// cumulative histogram since the previous reading: the distribution of the
+// values, estimated using the midpoints of their buckets, and the 50th and
+// 99th percentiles.  No metrics are created if no values were added.
+func histogramMetrics(name string, previous, current metrics.Value) []runtimeMetric {
+	cur, counts := histogramDelta(previous, current)
+	if nil == cur {
+		return nil
+	}
+
+	var data metricData
+	var total uint64
+	for i, n := range counts {
+		if n == 0 {
+			continue
+		}
+		lower, upper := cur.Buckets[i], cur.Buckets[i+1]
+		value := bucketMidpoint(lower, upper)
+		if total == 0 || value < data.min {
+			data.min = value
+		}
+		if total == 0 || value > data.max {
+			data.max = value
+		}
+		total += n
+		data.countSatisfied += float64(n)
+		data.totalTolerated += float64(n) * value
+		data.sumSquares += float64(n) * value * value
+	}
+	if total == 0 {
+		return nil
+	}
+	return []runtimeMetric{
+		{name: name, data: data},
+		runtimeMetricValue(name+"/p50", histogramPercentile(counts, cur.Buckets, total, 0.5)),
+		runtimeMetricValue(name+"/p99", histogramPercentile(counts, cur.Buckets, total, 0.99)),
+	}
+}
+
+// histogramDelta returns the current reading of a cumulative histogram and
+// the counts of the values added to its buckets since the previous reading,
+// or nil if the current reading is not a histogram.
+func histogramDelta(previous, current metrics.Value) (*metrics.Float64Histogram, []uint64) {
+	if current.Kind() != metrics.KindFloat64Histogram {
+		return nil, nil
+	}
+	cur := current.Float64Histogram()
+	counts := make([]uint64, len(cur.Counts))
+	copy(counts, cur.Counts)
+	if previous.Kind() == metrics.KindFloat64Histogram {
+		if prev := previous.Float64Histogram(); len(prev.Counts) == len(counts) {
+			for i := range counts {
+				if counts[i] >= prev.Counts[i] {
+					counts[i] -= prev.Counts[i]
+				} else {
+					counts[i] = 0
+				}
+			}
+		}
+	}
+	return cur, counts
+}
+
+// histogramDeltaSum returns the sum of the values added to a cumulative
+// histogram since the previous reading, estimated using the midpoints of
+// their buckets.
+func histogramDeltaSum(previous, current metrics.Value) float64 {
+	cur, counts := histogramDelta(previous, current)
+	var sum float64
+	for i, n := range counts {
+		if n > 0 {
+			sum += float64(n) * bucketMidpoint(cur.Buckets[i], cur.Buckets[i+1])
+		}
+	}
+	return sum
+}
+
+// txnRuntimeReading is a reading of the cumulative runtime/metrics used to
+// attribute the GC pauses and the scheduler latency to a transaction when
+// Config.RuntimeSampler.TransactionAttributes is enabled.  The readings are
+// pooled, since metrics.Read reuses the histograms of the samples, and so
+// reading the metrics allocates nothing once the pool is warm.
+type txnRuntimeReading struct {
+	samples []metrics.Sample
+	// The indexes of the samples, or -1 if the metric is not supported.
+	gcPausesIdx     int
+	schedLatencyIdx int
+}
+
+var txnRuntimeReadings = sync.Pool{New: func() interface{} {
+	r := &txnRuntimeReading{gcPausesIdx: -1, schedLatencyIdx: -1}
+	for _, name := range supportedRuntimeMetrics() {
+		switch name {
+		case rmGCPauses, rmGCPausesOld:
+			r.gcPausesIdx = len(r.samples)
+		case rmSchedLatency:
+			r.schedLatencyIdx = len(r.samples)
+		default:
+			continue
+		}
+		r.samples = append(r.samples, metrics.Sample{Name: name})
+	}
+	return r
+}}
+
+func readTxnRuntimeMetrics() *txnRuntimeReading {
+	r := txnRuntimeReadings.Get().(*txnRuntimeReading)
+	metrics.Read(r.samples)
+	return r
+}
+
+// release returns the reading to the pool once it is no longer used.
+func (r *txnRuntimeReading) release() { txnRuntimeReadings.Put(r) }
+
+func (r *txnRuntimeReading) value(idx int) metrics.Value {
+	if idx < 0 {
+		return metrics.Value{}
+	}
+	return r.samples[idx].Value
+}
+
+func (r *txnRuntimeReading) gcPauses() metrics.Value     { return r.value(r.gcPausesIdx) }
+func (r *txnRuntimeReading) schedLatency() metrics.Value { return r.value(r.schedLatencyIdx) }
+
+// addTxnRuntimeAttributes adds the GC pause time and the scheduler latency
+// of the process between the start and the end of the transaction.  Both are
+// approximate: the GC pauses stop every goroutine, but the scheduler latency
+// is the total of the goroutines of the process, and not only of those of
+// the transaction.
+func addTxnRuntimeAttributes(attrs agentAttributes, start, end *txnRuntimeReading) {
+	if start.gcPauses().Kind() == metrics.KindFloat64Histogram {
+		attrs.Add(AttributeRuntimeGCPauseTime, "", histogramDeltaSum(start.gcPauses(), end.gcPauses()))
+	}
+	if start.schedLatency().Kind() == metrics.KindFloat64Histogram {
+		attrs.Add(AttributeRuntimeSchedulerLatency, "", histogramDeltaSum(start.schedLatency(), end.schedLatency()))
+	}
+}
+
+// bucketMidpoint returns the value used for the values in a histogram
+// bucket.  The boundaries of the first and last buckets may be infinite.
+func bucketMidpoint(lower, upper float64) float64 {
+	switch {
+	case math.IsInf(lower, -1) && math.IsInf(upper, 1):
+		return 0
+	case math.IsInf(lower, -1):
+		return upper
+	case math.IsInf(upper, 1):
+		return lower
+	}
+	return (lower + upper) / 2
+}
+
+// histogramPercentile returns the upper boundary of the bucket containing
+// the percentile, or its lower boundary if the upper one is infinite.
+func histogramPercentile(counts []uint64, buckets []float64, total uint64, percentile float64) float64 {
+	target := uint64(math.Ceil(float64(total) * percentile))
+	var seen uint64
+	for i, n := range counts {
+		seen += n
+		if seen >= target && n > 0 {
+			if math.IsInf(buckets[i+1], 1) {
+				return buckets[i]
+			}
+			return buckets[i+1]
+		}
+	}
+	return 0
+}
@@ -0,0 +1,225 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package newrelic
+
+import (
+	"sync/atomic"
+)
+
+// The outcomes of a SagaTransaction, recorded in the AttributeSagaOutcome
+// attribute.
+const (
+	// SagaOutcomeCompleted means that every step of the saga completed.
+	SagaOutcomeCompleted = "completed"
+	// SagaOutcomeCompensated means that a step failed, and that every
+	// compensation run to roll back the saga completed.
+	SagaOutcomeCompensated = "compensated"
+	// SagaOutcomeFailed means that a step failed and that the saga was not
+	// rolled back, either because no compensation was run or because a
+	// compensation failed.
+	SagaOutcomeFailed = "failed"
+)
+
+// Outcomes of the steps of a SagaTransaction, recorded in the
+// "saga.step.outcome" attribute of their spans.
+const (
+	sagaStepCompleted          = "completed"
+	sagaStepFailed             = "failed"
+	sagaStepCompensated        = "compensated"
+	sagaStepCompensationFailed = "compensationFailed"
+)
+
+const (
+	// sagaMetricPrefix is the prefix of the metrics recorded for each
+	// SagaTransaction.  The transaction name follows, eg.
+	// "Saga/OtherTransaction/Go/place-order/Outcome/compensated".
+	sagaMetricPrefix = "Saga/"
+	// sagaStepPrefix and sagaCompensationPrefix are the prefixes of the
+	// names of the segments of the steps and compensations of a saga.
+	sagaStepPrefix         = "Saga/Step/"
+	sagaCompensationPrefix = "Saga/Compensate/"
+)
+
+// sagaSummary contains the step counts of a SagaTransaction.
+type sagaSummary struct {
+	completed          int64
+	failed             int64
+	compensated        int64
+	compensationFailed int64
+	outcome            string
+}
+
+// SagaTransaction is a background transaction for a saga: a business workflow
+// made of steps, each of which is rolled back by a compensation if a later
+// step fails.  Each step and compensation is a segment, whose span records
+// the name of the step and whether it completed or failed:
+//
+//	saga := app.StartSagaTransaction("place-order")
+//	defer saga.End()
+//
+//	step := saga.StartStep("reserve-inventory")
+//	err := reserveInventory(ctx)
+//	step.End(err)
+//	if err != nil {
+//		return err
+//	}
+//
+//	step = saga.StartStep("charge-card")
+//	err = chargeCard(ctx)
+//	step.End(err)
+//	if err != nil {
+//		compensation := saga.StartCompensation("reserve-inventory")
+//		compensation.End(releaseInventory(ctx))
+//		return err
+//	}
+//
+// When the saga ends, its outcome is added to the transaction as the
+// AttributeSagaOutcome attribute, SagaOutcomeCompleted,
+// SagaOutcomeCompensated, or SagaOutcomeFailed, along with the step counts in
+// the AttributeSagaStepsCompleted, AttributeSagaStepsFailed,
+// AttributeSagaCompensations, and AttributeSagaCompensationsFailed
+// attributes.  Each saga is counted in the metric:
+//
+//	Saga/{transaction name}/Outcome/{outcome}
+//
+// Steps may run in multiple goroutines, using Transaction.NewGoroutine on the
+// transaction of the saga to start them.
+type SagaTransaction struct {
+	// The counts are accessed atomically, and are first to ensure their
+	// alignment on 32-bit platforms.
+	counts sagaSummary
+
+	txn *Transaction
+}
+
+// StartSagaTransaction starts a background transaction with the given name
+// for a saga.  The SagaTransaction must be ended using SagaTransaction.End,
+// rather than by ending its Transaction, for its outcome to be recorded.
+func (app *Application) StartSagaTransaction(name string, opts ...TraceOption) *SagaTransaction {
+	return &SagaTransaction{txn: app.StartTransaction(name, opts...)}
+}
+
+// Transaction returns the transaction of the saga, to be used to create
+// segments, record errors, and add attributes.  It returns nil if the
+// SagaTransaction is nil.
+func (s *SagaTransaction) Transaction() *Transaction {
+	if nil == s {
+		return nil
+	}
+	return s.txn
+}
+
+// SagaStep is a step or a compensation of a SagaTransaction.  End it with the
+// error of the step, if any.
+type SagaStep struct {
+	saga         *SagaTransaction
+	segment      *Segment
+	compensation bool
+	ended        int32
+}
+
+// StartStep starts a step of the saga, as a segment named
+// "Saga/Step/{name}".
+func (s *SagaTransaction) StartStep(name string) *SagaStep {
+	return s.startStep(sagaStepPrefix, name, false)
+}
+
+// StartCompensation starts the compensation of a step of the saga, which
+// rolls back the step, as a segment named "Saga/Compensate/{step}".
+func (s *SagaTransaction) StartCompensation(step string) *SagaStep {
+	return s.startStep(sagaCompensationPrefix, step, true)
+}
+
+func (s *SagaTransaction) startStep(prefix, name string, compensation bool) *SagaStep {
+	if nil == s {
+		return nil
+	}
+	segment := s.txn.StartSegment(prefix + name)
+	segment.AddAttribute("saga.step", name)
+	if compensation {
+		segment.AddAttribute("saga.step.type", "compensation")
+	} else {
+		segment.AddAttribute("saga.step.type", "action")
+	}
+	return &SagaStep{saga: s, segment: segment, compensation: compensation}
+}
+
+// End ends the step.  The step failed if err is not nil, in which case the
+// error message is recorded in the "saga.step.error" attribute of the span.
+// Use Transaction.NoticeError to record the error as well.  Only the first
+// call to End has an effect.
+func (st *SagaStep) End(err error) {
+	if nil == st || !atomic.CompareAndSwapInt32(&st.ended, 0, 1) {
+		return
+	}
+	var outcome string
+	counts := &st.saga.counts
+	switch {
+	case st.compensation && nil == err:
+		outcome = sagaStepCompensated
+		atomic.AddInt64(&counts.compensated, 1)
+	case st.compensation:
+		outcome = sagaStepCompensationFailed
+		atomic.AddInt64(&counts.compensationFailed, 1)
+	case nil == err:
+		outcome = sagaStepCompleted
+		atomic.AddInt64(&counts.completed, 1)
+	default:
+		outcome = sagaStepFailed
+		atomic.AddInt64(&counts.failed, 1)
+	}
+	st.segment.AddAttribute("saga.step.outcome", outcome)
+	if nil != err {
+		st.segment.AddAttribute("saga.step.error", err.Error())
+	}
+	st.segment.End()
+}
+
+// End records the outcome of the saga and ends its transaction.  Steps ended
+// after End are not counted.
+func (s *SagaTransaction) End() {
+	if nil == s || nil == s.txn || nil == s.txn.thread {
+		return
+	}
+	summary := sagaSummary{
+		completed:          atomic.LoadInt64(&s.counts.completed),
+		failed:             atomic.LoadInt64(&s.counts.failed),
+		compensated:        atomic.LoadInt64(&s.counts.compensated),
+		compensationFailed: atomic.LoadInt64(&s.counts.compensationFailed),
+	}
+	switch {
+	case 0 == summary.failed && 0 == summary.compensationFailed:
+		summary.outcome = SagaOutcomeCompleted
+	case 0 == summary.compensationFailed && summary.compensated > 0:
+		summary.outcome = SagaOutcomeCompensated
+	default:
+		summary.outcome = SagaOutcomeFailed
+	}
+	if err := s.txn.thread.setSagaSummary(summary); nil != err {
+		s.txn.thread.logAPIError(err, "end saga transaction", nil)
+		return
+	}
+	s.txn.End()
+}
+
+// setSagaSummary adds the outcome of a saga to the transaction.
+func (txn *txn) setSagaSummary(s sagaSummary) error {
+	txn.Lock()
+	defer txn.Unlock()
+	if txn.finished {
+		return errAlreadyEnded
+	}
+	txn.Attrs.Agent.Add(AttributeSagaOutcome, s.outcome, nil)
+	txn.Attrs.Agent.Add(AttributeSagaStepsCompleted, "", s.completed)
+	txn.Attrs.Agent.Add(AttributeSagaStepsFailed, "", s.failed)
+	txn.Attrs.Agent.Add(AttributeSagaCompensations, "", s.compensated+s.compensationFailed)
+	txn.Attrs.Agent.Add(AttributeSagaCompensationsFailed, "", s.compensationFailed)
+	txn.saga = &s
+	return nil
+}
+
+// mergeIntoHarvest records the metrics of the saga of a transaction.
+func (s *sagaSummary) mergeIntoHarvest(name string, metrics *metricTable) {
+	metrics.addSingleCount(sagaMetricPrefix+name+"/Outcome/"+s.outcome, unforced)
+}
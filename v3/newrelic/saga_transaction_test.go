@@ -0,0 +1,162 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package newrelic
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/newrelic/go-agent/v3/internal"
+)
+
+func TestSagaTransactionCompensated(t *testing.T) {
+	app := testApp(distributedTracingReplyFields, enableBetterCAT, t)
+	saga := app.StartSagaTransaction("place-order")
+
+	saga.StartStep("reserve").End(nil)
+	step := saga.StartStep("charge")
+	step.End(errors.New("card declined"))
+	step.End(nil)
+	saga.StartCompensation("reserve").End(nil)
+	saga.End()
+
+	app.expectNoLoggedErrors(t)
+	app.ExpectTxnEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"name":     "OtherTransaction/Go/place-order",
+			"guid":     internal.MatchAnything,
+			"priority": internal.MatchAnything,
+			"sampled":  internal.MatchAnything,
+			"traceId":  internal.MatchAnything,
+		},
+		AgentAttributes: map[string]interface{}{
+			AttributeSagaOutcome:             SagaOutcomeCompensated,
+			AttributeSagaStepsCompleted:      1,
+			AttributeSagaStepsFailed:         1,
+			AttributeSagaCompensations:       1,
+			AttributeSagaCompensationsFailed: 0,
+		},
+	}})
+	app.ExpectMetricsPresent(t, []internal.WantMetric{
+		{Name: "Saga/OtherTransaction/Go/place-order/Outcome/compensated", Scope: "", Forced: false, Data: []float64{1, 0, 0, 0, 0, 0}},
+		{Name: "Custom/Saga/Step/reserve", Scope: "OtherTransaction/Go/place-order", Forced: false, Data: nil},
+		{Name: "Custom/Saga/Step/charge", Scope: "OtherTransaction/Go/place-order", Forced: false, Data: nil},
+		{Name: "Custom/Saga/Compensate/reserve", Scope: "OtherTransaction/Go/place-order", Forced: false, Data: nil},
+	})
+	app.ExpectSpanEvents(t, []internal.WantEvent{
+		{
+			Intrinsics: map[string]interface{}{
+				"name":     "Custom/Saga/Step/reserve",
+				"category": "generic",
+				"parentId": internal.MatchAnything,
+			},
+			UserAttributes: map[string]interface{}{
+				"saga.step":         "reserve",
+				"saga.step.type":    "action",
+				"saga.step.outcome": "completed",
+			},
+			AgentAttributes: map[string]interface{}{},
+		},
+		{
+			Intrinsics: map[string]interface{}{
+				"name":     "Custom/Saga/Step/charge",
+				"category": "generic",
+				"parentId": internal.MatchAnything,
+			},
+			UserAttributes: map[string]interface{}{
+				"saga.step":         "charge",
+				"saga.step.type":    "action",
+				"saga.step.outcome": "failed",
+				"saga.step.error":   "card declined",
+			},
+			AgentAttributes: map[string]interface{}{},
+		},
+		{
+			Intrinsics: map[string]interface{}{
+				"name":     "Custom/Saga/Compensate/reserve",
+				"category": "generic",
+				"parentId": internal.MatchAnything,
+			},
+			UserAttributes: map[string]interface{}{
+				"saga.step":         "reserve",
+				"saga.step.type":    "compensation",
+				"saga.step.outcome": "compensated",
+			},
+			AgentAttributes: map[string]interface{}{},
+		},
+		{
+			Intrinsics: map[string]interface{}{
+				"name":             "OtherTransaction/Go/place-order",
+				"transaction.name": "OtherTransaction/Go/place-order",
+				"category":         "generic",
+				"nr.entryPoint":    true,
+			},
+			UserAttributes: map[string]interface{}{},
+			AgentAttributes: map[string]interface{}{
+				AttributeSagaOutcome:             SagaOutcomeCompensated,
+				AttributeSagaStepsCompleted:      1,
+				AttributeSagaStepsFailed:         1,
+				AttributeSagaCompensations:       1,
+				AttributeSagaCompensationsFailed: 0,
+			},
+		},
+	})
+}
+
+func TestSagaTransactionOutcomes(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		run     func(*SagaTransaction)
+		outcome string
+	}{
+		{
+			name:    "completed",
+			run:     func(s *SagaTransaction) { s.StartStep("a").End(nil) },
+			outcome: SagaOutcomeCompleted,
+		},
+		{
+			name:    "not-compensated",
+			run:     func(s *SagaTransaction) { s.StartStep("a").End(errors.New("failed")) },
+			outcome: SagaOutcomeFailed,
+		},
+		{
+			name: "compensation-failed",
+			run: func(s *SagaTransaction) {
+				s.StartStep("a").End(nil)
+				s.StartStep("b").End(errors.New("failed"))
+				s.StartCompensation("a").End(errors.New("failed"))
+			},
+			outcome: SagaOutcomeFailed,
+		},
+	} {
+		app := testApp(nil, ConfigDistributedTracerEnabled(false), t)
+		saga := app.StartSagaTransaction(tc.name)
+		tc.run(saga)
+		saga.End()
+		app.expectNoLoggedErrors(t)
+		app.ExpectMetricsPresent(t, []internal.WantMetric{
+			{Name: "Saga/OtherTransaction/Go/" + tc.name + "/Outcome/" + tc.outcome, Scope: "", Forced: false, Data: nil},
+		})
+	}
+}
+
+func TestSagaTransactionEndedTwice(t *testing.T) {
+	app := testApp(nil, ConfigDistributedTracerEnabled(false), t)
+	saga := app.StartSagaTransaction("place-order")
+	saga.Transaction().End()
+	saga.End()
+	app.expectSingleLoggedError(t, "unable to end saga transaction", map[string]interface{}{
+		"reason": errAlreadyEnded.Error(),
+	})
+}
+
+func TestNilSagaTransaction(t *testing.T) {
+	var saga *SagaTransaction
+	if saga.Transaction() != nil {
+		t.Error("nil saga has a transaction")
+	}
+	saga.StartStep("a").End(nil)
+	saga.StartCompensation("a").End(errors.New("failed"))
+	saga.End()
+}
@@ -5,6 +5,7 @@ package newrelic
 
 import (
 	"runtime"
+	"runtime/metrics"
 	"time"
 
 	"github.com/newrelic/go-agent/v3/internal/sysinfo"
@@ -17,6 +18,13 @@ type systemSample struct {
 	usage        sysinfo.Usage
 	numGoroutine int
 	numCPU       int
+	// cgroupLimits are the limits of the container of the process, and
+	// cgroupMemoryUsed its memory usage when it has a memory limit.
+	cgroupLimits     sysinfo.CgroupLimits
+	cgroupMemoryUsed uint64
+	// runtimeMetrics is only read when
+	// Config.RuntimeSampler.DetailedMetrics is enabled.
+	runtimeMetrics map[string]metrics.Value
 }
 
 func bytesToMebibytesFloat(bts uint64) float64 {
@@ -39,6 +47,23 @@ func getSystemSample(now time.Time, lg Logger) *systemSample {
 		})
 	}
 
+	if limits, err := sysinfo.GetCgroupLimits(); err == nil {
+		s.cgroupLimits = limits
+		if limits.MemoryBytes > 0 {
+			if used, err := sysinfo.GetCgroupMemoryUsage(); err == nil {
+				s.cgroupMemoryUsed = used
+			} else {
+				lg.Warn("unable to get cgroup memory usage", map[string]interface{}{
+					"error": err.Error(),
+				})
+			}
+		}
+	} else if err != sysinfo.ErrFeatureUnsupported && err != sysinfo.ErrCgroupLimitsNotFound {
+		lg.Warn("unable to get cgroup limits", map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+
 	runtime.ReadMemStats(&s.memStats)
 
 	return &s
@@ -61,6 +86,11 @@ type systemStats struct {
 	deltaPauseTotal time.Duration
 	minPause        time.Duration
 	maxPause        time.Duration
+	runtimeMetrics  []runtimeMetric
+	// memoryUsed and memoryLimit are the memory usage and limit of the
+	// container, or zero if it has no memory limit.
+	memoryUsed  uint64
+	memoryLimit uint64
 }
 
 // systemSamples is used as the parameter to getSystemStats to avoid mixing up the previous
@@ -82,8 +112,13 @@ func getSystemStats(ss systemSamples) systemStats {
 		heapObjects:  cur.memStats.HeapObjects,
 	}
 
-	// CPU Utilization
-	totalCPUSeconds := elapsed.Seconds() * float64(cur.numCPU)
+	// CPU Utilization is relative to the CPU quota of the container, when
+	// it is lower than the number of CPUs of the host.
+	cpus := float64(cur.numCPU)
+	if limit := cur.cgroupLimits.CPUs; limit > 0 && limit < cpus {
+		cpus = limit
+	}
+	totalCPUSeconds := elapsed.Seconds() * cpus
 	if prev.usage.User != 0 && cur.usage.User > prev.usage.User {
 		s.user.used = cur.usage.User - prev.usage.User
 		s.user.fraction = s.user.used.Seconds() / totalCPUSeconds
@@ -121,6 +156,15 @@ func getSystemStats(ss systemSamples) systemStats {
 		s.maxPause = time.Duration(maxPauseNs) * time.Nanosecond
 	}
 
+	if cur.cgroupLimits.MemoryBytes > 0 {
+		s.memoryUsed = cur.cgroupMemoryUsed
+		s.memoryLimit = cur.cgroupLimits.MemoryBytes
+	}
+
+	if nil != cur.runtimeMetrics {
+		s.runtimeMetrics = getRuntimeMetrics(prev.runtimeMetrics, cur.runtimeMetrics)
+	}
+
 	return s
 }
 
@@ -144,4 +188,12 @@ func (s systemStats) MergeIntoHarvest(h *harvest) {
 			sumSquares:      s.deltaPauseTotal.Seconds() * s.deltaPauseTotal.Seconds(),
 		}, forced)
 	}
+	if s.memoryLimit > 0 {
+		h.Metrics.addValueExclusive(containerMemoryUsed, "", bytesToMebibytesFloat(s.memoryUsed), 0, forced)
+		h.Metrics.addValueExclusive(containerMemoryLimit, "", bytesToMebibytesFloat(s.memoryLimit), 0, forced)
+		h.Metrics.addValueExclusive(containerMemoryUtilization, "", float64(s.memoryUsed)/float64(s.memoryLimit), 0, forced)
+	}
+	for _, m := range s.runtimeMetrics {
+		h.Metrics.add(m.name, "", m.data, forced)
+	}
 }
@@ -4,11 +4,14 @@
 package newrelic
 
 import (
+	"math"
+	"runtime"
 	"testing"
 	"time"
 
 	"github.com/newrelic/go-agent/v3/internal"
 	"github.com/newrelic/go-agent/v3/internal/logger"
+	"github.com/newrelic/go-agent/v3/internal/sysinfo"
 )
 
 func TestGetSample(t *testing.T) {
@@ -87,3 +90,106 @@ func TestMetricsCreatedEmpty(t *testing.T) {
 		{Name: "GC/System/Pause Fraction", Scope: "", Forced: true, Data: []float64{1, 0, 0, 0, 0, 0}},
 	})
 }
+
+func TestSystemStatsCgroupLimits(t *testing.T) {
+	now := time.Now()
+	previous := &systemSample{
+		when:   now,
+		usage:  sysinfo.Usage{User: time.Second, System: time.Second},
+		numCPU: 8,
+	}
+	current := &systemSample{
+		when:             now.Add(10 * time.Second),
+		usage:            sysinfo.Usage{User: 3 * time.Second, System: 2 * time.Second},
+		numCPU:           8,
+		cgroupLimits:     sysinfo.CgroupLimits{CPUs: 0.5, MemoryBytes: 256 * 1024 * 1024},
+		cgroupMemoryUsed: 64 * 1024 * 1024,
+	}
+	stats := getSystemStats(systemSamples{Previous: previous, Current: current})
+	if stats.user.fraction != 0.4 || stats.system.fraction != 0.2 {
+		t.Error("utilization not relative to the CPU limit:", stats.user.fraction, stats.system.fraction)
+	}
+
+	h := newHarvest(now, testHarvestCfgr)
+	stats.MergeIntoHarvest(h)
+	for name, want := range map[string]float64{
+		"Memory/Container/Used":        64,
+		"Memory/Container/Limit":       256,
+		"Memory/Container/Utilization": 0.25,
+	} {
+		metric := h.Metrics.metrics[metricID{Name: name}]
+		if nil == metric || metric.forced != forced || metric.data.totalTolerated != want {
+			t.Error(name, metric)
+		}
+	}
+}
+
+func TestGetRuntimeMetrics(t *testing.T) {
+	previous := readRuntimeMetrics()
+	runtime.GC()
+	current := readRuntimeMetrics()
+
+	found := make(map[string]bool)
+	for _, m := range getRuntimeMetrics(previous, current) {
+		found[m.name] = true
+	}
+	for _, name := range []string{
+		"Go/Runtime/GC/Pauses",
+		"Go/Runtime/GC/Pauses/p50",
+		"Go/Runtime/GC/Pauses/p99",
+		"Go/Runtime/GC/HeapGoal",
+		"Go/Runtime/GC/HeapLive",
+		"Go/Runtime/CgoCalls",
+		"Go/Runtime/Memory/Classes/total",
+		"Go/Runtime/Memory/Classes/heap/objects",
+	} {
+		if !found[name] {
+			t.Error("missing metric", name)
+		}
+	}
+}
+
+func TestRuntimeMetricsMergedIntoHarvest(t *testing.T) {
+	h := newHarvest(time.Now(), testHarvestCfgr)
+	stats := systemStats{
+		runtimeMetrics: []runtimeMetric{runtimeMetricValue("Go/Runtime/GC/HeapGoal", 4)},
+	}
+	stats.MergeIntoHarvest(h)
+	metric := h.Metrics.metrics[metricID{Name: "Go/Runtime/GC/HeapGoal"}]
+	if nil == metric || metric.forced != forced || metric.data.totalTolerated != 4 {
+		t.Error(metric)
+	}
+}
+
+func TestHistogramPercentile(t *testing.T) {
+	buckets := []float64{math.Inf(-1), 1, 2, math.Inf(1)}
+	counts := []uint64{1, 98, 1}
+	if p := histogramPercentile(counts, buckets, 100, 0.5); p != 2 {
+		t.Error(p)
+	}
+	if p := histogramPercentile(counts, buckets, 100, 0.99); p != 2 {
+		t.Error(p)
+	}
+	if p := histogramPercentile(counts, buckets, 100, 1); p != 2 {
+		// The upper boundary of the last bucket is infinite.
+		t.Error(p)
+	}
+	if m := bucketMidpoint(1, 2); m != 1.5 {
+		t.Error(m)
+	}
+	if m := bucketMidpoint(math.Inf(-1), 1); m != 1 {
+		t.Error(m)
+	}
+}
+
+func TestHistogramDeltaSum(t *testing.T) {
+	start := readTxnRuntimeMetrics()
+	runtime.GC()
+	end := readTxnRuntimeMetrics()
+	if sum := histogramDeltaSum(start.gcPauses(), end.gcPauses()); sum <= 0 {
+		t.Error("no GC pauses:", sum)
+	}
+	if sum := histogramDeltaSum(end.gcPauses(), end.gcPauses()); sum != 0 {
+		t.Error("GC pauses without a GC:", sum)
+	}
+}
@@ -0,0 +1,203 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package newrelic
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/newrelic/go-agent/v3/internal"
+)
+
+// defaultSecondaryData is the data sent to a SecondaryDestination whose Data
+// is zero.
+const defaultSecondaryData = SecondaryMetrics | SecondaryErrors
+
+// secondaryData returns the selection of data which includes the payloads of
+// the collector command.
+func secondaryData(cmd string) SecondaryData {
+	switch cmd {
+	case cmdMetrics:
+		return SecondaryMetrics
+	case cmdErrorData, cmdErrorEvents:
+		return SecondaryErrors
+	case cmdTxnEvents:
+		return SecondaryTransactionEvents
+	case cmdCustomEvents:
+		return SecondaryCustomEvents
+	case cmdLogEvents:
+		return SecondaryLogEvents
+	case cmdSpanEvents:
+		return SecondarySpanEvents
+	case cmdTxnTraces, cmdSlowSQLs:
+		return SecondaryTraces
+	}
+	return 0
+}
+
+// secondaryDestination is the connection of the application to a
+// SecondaryDestination.  It connects and sends its data independently of the
+// primary account.
+type secondaryDestination struct {
+	data        SecondaryData
+	config      config
+	rpmControls rpmControls
+	// host identifies the destination in the logs.
+	host string
+
+	// reply is the reply of the connection, or nil while the destination
+	// is not connected.  It is protected by the mutex.
+	sync.Mutex
+	reply *internal.ConnectReply
+
+	// sending is 1 while a harvest is sent to the destination.  It must be
+	// accessed atomically.
+	sending int32
+}
+
+// newSecondaryDestinations returns the connections to the
+// SecondaryDestinations of the application.
+func newSecondaryDestinations(app *app) []*secondaryDestination {
+	var dests []*secondaryDestination
+	for _, dest := range app.config.SecondaryDestinations {
+		c := app.config
+		c.License = dest.License
+		c.Host = dest.Host
+		c.SecurityPoliciesToken = ""
+		d := &secondaryDestination{
+			data:        dest.Data,
+			config:      c,
+			rpmControls: newRPMControls(c),
+			host:        c.preconnectHost(),
+		}
+		if d.data == 0 {
+			d.data = defaultSecondaryData
+		}
+		d.rpmControls.Logger = app.Logger
+		dests = append(dests, d)
+	}
+	return dests
+}
+
+func (d *secondaryDestination) getReply() *internal.ConnectReply {
+	d.Lock()
+	defer d.Unlock()
+	return d.reply
+}
+
+func (d *secondaryDestination) setReply(reply *internal.ConnectReply) {
+	d.Lock()
+	defer d.Unlock()
+	d.reply = reply
+}
+
+// connectRoutine connects the destination, retrying with the backoff of the
+// primary connection, until it succeeds, the destination refuses the
+// application, or the application shuts down.
+func (d *secondaryDestination) connectRoutine(app *app) {
+	for attempts := 0; ; attempts++ {
+		reply, resp := connectAttempt(d.config, d.rpmControls)
+		if reply != nil {
+			d.setReply(reply)
+			app.Info("secondary destination connected", map[string]interface{}{
+				"destination": d.host,
+				"run":         reply.RunID.String(),
+			})
+			return
+		}
+		if resp.IsDisconnect() {
+			app.Error("secondary destination disconnected", map[string]interface{}{
+				"destination": d.host,
+			})
+			return
+		}
+		if nil != resp.GetError() {
+			app.Warn("secondary destination connect failure", map[string]interface{}{
+				"destination": d.host,
+				"error":       resp.GetError().Error(),
+			})
+		}
+		select {
+		case <-time.After(time.Duration(getConnectBackoffTime(attempts)) * time.Second):
+		case <-app.shutdownStarted:
+			return
+		}
+	}
+}
+
+// harvestSecondaryDestinations sends the payloads selected by each connected
+// secondary destination, in a goroutine per destination so that a slow
+// destination does not delay the harvest.  A destination is skipped while
+// the previous harvest sent to it is in progress.
+func (app *app) harvestSecondaryDestinations(payloads []payloadCreator, harvestStart time.Time) {
+	for _, d := range app.secondaryDestinations {
+		reply := d.getReply()
+		if nil == reply {
+			continue
+		}
+		if !atomic.CompareAndSwapInt32(&d.sending, 0, 1) {
+			app.Warn("secondary destination harvest skipped", map[string]interface{}{
+				"destination": d.host,
+				"reason":      "previous harvest in progress",
+			})
+			continue
+		}
+		var calls []rpmCmd
+		for _, p := range payloads {
+			cmd := p.EndpointMethod()
+			if d.data&secondaryData(cmd) == 0 {
+				continue
+			}
+			data, err := p.Data(reply.RunID.String(), harvestStart)
+			if err != nil || data == nil {
+				continue
+			}
+			calls = append(calls, rpmCmd{
+				Collector:         reply.Collector,
+				RunID:             reply.RunID.String(),
+				Name:              cmd,
+				Data:              data,
+				RequestHeadersMap: reply.RequestHeadersMap,
+				MaxPayloadSize:    reply.MaxPayloadSizeInBytes,
+			})
+		}
+		app.secondaryHarvests.Add(1)
+		go func(d *secondaryDestination) {
+			defer app.secondaryHarvests.Done()
+			defer atomic.StoreInt32(&d.sending, 0)
+			d.send(app, calls)
+		}(d)
+	}
+}
+
+// send sends the payloads of a harvest to the destination.  The payloads
+// which are not accepted are dropped.
+func (d *secondaryDestination) send(app *app, calls []rpmCmd) {
+	for _, call := range calls {
+		resp := collectorRequest(call, d.rpmControls)
+		if resp.IsDisconnect() {
+			d.setReply(nil)
+			app.Error("secondary destination disconnected", map[string]interface{}{
+				"destination": d.host,
+			})
+			return
+		}
+		if resp.IsRestartException() {
+			d.setReply(nil)
+			app.Info("secondary destination restarted", map[string]interface{}{
+				"destination": d.host,
+			})
+			go d.connectRoutine(app)
+			return
+		}
+		if resp.GetError() != nil {
+			app.Warn("secondary destination harvest failure", map[string]interface{}{
+				"destination": d.host,
+				"cmd":         call.Name,
+				"error":       resp.GetError().Error(),
+			})
+		}
+	}
+}
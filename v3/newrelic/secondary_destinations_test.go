@@ -0,0 +1,127 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package newrelic
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/newrelic/go-agent/v3/internal"
+)
+
+func TestHarvestSecondaryDestinations(t *testing.T) {
+	app := testApp(nil, func(cfg *Config) {
+		cfg.SecondaryDestinations = []SecondaryDestination{
+			{License: "eu01xx0123456789012345678901234567890123"},
+			{License: "0123456789012345678901234567890123456789", Host: "collector.example.com", Data: SecondaryTransactionEvents},
+		}
+	}, t)
+	primary := &spoolCollector{statusCode: 200}
+	// The test application is disabled, and so has no collector client.
+	app.app.rpmControls = newRPMControls(app.app.config)
+	app.app.rpmControls.Client = &http.Client{Transport: primary}
+	dests := newSecondaryDestinations(app.app)
+	if len(dests) != 2 || dests[0].host != "collector.eu01.nr-data.net" || dests[1].host != "collector.example.com" {
+		t.Fatal("incorrect destinations:", dests)
+	}
+	collectors := []*spoolCollector{{statusCode: 503}, {statusCode: 200}}
+	for i, d := range dests {
+		d.rpmControls.Client = &http.Client{Transport: collectors[i]}
+		reply := internal.ConnectReplyDefaults()
+		reply.RunID = internal.AgentRunID("secondary" + string(rune('1'+i)))
+		d.setReply(reply)
+	}
+	app.app.secondaryDestinations = dests
+
+	reply := internal.ConnectReplyDefaults()
+	reply.RunID = "primary"
+	run := newAppRun(app.app.config, reply)
+	harvest := func() {
+		now := time.Now()
+		h := newHarvest(now, testHarvestCfgr)
+		h.Metrics.addCount("Custom/count", 1, forced)
+		h.ErrorTraces = append(h.ErrorTraces, &tracedError{
+			txnEvent:  txnEvent{FinalName: "WebTransaction/Go/hello"},
+			errorData: errorData{Msg: "oops", Klass: "error"},
+		})
+		h.TxnEvents.AddTxnEvent(&txnEvent{FinalName: "WebTransaction/Go/hello"}, 1)
+		app.app.doHarvest(h, now, run)
+		app.app.secondaryHarvests.Wait()
+	}
+	methods := func(requests []string) string {
+		var ms []string
+		for _, r := range requests {
+			ms = append(ms, strings.Join(strings.Fields(r)[:2], " "))
+		}
+		return strings.Join(ms, ",")
+	}
+
+	// The failure of the first destination affects neither the primary
+	// account nor the second destination.
+	harvest()
+	if got := methods(primary.respond(200)); got != "metric_data primary,error_data primary,analytic_event_data primary" {
+		t.Error("incorrect primary requests:", got)
+	}
+	if got := methods(collectors[0].respond(410)); got != "metric_data secondary1,error_data secondary1" {
+		t.Error("incorrect first destination requests:", got)
+	}
+	if got := methods(collectors[1].respond(200)); got != "analytic_event_data secondary2" {
+		t.Error("incorrect second destination requests:", got)
+	}
+	if nil == dests[0].getReply() {
+		t.Error("destination disconnected after failure")
+	}
+
+	// A disconnected destination is no longer sent data.
+	harvest()
+	if got := methods(collectors[0].respond(200)); got != "metric_data secondary1" {
+		t.Error("incorrect first destination requests:", got)
+	}
+	if nil != dests[0].getReply() {
+		t.Error("destination not disconnected")
+	}
+	harvest()
+	if requests := collectors[0].respond(200); len(requests) != 0 {
+		t.Error("data sent to disconnected destination:", requests)
+	}
+	if requests := primary.respond(200); len(requests) != 6 {
+		t.Error("incorrect primary requests:", methods(requests))
+	}
+}
+
+func TestSecondaryDestinationsConfig(t *testing.T) {
+	cfg := defaultConfig()
+	cfg.License = "0123456789012345678901234567890123456789"
+	cfg.AppName = "app"
+	ConfigSecondaryDestination("short", SecondaryMetrics)(&cfg)
+	if err := cfg.validate(); err != errSecondaryLicenseLen {
+		t.Error("incorrect error for invalid license:", err)
+	}
+
+	cfg.SecondaryDestinations[0].License = "eu01xx0123456789012345678901234567890123"
+	js, err := json.Marshal(settings(cfg))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(js), "eu01xx") || !strings.Contains(string(js), `"SecondaryDestinations":[{"Data":1,"Host":""}]`) {
+		t.Error("incorrect settings:", string(js))
+	}
+	redacted, err := configJSON(cfg, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(redacted), "eu01xx") || strings.Count(string(redacted), `"License": "[redacted]"`) != 2 {
+		t.Error("secondary license not redacted:", string(redacted))
+	}
+	js, err = configJSON(cfg, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(js), `"License": "eu01xx0123456789012345678901234567890123"`) {
+		t.Error("secondary license missing:", string(js))
+	}
+}
@@ -0,0 +1,34 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !newrelic_nosecurity && !newrelic_minimal
+// +build !newrelic_nosecurity,!newrelic_minimal
+
+package newrelic
+
+// secureAgent is a global interface point for the nrsecureagent's hooks into the go agent.
+// The default value for this is a noOpSecurityAgent value, which has null definitions for
+// the methods. The Go compiler is expected to optimize away all the securityAgent method
+// calls in this case, effectively removing the hooks from the running agent.
+//
+// If the nrsecureagent integration was initialized, it will register a real securityAgent
+// value in the securityAgent variable instead, thus "activating" the hooks.
+var secureAgent securityAgent = noOpSecurityAgent{}
+
+func (app *Application) RegisterSecurityAgent(s securityAgent) {
+	if app != nil && app.app != nil && s != nil {
+		secureAgent = s
+		run, _ := app.app.getState()
+		if run.Reply.IsConnectedToNewRelic() {
+			secureAgent.RefreshState(getLinkedMetaData(app.app))
+		}
+	}
+}
+
+// IsSecurityAgentPresent returns true if there's an actual security agent hooked in to the
+// Go APM agent, whether or not it's enabled or operating in any particular mode. It returns
+// false only if the hook-in interface for those functions is a No-Op will null functionality.
+func IsSecurityAgentPresent() bool {
+	_, isNoOp := secureAgent.(noOpSecurityAgent)
+	return !isNoOp
+}
@@ -0,0 +1,32 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build newrelic_nosecurity || newrelic_minimal
+// +build newrelic_nosecurity newrelic_minimal
+
+package newrelic
+
+// secureAgent is always the no-op security agent when the security agent
+// hooks are excluded from the build by the newrelic_nosecurity or the
+// newrelic_minimal build tag.  Its type is not the securityAgent interface,
+// so that the calls of its methods are inlined and, since
+// IsSecurityAgentPresent always returns false, the hooks are removed by the
+// compiler.
+var secureAgent noOpSecurityAgent
+
+// RegisterSecurityAgent does nothing when the security agent hooks are
+// excluded from the build, except logging that the security agent is not
+// used.
+func (app *Application) RegisterSecurityAgent(s securityAgent) {
+	if app != nil && app.app != nil && s != nil {
+		app.app.Warn("security agent not registered", map[string]interface{}{
+			"reason": "security agent hooks excluded from the build",
+		})
+	}
+}
+
+// IsSecurityAgentPresent returns false when the security agent hooks are
+// excluded from the build.
+func IsSecurityAgentPresent() bool {
+	return false
+}
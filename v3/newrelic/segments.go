@@ -4,7 +4,10 @@
 package newrelic
 
 import (
+	"context"
 	"net/http"
+	"sync/atomic"
+	"time"
 )
 
 // SegmentStartTime is created by Transaction.StartSegmentNow and marks the
@@ -20,6 +23,69 @@ type SegmentStartTime struct {
 type Segment struct {
 	StartTime SegmentStartTime
 	Name      string
+
+	// sampleRate is set by SegmentSampler.Start to the number of instances
+	// of the segment represented by this one.
+	sampleRate int
+
+	// ctx is set by StartSegmentWithContext.
+	ctx context.Context
+}
+
+// SegmentSampler is used to instrument extremely hot code paths where
+// recording every segment would be too costly.  Create one SegmentSampler for
+// each instrumented code path, usually as a package variable, since it counts
+// the calls made from every transaction and goroutine.  Only the first of
+// every Rate calls to Start starts a segment; the other calls return nil,
+// which is safe to End, without locking the transaction.  The recorded
+// segment's duration is scaled by Rate in the segment's metric so that the
+// metric's call count and total time approximate those of the unsampled code
+// path.  Transaction traces and span events only contain the recorded
+// segments, and the time spent in instances which are not recorded is
+// included in the exclusive time of the enclosing segment.
+//
+//	var processItemSampler = newrelic.NewSegmentSampler("processItem", 100)
+//
+//	for _, item := range items {
+//		s := processItemSampler.Start(txn)
+//		processItem(item)
+//		s.End()
+//	}
+//
+// Since the calls are counted across transactions, the scoped metrics of a
+// single transaction are approximate, while the unscoped metrics of the code
+// path are accurate over many transactions.
+type SegmentSampler struct {
+	name  string
+	rate  uint64
+	calls uint64
+}
+
+// NewSegmentSampler creates a SegmentSampler recording one in every rate
+// calls as a segment with the given name.  If rate is less than or equal to 1,
+// every call is recorded, as with Transaction.StartSegment.
+func NewSegmentSampler(name string, rate int) *SegmentSampler {
+	if rate < 1 {
+		rate = 1
+	}
+	return &SegmentSampler{name: name, rate: uint64(rate)}
+}
+
+// Start starts a segment in the transaction if this call is sampled, and
+// returns nil otherwise.  Start is safe to call on a nil SegmentSampler or
+// with a nil Transaction, and safe for concurrent use.
+func (ss *SegmentSampler) Start(txn *Transaction) *Segment {
+	if nil == ss || nil == txn || nil == txn.thread {
+		return nil
+	}
+	if ss.rate > 1 && (atomic.AddUint64(&ss.calls, 1)-1)%ss.rate != 0 {
+		return nil
+	}
+	s := txn.StartSegment(ss.name)
+	if ss.rate > 1 {
+		s.sampleRate = int(ss.rate)
+	}
+	return s
 }
 
 // DatastoreSegment is used to instrument calls to databases and object stores.
@@ -71,6 +137,16 @@ type DatastoreSegment struct {
 	// and Transaction Trace segments.
 	DatabaseName string
 
+	// ExplainPlan may be set to a function which returns the query plan of
+	// ParameterizedQuery, as the text output of an EXPLAIN statement.  If
+	// DatastoreTracer.ExplainPlan is enabled and the segment is slower than
+	// DatastoreTracer.ExplainPlan.Threshold, it is called in a separate
+	// goroutine once the segment has ended, and so must not use resources
+	// which the caller may release, such as the connection of the query.
+	// The plans are captured one at a time, and a limited number of plans
+	// are captured per transaction and per minute.
+	ExplainPlan func() (string, error)
+
 	// secureAgentEvent is used when vulnerability scanning is enabled to
 	// record security-related information about the datastore operations.
 	secureAgentEvent any
@@ -117,11 +193,22 @@ type ExternalSegment struct {
 	// external metrics and the "component" span attribute.  It should be
 	// the framework making the external call.
 	Library string
+	// SuppressOutboundHeaders prevents distributed tracing and cross
+	// application tracing headers from being returned by
+	// GetOutboundHeaders.  It is set by StartExternalSegment when the
+	// request's context was created using WithoutOutboundHeaders.  The
+	// headers are also not returned when the request's host is listed in
+	// DistributedTracer.ExcludedHosts, whether or not this field is set.
+	SuppressOutboundHeaders bool
 
 	// statusCode is the status code for the response.  This value takes
 	// precedence over the status code set on the Response.
 	statusCode *int
 
+	// timings records the phases of the request when it is traced using
+	// ClientTrace.
+	timings *externalTimings
+
 	// secureAgentEvent records security information when vulnerability
 	// scanning is enabled.
 	secureAgentEvent any
@@ -144,6 +231,49 @@ type MessageProducerSegment struct {
 	// DestinationTemporary must be set to true if destination is temporary
 	// to improve metric grouping.
 	DestinationTemporary bool
+
+	// Payload is the message produced.  If set, the key, the headers, and
+	// a snippet of the body of the message are recorded on the span when
+	// Config.MessagePayloads is enabled.
+	Payload *MessagePayload
+}
+
+// MessageConsumerSegment instruments the consumption of messages within an
+// existing transaction, for example by a poll loop which handles many messages
+// in a single transaction.  To instead create a transaction for each message
+// consumed, name the transaction after the destination as described with
+// AttributeMessageQueueName.
+type MessageConsumerSegment struct {
+	StartTime SegmentStartTime
+
+	// Library is the name of the library instrumented.  eg. "Kafka",
+	// "RabbitMQ"
+	Library string
+
+	// DestinationType is the destination type.
+	DestinationType MessageDestinationType
+
+	// DestinationName is the name of your queue or topic.  eg. "UsersQueue".
+	DestinationName string
+
+	// DestinationTemporary must be set to true if destination is temporary
+	// to improve metric grouping.
+	DestinationTemporary bool
+
+	// MessageTimestamp is the time the message was added to the
+	// destination, or the time of the oldest message when several
+	// messages are consumed.  If set, the time between it and the start of
+	// the segment is recorded as the queue time of the messages.
+	MessageTimestamp time.Time
+
+	// MessageCount is the number of messages consumed.  It is recorded if
+	// greater than zero.
+	MessageCount int
+
+	// Payload is the message consumed.  If set, the key, the headers, and
+	// a snippet of the body of the message are recorded on the span when
+	// Config.MessagePayloads is enabled.
+	Payload *MessagePayload
 }
 
 // MessageDestinationType is used for the MessageSegment.DestinationType field.
@@ -159,6 +289,9 @@ const (
 
 // AddAttribute adds a key value pair to the current segment.
 //
+// The attribute is added to the span event of the segment, and to its
+// transaction trace segment subject to TransactionTracer.Segments.Attributes.
+//
 // The key must contain fewer than than 255 bytes.  The value must be a
 // number, string, or boolean.
 func (s *Segment) AddAttribute(key string, val interface{}) {
@@ -186,8 +319,40 @@ func (s *Segment) End() {
 	}
 }
 
+// Pause stops counting the time of the segment as its exclusive time, for
+// example while waiting on a semaphore whose wait time is accounted for
+// separately, until Resume is called.  The time the segment is paused is still
+// part of its duration.  A segment ended while paused is paused until its end.
+// Pausing a paused segment has no effect.
+func (s *Segment) Pause() {
+	if s == nil {
+		return
+	}
+	if err := pauseBasic(s, (*tracingThread).pauseSegment); err != nil {
+		s.StartTime.thread.logAPIError(err, "pause segment", map[string]interface{}{
+			"name": s.Name,
+		})
+	}
+}
+
+// Resume resumes counting the time of a segment paused with Pause as its
+// exclusive time.  Resuming a segment which is not paused has no effect.
+func (s *Segment) Resume() {
+	if s == nil {
+		return
+	}
+	if err := pauseBasic(s, (*tracingThread).resumeSegment); err != nil {
+		s.StartTime.thread.logAPIError(err, "resume segment", map[string]interface{}{
+			"name": s.Name,
+		})
+	}
+}
+
 // AddAttribute adds a key value pair to the current DatastoreSegment.
 //
+// The attribute is added to the span event of the segment, and to its
+// transaction trace segment subject to TransactionTracer.Segments.Attributes.
+//
 // The key must contain fewer than than 255 bytes.  The value must be a
 // number, string, or boolean.
 func (s *DatastoreSegment) AddAttribute(key string, val interface{}) {
@@ -213,6 +378,9 @@ func (s *DatastoreSegment) End() {
 
 // AddAttribute adds a key value pair to the current ExternalSegment.
 //
+// The attribute is added to the span event of the segment, and to its
+// transaction trace segment subject to TransactionTracer.Segments.Attributes.
+//
 // The key must contain fewer than than 255 bytes.  The value must be a
 // number, string, or boolean.
 func (s *ExternalSegment) AddAttribute(key string, val interface{}) {
@@ -268,6 +436,30 @@ func (s *MessageProducerSegment) End() {
 	}
 }
 
+// AddAttribute adds a key value pair to the current MessageConsumerSegment.
+//
+// The key must contain fewer than than 255 bytes.  The value must be a
+// number, string, or boolean.
+func (s *MessageConsumerSegment) AddAttribute(key string, val interface{}) {
+	if nil == s {
+		return
+	}
+	addSpanAttr(s.StartTime, key, val)
+}
+
+// End finishes the message consumer segment.
+func (s *MessageConsumerSegment) End() {
+	if nil == s {
+		return
+	}
+	if err := endMessageConsumer(s); err != nil {
+		s.StartTime.thread.logAPIError(err, "end message consumer segment", map[string]interface{}{
+			"library":          s.Library,
+			"destination-name": s.DestinationName,
+		})
+	}
+}
+
 // SetStatusCode sets the status code for the response of this ExternalSegment.
 // This status code will be included as an attribute on Span Events.  If status
 // code is not set using this method, then the status code found on the
@@ -334,8 +526,9 @@ func StartExternalSegment(txn *Transaction, request *http.Request) *ExternalSegm
 		txn = transactionFromRequestContext(request)
 	}
 	s := &ExternalSegment{
-		StartTime: txn.StartSegmentNow(),
-		Request:   request,
+		StartTime:               txn.StartSegmentNow(),
+		Request:                 request,
+		SuppressOutboundHeaders: outboundHeadersSuppressed(request),
 	}
 	if IsSecurityAgentPresent() {
 		s.secureAgentEvent = secureAgent.SendEvent("OUTBOUND", request)
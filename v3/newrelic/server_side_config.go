@@ -0,0 +1,79 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package newrelic
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+
+	"github.com/newrelic/go-agent/v3/internal"
+)
+
+// ServerSideConfigUpdate is passed to Config.ServerSideConfigCallback when
+// the server-side configuration changes.
+type ServerSideConfigUpdate struct {
+	// Settings are the server-side settings in effect, by name, such as
+	// "transaction_tracer.enabled".  They override the local settings.
+	Settings map[string]interface{}
+	// Changed are the names of the settings whose value differs from the
+	// previous connection, sorted.  They include the settings which are
+	// no longer set on the server, and so revert to the local settings.
+	Changed []string
+}
+
+// serverSideSettings returns the server-side settings of the connect reply,
+// by name.
+func serverSideSettings(reply *internal.ConnectReply) map[string]interface{} {
+	js, err := json.Marshal(reply.ServerSideConfig)
+	if err != nil {
+		return nil
+	}
+	var all map[string]interface{}
+	if err := json.Unmarshal(js, &all); err != nil {
+		return nil
+	}
+	settings := make(map[string]interface{}, len(all))
+	for name, value := range all {
+		if nil != value {
+			settings[name] = value
+		}
+	}
+	return settings
+}
+
+// changedSettings returns the names of the settings which differ.
+func changedSettings(previous, current map[string]interface{}) []string {
+	var changed []string
+	for name, value := range current {
+		if old, ok := previous[name]; !ok || !reflect.DeepEqual(old, value) {
+			changed = append(changed, name)
+		}
+	}
+	for name := range previous {
+		if _, ok := current[name]; !ok {
+			changed = append(changed, name)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// notifyServerSideConfig logs the changes of the server-side configuration
+// of a new connection, and calls the ServerSideConfigCallback.  It is called
+// by the goroutine which harvests the data.
+func (app *app) notifyServerSideConfig(run *appRun) {
+	settings := serverSideSettings(run.Reply)
+	changed := changedSettings(app.serverSideSettings, settings)
+	app.serverSideSettings = settings
+	if len(changed) == 0 {
+		return
+	}
+	app.Info("server-side configuration changed", map[string]interface{}{
+		"changed": changed,
+	})
+	if cb := run.Config.ServerSideConfigCallback; nil != cb {
+		cb(ServerSideConfigUpdate{Settings: settings, Changed: changed})
+	}
+}
@@ -0,0 +1,50 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package newrelic
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/newrelic/go-agent/v3/internal"
+)
+
+func TestNotifyServerSideConfig(t *testing.T) {
+	var updates []ServerSideConfigUpdate
+	app := testApp(nil, ConfigServerSideConfigCallback(func(u ServerSideConfigUpdate) {
+		updates = append(updates, u)
+	}), t)
+	connect := func(agentConfig string) {
+		reply := internal.ConnectReplyDefaults()
+		if err := json.Unmarshal([]byte(`{"agent_config":`+agentConfig+`}`), reply); err != nil {
+			t.Fatal(err)
+		}
+		app.app.notifyServerSideConfig(newAppRun(app.app.config, reply))
+	}
+
+	connect(`{}`)
+	if len(updates) != 0 {
+		t.Fatal("callback called without server-side config:", updates)
+	}
+	connect(`{"transaction_tracer.enabled": false, "attributes.exclude": ["secret"]}`)
+	connect(`{"transaction_tracer.enabled": false, "attributes.exclude": ["secret"]}`)
+	connect(`{"attributes.exclude": ["secret", "token"]}`)
+	if len(updates) != 2 {
+		t.Fatal("incorrect number of updates:", updates)
+	}
+	if want := []string{"attributes.exclude", "transaction_tracer.enabled"}; !reflect.DeepEqual(updates[0].Changed, want) {
+		t.Error("incorrect changes:", updates[0].Changed)
+	}
+	if updates[0].Settings["transaction_tracer.enabled"] != false {
+		t.Error("incorrect settings:", updates[0].Settings)
+	}
+	if want := []string{"attributes.exclude", "transaction_tracer.enabled"}; !reflect.DeepEqual(updates[1].Changed, want) {
+		t.Error("incorrect changes:", updates[1].Changed)
+	}
+	if _, ok := updates[1].Settings["transaction_tracer.enabled"]; ok || len(updates[1].Settings) != 1 {
+		t.Error("incorrect settings:", updates[1].Settings)
+	}
+	app.expectNoLoggedErrors(t)
+}
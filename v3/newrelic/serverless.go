@@ -24,8 +24,8 @@ const (
 // serverlessHarvest is used to store and log data when the agent is running in
 // serverless mode.
 type serverlessHarvest struct {
-	logger          Logger
-	awsExecutionEnv string
+	logger       Logger
+	executionEnv string
 
 	// The Lambda handler could be using multiple goroutines so we use a
 	// mutex to prevent race conditions.
@@ -36,8 +36,8 @@ type serverlessHarvest struct {
 // newServerlessHarvest creates a new serverlessHarvest.
 func newServerlessHarvest(logger Logger, getEnv func(string) string) *serverlessHarvest {
 	return &serverlessHarvest{
-		logger:          logger,
-		awsExecutionEnv: getEnv("AWS_EXECUTION_ENV"),
+		logger:       logger,
+		executionEnv: serverlessExecutionEnv(getEnv),
 
 		// We can use dfltHarvestCfgr because
 		// serverless mode doesn't have a connect, and therefore won't
@@ -46,6 +46,24 @@ func newServerlessHarvest(logger Logger, getEnv func(string) string) *serverless
 	}
 }
 
+// serverlessExecutionEnv returns the name of the serverless platform the
+// agent is running on, detected using the environment variables set by the
+// platform.
+func serverlessExecutionEnv(getEnv func(string) string) string {
+	if env := getEnv("AWS_EXECUTION_ENV"); "" != env {
+		return env
+	}
+	switch {
+	case "" != getEnv("FUNCTION_TARGET"):
+		return "gcp.cloud_functions"
+	case "" != getEnv("CLOUD_RUN_JOB"):
+		return "gcp.cloud_run_job"
+	case "" != getEnv("K_SERVICE"):
+		return "gcp.cloud_run"
+	}
+	return ""
+}
+
 // Consume adds data to the harvest.
 func (sh *serverlessHarvest) Consume(data harvestable) {
 	if nil == sh {
@@ -143,7 +161,7 @@ func (sh *serverlessHarvest) Write(arn string, writer io.Writer) {
 			MetadataVersion:      lambdaMetadataVersion,
 			ProtocolVersion:      procotolVersion,
 			AgentVersion:         Version,
-			ExecutionEnvironment: sh.awsExecutionEnv,
+			ExecutionEnvironment: sh.executionEnv,
 			ARN:                  arn,
 			AgentLanguage:        agentLanguage,
 		},
@@ -111,3 +111,21 @@ func BenchmarkServerless(b *testing.B) {
 		}
 	}
 }
+
+func TestServerlessExecutionEnv(t *testing.T) {
+	for _, tc := range []struct {
+		env  map[string]string
+		want string
+	}{
+		{env: map[string]string{}, want: ""},
+		{env: map[string]string{"AWS_EXECUTION_ENV": "AWS_Lambda_go1.x"}, want: "AWS_Lambda_go1.x"},
+		{env: map[string]string{"FUNCTION_TARGET": "HelloHTTP", "K_SERVICE": "hello"}, want: "gcp.cloud_functions"},
+		{env: map[string]string{"CLOUD_RUN_JOB": "export"}, want: "gcp.cloud_run_job"},
+		{env: map[string]string{"K_SERVICE": "hello"}, want: "gcp.cloud_run"},
+	} {
+		getEnv := func(key string) string { return tc.env[key] }
+		if got := serverlessExecutionEnv(getEnv); got != tc.want {
+			t.Errorf("%v: got %q, want %q", tc.env, got, tc.want)
+		}
+	}
+}
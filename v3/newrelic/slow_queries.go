@@ -7,6 +7,7 @@ import (
 	"bytes"
 	"container/heap"
 	"hash/fnv"
+	"strings"
 	"time"
 
 	"github.com/newrelic/go-agent/v3/internal/jsonx"
@@ -42,6 +43,23 @@ func (q queryParameters) WriteJSON(buf *bytes.Buffer) {
 	buf.WriteByte('}')
 }
 
+// explainPlan is the text output of an EXPLAIN statement.  It is written as
+// the column headers followed by the rows, with one row per line of the plan.
+type explainPlan string
+
+func (p explainPlan) WriteJSON(buf *bytes.Buffer) {
+	buf.WriteString(`[["QUERY PLAN"],[`)
+	for i, line := range strings.Split(strings.TrimRight(string(p), "\n"), "\n") {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteByte('[')
+		jsonx.AppendString(buf, line)
+		buf.WriteByte(']')
+	}
+	buf.WriteString(`]]`)
+}
+
 // https://source.datanerd.us/agents/agent-specs/blob/master/Slow-SQLs-LEGACY.md
 
 // slowQueryInstance represents a single datastore call.
@@ -56,6 +74,7 @@ type slowQueryInstance struct {
 	PortPathOrID       string
 	DatabaseName       string
 	StackTrace         stackTrace
+	ExplainPlan        *queryPlan
 
 	txnEvent
 }
@@ -220,6 +239,9 @@ func (slow *slowQuery) WriteJSON(buf *bytes.Buffer) {
 	if nil != slow.QueryParameters {
 		w.writerField("query_parameters", slow.QueryParameters)
 	}
+	if plan := slow.ExplainPlan.get(); "" != plan {
+		w.writerField("explain_plan", plan)
+	}
 
 	sharedBetterCATIntrinsics(&slow.txnEvent, &w)
 
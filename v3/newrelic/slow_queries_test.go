@@ -289,3 +289,51 @@ func TestSlowQueriesBetterCAT(t *testing.T) {
 		t.Error(string(js), expect)
 	}
 }
+
+func TestSlowQueriesExplainPlan(t *testing.T) {
+	acfg := createAttributeConfig(config{Config: defaultConfig()}, true)
+	txnEvent := txnEvent{
+		FinalName: "OtherTransaction/Go/hello",
+		Duration:  3 * time.Second,
+		Attrs:     newAttributes(acfg),
+	}
+
+	txnSlows := newSlowQueries(maxTxnSlowQueries)
+	txnSlows.observeInstance(slowQueryInstance{
+		Duration:           2 * time.Second,
+		DatastoreMetric:    "Datastore/statement/Postgres/users/SELECT",
+		ParameterizedQuery: "SELECT * FROM users WHERE name = $1",
+		ExplainPlan:        &queryPlan{plan: "Seq Scan on users  (cost=0.00..1.01 rows=1 width=36)\n  Filter: (name = '?'::text)\n"},
+	})
+	harvestSlows := newSlowQueries(maxHarvestSlowSQLs)
+	harvestSlows.Merge(txnSlows, txnEvent)
+	js, err := harvestSlows.Data("agentRunID", time.Now())
+	expect := compactJSONString(`[[
+	[
+		"OtherTransaction/Go/hello",
+		"",
+		1050616125,
+		"SELECT * FROM users WHERE name = $1",
+		"Datastore/statement/Postgres/users/SELECT",
+		1,
+		2000,
+		2000,
+		2000,
+		{
+			"explain_plan":[
+				["QUERY PLAN"],
+				[
+					["Seq Scan on users  (cost=0.00..1.01 rows=1 width=36)"],
+					["  Filter: (name = '?'::text)"]
+				]
+			]
+		}
+	]
+]]`)
+	if nil != err {
+		t.Error(err)
+	}
+	if string(js) != expect {
+		t.Error(string(js), expect)
+	}
+}
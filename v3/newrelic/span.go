@@ -0,0 +1,163 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package newrelic
+
+import (
+	"reflect"
+	"sync"
+	"time"
+)
+
+// Span records a lightweight background operation which does not merit a
+// transaction, such as a cache refresh or a periodic cleanup.  It is reported
+// as a span event belonging to the application's entity, and is the root of
+// its own trace.  No transaction event, metrics, or transaction trace are
+// created for it.  Create a Span using Application.StartSpan, and call End
+// when the operation is complete.
+//
+// Spans are only recorded when distributed tracing and span events are
+// enabled.  They are added to the same span event reservoir as the span events
+// of transactions, and are sent with the next harvest.  Since they are not
+// sampled by the adaptive sampler, they have a lower priority than the span
+// events of sampled transactions, which are kept first when the reservoir is
+// full.
+//
+// All methods of Span are safe to call on a nil Span and from multiple
+// goroutines.
+type Span struct {
+	sync.Mutex
+	app   *app
+	run   *appRun
+	event *spanEvent
+	attrs *attributes
+	ended bool
+}
+
+// StartSpan starts a Span with the given name for a background operation which
+// does not merit a transaction.  Span.End must be called to record the span.
+func (app *Application) StartSpan(name string) *Span {
+	if app == nil || app.app == nil {
+		return nil
+	}
+	return app.app.startSpan(name, time.Now())
+}
+
+func (app *app) startSpan(name string, start time.Time) *Span {
+	run, _ := app.getState()
+	s := &Span{app: app, run: run}
+	if !run.Config.DistributedTracer.Enabled || !run.Config.SpanEvents.Enabled {
+		return s
+	}
+	gen := run.Reply.TraceIDGenerator
+	s.attrs = newAttributes(run.AttributeConfig)
+	s.event = &spanEvent{
+		TraceID:      gen.GenerateTraceID(),
+		GUID:         gen.GenerateSpanID(),
+		Sampled:      true,
+		Priority:     newPriorityFromRandom(gen.Float32),
+		Timestamp:    start,
+		Name:         name,
+		Category:     spanCategoryGeneric,
+		Kind:         "internal",
+		IsEntrypoint: true,
+	}
+	return s
+}
+
+// AddAttribute adds a custom attribute to the span.  The attribute is subject
+// to the same validation and configuration as the custom attributes of
+// transactions.
+func (s *Span) AddAttribute(key string, val interface{}) {
+	if s == nil || s.event == nil {
+		return
+	}
+	s.Lock()
+	defer s.Unlock()
+
+	var err error
+	switch {
+	case s.ended:
+		err = errAlreadyEnded
+	case s.run.Config.HighSecurity:
+		err = errHighSecurityEnabled
+	case !s.run.Reply.SecurityPolicies.CustomParameters.Enabled():
+		err = errSecurityPolicy
+	default:
+		err = addUserAttribute(s.attrs, key, val, destSpan)
+	}
+	if err != nil {
+		s.app.Error("unable to add span attribute", map[string]interface{}{
+			"reason": err.Error(),
+		})
+	}
+}
+
+// NoticeError records the class and message of err on the span.  Unlike
+// Transaction.NoticeError, no error event or error trace is created.
+func (s *Span) NoticeError(err error) {
+	if s == nil || s.event == nil || err == nil {
+		return
+	}
+	s.Lock()
+	defer s.Unlock()
+
+	if s.ended || !s.run.Config.ErrorCollector.Enabled {
+		return
+	}
+	msg := truncateStringMessageIfLong(err.Error())
+	if s.run.Config.HighSecurity {
+		msg = highSecurityErrorMsg
+	} else if !s.run.Reply.SecurityPolicies.AllowRawExceptionMessages.Enabled() {
+		msg = securityPolicyErrorMsg
+	}
+	s.event.AgentAttributes.addString(SpanAttributeErrorClass, spanErrorClass(err))
+	s.event.AgentAttributes.addString(SpanAttributeErrorMessage, msg)
+}
+
+// End ends the span and records it.  Calling End more than once has no effect.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.Lock()
+	defer s.Unlock()
+
+	if s.ended {
+		return
+	}
+	s.ended = true
+	if s.event == nil {
+		return
+	}
+	s.event.Duration = time.Since(s.event.Timestamp)
+	s.event.UserAttributes.addUserAttrs(s.attrs.user)
+	s.event.AgentAttributes = s.attrs.filterSpanAttributes(s.event.AgentAttributes, destSpan)
+
+	if shouldUseTraceObserver(s.run.Config) {
+		if observer := s.app.getObserver(); observer != nil {
+			observer.consumeSpan(s.event)
+		}
+		return
+	}
+	s.app.Consume(s.run.Reply.RunID, s.event)
+}
+
+// MergeIntoHarvest implements harvestable for spans recorded without a
+// transaction.
+func (e *spanEvent) MergeIntoHarvest(h *harvest) {
+	h.SpanEvents.addEventPopulated(e)
+}
+
+// spanErrorClass returns the class of the error in the same way as
+// Transaction.NoticeError.
+func spanErrorClass(err error) string {
+	cause := errorCause(err)
+	if c := errorClassMethod(err); c != "" {
+		return c
+	}
+	if c := errorClassMethod(cause); c != "" {
+		return c
+	}
+	return reflect.TypeOf(cause).String()
+}
@@ -5,6 +5,7 @@ package newrelic
 
 import (
 	"bytes"
+	"sort"
 	"time"
 )
 
@@ -109,6 +110,104 @@ func (e *spanEvent) MarshalJSON() ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
+// spanAttributeAggregateCount is the number of span events represented by a
+// span event created by aggregateSpanEvents.
+const spanAttributeAggregateCount = "nr.aggregateCount"
+
+// aggregateSpanEvents combines span events which have the same parent, name,
+// category, and key attributes, and which are not the parent of another span
+// event, into a single span event.  The first span event of each group is
+// kept: its duration becomes the sum of the group's durations and its
+// timestamp the earliest of the group's timestamps.  Entry point spans and
+// spans containing errors are never combined.  The order of the span events
+// is preserved.
+func aggregateSpanEvents(events []*spanEvent, keyAttributes []string) []*spanEvent {
+	if len(events) < 2 {
+		return events
+	}
+	parents := make(map[string]struct{}, len(events))
+	for _, e := range events {
+		if "" != e.ParentID {
+			parents[e.ParentID] = struct{}{}
+		}
+	}
+
+	groups := make(map[string]*spanEvent)
+	counts := make(map[*spanEvent]int)
+	buf := &bytes.Buffer{}
+	out := events[:0]
+	for _, e := range events {
+		_, isParent := parents[e.GUID]
+		_, hasError := e.AgentAttributes[SpanAttributeErrorClass]
+		if isParent || hasError || e.IsEntrypoint {
+			out = append(out, e)
+			continue
+		}
+		key := spanAggregationKey(buf, e, keyAttributes)
+		if first, ok := groups[key]; ok {
+			counts[first]++
+			first.Duration += e.Duration
+			if e.Timestamp.Before(first.Timestamp) {
+				first.Timestamp = e.Timestamp
+			}
+			continue
+		}
+		groups[key] = e
+		counts[e] = 1
+		out = append(out, e)
+	}
+	for e, n := range counts {
+		if n > 1 {
+			e.AgentAttributes.addInt(spanAttributeAggregateCount, n)
+		}
+	}
+	return out
+}
+
+// spanAggregationKey returns a string identifying the span events which may
+// be combined with the span event.
+func spanAggregationKey(buf *bytes.Buffer, e *spanEvent, keyAttributes []string) string {
+	buf.Reset()
+	buf.WriteString(e.ParentID)
+	buf.WriteByte(0)
+	buf.WriteString(e.Name)
+	buf.WriteByte(0)
+	buf.WriteString(string(e.Category))
+	buf.WriteByte(0)
+	buf.WriteString(e.Component)
+	buf.WriteByte(0)
+	buf.WriteString(e.Kind)
+	if len(keyAttributes) > 0 {
+		for _, key := range keyAttributes {
+			buf.WriteByte(0)
+			if val, ok := e.AgentAttributes[key]; ok {
+				val.WriteJSON(buf)
+			} else if val, ok := e.UserAttributes[key]; ok {
+				val.WriteJSON(buf)
+			}
+		}
+		return buf.String()
+	}
+	writeSortedAttrs(buf, e.AgentAttributes)
+	writeSortedAttrs(buf, e.UserAttributes)
+	return buf.String()
+}
+
+func writeSortedAttrs(buf *bytes.Buffer, attrs spanAttributeMap) {
+	keys := make([]string, 0, len(attrs))
+	for key := range attrs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	buf.WriteByte(0)
+	for _, key := range keys {
+		buf.WriteString(key)
+		buf.WriteByte('=')
+		attrs[key].WriteJSON(buf)
+		buf.WriteByte(0)
+	}
+}
+
 type spanEvents struct {
 	*analyticsEvents
 }
@@ -5,6 +5,7 @@ package newrelic
 
 import (
 	"encoding/json"
+	"reflect"
 	"testing"
 	"time"
 
@@ -244,3 +245,76 @@ func TestSpanEventsMerge(t *testing.T) {
 		},
 	})
 }
+
+func TestAggregateSpanEvents(t *testing.T) {
+	start := time.Now()
+	query := func(guid, parent, statement string, offset time.Duration) *spanEvent {
+		e := &spanEvent{
+			GUID:      guid,
+			ParentID:  parent,
+			Timestamp: start.Add(offset),
+			Duration:  time.Millisecond,
+			Name:      "Datastore/statement/MySQL/users/select",
+			Category:  spanCategoryDatastore,
+		}
+		e.AgentAttributes.addString(SpanAttributeDBStatement, statement)
+		return e
+	}
+	root := &spanEvent{GUID: "root", Name: "OtherTransaction/Go/hello", Category: spanCategoryGeneric, IsEntrypoint: true}
+	errored := query("errored", "root", "SELECT", 4*time.Millisecond)
+	errored.AgentAttributes.addString(SpanAttributeErrorClass, "myError")
+	events := []*spanEvent{
+		query("q1", "root", "SELECT", 2*time.Millisecond),
+		query("q2", "root", "SELECT", time.Millisecond),
+		query("q3", "root", "SELECT", 3*time.Millisecond),
+		query("q4", "root", "INSERT", 0),
+		query("q5", "other", "SELECT", 0),
+		errored,
+		root,
+	}
+
+	out := aggregateSpanEvents(events, nil)
+	var guids []string
+	for _, e := range out {
+		guids = append(guids, e.GUID)
+	}
+	if !reflect.DeepEqual(guids, []string{"q1", "q4", "q5", "errored", "root"}) {
+		t.Fatal(guids)
+	}
+	if out[0].Duration != 3*time.Millisecond {
+		t.Error(out[0].Duration)
+	}
+	if !out[0].Timestamp.Equal(start.Add(time.Millisecond)) {
+		t.Error(out[0].Timestamp)
+	}
+	if _, ok := out[0].AgentAttributes[spanAttributeAggregateCount]; !ok {
+		t.Error("aggregate count missing", out[0].AgentAttributes)
+	}
+	if _, ok := out[1].AgentAttributes[spanAttributeAggregateCount]; ok {
+		t.Error("unexpected aggregate count", out[1].AgentAttributes)
+	}
+}
+
+func TestAggregateSpanEventsKeyAttributes(t *testing.T) {
+	var events []*spanEvent
+	for _, statement := range []string{"SELECT", "INSERT"} {
+		e := &spanEvent{GUID: statement, ParentID: "root", Name: "Custom/query", Category: spanCategoryGeneric}
+		e.AgentAttributes.addString(SpanAttributeDBStatement, statement)
+		e.AgentAttributes.addString(SpanAttributeDBCollection, "users")
+		events = append(events, e)
+	}
+	if out := aggregateSpanEvents(events, []string{SpanAttributeDBCollection}); len(out) != 1 {
+		t.Error(len(out))
+	}
+}
+
+func TestAggregateSpanEventsParentsNotCombined(t *testing.T) {
+	events := []*spanEvent{
+		{GUID: "a", ParentID: "root", Name: "Custom/outer", Category: spanCategoryGeneric},
+		{GUID: "b", ParentID: "root", Name: "Custom/outer", Category: spanCategoryGeneric},
+		{GUID: "c", ParentID: "a", Name: "Custom/inner", Category: spanCategoryGeneric},
+	}
+	if out := aggregateSpanEvents(events, nil); len(out) != 3 {
+		t.Error(len(out))
+	}
+}
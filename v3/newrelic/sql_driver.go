@@ -19,6 +19,15 @@ type SQLDriverSegmentBuilder struct {
 	BaseSegment DatastoreSegment
 	ParseQuery  func(segment *DatastoreSegment, query string)
 	ParseDSN    func(segment *DatastoreSegment, dataSourceName string)
+	// ExplainPlan, if set, returns the function capturing the query plan
+	// of the query of the segment, which is used as the ExplainPlan of the
+	// segment.  dataSourceName is the name the connection was opened
+	// with, and is empty if the connection was opened by a connector
+	// wrapped using InstrumentSQLConnector.
+	ExplainPlan func(segment *DatastoreSegment, dataSourceName, query string, args []driver.NamedValue) func() (string, error)
+
+	// dataSourceName is the name the connection was opened with.
+	dataSourceName string
 }
 
 // InstrumentSQLDriver wraps a driver.Driver, adding instrumentation for exec
@@ -57,6 +66,7 @@ func (bld SQLDriverSegmentBuilder) useDSN(dsn string) SQLDriverSegmentBuilder {
 	if f := bld.ParseDSN; f != nil {
 		f(&bld.BaseSegment, dsn)
 	}
+	bld.dataSourceName = dsn
 	return bld
 }
 
@@ -74,9 +84,19 @@ func (bld SQLDriverSegmentBuilder) startSegment(ctx context.Context) DatastoreSe
 func (bld SQLDriverSegmentBuilder) startSegmentAt(ctx context.Context, at time.Time) DatastoreSegment {
 	segment := bld.BaseSegment
 	segment.StartTime = FromContext(ctx).startSegmentAt(at)
+	if params := queryParametersFromContext(ctx); nil != params {
+		segment.QueryParameters = params
+	}
 	return segment
 }
 
+// explain sets the ExplainPlan of the segment of the query.
+func (bld SQLDriverSegmentBuilder) explain(segment *DatastoreSegment, query string, args []driver.NamedValue) {
+	if f := bld.ExplainPlan; f != nil && nil != segment.StartTime.thread {
+		segment.ExplainPlan = f(segment, bld.dataSourceName, query, args)
+	}
+}
+
 type wrapDriver struct {
 	bld      SQLDriverSegmentBuilder
 	original driver.Driver
@@ -94,6 +114,7 @@ type wrapConn struct {
 
 type wrapStmt struct {
 	bld      SQLDriverSegmentBuilder
+	query    string
 	original driver.Stmt
 }
 
@@ -144,6 +165,7 @@ func prepare(original driver.Stmt, err error, bld SQLDriverSegmentBuilder, query
 	}
 	return optionalMethodsStmt(&wrapStmt{
 		bld:      bld.useQuery(query),
+		query:    query,
 		original: original,
 	}), nil
 }
@@ -207,7 +229,9 @@ func (w *wrapConn) ExecContext(ctx context.Context, query string, args []driver.
 	startTime := time.Now()
 	result, err = w.original.(driver.ExecerContext).ExecContext(ctx, query, args)
 	if err != driver.ErrSkip {
-		seg := w.bld.useQuery(query).startSegmentAt(ctx, startTime)
+		bld := w.bld.useQuery(query)
+		seg := bld.startSegmentAt(ctx, startTime)
+		bld.explain(&seg, query, args)
 		seg.End()
 	}
 	return result, err
@@ -251,7 +275,9 @@ func (w *wrapConn) QueryContext(ctx context.Context, query string, args []driver
 	startTime := time.Now()
 	rows, err = w.original.(driver.QueryerContext).QueryContext(ctx, query, args)
 	if err != driver.ErrSkip {
-		seg := w.bld.useQuery(query).startSegmentAt(ctx, startTime)
+		bld := w.bld.useQuery(query)
+		seg := bld.startSegmentAt(ctx, startTime)
+		bld.explain(&seg, query, args)
 		seg.End()
 	}
 	return rows, err
@@ -321,6 +347,7 @@ func (w *wrapStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (d
 	}
 	segment := w.bld.startSegment(ctx)
 	result, err = w.original.(driver.StmtExecContext).ExecContext(ctx, args)
+	w.bld.explain(&segment, w.query, args)
 	segment.End()
 	return result, err
 }
@@ -338,6 +365,7 @@ func (w *wrapStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (
 	}
 	segment := w.bld.startSegment(ctx)
 	rows, err = w.original.(driver.StmtQueryContext).QueryContext(ctx, args)
+	w.bld.explain(&segment, w.query, args)
 	segment.End()
 	return rows, err
 }
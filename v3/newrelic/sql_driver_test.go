@@ -143,6 +143,32 @@ func TestDriverConnQueryContext(t *testing.T) {
 	app.ExpectMetrics(t, driverTestMetrics)
 }
 
+func TestDriverQueryParametersFromContext(t *testing.T) {
+	// Test that query parameters added to the context are recorded.
+	cfgfn := func(cfg *Config) {
+		cfg.DatastoreTracer.SlowQuery.Threshold = 0
+		cfg.DistributedTracer.Enabled = false
+	}
+	app := testApp(nil, cfgfn, t)
+	dr := InstrumentSQLDriver(testDriver{}, testBuilder)
+	txn := app.StartTransaction("hello")
+	conn, _ := dr.Open("myhost,myport,mydatabase")
+	ctx := NewContext(context.Background(), txn)
+	ctx = WithQueryParameters(ctx, map[string]interface{}{"id": 123})
+	conn.(driver.ExecerContext).ExecContext(ctx, "myoperation,mycollection", nil)
+	txn.End()
+	app.ExpectSlowQueries(t, []internal.WantSlowQuery{{
+		Count:        1,
+		MetricName:   "Datastore/statement/MySQL/mycollection/myoperation",
+		Query:        "'myoperation' on 'mycollection' using 'MySQL'",
+		TxnName:      "OtherTransaction/Go/hello",
+		DatabaseName: "mydatabase",
+		Host:         "myhost",
+		PortPathOrID: "myport",
+		Params:       map[string]interface{}{"id": 123},
+	}})
+}
+
 func TestDriverContext(t *testing.T) {
 	// Test that driver.OpenConnector returns an instrumented connector.
 	app := testApp(nil, ConfigDistributedTracerEnabled(false), t)
@@ -278,3 +304,44 @@ func TestSQLNoTxnNoCry(t *testing.T) {
 	conn, _ := connector.Connect(nil)
 	conn.(driver.QueryerContext).QueryContext(context.Background(), "myoperation,mycollection", nil)
 }
+
+func TestDriverExplainPlan(t *testing.T) {
+	app := testApp(nil, func(cfg *Config) {
+		cfg.DistributedTracer.Enabled = false
+		cfg.DatastoreTracer.SlowQuery.Threshold = 0
+		cfg.DatastoreTracer.ExplainPlan.Enabled = true
+		cfg.DatastoreTracer.ExplainPlan.Threshold = 0
+	}, t)
+	var explained []string
+	bld := testBuilder
+	bld.ExplainPlan = func(segment *DatastoreSegment, dsn, query string, args []driver.NamedValue) func() (string, error) {
+		return func() (string, error) {
+			explained = append(explained, segment.Operation+" "+dsn+" "+query+" "+args[0].Value.(string))
+			return "Result", nil
+		}
+	}
+	dr := InstrumentSQLDriver(testDriver{}, bld)
+	txn := app.StartTransaction("hello")
+	conn, _ := dr.Open("myhost,myport,mydatabase")
+	ctx := NewContext(context.Background(), txn)
+	args := []driver.NamedValue{{Ordinal: 1, Value: "x"}}
+	conn.(driver.QueryerContext).QueryContext(ctx, "myoperation,mycollection", args)
+	app.app.explainPlanner.wait()
+	stmt, _ := conn.Prepare("otheroperation,mycollection")
+	stmt.(driver.StmtExecContext).ExecContext(ctx, args)
+	app.app.explainPlanner.wait()
+	txn.End()
+
+	if len(explained) != 2 ||
+		explained[0] != "myoperation myhost,myport,mydatabase myoperation,mycollection x" ||
+		explained[1] != "otheroperation myhost,myport,mydatabase otheroperation,mycollection x" {
+		t.Error("incorrect plans captured:", explained)
+	}
+
+	// No plan is captured outside of a transaction.
+	conn.(driver.QueryerContext).QueryContext(context.Background(), "myoperation,mycollection", args)
+	app.app.explainPlanner.wait()
+	if len(explained) != 2 {
+		t.Error("plan captured without a transaction:", explained)
+	}
+}
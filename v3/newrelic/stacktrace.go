@@ -7,7 +7,9 @@ import (
 	"bytes"
 	"path"
 	"runtime"
+	"strconv"
 	"strings"
+	"time"
 )
 
 // stackTrace is a stack trace.
@@ -59,11 +61,77 @@ func (f StacktraceFrame) WriteJSON(buf *bytes.Buffer) {
 	buf.WriteByte('}')
 }
 
-func writeFrames(buf *bytes.Buffer, frames []StacktraceFrame) {
-	// Remove top agent frames.
+// stackTraceFilter removes frames from stack traces and trims their file
+// paths as configured by Config.StackTraces.  The zero value keeps every frame.
+type stackTraceFilter struct {
+	maxDepth    int
+	skipRuntime bool
+	skipVendor  bool
+	trimGOPATH  bool
+}
+
+func newStackTraceFilter(c *Config) stackTraceFilter {
+	return stackTraceFilter{
+		maxDepth:    c.StackTraces.MaxDepth,
+		skipRuntime: c.StackTraces.SkipRuntimeFrames,
+		skipVendor:  c.StackTraces.SkipVendorFrames,
+		trimGOPATH:  c.StackTraces.TrimGOPATH,
+	}
+}
+
+// skip returns true if the frame with the given function and file should be
+// removed.
+func (f stackTraceFilter) skip(function, file string) bool {
+	if f.skipRuntime && strings.HasPrefix(function, "runtime.") {
+		return true
+	}
+	if f.skipVendor && strings.Contains(file, "/vendor/") {
+		return true
+	}
+	return false
+}
+
+// filePath trims the GOPATH or GOROOT from the file path.
+func (f stackTraceFilter) filePath(file string) string {
+	if !f.trimGOPATH {
+		return file
+	}
+	if idx := strings.LastIndex(file, "/pkg/mod/"); idx >= 0 {
+		return file[idx+len("/pkg/mod/"):]
+	}
+	if idx := strings.Index(file, "/src/"); idx >= 0 {
+		return file[idx+len("/src/"):]
+	}
+	return file
+}
+
+// apply returns the frames which are not skipped, with their file paths
+// trimmed, up to the maximum depth.
+func (f stackTraceFilter) apply(frames []StacktraceFrame) []StacktraceFrame {
+	filtered := make([]StacktraceFrame, 0, len(frames))
+	for _, frame := range frames {
+		if f.skip(frame.Name, frame.File) {
+			continue
+		}
+		frame.File = f.filePath(frame.File)
+		filtered = append(filtered, frame)
+	}
+	if f.maxDepth > 0 && len(filtered) > f.maxDepth {
+		filtered = filtered[:f.maxDepth]
+	}
+	return filtered
+}
+
+// removeAgentFrames removes the top agent frames.
+func removeAgentFrames(frames []StacktraceFrame) []StacktraceFrame {
 	for len(frames) > 0 && frames[0].isAgent() {
 		frames = frames[1:]
 	}
+	return frames
+}
+
+func writeFrames(buf *bytes.Buffer, frames []StacktraceFrame) {
+	frames = removeAgentFrames(frames)
 	// Truncate excessively long stack traces (they may be provided by the
 	// customer).
 	if len(frames) > maxStackTraceFrames {
@@ -106,6 +174,12 @@ func (st stackTrace) WriteJSON(buf *bytes.Buffer) {
 	writeFrames(buf, frames)
 }
 
+// writeFilteredJSON adds the stack trace to the buffer, after removing the top
+// agent frames, with the filter applied.
+func (st stackTrace) writeFilteredJSON(buf *bytes.Buffer, f stackTraceFilter) {
+	writeFrames(buf, f.apply(removeAgentFrames(st.frames())))
+}
+
 // MarshalJSON prepares JSON in the format expected by the collector.
 func (st stackTrace) MarshalJSON() ([]byte, error) {
 	estimate := 256 * len(st)
@@ -115,3 +189,47 @@ func (st stackTrace) MarshalJSON() ([]byte, error) {
 
 	return buf.Bytes(), nil
 }
+
+// spanStackTraces captures the stack traces of the segments whose duration
+// exceeds the threshold, for Config.SpanEvents.StackTraces.
+type spanStackTraces struct {
+	threshold time.Duration
+	maxFrames int
+	filter    stackTraceFilter
+}
+
+func newSpanStackTraces(c *Config) spanStackTraces {
+	return spanStackTraces{
+		threshold: c.SpanEvents.StackTraces.Threshold,
+		maxFrames: c.SpanEvents.StackTraces.MaxFrames,
+		filter:    newStackTraceFilter(c),
+	}
+}
+
+// capture returns true if the stack trace of a segment with the given
+// duration should be captured.
+func (s spanStackTraces) capture(duration time.Duration) bool {
+	return s.threshold > 0 && duration >= s.threshold
+}
+
+// format returns the abbreviated stack trace, one "function (file:line)"
+// frame per line, without the top agent frames.  Frames are added while the
+// stack trace is shorter than the limit of attribute values.
+func (s spanStackTraces) format(frames []StacktraceFrame) string {
+	frames = s.filter.apply(removeAgentFrames(frames))
+	var b strings.Builder
+	for i, frame := range frames {
+		if s.maxFrames > 0 && i >= s.maxFrames {
+			break
+		}
+		line := frame.formattedName() + " (" + path.Base(frame.File) + ":" + strconv.FormatInt(frame.Line, 10) + ")"
+		if i > 0 {
+			line = "\n" + line
+		}
+		if b.Len()+len(line) > attributeValueLengthLimit {
+			break
+		}
+		b.WriteString(line)
+	}
+	return b.String()
+}
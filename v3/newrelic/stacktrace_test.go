@@ -6,8 +6,10 @@ package newrelic
 import (
 	"bytes"
 	"encoding/json"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/newrelic/go-agent/v3/internal/stacktracetest"
 )
@@ -228,3 +230,110 @@ func TestFramesCount(t *testing.T) {
 		t.Error("Invalid # of frames", len(st), len(frames))
 	}
 }
+
+func TestStackTraceFilter(t *testing.T) {
+	frames := []StacktraceFrame{
+		{
+			File: "/home/me/go/pkg/mod/github.com/gin-gonic/gin@v1.9.1/context.go",
+			Name: "github.com/gin-gonic/gin.(*Context).Next",
+			Line: 174,
+		},
+		{
+			File: "/home/me/project/vendor/github.com/x/middleware/middleware.go",
+			Name: "github.com/x/middleware.Wrap.func1",
+			Line: 12,
+		},
+		{
+			File: "/home/me/go/src/github.com/me/project/main.go",
+			Name: "main.handler",
+			Line: 30,
+		},
+		{
+			File: "/usr/local/go/src/runtime/asm_amd64.s",
+			Name: "runtime.goexit",
+			Line: 1357,
+		},
+	}
+
+	if got := (stackTraceFilter{}).apply(frames); !reflect.DeepEqual(got, frames) {
+		t.Error("zero filter changed frames", got)
+	}
+
+	filter := newStackTraceFilter(&Config{})
+	filter.skipRuntime = true
+	filter.skipVendor = true
+	filter.trimGOPATH = true
+	expect := []StacktraceFrame{
+		{
+			File: "github.com/gin-gonic/gin@v1.9.1/context.go",
+			Name: "github.com/gin-gonic/gin.(*Context).Next",
+			Line: 174,
+		},
+		{
+			File: "github.com/me/project/main.go",
+			Name: "main.handler",
+			Line: 30,
+		},
+	}
+	if got := filter.apply(frames); !reflect.DeepEqual(got, expect) {
+		t.Error(got)
+	}
+
+	filter.maxDepth = 1
+	if got := filter.apply(frames); !reflect.DeepEqual(got, expect[:1]) {
+		t.Error(got)
+	}
+}
+
+func TestSpanStackTracesFormat(t *testing.T) {
+	frames := []StacktraceFrame{
+		{
+			File: "/home/me/go/pkg/mod/github.com/newrelic/go-agent/v3/newrelic/segments.go",
+			Name: "github.com/newrelic/go-agent/v3/newrelic.(*Segment).End",
+			Line: 40,
+		},
+		{
+			File: "/home/me/project/store/store.go",
+			Name: "github.com/me/project/store.(*Store).Get",
+			Line: 88,
+		},
+		{
+			File: "/home/me/project/main.go",
+			Name: "main.handler",
+			Line: 30,
+		},
+		{
+			File: "/usr/local/go/src/runtime/asm_amd64.s",
+			Name: "runtime.goexit",
+			Line: 1357,
+		},
+	}
+	cfg := defaultConfig()
+	cfg.StackTraces.SkipRuntimeFrames = true
+	s := newSpanStackTraces(&cfg)
+	expect := "store.(*Store).Get (store.go:88)\nmain.handler (main.go:30)"
+	if got := s.format(frames); got != expect {
+		t.Errorf("got %q, expected %q", got, expect)
+	}
+
+	s.maxFrames = 1
+	if got := s.format(frames); got != "store.(*Store).Get (store.go:88)" {
+		t.Error(got)
+	}
+
+	long := StacktraceFrame{Name: "main." + strings.Repeat("x", 200), File: "main.go", Line: 1}
+	s.maxFrames = 0
+	if got := s.format([]StacktraceFrame{long, long}); len(got) > attributeValueLengthLimit || !strings.HasPrefix(got, "main.xxx") {
+		t.Error(len(got), got)
+	}
+}
+
+func TestSpanStackTracesCapture(t *testing.T) {
+	if (spanStackTraces{}).capture(time.Hour) {
+		t.Error("stack traces captured when disabled")
+	}
+	s := spanStackTraces{threshold: time.Second}
+	if s.capture(time.Millisecond) || !s.capture(time.Second) {
+		t.Error("threshold not applied")
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package newrelic
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// PauseTelemetry stops the transmission of data to New Relic until
+// ResumeTelemetry is called, for example to conserve bandwidth during an
+// incident or to honor a data-governance freeze.  The application keeps
+// running and instrumenting transactions while paused.
+//
+// By default the data collected while paused is buffered and sent by the
+// first harvest after ResumeTelemetry is called.  Events are sampled into
+// the usual fixed-size reservoirs, so the buffer does not grow without bound
+// however long the pause lasts.  Set TelemetryPause.DropData to discard the
+// data instead.  Data buffered when the application is shut down is never
+// sent.
+//
+// Pausing has no effect in ServerlessMode, in which no data is sent to New
+// Relic directly, and does not stop the streaming of span events to an
+// Infinite Tracing trace observer.
+func (app *Application) PauseTelemetry() {
+	if app == nil || app.app == nil {
+		return
+	}
+	if atomic.CompareAndSwapInt32(&app.app.telemetryPaused, 0, 1) {
+		app.app.Info("telemetry paused", map[string]interface{}{
+			"app":       app.app.config.AppName,
+			"drop_data": app.app.config.TelemetryPause.DropData,
+		})
+	}
+}
+
+// ResumeTelemetry resumes the transmission of data to New Relic stopped by
+// PauseTelemetry.
+func (app *Application) ResumeTelemetry() {
+	if app == nil || app.app == nil {
+		return
+	}
+	if atomic.CompareAndSwapInt32(&app.app.telemetryPaused, 1, 0) {
+		app.app.Info("telemetry resumed", map[string]interface{}{
+			"app": app.app.config.AppName,
+		})
+	}
+}
+
+// TelemetryPaused returns true if the transmission of data to New Relic has
+// been stopped by PauseTelemetry.
+func (app *Application) TelemetryPaused() bool {
+	if app == nil || app.app == nil {
+		return false
+	}
+	return app.app.isTelemetryPaused()
+}
+
+func (app *app) isTelemetryPaused() bool {
+	return atomic.LoadInt32(&app.telemetryPaused) == 1
+}
+
+// readyHarvest returns the data of h which is ready to be sent, or nil if
+// no data is ready or telemetry is paused.  While telemetry is paused the
+// data is either left in h until telemetry is resumed or, if
+// TelemetryPause.DropData is set, discarded when it would have been sent.
+func (app *app) readyHarvest(h *harvest, now time.Time) *harvest {
+	if !app.isTelemetryPaused() {
+		ready := h.Ready(now)
+		app.stats.recordEvents(ready)
+		return ready
+	}
+	if app.config.TelemetryPause.DropData {
+		h.Ready(now)
+	}
+	return nil
+}
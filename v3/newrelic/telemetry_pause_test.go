@@ -0,0 +1,73 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package newrelic
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPauseTelemetryNilApplication(t *testing.T) {
+	var app *Application
+	app.PauseTelemetry()
+	app.ResumeTelemetry()
+	if app.TelemetryPaused() {
+		t.Error("nil application telemetry paused")
+	}
+}
+
+func TestPauseResumeTelemetry(t *testing.T) {
+	app := testApp(nil, nil, t)
+	if app.TelemetryPaused() {
+		t.Fatal("telemetry paused before PauseTelemetry")
+	}
+	app.PauseTelemetry()
+	app.PauseTelemetry()
+	if !app.TelemetryPaused() {
+		t.Fatal("telemetry not paused after PauseTelemetry")
+	}
+	app.ResumeTelemetry()
+	if app.TelemetryPaused() {
+		t.Fatal("telemetry paused after ResumeTelemetry")
+	}
+}
+
+func TestReadyHarvestTelemetryPausedBuffersData(t *testing.T) {
+	app := testApp(nil, nil, t)
+	now := time.Now()
+	h := newHarvest(now, testHarvestCfgr)
+	h.Metrics.addCount("Custom/buffered", 1, forced)
+
+	app.PauseTelemetry()
+	if ready := app.app.readyHarvest(h, now.Add(61*time.Second)); ready != nil {
+		t.Fatal("harvest ready while telemetry paused")
+	}
+	app.ResumeTelemetry()
+	ready := app.app.readyHarvest(h, now.Add(122*time.Second))
+	if ready == nil {
+		t.Fatal("harvest not ready after telemetry resumed")
+	}
+	if _, ok := ready.Metrics.metrics[metricID{Name: "Custom/buffered"}]; !ok {
+		t.Error("data collected while telemetry paused not buffered")
+	}
+}
+
+func TestReadyHarvestTelemetryPausedDropsData(t *testing.T) {
+	app := testApp(nil, ConfigTelemetryPauseDropData(true), t)
+	now := time.Now()
+	h := newHarvest(now, testHarvestCfgr)
+	h.Metrics.addCount("Custom/dropped", 1, forced)
+
+	app.PauseTelemetry()
+	if ready := app.app.readyHarvest(h, now.Add(61*time.Second)); ready != nil {
+		t.Fatal("harvest ready while telemetry paused")
+	}
+	if _, ok := h.Metrics.metrics[metricID{Name: "Custom/dropped"}]; ok {
+		t.Error("data collected while telemetry paused not dropped")
+	}
+	app.ResumeTelemetry()
+	if ready := app.app.readyHarvest(h, now.Add(122*time.Second)); ready == nil {
+		t.Fatal("harvest not ready after telemetry resumed")
+	}
+}
@@ -0,0 +1,190 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package newrelic
+
+import (
+	"sync"
+	"time"
+)
+
+const defaultMaxTenants = 100
+
+const (
+	tenantMetricPrefix = "Tenant/"
+	// tenantOther replaces the tenant of transactions once there are too
+	// many tenants.
+	tenantOther              = "Other"
+	tenantTransactionsSuffix = "/Transactions"
+	tenantErrorsSuffix       = "/Errors"
+	tenantOverflowMetric     = "Supportability/Go/TenantAccounting/Overflow"
+)
+
+// TenantInfo contains information about a transaction for the TenantCallback.
+// All fields are copies of agent data, and the attributes of the transaction
+// are only accessible through methods.
+type TenantInfo struct {
+	txnAttributes *attributes
+
+	// TransactionName is the name of the transaction as it appears in the
+	// New Relic UI, for example `WebTransaction/Go/GET /users`.
+	TransactionName string
+
+	// IsWeb is true if the transaction is a web transaction.
+	IsWeb bool
+
+	// Duration is the duration of the transaction.
+	Duration time.Duration
+
+	// HasErrors is true if the transaction recorded an error which is not
+	// expected.
+	HasErrors bool
+}
+
+// GetTransactionUserAttribute looks up a custom attribute of the transaction
+// by key, returning the value and whether the key was found.
+func (t *TenantInfo) GetTransactionUserAttribute(attribute string) (interface{}, bool) {
+	if t.txnAttributes == nil {
+		return nil, false
+	}
+	if a, ok := t.txnAttributes.user[attribute]; ok {
+		return a.value, true
+	}
+	return nil, false
+}
+
+// GetRequestURI returns the URI of the request of a web transaction, or an
+// empty string.
+func (t *TenantInfo) GetRequestURI() string {
+	return t.agentStringAttribute(AttributeRequestURI)
+}
+
+// GetRequestMethod returns the method of the request of a web transaction, or
+// an empty string.
+func (t *TenantInfo) GetRequestMethod() string {
+	return t.agentStringAttribute(AttributeRequestMethod)
+}
+
+// GetUserID returns the user ID set on the transaction using
+// Transaction.SetUserID, or an empty string.
+func (t *TenantInfo) GetUserID() string {
+	return t.agentStringAttribute(AttributeUserID)
+}
+
+func (t *TenantInfo) agentStringAttribute(key string) string {
+	if t.txnAttributes == nil {
+		return ""
+	}
+	return t.txnAttributes.Agent[key].stringVal
+}
+
+// TenantCallback is a user defined callback function which returns the tenant
+// of a transaction, for example from a custom attribute added by the
+// application.  If the transaction does not belong to a tenant, the function
+// should return an empty string.
+//
+// example function:
+//
+//	func tenantCallback(info newrelic.TenantInfo) string {
+//		if tenant, ok := info.GetTransactionUserAttribute("tenant"); ok {
+//			return fmt.Sprint(tenant)
+//		}
+//		return ""
+//	}
+type TenantCallback func(TenantInfo) string
+
+// tenantAccountant records the usage of each tenant as metrics.  Once there
+// are MaxTenants tenants, the usage of new tenants is recorded under the
+// tenant "Other".
+//
+// A tenantAccountant is shared by all of the transactions of an appRun.
+type tenantAccountant struct {
+	sync.Mutex
+	callback   TenantCallback
+	maxTenants int
+	logger     Logger
+	tenants    map[string]struct{}
+	overflowed bool
+}
+
+func newTenantAccountant(cfg config) *tenantAccountant {
+	c := cfg.TenantAccounting
+	if !c.Enabled || c.TenantCallback == nil || c.MaxTenants <= 0 {
+		return nil
+	}
+	return &tenantAccountant{
+		callback:   c.TenantCallback,
+		maxTenants: c.MaxTenants,
+		logger:     cfg.Logger,
+		tenants:    make(map[string]struct{}),
+	}
+}
+
+// tenant returns the tenant to use in the metric names, and whether the tenant
+// was replaced because there are too many tenants.
+func (ta *tenantAccountant) tenant(name string) (string, bool) {
+	ta.Lock()
+	defer ta.Unlock()
+
+	if _, ok := ta.tenants[name]; ok {
+		return name, false
+	}
+	if len(ta.tenants) >= ta.maxTenants {
+		if !ta.overflowed {
+			ta.overflowed = true
+			if ta.logger != nil {
+				ta.logger.Warn("tenant accounting limit reached", map[string]interface{}{
+					"max_tenants": ta.maxTenants,
+				})
+			}
+		}
+		return tenantOther, true
+	}
+	ta.tenants[name] = struct{}{}
+	return name, false
+}
+
+// txnTenant is the tenant of a transaction, found when the transaction ends.
+type txnTenant struct {
+	name string
+	// overflow is true if the tenant was replaced by tenantOther because
+	// there are too many tenants.
+	overflow bool
+}
+
+// tenantOf calls the TenantCallback for the transaction.  It is called when
+// the transaction ends, in the goroutine ending it, so that a slow callback
+// does not delay the harvest.  It returns nil if the transaction does not
+// belong to a tenant.
+func (ta *tenantAccountant) tenantOf(args *txnData) *txnTenant {
+	if ta == nil {
+		return nil
+	}
+	name := ta.callback(TenantInfo{
+		txnAttributes:   args.Attrs,
+		TransactionName: args.FinalName,
+		IsWeb:           args.IsWeb,
+		Duration:        args.Duration,
+		HasErrors:       args.NoticeErrors(),
+	})
+	if name == "" {
+		return nil
+	}
+	name, overflow := ta.tenant(name)
+	return &txnTenant{name: name, overflow: overflow}
+}
+
+// mergeIntoHarvest adds the metrics for the tenant of the transaction.
+func (t *txnTenant) mergeIntoHarvest(args *txnData, metrics *metricTable) {
+	if t == nil {
+		return
+	}
+	if t.overflow {
+		metrics.addSingleCount(tenantOverflowMetric, forced)
+	}
+	prefix := tenantMetricPrefix + t.name
+	metrics.addDuration(prefix+tenantTransactionsSuffix, "", args.Duration, args.Duration, unforced)
+	if args.NoticeErrors() {
+		metrics.addSingleCount(prefix+tenantErrorsSuffix, unforced)
+	}
+}
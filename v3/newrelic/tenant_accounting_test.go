@@ -0,0 +1,171 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package newrelic
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/newrelic/go-agent/v3/internal"
+)
+
+func tenantFromAttribute(info TenantInfo) string {
+	if tenant, ok := info.GetTransactionUserAttribute("tenant"); ok {
+		return fmt.Sprint(tenant)
+	}
+	return ""
+}
+
+func TestTenantAccountingMetrics(t *testing.T) {
+	app := testApp(nil, ConfigTenantAccounting(tenantFromAttribute), t)
+
+	txn := app.StartTransaction("a")
+	txn.AddAttribute("tenant", "acme")
+	txn.End()
+
+	txn = app.StartTransaction("b")
+	txn.AddAttribute("tenant", "acme")
+	txn.NoticeError(errors.New("oops"))
+	txn.End()
+
+	txn = app.StartTransaction("c")
+	txn.AddAttribute("tenant", 42)
+	txn.End()
+
+	app.StartTransaction("d").End()
+
+	app.ExpectMetricsPresent(t, []internal.WantMetric{
+		{Name: "Tenant/acme/Transactions", Scope: "", Forced: false, Data: []float64{2}},
+		{Name: "Tenant/acme/Errors", Scope: "", Forced: false, Data: []float64{1, 0, 0, 0, 0, 0}},
+		{Name: "Tenant/42/Transactions", Scope: "", Forced: false, Data: []float64{1}},
+	})
+}
+
+func TestTenantAccountingMaxTenants(t *testing.T) {
+	app := testApp(nil, func(cfg *Config) {
+		ConfigTenantAccounting(tenantFromAttribute)(cfg)
+		ConfigTenantAccountingMaxTenants(1)(cfg)
+	}, t)
+
+	for _, tenant := range []string{"acme", "globex", "initech", "acme"} {
+		txn := app.StartTransaction("job")
+		txn.AddAttribute("tenant", tenant)
+		txn.End()
+	}
+
+	app.ExpectMetricsPresent(t, []internal.WantMetric{
+		{Name: "Tenant/acme/Transactions", Scope: "", Forced: false, Data: []float64{2}},
+		{Name: "Tenant/Other/Transactions", Scope: "", Forced: false, Data: []float64{2}},
+		{Name: tenantOverflowMetric, Scope: "", Forced: true, Data: []float64{2, 0, 0, 0, 0, 0}},
+	})
+}
+
+func TestTenantAccountingInfo(t *testing.T) {
+	var info TenantInfo
+	app := testApp(nil, ConfigTenantAccounting(func(i TenantInfo) string {
+		info = i
+		return ""
+	}), t)
+
+	txn := app.StartTransaction("hello")
+	txn.SetUserID("gopher")
+	txn.SetWebRequestHTTP(&http.Request{Method: "POST", URL: helloRequest.URL})
+	txn.SetWebResponse(nil).WriteHeader(500)
+	txn.End()
+
+	if info.TransactionName != "WebTransaction/Go/hello" || !info.IsWeb || !info.HasErrors || info.Duration <= 0 {
+		t.Errorf("incorrect tenant info: %+v", info)
+	}
+	if method := info.GetRequestMethod(); method != "POST" {
+		t.Error("incorrect request method:", method)
+	}
+	if uri := info.GetRequestURI(); uri != helloRequest.URL.Path {
+		t.Error("incorrect request uri:", uri)
+	}
+	if user := info.GetUserID(); user != "gopher" {
+		t.Error("incorrect user id:", user)
+	}
+	if _, ok := info.GetTransactionUserAttribute("tenant"); ok {
+		t.Error("unexpected user attribute")
+	}
+}
+
+func TestTenantAccountantRecord(t *testing.T) {
+	cfg := config{Config: defaultConfig()}
+	ConfigTenantAccounting(tenantFromAttribute)(&cfg.Config)
+	ta := newTenantAccountant(cfg)
+
+	mt := newMetricTable(100, time.Now())
+	args := &txnData{}
+	args.Attrs = newAttributes(createAttributeConfig(cfg, true))
+	args.Duration = 2 * time.Second
+	if tenant := ta.tenantOf(args); tenant != nil {
+		t.Error("unexpected tenant for transaction without tenant:", tenant)
+	}
+	var tenant *txnTenant
+	tenant.mergeIntoHarvest(args, mt)
+	if len(mt.metrics) != 0 {
+		t.Error("unexpected metrics for transaction without tenant:", mt.metrics)
+	}
+
+	addUserAttribute(args.Attrs, "tenant", "acme", destAll)
+	ta.tenantOf(args).mergeIntoHarvest(args, mt)
+	m := mt.metrics[metricID{Name: "Tenant/acme/Transactions"}]
+	if m == nil || m.data.countSatisfied != 1 || m.data.totalTolerated != 2 {
+		t.Error("incorrect transactions metric:", m)
+	}
+	if m := mt.metrics[metricID{Name: "Tenant/acme/Errors"}]; m != nil {
+		t.Error("unexpected errors metric:", m)
+	}
+	if len(mt.metrics) != 1 {
+		t.Error("incorrect number of metrics:", len(mt.metrics))
+	}
+}
+
+func TestTenantCallbackCalledAtEnd(t *testing.T) {
+	// The callback is called in the goroutine ending the transaction, and
+	// not in the goroutine merging it into the harvest.
+	var calls int
+	done := make(chan struct{})
+	callback := func(info TenantInfo) string {
+		calls++
+		<-done
+		return "acme"
+	}
+	app := testApp(nil, ConfigTenantAccounting(callback), t)
+	txn := app.StartTransaction("hello")
+	ended := make(chan struct{})
+	go func() {
+		txn.End()
+		close(ended)
+	}()
+	select {
+	case <-ended:
+		t.Fatal("transaction ended before the callback returned")
+	case <-time.After(10 * time.Millisecond):
+	}
+	close(done)
+	<-ended
+	if calls != 1 {
+		t.Error("incorrect number of calls:", calls)
+	}
+	app.ExpectMetricsPresent(t, []internal.WantMetric{
+		{Name: "Tenant/acme/Transactions", Scope: "", Forced: false, Data: nil},
+	})
+}
+
+func TestTenantAccountingDisabled(t *testing.T) {
+	cfg := config{Config: defaultConfig()}
+	if ta := newTenantAccountant(cfg); ta != nil {
+		t.Error("tenant accounting should be disabled by default")
+	}
+	ConfigTenantAccounting(tenantFromAttribute)(&cfg.Config)
+	ConfigTenantAccountingMaxTenants(0)(&cfg.Config)
+	if ta := newTenantAccountant(cfg); ta != nil {
+		t.Error("tenant accounting should be disabled without tenants")
+	}
+}
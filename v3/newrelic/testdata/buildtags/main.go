@@ -0,0 +1,42 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+// This program is built by TestBuildTagsExcludeFeatures with each of the
+// build tags excluding features of the agent.  It uses each of these
+// features, so that their code is linked into the binary unless excluded.
+package main
+
+import (
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/newrelic/go-agent/v3/newrelic"
+)
+
+func main() {
+	app, err := newrelic.NewApplication(
+		newrelic.ConfigAppName("Build Tags"),
+		newrelic.ConfigFromEnvironment(),
+	)
+	if err != nil {
+		os.Exit(1)
+	}
+
+	http.HandleFunc(newrelic.WrapHandleFunc(app, "/", func(w http.ResponseWriter, r *http.Request) {
+		txn := newrelic.FromContext(r.Context())
+		txn.RecordLog(newrelic.LogData{Message: "request"})
+
+		req, _ := http.NewRequest("GET", "http://example.com", nil)
+		client := &http.Client{Transport: newrelic.NewRoundTripper(nil)}
+		if resp, err := client.Do(newrelic.RequestWithTransactionContext(req, txn)); err == nil {
+			resp.Body.Close()
+		}
+		w.Write([]byte("hello"))
+	}))
+	app.RecordLLMFeedbackEvent("trace", 1, "category", "message", nil)
+	app.RecordLog(newrelic.LogData{Message: "started"})
+
+	http.ListenAndServe(":8000", nil)
+	app.Shutdown(10 * time.Second)
+}
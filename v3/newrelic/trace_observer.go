@@ -1,6 +1,9 @@
 // Copyright 2020 New Relic Corporation. All rights reserved.
 // SPDX-License-Identifier: Apache-2.0
 
+//go:build !newrelic_noinfinitetracing && !newrelic_minimal
+// +build !newrelic_noinfinitetracing,!newrelic_minimal
+
 package newrelic
 
 import (
@@ -54,10 +57,10 @@ type observerSupport struct {
 }
 
 const (
-	// versionSupports8T records whether we are using a supported version of Go
-	// for Infinite Tracing
-	versionSupports8T = true
-	grpcVersion       = grpc.Version
+	// infiniteTracingIncluded records whether Infinite Tracing is included in
+	// the build.
+	infiniteTracingIncluded = true
+	grpcVersion             = grpc.Version
 	// recordSpanBackoff is the time to wait after a failure on the RecordSpan
 	// endpoint before retrying
 	recordSpanBackoff = 15 * time.Second
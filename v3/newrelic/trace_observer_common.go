@@ -58,8 +58,8 @@ const (
 )
 
 var (
-	errUnsupportedVersion = errors.New("non supported Go version - to use Infinite Tracing, " +
-		"you must use at least version 1.9 or higher of Go")
+	errInfiniteTracingExcluded = errors.New("Infinite Tracing is excluded from this build " +
+		"by the newrelic_noinfinitetracing or the newrelic_minimal build tag")
 
 	errSpanOrDTDisabled = errors.New("in order to enable Infinite Tracing, you must have both " +
 		"Distributed Tracing and Span Events enabled")
@@ -1,7 +1,8 @@
 // Copyright 2020 New Relic Corporation. All rights reserved.
 // SPDX-License-Identifier: Apache-2.0
 
-// +build !go1.9
+//go:build newrelic_noinfinitetracing || newrelic_minimal
+// +build newrelic_noinfinitetracing newrelic_minimal
 
 package newrelic
 
@@ -10,14 +11,14 @@ import (
 )
 
 func newTraceObserver(runID internal.AgentRunID, requestHeadersMap map[string]string, cfg observerConfig) (traceObserver, error) {
-	return nil, errUnsupportedVersion
+	return nil, errInfiniteTracingExcluded
 }
 
 const (
-	// versionSupports8T records whether we are using a supported version of Go for
-	// Infinite Tracing
-	versionSupports8T = false
-	grpcVersion       = "not-installed"
+	// infiniteTracingIncluded records whether Infinite Tracing is included in
+	// the build.  Excluding it removes the gRPC and protobuf dependencies.
+	infiniteTracingIncluded = false
+	grpcVersion             = "not-installed"
 )
 
 func expectObserverEvents(v internal.Validator, events *analyticsEvents, expect []internal.WantEvent, extraAttributes map[string]interface{}) {
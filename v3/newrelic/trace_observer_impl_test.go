@@ -1,6 +1,9 @@
 // Copyright 2020 New Relic Corporation. All rights reserved.
 // SPDX-License-Identifier: Apache-2.0
 
+//go:build !newrelic_noinfinitetracing && !newrelic_minimal
+// +build !newrelic_noinfinitetracing,!newrelic_minimal
+
 package newrelic
 
 import (
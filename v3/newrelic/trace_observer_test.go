@@ -1,6 +1,9 @@
 // Copyright 2020 New Relic Corporation. All rights reserved.
 // SPDX-License-Identifier: Apache-2.0
 
+//go:build !newrelic_noinfinitetracing && !newrelic_minimal
+// +build !newrelic_noinfinitetracing,!newrelic_minimal
+
 package newrelic
 
 import (
@@ -1049,3 +1052,27 @@ func TestTraceObserverRoundTrip(t *testing.T) {
 		"license_key":     testLicenseKey,
 	})
 }
+
+func TestApplyGoroutineBudgetTraceObserver(t *testing.T) {
+	for _, tc := range []struct {
+		max      int
+		observer bool
+	}{
+		{max: 5, observer: false},
+		{max: 6, observer: true},
+	} {
+		cfg := defaultConfig()
+		cfg.AppName = "my app"
+		cfg.License = testLicenseKey
+		cfg.DistributedTracer.Enabled = true
+		cfg.InfiniteTracing.TraceObserver.Host = "nr-internal.aws-us-east-2.tracing.staging-edge.nr-data.net"
+		cfg.GoroutineBudget.Max = tc.max
+		c, err := newInternalConfig(cfg, func(string) string { return "" }, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if observer := nil != c.traceObserverURL; observer != tc.observer {
+			t.Errorf("incorrect trace observer for budget %d: %v", tc.max, observer)
+		}
+	}
+}
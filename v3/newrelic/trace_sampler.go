@@ -0,0 +1,104 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package newrelic
+
+// SamplingDecision is returned by a Sampler to override the sampling decision
+// of a transaction.
+type SamplingDecision int
+
+// These sampling decisions are returned by a Sampler.
+const (
+	// SamplingDecisionDefault leaves the decision to the sampling decision
+	// of the inbound distributed trace payload, if any, or to the adaptive
+	// sampler.
+	SamplingDecisionDefault SamplingDecision = iota
+	// SamplingDecisionSample samples the transaction.
+	SamplingDecisionSample
+	// SamplingDecisionDrop does not sample the transaction.
+	SamplingDecisionDrop
+)
+
+// SamplingContext describes the transaction whose sampling decision is made by
+// a Sampler.  The sampling decision of a transaction is made the first time it
+// is needed: when the first span is created, when distributed trace headers
+// are inserted, or when the transaction ends.  The name and attributes are
+// those of the transaction at that time.
+type SamplingContext struct {
+	// Name is the name of the transaction, as given to
+	// Application.StartTransaction or Transaction.SetName.
+	Name string
+	// IsWeb is true if the transaction is a web transaction.
+	IsWeb bool
+	// AgentAttributes are the attributes recorded by the agent, such as
+	// AttributeRequestURI and AttributeRequestMethod.
+	AgentAttributes map[string]interface{}
+	// UserAttributes are the attributes added with
+	// Transaction.AddAttribute.
+	UserAttributes map[string]interface{}
+	// Priority is the priority of the transaction, between 0 and 2.  It is
+	// the priority of the inbound distributed trace payload, if any.
+	Priority float32
+	// InboundSampled is the sampling decision of the inbound distributed
+	// trace payload, or nil if the transaction has accepted no payload or
+	// the payload has no decision.
+	InboundSampled *bool
+}
+
+// Sampler overrides the sampling decisions of transactions when assigned to
+// Config.DistributedTracer.Sampler.  Transactions sampled by a Sampler do not
+// count towards the target of the adaptive sampler.  A Sampler must be safe for
+// use in multiple goroutines.
+type Sampler interface {
+	Sample(SamplingContext) SamplingDecision
+}
+
+// SamplerFunc is a function which implements Sampler.  For example, to always
+// sample checkout transactions and never sample health checks:
+//
+//	cfg.DistributedTracer.Sampler = newrelic.SamplerFunc(func(c newrelic.SamplingContext) newrelic.SamplingDecision {
+//		switch {
+//		case strings.HasPrefix(c.Name, "POST /checkout"):
+//			return newrelic.SamplingDecisionSample
+//		case c.AgentAttributes[newrelic.AttributeRequestURI] == "/health":
+//			return newrelic.SamplingDecisionDrop
+//		}
+//		return newrelic.SamplingDecisionDefault
+//	})
+type SamplerFunc func(SamplingContext) SamplingDecision
+
+// Sample calls f.
+func (f SamplerFunc) Sample(c SamplingContext) SamplingDecision {
+	return f(c)
+}
+
+// samplerDecision returns the decision of the configured Sampler, if any.  It
+// must be called with the transaction locked.
+func (txn *txn) samplerDecision() SamplingDecision {
+	sampler := txn.Config.DistributedTracer.Sampler
+	if nil == sampler {
+		return SamplingDecisionDefault
+	}
+	c := SamplingContext{
+		Name:            txn.Name,
+		IsWeb:           txn.IsWeb,
+		AgentAttributes: make(map[string]interface{}, len(txn.Attrs.Agent)),
+		UserAttributes:  make(map[string]interface{}, len(txn.Attrs.user)),
+		Priority:        txn.BetterCAT.Priority.Float32(),
+	}
+	for key, val := range txn.Attrs.Agent {
+		if val.stringVal != "" {
+			c.AgentAttributes[key] = val.stringVal
+		} else {
+			c.AgentAttributes[key] = val.otherVal
+		}
+	}
+	for key, val := range txn.Attrs.user {
+		c.UserAttributes[key] = val.value
+	}
+	if txn.inboundSampled {
+		sampled := txn.BetterCAT.Sampled
+		c.InboundSampled = &sampled
+	}
+	return sampler.Sample(c)
+}
@@ -0,0 +1,179 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package newrelic
+
+import (
+	"testing"
+
+	"github.com/newrelic/go-agent/v3/internal"
+)
+
+func samplerConfig(s SamplerFunc) ConfigOption {
+	return func(cfg *Config) {
+		enableBetterCAT(cfg)
+		cfg.DistributedTracer.Sampler = s
+	}
+}
+
+const sampledInboundPayload = `{
+	"v":[0,1],
+	"d":{
+		"ty":"App",
+		"ap":"456",
+		"ac":"321",
+		"id":"id",
+		"tr":"traceID",
+		"ti":1488325987402,
+		"tk":"123",
+		"pr":0.5,
+		"sa":true
+	}
+}`
+
+func TestSamplerForcesSampled(t *testing.T) {
+	replyfn := func(reply *internal.ConnectReply) {
+		distributedTracingReplyFields(reply)
+		reply.SetSampleNothing()
+	}
+	var got SamplingContext
+	app := testApp(replyfn, samplerConfig(func(c SamplingContext) SamplingDecision {
+		got = c
+		return SamplingDecisionSample
+	}), t)
+	txn := app.StartTransaction("checkout")
+	txn.AddAttribute("tier", "gold")
+	if !txn.IsSampled() {
+		t.Error("transaction not sampled")
+	}
+	txn.End()
+	app.expectNoLoggedErrors(t)
+
+	if got.Name != "checkout" || got.IsWeb || got.InboundSampled != nil {
+		t.Errorf("unexpected sampling context: %+v", got)
+	}
+	if got.UserAttributes["tier"] != "gold" {
+		t.Error(got.UserAttributes)
+	}
+	if got.Priority >= 1.0 {
+		t.Error(got.Priority)
+	}
+	app.ExpectSpanEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"name":             "OtherTransaction/Go/checkout",
+			"transaction.name": "OtherTransaction/Go/checkout",
+			"sampled":          true,
+			"category":         "generic",
+			"priority":         internal.MatchAnything,
+			"guid":             internal.MatchAnything,
+			"transactionId":    internal.MatchAnything,
+			"nr.entryPoint":    true,
+			"traceId":          internal.MatchAnything,
+		},
+	}})
+}
+
+func TestSamplerDropsTransaction(t *testing.T) {
+	app := testApp(distributedTracingReplyFields, samplerConfig(func(c SamplingContext) SamplingDecision {
+		if c.AgentAttributes[AttributeRequestURI] == "/health" {
+			return SamplingDecisionDrop
+		}
+		return SamplingDecisionDefault
+	}), t)
+	txn := app.StartTransaction("health")
+	txn.SetWebRequestHTTP(helloRequest)
+	txn.End()
+	app.expectNoLoggedErrors(t)
+	app.ExpectSpanEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"name":             "WebTransaction/Go/health",
+			"transaction.name": "WebTransaction/Go/health",
+			"sampled":          true,
+			"category":         "generic",
+			"priority":         internal.MatchAnything,
+			"guid":             internal.MatchAnything,
+			"transactionId":    internal.MatchAnything,
+			"nr.entryPoint":    true,
+			"traceId":          internal.MatchAnything,
+		},
+	}})
+
+	app = testApp(distributedTracingReplyFields, samplerConfig(func(c SamplingContext) SamplingDecision {
+		if !c.IsWeb {
+			t.Error("transaction is not web")
+		}
+		if c.AgentAttributes[AttributeRequestURI] == "/hello" {
+			return SamplingDecisionDrop
+		}
+		return SamplingDecisionDefault
+	}), t)
+	txn = app.StartTransaction("hello")
+	txn.SetWebRequestHTTP(helloRequest)
+	if txn.IsSampled() {
+		t.Error("transaction sampled")
+	}
+	txn.End()
+	app.expectNoLoggedErrors(t)
+	app.ExpectSpanEvents(t, []internal.WantEvent{})
+}
+
+func TestSamplerInboundDecision(t *testing.T) {
+	replyfn := func(reply *internal.ConnectReply) {
+		distributedTracingReplyFields(reply)
+		reply.SetSampleNothing()
+	}
+	var got SamplingContext
+	app := testApp(replyfn, samplerConfig(func(c SamplingContext) SamplingDecision {
+		got = c
+		return SamplingDecisionDefault
+	}), t)
+	txn := app.StartTransaction("hello")
+	txn.AcceptDistributedTraceHeaders(TransportHTTP, headersFromString(sampledInboundPayload))
+	if !txn.IsSampled() {
+		t.Error("inbound decision not used")
+	}
+	txn.End()
+	app.expectNoLoggedErrors(t)
+	if got.InboundSampled == nil || !*got.InboundSampled || got.Priority != 0.5 {
+		t.Errorf("unexpected sampling context: %+v", got)
+	}
+
+	app = testApp(replyfn, samplerConfig(func(c SamplingContext) SamplingDecision {
+		return SamplingDecisionDrop
+	}), t)
+	txn = app.StartTransaction("hello")
+	txn.AcceptDistributedTraceHeaders(TransportHTTP, headersFromString(sampledInboundPayload))
+	if txn.IsSampled() {
+		t.Error("inbound decision not overridden")
+	}
+	txn.End()
+	app.expectNoLoggedErrors(t)
+	app.ExpectSpanEvents(t, []internal.WantEvent{})
+}
+
+func TestSamplerWithSampledOption(t *testing.T) {
+	app := testApp(distributedTracingReplyFields, samplerConfig(func(c SamplingContext) SamplingDecision {
+		t.Error("sampler called")
+		return SamplingDecisionDrop
+	}), t)
+	txn := app.StartTransaction("hello", WithSampled(true))
+	if !txn.IsSampled() {
+		t.Error("transaction not sampled")
+	}
+	txn.End()
+	app.expectNoLoggedErrors(t)
+}
+
+func TestSamplerNotConsumingAdaptiveSampler(t *testing.T) {
+	a := testApp(distributedTracingReplyFields, samplerConfig(func(c SamplingContext) SamplingDecision {
+		return SamplingDecisionSample
+	}), t)
+	txn := a.StartTransaction("hello")
+	txn.IsSampled()
+	txn.End()
+
+	run, _ := a.Application.Private.(*app).getState()
+	if n := run.adaptiveSampler.currentPeriod.numSeen; n != 0 {
+		t.Error("adaptive sampler consulted", n)
+	}
+}
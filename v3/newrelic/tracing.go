@@ -37,6 +37,8 @@ type txnEvent struct {
 	datastoreDuration  time.Duration
 	errGroupCallback   ErrorGroupCallback
 	TxnID              string
+	Breadcrumbs        *breadcrumbs
+	Timings            txnTimings
 }
 
 // betterCAT stores the transaction's priority and all fields related
@@ -102,10 +104,13 @@ type txnData struct {
 	TraceIDGenerator        *internal.TraceIDGenerator
 	ShouldCollectSpanEvents func() bool
 	ShouldCreateSpanGUID    func() bool
-	rootSpanErrData         *errorData
-	Errors                  txnErrors // Lazily initialized.
-	SpanEvents              []*spanEvent
-	logs                    logEventHeap
+	// SpanStackTraces is the capture of the stack traces of slow segments,
+	// which is disabled if its threshold is zero.
+	SpanStackTraces spanStackTraces
+	rootSpanErrData *errorData
+	Errors          txnErrors // Lazily initialized.
+	SpanEvents      []*spanEvent
+	logs            logEventHeap
 
 	customSegments    map[string]*metricData
 	datastoreSegments map[datastoreMetricKey]*metricData
@@ -115,6 +120,12 @@ type txnData struct {
 
 func (t *txnData) saveTraceSegment(end segmentEnd, name string, attrs spanAttributeMap, externalGUID string) {
 	attrs = t.Attrs.filterSpanAttributes(attrs, destSegment)
+	// Custom attributes do not replace agent attributes of the same name.
+	for key, val := range end.traceAttributes {
+		if _, ok := attrs[key]; !ok {
+			attrs.add(key, val)
+		}
+	}
 	t.TxnTrace.witnessNode(end, name, attrs, externalGUID)
 }
 
@@ -299,9 +310,14 @@ func addAttr(m *spanAttributeMap, key string, val any) {
 type segmentFrame struct {
 	segmentTime
 	children        time.Duration
+	paused          time.Duration
+	pausedAt        time.Time
 	spanID          string
 	agentAttributes spanAttributeMap
 	userAttributes  spanAttributeMap
+	// traceAttributes contains the custom attributes of the segment
+	// added to its transaction trace node.
+	traceAttributes spanAttributeMap
 }
 
 type segmentEnd struct {
@@ -314,6 +330,7 @@ type segmentEnd struct {
 	threadID        uint64
 	agentAttributes spanAttributeMap
 	userAttributes  spanAttributeMap
+	traceAttributes spanAttributeMap
 }
 
 func (end segmentEnd) spanEvent() *spanEvent {
@@ -367,16 +384,20 @@ func (thread *tracingThread) AddAgentSpanAttribute(key string, val string) {
 	}
 }
 
-// AddUserSpanAttribute allows custom attributes to be added to spans.
-func (thread *tracingThread) AddUserSpanAttribute(key string, val any) {
+// AddUserSpanAttribute allows custom attributes to be added to spans, and to
+// transaction trace segments, depending on the destinations.
+func (thread *tracingThread) AddUserSpanAttribute(key string, val any, dests destinationSet) {
 	if len(thread.stack) > 0 {
-		userAttributes := &thread.stack[len(thread.stack)-1].userAttributes
-		userAttributes.addUserAttrs(map[string]userAttribute{
+		frame := &thread.stack[len(thread.stack)-1]
+		frame.userAttributes.addUserAttrs(map[string]userAttribute{
 			key: {
 				value: val,
-				dests: destAll,
+				dests: dests,
 			},
 		})
+		if dests&destSegment != 0 {
+			addAttr(&frame.traceAttributes, key, val)
+		}
 	}
 }
 
@@ -436,8 +457,70 @@ var (
 	// incorrect order.
 	errSegmentOrder = errors.New(`improper segment use: segments must be ended in "last started first ended" order: ` +
 		`use https://godoc.org/github.com/newrelic/go-agent/v3/newrelic#Transaction.NewGoroutine to use the transaction in multiple goroutines`)
+	// errSegmentNotInProgress indicates that a segment has been paused or
+	// resumed after it ended.
+	errSegmentNotInProgress = errors.New("segment is not in progress")
 )
 
+// segmentStart returns the start time of the segment, if it is still in
+// progress.
+func (thread *tracingThread) segmentStart(start segmentStartTime) (time.Time, bool) {
+	if start.Stamp == 0 || start.Depth < 0 || start.Depth >= len(thread.stack) {
+		return time.Time{}, false
+	}
+	frame := thread.stack[start.Depth]
+	if start.Stamp != frame.Stamp {
+		return time.Time{}, false
+	}
+	return frame.Time, true
+}
+
+// inProgressFrame returns the frame of the segment, if it is still in
+// progress.
+func (thread *tracingThread) inProgressFrame(start segmentStartTime) (*segmentFrame, error) {
+	if start.Stamp == 0 || start.Depth < 0 {
+		return nil, errMalformedSegment
+	}
+	if start.Depth >= len(thread.stack) || start.Stamp != thread.stack[start.Depth].Stamp {
+		return nil, errSegmentNotInProgress
+	}
+	return &thread.stack[start.Depth], nil
+}
+
+// pauseSegment stops counting the time of the segment as exclusive time until
+// the segment is resumed or ended.
+func (thread *tracingThread) pauseSegment(start segmentStartTime, now time.Time) error {
+	frame, err := thread.inProgressFrame(start)
+	if err != nil {
+		return err
+	}
+	if frame.pausedAt.IsZero() {
+		frame.pausedAt = now
+	}
+	return nil
+}
+
+// resumeSegment resumes counting the time of a paused segment as exclusive
+// time.
+func (thread *tracingThread) resumeSegment(start segmentStartTime, now time.Time) error {
+	frame, err := thread.inProgressFrame(start)
+	if err != nil {
+		return err
+	}
+	frame.resume(now)
+	return nil
+}
+
+func (frame *segmentFrame) resume(now time.Time) {
+	if frame.pausedAt.IsZero() {
+		return
+	}
+	if now.After(frame.pausedAt) {
+		frame.paused += now.Sub(frame.pausedAt)
+	}
+	frame.pausedAt = time.Time{}
+}
+
 func endSegment(t *txnData, thread *tracingThread, start segmentStartTime, now time.Time) (segmentEnd, error) {
 	if start.Stamp == 0 {
 		return segmentEnd{}, errMalformedSegment
@@ -452,6 +535,8 @@ func endSegment(t *txnData, thread *tracingThread, start segmentStartTime, now t
 	if start.Stamp != frame.Stamp {
 		return segmentEnd{}, errSegmentOrder
 	}
+	// A segment ended while paused is paused until its end.
+	frame.resume(now)
 
 	var children time.Duration
 	for i := start.Depth; i < len(thread.stack); i++ {
@@ -462,12 +547,15 @@ func endSegment(t *txnData, thread *tracingThread, start segmentStartTime, now t
 		start:           frame.segmentTime,
 		agentAttributes: frame.agentAttributes,
 		userAttributes:  frame.userAttributes,
+		traceAttributes: frame.traceAttributes,
 	}
 	if s.stop.Time.After(s.start.Time) {
 		s.duration = s.stop.Time.Sub(s.start.Time)
 	}
-	if s.duration > children {
-		s.exclusive = s.duration - children
+	// The time the segment was paused is excluded from its exclusive time
+	// only, since it is still part of the duration of its parent.
+	if s.duration > children+frame.paused {
+		s.exclusive = s.duration - children - frame.paused
 	}
 
 	// Note that we expect (depth == (len(t.stack) - 1)).  However, if
@@ -492,6 +580,10 @@ func endSegment(t *txnData, thread *tracingThread, start segmentStartTime, now t
 		// identifier because we've already popped the segment that's
 		// ending off of the stack.
 		s.ParentID = t.CurrentSpanIdentifier(thread)
+
+		if t.SpanStackTraces.capture(s.duration) {
+			s.agentAttributes.addString(SpanAttributeCodeStacktrace, t.SpanStackTraces.format(getStackTrace().frames()))
+		}
 	}
 
 	s.threadID = thread.threadID
@@ -504,6 +596,13 @@ func endSegment(t *txnData, thread *tracingThread, start segmentStartTime, now t
 
 // endBasicSegment ends a basic segment.
 func endBasicSegment(t *txnData, thread *tracingThread, start segmentStartTime, now time.Time, name string) error {
+	return endSampledBasicSegment(t, thread, start, now, name, 1)
+}
+
+// endSampledBasicSegment ends a basic segment which was recorded once in
+// every rate instances.  The segment's contribution to its metric is scaled by
+// the rate so that the metric approximates the unsampled totals.
+func endSampledBasicSegment(t *txnData, thread *tracingThread, start segmentStartTime, now time.Time, name string, rate int) error {
 	end, err := endSegment(t, thread, start, now)
 	if err != nil {
 		return err
@@ -512,6 +611,13 @@ func endBasicSegment(t *txnData, thread *tracingThread, start segmentStartTime,
 		t.customSegments = make(map[string]*metricData)
 	}
 	m := metricDataFromDuration(end.duration, end.exclusive)
+	if rate > 1 {
+		scale := float64(rate)
+		m.countSatisfied *= scale
+		m.totalTolerated *= scale
+		m.exclusiveFailed *= scale
+		m.sumSquares *= scale
+	}
 	if data, ok := t.customSegments[name]; ok {
 		data.aggregate(m)
 	} else {
@@ -549,6 +655,7 @@ type endExternalParams struct {
 	Library    string
 	Method     string
 	StatusCode *int
+	Timings    *externalTimings
 }
 
 // endExternalSegment ends an external segment.
@@ -621,6 +728,7 @@ func endExternalSegment(p endExternalParams) error {
 		if p.Library == "http" {
 			attributes.addString(SpanAttributeHTTPURL, safeURL(p.URL))
 		}
+		p.Timings.addAttributes(&attributes)
 		t.saveTraceSegment(end, key.scopedMetric(), attributes, transactionGUID)
 	}
 
@@ -629,15 +737,28 @@ func endExternalSegment(p endExternalParams) error {
 		evt.Category = spanCategoryHTTP
 		evt.Kind = "client"
 		evt.Component = p.Library
+		semconv := t.Attrs != nil && t.Attrs.config.addHTTPSemanticConventions()
 		if p.Library == "http" {
 			evt.AgentAttributes.addString(SpanAttributeHTTPURL, safeURL(p.URL))
 			evt.AgentAttributes.addString(SpanAttributeHTTPMethod, p.Method)
+			if semconv {
+				evt.AgentAttributes.addString(SpanAttributeURLFull, safeURL(p.URL))
+				evt.AgentAttributes.addString(AttributeHTTPRequestMethod, p.Method)
+			}
 		}
+		code := -1
 		if p.StatusCode != nil {
-			evt.AgentAttributes.addInt(SpanAttributeHTTPStatusCode, *p.StatusCode)
+			code = *p.StatusCode
 		} else if p.Response != nil {
-			evt.AgentAttributes.addInt(SpanAttributeHTTPStatusCode, p.Response.StatusCode)
+			code = p.Response.StatusCode
+		}
+		if code != -1 {
+			evt.AgentAttributes.addInt(SpanAttributeHTTPStatusCode, code)
+			if semconv {
+				evt.AgentAttributes.addInt(AttributeHTTPResponseStatusCode, code)
+			}
 		}
+		p.Timings.addAttributes(&evt.AgentAttributes)
 		t.saveSpanEvent(evt)
 	}
 
@@ -655,9 +776,19 @@ type endMessageParams struct {
 	Library         string
 	DestinationType string
 	DestinationTemp bool
+
+	// Consume, MessageTimestamp, and MessageCount are set for message
+	// consumer segments.
+	Consume          bool
+	MessageTimestamp time.Time
+	MessageCount     int
+
+	// Attributes are added to the span and the trace segment, such as the
+	// attributes describing the payload of the message.
+	Attributes spanAttributeMap
 }
 
-// endMessageSegment ends an external segment.
+// endMessageSegment ends a message producer or consumer segment.
 func endMessageSegment(p endMessageParams) error {
 	t := p.TxnData
 	end, err := endSegment(t, p.Thread, p.Start, p.Now)
@@ -670,6 +801,24 @@ func endMessageSegment(p endMessageParams) error {
 		DestinationType: p.DestinationType,
 		DestinationName: p.DestinationName,
 		DestinationTemp: p.DestinationTemp,
+		ConsumeSegment:  p.Consume,
+	}
+
+	var attributes spanAttributeMap
+	if p.Consume {
+		if !p.MessageTimestamp.IsZero() {
+			queueTime := end.start.Time.Sub(p.MessageTimestamp)
+			if queueTime < 0 {
+				queueTime = 0
+			}
+			attributes.addFloat(SpanAttributeMessageQueueTime, queueTime.Seconds())
+		}
+		if p.MessageCount > 0 {
+			attributes.addInt(SpanAttributeMessageCount, p.MessageCount)
+		}
+	}
+	for k, v := range p.Attributes {
+		attributes.add(k, v)
 	}
 
 	if t.messageSegments == nil {
@@ -687,13 +836,19 @@ func endMessageSegment(p endMessageParams) error {
 	}
 
 	if t.TxnTrace.considerNode(end) {
-		attributes := end.agentAttributes.copy()
-		t.saveTraceSegment(end, key.Name(), attributes, "")
+		segmentAttributes := end.agentAttributes.copy()
+		for k, v := range attributes {
+			segmentAttributes.add(k, v)
+		}
+		t.saveTraceSegment(end, key.Name(), segmentAttributes, "")
 	}
 
 	if evt := end.spanEvent(); evt != nil {
 		evt.Name = key.Name()
 		evt.Category = spanCategoryGeneric
+		for k, v := range attributes {
+			evt.AgentAttributes.add(k, v)
+		}
 		t.saveSpanEvent(evt)
 	}
 
@@ -715,6 +870,7 @@ type endDatastoreParams struct {
 	PortPathOrID       string
 	Database           string
 	ThisHost           string
+	ExplainPlan        *queryPlan
 }
 
 const (
@@ -818,6 +974,9 @@ func endDatastoreSegment(p endDatastoreParams) error {
 		if len(queryParams) > 0 {
 			attributes.add(spanAttributeQueryParameters, queryParams)
 		}
+		if nil != p.ExplainPlan {
+			attributes.add(segmentAttributeExplainPlan, p.ExplainPlan)
+		}
 		p.TxnData.saveTraceSegment(end, scopedMetric, attributes, "")
 	}
 
@@ -834,6 +993,7 @@ func endDatastoreSegment(p endDatastoreParams) error {
 			PortPathOrID:       p.PortPathOrID,
 			DatabaseName:       p.Database,
 			StackTrace:         getStackTrace(),
+			ExplainPlan:        p.ExplainPlan,
 		})
 	}
 
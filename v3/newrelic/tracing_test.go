@@ -221,6 +221,26 @@ func TestLostChildren(t *testing.T) {
 	})
 }
 
+func TestSampledSegmentMetrics(t *testing.T) {
+	start := time.Date(2014, time.November, 28, 1, 1, 0, 0, time.UTC)
+	txndata := &txnData{}
+	thread := &tracingThread{}
+
+	t1 := startSegment(txndata, thread, start.Add(1*time.Second))
+	endSampledBasicSegment(txndata, thread, t1, start.Add(2*time.Second), "hot", 10)
+	t2 := startSegment(txndata, thread, start.Add(3*time.Second))
+	endSampledBasicSegment(txndata, thread, t2, start.Add(5*time.Second), "hot", 10)
+
+	metrics := newMetricTable(100, time.Now())
+	txndata.FinalName = "WebTransaction/Go/zip"
+	txndata.IsWeb = true
+	mergeBreakdownMetrics(txndata, metrics)
+	expectMetrics(t, metrics, []internal.WantMetric{
+		{Name: "Custom/hot", Scope: "", Forced: false, Data: []float64{20, 30, 30, 1, 2, 50}},
+		{Name: "Custom/hot", Scope: txndata.FinalName, Forced: false, Data: []float64{20, 30, 30, 1, 2, 50}},
+	})
+}
+
 //                                          |-t3-|    |-t4-|
 //                           |-t2-|    |-never-finished----------
 //            |-t1-|    |--never-finished------------------------
@@ -388,6 +408,62 @@ func TestSegmentBasic(t *testing.T) {
 	})
 }
 
+func TestSegmentPauseResume(t *testing.T) {
+	start := time.Date(2014, time.November, 28, 1, 1, 0, 0, time.UTC)
+	txndata := &txnData{}
+	thread := &tracingThread{}
+
+	parent := startSegment(txndata, thread, start)
+	token := startSegment(txndata, thread, start.Add(1*time.Second))
+	// Pausing a paused segment and resuming a running segment have no
+	// effect.
+	if err := thread.resumeSegment(token, start.Add(2*time.Second)); err != nil {
+		t.Error(err)
+	}
+	if err := thread.pauseSegment(token, start.Add(2*time.Second)); err != nil {
+		t.Error(err)
+	}
+	if err := thread.pauseSegment(token, start.Add(3*time.Second)); err != nil {
+		t.Error(err)
+	}
+	if err := thread.resumeSegment(token, start.Add(4*time.Second)); err != nil {
+		t.Error(err)
+	}
+	child := startSegment(txndata, thread, start.Add(5*time.Second))
+	if _, err := endSegment(txndata, thread, child, start.Add(6*time.Second)); err != nil {
+		t.Error(err)
+	}
+	// The segment is ended while paused.
+	if err := thread.pauseSegment(token, start.Add(7*time.Second)); err != nil {
+		t.Error(err)
+	}
+	end, err := endSegment(txndata, thread, token, start.Add(9*time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if end.duration != 8*time.Second {
+		t.Error(end.duration)
+	}
+	if end.exclusive != 3*time.Second {
+		t.Error(end.exclusive)
+	}
+	if err := thread.pauseSegment(token, start.Add(10*time.Second)); err != errSegmentNotInProgress {
+		t.Error(err)
+	}
+
+	// The paused time is part of the duration of the parent.
+	end, err = endSegment(txndata, thread, parent, start.Add(10*time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if end.exclusive != 2*time.Second {
+		t.Error(end.exclusive)
+	}
+	if err := thread.resumeSegment(segmentStartTime{}, start); err != errMalformedSegment {
+		t.Error(err)
+	}
+}
+
 func parseURL(raw string) *url.URL {
 	u, _ := url.Parse(raw)
 	return u
@@ -4,6 +4,7 @@
 package newrelic
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -183,6 +184,112 @@ func (txn *Transaction) SetUserID(userID string) {
 	txn.thread.logAPIError(txn.thread.AddUserID(userID), "set user ID", nil)
 }
 
+// SetUser records the user of the transaction in the AttributeUserID
+// attribute, like SetUserID, so that the errors and spans of the transaction
+// can be tied to the users they impact.  The email address and name of the
+// user may also be recorded using WithUserEmail and WithUserName:
+//
+//	txn.SetUser(user.ID, newrelic.WithUserEmail(user.Email))
+//
+// The email address and name are personal information, so their attributes
+// are disabled by default and must be added to Config.Attributes.Include to
+// be recorded.  They are never recorded when high security mode is enabled.
+func (txn *Transaction) SetUser(id string, options ...UserOption) {
+	if txn == nil || txn.thread == nil {
+		return
+	}
+
+	user := userInfo{id: id}
+	for _, option := range options {
+		if option != nil {
+			option(&user)
+		}
+	}
+	txn.thread.logAPIError(txn.thread.AddUser(user), "set user", nil)
+}
+
+// SetMessageQueueTime records the time a consumed message spent in the broker
+// before being processed, as the message.queueTime attribute of the
+// transaction.  Call it in a transaction which consumes a message with the time
+// the message was added to the destination, which is often provided by the
+// broker as the timestamp of the message.  The queue time is the time between
+// the timestamp and the start of the transaction, or zero if the clock of the
+// broker is ahead.  Nothing is recorded for a zero timestamp.
+func (txn *Transaction) SetMessageQueueTime(enqueued time.Time) {
+	if txn == nil || txn.thread == nil || enqueued.IsZero() {
+		return
+	}
+	txn.thread.logAPIError(txn.thread.SetMessageQueueTime(enqueued), "set message queue time", nil)
+}
+
+// SetSamplingPriority raises the priority of the transaction by boost, a value
+// between 0 and 1, so that business critical transactions, such as payments
+// or signups, are preferentially kept when more events or traces are recorded
+// than can be sent.  The priority is used to choose the transaction, error,
+// span, and log events kept for each harvest and, with distributed tracing,
+// is used by the sampler and propagated to the downstream services.  A boost
+// of 1 keeps the transaction over the transactions without a boost.  Calling
+// it again replaces the boost.  The sampling decision is not changed once it
+// has been made, so call it early in the transaction, before starting
+// external segments.
+func (txn *Transaction) SetSamplingPriority(boost float32) {
+	if txn == nil || txn.thread == nil {
+		return
+	}
+	txn.thread.logAPIError(txn.thread.SetSamplingPriority(boost), "set sampling priority", map[string]interface{}{
+		"boost": boost,
+	})
+}
+
+// SetMessagePayload records the key, the headers, and a snippet of the body of
+// the message consumed by the transaction, when Config.MessagePayloads is
+// enabled, as the AttributeMessageKey, AttributeMessageHeaders,
+// AttributeMessagePayload, and AttributeMessagePayloadSize attributes.  The
+// message is first passed to Config.MessagePayloads.Redactor, if set.
+func (txn *Transaction) SetMessagePayload(p MessagePayload) {
+	if txn == nil || txn.thread == nil {
+		return
+	}
+	txn.thread.logAPIError(txn.thread.SetMessagePayload(p), "set message payload", nil)
+}
+
+// AddBreadcrumb records a short note describing something that happened
+// during the transaction, such as a cache miss or a retried call.  The most
+// recent breadcrumbs are kept with the transaction and attached to any error
+// or transaction trace captured for it, making it easier to see what led up
+// to a failure.  Only the last 10 breadcrumbs are retained.
+//
+// The attrs map follows the same rules as AddAttribute: keys and string
+// values are limited to 255 bytes, and values must be a string, bool, or
+// numeric type.  Breadcrumbs are disabled when high security mode is enabled
+// and may be excluded using the "breadcrumbs" attribute name in the
+// attribute configuration.
+func (txn *Transaction) AddBreadcrumb(category, message string, attrs map[string]interface{}) {
+	if txn == nil || txn.thread == nil {
+		return
+	}
+	txn.thread.logAPIError(txn.thread.AddBreadcrumb(category, message, attrs), "add breadcrumb", nil)
+}
+
+// AddTiming records a named sub-timing of the transaction, such as the time
+// spent authenticating, fetching, or rendering a request.  Timings are a
+// cheap alternative to segments for coarse phase breakdowns: they are
+// recorded on the transaction event as "timing.<name>" attributes, in
+// seconds, and do not appear in transaction traces or span events.
+//
+// Durations added with the same name are summed.  Up to 16 distinct names
+// are recorded per transaction.
+//
+//	start := time.Now()
+//	user, err := authenticate(r)
+//	txn.AddTiming("auth", time.Since(start))
+func (txn *Transaction) AddTiming(name string, duration time.Duration) {
+	if txn == nil || txn.thread == nil {
+		return
+	}
+	txn.thread.logAPIError(txn.thread.AddTiming(name, duration), "add timing", nil)
+}
+
 // RecordLog records the data from a single log line.
 // This consumes a LogData object that should be configured
 // with data taken from a logging framework.
@@ -210,13 +317,40 @@ func (txn *Transaction) RecordLog(log LogData) {
 // the request is non-nil, SetWebRequestHTTP will additionally collect
 // details on request attributes, url, and method.  If headers are
 // present, the agent will look for distributed tracing headers using
-// Transaction.AcceptDistributedTraceHeaders.
+// Transaction.AcceptDistributedTraceHeaders.  The request headers are copied
+// when SetWebRequestHTTP is called, and are not used after it returns.
 func (txn *Transaction) SetWebRequestHTTP(r *http.Request) {
 	if r == nil {
 		txn.SetWebRequest(WebRequest{})
 		return
 	}
-	wr := WebRequest{
+	txn.SetWebRequest(webRequestHTTP(r))
+}
+
+// SetWebRequestHTTPClone is SetWebRequestHTTP for callers which modify the
+// request concurrently: the headers, including their values, and the URL
+// are deep copied, so that the agent never shares any part of them with the
+// caller.
+func (txn *Transaction) SetWebRequestHTTPClone(r *http.Request) {
+	if r == nil {
+		txn.SetWebRequest(WebRequest{})
+		return
+	}
+	wr := webRequestHTTP(r)
+	wr.Header = r.Header.Clone()
+	if r.URL != nil {
+		u := *r.URL
+		if u.User != nil {
+			user := *u.User
+			u.User = &user
+		}
+		wr.URL = &u
+	}
+	txn.setWebRequest(wr)
+}
+
+func webRequestHTTP(r *http.Request) WebRequest {
+	return WebRequest{
 		Header:        r.Header,
 		URL:           r.URL,
 		Method:        r.Method,
@@ -227,7 +361,6 @@ func (txn *Transaction) SetWebRequestHTTP(r *http.Request) {
 		Type:          "HTTP",
 		RemoteAddress: r.RemoteAddr,
 	}
-	txn.SetWebRequest(wr)
 }
 
 func transport(r *http.Request) TransportType {
@@ -263,8 +396,18 @@ func reqBody(req *http.Request) *BodyBuffer {
 // additionally collects details on request attributes, url, and method if
 // these fields are set.  If headers are present, the agent will look for
 // distributed tracing headers using Transaction.AcceptDistributedTraceHeaders.
-// Use Transaction.SetWebRequestHTTP if you have a *http.Request.
+// Use Transaction.SetWebRequestHTTP if you have a *http.Request.  The header
+// map is copied when SetWebRequest is called, and is not used after it
+// returns.
 func (txn *Transaction) SetWebRequest(r WebRequest) {
+	if txn == nil || txn.thread == nil {
+		return
+	}
+	r.Header = snapshotHeader(r.Header)
+	txn.setWebRequest(r)
+}
+
+func (txn *Transaction) setWebRequest(r WebRequest) {
 	if txn == nil || txn.thread == nil {
 		return
 	}
@@ -334,6 +477,26 @@ func (txn *Transaction) StartSegment(name string) *Segment {
 	}
 }
 
+// StartSegmentWithContext is StartSegment for code which runs under a context,
+// typically one with a deadline.  The span of the segment records the time
+// which remained before the deadline of the context when the segment started
+// in the "context.deadline.remaining" attribute, and the error of the context
+// in the "context.error" attribute if it was canceled or its deadline was
+// exceeded when the segment ended.  If Config.SpanEvents.ContextErrors is
+// enabled, such spans are also marked as errors.
+//
+//	segment := txn.StartSegmentWithContext(ctx, "callInventory")
+//	resp, err := inventory.Get(ctx, req)
+//	segment.End()
+//
+// Use WithContext to record the same attributes on the root span of a
+// transaction.
+func (txn *Transaction) StartSegmentWithContext(ctx context.Context, name string) *Segment {
+	s := txn.StartSegment(name)
+	s.ctx = ctx
+	return s
+}
+
 // InsertDistributedTraceHeaders adds the Distributed Trace headers used to
 // link transactions.  InsertDistributedTraceHeaders should be called every
 // time an outbound call is made since the payload contains a timestamp.
@@ -370,6 +533,7 @@ func (txn *Transaction) AcceptDistributedTraceHeaders(t TransportType, hdrs http
 	if txn == nil || txn.thread == nil {
 		return
 	}
+	hdrs = snapshotHeader(hdrs)
 	txn.thread.logAPIError(txn.thread.AcceptDistributedTraceHeaders(t, hdrs), "accept trace payload", nil)
 }
 
@@ -600,6 +764,16 @@ func (tt TransportType) toString() string {
 	}
 }
 
+// isMessageQueue returns whether the transport type is a message queue.
+func (tt TransportType) isMessageQueue() bool {
+	switch tt {
+	case TransportKafka, TransportJMS, TransportIronMQ, TransportAMQP, TransportQueue:
+		return true
+	default:
+		return false
+	}
+}
+
 // WebRequest is used to provide request information to Transaction.SetWebRequest.
 type WebRequest struct {
 	// Header may be nil if you don't have any headers or don't want to
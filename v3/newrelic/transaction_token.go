@@ -0,0 +1,87 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package newrelic
+
+import "context"
+
+// TransactionToken identifies a transaction so that it can be used in other
+// goroutines.  Unlike a *Transaction, a TransactionToken may be copied, stored
+// in a context, and passed to any number of goroutines, each of which resumes
+// the transaction using Application.ResumeTransaction:
+//
+//	token := txn.Token()
+//	for _, item := range items {
+//		go func(item Item) {
+//			txn := app.ResumeTransaction(token)
+//			defer txn.StartSegment("process").End()
+//			process(item)
+//		}(item)
+//	}
+//
+// ResumeTransaction calls NewGoroutine, so that goroutines never share a
+// *Transaction.  The zero value is a valid token which resumes no
+// transaction.
+type TransactionToken struct {
+	txn *txn
+}
+
+// Token returns the token of the transaction.  It returns the zero value if
+// the transaction is nil.
+func (txn *Transaction) Token() TransactionToken {
+	if txn == nil || txn.thread == nil {
+		return TransactionToken{}
+	}
+	return TransactionToken{txn: txn.thread.txn}
+}
+
+// IsZero returns true if the token does not identify a transaction.
+func (token TransactionToken) IsZero() bool {
+	return token.txn == nil
+}
+
+// ResumeTransaction returns a Transaction reference for use in the current
+// goroutine for the transaction of the token, as returned by
+// Transaction.NewGoroutine.  It returns nil if the token is the zero value or
+// belongs to a transaction of another application.  Call it in each
+// goroutine using the transaction, rather than passing the *Transaction
+// returned between goroutines.  Like the transaction returned by
+// NewGoroutine, the transaction returned has no effect if the transaction has
+// ended.
+func (app *Application) ResumeTransaction(token TransactionToken) *Transaction {
+	if app == nil || app.app == nil || token.txn == nil || token.txn.app != app.app {
+		return nil
+	}
+	// The reference to the main thread is only used to create a new
+	// thread.
+	main := newTransaction(&thread{txn: token.txn, thread: &token.txn.mainThread})
+	txn := main.NewGoroutine()
+	if token.txn.Config.GoroutineTransactions.Enabled {
+		txn.thread.bindGoroutine(txn, currentGoroutineID())
+	}
+	return txn
+}
+
+type transactionTokenKey struct{}
+
+// NewTokenContext returns a new context.Context that carries the transaction
+// token.  Resume the transaction from the context using
+// Application.ResumeTransaction and TokenFromContext:
+//
+//	txn := app.ResumeTransaction(newrelic.TokenFromContext(ctx))
+func NewTokenContext(ctx context.Context, token TransactionToken) context.Context {
+	return context.WithValue(ctx, transactionTokenKey{}, token)
+}
+
+// TokenFromContext returns the transaction token from the context.  If the
+// context carries no token, it returns the token of the transaction added to
+// the context using NewContext, or the zero value.
+func TokenFromContext(ctx context.Context) TransactionToken {
+	if ctx == nil {
+		return TransactionToken{}
+	}
+	if token, ok := ctx.Value(transactionTokenKey{}).(TransactionToken); ok {
+		return token
+	}
+	return FromContext(ctx).Token()
+}
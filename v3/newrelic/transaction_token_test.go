@@ -0,0 +1,69 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package newrelic
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/newrelic/go-agent/v3/internal"
+)
+
+func TestResumeTransaction(t *testing.T) {
+	app := testApp(nil, ConfigDistributedTracerEnabled(false), t)
+	txn := app.StartTransaction("hello")
+	ctx := NewTokenContext(context.Background(), txn.Token())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			txn := app.ResumeTransaction(TokenFromContext(ctx))
+			txn.StartSegment("async").End()
+		}()
+	}
+	wg.Wait()
+	txn.End()
+
+	app.expectNoLoggedErrors(t)
+	app.ExpectMetrics(t, []internal.WantMetric{
+		{Name: "OtherTransaction/Go/hello", Scope: "", Forced: true, Data: nil},
+		{Name: "OtherTransaction/all", Scope: "", Forced: true, Data: nil},
+		{Name: "OtherTransactionTotalTime", Scope: "", Forced: true, Data: nil},
+		{Name: "OtherTransactionTotalTime/Go/hello", Scope: "", Forced: false, Data: nil},
+		{Name: "Custom/async", Scope: "", Forced: false, Data: nil},
+		{Name: "Custom/async", Scope: "OtherTransaction/Go/hello", Forced: false, Data: nil},
+	})
+
+	// Resuming a transaction which has ended is safe.
+	app.ResumeTransaction(txn.Token()).StartSegment("late").End()
+}
+
+func TestResumeTransactionInvalidToken(t *testing.T) {
+	app := testApp(nil, nil, t)
+	other := testApp(nil, nil, t)
+	txn := other.StartTransaction("hello")
+	defer txn.End()
+
+	if resumed := app.ResumeTransaction(txn.Token()); resumed != nil {
+		t.Error("transaction of another application resumed")
+	}
+	var token TransactionToken
+	if !token.IsZero() || app.ResumeTransaction(token) != nil {
+		t.Error("zero token resumed a transaction")
+	}
+	var nilTxn *Transaction
+	if !nilTxn.Token().IsZero() {
+		t.Error("nil transaction has a token")
+	}
+	if !TokenFromContext(context.Background()).IsZero() {
+		t.Error("empty context has a token")
+	}
+	ctx := NewContext(context.Background(), txn)
+	if TokenFromContext(ctx) != txn.Token() {
+		t.Error("token missing from transaction context")
+	}
+}
@@ -7,7 +7,6 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"time"
 
 	"github.com/newrelic/go-agent/v3/internal"
 	"github.com/newrelic/go-agent/v3/internal/cat"
@@ -81,7 +80,7 @@ func (txp *txnCrossProcess) Init(enabled bool, dt bool, reply *internal.ConnectR
 	txp.CrossProcessID = []byte(reply.CrossProcessID)
 	txp.EncodingKey = []byte(reply.EncodingKey)
 	txp.DistributedTracingEnabled = dt
-	txp.Enabled = enabled
+	txp.Enabled = enabled && crossAppTracingIncluded
 	txp.TrustedAccounts = reply.TrustedAccounts
 }
 
@@ -99,15 +98,7 @@ func (txp *txnCrossProcess) CreateCrossProcessMetadata(txnName, appName string)
 	}
 
 	if txp.Enabled {
-		txp.SetOutbound(true)
-		txp.requireTripID()
-
-		id, err := txp.outboundID()
-		if err != nil {
-			return metadata, err
-		}
-
-		txnData, err := txp.outboundTxnData(txnName, appName)
+		id, txnData, err := txp.outboundCAT(txnName, appName)
 		if err != nil {
 			return metadata, err
 		}
@@ -153,56 +144,6 @@ func (txp *txnCrossProcess) IsSynthetics() bool {
 	return (txp.Type&txnCrossProcessSynthetics) != 0 && txp.Synthetics != nil
 }
 
-// ParseAppData decodes the given appData value.
-func (txp *txnCrossProcess) ParseAppData(encodedAppData string) (*cat.AppDataHeader, error) {
-	if !txp.Enabled {
-		return nil, nil
-	}
-	if encodedAppData != "" {
-		rawAppData, err := deobfuscate(encodedAppData, txp.EncodingKey)
-		if err != nil {
-			return nil, err
-		}
-
-		appData := &cat.AppDataHeader{}
-		if err := json.Unmarshal(rawAppData, appData); err != nil {
-			return nil, err
-		}
-
-		return appData, nil
-	}
-
-	return nil, nil
-}
-
-// CreateAppData creates the appData value that should be sent with a response
-// to ensure CAT operates as expected.
-func (txp *txnCrossProcess) CreateAppData(name string, queueTime, responseTime time.Duration, contentLength int64) (string, error) {
-	// If CAT is disabled, do nothing, successfully.
-	if !txp.Enabled {
-		return "", nil
-	}
-
-	data, err := json.Marshal(&cat.AppDataHeader{
-		CrossProcessID:        string(txp.CrossProcessID),
-		TransactionName:       name,
-		QueueTimeInSeconds:    queueTime.Seconds(),
-		ResponseTimeInSeconds: responseTime.Seconds(),
-		ContentLength:         contentLength,
-		TransactionGUID:       txp.GUID,
-	})
-	if err != nil {
-		return "", err
-	}
-
-	obfuscated, err := obfuscate(data, txp.EncodingKey)
-	if err != nil {
-		return "", err
-	}
-
-	return obfuscated, nil
-}
-
 // Used returns true if any CAT or Synthetics related functionality has been
 // triggered on the transaction.
 func (txp *txnCrossProcess) Used() bool {
@@ -265,60 +206,6 @@ func (txp *txnCrossProcess) handleInboundRequestHeaders(metadata crossProcessMet
 	return nil
 }
 
-func (txp *txnCrossProcess) handleInboundRequestEncodedCAT(encodedID, encodedTxnData string) error {
-	rawID, err := deobfuscate(encodedID, txp.EncodingKey)
-	if err != nil {
-		return err
-	}
-
-	rawTxnData, err := deobfuscate(encodedTxnData, txp.EncodingKey)
-	if err != nil {
-		return err
-	}
-
-	if err := txp.handleInboundRequestID(rawID); err != nil {
-		return err
-	}
-
-	return txp.handleInboundRequestTxnData(rawTxnData)
-}
-
-func (txp *txnCrossProcess) handleInboundRequestID(raw []byte) error {
-	id, err := cat.NewIDHeader(raw)
-	if err != nil {
-		return err
-	}
-
-	if !txp.TrustedAccounts.IsTrusted(id.AccountID) {
-		return errAccountNotTrusted
-	}
-
-	txp.SetInbound(true)
-	txp.ClientID = string(raw)
-	txp.setRequireGUID()
-
-	return nil
-}
-
-func (txp *txnCrossProcess) handleInboundRequestTxnData(raw []byte) error {
-	txnData := &cat.TxnDataHeader{}
-	if err := json.Unmarshal(raw, txnData); err != nil {
-		return err
-	}
-
-	txp.SetInbound(true)
-	if txnData.TripID != "" {
-		txp.TripID = txnData.TripID
-	} else {
-		txp.setRequireGUID()
-		txp.TripID = txp.GUID
-	}
-	txp.ReferringTxnGUID = txnData.GUID
-	txp.ReferringPathHash = txnData.PathHash
-
-	return nil
-}
-
 func (txp *txnCrossProcess) handleInboundRequestEncodedSynthetics(encoded string) error {
 	raw, err := deobfuscate(encoded, txp.EncodingKey)
 	if err != nil {
@@ -381,28 +268,6 @@ func (txp *txnCrossProcess) handleInboundRequestSyntheticsInfo(raw []byte) error
 	return nil
 }
 
-func (txp *txnCrossProcess) outboundID() (string, error) {
-	return obfuscate(txp.CrossProcessID, txp.EncodingKey)
-}
-
-func (txp *txnCrossProcess) outboundTxnData(txnName, appName string) (string, error) {
-	pathHash, err := txp.setPathHash(txnName, appName)
-	if err != nil {
-		return "", err
-	}
-
-	data, err := json.Marshal(&cat.TxnDataHeader{
-		GUID:     txp.GUID,
-		TripID:   txp.TripID,
-		PathHash: pathHash,
-	})
-	if err != nil {
-		return "", err
-	}
-
-	return obfuscate(data, txp.EncodingKey)
-}
-
 // setRequireGUID ensures that the transaction has a valid GUID, and sets the
 // nr.guid and trip ID if they are not already set.  If the customer has enabled
 // DistributedTracing, then the new style of guid will be set elsewhere.
@@ -434,31 +299,3 @@ func (txp *txnCrossProcess) requireTripID() {
 	txp.setRequireGUID()
 	txp.TripID = txp.GUID
 }
-
-// setPathHash generates a path hash, sets the transaction's path hash to
-// match, and returns it. This function will also ensure that the alternate
-// path hashes are correctly updated.
-func (txp *txnCrossProcess) setPathHash(txnName, appName string) (string, error) {
-	pathHash, err := cat.GeneratePathHash(txp.ReferringPathHash, txnName, appName)
-	if err != nil {
-		return "", err
-	}
-
-	if pathHash != txp.PathHash {
-		if txp.PathHash != "" {
-			// Lazily initialise the alternate path hashes if they haven't been
-			// already.
-			if txp.AlternatePathHashes == nil {
-				txp.AlternatePathHashes = make(map[string]bool)
-			}
-
-			// The spec limits us to a maximum of 10 alternate path hashes.
-			if len(txp.AlternatePathHashes) < 10 {
-				txp.AlternatePathHashes[txp.PathHash] = true
-			}
-		}
-		txp.PathHash = pathHash
-	}
-
-	return pathHash, nil
-}
@@ -0,0 +1,45 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build newrelic_nocat || newrelic_minimal
+// +build newrelic_nocat newrelic_minimal
+
+package newrelic
+
+import (
+	"time"
+
+	"github.com/newrelic/go-agent/v3/internal/cat"
+)
+
+// crossAppTracingIncluded records whether Cross Application Tracing is
+// included in the build.  When it is excluded by the newrelic_nocat or the
+// newrelic_minimal build tag, CrossApplicationTracer.Enabled is always false,
+// whatever the local and server-side configuration, and the CAT headers are
+// neither read nor written.  Synthetics, which share the headers of Cross
+// Application Tracing, are still supported.
+const crossAppTracingIncluded = false
+
+// ParseAppData returns no app data when Cross Application Tracing is
+// excluded from the build.
+func (txp *txnCrossProcess) ParseAppData(encodedAppData string) (*cat.AppDataHeader, error) {
+	return nil, nil
+}
+
+// CreateAppData returns no app data when Cross Application Tracing is
+// excluded from the build.
+func (txp *txnCrossProcess) CreateAppData(name string, queueTime, responseTime time.Duration, contentLength int64) (string, error) {
+	return "", nil
+}
+
+func (txp *txnCrossProcess) handleInboundRequestEncodedCAT(encodedID, encodedTxnData string) error {
+	return nil
+}
+
+func (txp *txnCrossProcess) outboundCAT(txnName, appName string) (string, string, error) {
+	return "", "", nil
+}
+
+func (txp *txnCrossProcess) setPathHash(txnName, appName string) (string, error) {
+	return "", nil
+}
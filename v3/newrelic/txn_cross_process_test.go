@@ -101,6 +101,10 @@ func (req *request) withSyntheticsInfo(initiator, synthType, encodingKey string)
 }
 
 func TestTxnCrossProcessInit(t *testing.T) {
+	if !crossAppTracingIncluded {
+		t.Skip("Cross Application Tracing is excluded from the build")
+	}
+
 	for _, tc := range []struct {
 		name          string
 		enabled       bool
@@ -216,6 +220,10 @@ func TestTxnCrossProcessInit(t *testing.T) {
 }
 
 func TestTxnCrossProcessCreateCrossProcessMetadata(t *testing.T) {
+	if !crossAppTracingIncluded {
+		t.Skip("Cross Application Tracing is excluded from the build")
+	}
+
 	for _, tc := range []struct {
 		name             string
 		enabled          bool
@@ -354,6 +362,10 @@ func TestTxnCrossProcessCreateCrossProcessMetadata(t *testing.T) {
 }
 
 func TestTxnCrossProcessCreateCrossProcessMetadataError(t *testing.T) {
+	if !crossAppTracingIncluded {
+		t.Skip("Cross Application Tracing is excluded from the build")
+	}
+
 	// Ensure errors bubble back up from deeper within our obfuscation code.
 	// It's likely impossible to get outboundTxnData() to fail, but we can get
 	// outboundID() to fail by having an empty encoding key.
@@ -389,6 +401,10 @@ func TestTxnCrossProcessCreateCrossProcessMetadataError(t *testing.T) {
 }
 
 func TestTxnCrossProcessFinalise(t *testing.T) {
+	if !crossAppTracingIncluded {
+		t.Skip("Cross Application Tracing is excluded from the build")
+	}
+
 	// No CAT.
 	txp := &txnCrossProcess{}
 	txp.Init(true, false, replyAccountOne)
@@ -604,6 +620,10 @@ func TestTxnCrossProcessSetSynthetics(t *testing.T) {
 }
 
 func TestTxnCrossProcessParseAppData(t *testing.T) {
+	if !crossAppTracingIncluded {
+		t.Skip("Cross Application Tracing is excluded from the build")
+	}
+
 	for _, tc := range []struct {
 		name            string
 		encodingKey     string
@@ -674,6 +694,10 @@ func TestTxnCrossProcessParseAppData(t *testing.T) {
 }
 
 func TestTxnCrossProcessCreateAppData(t *testing.T) {
+	if !crossAppTracingIncluded {
+		t.Skip("Cross Application Tracing is excluded from the build")
+	}
+
 	for _, tc := range []struct {
 		name            string
 		enabled         bool
@@ -749,6 +773,10 @@ func TestTxnCrossProcessCreateAppData(t *testing.T) {
 }
 
 func TestTxnCrossProcessHandleInboundRequestHeaders(t *testing.T) {
+	if !crossAppTracingIncluded {
+		t.Skip("Cross Application Tracing is excluded from the build")
+	}
+
 	for _, tc := range []struct {
 		name          string
 		enabled       bool
@@ -31,6 +31,9 @@ func (e *txnEvent) WriteJSON(buf *bytes.Buffer) {
 	// https://source.datanerd.us/agents/agent-specs/blob/master/Total-Time-Async.md#attributes
 	w.floatField("totalTime", e.TotalTime.Seconds())
 
+	// Timings are only recorded on transaction events.
+	e.Timings.writeIntrinsics(&w)
+
 	// Write better CAT intrinsics if enabled
 	sharedBetterCATIntrinsics(e, &w)
 
@@ -0,0 +1,61 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package newrelic
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// txnNamePatterns matches the transaction names listed in
+// Config.IgnoredTransactionNames.
+type txnNamePatterns []*regexp.Regexp
+
+// compileTxnNamePatterns compiles the patterns of
+// Config.IgnoredTransactionNames.  Patterns surrounded by slashes are regular
+// expressions, and all others are globs in which "*" matches any sequence of
+// characters and "?" matches any single character.
+func compileTxnNamePatterns(patterns []string) (txnNamePatterns, error) {
+	var compiled txnNamePatterns
+	for _, p := range patterns {
+		var expr string
+		if len(p) > 1 && strings.HasPrefix(p, "/") && strings.HasSuffix(p, "/") {
+			expr = p[1 : len(p)-1]
+		} else {
+			expr = "^" + globToRegexp(p) + "$"
+		}
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ignored transaction name %q: %v", p, err)
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
+func globToRegexp(glob string) string {
+	quoted := regexp.QuoteMeta(glob)
+	quoted = strings.ReplaceAll(quoted, `\*`, ".*")
+	return strings.ReplaceAll(quoted, `\?`, ".")
+}
+
+func (ps txnNamePatterns) match(name string) bool {
+	for _, re := range ps {
+		if re.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// ignoredByName returns true if the transaction, named as it would be if it
+// ended now, matches Config.IgnoredTransactionNames.
+func (txn *txn) ignoredByName() bool {
+	if len(txn.Config.ignoredTxnNames) == 0 {
+		return false
+	}
+	name := txn.appRun.createTransactionName(txn.Name, txn.IsWeb)
+	return name != "" && txn.Config.ignoredTxnNames.match(name)
+}
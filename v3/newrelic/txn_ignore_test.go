@@ -0,0 +1,101 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package newrelic
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/newrelic/go-agent/v3/internal"
+)
+
+func TestCompileTxnNamePatterns(t *testing.T) {
+	patterns, err := compileTxnNamePatterns([]string{
+		"WebTransaction/Go/GET /healthz",
+		"*/readyz",
+		"OtherTransaction/Go/job-?",
+		"/^WebTransaction/Go/GET /(live|status)z$/",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		name  string
+		match bool
+	}{
+		{"WebTransaction/Go/GET /healthz", true},
+		{"WebTransaction/Go/GET /healthz/deep", false},
+		{"WebTransaction/Go/GET /readyz", true},
+		{"OtherTransaction/Go/job-1", true},
+		{"OtherTransaction/Go/job-12", false},
+		{"WebTransaction/Go/GET /livez", true},
+		{"WebTransaction/Go/GET /statusz", true},
+		{"WebTransaction/Go/GET /users", false},
+		{"WebTransaction/Go/GET /healthzz", false},
+	} {
+		if got := patterns.match(tc.name); got != tc.match {
+			t.Errorf("match(%q) = %t, want %t", tc.name, got, tc.match)
+		}
+	}
+}
+
+func TestCompileTxnNamePatternsInvalid(t *testing.T) {
+	if _, err := compileTxnNamePatterns([]string{"/(unclosed/"}); err == nil {
+		t.Error("invalid regular expression compiled")
+	}
+	_, err := NewApplication(
+		ConfigAppName(sampleAppName),
+		ConfigLicense(testLicenseKey),
+		ConfigIgnoreTransactions("/(unclosed/"),
+	)
+	if err == nil {
+		t.Error("application created with invalid ignored transaction name")
+	}
+}
+
+func TestIgnoreTransactionsByName(t *testing.T) {
+	app := testApp(nil, ConfigIgnoreTransactions("*/healthz"), t)
+	req, _ := http.NewRequest("GET", "/healthz", nil)
+	txn := app.StartTransaction("GET /healthz")
+	txn.SetWebRequestHTTP(req)
+	txn.NoticeError(myError{})
+	txn.End()
+	app.expectNoLoggedErrors(t)
+	app.ExpectErrors(t, []internal.WantError{})
+	app.ExpectErrorEvents(t, []internal.WantEvent{})
+	app.ExpectMetrics(t, []internal.WantMetric{})
+	app.ExpectTxnEvents(t, []internal.WantEvent{})
+}
+
+func TestIgnoreTransactionsByNameNoMatch(t *testing.T) {
+	app := testApp(nil, func(cfg *Config) {
+		cfg.DistributedTracer.Enabled = false
+		cfg.IgnoredTransactionNames = []string{"*/healthz"}
+	}, t)
+	txn := app.StartTransaction("hello")
+	txn.End()
+	app.expectNoLoggedErrors(t)
+	app.ExpectTxnEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"name": "OtherTransaction/Go/hello",
+		},
+	}})
+}
+
+func TestIgnoreTransactionsByNameNotConsumingAdaptiveSampler(t *testing.T) {
+	a := testApp(distributedTracingReplyFields, func(cfg *Config) {
+		enableBetterCAT(cfg)
+		cfg.IgnoredTransactionNames = []string{"OtherTransaction/Go/probe"}
+	}, t)
+	txn := a.StartTransaction("probe")
+	if txn.IsSampled() {
+		t.Error("ignored transaction sampled")
+	}
+	txn.End()
+
+	run, _ := a.Application.Private.(*app).getState()
+	if n := run.adaptiveSampler.currentPeriod.numSeen; n != 0 {
+		t.Error("adaptive sampler consulted", n)
+	}
+}
@@ -0,0 +1,177 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package newrelic
+
+import (
+	"strings"
+	"sync"
+)
+
+const (
+	defaultMaxTxnNames           = 2000
+	defaultMaxTxnNamesPerPattern = 100
+)
+
+const (
+	txnNameCollapsedMetric = "Supportability/Go/TransactionNameCardinality/Collapsed"
+	txnNameOtherMetric     = "Supportability/Go/TransactionNameCardinality/Other"
+
+	// txnNamePatternMetricPrefix is followed by the parameterized name of
+	// transactions which were collapsed.  The number of these metrics is
+	// bounded, since a pattern is only collapsed once MaxNamesPerPattern of
+	// the MaxNames unique names share it.
+	txnNamePatternMetricPrefix = txnNameCollapsedMetric + "/"
+
+	// txnNameOther replaces the part of the transaction name following the
+	// prefix, such as "WebTransaction/Go/", when there are too many names.
+	txnNameOther = "other"
+)
+
+// txnNamePattern counts the unique transaction names which share a
+// parameterized form.
+type txnNamePattern struct {
+	names     int
+	collapsed bool
+}
+
+// txnNameGuard limits the number of unique transaction names.  Names which
+// contain segments that look like identifiers, such as numbers, UUIDs, or
+// long hexadecimal strings, are collapsed into their parameterized form once
+// there are too many names with that form.  Once the total number of unique
+// names reaches the limit, transactions with new names are named "other".
+//
+// A txnNameGuard is shared by all of the transactions of an appRun.
+type txnNameGuard struct {
+	sync.Mutex
+	maxNames           int
+	maxNamesPerPattern int
+	logger             Logger
+	names              map[string]struct{}
+	patterns           map[string]*txnNamePattern
+}
+
+func newTxnNameGuard(cfg config) *txnNameGuard {
+	c := cfg.TransactionNameCardinality
+	if !c.Enabled || c.MaxNames <= 0 || c.MaxNamesPerPattern <= 0 {
+		return nil
+	}
+	return &txnNameGuard{
+		maxNames:           c.MaxNames,
+		maxNamesPerPattern: c.MaxNamesPerPattern,
+		logger:             cfg.Logger,
+		names:              make(map[string]struct{}),
+		patterns:           make(map[string]*txnNamePattern),
+	}
+}
+
+// guard returns the name to use for a transaction named name.  If the name was
+// replaced, the supportability metric to record is also returned.
+func (g *txnNameGuard) guard(name string) (string, string) {
+	if g == nil {
+		return name, ""
+	}
+	param := parameterizeTxnName(name)
+
+	g.Lock()
+	defer g.Unlock()
+
+	var pattern *txnNamePattern
+	if param != name {
+		pattern = g.patterns[param]
+		if pattern == nil && len(g.patterns) < g.maxNames {
+			pattern = &txnNamePattern{}
+			g.patterns[param] = pattern
+		}
+		if pattern != nil && pattern.collapsed {
+			return param, txnNameCollapsedMetric
+		}
+	}
+	if _, ok := g.names[name]; ok {
+		return name, ""
+	}
+	if pattern != nil && pattern.names >= g.maxNamesPerPattern {
+		pattern.collapsed = true
+		if g.logger != nil {
+			g.logger.Warn("transaction names collapsed due to high cardinality", map[string]interface{}{
+				"pattern": param,
+				"names":   pattern.names,
+			})
+		}
+		return param, txnNameCollapsedMetric
+	}
+	if len(g.names) >= g.maxNames {
+		return otherTxnName(name), txnNameOtherMetric
+	}
+	g.names[name] = struct{}{}
+	if pattern != nil {
+		pattern.names++
+	}
+	return name, ""
+}
+
+// otherTxnName replaces the part of the transaction name following its
+// prefix, such as "WebTransaction/Go/", with txnNameOther.
+func otherTxnName(name string) string {
+	first := strings.IndexByte(name, '/')
+	if first < 0 {
+		return txnNameOther
+	}
+	second := strings.IndexByte(name[first+1:], '/')
+	if second < 0 {
+		return name[:first+1] + txnNameOther
+	}
+	return name[:first+second+2] + txnNameOther
+}
+
+// parameterizeTxnName replaces the path segments of the transaction name
+// which look like identifiers with "*".
+func parameterizeTxnName(name string) string {
+	// Every identifier contains a digit.
+	if !strings.ContainsAny(name, "0123456789") {
+		return name
+	}
+	segments := strings.Split(name, "/")
+	changed := false
+	for i, segment := range segments {
+		if isIdentifierSegment(segment) {
+			segments[i] = "*"
+			changed = true
+		}
+	}
+	if !changed {
+		return name
+	}
+	return strings.Join(segments, "/")
+}
+
+// isIdentifierSegment returns true if the segment is a number, a UUID, or a
+// hexadecimal string of at least 16 characters containing a digit.
+func isIdentifierSegment(s string) bool {
+	if s == "" {
+		return false
+	}
+	digits, hex := 0, 0
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c >= '0' && c <= '9':
+			digits++
+		case c >= 'a' && c <= 'f', c >= 'A' && c <= 'F':
+			hex++
+		case c == '-' && len(s) == 36 && (i == 8 || i == 13 || i == 18 || i == 23):
+		default:
+			return false
+		}
+	}
+	switch {
+	case digits == len(s):
+		return true
+	case digits == 0:
+		return false
+	case len(s) == 36 && digits+hex == 32:
+		return true
+	default:
+		return digits+hex == len(s) && len(s) >= 16
+	}
+}
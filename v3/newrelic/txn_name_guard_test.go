@@ -0,0 +1,119 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package newrelic
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/newrelic/go-agent/v3/internal"
+)
+
+func TestParameterizeTxnName(t *testing.T) {
+	testcases := []struct {
+		input  string
+		expect string
+	}{
+		{input: "WebTransaction/Go/GET /users", expect: "WebTransaction/Go/GET /users"},
+		{input: "WebTransaction/Go/GET /users/123", expect: "WebTransaction/Go/GET /users/*"},
+		{input: "WebTransaction/Go/GET /users/123/orders/456", expect: "WebTransaction/Go/GET /users/*/orders/*"},
+		{input: "WebTransaction/Go/GET /v1/users", expect: "WebTransaction/Go/GET /v1/users"},
+		{input: "WebTransaction/Go/GET /items/6f1c2a3b-1d2e-4f5a-8b9c-0d1e2f3a4b5c", expect: "WebTransaction/Go/GET /items/*"},
+		{input: "WebTransaction/Go/GET /items/5f8d0d55b54764421b7156c3", expect: "WebTransaction/Go/GET /items/*"},
+		{input: "WebTransaction/Go/GET /items/deadbeefdeadbeef", expect: "WebTransaction/Go/GET /items/deadbeefdeadbeef"},
+		{input: "WebTransaction/Go/GET /items/abc123", expect: "WebTransaction/Go/GET /items/abc123"},
+		{input: "OtherTransaction/Go/job 42", expect: "OtherTransaction/Go/job 42"},
+	}
+	for _, tc := range testcases {
+		if actual := parameterizeTxnName(tc.input); actual != tc.expect {
+			t.Errorf("parameterizeTxnName(%q) = %q, expected %q", tc.input, actual, tc.expect)
+		}
+	}
+}
+
+func TestOtherTxnName(t *testing.T) {
+	testcases := []struct {
+		input  string
+		expect string
+	}{
+		{input: "WebTransaction/Go/GET /users", expect: "WebTransaction/Go/other"},
+		{input: "OtherTransaction/Go/job", expect: "OtherTransaction/Go/other"},
+		{input: "WebTransaction/Go", expect: "WebTransaction/other"},
+		{input: "job", expect: "other"},
+	}
+	for _, tc := range testcases {
+		if actual := otherTxnName(tc.input); actual != tc.expect {
+			t.Errorf("otherTxnName(%q) = %q, expected %q", tc.input, actual, tc.expect)
+		}
+	}
+}
+
+func TestTxnNameGuardCollapsesPattern(t *testing.T) {
+	cfg := config{Config: defaultConfig()}
+	cfg.TransactionNameCardinality.Enabled = true
+	cfg.TransactionNameCardinality.MaxNamesPerPattern = 2
+	g := newTxnNameGuard(cfg)
+
+	for i, expect := range []string{"/users/1", "/users/2", "/users/*", "/users/*"} {
+		name, metric := g.guard("WebTransaction/Go/GET /users/" + strconv.Itoa(i+1))
+		if name != "WebTransaction/Go/GET "+expect {
+			t.Errorf("transaction %d: incorrect name %q", i, name)
+		}
+		if collapsed := expect == "/users/*"; collapsed != (metric == txnNameCollapsedMetric) {
+			t.Errorf("transaction %d: incorrect metric %q", i, metric)
+		}
+	}
+	// Names seen before the pattern was collapsed are collapsed too.
+	if name, _ := g.guard("WebTransaction/Go/GET /users/1"); name != "WebTransaction/Go/GET /users/*" {
+		t.Errorf("incorrect name %q", name)
+	}
+	if name, metric := g.guard("WebTransaction/Go/GET /orders/1"); name != "WebTransaction/Go/GET /orders/1" || metric != "" {
+		t.Errorf("incorrect name %q or metric %q", name, metric)
+	}
+}
+
+func TestTxnNameGuardOther(t *testing.T) {
+	cfg := config{Config: defaultConfig()}
+	cfg.TransactionNameCardinality.Enabled = true
+	cfg.TransactionNameCardinality.MaxNames = 2
+	g := newTxnNameGuard(cfg)
+
+	for _, input := range []string{"WebTransaction/Go/a", "WebTransaction/Go/b", "WebTransaction/Go/a"} {
+		if name, metric := g.guard(input); name != input || metric != "" {
+			t.Errorf("incorrect name %q or metric %q", name, metric)
+		}
+	}
+	if name, metric := g.guard("WebTransaction/Go/c"); name != "WebTransaction/Go/other" || metric != txnNameOtherMetric {
+		t.Errorf("incorrect name %q or metric %q", name, metric)
+	}
+}
+
+func TestTxnNameGuardDisabled(t *testing.T) {
+	cfg := config{Config: defaultConfig()}
+	if g := newTxnNameGuard(cfg); g != nil {
+		t.Error("guard should be disabled by default")
+	}
+	var g *txnNameGuard
+	if name, metric := g.guard("WebTransaction/Go/GET /users/1"); name != "WebTransaction/Go/GET /users/1" || metric != "" {
+		t.Errorf("incorrect name %q or metric %q", name, metric)
+	}
+}
+
+func TestTxnNameCardinalityMetrics(t *testing.T) {
+	app := testApp(nil, func(cfg *Config) {
+		cfg.TransactionNameCardinality.Enabled = true
+		cfg.TransactionNameCardinality.MaxNamesPerPattern = 1
+	}, t)
+	app.StartTransaction("job 1").End()
+	app.StartTransaction("job/2").End()
+	app.StartTransaction("job/3").End()
+
+	app.ExpectMetricsPresent(t, []internal.WantMetric{
+		{Name: "OtherTransaction/Go/job 1", Scope: "", Forced: true, Data: nil},
+		{Name: "OtherTransaction/Go/job/2", Scope: "", Forced: true, Data: nil},
+		{Name: "OtherTransaction/Go/job/*", Scope: "", Forced: true, Data: nil},
+		{Name: "Supportability/Go/TransactionNameCardinality/Collapsed", Scope: "", Forced: true, Data: []float64{1, 0, 0, 0, 0, 0}},
+		{Name: "Supportability/Go/TransactionNameCardinality/Collapsed/OtherTransaction/Go/job/*", Scope: "", Forced: true, Data: []float64{1, 0, 0, 0, 0, 0}},
+	})
+}
@@ -0,0 +1,62 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package newrelic
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// timingPrefix is the prefix of the transaction event intrinsics which record
+// the timings added using Transaction.AddTiming.
+const timingPrefix = "timing."
+
+var (
+	errTimingNameEmpty   = errors.New("timing name is empty")
+	errTimingNameTooLong = fmt.Errorf("timing name exceeds length limit of %d", attributeKeyLengthLimit-len(timingPrefix))
+	errNegativeTiming    = errors.New("timing duration is negative")
+	errTooManyTxnTimings = fmt.Errorf("too many timings: limit is %d", maxTxnTimings)
+)
+
+// txnTimings holds the named sub-timings of a transaction.  Durations added
+// with the same name are summed.
+type txnTimings map[string]time.Duration
+
+func (ts *txnTimings) add(name string, d time.Duration) error {
+	if name == "" {
+		return errTimingNameEmpty
+	}
+	if len(timingPrefix)+len(name) > attributeKeyLengthLimit {
+		return errTimingNameTooLong
+	}
+	if d < 0 {
+		return errNegativeTiming
+	}
+	if *ts == nil {
+		*ts = make(txnTimings)
+	}
+	if _, ok := (*ts)[name]; !ok && len(*ts) >= maxTxnTimings {
+		return errTooManyTxnTimings
+	}
+	(*ts)[name] += d
+	return nil
+}
+
+// writeIntrinsics writes the timings as intrinsics in seconds, sorted by name
+// so that the output is stable.
+func (ts txnTimings) writeIntrinsics(w *jsonFieldsWriter) {
+	if len(ts) == 0 {
+		return
+	}
+	names := make([]string, 0, len(ts))
+	for name := range ts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		w.floatField(timingPrefix+name, ts[name].Seconds())
+	}
+}
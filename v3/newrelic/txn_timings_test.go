@@ -0,0 +1,73 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package newrelic
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/newrelic/go-agent/v3/internal"
+)
+
+func TestAddTiming(t *testing.T) {
+	app := testApp(nil, ConfigDistributedTracerEnabled(false), t)
+	txn := app.StartTransaction("hello")
+	txn.AddTiming("auth", 250*time.Millisecond)
+	txn.AddTiming("render", time.Second)
+	txn.AddTiming("auth", 250*time.Millisecond)
+	txn.End()
+
+	app.expectNoLoggedErrors(t)
+	app.ExpectTxnEvents(t, []internal.WantEvent{{
+		Intrinsics: map[string]interface{}{
+			"name":          "OtherTransaction/Go/hello",
+			"timing.auth":   0.5,
+			"timing.render": 1.0,
+		},
+		UserAttributes:  map[string]interface{}{},
+		AgentAttributes: map[string]interface{}{},
+	}})
+}
+
+func TestAddTimingTxnEnded(t *testing.T) {
+	app := testApp(nil, ConfigDistributedTracerEnabled(false), t)
+	txn := app.StartTransaction("hello")
+	txn.End()
+	txn.AddTiming("auth", time.Second)
+	app.expectSingleLoggedError(t, "unable to add timing", map[string]interface{}{
+		"reason": errAlreadyEnded.Error(),
+	})
+
+	var nilTxn *Transaction
+	nilTxn.AddTiming("auth", time.Second)
+}
+
+func TestTxnTimingsAdd(t *testing.T) {
+	var ts txnTimings
+	if err := ts.add("", time.Second); err != errTimingNameEmpty {
+		t.Error(err)
+	}
+	if err := ts.add(strings.Repeat("a", attributeKeyLengthLimit), time.Second); err != errTimingNameTooLong {
+		t.Error(err)
+	}
+	if err := ts.add("auth", -time.Second); err != errNegativeTiming {
+		t.Error(err)
+	}
+	for i := 0; i < maxTxnTimings; i++ {
+		if err := ts.add(strings.Repeat("a", i+1), time.Second); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := ts.add("auth", time.Second); err != errTooManyTxnTimings {
+		t.Error(err)
+	}
+	// Existing timings may still be added to once the limit is reached.
+	if err := ts.add("a", time.Second); err != nil {
+		t.Error(err)
+	}
+	if d := ts["a"]; d != 2*time.Second {
+		t.Error(d)
+	}
+}
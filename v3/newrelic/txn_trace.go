@@ -138,6 +138,10 @@ func printNodeStart(buf *bytes.Buffer, n nodeDetails) {
 		w.stringField("transaction_guid", n.TransactionGUID)
 	}
 	for k, v := range n.attributes {
+		if p, ok := v.(*queryPlan); ok && "" == p.get() {
+			// The query plan has not been captured.
+			continue
+		}
 		w.writerField(k, v)
 	}
 	buf.WriteByte('}')
@@ -282,6 +286,10 @@ func (trace *harvestTrace) writeJSON(buf *bytes.Buffer) {
 	buf.WriteByte(',')
 	buf.WriteString(`"intrinsics":`)
 	intrinsicsJSON(&trace.txnEvent, buf, false)
+	if breadcrumbsEnabled(trace.Breadcrumbs, trace.Attrs, destTxnTrace) {
+		buf.WriteString(`,"breadcrumbs":`)
+		trace.Breadcrumbs.WriteJSON(buf)
+	}
 	buf.WriteByte('}')
 
 	// If the trace string pool is used, end another array here.
@@ -427,6 +427,10 @@ func TestTxnTraceAsync(t *testing.T) {
 }
 
 func TestTxnTraceOldCAT(t *testing.T) {
+	if !crossAppTracingIncluded {
+		t.Skip("Cross Application Tracing is excluded from the build")
+	}
+
 	start := time.Date(2014, time.November, 28, 1, 1, 0, 0, time.UTC)
 	txndata := &txnData{}
 	thread := &tracingThread{}
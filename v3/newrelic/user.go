@@ -0,0 +1,33 @@
+// Copyright 2020 New Relic Corporation. All rights reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package newrelic
+
+// userInfo is the user of a transaction set using Transaction.SetUser.
+type userInfo struct {
+	id    string
+	email string
+	name  string
+}
+
+// UserOption sets optional information about the user of a transaction.  Use
+// it with Transaction.SetUser.
+type UserOption func(*userInfo)
+
+// WithUserEmail records the email address of the user in the
+// AttributeUserEmail attribute.  The attribute is disabled by default, and is
+// never recorded when high security mode is enabled.
+func WithUserEmail(email string) UserOption {
+	return func(u *userInfo) {
+		u.email = email
+	}
+}
+
+// WithUserName records the name of the user in the AttributeUserName
+// attribute.  The attribute is disabled by default, and is never recorded
+// when high security mode is enabled.
+func WithUserName(name string) UserOption {
+	return func(u *userInfo) {
+		u.name = name
+	}
+}